@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"cloud.google.com/go/storage"
@@ -39,8 +40,24 @@ type RepoConfig struct {
 	BranchVersioning bool     `yaml:"branch_versioning,omitempty"`
 	HashAllCommits   bool     `yaml:"hash_all_commits,omitempty"`
 	FileExts         []string `yaml:"file_extensions"`
+	// Submodules makes the submodules of each version be checked out and
+	// hashed along with it, for projects that vendor dependencies as
+	// submodules.
+	Submodules bool `yaml:"submodules,omitempty"`
+	// Branches and Commits are indexed in addition to the tags, for projects
+	// that release from long-lived branches or untagged commits.
+	Branches []string `yaml:"branches,omitempty"`
+	Commits  []string `yaml:"commits,omitempty"`
+	// CloneDepth makes the mirror of the repository a shallow clone of the
+	// last CloneDepth commits of each branch and tag, for very large
+	// repositories. Tags and branches are indexed as with a full clone, but
+	// older commits aren't in the mirror, so it can't be combined with
+	// HashAllCommits.
+	CloneDepth int `yaml:"clone_depth,omitempty"`
 }
 
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 // Load loads the repository configurations from the provided bucket.
 func Load(ctx context.Context, cfgBucket *storage.BucketHandle) ([]*RepoConfig, error) {
 	var repos []*RepoConfig
@@ -54,7 +71,7 @@ func Load(ctx context.Context, cfgBucket *storage.BucketHandle) ([]*RepoConfig,
 		if err != nil {
 			return nil, err
 		}
-		if filepath.Ext(attrs.Name) != ".yaml" {
+		if filepath.Ext(attrs.Name) != ".yaml" || attrs.Name == DiscoveryName {
 			continue
 		}
 
@@ -91,5 +108,16 @@ func parseConfig(buf []byte) (*RepoConfig, error) {
 	if err := yaml.Unmarshal(buf, cfg); err != nil {
 		return nil, err
 	}
+	for _, commit := range cfg.Commits {
+		if !commitRegexp.MatchString(commit) {
+			return nil, fmt.Errorf("invalid commit %q in %s: must be a full SHA-1", commit, cfg.Name)
+		}
+	}
+	if cfg.CloneDepth < 0 {
+		return nil, fmt.Errorf("invalid clone_depth %d in %s", cfg.CloneDepth, cfg.Name)
+	}
+	if cfg.CloneDepth > 0 && cfg.HashAllCommits {
+		return nil, fmt.Errorf("clone_depth and hash_all_commits are exclusive in %s", cfg.Name)
+	}
 	return cfg, nil
 }
@@ -16,6 +16,8 @@ Copyright 2022 Google LLC
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -28,6 +30,11 @@ type: "GIT"
 base_cpe: "cpe"
 hash_all_commits: true
 branch_versioning: true
+submodules: true
+branches:
+  - "release-1.x"
+commits:
+  - "d2ba34cb1b4f15bea1c2e5ec2e8d1c0b16db7b11"
 file_extensions:
   - ".c"
   - ".cc"
@@ -41,7 +48,10 @@ func TestParseConfig(t *testing.T) {
 		BaseCPE:          "cpe",
 		HashAllCommits:   true,
 		BranchVersioning: true,
+		Submodules:       true,
 		FileExts:         []string{".c", ".cc"},
+		Branches:         []string{"release-1.x"},
+		Commits:          []string{"d2ba34cb1b4f15bea1c2e5ec2e8d1c0b16db7b11"},
 	}
 
 	got, err := parseConfig([]byte(cfg))
@@ -52,3 +62,91 @@ func TestParseConfig(t *testing.T) {
 		t.Errorf("parseConfig() returned an unexpected diff (-want, +got):\n%s", diff)
 	}
 }
+
+func TestParseConfigCloneDepth(t *testing.T) {
+	got, err := parseConfig([]byte("name: \"abc\"\nclone_depth: 1\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned an unexpected error: %v", err)
+	}
+	if got.CloneDepth != 1 {
+		t.Errorf("parseConfig() clone depth = %d, want 1", got.CloneDepth)
+	}
+	for _, invalid := range []string{
+		"name: \"abc\"\nclone_depth: -1\n",
+		"name: \"abc\"\nclone_depth: 1\nhash_all_commits: true\n",
+	} {
+		if _, err := parseConfig([]byte(invalid)); err == nil {
+			t.Errorf("parseConfig(%q) returned no error", invalid)
+		}
+	}
+}
+
+func TestParseConfigInvalidCommit(t *testing.T) {
+	for _, commit := range []string{"d2ba34c", "v1.0.0", "D2BA34CB1B4F15BEA1C2E5EC2E8D1C0B16DB7B11"} {
+		buf := []byte("name: \"abc\"\ncommits:\n  - \"" + commit + "\"\n")
+		if _, err := parseConfig(buf); err == nil {
+			t.Errorf("parseConfig() with commit %q returned no error", commit)
+		}
+	}
+}
+
+const discoveryCfg = `
+sources:
+  - "gs://osv-vulnerabilities/GIT/all.zip"
+allow:
+  - "^https://github\\.com/"
+deny:
+  - "^https://github\\.com/torvalds/"
+file_extensions:
+  - ".c"
+`
+
+func TestParseDiscovery(t *testing.T) {
+	d, err := parseDiscovery([]byte(discoveryCfg))
+	if err != nil {
+		t.Fatalf("parseDiscovery() returned an unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"gs://osv-vulnerabilities/GIT/all.zip"}, d.Sources); diff != "" {
+		t.Errorf("parseDiscovery() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+	for addr, want := range map[string]bool{
+		"https://github.com/curl/curl":       true,
+		"https://github.com/torvalds/linux":  false,
+		"https://gitlab.com/libtiff/libtiff": false,
+	} {
+		if got := d.Allowed(addr); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	for _, invalid := range []string{
+		"sources: []\n",
+		"allow: [\"(\"]\nfile_extensions: [\".c\"]\n",
+	} {
+		if _, err := parseDiscovery([]byte(invalid)); err == nil {
+			t.Errorf("parseDiscovery(%q) succeeded", invalid)
+		}
+	}
+}
+
+// TestParseConfigTestdata checks the configs under testdata/valid parse and
+// those under testdata/invalid don't. vulnfeeds/cmd/check-config validates
+// configs against the same files, so its rules can't drift from these.
+func TestParseConfigTestdata(t *testing.T) {
+	for _, dir := range []string{"valid", "invalid"} {
+		paths, err := filepath.Glob(filepath.Join("testdata", dir, "*.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parseConfig(buf)
+			if wantErr := dir == "invalid"; (err != nil) != wantErr {
+				t.Errorf("parseConfig(%s) error = %v, wantErr %v", path, err, wantErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"cloud.google.com/go/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// DiscoveryName is the name of the object in the configs bucket holding the
+// discovery configuration. Load doesn't read it as a repository.
+const DiscoveryName = "discovery.yaml"
+
+// Discovery configures the repositories indexed in addition to the
+// configured ones, discovered from the GIT ranges of published OSV records.
+type Discovery struct {
+	// Sources are the gs:// URLs of zips of OSV records to discover
+	// repositories from, e.g. gs://osv-vulnerabilities/GIT/all.zip.
+	Sources []string `yaml:"sources"`
+	// Allow and Deny are regular expressions matched against the
+	// repositories' addresses. A repository is discovered when it matches
+	// any of Allow (or Allow is empty) and none of Deny.
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+	// FileExts are the file extensions indexed for discovered repositories.
+	FileExts []string `yaml:"file_extensions"`
+
+	allow, deny []*regexp.Regexp
+}
+
+// Allowed reports whether the repository at addr may be discovered.
+func (d *Discovery) Allowed(addr string) bool {
+	for _, re := range d.deny {
+		if re.MatchString(addr) {
+			return false
+		}
+	}
+	if len(d.allow) == 0 {
+		return true
+	}
+	for _, re := range d.allow {
+		if re.MatchString(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDiscovery loads the discovery configuration from the provided bucket.
+// It returns nil if the bucket doesn't have one.
+func LoadDiscovery(ctx context.Context, cfgBucket *storage.BucketHandle) (*Discovery, error) {
+	r, err := cfgBucket.Object(DiscoveryName).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive object %s: %v", DiscoveryName, err)
+	}
+	defer r.Close()
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %v", DiscoveryName, err)
+	}
+	return parseDiscovery(buf)
+}
+
+func parseDiscovery(buf []byte) (*Discovery, error) {
+	d := &Discovery{}
+	if err := yaml.Unmarshal(buf, d); err != nil {
+		return nil, err
+	}
+	if len(d.FileExts) == 0 {
+		return nil, fmt.Errorf("invalid %s: no file extensions", DiscoveryName)
+	}
+	for _, pattern := range d.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %v", pattern, err)
+		}
+		d.allow = append(d.allow, re)
+	}
+	for _, pattern := range d.Deny {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %v", pattern, err)
+		}
+		d.deny = append(d.deny, re)
+	}
+	return d, nil
+}
@@ -19,10 +19,14 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"github.com/google/osv.dev/gcp/indexer/config"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+	"github.com/google/osv.dev/gcp/indexer/shared"
+	"github.com/google/osv.dev/gcp/indexer/stages/export"
 	"github.com/google/osv.dev/gcp/indexer/stages/preparation"
 	"github.com/google/osv.dev/gcp/indexer/stages/processing"
 
@@ -38,6 +42,15 @@ var (
 	pubsubTopic   = flag.String("topic", "", "sets the pubsub topic to publish to or to read from")
 	subName       = flag.String("subscription", "", "sets the pubsub subscription name for workers")
 	subMessages   = flag.Int("messages", 1, "pubsub outstanding messages")
+	manifestsBkt  = flag.String("manifests", "", "bucket to publish the manifest of indexed versions of each repository to")
+	snapshotsBkt  = flag.String("snapshots", "", "bucket to publish snapshots of the index to, for querying it offline")
+	snapshotShard = flag.Int("snapshot_shard_size", export.DefaultShardSize, "number of documents per snapshot shard")
+	snapshotDelta = flag.Int("snapshot_max_deltas", 0, "number of snapshots exported as deltas of the previous one after each full snapshot, 0 to only export full snapshots")
+	pendingLease  = flag.Duration("pending_lease", idxStorage.DefaultPendingLease, "how long versions published for processing are taken to be in progress, before being published again")
+	legacyPages   = flag.Bool("legacy_pages", false, "store the hashes of pages unpacked, for readers of the index that don't read packed hashes yet")
+	diskBudget    = flag.Int64("disk_budget", 0, "maximum total bytes of repository checkouts a worker processes concurrently, 0 for no limit")
+	metricsExp    = flag.String("metrics_exporter", metrics.ExporterNone, "where to export the stages' metrics to: \"cloud_monitoring\", or empty for nowhere")
+	metricsPeriod = flag.Duration("metrics_interval", time.Minute, "how often to export the stages' metrics")
 )
 
 func main() {
@@ -45,6 +58,14 @@ func main() {
 
 	ctx := context.Background()
 
+	shutdownMetrics, err := metrics.Setup(ctx, *metricsExp, *projectID, *metricsPeriod)
+	if err != nil {
+		log.Exitf("failed to set up metrics: %v", err)
+	}
+	// Flush the last metrics. log.Exitf doesn't run deferred calls, so a
+	// failed run flushes them itself.
+	defer metrics.Shutdown(context.Background(), shutdownMetrics)
+
 	psCl, err := pubsub.NewClient(ctx, *projectID)
 	if err != nil {
 		log.Exitf("failed to initialize pubsub client: %v", err)
@@ -64,25 +85,39 @@ func main() {
 		log.Exitf("failed to create the indexers' storer: %v", err)
 	}
 	defer storer.Close()
+	storer.PendingLease = *pendingLease
+	storer.LegacyPages = *legacyPages
 
 	if *worker {
-		if err := runWorker(ctx, storer, repoBucketHdl, psCl.Subscription(*subName), *subMessages); err != nil {
+		if err := runWorker(ctx, storer, repoBucketHdl, psCl.Subscription(*subName), *subMessages, *diskBudget); err != nil {
+			metrics.Shutdown(context.Background(), shutdownMetrics)
 			log.Exitf("failed to run worker: %v", err)
 		}
 		return
 	}
 
-	if err := runController(ctx, storer, repoBucketHdl, gcsClient.Bucket(*configsBucket), psCl); err != nil {
+	var manifestBucketHdl *storage.BucketHandle
+	if *manifestsBkt != "" {
+		manifestBucketHdl = gcsClient.Bucket(*manifestsBkt)
+	}
+	var snapshotBucketHdl *storage.BucketHandle
+	if *snapshotsBkt != "" {
+		snapshotBucketHdl = gcsClient.Bucket(*snapshotsBkt)
+	}
+	if err := runController(ctx, storer, gcsClient, repoBucketHdl, gcsClient.Bucket(*configsBucket), manifestBucketHdl, snapshotBucketHdl, psCl); err != nil {
+		metrics.Shutdown(context.Background(), shutdownMetrics)
 		log.Exitf("failed to run controller: %v", err)
 	}
 }
 
-func runWorker(ctx context.Context, storer *idxStorage.Store, repoBucketHdl *storage.BucketHandle, sub *pubsub.Subscription, outstanding int) error {
+func runWorker(ctx context.Context, storer *idxStorage.Store, repoBucketHdl *storage.BucketHandle, sub *pubsub.Subscription, outstanding int, diskBudget int64) error {
 	procStage := processing.Stage{
 		Storer:                    storer,
 		RepoHdl:                   repoBucketHdl,
 		Input:                     sub,
 		PubSubOutstandingMessages: outstanding,
+		DiskBudget:                diskBudget,
+		Journal:                   storer,
 	}
 	// The preparation results are picked up by the processing stage
 	// in worker mode.
@@ -92,11 +127,26 @@ func runWorker(ctx context.Context, storer *idxStorage.Store, repoBucketHdl *sto
 	return procStage.Run(ctx)
 }
 
-func runController(ctx context.Context, storer *idxStorage.Store, repoBucketHdl, cfgBucketHdl *storage.BucketHandle, psCl *pubsub.Client) error {
+func runController(ctx context.Context, storer *idxStorage.Store, gcsClient *storage.Client, repoBucketHdl, cfgBucketHdl, manifestBucketHdl, snapshotBucketHdl *storage.BucketHandle, psCl *pubsub.Client) error {
 	cfgs, err := config.Load(ctx, cfgBucketHdl)
 	if err != nil {
 		return fmt.Errorf("failed to load configurations: %v", err)
 	}
+	discovery, err := config.LoadDiscovery(ctx, cfgBucketHdl)
+	if err != nil {
+		return fmt.Errorf("failed to load discovery configuration: %v", err)
+	}
+	if discovery != nil {
+		// Repositories referenced by published OSV records are indexed
+		// alongside the configured ones. A failed discovery only leaves
+		// them out of this run.
+		discovered, err := preparation.Discover(ctx, gcsClient, discovery, cfgs)
+		if err != nil {
+			log.Errorf("failed to discover repositories: %v", err)
+		}
+		log.Infof("discovered %d repositories in addition to the %d configured", len(discovered), len(cfgs))
+		cfgs = append(cfgs, discovered...)
+	}
 
 	topic := psCl.Topic(*pubsubTopic)
 	defer topic.Stop()
@@ -105,8 +155,46 @@ func runController(ctx context.Context, storer *idxStorage.Store, repoBucketHdl,
 		Checker: storer,
 		RepoHdl: repoBucketHdl,
 		Output:  topic,
+		Journal: storer,
 	}
 	// The pipline starts by cloning and/or updating the configured
 	// repositories. The results are returned on the procChan channel.
-	return prepStage.Run(ctx, cfgs)
+	if err := prepStage.Run(ctx, cfgs); err != nil {
+		return err
+	}
+	if manifestBucketHdl != nil {
+		writeManifests(ctx, storer, manifestBucketHdl, cfgs)
+	}
+	if snapshotBucketHdl != nil {
+		// Like the manifests, the snapshot holds the versions indexed as of
+		// the end of the previous run.
+		exportStage := &export.Stage{
+			Source:          storer,
+			Output:          &export.GCSOutput{Bucket: snapshotBucketHdl, Prefix: "snapshots/"},
+			HashType:        shared.MD5,
+			DocumentVersion: shared.LatestDocumentVersion,
+			ShardSize:       *snapshotShard,
+			MaxDeltas:       *snapshotDelta,
+		}
+		index, err := exportStage.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to export snapshot: %v", err)
+		}
+		log.Infof("exported snapshot with %d shards, %d deltas since the last full one", len(index.Shards), index.Depth)
+	}
+	return nil
+}
+
+// writeManifests publishes the versions indexed for each repository. The
+// workers process the versions published by this run asynchronously, so
+// these are the versions indexed as of the end of the previous run.
+func writeManifests(ctx context.Context, storer *idxStorage.Store, bucketHdl *storage.BucketHandle, cfgs []*config.RepoConfig) {
+	for _, repoCfg := range cfgs {
+		m, err := storer.ExportManifest(ctx, bucketHdl, repoCfg.Name, repoCfg.Address, shared.MD5)
+		if err != nil {
+			log.Errorf("failed to export manifest for %s: %v", repoCfg.Name, err)
+			continue
+		}
+		log.Infof("wrote manifest for %s with %d versions", repoCfg.Name, len(m.Versions))
+	}
 }
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package manifest provides functionality to publish the versions indexed for each repository.
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+)
+
+const objectFmt = "manifests/%s.json"
+
+// Version is a single indexed version of a repository.
+type Version struct {
+	Tag       string    `json:"tag"`
+	RefType   string    `json:"ref_type,omitempty"`
+	Commit    string    `json:"commit"`
+	FileCount int       `json:"file_count"`
+	When      time.Time `json:"when"`
+	// Key is the name of the version's RepoIndex document, the parent of its
+	// bucket and page entities.
+	Key string `json:"key"`
+	// PageBitmap is the hex encoded bitmap of the buckets with pages (as
+	// RepoIndex.empty_bucket_bitmap, 1 meaning not empty).
+	PageBitmap string `json:"page_bitmap"`
+}
+
+// Manifest lists the indexed versions of a repository.
+type Manifest struct {
+	Name     string    `json:"name"`
+	RepoAddr string    `json:"repo_addr"`
+	BaseCPE  string    `json:"base_cpe,omitempty"`
+	Versions []Version `json:"versions"`
+}
+
+// Sort orders the versions by tag, then commit, so manifests only change
+// when the indexed versions do.
+func (m *Manifest) Sort() {
+	sort.Slice(m.Versions, func(i, j int) bool {
+		if m.Versions[i].Tag != m.Versions[j].Tag {
+			return m.Versions[i].Tag < m.Versions[j].Tag
+		}
+		return m.Versions[i].Commit < m.Versions[j].Commit
+	})
+}
+
+// Encode writes the manifest as compact JSON, so the manifest of even the
+// largest repositories is a single small object.
+func (m *Manifest) Encode(w io.Writer) error {
+	m.Sort()
+	if m.Versions == nil {
+		m.Versions = []Version{}
+	}
+	return json.NewEncoder(w).Encode(m)
+}
+
+// ObjectName returns the name of the manifest object of a repository.
+func ObjectName(name string) string {
+	return fmt.Sprintf(objectFmt, name)
+}
+
+// Write writes the manifest of a repository to the bucket.
+func Write(ctx context.Context, bucketHdl *storage.BucketHandle, m *Manifest) error {
+	objW := bucketHdl.Object(ObjectName(m.Name)).NewWriter(ctx)
+	objW.ContentType = "application/json"
+	if err := m.Encode(objW); err != nil {
+		objW.Close()
+		return fmt.Errorf("failed to encode manifest for %s: %v", m.Name, err)
+	}
+	err := objW.Close()
+	metrics.GCS(ctx, "write", err)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest for %s: %v", m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncode(t *testing.T) {
+	m := &Manifest{
+		Name:     "abc",
+		RepoAddr: "https://example.com/abc.git",
+		Versions: []Version{
+			{Tag: "refs/tags/v1.1", RefType: "tag", Commit: "bb", FileCount: 2, Key: "k2", PageBitmap: "03"},
+			{Tag: "refs/tags/v1.0", RefType: "tag", Commit: "aa", FileCount: 1, Key: "k1", PageBitmap: "01"},
+		},
+	}
+	want := `{"name":"abc","repo_addr":"https://example.com/abc.git","versions":[` +
+		`{"tag":"refs/tags/v1.0","ref_type":"tag","commit":"aa","file_count":1,"when":"0001-01-01T00:00:00Z","key":"k1","page_bitmap":"01"},` +
+		`{"tag":"refs/tags/v1.1","ref_type":"tag","commit":"bb","file_count":2,"when":"0001-01-01T00:00:00Z","key":"k2","page_bitmap":"03"}]}` + "\n"
+	var buf bytes.Buffer
+	if err := m.Encode(&buf); err != nil {
+		t.Fatalf("Encode() returned an unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Encode() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&Manifest{Name: "abc"}).Encode(&buf); err != nil {
+		t.Fatalf("Encode() returned an unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"versions":[]`)) {
+		t.Errorf("Encode() = %s, want an empty versions list", buf.String())
+	}
+}
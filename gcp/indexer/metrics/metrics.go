@@ -0,0 +1,156 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package metrics instruments the indexer stages with OpenTelemetry metrics.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mexporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	log "github.com/golang/glog"
+)
+
+// The stages of the indexer.
+const (
+	Preparation = "preparation"
+	Processing  = "processing"
+	Storage     = "storage"
+	Export      = "export"
+)
+
+// The exporters metrics can be exported with.
+const (
+	ExporterNone            = ""
+	ExporterCloudMonitoring = "cloud_monitoring"
+)
+
+const meterName = "github.com/google/osv.dev/gcp/indexer"
+
+// The instruments are created with the global meter provider, which
+// forwards them to the provider Setup installs, so they record nothing
+// until it's called.
+var (
+	meter = otel.Meter(meterName)
+
+	// items counts the repositories (preparation) and versions (processing
+	// and storage) each stage handled, by outcome.
+	items = must(meter.Int64Counter("indexer.stage.items",
+		metric.WithDescription("Items handled by each stage, by outcome")))
+	// queueDepth is the items each stage has received but not finished,
+	// including those waiting for a worker or for disk space.
+	queueDepth = must(meter.Int64UpDownCounter("indexer.stage.queue_depth",
+		metric.WithDescription("Items received by each stage but not yet finished")))
+	// latency is the time each stage took per repository.
+	latency = must(meter.Float64Histogram("indexer.stage.latency",
+		metric.WithDescription("Time each stage took per repository"),
+		metric.WithUnit("s")))
+	// stageErrors counts the errors of each stage, by the operation that failed.
+	stageErrors = must(meter.Int64Counter("indexer.stage.errors",
+		metric.WithDescription("Errors of each stage, by the operation that failed")))
+	// gcsOps and datastoreOps count the calls made to GCS and Datastore.
+	gcsOps = must(meter.Int64Counter("indexer.gcs.operations",
+		metric.WithDescription("GCS operations, by operation and outcome")))
+	datastoreOps = must(meter.Int64Counter("indexer.datastore.operations",
+		metric.WithDescription("Datastore operations, by operation and outcome")))
+)
+
+func must[T any](instrument T, err error) T {
+	if err != nil {
+		panic(fmt.Sprintf("failed to create instrument: %v", err))
+	}
+	return instrument
+}
+
+// Setup installs the meter provider exporting to exporter every interval,
+// returning a function that flushes and shuts it down. With ExporterNone,
+// metrics aren't recorded.
+func Setup(ctx context.Context, exporter, projectID string, interval time.Duration) (func(context.Context) error, error) {
+	var exp sdkmetric.Exporter
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterCloudMonitoring:
+		var err error
+		exp, err = mexporter.New(mexporter.WithProjectID(projectID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cloud Monitoring exporter: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter: %q", exporter)
+	}
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(interval))),
+	)
+	otel.SetMeterProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// outcome returns the outcome attribute of an operation that returned err.
+func outcome(err error) attribute.KeyValue {
+	if err != nil {
+		return attribute.String("outcome", "error")
+	}
+	return attribute.String("outcome", "ok")
+}
+
+// Begin records that stage received an item of repo, returning the function
+// to call with its result once it's finished. Items finished with skip set
+// are counted as skipped, e.g. versions that were already indexed.
+func Begin(ctx context.Context, stage, repo string) func(skip bool, err error) {
+	stageAttr := attribute.String("stage", stage)
+	queueDepth.Add(ctx, 1, metric.WithAttributes(stageAttr))
+	start := time.Now()
+	return func(skip bool, err error) {
+		queueDepth.Add(ctx, -1, metric.WithAttributes(stageAttr))
+		result := outcome(err)
+		if skip && err == nil {
+			result = attribute.String("outcome", "skipped")
+		}
+		items.Add(ctx, 1, metric.WithAttributes(stageAttr, result))
+		latency.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(stageAttr, attribute.String("repo", repo)))
+	}
+}
+
+// Error records an error of stage in op.
+func Error(ctx context.Context, stage, op string) {
+	stageErrors.Add(ctx, 1, metric.WithAttributes(attribute.String("stage", stage), attribute.String("op", op)))
+}
+
+// GCS records a GCS operation, e.g. "read" or "write", that returned err.
+func GCS(ctx context.Context, op string, err error) {
+	gcsOps.Add(ctx, 1, metric.WithAttributes(attribute.String("op", op), outcome(err)))
+}
+
+// Datastore records a Datastore operation, e.g. "get" or "put_multi", that
+// returned err.
+func Datastore(ctx context.Context, op string, err error) {
+	datastoreOps.Add(ctx, 1, metric.WithAttributes(attribute.String("op", op), outcome(err)))
+}
+
+// Shutdown flushes the metrics with shutdown, as returned by Setup, logging
+// any error.
+func Shutdown(ctx context.Context, shutdown func(context.Context) error) {
+	if err := shutdown(ctx); err != nil {
+		log.Errorf("failed to flush metrics: %v", err)
+	}
+}
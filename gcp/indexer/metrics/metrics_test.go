@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// sums returns the values of a sum metric by the value of the given
+// attribute keys, joined with "/".
+func sums(rm metricdata.ResourceMetrics, name string, keys ...attribute.Key) map[string]int64 {
+	got := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				label := ""
+				for i, key := range keys {
+					if i > 0 {
+						label += "/"
+					}
+					v, _ := dp.Attributes.Value(key)
+					label += v.AsString()
+				}
+				got[label] += dp.Value
+			}
+		}
+	}
+	return got
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	ctx := context.Background()
+
+	first := Begin(ctx, Processing, "repo")
+	second := Begin(ctx, Processing, "repo")
+	third := Begin(ctx, Storage, "repo")
+	first(false, nil)
+	third(true, nil)
+	Error(ctx, Processing, "checkout")
+	GCS(ctx, "read", nil)
+	GCS(ctx, "read", errors.New("failed"))
+	Datastore(ctx, "put", nil)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		keys []attribute.Key
+		want map[string]int64
+	}{
+		{"indexer.stage.items", []attribute.Key{"stage", "outcome"}, map[string]int64{"processing/ok": 1, "storage/skipped": 1}},
+		{"indexer.stage.queue_depth", []attribute.Key{"stage"}, map[string]int64{"processing": 1, "storage": 0}},
+		{"indexer.stage.errors", []attribute.Key{"stage", "op"}, map[string]int64{"processing/checkout": 1}},
+		{"indexer.gcs.operations", []attribute.Key{"op", "outcome"}, map[string]int64{"read/ok": 1, "read/error": 1}},
+		{"indexer.datastore.operations", []attribute.Key{"op", "outcome"}, map[string]int64{"put/ok": 1}},
+	} {
+		if diff := cmp.Diff(tc.want, sums(rm, tc.name, tc.keys...)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", tc.name, diff)
+		}
+	}
+	second(false, errors.New("failed"))
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "prometheus", "", 0); err == nil {
+		t.Errorf("Setup() with an unknown exporter succeeded")
+	}
+}
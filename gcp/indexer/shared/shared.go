@@ -24,14 +24,19 @@ import (
 	"path/filepath"
 
 	"cloud.google.com/go/storage"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
 )
 
 const (
 	TarExt = ".tar"
 	Git    = "GIT"
 	MD5    = "MD5"
+	// The kinds of reference a repository is indexed at.
+	RefTag    = "tag"
+	RefBranch = "branch"
+	RefCommit = "commit"
 	// Update this to force reindexing and updating of all entries with lesser version number
-	LatestDocumentVersion = 2
+	LatestDocumentVersion = 4
 )
 
 // CopyFromBucket copies a directory from a bucket to a temporary location.
@@ -42,9 +47,11 @@ func CopyFromBucket(ctx context.Context, bucketHdl *storage.BucketHandle, name s
 	}
 	obj := bucketHdl.Object(name + TarExt)
 	r, err := obj.NewReader(ctx)
+	metrics.GCS(ctx, "read", err)
 	if err != nil {
 		return "", err
 	}
+	defer r.Close()
 	tarRdr := tar.NewReader(r)
 	for {
 		hdr, err := tarRdr.Next()
@@ -0,0 +1,384 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package export provides functionality to publish snapshots of the index,
+// so it can be queried offline and mirrored. Snapshots are either full, or
+// deltas holding only the documents changed since the snapshot they're based
+// on.
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+
+	log "github.com/golang/glog"
+)
+
+// The snapshot format is documented in tools/indexer-query, which reads it.
+// The types here need to be kept in sync with it.
+const (
+	// FormatVersion is the version of the snapshot format. Version 2 added
+	// deltas.
+	FormatVersion = 2
+	// IndexName is the name of a snapshot's index, which lists its shards.
+	IndexName = "index.json"
+	// LatestName is the name of the object naming the latest snapshot.
+	LatestName = "LATEST"
+	// DigestsName is the name of a snapshot's digests, the SHA-256 of each
+	// document of the index as of the snapshot, which the next delta is
+	// computed against.
+	DigestsName = "digests.json.gz"
+
+	shardNameFmt = "documents-%05d.jsonl.gz"
+	// DefaultShardSize is the number of documents per shard.
+	DefaultShardSize = 1000
+	snapshotTimeFmt  = "20060102T150405Z"
+)
+
+// Index lists the shards of a snapshot.
+type Index struct {
+	FormatVersion   int     `json:"format_version"`
+	Created         string  `json:"created"`
+	DocumentVersion int     `json:"document_version"`
+	Shards          []Shard `json:"shards"`
+	// Base is the directory of the snapshot a delta is applied on top of,
+	// relative to the snapshots' directory. Empty for full snapshots.
+	Base string `json:"base,omitempty"`
+	// Depth is the number of deltas since the last full snapshot.
+	Depth int `json:"depth,omitempty"`
+	// Removed are the keys of the documents removed since Base.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Shard is a gzipped file of Documents, one JSON object per line.
+type Shard struct {
+	Name      string `json:"name"`
+	Documents int    `json:"documents"`
+	SHA256    string `json:"sha256"`
+}
+
+// Document is an indexed version with its non-empty buckets.
+type Document struct {
+	Key               string   `json:"key"`
+	Name              string   `json:"name"`
+	RepoAddr          string   `json:"repo_addr"`
+	Tag               string   `json:"tag"`
+	Commit            string   `json:"commit"`
+	FileCount         int      `json:"file_count"`
+	EmptyBucketBitmap []byte   `json:"empty_bucket_bitmap"`
+	Buckets           []Bucket `json:"buckets"`
+}
+
+// Bucket is a bucket of a Document, with the file hashes of its page.
+type Bucket struct {
+	// Index is the index of the bucket, or -1 for buckets stored without a
+	// page.
+	Index      int      `json:"index"`
+	NodeHash   string   `json:"node_hash"`
+	Files      int      `json:"files"`
+	FileHashes []string `json:"file_hashes,omitempty"`
+}
+
+// Source enumerates the documents of the index.
+type Source interface {
+	ExportDocuments(ctx context.Context, hashType string, fn func(*Document) error) error
+}
+
+// Output is where a snapshot's objects are written to, and the previous
+// snapshots are read from.
+type Output interface {
+	Create(ctx context.Context, name string) io.WriteCloser
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// GCSOutput is an Output under a prefix of a GCS bucket.
+type GCSOutput struct {
+	Bucket *storage.BucketHandle
+	Prefix string
+}
+
+func (o *GCSOutput) Create(ctx context.Context, name string) io.WriteCloser {
+	return o.Bucket.Object(o.Prefix + name).NewWriter(ctx)
+}
+
+func (o *GCSOutput) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	r, err := o.Bucket.Object(o.Prefix + name).NewReader(ctx)
+	metrics.GCS(ctx, "read", err)
+	return r, err
+}
+
+// Stage holds the data types necessary to export a snapshot.
+type Stage struct {
+	Source   Source
+	Output   Output
+	HashType string
+	// DocumentVersion is the document version of the exported documents.
+	DocumentVersion int
+	// ShardSize is the number of documents per shard, DefaultShardSize if 0.
+	ShardSize int
+	// MaxDeltas is the number of deltas exported after each full snapshot,
+	// 0 to only export full snapshots.
+	MaxDeltas int
+
+	// now returns the time of the snapshot, time.Now if nil.
+	now func() time.Time
+}
+
+// base is the snapshot a delta is based on.
+type base struct {
+	dir     string
+	index   Index
+	digests map[string]string
+}
+
+// loadBase returns the latest snapshot, if the next snapshot can be a delta
+// of it. A delta isn't exported when the latest snapshot can't be read, was
+// exported in another format or document version, or is the last delta
+// before a full snapshot is due.
+func (s *Stage) loadBase(ctx context.Context) (*base, error) {
+	if s.MaxDeltas <= 0 {
+		return nil, nil
+	}
+	latest, err := readObject(ctx, s.Output, LatestName)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := &base{dir: strings.TrimSpace(string(latest))}
+	buf, err := readObject(ctx, s.Output, b.dir+IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &b.index); err != nil {
+		return nil, err
+	}
+	if b.index.FormatVersion != FormatVersion || b.index.DocumentVersion != s.DocumentVersion || b.index.Depth >= s.MaxDeltas {
+		return nil, nil
+	}
+	buf, err = readObject(ctx, s.Output, b.dir+DigestsName)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(gz).Decode(&b.digests); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Run exports a snapshot under a directory named after the time it was
+// created, then points LatestName at it. The snapshot is a delta of the
+// latest one if MaxDeltas allows, or full otherwise. The index is written
+// after the shards and digests, and LatestName after the index, so readers
+// never see a partial snapshot.
+func (s *Stage) Run(ctx context.Context) (*Index, error) {
+	now := time.Now().UTC()
+	if s.now != nil {
+		now = s.now().UTC()
+	}
+	dir := now.Format(snapshotTimeFmt) + "/"
+	index := &Index{
+		FormatVersion:   FormatVersion,
+		Created:         now.Format(time.RFC3339),
+		DocumentVersion: s.DocumentVersion,
+		Shards:          []Shard{},
+	}
+	b, err := s.loadBase(ctx)
+	if err != nil {
+		// The next full snapshot is a new base.
+		log.Warningf("failed to load the latest snapshot, exporting a full one: %v", err)
+		metrics.Error(ctx, metrics.Export, "load_base")
+		b = nil
+	}
+	if b != nil {
+		index.Base = b.dir
+		index.Depth = b.index.Depth + 1
+	}
+	digests := make(map[string]string)
+	shardSize := s.ShardSize
+	if shardSize == 0 {
+		shardSize = DefaultShardSize
+	}
+
+	var w *shardWriter
+	flush := func() error {
+		if w == nil {
+			return nil
+		}
+		shard, err := w.close(ctx)
+		w = nil
+		if err != nil {
+			metrics.Error(ctx, metrics.Export, "write_shard")
+			return err
+		}
+		index.Shards = append(index.Shards, shard)
+		log.Infof("wrote snapshot shard %s with %d documents", shard.Name, shard.Documents)
+		return nil
+	}
+	err = s.Source.ExportDocuments(ctx, s.HashType, func(doc *Document) error {
+		digest, err := documentDigest(doc)
+		if err != nil {
+			return err
+		}
+		digests[doc.Key] = digest
+		if b != nil && b.digests[doc.Key] == digest {
+			return nil
+		}
+		if w == nil {
+			name := fmt.Sprintf(shardNameFmt, len(index.Shards))
+			w = newShardWriter(name, s.Output.Create(ctx, dir+name))
+		}
+		if err := w.write(doc); err != nil {
+			return err
+		}
+		if w.shard.Documents >= shardSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	} else if w != nil {
+		w.obj.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export documents: %v", err)
+	}
+	if b != nil {
+		for key := range b.digests {
+			if _, ok := digests[key]; !ok {
+				index.Removed = append(index.Removed, key)
+			}
+		}
+		sort.Strings(index.Removed)
+	}
+
+	if err := writeObject(ctx, s.Output, dir+DigestsName, func(w io.Writer) error {
+		gz := gzip.NewWriter(w)
+		if err := json.NewEncoder(gz).Encode(digests); err != nil {
+			return err
+		}
+		return gz.Close()
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot digests: %v", err)
+	}
+
+	if err := writeObject(ctx, s.Output, dir+IndexName, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(index)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot index: %v", err)
+	}
+	if err := writeObject(ctx, s.Output, LatestName, func(w io.Writer) error {
+		_, err := io.WriteString(w, dir)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", LatestName, err)
+	}
+	return index, nil
+}
+
+// writeObject writes an object with the output of encode.
+func writeObject(ctx context.Context, out Output, name string, encode func(io.Writer) error) error {
+	obj := out.Create(ctx, name)
+	if err := encode(obj); err != nil {
+		obj.Close()
+		return err
+	}
+	err := obj.Close()
+	metrics.GCS(ctx, "write", err)
+	return err
+}
+
+// readObject reads the whole of an object.
+func readObject(ctx context.Context, out Output, name string) ([]byte, error) {
+	r, err := out.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// documentDigest returns the hex encoded SHA-256 of doc's JSON encoding.
+func documentDigest(doc *Document) (string, error) {
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// shardWriter writes documents to a shard, gzipped and checksummed.
+type shardWriter struct {
+	shard  Shard
+	obj    io.WriteCloser
+	hasher hash.Hash
+	gz     *gzip.Writer
+	enc    *json.Encoder
+}
+
+func newShardWriter(name string, obj io.WriteCloser) *shardWriter {
+	hasher := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(obj, hasher))
+	return &shardWriter{
+		shard:  Shard{Name: name},
+		obj:    obj,
+		hasher: hasher,
+		gz:     gz,
+		enc:    json.NewEncoder(gz),
+	}
+}
+
+func (w *shardWriter) write(doc *Document) error {
+	if err := w.enc.Encode(doc); err != nil {
+		return err
+	}
+	w.shard.Documents++
+	return nil
+}
+
+func (w *shardWriter) close(ctx context.Context) (Shard, error) {
+	if err := w.gz.Close(); err != nil {
+		w.obj.Close()
+		return Shard{}, err
+	}
+	err := w.obj.Close()
+	metrics.GCS(ctx, "write", err)
+	if err != nil {
+		return Shard{}, err
+	}
+	w.shard.SHA256 = hex.EncodeToString(w.hasher.Sum(nil))
+	return w.shard, nil
+}
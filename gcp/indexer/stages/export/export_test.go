@@ -0,0 +1,235 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+type memObject struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (o *memObject) Close() error {
+	o.closed = true
+	return nil
+}
+
+type memOutput map[string]*memObject
+
+func (o memOutput) Create(_ context.Context, name string) io.WriteCloser {
+	obj := &memObject{}
+	o[name] = obj
+	return obj
+}
+
+func (o memOutput) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	obj, ok := o[name]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(obj.Bytes())), nil
+}
+
+// readShards returns the documents of the shards of a snapshot.
+func readShards(t *testing.T, out memOutput, dir string, index *Index) []*Document {
+	t.Helper()
+	var docs []*Document
+	for _, shard := range index.Shards {
+		gz, err := gzip.NewReader(bytes.NewReader(out[dir+shard.Name].Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(gz)
+		for dec.More() {
+			doc := &Document{}
+			if err := dec.Decode(doc); err != nil {
+				t.Fatal(err)
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+type fakeSource []*Document
+
+func (s fakeSource) ExportDocuments(_ context.Context, _ string, fn func(*Document) error) error {
+	for _, doc := range s {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	var docs fakeSource
+	for i := range 5 {
+		docs = append(docs, &Document{
+			Key:     fmt.Sprintf("doc-%d", i),
+			Tag:     fmt.Sprintf("v%d", i),
+			Buckets: []Bucket{{Index: i, NodeHash: "abcd", Files: 1, FileHashes: []string{"ef01"}}},
+		})
+	}
+	out := memOutput{}
+	s := &Stage{Source: docs, Output: out, HashType: "MD5", DocumentVersion: 4, ShardSize: 2}
+	index, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, ok := out[LatestName]
+	if !ok {
+		t.Fatalf("%s wasn't written", LatestName)
+	}
+	dir := latest.String()
+	if !strings.HasSuffix(dir, "/") {
+		t.Errorf("%s = %q, want a directory", LatestName, dir)
+	}
+	var gotIndex Index
+	if err := json.Unmarshal(out[dir+IndexName].Bytes(), &gotIndex); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(*index, gotIndex); diff != "" {
+		t.Errorf("index mismatch (-returned +written):\n%s", diff)
+	}
+	if gotIndex.FormatVersion != FormatVersion || gotIndex.DocumentVersion != 4 {
+		t.Errorf("index = %+v, want format version %d and document version 4", gotIndex, FormatVersion)
+	}
+
+	var got []*Document
+	var counts []int
+	for _, shard := range gotIndex.Shards {
+		obj := out[dir+shard.Name]
+		if !obj.closed {
+			t.Errorf("shard %s wasn't closed", shard.Name)
+		}
+		if sum := sha256.Sum256(obj.Bytes()); hex.EncodeToString(sum[:]) != shard.SHA256 {
+			t.Errorf("shard %s checksum = %x, index has %s", shard.Name, sum, shard.SHA256)
+		}
+		gz, err := gzip.NewReader(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(gz)
+		n := 0
+		for dec.More() {
+			doc := &Document{}
+			if err := dec.Decode(doc); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, doc)
+			n++
+		}
+		if n != shard.Documents {
+			t.Errorf("shard %s has %d documents, index has %d", shard.Name, n, shard.Documents)
+		}
+		counts = append(counts, n)
+	}
+	if diff := cmp.Diff([]int{2, 2, 1}, counts); diff != "" {
+		t.Errorf("shard sizes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]*Document(docs), got); diff != "" {
+		t.Errorf("documents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunDeltas(t *testing.T) {
+	docs := fakeSource{
+		{Key: "doc-0", Tag: "v0", Buckets: []Bucket{}},
+		{Key: "doc-1", Tag: "v1", Buckets: []Bucket{}},
+		{Key: "doc-2", Tag: "v2", Buckets: []Bucket{}},
+	}
+	out := memOutput{}
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Stage{Source: docs, Output: out, HashType: "MD5", DocumentVersion: 4, MaxDeltas: 1}
+	s.now = func() time.Time { return clock }
+	run := func() (string, *Index) {
+		t.Helper()
+		index, err := s.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock = clock.Add(time.Hour)
+		return out[LatestName].String(), index
+	}
+
+	fullDir, full := run()
+	if full.Base != "" || full.Depth != 0 || len(readShards(t, out, fullDir, full)) != 3 {
+		t.Fatalf("first snapshot = %+v, want a full one", full)
+	}
+
+	// doc-1 changes, doc-2 is removed and doc-3 is added.
+	s.Source = fakeSource{
+		docs[0],
+		{Key: "doc-1", Tag: "v1.1", Buckets: []Bucket{}},
+		{Key: "doc-3", Tag: "v3", Buckets: []Bucket{}},
+	}
+	deltaDir, delta := run()
+	if delta.Base != fullDir || delta.Depth != 1 {
+		t.Errorf("second snapshot base = %q and depth = %d, want a delta of %q", delta.Base, delta.Depth, fullDir)
+	}
+	var keys []string
+	for _, doc := range readShards(t, out, deltaDir, delta) {
+		keys = append(keys, doc.Key+"@"+doc.Tag)
+	}
+	if diff := cmp.Diff([]string{"doc-1@v1.1", "doc-3@v3"}, keys); diff != "" {
+		t.Errorf("delta documents mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"doc-2"}, delta.Removed); diff != "" {
+		t.Errorf("delta removed mismatch (-want +got):\n%s", diff)
+	}
+
+	// After MaxDeltas deltas, the next snapshot is full again.
+	_, next := run()
+	if next.Base != "" || next.Depth != 0 || next.Removed != nil {
+		t.Errorf("third snapshot = %+v, want a full one", next)
+	}
+}
+
+func TestRunEmpty(t *testing.T) {
+	out := memOutput{}
+	index, err := (&Stage{Source: fakeSource{}, Output: out, HashType: "MD5"}).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Shards) != 0 {
+		t.Errorf("Run() wrote %d shards, want none", len(index.Shards))
+	}
+	if _, ok := out[out[LatestName].String()+IndexName]; !ok {
+		t.Errorf("index wasn't written")
+	}
+}
+
+func TestRunSourceError(t *testing.T) {
+	out := memOutput{}
+	s := &Stage{Source: errSource{}, Output: out, HashType: "MD5"}
+	if _, err := s.Run(context.Background()); err == nil {
+		t.Fatal("Run() succeeded, want an error")
+	}
+	if _, ok := out[LatestName]; ok {
+		t.Errorf("%s was written for a failed export", LatestName)
+	}
+}
+
+type errSource struct{}
+
+func (errSource) ExportDocuments(_ context.Context, _ string, fn func(*Document) error) error {
+	if err := fn(&Document{Key: "doc"}); err != nil {
+		return err
+	}
+	return fmt.Errorf("datastore unavailable")
+}
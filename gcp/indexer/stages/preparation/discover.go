@@ -0,0 +1,166 @@
+/*
+Copyright 2022 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package preparation
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/osv.dev/gcp/indexer/config"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+	"github.com/google/osv.dev/gcp/indexer/shared"
+
+	log "github.com/golang/glog"
+)
+
+// osvRecord holds the fields of an OSV record discovery reads.
+type osvRecord struct {
+	ID        string `json:"id"`
+	Withdrawn string `json:"withdrawn"`
+	Affected  []struct {
+		Ranges []struct {
+			Type string `json:"type"`
+			Repo string `json:"repo"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// Discover returns the configurations of the repositories referenced by the
+// GIT ranges of the OSV records in d's sources, that d allows and that
+// aren't configured already, for indexing alongside the configured ones.
+func Discover(ctx context.Context, client *storage.Client, d *config.Discovery, configured []*config.RepoConfig) ([]*config.RepoConfig, error) {
+	addrs := make(map[string]string)
+	for _, source := range d.Sources {
+		if err := discoverSource(ctx, client, source, addrs); err != nil {
+			return nil, fmt.Errorf("failed to discover repositories from %s: %v", source, err)
+		}
+	}
+	return discoveredConfigs(d, addrs, configured), nil
+}
+
+func discoverSource(ctx context.Context, client *storage.Client, source string, addrs map[string]string) error {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme != "gs" || u.Host == "" {
+		return fmt.Errorf("not a gs:// URL")
+	}
+	r, err := client.Bucket(u.Host).Object(strings.TrimPrefix(u.Path, "/")).NewReader(ctx)
+	metrics.GCS(ctx, "read", err)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	// Reading a zip needs random access, so it's spooled to disk first.
+	f, err := os.CreateTemp("", "discovery-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return err
+	}
+	return reposFromZip(zr, addrs)
+}
+
+// reposFromZip adds the repositories referenced by the GIT ranges of the
+// records in zr to addrs, keyed by their normalized address.
+func reposFromZip(zr *zip.Reader, addrs map[string]string) error {
+	for _, f := range zr.File {
+		if path.Ext(f.Name) != ".json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		var record osvRecord
+		err = json.NewDecoder(rc).Decode(&record)
+		rc.Close()
+		if err != nil {
+			log.Warningf("skipping malformed record %s: %v", f.Name, err)
+			continue
+		}
+		if record.Withdrawn != "" {
+			continue
+		}
+		for _, affected := range record.Affected {
+			for _, r := range affected.Ranges {
+				if r.Type != shared.Git || r.Repo == "" {
+					continue
+				}
+				key := normalizeAddress(r.Repo)
+				if _, ok := addrs[key]; !ok {
+					addrs[key] = r.Repo
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeAddress returns the form of a repository address that the
+// different spellings of the same repository have in common.
+func normalizeAddress(addr string) string {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	for _, scheme := range []string{"https://", "http://", "git://"} {
+		addr = strings.TrimPrefix(addr, scheme)
+	}
+	addr = strings.TrimSuffix(addr, "/")
+	return strings.TrimSuffix(addr, ".git")
+}
+
+// discoveredConfigs returns the configurations of the addresses in addrs
+// that d allows and that aren't configured already, ordered by name.
+func discoveredConfigs(d *config.Discovery, addrs map[string]string, configured []*config.RepoConfig) []*config.RepoConfig {
+	names := make(map[string]bool)
+	known := make(map[string]bool)
+	for _, cfg := range configured {
+		names[cfg.Name] = true
+		known[normalizeAddress(cfg.Address)] = true
+	}
+	var cfgs []*config.RepoConfig
+	for key, addr := range addrs {
+		// The name is the normalized address, which the repository's
+		// checkout and versions are stored under.
+		if known[key] || names[key] || !d.Allowed(addr) {
+			continue
+		}
+		cfgs = append(cfgs, &config.RepoConfig{
+			Address:  addr,
+			Name:     key,
+			Type:     shared.Git,
+			FileExts: d.FileExts,
+		})
+	}
+	slices.SortFunc(cfgs, func(a, b *config.RepoConfig) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cfgs
+}
@@ -0,0 +1,86 @@
+package preparation
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/osv.dev/gcp/indexer/config"
+)
+
+func TestReposFromZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"CVE-2024-0001.json": `{"id": "CVE-2024-0001", "affected": [{"ranges": [
+			{"type": "GIT", "repo": "https://github.com/curl/curl.git"},
+			{"type": "SEMVER"}
+		]}]}`,
+		"CVE-2024-0002.json": `{"id": "CVE-2024-0002", "affected": [{"ranges": [
+			{"type": "GIT", "repo": "https://github.com/Curl/curl/"},
+			{"type": "GIT", "repo": "https://gitlab.com/libtiff/libtiff"}
+		]}]}`,
+		"CVE-2024-0003.json": `{"id": "CVE-2024-0003", "withdrawn": "2024-01-01T00:00:00Z", "affected": [{"ranges": [
+			{"type": "GIT", "repo": "https://github.com/madler/zlib"}
+		]}]}`,
+		"CVE-2024-0004.json": `{"truncated`,
+		"README.md":          `Not a record.`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs := make(map[string]string)
+	if err := reposFromZip(zr, addrs); err != nil {
+		t.Fatalf("reposFromZip() returned an unexpected error: %v", err)
+	}
+	want := []string{"github.com/curl/curl", "gitlab.com/libtiff/libtiff"}
+	var got []string
+	for key := range addrs {
+		got = append(got, key)
+	}
+	if diff := cmp.Diff(want, got, cmpSorted); diff != "" {
+		t.Errorf("reposFromZip() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+}
+
+var cmpSorted = cmp.Transformer("sort", func(in []string) []string {
+	out := append([]string(nil), in...)
+	slices.Sort(out)
+	return out
+})
+
+func TestDiscoveredConfigs(t *testing.T) {
+	d := &config.Discovery{FileExts: []string{".c"}}
+	addrs := map[string]string{
+		"github.com/curl/curl":       "https://github.com/curl/curl.git",
+		"github.com/madler/zlib":     "https://github.com/madler/zlib",
+		"gitlab.com/libtiff/libtiff": "https://gitlab.com/libtiff/libtiff",
+	}
+	configured := []*config.RepoConfig{
+		{Name: "zlib", Address: "https://github.com/madler/zlib.git"},
+	}
+
+	want := []*config.RepoConfig{
+		{Address: "https://github.com/curl/curl.git", Name: "github.com/curl/curl", Type: "GIT", FileExts: []string{".c"}},
+		{Address: "https://gitlab.com/libtiff/libtiff", Name: "gitlab.com/libtiff/libtiff", Type: "GIT", FileExts: []string{".c"}},
+	}
+	got := discoveredConfigs(d, addrs, configured)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("discoveredConfigs() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+}
@@ -33,8 +33,10 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/osv.dev/gcp/indexer/config"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
 	"github.com/google/osv.dev/gcp/indexer/shared"
 	"golang.org/x/sync/semaphore"
+	"google.golang.org/api/iterator"
 
 	log "github.com/golang/glog"
 )
@@ -43,16 +45,22 @@ const workers = 5
 
 // Result is the data structure returned by the stage.
 type Result struct {
-	Name              string
-	BaseCPE           string
-	CheckoutOptions   *git.CheckoutOptions
-	Commit            plumbing.Hash
-	Reference         plumbing.Hash
-	CommitTag         string
-	When              time.Time
-	Type              string
-	Addr              string
-	FileExts          []string
+	Name            string
+	BaseCPE         string
+	CheckoutOptions *git.CheckoutOptions
+	Commit          plumbing.Hash
+	Reference       plumbing.Hash
+	CommitTag       string
+	// RefType is the kind of reference indexed, one of shared.RefTag,
+	// shared.RefBranch or shared.RefCommit.
+	RefType  string
+	When     time.Time
+	Type     string
+	Addr     string
+	FileExts []string
+	// Submodules is whether the submodules of the version are checked out
+	// and hashed along with it.
+	Submodules        bool
 	EmptyBucketBitmap []byte
 	FileCount         int
 }
@@ -62,11 +70,21 @@ type Checker interface {
 	Exists(ctx context.Context, addr string, hashType string, hash plumbing.Hash) (bool, error)
 }
 
+// Journal records the versions published for processing, so a run after one
+// that died doesn't publish those still queued or in progress again.
+type Journal interface {
+	Pending(ctx context.Context, addr string, hashType string, hash plumbing.Hash) (bool, error)
+	MarkPending(ctx context.Context, result *Result, hashType string) error
+}
+
 // Stage holds the data types necessary to process repository configuration.
 type Stage struct {
 	Checker Checker
 	RepoHdl *storage.BucketHandle
 	Output  *pubsub.Topic
+	// Journal is optional; without it, every version not yet stored is
+	// published.
+	Journal Journal
 }
 
 // Run runs the stage and outputs Result data types to the results channel.
@@ -75,18 +93,29 @@ func (s *Stage) Run(ctx context.Context, cfgs []*config.RepoConfig) error {
 	defer wCancel()
 
 	sem := semaphore.NewWeighted(workers)
-	for _, repoCfg := range cfgs {
+	// Every configuration is queued up front, so the queue depth shows the
+	// repositories left.
+	done := make([]func(bool, error), len(cfgs))
+	for i, repoCfg := range cfgs {
+		done[i] = metrics.Begin(ctx, metrics.Preparation, repoCfg.Name)
+	}
+	for i, repoCfg := range cfgs {
 		if err := sem.Acquire(wCtx, 1); err != nil {
+			for _, d := range done[i:] {
+				d(false, err)
+			}
 			return fmt.Errorf("failed to acquire semaphore: %v", err)
 		}
 
-		go func(ctx context.Context, repoCfg *config.RepoConfig) {
+		go func(ctx context.Context, repoCfg *config.RepoConfig, done func(bool, error)) {
 			defer sem.Release(1)
 
 			var err error
+			defer func() { done(false, err) }()
 			select {
 			case <-ctx.Done():
 				log.Error(context.Canceled)
+				err = context.Canceled
 				return
 			default:
 			}
@@ -95,12 +124,13 @@ func (s *Stage) Run(ctx context.Context, cfgs []*config.RepoConfig) error {
 			case shared.Git:
 				err = s.processGit(ctx, repoCfg)
 			default:
-				log.Errorf("unsupported config type: %s", repoCfg.Type)
+				err = fmt.Errorf("unsupported config type: %s", repoCfg.Type)
+				metrics.Error(ctx, metrics.Preparation, "config")
 			}
 			if err != nil {
 				log.Errorf("preparation failed for %s: %v", repoCfg.Name, err)
 			}
-		}(wCtx, repoCfg)
+		}(wCtx, repoCfg, done[i])
 	}
 	return sem.Acquire(ctx, workers)
 }
@@ -108,6 +138,11 @@ func (s *Stage) Run(ctx context.Context, cfgs []*config.RepoConfig) error {
 func (s *Stage) objectExists(ctx context.Context, name string) bool {
 	objItr := s.RepoHdl.Objects(ctx, &storage.Query{Prefix: name + shared.TarExt})
 	_, err := objItr.Next()
+	if err == iterator.Done {
+		metrics.GCS(ctx, "list", nil)
+		return false
+	}
+	metrics.GCS(ctx, "list", err)
 	return err == nil
 }
 
@@ -118,9 +153,9 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 		repoDir string
 	)
 	if !s.objectExists(ctx, repoCfg.Name) {
-		repo, repoDir, err = s.cloneGitRepo(ctx, repoCfg.Name, repoCfg.Address)
+		repo, repoDir, err = s.cloneGitRepo(ctx, repoCfg)
 	} else {
-		repo, repoDir, err = s.updateGitRepo(ctx, repoCfg.Name)
+		repo, repoDir, err = s.updateGitRepo(ctx, repoCfg)
 	}
 	if repoDir != "" {
 		defer func() {
@@ -131,6 +166,7 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 	}
 
 	if err != nil {
+		metrics.Error(ctx, metrics.Preparation, "clone")
 		return fmt.Errorf("failed to clone/update repo: %w", err)
 	}
 
@@ -145,63 +181,65 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 	})
 
 	commitTracker := make(map[plumbing.Hash]bool)
-	// repoInfo is used as the iterator function to create RepositoryInformation structs.
-	repoInfo := func(ref *plumbing.Reference) error {
-		// Resolve the real commit hash
-		commitHash, err := repo.ResolveRevision(plumbing.Revision(ref.Name().String()))
+	// repoInfo returns the iterator function to create RepositoryInformation structs for references of refType.
+	repoInfo := func(refType string) func(ref *plumbing.Reference) error {
+		return func(ref *plumbing.Reference) error {
+			// Resolve the real commit hash
+			commitHash, err := repo.ResolveRevision(plumbing.Revision(ref.Name().String()))
 
-		if err != nil {
-			log.Errorf("Failed to resolve %s: %v", ref.Name().String(), err)
-			// Ignore errors as this will block the iteration otherwise.
-			return nil
-		}
+			if err != nil {
+				log.Errorf("Failed to resolve %s: %v", ref.Name().String(), err)
+				// Ignore errors as this will block the iteration otherwise.
+				return nil
+			}
 
-		found, err := s.Checker.Exists(ctx, repoCfg.Address, shared.MD5, ref.Hash())
-		if err != nil {
-			return err
-		}
-		if found {
-			return nil
-		}
+			found, err := s.needed(ctx, repoCfg.Address, ref.Hash())
+			if err != nil {
+				return err
+			}
+			if !found {
+				return nil
+			}
 
-		var when time.Time
-		if c, ok := allCommits[*commitHash]; ok {
-			when = c.Author.When
-		}
+			var when time.Time
+			if c, ok := allCommits[*commitHash]; ok {
+				when = c.Author.When
+			}
 
-		commitTag := ref.Name().String()
+			commitTag := ref.Name().String()
+
+			result := &Result{
+				Name:    repoCfg.Name,
+				BaseCPE: repoCfg.BaseCPE,
+				CheckoutOptions: &git.CheckoutOptions{
+					Branch: ref.Name(),
+				},
+				When:       when,
+				Commit:     *commitHash,
+				Reference:  ref.Hash(),
+				CommitTag:  commitTag,
+				RefType:    refType,
+				Type:       shared.Git,
+				Addr:       repoCfg.Address,
+				FileExts:   repoCfg.FileExts,
+				Submodules: repoCfg.Submodules,
+			}
+			commitTracker[*commitHash] = true
+			buf, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
 
-		result := &Result{
-			Name:    repoCfg.Name,
-			BaseCPE: repoCfg.BaseCPE,
-			CheckoutOptions: &git.CheckoutOptions{
-				Branch: ref.Name(),
-			},
-			When:      when,
-			Commit:    *commitHash,
-			Reference: ref.Hash(),
-			CommitTag: commitTag,
-			Type:      shared.Git,
-			Addr:      repoCfg.Address,
-			FileExts:  repoCfg.FileExts,
+			log.Infof("publishing %s at version: %s", result.Name, commitTag)
+			return s.publish(ctx, result, buf)
 		}
-		commitTracker[*commitHash] = true
-		buf, err := json.Marshal(result)
-		if err != nil {
-			return err
-		}
-
-		log.Infof("publishing %s at version: %s", result.Name, commitTag)
-		pubRes := s.Output.Publish(ctx, &pubsub.Message{Data: buf})
-		_, err = pubRes.Get(ctx)
-		return err
 	}
 
 	repoItr, err := repo.Tags()
 	if err != nil {
 		return err
 	}
-	if err := repoItr.ForEach(repoInfo); err != nil {
+	if err := repoItr.ForEach(repoInfo(shared.RefTag)); err != nil {
 		return err
 	}
 
@@ -210,7 +248,63 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 		if err != nil {
 			return err
 		}
-		if err := repoItr.ForEach(repoInfo); err != nil {
+		if err := repoItr.ForEach(repoInfo(shared.RefBranch)); err != nil {
+			return err
+		}
+	}
+
+	for _, branch := range repoCfg.Branches {
+		ref, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+		if err != nil {
+			log.Errorf("failed to find branch %s of %s: %v", branch, repoCfg.Name, err)
+			continue
+		}
+		if err := repoInfo(shared.RefBranch)(ref); err != nil {
+			return err
+		}
+	}
+
+	for _, commit := range repoCfg.Commits {
+		h := plumbing.NewHash(commit)
+		c, ok := allCommits[h]
+		if !ok {
+			log.Errorf("failed to find commit %s of %s", commit, repoCfg.Name)
+			continue
+		}
+		if commitTracker[h] {
+			continue
+		}
+		needed, err := s.needed(ctx, repoCfg.Address, h)
+		if err != nil {
+			return err
+		}
+		if !needed {
+			continue
+		}
+		result := &Result{
+			Name:    repoCfg.Name,
+			BaseCPE: repoCfg.BaseCPE,
+			CheckoutOptions: &git.CheckoutOptions{
+				Hash:  h,
+				Force: true,
+			},
+			Reference:  h,
+			When:       c.Author.When,
+			Commit:     h,
+			CommitTag:  commit,
+			RefType:    shared.RefCommit,
+			Type:       shared.Git,
+			Addr:       repoCfg.Address,
+			FileExts:   repoCfg.FileExts,
+			Submodules: repoCfg.Submodules,
+		}
+		commitTracker[h] = true
+		buf, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		log.Infof("publishing %s at commit: %s", result.Name, commit)
+		if err := s.publish(ctx, result, buf); err != nil {
 			return err
 		}
 	}
@@ -218,11 +312,11 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 	if repoCfg.HashAllCommits {
 		for h, c := range allCommits {
 			if found := commitTracker[h]; !found {
-				exists, err := s.Checker.Exists(ctx, repoCfg.Address, shared.MD5, h)
+				needed, err := s.needed(ctx, repoCfg.Address, h)
 				if err != nil {
 					return err
 				}
-				if exists {
+				if !needed {
 					continue
 				}
 				result := &Result{
@@ -231,33 +325,75 @@ func (s *Stage) processGit(ctx context.Context, repoCfg *config.RepoConfig) erro
 						Hash:  h,
 						Force: true,
 					},
-					Reference: h,
-					When:      c.Author.When,
-					Commit:    h,
-					Type:      shared.Git,
-					FileExts:  repoCfg.FileExts,
+					Reference:  h,
+					When:       c.Author.When,
+					Commit:     h,
+					RefType:    shared.RefCommit,
+					Type:       shared.Git,
+					FileExts:   repoCfg.FileExts,
+					Submodules: repoCfg.Submodules,
 				}
 				buf, err := json.Marshal(result)
 				if err != nil {
 					return err
 				}
-				pubRes := s.Output.Publish(ctx, &pubsub.Message{Data: buf})
-				_, err = pubRes.Get(ctx)
-				return err
+				return s.publish(ctx, result, buf)
 			}
 		}
 	}
 	return nil
 }
 
-func (s *Stage) cloneGitRepo(ctx context.Context, name, address string) (*git.Repository, string, error) {
+// needed returns whether a version needs to be published for processing:
+// it's neither stored nor, according to the journal, still queued or in
+// progress.
+func (s *Stage) needed(ctx context.Context, addr string, hash plumbing.Hash) (bool, error) {
+	found, err := s.Checker.Exists(ctx, addr, shared.MD5, hash)
+	if err != nil || found {
+		return false, err
+	}
+	if s.Journal == nil {
+		return true, nil
+	}
+	pending, err := s.Journal.Pending(ctx, addr, shared.MD5, hash)
+	if err != nil {
+		return false, err
+	}
+	return !pending, nil
+}
+
+// publish publishes a result for the processing stage, and records it in
+// the journal.
+func (s *Stage) publish(ctx context.Context, result *Result, buf []byte) error {
+	pubRes := s.Output.Publish(ctx, &pubsub.Message{Data: buf})
+	if _, err := pubRes.Get(ctx); err != nil {
+		metrics.Error(ctx, metrics.Preparation, "publish")
+		return err
+	}
+	if s.Journal == nil {
+		return nil
+	}
+	// If this fails, the version is published again by the next run, which
+	// only duplicates the work.
+	if err := s.Journal.MarkPending(ctx, result, shared.MD5); err != nil {
+		log.Errorf("failed to journal %s at %s: %v", result.Name, result.CommitTag, err)
+	}
+	return nil
+}
+
+// cloneGitRepo mirrors a repository to the bucket. The mirror is a bare
+// clone, as the processing stage checks out each version on its own, and a
+// shallow one if the configuration has a clone depth.
+func (s *Stage) cloneGitRepo(ctx context.Context, repoCfg *config.RepoConfig) (*git.Repository, string, error) {
+	name := repoCfg.Name
 	tmpDir, err := os.MkdirTemp("", "")
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create tmp dir: %v", err)
 	}
 
-	repo, err := git.PlainClone(tmpDir, false, &git.CloneOptions{
-		URL: address,
+	repo, err := git.PlainClone(tmpDir, true, &git.CloneOptions{
+		URL:   repoCfg.Address,
+		Depth: repoCfg.CloneDepth,
 	})
 	if err != nil {
 		return nil, tmpDir, fmt.Errorf("failed to clone repository for %s: %v", name, err)
@@ -265,7 +401,10 @@ func (s *Stage) cloneGitRepo(ctx context.Context, name, address string) (*git.Re
 	return repo, tmpDir, s.copyToBucket(ctx, tmpDir, name)
 }
 
-func (s *Stage) updateGitRepo(ctx context.Context, name string) (*git.Repository, string, error) {
+// updateGitRepo fetches the new commits of a mirrored repository. Mirrors
+// made before they were bare clones are updated as they are.
+func (s *Stage) updateGitRepo(ctx context.Context, repoCfg *config.RepoConfig) (*git.Repository, string, error) {
+	name := repoCfg.Name
 	repoDir, err := shared.CopyFromBucket(ctx, s.RepoHdl, name)
 	if err != nil {
 		return nil, "", err
@@ -276,7 +415,8 @@ func (s *Stage) updateGitRepo(ctx context.Context, name string) (*git.Repository
 		return nil, "", err
 	}
 	if err := repo.Fetch(&git.FetchOptions{
-		Tags: git.AllTags,
+		Tags:  git.AllTags,
+		Depth: repoCfg.CloneDepth,
 	}); err != nil && err != git.NoErrAlreadyUpToDate {
 		log.Errorf("failed to fetch '%s' with %v", name, err)
 		return nil, "", err
@@ -301,7 +441,9 @@ func (r *Stage) copyToBucket(ctx context.Context, dir, name string) error {
 
 	obj := r.RepoHdl.Object(name + shared.TarExt)
 	objW := obj.NewWriter(ctx)
-	defer objW.Close()
+	defer func() {
+		metrics.GCS(ctx, "write", objW.Close())
+	}()
 	tarW := tar.NewWriter(objW)
 	defer tarW.Close()
 
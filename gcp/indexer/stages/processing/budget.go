@@ -0,0 +1,80 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package processing
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// diskBudget limits the total size of the checkouts processed concurrently.
+// Checkouts are admitted in the order they ask for space, so a large
+// repository waiting for space isn't starved by smaller ones.
+type diskBudget struct {
+	limit int64
+	sem   *semaphore.Weighted
+}
+
+// newDiskBudget returns a budget of limit bytes, or nil, which never blocks,
+// if limit isn't positive.
+func newDiskBudget(limit int64) *diskBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &diskBudget{limit: limit, sem: semaphore.NewWeighted(limit)}
+}
+
+// fit returns the bytes reserved for a checkout of size bytes. Checkouts
+// larger than the whole budget take all of it, so they are processed on
+// their own.
+func (b *diskBudget) fit(size int64) int64 {
+	return min(max(size, 1), b.limit)
+}
+
+// acquire blocks until size bytes are available, returning the number of
+// bytes to release once the checkout is removed.
+func (b *diskBudget) acquire(ctx context.Context, size int64) (int64, error) {
+	if b == nil {
+		return 0, nil
+	}
+	size = b.fit(size)
+	if err := b.sem.Acquire(ctx, size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// release returns bytes acquired for a checkout to the budget.
+func (b *diskBudget) release(size int64) {
+	if b == nil || size == 0 {
+		return
+	}
+	b.sem.Release(size)
+}
+
+// tryAcquire is like acquire, but returns false instead of blocking if the
+// space isn't available right away.
+func (b *diskBudget) tryAcquire(size int64) (int64, bool) {
+	if b == nil {
+		return 0, true
+	}
+	size = b.fit(size)
+	if !b.sem.TryAcquire(size) {
+		return 0, false
+	}
+	return size, true
+}
@@ -0,0 +1,70 @@
+package processing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDiskBudget(t *testing.T) {
+	ctx := context.Background()
+	b := newDiskBudget(100)
+
+	small, err := b.acquire(ctx, 60)
+	if err != nil || small != 60 {
+		t.Fatalf("acquire(60) = %d, %v, want 60, nil", small, err)
+	}
+	if _, ok := b.tryAcquire(50); ok {
+		t.Errorf("tryAcquire(50) succeeded with 40 bytes free")
+	}
+
+	// A checkout larger than the budget waits for all of it.
+	acquired := make(chan int64)
+	go func() {
+		large, err := b.acquire(ctx, 500)
+		if err != nil {
+			t.Errorf("acquire(500) failed: %v", err)
+		}
+		acquired <- large
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("acquire(500) didn't wait for the budget to be released")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.release(small)
+	if large := <-acquired; large != 100 {
+		t.Errorf("acquire(500) reserved %d bytes, want 100", large)
+	}
+	if _, ok := b.tryAcquire(1); ok {
+		t.Errorf("tryAcquire(1) succeeded while a large checkout holds the budget")
+	}
+	b.release(100)
+	if reserved, ok := b.tryAcquire(0); !ok || reserved != 1 {
+		t.Errorf("tryAcquire(0) = %d, %v, want 1, true", reserved, ok)
+	}
+}
+
+func TestDiskBudgetUnlimited(t *testing.T) {
+	b := newDiskBudget(0)
+	if b != nil {
+		t.Fatalf("newDiskBudget(0) = %v, want nil", b)
+	}
+	reserved, err := b.acquire(context.Background(), 1<<40)
+	if err != nil || reserved != 0 {
+		t.Errorf("acquire() = %d, %v, want 0, nil", reserved, err)
+	}
+	b.release(reserved)
+}
+
+func TestDiskBudgetCancelled(t *testing.T) {
+	b := newDiskBudget(10)
+	if _, err := b.acquire(context.Background(), 10); err != nil {
+		t.Fatalf("acquire(10) failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.acquire(ctx, 1); err == nil {
+		t.Errorf("acquire() with a cancelled context succeeded")
+	}
+}
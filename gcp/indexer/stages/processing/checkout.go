@@ -0,0 +1,121 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package processing
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// maxLinkDepth is the number of symbolic links followed to a file before
+// giving up on it.
+const maxLinkDepth = 8
+
+// checkoutFull checks out a version of repo into dir, which needn't be the
+// repository's own worktree, so that bare mirrors can be checked out too.
+func checkoutFull(repo *git.Repository, opts *git.CheckoutOptions, dir string) (*git.Worktree, error) {
+	wtRepo, err := git.Open(repo.Storer, osfs.New(dir))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := wtRepo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	opts.Force = true
+	if err := tree.Checkout(opts); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// checkoutSparse writes the files of a commit with one of fileExts into dir,
+// like a sparse checkout limited to them, which is all hashFiles reads of a
+// full one. Symbolic links are written as the files they link to, as
+// hashFiles reads them through the link; links to files outside the tree
+// are left out.
+func checkoutSparse(repo *git.Repository, hash plumbing.Hash, dir string, fileExts []string) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("failed to get commit %s: %v", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("failed to get tree of %s: %v", hash, err)
+	}
+	return tree.Files().ForEach(func(f *object.File) error {
+		if !hasExt(f.Name, fileExts) {
+			return nil
+		}
+		target, err := resolveLink(tree, f)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return nil
+		}
+		content, err := target.Contents()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", f.Name, err)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(p), 0760); err != nil {
+			return err
+		}
+		return os.WriteFile(p, []byte(content), 0660)
+	})
+}
+
+// resolveLink returns the file a symbolic link in tree links to, following
+// links to links, or f itself if it isn't a link. It returns nil if the link
+// leads outside the tree.
+func resolveLink(tree *object.Tree, f *object.File) (*object.File, error) {
+	for range maxLinkDepth {
+		if f.Mode != filemode.Symlink {
+			return f, nil
+		}
+		target, err := f.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read link %s: %v", f.Name, err)
+		}
+		if path.IsAbs(target) {
+			return nil, nil
+		}
+		linked, err := tree.File(path.Join(path.Dir(f.Name), target))
+		if err != nil {
+			return nil, nil
+		}
+		f = linked
+	}
+	return nil, nil
+}
+
+func hasExt(name string, fileExts []string) bool {
+	for _, ext := range fileExts {
+		if path.Ext(name) == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package processing
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// mirrorRepo commits files to a new repository and returns a shallow bare
+// clone of it, as the preparation stage mirrors repositories, and the commit.
+func mirrorRepo(t *testing.T, files map[string]string, links map[string]string) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, content := range files {
+		full := filepath.Join(srcDir, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for p, target := range links {
+		full := filepath.Join(srcDir, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, full); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree, err := src.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := tree.Commit("v1", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.CreateTag("v1", hash, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	mirror, err := git.PlainClone(t.TempDir(), true, &git.CloneOptions{URL: srcDir, Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mirror, hash
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCheckoutSparse(t *testing.T) {
+	fileExts := []string{".c", ".h"}
+	repo, hash := mirrorRepo(t, map[string]string{
+		"src/a.c":                    "int a;",
+		"src/a.h":                    "int a();",
+		"src/b.c":                    "// SPDX-License-Identifier: MIT\n",
+		"README.md":                  "readme",
+		"docs/big.bin":               "binary",
+		"third_party/zlib/inflate.c": "int inflate;",
+		"LICENSES/MIT.c":             "int mit;",
+	}, map[string]string{
+		"src/link.h":     "a.h",
+		"src/outside.h":  "../../outside.h",
+		"include/a.h":    "../src/a.h",
+		"include/readme": "../README.md",
+	})
+
+	sparseDir := t.TempDir()
+	if err := checkoutSparse(repo, hash, sparseDir, fileExts); err != nil {
+		t.Fatalf("checkoutSparse() error: %v", err)
+	}
+	wantFiles := []string{
+		"LICENSES/MIT.c",
+		"include/a.h",
+		"src/a.c",
+		"src/a.h",
+		"src/b.c",
+		"src/link.h",
+		"third_party/zlib/inflate.c",
+	}
+	if got := listFiles(t, sparseDir); !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf("checkoutSparse() wrote %v, want %v", got, wantFiles)
+	}
+
+	// Hashing the sparse checkout gives the hashes of a full one.
+	fullDir := t.TempDir()
+	if _, err := checkoutFull(repo, &git.CheckoutOptions{Branch: plumbing.NewTagReferenceName("v1")}, fullDir); err != nil {
+		t.Fatalf("checkoutFull() error: %v", err)
+	}
+	// The link out of the tree can't be read in a full checkout either.
+	if err := os.Remove(filepath.Join(fullDir, "src", "outside.h")); err != nil {
+		t.Fatal(err)
+	}
+	results := func(dir string) []string {
+		t.Helper()
+		fileResults, err := hashFiles(dir, dir, "", fileExts, nil)
+		if err != nil {
+			t.Fatalf("hashFiles() error: %v", err)
+		}
+		var out []string
+		for _, fr := range fileResults {
+			out = append(out, fr.Path+":"+string(fr.Hash))
+		}
+		sort.Strings(out)
+		return out
+	}
+	if got, want := results(sparseDir), results(fullDir); !reflect.DeepEqual(got, want) {
+		t.Errorf("hashes of sparse checkout = %q, want %q", got, want)
+	}
+}
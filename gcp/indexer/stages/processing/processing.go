@@ -27,12 +27,14 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/storage"
 	"github.com/go-git/go-git/v5"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
 	"github.com/google/osv.dev/gcp/indexer/shared"
 	"github.com/google/osv.dev/gcp/indexer/stages/preparation"
 
@@ -43,14 +45,24 @@ type Hash = []byte
 
 // Storer is used to permanently store the results.
 type Storer interface {
-	Store(ctx context.Context, repoInfo *preparation.Result, hashType string, bucketNodes []*BucketNode) error
+	Store(ctx context.Context, repoInfo *preparation.Result, hashType string, bucketNodes []*BucketNode, pages []*Page) error
 	Clean(ctx context.Context, repoInfo *preparation.Result, hashType string) error
 }
 
+// Journal records the outcome of processing each version, so the next
+// preparation run publishes only the versions not processed.
+type Journal interface {
+	MarkDone(ctx context.Context, repoInfo *preparation.Result, hashType string) error
+	MarkFailed(ctx context.Context, repoInfo *preparation.Result, hashType string, err error) error
+}
+
 // FileResult holds the per file hash and path information.
 type FileResult struct {
 	Path string `datastore:"path,noindex"`
 	Hash Hash   `datastore:"hash"`
+	// Submodule is the path of the submodule the file is in, or empty for
+	// the files of the repository itself.
+	Submodule string `datastore:"submodule,noindex"`
 }
 
 // FileResult holds the per file hash and path information.
@@ -60,12 +72,28 @@ type BucketNode struct {
 	DocumentVersion int  `datastore:"document_version,noindex"`
 }
 
+// Page holds the sorted file hashes of a single bucket, stored alongside its
+// BucketNode so buckets that nearly match can be compared file by file.
+type Page struct {
+	BucketIndex     int    `datastore:"bucket_index,noindex"`
+	FileHashes      []Hash `datastore:"file_hashes,noindex"`
+	DocumentVersion int    `datastore:"document_version,noindex"`
+}
+
 // Stage holds the data structures necessary to perform the processing.
 type Stage struct {
 	Storer                    Storer
 	RepoHdl                   *storage.BucketHandle
 	Input                     *pubsub.Subscription
 	PubSubOutstandingMessages int
+	// DiskBudget is the maximum total size in bytes of the checkouts
+	// processed concurrently, or 0 for no limit. Messages whose checkout
+	// doesn't fit are queued until enough of the budget is released.
+	DiskBudget int64
+	// Journal is optional.
+	Journal Journal
+
+	budget *diskBudget
 }
 
 // bucketCount should be a divisor of 2^16
@@ -87,11 +115,69 @@ var (
 		"vendor":      {},
 		"vendored":    {},
 	}
+	// licenseDirNames are directories of license texts, such as the
+	// LICENSES directory of SPDX identifier named texts REUSE compliant
+	// projects have.
+	licenseDirNames = map[string]struct{}{
+		"license":  {},
+		"licenses": {},
+		"licence":  {},
+		"licences": {},
+	}
+	// licenseFileName matches the names of license and notice files, e.g.
+	// LICENSE, COPYING.LESSER, LICENSE-MIT and NOTICE.md.
+	licenseFileName = regexp.MustCompile(`^(?:un)?(?:licen[cs]e|copying|copyright|notice|patents)`)
+	// licenseFileExts are the extensions of license and notice files, as
+	// opposed to those of sources named after them, like license.c.
+	licenseFileExts = map[string]struct{}{
+		"":          {},
+		".txt":      {},
+		".md":       {},
+		".markdown": {},
+		".rst":      {},
+		".html":     {},
+		".htm":      {},
+		".lesser":   {},
+		".lib":      {},
+		".gpl":      {},
+		".lgpl":     {},
+		".mit":      {},
+		".bsd":      {},
+		".apache":   {},
+	}
+	// comments matches C style block and line comments.
+	comments = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	// licenseMention matches the license notices comments are recognized
+	// as boilerplate by.
+	licenseMention = regexp.MustCompile(`(?i)spdx-license-identifier|licen[cs]e|copyright`)
 )
 
+// isLicenseFile returns whether a file is a license text or notice, by its
+// name.
+func isLicenseFile(name string) bool {
+	name = strings.ToLower(name)
+	if !licenseFileName.MatchString(name) {
+		return false
+	}
+	_, ok := licenseFileExts[filepath.Ext(name)]
+	return ok
+}
+
+// isLicenseBoilerplate returns whether a source file holds nothing but
+// comments carrying a license notice, e.g. an SPDX-License-Identifier
+// header. Such files are identical across many unrelated projects and
+// versions, so hashing them would make unrelated libraries look alike.
+func isLicenseBoilerplate(buf []byte) bool {
+	if len(bytes.TrimSpace(comments.ReplaceAll(buf, nil))) != 0 {
+		return false
+	}
+	return licenseMention.Match(buf)
+}
+
 // Run runs the stages and hashes all files for each incoming request.
 func (s *Stage) Run(ctx context.Context) error {
 	s.Input.ReceiveSettings.MaxOutstandingMessages = s.PubSubOutstandingMessages
+	s.budget = newDiskBudget(s.DiskBudget)
 	return s.Input.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
 		// Always ack the message. Transient errors can be solved by the
 		// next scheduled run.
@@ -102,7 +188,9 @@ func (s *Stage) Run(ctx context.Context) error {
 			return
 		}
 		log.Infof("begin processing: '%v' @ '%v'", repoInfo.Name, repoInfo.CommitTag)
+		done := metrics.Begin(ctx, metrics.Processing, repoInfo.Name)
 		var err error
+		defer func() { done(false, err) }()
 		switch repoInfo.Type {
 		case shared.Git:
 			err = s.processGit(ctx, repoInfo)
@@ -114,12 +202,56 @@ func (s *Stage) Run(ctx context.Context) error {
 		} else {
 			log.Infof("successfully processed: '%v' @ '%v'", repoInfo.Name, repoInfo.CommitTag)
 		}
+		s.journal(ctx, repoInfo, err)
 	})
 }
 
+// journal records the outcome of processing a version in the journal.
+func (s *Stage) journal(ctx context.Context, repoInfo *preparation.Result, procErr error) {
+	if s.Journal == nil {
+		return
+	}
+	var err error
+	if procErr != nil {
+		err = s.Journal.MarkFailed(ctx, repoInfo, shared.MD5, procErr)
+	} else {
+		err = s.Journal.MarkDone(ctx, repoInfo, shared.MD5)
+	}
+	if err != nil {
+		log.Errorf("failed to journal ('%v' @ '%v'): %v", repoInfo.Name, repoInfo.CommitTag, err)
+	}
+}
+
+// reserveCheckout blocks until the budget has room for the checkout of a
+// repository, returning the bytes to release once it's removed. The size of
+// the repository's archive stands in for the size of its checkout.
+func (s *Stage) reserveCheckout(ctx context.Context, name string) (int64, error) {
+	if s.budget == nil {
+		return 0, nil
+	}
+	attrs, err := s.RepoHdl.Object(name + shared.TarExt).Attrs(ctx)
+	metrics.GCS(ctx, "attrs", err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archive size: %v", err)
+	}
+	if reserved, ok := s.budget.tryAcquire(attrs.Size); ok {
+		return reserved, nil
+	}
+	log.Infof("queueing '%v' until %d bytes of the disk budget are free", name, s.budget.fit(attrs.Size))
+	return s.budget.acquire(ctx, attrs.Size)
+}
+
 func (s *Stage) processGit(ctx context.Context, repoInfo *preparation.Result) error {
+	reserved, err := s.reserveCheckout(ctx, repoInfo.Name)
+	if err != nil {
+		metrics.Error(ctx, metrics.Processing, "reserve")
+		return err
+	}
+	defer s.budget.release(reserved)
+
 	repoDir, err := shared.CopyFromBucket(ctx, s.RepoHdl, repoInfo.Name)
 	if err != nil {
+		metrics.Error(ctx, metrics.Processing, "copy")
 		return err
 	}
 	defer func() {
@@ -130,61 +262,165 @@ func (s *Stage) processGit(ctx context.Context, repoInfo *preparation.Result) er
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
+		metrics.Error(ctx, metrics.Processing, "checkout")
 		return fmt.Errorf("failed to open repo: %v", err)
 	}
-	tree, err := repo.Worktree()
+	checkoutDir, err := os.MkdirTemp("", repoInfo.Name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(checkoutDir); err != nil {
+			log.Errorf("failed to remove checkout folder: %v", err)
+		}
+	}()
+
+	// Without submodules, only the files hashed are checked out.
+	var submodules []string
+	if repoInfo.Submodules {
+		tree, err := checkoutFull(repo, repoInfo.CheckoutOptions, checkoutDir)
+		if err != nil {
+			metrics.Error(ctx, metrics.Processing, "checkout")
+			return fmt.Errorf("failed to checkout tree: %v", err)
+		}
+		submodules = updateSubmodules(tree)
+	} else if err := checkoutSparse(repo, repoInfo.Commit, checkoutDir, repoInfo.FileExts); err != nil {
+		metrics.Error(ctx, metrics.Processing, "checkout")
+		return fmt.Errorf("failed to checkout files: %v", err)
+	}
+	// Submodules are walked on their own, so that the vendored library
+	// directories they're usually checked out in aren't skipped.
+	skip := make(map[string]bool)
+	for _, sm := range submodules {
+		skip[filepath.Join(checkoutDir, sm)] = true
+	}
+	fileResults, err := hashFiles(checkoutDir, checkoutDir, "", repoInfo.FileExts, skip)
 	if err != nil {
-		return fmt.Errorf("failed to get work tree: %v", err)
+		metrics.Error(ctx, metrics.Processing, "walk")
+		return fmt.Errorf("failed during file walk: %v", err)
 	}
-	repoInfo.CheckoutOptions.Force = true
-	if err := tree.Checkout(repoInfo.CheckoutOptions); err != nil {
-		return fmt.Errorf("failed to checkout tree: %v", err)
+	for _, sm := range submodules {
+		results, err := hashFiles(checkoutDir, filepath.Join(checkoutDir, sm), sm, repoInfo.FileExts, skip)
+		if err != nil {
+			metrics.Error(ctx, metrics.Processing, "walk")
+			return fmt.Errorf("failed during file walk of submodule %s: %v", sm, err)
+		}
+		fileResults = append(fileResults, results...)
 	}
 
+	log.Info("begin processing buckets")
+	bucketResults, bucketFiles := processBuckets(fileResults)
+	// Build up a bitmap of filled in buckets
+	repoInfo.FileCount = len(fileResults)
+	repoInfo.EmptyBucketBitmap = createFilledBucketBitmap(bucketResults)
+	log.Info("begin storage")
+	err = s.Storer.Store(ctx, repoInfo, shared.MD5, bucketResults, processPages(bucketFiles))
+	if err != nil {
+		metrics.Error(ctx, metrics.Processing, "store")
+		return err
+	}
+
+	// Skip cleaning section
+	return nil
+	// log.Info("begin cleaning old versions")
+	// return s.Storer.Clean(ctx, repoInfo, shared.MD5)
+}
+
+// updateSubmodules initializes and checks out the submodules of tree,
+// recursively, at the commits the checked out version records, returning
+// their paths. A submodule that can't be checked out is left out of the
+// index rather than failing the version.
+func updateSubmodules(tree *git.Worktree) []string {
+	subs, err := tree.Submodules()
+	if err != nil {
+		log.Errorf("failed to list submodules: %v", err)
+		return nil
+	}
+	var paths []string
+	for _, sub := range subs {
+		path := sub.Config().Path
+		if err := sub.Update(&git.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		}); err != nil {
+			log.Errorf("failed to check out submodule %s: %v", path, err)
+			continue
+		}
+		paths = append(paths, path)
+		subTree, err := sub.Repository()
+		if err != nil {
+			continue
+		}
+		wt, err := subTree.Worktree()
+		if err != nil {
+			continue
+		}
+		// The nested submodules were checked out by the recursive
+		// update, they're only listed here.
+		nested, err := wt.Submodules()
+		if err != nil {
+			continue
+		}
+		for _, n := range nested {
+			paths = append(paths, filepath.Join(path, n.Config().Path))
+		}
+	}
+	return paths
+}
+
+// hashFiles hashes the files under root with one of fileExts, skipping
+// vendored libraries, license texts and the directories in skip (other than
+// root itself). Their paths are relative to repoDir, and they're recorded
+// as in submodule, the path of root if it's a submodule.
+func hashFiles(repoDir, root, submodule string, fileExts []string, skip map[string]bool) ([]*FileResult, error) {
 	var fileResults []*FileResult
-	if err := filepath.Walk(repoDir, func(p string, info fs.FileInfo, err error) error {
+	err := filepath.Walk(root, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if info.IsDir() {
+			if p == root {
+				return nil
+			}
+			if skip[p] {
+				return filepath.SkipDir
+			}
 			if _, ok := vendoredLibNames[strings.ToLower(info.Name())]; ok {
 				// Ignore vendored libraries, as they can cause bad matches.
 				return filepath.SkipDir
 			}
+			if _, ok := licenseDirNames[strings.ToLower(info.Name())]; ok {
+				return filepath.SkipDir
+			}
 
 			return nil
 		}
+		if isLicenseFile(info.Name()) {
+			// License texts are identical across many projects and
+			// versions.
+			return nil
+		}
 
-		for _, ext := range repoInfo.FileExts {
+		for _, ext := range fileExts {
 			if filepath.Ext(p) == ext {
 				buf, err := os.ReadFile(p)
 				if err != nil {
 					return err
 				}
+				if isLicenseBoilerplate(buf) {
+					return nil
+				}
 				hash := md5.Sum(buf)
 				fileResults = append(fileResults, &FileResult{
-					Path: strings.ReplaceAll(p, repoDir, ""),
-					Hash: hash[:],
+					Path:      strings.TrimPrefix(p, repoDir),
+					Hash:      hash[:],
+					Submodule: submodule,
 				})
 			}
 		}
 		return nil
-	}); err != nil {
-		return fmt.Errorf("failed during file walk: %v", err)
-	}
-
-	log.Info("begin processing buckets")
-	bucketResults, _ := processBuckets(fileResults)
-	// Build up a bitmap of filled in buckets
-	repoInfo.FileCount = len(fileResults)
-	repoInfo.EmptyBucketBitmap = createFilledBucketBitmap(bucketResults)
-	log.Info("begin storage")
-	err = s.Storer.Store(ctx, repoInfo, shared.MD5, bucketResults)
-	if err != nil {
-		return err
-	}
-
-	// Skip cleaning section
-	return nil
-	// log.Info("begin cleaning old versions")
-	// return s.Storer.Clean(ctx, repoInfo, shared.MD5)
+	})
+	return fileResults, err
 }
 
 func createFilledBucketBitmap(nodes []*BucketNode) []byte {
@@ -234,3 +470,21 @@ func processBuckets(fileResults []*FileResult) ([]*BucketNode, [][]*FileResult)
 
 	return results, buckets
 }
+
+// processPages returns the page of file hashes of each bucket, indexed as
+// the buckets are. The hashes are sorted, as they are when hashing buckets.
+func processPages(buckets [][]*FileResult) []*Page {
+	pages := make([]*Page, len(buckets))
+	for bucketIdx, bucket := range buckets {
+		page := &Page{
+			BucketIndex:     bucketIdx,
+			FileHashes:      make([]Hash, 0, len(bucket)),
+			DocumentVersion: shared.LatestDocumentVersion,
+		}
+		for _, fr := range bucket {
+			page.FileHashes = append(page.FileHashes, fr.Hash)
+		}
+		pages[bucketIdx] = page
+	}
+	return pages
+}
@@ -1,8 +1,12 @@
 package processing
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/google/osv.dev/gcp/indexer/shared"
 )
 
 func Test_processBuckets(t *testing.T) {
@@ -59,3 +63,134 @@ func Test_processBuckets(t *testing.T) {
 		})
 	}
 }
+
+func Test_processPages(t *testing.T) {
+	fileResults := []*FileResult{
+		{Path: "b", Hash: []byte{0, 1, 9}},
+		{Path: "a", Hash: []byte{0, 1, 2}},
+		{Path: "c", Hash: []byte{1, 9, 1}},
+	}
+	_, buckets := processBuckets(fileResults)
+	pages := processPages(buckets)
+	if len(pages) != bucketCount {
+		t.Fatalf("processPages() returned %d pages, want %d", len(pages), bucketCount)
+	}
+	want := map[int]*Page{
+		1:   {BucketIndex: 1, FileHashes: []Hash{{0, 1, 2}, {0, 1, 9}}, DocumentVersion: shared.LatestDocumentVersion},
+		265: {BucketIndex: 265, FileHashes: []Hash{{1, 9, 1}}, DocumentVersion: shared.LatestDocumentVersion},
+		2:   {BucketIndex: 2, FileHashes: []Hash{}, DocumentVersion: shared.LatestDocumentVersion},
+	}
+	for idx, page := range want {
+		if !reflect.DeepEqual(pages[idx], page) {
+			t.Errorf("processPages()[%d] = %v, want %v", idx, pages[idx], page)
+		}
+	}
+}
+
+func Test_isLicenseFile(t *testing.T) {
+	tests := map[string]bool{
+		"LICENSE":         true,
+		"LICENSE.txt":     true,
+		"LICENSE-MIT":     true,
+		"LICENSE_1_0.txt": true,
+		"COPYING":         true,
+		"COPYING.LESSER":  true,
+		"NOTICE.md":       true,
+		"UNLICENSE":       true,
+		"license.c":       false,
+		"license_check.h": false,
+		"copyright.cc":    false,
+		"notices.txt":     true,
+		"README.md":       false,
+		"main.c":          false,
+	}
+	for name, want := range tests {
+		if got := isLicenseFile(name); got != want {
+			t.Errorf("isLicenseFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func Test_isLicenseBoilerplate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{
+			name: "SPDX header only",
+			src:  "// SPDX-License-Identifier: Apache-2.0\n// Copyright 2024 The Authors\n",
+			want: true,
+		},
+		{
+			name: "license block only",
+			src:  "/*\n * Licensed under the Apache License, Version 2.0 (the \"License\");\n * you may not use this file except in compliance with the License.\n */\n\n",
+			want: true,
+		},
+		{
+			name: "license header and code",
+			src:  "// SPDX-License-Identifier: MIT\n#include <stdio.h>\nint main(void) { return 0; }\n",
+		},
+		{
+			name: "comments without a license",
+			src:  "/* Intentionally empty. */\n",
+		},
+		{
+			name: "empty",
+			src:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLicenseBoilerplate([]byte(tt.src)); got != tt.want {
+				t.Errorf("isLicenseBoilerplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	repoDir := t.TempDir()
+	for p, content := range map[string]string{
+		"src/a.c":                    "int a;",
+		"src/a.h":                    "int a();",
+		"README.md":                  "readme",
+		"third_party/zlib/inflate.c": "int inflate;",
+		"third_party/other/b.c":      "int b;",
+		"LICENSES/MIT.c":             "int mit;",
+	} {
+		full := filepath.Join(repoDir, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub := filepath.Join(repoDir, "third_party", "zlib")
+	skip := map[string]bool{sub: true}
+
+	paths := func(results []*FileResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Submodule+":"+r.Path)
+		}
+		return out
+	}
+	got, err := hashFiles(repoDir, repoDir, "", []string{".c", ".h"}, skip)
+	if err != nil {
+		t.Fatalf("hashFiles() error: %v", err)
+	}
+	if want := []string{":/src/a.c", ":/src/a.h"}; !reflect.DeepEqual(paths(got), want) {
+		t.Errorf("hashFiles() = %v, want %v", paths(got), want)
+	}
+	// The submodule is hashed on its own, even in a vendored library
+	// directory.
+	got, err = hashFiles(repoDir, sub, "third_party/zlib", []string{".c", ".h"}, skip)
+	if err != nil {
+		t.Fatalf("hashFiles() error: %v", err)
+	}
+	if want := []string{"third_party/zlib:/third_party/zlib/inflate.c"}; !reflect.DeepEqual(paths(got), want) {
+		t.Errorf("hashFiles() = %v, want %v", paths(got), want)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+	"github.com/google/osv.dev/gcp/indexer/shared"
+	"github.com/google/osv.dev/gcp/indexer/stages/export"
+	"github.com/google/osv.dev/gcp/indexer/stages/processing"
+	"google.golang.org/api/iterator"
+)
+
+// ExportDocuments calls fn with each document indexed at the latest document
+// version, with its buckets and pages.
+func (s *Store) ExportDocuments(ctx context.Context, hashType string, fn func(*export.Document) error) error {
+	it := s.dsCl.Run(ctx, datastore.NewQuery(docKind))
+	for {
+		doc := &document{}
+		key, err := it.Next(doc)
+		if err == iterator.Done {
+			return nil
+		}
+		metrics.Datastore(ctx, "next", err)
+		if err != nil {
+			return err
+		}
+		if doc.FileHashType != hashType || doc.DocumentVersion != shared.LatestDocumentVersion {
+			continue
+		}
+
+		var buckets []*processing.BucketNode
+		bucketKeys, err := s.dsCl.GetAll(ctx, datastore.NewQuery(bucketKind).Ancestor(key), &buckets)
+		metrics.Datastore(ctx, "get_all", err)
+		if err != nil {
+			return err
+		}
+		var stored []*storedPage
+		pageKeys, err := s.dsCl.GetAll(ctx, datastore.NewQuery(pageKind).Ancestor(key), &stored)
+		metrics.Datastore(ctx, "get_all", err)
+		if err != nil {
+			return err
+		}
+		pages := make([]*processing.Page, len(stored))
+		for i, sp := range stored {
+			if pages[i], err = sp.page(); err != nil {
+				return fmt.Errorf("failed to read page %v: %v", pageKeys[i], err)
+			}
+		}
+		if err := fn(newExportDocument(key, doc, bucketKeys, buckets, pageKeys, pages)); err != nil {
+			return err
+		}
+	}
+}
+
+// newExportDocument returns the snapshot document of a stored document. Each
+// page is the child of its bucket, so buckets are matched to their pages by
+// key. Buckets and pages of older document versions are skipped.
+func newExportDocument(key *datastore.Key, doc *document, bucketKeys []*datastore.Key, buckets []*processing.BucketNode, pageKeys []*datastore.Key, pages []*processing.Page) *export.Document {
+	pagesByBucket := make(map[string]*processing.Page)
+	for i, page := range pages {
+		if page.DocumentVersion == shared.LatestDocumentVersion && pageKeys[i].Parent != nil {
+			pagesByBucket[pageKeys[i].Parent.Name] = page
+		}
+	}
+	out := &export.Document{
+		Key:               key.Name,
+		Name:              doc.Name,
+		RepoAddr:          doc.RepoAddr,
+		Tag:               doc.Tag,
+		Commit:            hex.EncodeToString(doc.Commit),
+		FileCount:         doc.FileCount,
+		EmptyBucketBitmap: doc.EmptyBucketBitmap,
+		Buckets:           []export.Bucket{},
+	}
+	for i, bucket := range buckets {
+		if bucket.DocumentVersion != shared.LatestDocumentVersion || bucket.FilesContained == 0 {
+			continue
+		}
+		b := export.Bucket{
+			Index:    -1,
+			NodeHash: hex.EncodeToString(bucket.NodeHash),
+			Files:    bucket.FilesContained,
+		}
+		if page, ok := pagesByBucket[bucketKeys[i].Name]; ok {
+			b.Index = page.BucketIndex
+			for _, h := range page.FileHashes {
+				b.FileHashes = append(b.FileHashes, hex.EncodeToString(h))
+			}
+		}
+		out.Buckets = append(out.Buckets, b)
+	}
+	return out
+}
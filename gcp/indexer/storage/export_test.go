@@ -0,0 +1,73 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/osv.dev/gcp/indexer/shared"
+	"github.com/google/osv.dev/gcp/indexer/stages/export"
+	"github.com/google/osv.dev/gcp/indexer/stages/processing"
+)
+
+func TestNewExportDocument(t *testing.T) {
+	docKey := datastore.NameKey(docKind, "addr-MD5-0102", nil)
+	bucketKey := func(name string) *datastore.Key { return datastore.NameKey(bucketKind, name, docKey) }
+	doc := &document{
+		Name:              "lib",
+		RepoAddr:          "https://example.com/lib",
+		Tag:               "refs/tags/v1",
+		Commit:            []byte{0xab, 0xcd},
+		FileCount:         3,
+		EmptyBucketBitmap: []byte{0x05},
+	}
+	bucketKeys := []*datastore.Key{bucketKey("b0"), bucketKey("b2"), bucketKey("old"), bucketKey("nopage")}
+	buckets := []*processing.BucketNode{
+		{NodeHash: []byte{0x01}, FilesContained: 1, DocumentVersion: shared.LatestDocumentVersion},
+		{NodeHash: []byte{0x02}, FilesContained: 2, DocumentVersion: shared.LatestDocumentVersion},
+		{NodeHash: []byte{0x03}, FilesContained: 1, DocumentVersion: shared.LatestDocumentVersion - 1},
+		{NodeHash: []byte{0x04}, FilesContained: 1, DocumentVersion: shared.LatestDocumentVersion},
+	}
+	pageKeys := []*datastore.Key{
+		datastore.NameKey(pageKind, "0", bucketKey("b0")),
+		datastore.NameKey(pageKind, "2", bucketKey("b2")),
+	}
+	pages := []*processing.Page{
+		{BucketIndex: 0, FileHashes: []processing.Hash{{0xaa}}, DocumentVersion: shared.LatestDocumentVersion},
+		{BucketIndex: 2, FileHashes: []processing.Hash{{0xbb}, {0xcc}}, DocumentVersion: shared.LatestDocumentVersion},
+	}
+
+	got := newExportDocument(docKey, doc, bucketKeys, buckets, pageKeys, pages)
+	want := &export.Document{
+		Key:               "addr-MD5-0102",
+		Name:              "lib",
+		RepoAddr:          "https://example.com/lib",
+		Tag:               "refs/tags/v1",
+		Commit:            "abcd",
+		FileCount:         3,
+		EmptyBucketBitmap: []byte{0x05},
+		Buckets: []export.Bucket{
+			{Index: 0, NodeHash: "01", Files: 1, FileHashes: []string{"aa"}},
+			{Index: 2, NodeHash: "02", Files: 2, FileHashes: []string{"bb", "cc"}},
+			{Index: -1, NodeHash: "04", Files: 1},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("newExportDocument() mismatch (-want +got):\n%s", diff)
+	}
+}
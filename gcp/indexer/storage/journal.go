@@ -0,0 +1,127 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
+	"github.com/google/osv.dev/gcp/indexer/stages/preparation"
+)
+
+// pendingKind is the kind of the journal of work items: versions published
+// for processing that haven't been stored yet. It's keyed like the documents.
+const pendingKind = "RepoIndexPending"
+
+// DefaultPendingLease is how long a version published for processing is
+// taken to still be queued or in progress. After that, its message is taken
+// to be lost, and it's published again.
+const DefaultPendingLease = 24 * time.Hour
+
+// pending is a work item of the journal.
+type pending struct {
+	Name   string    `datastore:"name,noindex"`
+	Tag    string    `datastore:"tag,noindex"`
+	Queued time.Time `datastore:"queued"`
+	// Attempts counts the times processing the version failed, and
+	// LastError is the error of the last.
+	Attempts  int    `datastore:"attempts,noindex"`
+	LastError string `datastore:"last_error,noindex"`
+	// Failed marks work items whose last attempt failed, so they are
+	// published again by the next run.
+	Failed bool `datastore:"failed,noindex"`
+}
+
+// active returns whether the work item is still queued or in progress.
+func (p *pending) active(now time.Time, lease time.Duration) bool {
+	return !p.Failed && now.Sub(p.Queued) < lease
+}
+
+func pendingKey(addr, hashType string, hash plumbing.Hash) *datastore.Key {
+	return datastore.NameKey(pendingKind, fmt.Sprintf(docKeyFmt, addr, hashType, hash[:]), nil)
+}
+
+// Pending returns whether a version has been published for processing
+// within the lease and is yet to be stored, so it needn't be published
+// again.
+func (s *Store) Pending(ctx context.Context, addr, hashType string, hash plumbing.Hash) (bool, error) {
+	p := &pending{}
+	err := s.dsCl.Get(ctx, pendingKey(addr, hashType, hash), p)
+	if err == datastore.ErrNoSuchEntity {
+		metrics.Datastore(ctx, "get", nil)
+		return false, nil
+	}
+	metrics.Datastore(ctx, "get", err)
+	if err != nil {
+		return false, err
+	}
+	lease := s.PendingLease
+	if lease == 0 {
+		lease = DefaultPendingLease
+	}
+	return p.active(time.Now(), lease), nil
+}
+
+// MarkPending records that a version was published for processing.
+func (s *Store) MarkPending(ctx context.Context, repoInfo *preparation.Result, hashType string) error {
+	key := pendingKey(repoInfo.Addr, hashType, repoInfo.Reference)
+	_, err := s.dsCl.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		p := &pending{}
+		if err := tx.Get(key, p); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		p.Name = repoInfo.Name
+		p.Tag = repoInfo.CommitTag
+		p.Queued = time.Now()
+		p.Failed = false
+		_, err := tx.Put(key, p)
+		return err
+	})
+	metrics.Datastore(ctx, "put", err)
+	return err
+}
+
+// MarkDone removes a version that was processed from the journal.
+func (s *Store) MarkDone(ctx context.Context, repoInfo *preparation.Result, hashType string) error {
+	err := s.dsCl.Delete(ctx, pendingKey(repoInfo.Addr, hashType, repoInfo.Reference))
+	metrics.Datastore(ctx, "delete", err)
+	return err
+}
+
+// MarkFailed records that processing a version failed, so the next run
+// publishes it again.
+func (s *Store) MarkFailed(ctx context.Context, repoInfo *preparation.Result, hashType string, procErr error) error {
+	key := pendingKey(repoInfo.Addr, hashType, repoInfo.Reference)
+	_, err := s.dsCl.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		p := &pending{}
+		if err := tx.Get(key, p); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		p.Name = repoInfo.Name
+		p.Tag = repoInfo.CommitTag
+		p.Attempts++
+		p.LastError = procErr.Error()
+		p.Failed = true
+		_, err := tx.Put(key, p)
+		return err
+	})
+	metrics.Datastore(ctx, "put", err)
+	return err
+}
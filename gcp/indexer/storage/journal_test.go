@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingActive(t *testing.T) {
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name    string
+		pending pending
+		want    bool
+	}{
+		{"queued within lease", pending{Queued: now.Add(-time.Hour)}, true},
+		{"lease expired", pending{Queued: now.Add(-25 * time.Hour)}, false},
+		{"failed", pending{Queued: now.Add(-time.Hour), Failed: true, Attempts: 1}, false},
+	} {
+		if got := tc.pending.active(now, DefaultPendingLease); got != tc.want {
+			t.Errorf("%s: active() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/osv.dev/gcp/indexer/stages/processing"
+)
+
+// packedDeflate is the format of packed hashes: the sorted hashes of a page,
+// each encoded as the length of the prefix it shares with the previous one
+// followed by the rest of it, compressed with DEFLATE.
+const packedDeflate = 1
+
+// storedPage is the datastore entity of a processing.Page. The hashes of
+// pages written before they were packed, or with Store.LegacyPages, are in
+// FileHashes; those of the others are in PackedHashes.
+type storedPage struct {
+	BucketIndex     int               `datastore:"bucket_index,noindex"`
+	FileHashes      []processing.Hash `datastore:"file_hashes,noindex"`
+	PackedHashes    []byte            `datastore:"packed_hashes,noindex"`
+	DocumentVersion int               `datastore:"document_version,noindex"`
+}
+
+// newStoredPage returns the entity of a page, with its hashes packed unless
+// legacy is set.
+func newStoredPage(page *processing.Page, legacy bool) (*storedPage, error) {
+	sp := &storedPage{
+		BucketIndex:     page.BucketIndex,
+		DocumentVersion: page.DocumentVersion,
+	}
+	if legacy {
+		sp.FileHashes = page.FileHashes
+		return sp, nil
+	}
+	packed, err := packHashes(page.FileHashes)
+	if err != nil {
+		return nil, err
+	}
+	sp.PackedHashes = packed
+	return sp, nil
+}
+
+// page returns the page of an entity, in either format.
+func (sp *storedPage) page() (*processing.Page, error) {
+	page := &processing.Page{
+		BucketIndex:     sp.BucketIndex,
+		FileHashes:      sp.FileHashes,
+		DocumentVersion: sp.DocumentVersion,
+	}
+	if len(sp.PackedHashes) == 0 {
+		return page, nil
+	}
+	hashes, err := unpackHashes(sp.PackedHashes)
+	if err != nil {
+		return nil, err
+	}
+	page.FileHashes = hashes
+	return page, nil
+}
+
+// packHashes encodes hashes in the packedDeflate format. They're encoded in
+// the order given, which only packs well when they're sorted, as the hashes
+// of a page are.
+func packHashes(hashes []processing.Hash) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(packedDeflate)
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	var scratch [binary.MaxVarintLen64]byte
+	write := func(v int) error {
+		_, err := w.Write(scratch[:binary.PutUvarint(scratch[:], uint64(v))])
+		return err
+	}
+	if err := write(len(hashes)); err != nil {
+		return nil, err
+	}
+	var prev processing.Hash
+	for _, h := range hashes {
+		shared := 0
+		for shared < len(h) && shared < len(prev) && h[shared] == prev[shared] {
+			shared++
+		}
+		if err := write(shared); err != nil {
+			return nil, err
+		}
+		if err := write(len(h) - shared); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(h[shared:]); err != nil {
+			return nil, err
+		}
+		prev = h
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unpackHashes decodes hashes packed by packHashes.
+func unpackHashes(packed []byte) ([]processing.Hash, error) {
+	if len(packed) == 0 || packed[0] != packedDeflate {
+		return nil, errors.New("unknown packed hashes format")
+	}
+	r := bufio.NewReader(flate.NewReader(bytes.NewReader(packed[1:])))
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid packed hashes: %v", err)
+	}
+	hashes := make([]processing.Hash, 0, min(count, 1<<16))
+	var prev processing.Hash
+	for range count {
+		shared, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packed hashes: %v", err)
+		}
+		rest, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packed hashes: %v", err)
+		}
+		if shared > uint64(len(prev)) || rest > 1<<16 {
+			return nil, errors.New("invalid packed hashes: lengths out of range")
+		}
+		h := make(processing.Hash, int(shared)+int(rest))
+		copy(h, prev[:shared])
+		if _, err := io.ReadFull(r, h[shared:]); err != nil {
+			return nil, fmt.Errorf("invalid packed hashes: %v", err)
+		}
+		hashes = append(hashes, h)
+		prev = h
+	}
+	return hashes, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/osv.dev/gcp/indexer/shared"
+	"github.com/google/osv.dev/gcp/indexer/stages/processing"
+)
+
+func TestPackHashes(t *testing.T) {
+	var hashes []processing.Hash
+	for i := range 1000 {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		h := md5.Sum(buf[:])
+		hashes = append(hashes, h[:])
+	}
+	for _, tc := range [][]processing.Hash{
+		nil,
+		{{0xaa}},
+		{{0xaa, 0xbb}, {0xaa, 0xbb, 0xcc}, {0xaa}, {}},
+		hashes,
+	} {
+		packed, err := packHashes(tc)
+		if err != nil {
+			t.Fatalf("packHashes() error: %v", err)
+		}
+		got, err := unpackHashes(packed)
+		if err != nil {
+			t.Fatalf("unpackHashes() error: %v", err)
+		}
+		if diff := cmp.Diff(tc, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("unpackHashes(packHashes()) mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	for _, invalid := range [][]byte{nil, {0x02}, {packedDeflate, 0x01, 0x02}} {
+		if _, err := unpackHashes(invalid); err == nil {
+			t.Errorf("unpackHashes(%x) succeeded", invalid)
+		}
+	}
+}
+
+func TestStoredPage(t *testing.T) {
+	page := &processing.Page{
+		BucketIndex:     3,
+		FileHashes:      []processing.Hash{{0xaa, 0x01}, {0xaa, 0x02}},
+		DocumentVersion: shared.LatestDocumentVersion,
+	}
+	for _, legacy := range []bool{false, true} {
+		sp, err := newStoredPage(page, legacy)
+		if err != nil {
+			t.Fatalf("newStoredPage(legacy=%v) error: %v", legacy, err)
+		}
+		if packed := len(sp.PackedHashes) != 0; packed == legacy || (len(sp.FileHashes) != 0) != legacy {
+			t.Errorf("newStoredPage(legacy=%v) = %+v, want hashes in one format", legacy, sp)
+		}
+		got, err := sp.page()
+		if err != nil {
+			t.Fatalf("page() error: %v", err)
+		}
+		if diff := cmp.Diff(page, got); diff != "" {
+			t.Errorf("page() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -18,24 +18,38 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/osv.dev/gcp/indexer/manifest"
+	"github.com/google/osv.dev/gcp/indexer/metrics"
 	"github.com/google/osv.dev/gcp/indexer/shared"
 	"github.com/google/osv.dev/gcp/indexer/stages/preparation"
 	"github.com/google/osv.dev/gcp/indexer/stages/processing"
+
+	log "github.com/golang/glog"
 )
 
 const (
 	docKind    = "RepoIndex"
 	bucketKind = "RepoIndexBucket"
+	pageKind   = "RepoIndexBucketPage"
 	// Address-HashType-ReferenceHash
 	docKeyFmt = "%s-%s-%x"
 	// BucketHash-HashType-NumberOfFiles
-	bucketKeyFmt            = "%x-%s-%d"
+	bucketKeyFmt = "%x-%s-%d"
+	// BucketIndex
+	pageKeyFmt              = "%d"
 	datastoreMultiEntrySize = 490
 )
 
@@ -45,6 +59,7 @@ type document struct {
 	BaseCPE           string    `datastore:"base_cpe"`
 	Commit            []byte    `datastore:"commit"`
 	Tag               string    `datastore:"tag"`
+	RefType           string    `datastore:"ref_type,omitempty"`
 	Version           string    `datastore:"version,omitempty"` // Deprecated: version is no longer used in favour of tags
 	When              time.Time `datastore:"when,omitempty"`
 	RepoType          string    `datastore:"repo_type"`
@@ -54,6 +69,29 @@ type document struct {
 	EmptyBucketBitmap []byte    `datastore:"empty_bucket_bitmap"`
 	FileCount         int       `datastore:"file_count"`
 	DocumentVersion   int       `datastore:"document_version"`
+	// PagesHash is a hash of the bucket nodes stored with the document, used
+	// to skip rewriting documents whose pages haven't changed.
+	PagesHash []byte `datastore:"pages_hash,noindex"`
+}
+
+// pagesHash returns a hash of the non-empty bucket nodes, in order.
+func pagesHash(treeNodes []*processing.BucketNode) []byte {
+	h := sha256.New()
+	for i, node := range treeNodes {
+		if node.FilesContained == 0 {
+			continue
+		}
+		binary.Write(h, binary.BigEndian, int64(i))
+		binary.Write(h, binary.BigEndian, int64(node.FilesContained))
+		h.Write(node.NodeHash)
+	}
+	return h.Sum(nil)
+}
+
+// unchanged reports whether an existing document is identical to doc,
+// including the hash of its pages.
+func unchanged(existing, doc *document) bool {
+	return existing.PagesHash != nil && cmp.Equal(existing, doc, cmpopts.EquateEmpty())
 }
 
 func newDoc(repoInfo *preparation.Result, hashType string) *document {
@@ -62,6 +100,7 @@ func newDoc(repoInfo *preparation.Result, hashType string) *document {
 		BaseCPE:           repoInfo.BaseCPE,
 		Commit:            repoInfo.Commit[:],
 		Tag:               repoInfo.CommitTag,
+		RefType:           repoInfo.RefType,
 		When:              repoInfo.When,
 		RepoType:          repoInfo.Type,
 		RepoAddr:          repoInfo.Addr,
@@ -77,8 +116,31 @@ func newDoc(repoInfo *preparation.Result, hashType string) *document {
 // Store provides the functionality to check for existing documents
 // in datastore and add new ones.
 type Store struct {
+	// PendingLease is how long versions in the journal of work items are
+	// taken to be in progress, DefaultPendingLease if 0.
+	PendingLease time.Duration
+	// LegacyPages makes pages be stored with their hashes unpacked, for
+	// readers that don't read packed hashes yet. Pages in either format
+	// are read.
+	LegacyPages bool
+
 	dsCl  *datastore.Client
 	cache sync.Map
+
+	written atomic.Int64
+	skipped atomic.Int64
+}
+
+// Counters counts the documents a Store has written, and skipped because
+// they were unchanged.
+type Counters struct {
+	Written int64
+	Skipped int64
+}
+
+// Counters returns the documents written and skipped so far.
+func (s *Store) Counters() Counters {
+	return Counters{Written: s.written.Load(), Skipped: s.skipped.Load()}
 }
 
 // New returns a new Store.
@@ -101,24 +163,51 @@ func (s *Store) Exists(ctx context.Context, addr string, hashType string, hash p
 	// by plumbing.Hash String()
 	key := datastore.NameKey(docKind, fmt.Sprintf(docKeyFmt, addr, hashType, hash[:]), nil)
 	tmp := &document{}
-	if err := s.dsCl.Get(ctx, key, tmp); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			return false, nil
-		}
+	err := s.dsCl.Get(ctx, key, tmp)
+	if err == datastore.ErrNoSuchEntity {
+		metrics.Datastore(ctx, "get", nil)
+		return false, nil
+	}
+	metrics.Datastore(ctx, "get", err)
+	if err != nil {
 		return false, err
 	}
 	s.cache.Store(fmt.Sprintf(docKeyFmt, addr, hashType, hash[:]), true)
 	return tmp.DocumentVersion == shared.LatestDocumentVersion, nil
 }
 
-// Store stores a new entry in datastore.
-func (s *Store) Store(ctx context.Context, repoInfo *preparation.Result, hashType string, treeNodes []*processing.BucketNode) error {
+// Store stores a new entry in datastore. Entries identical to the stored
+// one, including the hash of their pages, are skipped.
+func (s *Store) Store(ctx context.Context, repoInfo *preparation.Result, hashType string, treeNodes []*processing.BucketNode, pages []*processing.Page) (err error) {
+	done := metrics.Begin(ctx, metrics.Storage, repoInfo.Name)
+	skipped := false
+	defer func() { done(skipped, err) }()
+
 	docKey := datastore.NameKey(docKind, fmt.Sprintf(docKeyFmt, repoInfo.Addr, hashType, repoInfo.Reference[:]), nil)
+	doc := newDoc(repoInfo, hashType)
+	doc.PagesHash = pagesHash(treeNodes)
+
+	existing := &document{}
+	if err := s.dsCl.Get(ctx, docKey, existing); err == nil {
+		metrics.Datastore(ctx, "get", nil)
+		if unchanged(existing, doc) {
+			skipped = true
+			log.Infof("skipped unchanged document %v (%d written, %d skipped)", docKey.Name, s.written.Load(), s.skipped.Add(1))
+			return nil
+		}
+	} else if err != datastore.ErrNoSuchEntity {
+		metrics.Datastore(ctx, "get", err)
+		return err
+	} else {
+		metrics.Datastore(ctx, "get", nil)
+	}
 
 	// There are slightly too many items to put in a transaction (max 500 entries per transaction)
 	putMultiKeys := []*datastore.Key{}
 	putMultiNodes := []*processing.BucketNode{}
-	for _, node := range treeNodes {
+	putMultiPageKeys := []*datastore.Key{}
+	putMultiPages := []*storedPage{}
+	for i, node := range treeNodes {
 		if node.FilesContained == 0 {
 			continue
 		}
@@ -131,6 +220,14 @@ func (s *Store) Store(ctx context.Context, repoInfo *preparation.Result, hashTyp
 
 		putMultiKeys = append(putMultiKeys, bucketKey)
 		putMultiNodes = append(putMultiNodes, node)
+		if i < len(pages) {
+			page, err := newStoredPage(pages[i], s.LegacyPages)
+			if err != nil {
+				return fmt.Errorf("failed to pack page %d: %v", i, err)
+			}
+			putMultiPageKeys = append(putMultiPageKeys, datastore.NameKey(pageKind, fmt.Sprintf(pageKeyFmt, i), bucketKey))
+			putMultiPages = append(putMultiPages, page)
+		}
 	}
 
 	// Batch Puts into datastoreMultiEntrySize chunks
@@ -141,6 +238,20 @@ func (s *Store) Store(ctx context.Context, repoInfo *preparation.Result, hashTyp
 		}
 
 		_, err := s.dsCl.PutMulti(ctx, putMultiKeys[i:end], putMultiNodes[i:end])
+		metrics.Datastore(ctx, "put_multi", err)
+		if err != nil {
+			return err
+		}
+	}
+	// The pages are children of their buckets, and are stored after them.
+	for i := 0; i < len(putMultiPageKeys); i += datastoreMultiEntrySize {
+		end := i + datastoreMultiEntrySize
+		if end > len(putMultiPageKeys) {
+			end = len(putMultiPageKeys)
+		}
+
+		_, err := s.dsCl.PutMulti(ctx, putMultiPageKeys[i:end], putMultiPages[i:end])
+		metrics.Datastore(ctx, "put_multi", err)
 		if err != nil {
 			return err
 		}
@@ -148,11 +259,13 @@ func (s *Store) Store(ctx context.Context, repoInfo *preparation.Result, hashTyp
 
 	// Leave the repoIndex entry to last so that if previous input fails
 	// the controller will try again
-	doc := newDoc(repoInfo, hashType)
-	_, err := s.dsCl.Put(ctx, docKey, doc)
+	_, err = s.dsCl.Put(ctx, docKey, doc)
+	metrics.Datastore(ctx, "put", err)
 	if err != nil {
 		return err
 	}
+	written := s.written.Add(1)
+	log.Infof("stored document %v (%d written, %d skipped)", docKey.Name, written, s.skipped.Load())
 
 	return nil
 }
@@ -166,6 +279,7 @@ func (s *Store) Clean(ctx context.Context, repoInfo *preparation.Result, hashTyp
 	bucketHashes := []*processing.BucketNode{}
 	// GetAll should never return more than 2x the max number of buckets (512*2 = 1024) results.
 	bucketKeys, err := s.dsCl.GetAll(ctx, query, &bucketHashes)
+	metrics.Datastore(ctx, "get_all", err)
 
 	if err != nil {
 		return err
@@ -177,11 +291,70 @@ func (s *Store) Clean(ctx context.Context, repoInfo *preparation.Result, hashTyp
 			keysToDelete = append(keysToDelete, key)
 		}
 	}
+
+	pages := []*storedPage{}
+	pageKeys, err := s.dsCl.GetAll(ctx, datastore.NewQuery(pageKind).Ancestor(docKey), &pages)
+	metrics.Datastore(ctx, "get_all", err)
+	if err != nil {
+		return err
+	}
+	for i, key := range pageKeys {
+		if pages[i].DocumentVersion != shared.LatestDocumentVersion {
+			keysToDelete = append(keysToDelete, key)
+		}
+	}
 	err = s.dsCl.DeleteMulti(ctx, keysToDelete)
+	metrics.Datastore(ctx, "delete_multi", err)
 
 	return err
 }
 
+// Manifest returns the manifest of the versions of a repository indexed at
+// the latest document version.
+func (s *Store) Manifest(ctx context.Context, name, addr, hashType string) (*manifest.Manifest, error) {
+	query := datastore.NewQuery(docKind).FilterField("repo_addr", "=", addr)
+	var docs []*document
+	keys, err := s.dsCl.GetAll(ctx, query, &docs)
+	metrics.Datastore(ctx, "get_all", err)
+	if err != nil {
+		return nil, err
+	}
+	return newManifest(name, addr, hashType, keys, docs), nil
+}
+
+// ExportManifest writes the manifest of the versions of a repository to the
+// bucket, so they can be enumerated without scanning all its documents.
+func (s *Store) ExportManifest(ctx context.Context, bucketHdl *storage.BucketHandle, name, addr, hashType string) (*manifest.Manifest, error) {
+	m, err := s.Manifest(ctx, name, addr, hashType)
+	if err != nil {
+		return nil, err
+	}
+	return m, manifest.Write(ctx, bucketHdl, m)
+}
+
+func newManifest(name, addr, hashType string, keys []*datastore.Key, docs []*document) *manifest.Manifest {
+	m := &manifest.Manifest{Name: name, RepoAddr: addr}
+	for i, doc := range docs {
+		if doc.FileHashType != hashType || doc.DocumentVersion != shared.LatestDocumentVersion {
+			continue
+		}
+		if m.BaseCPE == "" {
+			m.BaseCPE = doc.BaseCPE
+		}
+		m.Versions = append(m.Versions, manifest.Version{
+			Tag:        doc.Tag,
+			RefType:    doc.RefType,
+			Commit:     hex.EncodeToString(doc.Commit),
+			FileCount:  doc.FileCount,
+			When:       doc.When,
+			Key:        keys[i].Name,
+			PageBitmap: hex.EncodeToString(doc.EmptyBucketBitmap),
+		})
+	}
+	m.Sort()
+	return m
+}
+
 // Close closes the datastore client.
 func (s *Store) Close() {
 	s.dsCl.Close()
@@ -17,10 +17,15 @@ limitations under the License.
 package storage
 
 import (
+	"bytes"
 	"testing"
 
+	"cloud.google.com/go/datastore"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/osv.dev/gcp/indexer/manifest"
+	"github.com/google/osv.dev/gcp/indexer/shared"
 	"github.com/google/osv.dev/gcp/indexer/stages/preparation"
+	"github.com/google/osv.dev/gcp/indexer/stages/processing"
 )
 
 func getRepoInfo(t *testing.T) *preparation.Result {
@@ -70,3 +75,103 @@ func TestNewDoc(t *testing.T) {
 		}
 	}
 }
+
+func TestPagesHash(t *testing.T) {
+	nodes := []*processing.BucketNode{
+		{NodeHash: []byte{0x01}, FilesContained: 1},
+		{NodeHash: []byte{}, FilesContained: 0},
+		{NodeHash: []byte{0x02}, FilesContained: 2},
+	}
+	same := []*processing.BucketNode{
+		{NodeHash: []byte{0x01}, FilesContained: 1},
+		{NodeHash: []byte{0xff}, FilesContained: 0},
+		{NodeHash: []byte{0x02}, FilesContained: 2},
+	}
+	changed := []*processing.BucketNode{
+		{NodeHash: []byte{0x01}, FilesContained: 1},
+		{NodeHash: []byte{}, FilesContained: 0},
+		{NodeHash: []byte{0x03}, FilesContained: 2},
+	}
+	moved := []*processing.BucketNode{
+		{NodeHash: []byte{0x01}, FilesContained: 1},
+		{NodeHash: []byte{0x02}, FilesContained: 2},
+		{NodeHash: []byte{}, FilesContained: 0},
+	}
+	if !bytes.Equal(pagesHash(nodes), pagesHash(same)) {
+		t.Errorf("pagesHash() differs for pages differing only in empty buckets")
+	}
+	for name, other := range map[string][]*processing.BucketNode{"changed": changed, "moved": moved} {
+		if bytes.Equal(pagesHash(nodes), pagesHash(other)) {
+			t.Errorf("pagesHash() is the same for %s pages", name)
+		}
+	}
+}
+
+func TestUnchanged(t *testing.T) {
+	newDocWithPages := func(hash []byte) *document {
+		doc := newDoc(getRepoInfo(t), "MD5")
+		doc.PagesHash = hash
+		return doc
+	}
+	for _, tc := range []struct {
+		name     string
+		existing *document
+		want     bool
+	}{
+		{
+			name:     "identical",
+			existing: newDocWithPages([]byte{0x01}),
+			want:     true,
+		},
+		{
+			name:     "different pages",
+			existing: newDocWithPages([]byte{0x02}),
+			want:     false,
+		},
+		{
+			name:     "stored before pages were hashed",
+			existing: newDocWithPages(nil),
+			want:     false,
+		},
+		{
+			name: "older document version",
+			existing: func() *document {
+				doc := newDocWithPages([]byte{0x01})
+				doc.DocumentVersion--
+				return doc
+			}(),
+			want: false,
+		},
+	} {
+		if got := unchanged(tc.existing, newDocWithPages([]byte{0x01})); got != tc.want {
+			t.Errorf("%s: unchanged() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNewManifest(t *testing.T) {
+	docs := []*document{
+		{Name: "abc", BaseCPE: "cpe", Tag: "refs/tags/v2", RefType: "tag", Commit: []byte{0xbb}, FileCount: 2, FileHashType: "MD5", EmptyBucketBitmap: []byte{0x03}, DocumentVersion: shared.LatestDocumentVersion},
+		{Name: "abc", BaseCPE: "cpe", Tag: "refs/tags/v1", RefType: "tag", Commit: []byte{0xaa}, FileCount: 1, FileHashType: "MD5", EmptyBucketBitmap: []byte{0x01}, DocumentVersion: shared.LatestDocumentVersion},
+		// Outdated documents are about to be reindexed.
+		{Name: "abc", BaseCPE: "cpe", Tag: "refs/tags/v0", Commit: []byte{0x99}, FileCount: 1, FileHashType: "MD5", DocumentVersion: shared.LatestDocumentVersion - 1},
+	}
+	want := &manifest.Manifest{
+		Name:     "abc",
+		RepoAddr: "example.com/abc",
+		BaseCPE:  "cpe",
+		Versions: []manifest.Version{
+			{Tag: "refs/tags/v1", RefType: "tag", Commit: "aa", FileCount: 1, Key: "k1", PageBitmap: "01"},
+			{Tag: "refs/tags/v2", RefType: "tag", Commit: "bb", FileCount: 2, Key: "k2", PageBitmap: "03"},
+		},
+	}
+	keys := []*datastore.Key{
+		datastore.NameKey(docKind, "k2", nil),
+		datastore.NameKey(docKind, "k1", nil),
+		datastore.NameKey(docKind, "k0", nil),
+	}
+	got := newManifest("abc", "example.com/abc", "MD5", keys, docs)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("newManifest() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+}
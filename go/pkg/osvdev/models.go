@@ -1,6 +1,10 @@
 package osvdev
 
-import "github.com/ossf/osv-schema/bindings/go/osvschema"
+import (
+	"time"
+
+	"github.com/ossf/osv-schema/bindings/go/osvschema"
+)
 
 // Package represents a package identifier for OSV.
 type Package struct {
@@ -74,3 +78,23 @@ type DetermineVersionsRequest struct {
 	Name       string                 `json:"name"`
 	FileHashes []DetermineVersionHash `json:"file_hashes"`
 }
+
+// IndexedVersion is a version of a repository indexed for determineversion.
+type IndexedVersion struct {
+	Tag        string    `json:"tag"`
+	RefType    string    `json:"ref_type,omitempty"`
+	Commit     string    `json:"commit"`
+	FileCount  int       `json:"file_count"`
+	When       time.Time `json:"when"`
+	Key        string    `json:"key"`
+	PageBitmap string    `json:"page_bitmap"`
+}
+
+// IndexManifest lists the versions of a repository indexed for determineversion,
+// as exported by the indexer.
+type IndexManifest struct {
+	Name     string           `json:"name"`
+	RepoAddr string           `json:"repo_addr"`
+	BaseCPE  string           `json:"base_cpe,omitempty"`
+	Versions []IndexedVersion `json:"versions"`
+}
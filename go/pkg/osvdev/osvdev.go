@@ -10,6 +10,7 @@ import (
 	"math"
 	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/ossf/osv-schema/bindings/go/osvschema"
@@ -24,6 +25,9 @@ const (
 	// DetermineVersionEndpoint is the URL for posting determineversion queries to OSV.
 	DetermineVersionEndpoint = "/v1experimental/determineversion"
 
+	// IndexManifestPath is the path under IndexManifestBaseURL of the indexer's manifests.
+	IndexManifestPath = "manifests"
+
 	// MaxQueriesPerQueryBatchRequest is a limit set in osv.dev's API, so is not configurable
 	MaxQueriesPerQueryBatchRequest = 1000
 
@@ -34,6 +38,9 @@ type OSVClient struct {
 	HTTPClient  *http.Client
 	Config      ClientConfig
 	BaseHostURL string
+	// IndexManifestBaseURL is the base URL of the bucket the indexer exports
+	// its manifests to, e.g. https://storage.googleapis.com/<bucket>.
+	IndexManifestBaseURL string
 }
 
 // DefaultClient creates a new OSVClient with default settings
@@ -225,6 +232,38 @@ func (c *OSVClient) ExperimentalDetermineVersion(ctx context.Context, query *Det
 	return &result, nil
 }
 
+// IndexManifest gets the manifest of the versions of a repository indexed for
+// determineversion, by the name of its indexer configuration.
+func (c *OSVClient) IndexManifest(ctx context.Context, name string) (*IndexManifest, error) {
+	if c.IndexManifestBaseURL == "" {
+		return nil, errors.New("no index manifest base URL configured")
+	}
+	resp, err := c.makeRetryRequest(func(client *http.Client) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.IndexManifestBaseURL+"/"+IndexManifestPath+"/"+url.PathEscape(name)+".json", nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.Config.UserAgent != "" {
+			req.Header.Set("User-Agent", c.Config.UserAgent)
+		}
+
+		return client.Do(req)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var manifest IndexManifest
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
 // makeRetryRequest will return an error on both network errors, and if the response is not 200
 func (c *OSVClient) makeRetryRequest(action func(client *http.Client) (*http.Response, error)) (*http.Response, error) {
 	var resp *http.Response
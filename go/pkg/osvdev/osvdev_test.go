@@ -2,6 +2,8 @@ package osvdev_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -411,3 +413,46 @@ func TestOSVClient_ExperimentalDetermineVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestOSVClient_IndexManifest(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manifests/protobuf.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"protobuf","repo_addr":"https://github.com/protocolbuffers/protobuf.git","versions":[` +
+			`{"tag":"refs/tags/v3.0.0","ref_type":"tag","commit":"aa","file_count":10,"when":"2016-07-27T00:00:00Z","key":"k","page_bitmap":"01"}]}`))
+	}))
+	defer srv.Close()
+
+	c := osvdev.DefaultClient()
+	c.Config.MaxRetryAttempts = 1
+	c.IndexManifestBaseURL = srv.URL
+
+	got, err := c.IndexManifest(context.Background(), "protobuf")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := &osvdev.IndexManifest{
+		Name:     "protobuf",
+		RepoAddr: "https://github.com/protocolbuffers/protobuf.git",
+		Versions: []osvdev.IndexedVersion{{
+			Tag:        "refs/tags/v3.0.0",
+			RefType:    "tag",
+			Commit:     "aa",
+			FileCount:  10,
+			When:       time.Date(2016, 7, 27, 0, 0, 0, 0, time.UTC),
+			Key:        "k",
+			PageBitmap: "01",
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected manifest (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.IndexManifest(context.Background(), "missing"); err == nil {
+		t.Errorf("Expected an error for a missing manifest")
+	}
+}
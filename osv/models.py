@@ -18,6 +18,7 @@ import enum
 import logging
 import re
 import os
+import zlib
 
 from urllib.parse import urlparse
 from typing import Self
@@ -833,6 +834,8 @@ class RepoIndex(ndb.Model):
   file_count: int = ndb.IntegerProperty()
   # Tag name of the source
   tag: str = ndb.StringProperty()
+  # The kind of reference indexed: tag, branch or commit
+  ref_type: str = ndb.StringProperty()
 
 
 class FileResult(ndb.Model):
@@ -851,6 +854,77 @@ class RepoIndexBucket(ndb.Model):
   files_contained: int = ndb.IntegerProperty()
 
 
+class RepoIndexBucketPage(ndb.Model):
+  """RepoIndexBucketPage entry, a child of a RepoIndexBucket, containing the
+  sorted file hashes of the bucket for comparing near-matching buckets file by
+  file."""
+  # The index of the bucket
+  bucket_index: int = ndb.IntegerProperty(indexed=False)
+  # The sorted file hashes in the bucket, for pages stored unpacked
+  file_hashes: list[bytes] = ndb.BlobProperty(repeated=True)
+  # The sorted file hashes in the bucket, packed by the indexer (see
+  # gcp/indexer/storage/pages.go)
+  packed_hashes: bytes = ndb.BlobProperty()
+
+  def hashes(self) -> list[bytes]:
+    """Returns the file hashes of the page, in either format.
+
+    Raises:
+      ValueError: if the packed hashes are of an unknown format, or corrupt.
+    """
+    if not self.packed_hashes:
+      return list(self.file_hashes)
+    return _unpack_hashes(self.packed_hashes)
+
+
+def _unpack_hashes(packed: bytes) -> list[bytes]:
+  """Decodes file hashes packed by the indexer.
+
+  The format (see unpackHashes in gcp/indexer/storage/pages.go) is a format
+  byte, then a raw DEFLATE stream of the uvarint count of hashes, and of each
+  hash the uvarint length of the prefix it shares with the previous one, the
+  uvarint length of the rest, and the rest.
+
+  Raises:
+    ValueError: if the packed hashes are of an unknown format, or corrupt.
+  """
+  if not packed or packed[0] != 1:
+    raise ValueError('unknown packed hashes format')
+  try:
+    # Like Go's flate reader, decodes what there is of a truncated stream.
+    data = zlib.decompressobj(wbits=-15).decompress(packed[1:])
+  except zlib.error as e:
+    raise ValueError(f'invalid packed hashes: {e}') from e
+  pos = 0
+
+  def uvarint() -> int:
+    nonlocal pos
+    value = shift = 0
+    while True:
+      if pos >= len(data) or shift > 63:
+        raise ValueError('invalid packed hashes: truncated')
+      b = data[pos]
+      pos += 1
+      value |= (b & 0x7f) << shift
+      if b < 0x80:
+        return value
+      shift += 7
+
+  hashes = []
+  prev = b''
+  for _ in range(uvarint()):
+    shared = uvarint()
+    rest = uvarint()
+    if shared > len(prev) or rest > 1 << 16:
+      raise ValueError('invalid packed hashes: lengths out of range')
+    if pos + rest > len(data):
+      raise ValueError('invalid packed hashes: truncated')
+    prev = prev[:shared] + data[pos:pos + rest]
+    pos += rest
+    hashes.append(prev)
+  return hashes
+
+
 class SourceRepositoryType(enum.IntEnum):
   """SourceRepository type."""
   GIT = 0
@@ -0,0 +1,91 @@
+# Copyright 2025 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Models tests."""
+
+import unittest
+
+from . import models
+from . import tests
+
+from google.cloud import ndb
+
+# The hashes of a page, sorted.
+_HASHES = [
+    bytes.fromhex('00112233445566778899aabbccddee00'),
+    bytes.fromhex('00112233445566778899aabbccddeeff'),
+    bytes.fromhex('0011ffeeddccbbaa9988776655443322'),
+    bytes.fromhex('ff00000000000000000000000000ffff'),
+]
+
+# _HASHES packed by packHashes in gcp/indexer/storage/pages.go.
+_PACKED_HASHES = bytes.fromhex(
+    '016261106010543276094d2bef98b96af799bbef18f819ff33f1fd7f77f7ccee55333b'
+    'cad3425d8c951804fe332083ffff010300')
+
+
+class RepoIndexBucketPageTest(unittest.TestCase):
+  """RepoIndexBucketPage tests."""
+
+  def setUp(self):
+    tests.reset_emulator()
+
+  @classmethod
+  def setUpClass(cls):
+    cls._ds_emulator = tests.start_datastore_emulator()
+
+    ndb_client = ndb.Client()
+    cls._ndb_context = ndb_client.context()
+    context = cls._ndb_context.__enter__()  # pylint: disable=unnecessary-dunder-call
+    context.set_memcache_policy(False)
+    context.set_cache_policy(False)
+
+  @classmethod
+  def tearDownClass(cls):
+    tests.stop_emulator()
+    cls._ndb_context.__exit__(None, None, None)  # pylint: disable=unnecessary-dunder-call
+
+  def test_packed_hashes(self):
+    """Test hashes of a page packed by the indexer."""
+    key = models.RepoIndexBucketPage(
+        bucket_index=1, packed_hashes=_PACKED_HASHES).put()
+    self.assertListEqual(_HASHES, key.get().hashes())
+
+  def test_legacy_hashes(self):
+    """Test hashes of a page stored unpacked."""
+    key = models.RepoIndexBucketPage(bucket_index=1, file_hashes=_HASHES).put()
+    self.assertListEqual(_HASHES, key.get().hashes())
+
+  def test_empty_page(self):
+    """Test hashes of a page without any."""
+    page = models.RepoIndexBucketPage(bucket_index=1)
+    self.assertListEqual([], page.hashes())
+
+  def test_truncated_hashes(self):
+    """Test packed hashes cut short raise ValueError."""
+    for length in (1, 2, 10, 40):
+      page = models.RepoIndexBucketPage(
+          bucket_index=1, packed_hashes=_PACKED_HASHES[:length])
+      with self.subTest(length=length), self.assertRaises(ValueError):
+        page.hashes()
+
+  def test_unknown_format(self):
+    """Test packed hashes of an unknown format raise ValueError."""
+    page = models.RepoIndexBucketPage(
+        bucket_index=1, packed_hashes=b'\x02' + _PACKED_HASHES[1:])
+    with self.assertRaises(ValueError):
+      page.hashes()
+
+
+if __name__ == '__main__':
+  unittest.main()
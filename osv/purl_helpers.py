@@ -66,6 +66,14 @@ PURL_ECOSYSTEM_MAP = {
     for ecosystem, purl_data in ECOSYSTEM_PURL_DATA.items()
 }
 
+# Legacy PURL forms that predate the namespaced form in the PURL spec, still
+# produced by some tools. These are accepted when parsing, but never
+# generated.
+LEGACY_PURL_ECOSYSTEM_MAP = {
+    # pkg:alpine/<name> rather than pkg:apk/alpine/<name>.
+    EcosystemPURL('alpine', None): 'Alpine',
+}
+
 
 def _url_encode(package_name):
   """URL encode a PURL `namespace/name` or `name`."""
@@ -123,6 +131,11 @@ def parse_purl(purl_str: str) -> ParsedPURL | None:
   if ecosystem:
     return ParsedPURL(ecosystem, package, version)
 
+  ecosystem = LEGACY_PURL_ECOSYSTEM_MAP.get(
+      EcosystemPURL(purl.type, purl.namespace))
+  if ecosystem:
+    return ParsedPURL(ecosystem, package, version)
+
   # If no match is found, try again using only the type.
   # Some ecosystems may use the namespace to represent additional
   # information (like vendors) and the namespace might be optional.
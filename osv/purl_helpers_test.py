@@ -140,6 +140,9 @@ class PurlHelpersTest(unittest.TestCase):
         ('Alpine', 'postgresql14', None),
         purl_helpers.parse_purl('pkg:apk/alpine/postgresql14?arch=source'))
 
+    self.assertEqual(('Alpine', 'postgresql14', '14.5-r0'),
+                     purl_helpers.parse_purl('pkg:alpine/postgresql14@14.5-r0'))
+
     self.assertEqual(('Bitnami', 'moodl', None),
                      purl_helpers.parse_purl('pkg:bitnami/moodl'))
 
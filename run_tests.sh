@@ -6,6 +6,7 @@ poetry run python -m unittest osv.purl_helpers_test
 poetry run python -m unittest osv.request_helper_test
 poetry run python -m unittest osv.semver_index_test
 poetry run python -m unittest osv.impact_test
+poetry run python -m unittest osv.models_test
 
 # Run all osv.ecosystems tests
 poetry run python -m unittest discover osv/ecosystems/ "*_test.py" .
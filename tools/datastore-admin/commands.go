@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+// lookupSize is the most keys Datastore looks up at once.
+const lookupSize = 1000
+
+// tally counts the entities the shards have gone through, logging progress
+// every `every` of them.
+type tally struct {
+	verb  string
+	every int64
+	n     atomic.Int64
+}
+
+func (t *tally) add(n int) {
+	total := t.n.Add(int64(n))
+	if t.every > 0 && total/t.every != (total-int64(n))/t.every {
+		log.Printf("%s %d.\n", t.verb, total)
+	}
+}
+
+// eachKey calls fn with the keys of kind in r, in batches of at most size.
+func eachKey(ctx context.Context, client *datastore.Client, kind string, r keyRange, size int, fn func([]*datastore.Key) error) error {
+	it := client.Run(ctx, r.query(kind).KeysOnly())
+	var batch []*datastore.Key
+	for {
+		key, err := it.Next(nil)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, key)
+		if len(batch) >= size {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// count counts the entities of kind with keys-only queries, which are
+// cheaper than reading the entities of very large kinds.
+func count(ctx context.Context, client *datastore.Client, kind string, shards int) (int64, error) {
+	t := &tally{verb: "Counted", every: int64(*progress)}
+	err := runSharded(ctx, client, kind, shards, func(ctx context.Context, _ int, r keyRange) error {
+		return eachKey(ctx, client, kind, r, lookupSize, func(keys []*datastore.Key) error {
+			t.add(len(keys))
+			return nil
+		})
+	})
+	return t.n.Load(), err
+}
+
+// sample writes n entities of kind, picked in the pseudo-random order of
+// their __scatter__ property, to w as a JSON object per line.
+func sample(ctx context.Context, client *datastore.Client, kind string, n int, w io.Writer) error {
+	q := datastore.NewQuery(kind).Order("__scatter__").KeysOnly().Limit(n)
+	keys, err := client.GetAll(ctx, q, nil)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for start := 0; start < len(keys); start += lookupSize {
+		end := min(start+lookupSize, len(keys))
+		entities := make([]datastore.PropertyList, end-start)
+		if err := client.GetMulti(ctx, keys[start:end], entities); err != nil {
+			return err
+		}
+		for i, entity := range entities {
+			if err := enc.Encode(sampledEntity(keys[start+i], entity)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sampledEntity returns the JSON form of an entity.
+func sampledEntity(key *datastore.Key, props datastore.PropertyList) map[string]any {
+	out := make(map[string]any, len(props))
+	for _, p := range props {
+		out[p.Name] = jsonValue(p.Value)
+	}
+	return map[string]any{"key": key.String(), "properties": out}
+}
+
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case *datastore.Key:
+		return v.String()
+	case *datastore.Entity:
+		out := make(map[string]any, len(v.Properties))
+		for _, p := range v.Properties {
+			out[p.Name] = jsonValue(p.Value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = jsonValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// confirm asks for the deletion of kind to be confirmed.
+func confirm(in io.Reader, out io.Writer, kind, projectID string) bool {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprintf(out, "Deleting kind: %s, in project: %s\nEnter yes to confirm: \n", kind, projectID)
+	scanner.Scan()
+	return scanner.Text() == "yes"
+}
+
+// deleteAll deletes the entities of kind, in batches of -batch_size waiting
+// -wait_ms in between the batches of each shard.
+func deleteAll(ctx context.Context, client *datastore.Client, kind string, shards int) (int64, error) {
+	t := &tally{verb: "Deleted", every: int64(*progress)}
+	err := runSharded(ctx, client, kind, shards, func(ctx context.Context, _ int, r keyRange) error {
+		return eachKey(ctx, client, kind, r, *batchSize, func(keys []*datastore.Key) error {
+			if err := client.DeleteMulti(ctx, keys); err != nil {
+				return err
+			}
+			t.add(len(keys))
+			time.Sleep(time.Duration(*waitTimeMS) * time.Millisecond)
+			return nil
+		})
+	})
+	return t.n.Load(), err
+}
@@ -1,4 +1,4 @@
-module github.com/google/datastore-remover
+module github.com/google/datastore-admin
 
 go 1.23.6
 
@@ -0,0 +1,101 @@
+// Command datastore-admin counts, samples and deletes the entities of a
+// Datastore kind:
+//
+//	datastore-admin -project_id=P -kind=K count
+//	datastore-admin -project_id=P -kind=K sample N
+//	datastore-admin -project_id=P -kind=K delete
+//
+// Each subcommand queries the kind in -shards key ranges concurrently.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"cloud.google.com/go/datastore"
+)
+
+var (
+	kind       = flag.String("kind", "", "kind to count, sample or delete")
+	projectID  = flag.String("project_id", "", "the gcp project ID")
+	shards     = flag.Int("shards", 8, "number of key ranges to query the kind in concurrently")
+	batchSize  = flag.Int("batch_size", 500, "batch size for deletions")
+	waitTimeMS = flag.Int("wait_ms", 500, "wait time in between batch deletions")
+	progress   = flag.Int("progress", 100000, "log progress every this many entities counted or deleted")
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] count | sample N | delete\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *kind == "" || *projectID == "" || flag.NArg() == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client, err := datastore.NewClient(ctx, *projectID)
+	if err != nil {
+		log.Fatalf("Failed to create datastore client: %v", err)
+	}
+	defer client.Close()
+
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "count":
+		if len(args) != 0 {
+			usage()
+			os.Exit(1)
+		}
+		n, err := count(ctx, client, *kind, *shards)
+		if err != nil {
+			log.Fatalf("Failed to count %s: %v", *kind, err)
+		}
+		fmt.Printf("%s: %d entities\n", *kind, n)
+	case "sample":
+		n, err := sampleSize(args)
+		if err != nil {
+			usage()
+			log.Fatal(err)
+		}
+		if err := sample(ctx, client, *kind, n, os.Stdout); err != nil {
+			log.Fatalf("Failed to sample %s: %v", *kind, err)
+		}
+	case "delete":
+		if len(args) != 0 {
+			usage()
+			os.Exit(1)
+		}
+		if !confirm(os.Stdin, os.Stdout, *kind, *projectID) {
+			fmt.Println("Not yes entered, exiting")
+			os.Exit(1)
+		}
+		n, err := deleteAll(ctx, client, *kind, *shards)
+		if err != nil {
+			log.Fatalf("Failed to delete %s after deleting %d: %v", *kind, n, err)
+		}
+		log.Printf("Deleted %d.\n", n)
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+// sampleSize parses the argument of the sample subcommand.
+func sampleSize(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("sample takes the number of entities to sample")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of entities to sample: %q", args[0])
+	}
+	return n, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+// oversample is how many keys are sampled per shard to pick the keys the
+// kind is split at.
+const oversample = 32
+
+// keyRange is a range of keys, from Start (inclusive) to End (exclusive). A
+// nil Start or End leaves the range open on that side.
+type keyRange struct {
+	Start, End *datastore.Key
+}
+
+// query returns the query of the entities of kind in the range.
+func (r keyRange) query(kind string) *datastore.Query {
+	q := datastore.NewQuery(kind)
+	if r.Start != nil {
+		q = q.FilterField("__key__", ">=", r.Start)
+	}
+	if r.End != nil {
+		q = q.FilterField("__key__", "<", r.End)
+	}
+	return q
+}
+
+// splitKind splits the keys of kind into at most shards ranges of about the
+// same number of entities, by sampling keys in the pseudo-random order of
+// their __scatter__ property, as Datastore's own query splitters do.
+func splitKind(ctx context.Context, client *datastore.Client, kind string, shards int) ([]keyRange, error) {
+	if shards <= 1 {
+		return []keyRange{{}}, nil
+	}
+	q := datastore.NewQuery(kind).Order("__scatter__").KeysOnly().Limit(shards * oversample)
+	var sample []*datastore.Key
+	it := client.Run(ctx, q)
+	for {
+		key, err := it.Next(nil)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sample = append(sample, key)
+	}
+	return keyRanges(splitPoints(sample, shards)), nil
+}
+
+// splitPoints returns the keys that split the sampled keys into shards
+// groups of the same size.
+func splitPoints(sample []*datastore.Key, shards int) []*datastore.Key {
+	slices.SortFunc(sample, compareKeys)
+	sample = slices.CompactFunc(sample, func(a, b *datastore.Key) bool { return compareKeys(a, b) == 0 })
+	if len(sample) < shards {
+		shards = len(sample)
+	}
+	var points []*datastore.Key
+	for i := 1; i < shards; i++ {
+		points = append(points, sample[i*len(sample)/shards])
+	}
+	return points
+}
+
+// keyRanges returns the ranges between consecutive split points, open at
+// both ends.
+func keyRanges(points []*datastore.Key) []keyRange {
+	ranges := make([]keyRange, 0, len(points)+1)
+	var start *datastore.Key
+	for _, p := range points {
+		ranges = append(ranges, keyRange{Start: start, End: p})
+		start = p
+	}
+	return append(ranges, keyRange{Start: start})
+}
+
+// compareKeys orders keys as Datastore does: by their path from the root,
+// comparing the kinds of each element, then IDs before names.
+func compareKeys(a, b *datastore.Key) int {
+	pa, pb := keyPath(a), keyPath(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := pa[i], pb[i]
+		if c := strings.Compare(x.Kind, y.Kind); c != 0 {
+			return c
+		}
+		switch {
+		case x.Name == "" && y.Name != "":
+			return -1
+		case x.Name != "" && y.Name == "":
+			return 1
+		case x.Name != "":
+			if c := strings.Compare(x.Name, y.Name); c != 0 {
+				return c
+			}
+		default:
+			if x.ID != y.ID {
+				if x.ID < y.ID {
+					return -1
+				}
+				return 1
+			}
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+// keyPath returns the elements of a key's path, from the root.
+func keyPath(k *datastore.Key) []*datastore.Key {
+	var path []*datastore.Key
+	for ; k != nil; k = k.Parent {
+		path = append(path, k)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// runSharded runs fn on each range of kind concurrently, returning the
+// first error.
+func runSharded(ctx context.Context, client *datastore.Client, kind string, shards int, fn func(ctx context.Context, shard int, r keyRange) error) error {
+	ranges, err := splitKind(ctx, client, kind, shards)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, len(ranges))
+	for i, r := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = fn(ctx, i, r); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	// The other shards fail with context.Canceled once one has failed, so
+	// the error that failed it is returned.
+	var canceled error
+	for _, err := range errs {
+		if errors.Is(err, context.Canceled) {
+			canceled = err
+		} else if err != nil {
+			return err
+		}
+	}
+	return canceled
+}
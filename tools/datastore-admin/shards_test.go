@@ -0,0 +1,78 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestCompareKeys(t *testing.T) {
+	parent := datastore.NameKey("Bug", "a", nil)
+	keys := []*datastore.Key{
+		datastore.NameKey("Bug", "b", nil),
+		datastore.NameKey("Affected", "a", parent),
+		datastore.NameKey("Bug", "a", nil),
+		datastore.IDKey("Bug", 10, nil),
+		datastore.IDKey("Bug", 2, nil),
+		parent,
+	}
+	slices.SortFunc(keys, compareKeys)
+	var got []string
+	for _, k := range keys {
+		got = append(got, k.String())
+	}
+	want := []string{"/Bug,2", "/Bug,10", "/Bug,a", "/Bug,a", "/Bug,a/Affected,a", "/Bug,b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sorted keys = %v, want %v", got, want)
+	}
+}
+
+func TestSplitPoints(t *testing.T) {
+	var sample []*datastore.Key
+	for _, name := range strings.Split("h g f e d c b a a", " ") {
+		sample = append(sample, datastore.NameKey("Bug", name, nil))
+	}
+	ranges := keyRanges(splitPoints(sample, 4))
+	var got []string
+	for _, r := range ranges {
+		var start, end string
+		if r.Start != nil {
+			start = r.Start.Name
+		}
+		if r.End != nil {
+			end = r.End.Name
+		}
+		got = append(got, start+"-"+end)
+	}
+	if want := []string{"-c", "c-e", "e-g", "g-"}; !slices.Equal(got, want) {
+		t.Errorf("ranges = %v, want %v", got, want)
+	}
+
+	// A kind with fewer entities than shards is split in fewer ranges.
+	if got := keyRanges(splitPoints(sample[:1], 4)); len(got) != 1 {
+		t.Errorf("ranges of one key = %v, want one range", got)
+	}
+}
+
+func TestSampleSize(t *testing.T) {
+	if n, err := sampleSize([]string{"20"}); err != nil || n != 20 {
+		t.Errorf("sampleSize(20) = %d, %v, want 20", n, err)
+	}
+	for _, args := range [][]string{nil, {"0"}, {"x"}, {"1", "2"}} {
+		if _, err := sampleSize(args); err == nil {
+			t.Errorf("sampleSize(%q) succeeded", args)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out strings.Builder
+	if !confirm(strings.NewReader("yes\n"), &out, "Bug", "p") {
+		t.Error("confirm(yes) = false")
+	}
+	if confirm(strings.NewReader("y\n"), &out, "Bug", "p") {
+		t.Error("confirm(y) = true")
+	}
+}
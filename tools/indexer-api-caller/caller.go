@@ -1,8 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
-	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -15,10 +16,16 @@ import (
 )
 
 var (
-	repoDir   = flag.String("lib", "", "library directory")
-	repoDir2  = flag.String("lib2", "", "specify another directory to compare file hashes to the first")
-	searchDir = flag.String("dir", "", "third party directory containing multiple libraries")
-	fileExts  = []string{
+	repoDir      = flag.String("lib", "", "library directory")
+	repoDir2     = flag.String("lib2", "", "specify another directory to compare file hashes to the first")
+	searchDir    = flag.String("dir", "", "third party directory containing multiple libraries")
+	ignoreArg    = flag.String("ignore", "", "gitignore-style file of patterns for (e.g. locally patched) files to exclude from hashing, relative to each library")
+	maxBytes     = flag.Int("max_request_bytes", defaultMaxRequestBytes, "maximum size of a request, beyond which a deterministic sample of the files is sent")
+	allChunks    = flag.Bool("all_chunks", false, "instead of sampling libraries too large for one request, send all their files in several requests and report the best match of each")
+	explainTop   = flag.Int("explain", 0, "for the top N candidate versions, fetch each from its repository and report which files matched, differed and were missing")
+	explainDir   = flag.String("explain_dir", filepath.Join(os.TempDir(), "indexer-api-caller"), "directory to cache the candidate versions fetched with -explain in")
+	explainFiles = flag.Int("explain_files", 10, "maximum number of files of each kind of difference to list with -explain")
+	fileExts     = []string{
 		".hpp",
 		".h",
 		".hh",
@@ -36,16 +43,29 @@ type FileResult struct {
 	Hash Hash   `datastore:"hash"`
 }
 
+// maxAttributionQueries limits the extra queries made to find which excluded
+// files change the result.
+const maxAttributionQueries = 20
+
 func main() {
 	flag.Parse()
 
+	var ignoreList *IgnoreList
+	if *ignoreArg != "" {
+		var err error
+		ignoreList, err = ParseIgnoreFile(*ignoreArg)
+		if err != nil {
+			log.Fatalf("Failed to read ignore file: %v", err)
+		}
+	}
+
 	if *repoDir != "" {
-		aRes, err := buildGit(*repoDir)
+		aRes, err := buildGit(*repoDir, ignoreList)
 		if err != nil {
 			log.Fatal(err)
 		}
 		if *repoDir2 != "" {
-			bRes, err := buildGit(*repoDir2)
+			bRes, err := buildGit(*repoDir2, ignoreList)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -73,7 +93,7 @@ func main() {
 			if entry.IsDir() {
 				path := filepath.Join(*searchDir, entry.Name())
 				log.Printf("Scanning %s", path)
-				_, err := buildGit(path)
+				_, err := buildGit(path, ignoreList)
 				if err != nil {
 					log.Printf("Error when scanning %v: %v", entry.Name(), err)
 				}
@@ -90,19 +110,21 @@ func fileResToMap(input []*FileResult) map[Hash]bool {
 	return a
 }
 
-func buildGit(repoDir string) ([]*FileResult, error) {
-	fileExts := []string{
-		".hpp",
-		".h",
-		".hh",
-		".cc",
-		".c",
-		".cpp",
-	}
-
-	var fileResults []*FileResult
+// hashFiles hashes the files of the library, split into those included and
+// those excluded by the ignore list. License texts and files of nothing but
+// a license notice are skipped, as the indexer skips them.
+func hashFiles(repoDir string, ignoreList *IgnoreList) (included, excluded []*FileResult, err error) {
 	if err := filepath.Walk(repoDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if info.IsDir() {
+			if p != repoDir && isLicenseDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isLicenseFile(info.Name()) {
 			return nil
 		}
 		for _, ext := range fileExts {
@@ -111,43 +133,172 @@ func buildGit(repoDir string) ([]*FileResult, error) {
 				if err != nil {
 					return err
 				}
+				if isLicenseBoilerplate(buf) {
+					return nil
+				}
 				hash := md5.Sum(buf)
-				fileResults = append(fileResults, &FileResult{
+				fr := &FileResult{
 					Path: strings.ReplaceAll(p, repoDir, ""),
 					Hash: hash,
-				})
+				}
+				if ignoreList.Ignored(filepath.ToSlash(fr.Path)) {
+					excluded = append(excluded, fr)
+				} else {
+					included = append(included, fr)
+				}
 			}
 		}
 		return nil
 	}); err != nil {
-		return nil, fmt.Errorf("failed during file walk: %v", err)
+		return nil, nil, fmt.Errorf("failed during file walk: %v", err)
 	}
 
-	log.Printf("Hashed %v files", len(fileResults))
+	return included, excluded, nil
+}
 
-	b := strings.Builder{}
-	b.WriteString(fmt.Sprintf(`{"name":"%s", "file_hashes": [`, filepath.Base(repoDir)))
+type determineVersionHash struct {
+	Hash     []byte `json:"hash"`
+	FilePath string `json:"file_path"`
+}
 
-	for i, fr := range fileResults {
-		if i == len(fileResults)-1 {
-			fmt.Fprintf(&b, "{\"hash\": \"%s\", \"file_path\": \"%s\"}", base64.StdEncoding.EncodeToString(fr.Hash[:]), fr.Path)
-		} else {
-			fmt.Fprintf(&b, "{\"hash\": \"%s\", \"file_path\": \"%s\"},", base64.StdEncoding.EncodeToString(fr.Hash[:]), fr.Path)
-		}
+type determineVersionRequest struct {
+	Name       string                 `json:"name"`
+	FileHashes []determineVersionHash `json:"file_hashes"`
+}
+
+type determineVersionResponse struct {
+	Matches []struct {
+		Score    float64 `json:"score"`
+		RepoInfo struct {
+			Address string `json:"address"`
+			Tag     string `json:"tag"`
+			Version string `json:"version"`
+			Commit  string `json:"commit"`
+		} `json:"repo_info"`
+		// The counts are int64s, which the API encodes as strings.
+		MinimumFileMatches json.Number `json:"minimum_file_matches"`
+		EstimatedDiffFiles json.Number `json:"estimated_diff_files"`
+	} `json:"matches"`
+}
+
+// bestMatch describes the best match of a response, or "no match".
+func (r *determineVersionResponse) bestMatch() string {
+	if len(r.Matches) == 0 {
+		return "no match"
+	}
+	m := r.Matches[0]
+	return fmt.Sprintf("%s@%s (score %.3f)", m.RepoInfo.Address, m.RepoInfo.Version, m.Score)
+}
+
+// bestMatchKey identifies the best match of a response, ignoring its score.
+func (r *determineVersionResponse) bestMatchKey() string {
+	if len(r.Matches) == 0 {
+		return ""
+	}
+	return r.Matches[0].RepoInfo.Address + "@" + r.Matches[0].RepoInfo.Version
+}
+
+// determineVersion queries the determineversion API, returning the raw and
+// decoded response.
+func determineVersion(name string, fileResults []*FileResult) ([]byte, *determineVersionResponse, error) {
+	req := determineVersionRequest{Name: name, FileHashes: make([]determineVersionHash, 0, len(fileResults))}
+	for _, fr := range fileResults {
+		req.FileHashes = append(req.FileHashes, determineVersionHash{Hash: fr.Hash[:], FilePath: fr.Path})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, err
 	}
-	b.WriteString("]}")
 
-	res, err := http.Post("https://api.osv.dev/v1experimental/determineversion", "application/json", strings.NewReader(b.String()))
+	res, err := http.Post("https://api.osv.dev/v1experimental/determineversion", "application/json", bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to make request: %v", err)
+		return nil, nil, fmt.Errorf("Failed to make request: %v", err)
 	}
+	defer res.Body.Close()
 
 	output, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", err.Error(), string(output))
+	}
+
+	var resp determineVersionResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return output, nil, fmt.Errorf("failed to decode response: %v: %s", err, string(output))
+	}
+
+	return output, &resp, nil
+}
+
+// reportExcluded reports whether hashing the excluded files as well would
+// have changed the best match, and if so, which of them change it.
+func reportExcluded(name string, included, excluded []*FileResult, resp *determineVersionResponse) error {
+	log.Printf("Excluded %d files from hashing", len(excluded))
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	_, all, err := determineVersion(name, append(append([]*FileResult{}, included...), excluded...))
+	if err != nil {
+		return err
+	}
+	if all.bestMatchKey() == resp.bestMatchKey() {
+		log.Printf("Including the excluded files would not change the result: %s", all.bestMatch())
+		return nil
+	}
+	log.Printf("Including the excluded files would change the result from %s to %s", resp.bestMatch(), all.bestMatch())
+
+	if len(excluded) > maxAttributionQueries {
+		log.Printf("Too many excluded files (%d) to find which change the result", len(excluded))
+		return nil
+	}
+	for _, fr := range excluded {
+		_, one, err := determineVersion(name, append(append([]*FileResult{}, included...), fr))
+		if err != nil {
+			return err
+		}
+		if one.bestMatchKey() != resp.bestMatchKey() {
+			log.Printf("  %s changes the result to %s", fr.Path, one.bestMatch())
+		}
+	}
+
+	return nil
+}
+
+func buildGit(repoDir string, ignoreList *IgnoreList) ([]*FileResult, error) {
+	fileResults, excluded, err := hashFiles(repoDir, ignoreList)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Hashed %v files", len(fileResults))
 
+	name := filepath.Base(repoDir)
+	sample := fileResults
+	if chunks := chunkFileResults(fileResults, *maxBytes); len(chunks) > 1 {
+		if *allChunks {
+			return fileResults, determineVersionChunks(name, chunks)
+		}
+		sample = chunks[0]
+		reportSampling(sample, len(fileResults))
+		excluded = inSampledBuckets(excluded, sample)
+	}
+	output, resp, err := determineVersion(name, sample)
 	if err != nil {
-		log.Panicf("%s: %s", err.Error(), string(output))
+		return nil, err
 	}
 
 	log.Println(string(output))
+
+	if ignoreList != nil {
+		if err := reportExcluded(name, sample, excluded, resp); err != nil {
+			return nil, err
+		}
+	}
+	if *explainTop > 0 {
+		if err := explainMatches(fileResults, resp, *explainTop, *explainDir, *explainFiles); err != nil {
+			return nil, err
+		}
+	}
+
 	return fileResults, nil
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// fileDiff compares the files of the library with those of a candidate
+// version: files are matched and differ by path, and files only one side has
+// are missing from the other, unless the other has the same content at
+// another path, in which case they've moved.
+type fileDiff struct {
+	Matched      []string
+	Differed     []string
+	Moved        []string
+	LocalOnly    []string
+	UpstreamOnly []string
+}
+
+// compareFiles compares the local files of the library with the upstream
+// files of a candidate version.
+func compareFiles(local, upstream []*FileResult) fileDiff {
+	upstreamByPath := make(map[string]Hash, len(upstream))
+	for _, fr := range upstream {
+		upstreamByPath[fr.Path] = fr.Hash
+	}
+	localByPath := make(map[string]Hash, len(local))
+	for _, fr := range local {
+		localByPath[fr.Path] = fr.Hash
+	}
+	upstreamHashes := fileResToMap(upstream)
+	localHashes := fileResToMap(local)
+
+	var d fileDiff
+	for _, fr := range local {
+		hash, ok := upstreamByPath[fr.Path]
+		switch {
+		case ok && hash == fr.Hash:
+			d.Matched = append(d.Matched, fr.Path)
+		case ok:
+			d.Differed = append(d.Differed, fr.Path)
+		case upstreamHashes[fr.Hash]:
+			d.Moved = append(d.Moved, fr.Path)
+		default:
+			d.LocalOnly = append(d.LocalOnly, fr.Path)
+		}
+	}
+	for _, fr := range upstream {
+		if _, ok := localByPath[fr.Path]; !ok && !localHashes[fr.Hash] {
+			d.UpstreamOnly = append(d.UpstreamOnly, fr.Path)
+		}
+	}
+	for _, paths := range [][]string{d.Matched, d.Differed, d.Moved, d.LocalOnly, d.UpstreamOnly} {
+		sort.Strings(paths)
+	}
+	return d
+}
+
+// fetchCandidate checks out the commit (or failing that, tag) of a candidate
+// version into a cache directory under cacheDir, one per repository, and
+// returns its directory.
+func fetchCandidate(cacheDir, address, ref string) (string, error) {
+	sum := sha256.Sum256([]byte(address))
+	dir := filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		if err := runGit(dir, "init", "--quiet"); err != nil {
+			return "", err
+		}
+	}
+	if err := runGit(dir, "fetch", "--quiet", "--depth", "1", address, ref); err != nil {
+		return "", err
+	}
+	if err := runGit(dir, "checkout", "--quiet", "--force", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	if err := runGit(dir, "clean", "--quiet", "-dfx"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", args[0], err, output)
+	}
+	return nil
+}
+
+// explainMatches reports, for the top n candidate versions of a response,
+// which of the library's files match the candidate's, which differ, and
+// which are missing from either, listing up to maxFiles of each.
+func explainMatches(local []*FileResult, resp *determineVersionResponse, n int, cacheDir string, maxFiles int) error {
+	for i, m := range resp.Matches {
+		if i == n {
+			break
+		}
+		log.Printf("Candidate %d: %s@%s (score %.3f, %s files matched, an estimated %s different)",
+			i+1, m.RepoInfo.Address, m.RepoInfo.Version, m.Score, count(m.MinimumFileMatches), count(m.EstimatedDiffFiles))
+		ref := m.RepoInfo.Commit
+		if ref == "" {
+			ref = m.RepoInfo.Tag
+		}
+		if m.RepoInfo.Address == "" || ref == "" {
+			log.Printf("  No repository or commit to compare with")
+			continue
+		}
+		dir, err := fetchCandidate(cacheDir, m.RepoInfo.Address, ref)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s@%s: %w", m.RepoInfo.Address, ref, err)
+		}
+		upstream, _, err := hashFiles(dir, nil)
+		if err != nil {
+			return err
+		}
+		reportFileDiff(compareFiles(local, upstream), maxFiles)
+	}
+	return nil
+}
+
+// count formats a count of a response, which is omitted when it's 0.
+func count(n json.Number) string {
+	if n == "" {
+		return "0"
+	}
+	return n.String()
+}
+
+// reportFileDiff logs a summary of a fileDiff, listing up to maxFiles of
+// each kind of difference.
+func reportFileDiff(d fileDiff, maxFiles int) {
+	log.Printf("  %d matched, %d differ, %d moved, %d missing upstream, %d missing locally",
+		len(d.Matched), len(d.Differed), len(d.Moved), len(d.LocalOnly), len(d.UpstreamOnly))
+	for _, kind := range []struct {
+		name  string
+		paths []string
+	}{
+		{"Differ", d.Differed},
+		{"Moved", d.Moved},
+		{"Missing upstream", d.LocalOnly},
+		{"Missing locally", d.UpstreamOnly},
+	} {
+		for i, p := range kind.paths {
+			if i == maxFiles {
+				log.Printf("    ... and %d more", len(kind.paths)-maxFiles)
+				break
+			}
+			log.Printf("    %s: %s", kind.name, p)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fileResult(path, content string) *FileResult {
+	return &FileResult{Path: path, Hash: md5.Sum([]byte(content))}
+}
+
+func TestCompareFiles(t *testing.T) {
+	local := []*FileResult{
+		fileResult("/src/a.c", "a"),
+		fileResult("/src/b.c", "patched b"),
+		fileResult("/src/moved.h", "moved"),
+		fileResult("/src/local.c", "local"),
+	}
+	upstream := []*FileResult{
+		fileResult("/src/a.c", "a"),
+		fileResult("/src/b.c", "b"),
+		fileResult("/include/moved.h", "moved"),
+		fileResult("/src/new.c", "new"),
+	}
+	want := fileDiff{
+		Matched:      []string{"/src/a.c"},
+		Differed:     []string{"/src/b.c"},
+		Moved:        []string{"/src/moved.h"},
+		LocalOnly:    []string{"/src/local.c"},
+		UpstreamOnly: []string{"/src/new.c"},
+	}
+	if got := compareFiles(local, upstream); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareFiles() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeResponseCounts(t *testing.T) {
+	var resp determineVersionResponse
+	data := `{"matches": [{"score": 0.9, "repo_info": {"address": "https://github.com/madler/zlib", "tag": "v1.3", "version": "1.3", "commit": "09155eaa2f9270dc4ed1fa13e2b4b2613e6e4851"}, "minimum_file_matches": "41", "estimated_diff_files": "3"}]}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatal(err)
+	}
+	m := resp.Matches[0]
+	if got := count(m.MinimumFileMatches); got != "41" {
+		t.Errorf("minimum_file_matches = %s, want 41", got)
+	}
+	if got := count(m.EstimatedDiffFiles); got != "3" {
+		t.Errorf("estimated_diff_files = %s, want 3", got)
+	}
+	if m.RepoInfo.Commit != "09155eaa2f9270dc4ed1fa13e2b4b2613e6e4851" {
+		t.Errorf("commit = %q", m.RepoInfo.Commit)
+	}
+}
+
+func TestFetchCandidate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "--quiet")
+	write("src/a.c", "v1")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	first := git("rev-parse", "HEAD")
+	write("src/a.c", "v2")
+	git("commit", "--quiet", "-am", "v2")
+
+	cacheDir := t.TempDir()
+	for _, tt := range []struct {
+		ref  string
+		want string
+	}{
+		{ref: first, want: "v1"},
+		{ref: "HEAD", want: "v2"},
+		{ref: "v1", want: "v1"},
+	} {
+		dir, err := fetchCandidate(cacheDir, repo, tt.ref)
+		if err != nil {
+			t.Fatalf("fetchCandidate(%s) error: %v", tt.ref, err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "src", "a.c"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("fetchCandidate(%s) checked out %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// ignorePattern is a single gitignore-style pattern.
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	// anchored patterns only match from the library root, others at any depth.
+	anchored bool
+}
+
+// IgnoreList excludes files (e.g. those carrying local patches) from hashing,
+// using gitignore-style patterns: blank lines and lines starting with # are
+// skipped, ! negates a pattern, a leading / anchors it to the library root, a
+// trailing / only matches directories, and ** matches any number of
+// directories.
+type IgnoreList struct {
+	patterns []ignorePattern
+}
+
+// ParseIgnoreFile reads an ignore file.
+func ParseIgnoreFile(fileName string) (*IgnoreList, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewIgnoreList(lines), nil
+}
+
+// NewIgnoreList parses ignore patterns.
+func NewIgnoreList(lines []string) *IgnoreList {
+	l := &IgnoreList{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// As in gitignore, a pattern with a slash before its end is relative
+		// to the root.
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.segments = strings.Split(line, "/")
+		l.patterns = append(l.patterns, p)
+	}
+
+	return l
+}
+
+// Ignored reports whether the file at relPath (relative to the library root,
+// slash separated) is excluded, by it or any of its parent directories
+// matching the last pattern that matches them.
+func (l *IgnoreList) Ignored(relPath string) bool {
+	if l == nil {
+		return false
+	}
+	segments := strings.Split(strings.Trim(relPath, "/"), "/")
+	ignored := false
+	for i := range segments {
+		isDir := i < len(segments)-1
+		for _, p := range l.patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.matches(segments[:i+1]) {
+				ignored = !p.negate
+			}
+		}
+		// As in gitignore, files in an excluded directory can't be re-included.
+		if ignored && isDir {
+			return true
+		}
+	}
+
+	return ignored
+}
+
+func (p ignorePattern) matches(segments []string) bool {
+	if p.anchored {
+		return matchSegments(p.segments, segments)
+	}
+	// Unanchored patterns match the trailing segments.
+	for i := range segments {
+		if matchSegments(p.segments, segments[i:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSegments matches pattern segments against all of the path segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
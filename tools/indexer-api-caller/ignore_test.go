@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestIgnored(t *testing.T) {
+	l := NewIgnoreList([]string{
+		"# Locally patched files",
+		"",
+		"*.patched.c",
+		"/src/config.h",
+		"build/",
+		"third_party/**/port.cc",
+		"*.h",
+		"!include/api.h",
+	})
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/foo.patched.c", true},
+		{"foo.patched.c", true},
+		{"src/foo.c", false},
+		{"src/config.h", true},
+		{"lib/src/config.cc", false},
+		{"build/gen.c", true},
+		{"src/build/gen.c", true},
+		{"build.c", false},
+		{"third_party/port.cc", true},
+		{"third_party/a/b/port.cc", true},
+		{"lib/port.cc", false},
+		{"include/util.h", true},
+		{"include/api.h", false},
+		{"/include/api.h", false},
+	}
+	for _, tc := range tests {
+		if got := l.Ignored(tc.path); got != tc.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	var none *IgnoreList
+	if none.Ignored("src/foo.c") {
+		t.Errorf("nil IgnoreList ignored a file")
+	}
+}
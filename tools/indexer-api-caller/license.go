@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// These need to be kept in sync with the indexer's processing stage (and
+// indexer-query's versionindex), so no hashes of files the indexer doesn't
+// hash are sent, which would lower the scores of the right versions.
+var (
+	licenseDirNames = map[string]bool{
+		"license":  true,
+		"licenses": true,
+		"licence":  true,
+		"licences": true,
+	}
+	licenseFileName = regexp.MustCompile(`^(?:un)?(?:licen[cs]e|copying|copyright|notice|patents)`)
+	licenseFileExts = map[string]bool{
+		"": true, ".txt": true, ".md": true, ".markdown": true, ".rst": true,
+		".html": true, ".htm": true, ".lesser": true, ".lib": true, ".gpl": true,
+		".lgpl": true, ".mit": true, ".bsd": true, ".apache": true,
+	}
+	comments       = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	licenseMention = regexp.MustCompile(`(?i)spdx-license-identifier|licen[cs]e|copyright`)
+)
+
+// isLicenseDir returns whether a directory holds license texts, e.g. the
+// LICENSES directory of REUSE compliant projects.
+func isLicenseDir(name string) bool {
+	return licenseDirNames[strings.ToLower(name)]
+}
+
+// isLicenseFile returns whether a file is a license text or notice, by its
+// name.
+func isLicenseFile(name string) bool {
+	name = strings.ToLower(name)
+	return licenseFileName.MatchString(name) && licenseFileExts[filepath.Ext(name)]
+}
+
+// isLicenseBoilerplate returns whether a source file holds nothing but
+// comments carrying a license notice, e.g. an SPDX-License-Identifier header.
+func isLicenseBoilerplate(buf []byte) bool {
+	if len(bytes.TrimSpace(comments.ReplaceAll(buf, nil))) != 0 {
+		return false
+	}
+	return licenseMention.Match(buf)
+}
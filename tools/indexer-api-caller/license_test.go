@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestHashFilesSkipsLicenses(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/zlib.c":             "int deflate(void) { return 0; }\n",
+		"src/license.c":          "int license(void) { return 0; }\n",
+		"src/spdx.h":             "// SPDX-License-Identifier: Zlib\n/* Copyright (C) 1995 Jean-loup Gailly */\n",
+		"LICENSE":                "zlib License\n",
+		"COPYING.LESSER":         "GNU LESSER GENERAL PUBLIC LICENSE\n",
+		"LICENSES/Zlib.h":        "int licensed(void);\n",
+		"contrib/licenses/mit.c": "int mit(void);\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	included, excluded, err := hashFiles(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fr := range included {
+		got = append(got, filepath.ToSlash(fr.Path))
+	}
+	slices.Sort(got)
+	want := []string{"/src/license.c", "/src/zlib.c"}
+	if !slices.Equal(got, want) || len(excluded) != 0 {
+		t.Errorf("hashFiles() = %q, %d excluded, want %q", got, len(excluded), want)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// bucketCount is the number of buckets the API (like the indexer) divides
+// file hashes into, by their first two bytes.
+const bucketCount = 512
+
+// defaultMaxRequestBytes keeps requests under gRPC's default 4 MiB message
+// size limit, which the API enforces, with room for the request's framing.
+const defaultMaxRequestBytes = 4<<20 - 64<<10
+
+// bucketIndex returns the bucket of a file hash.
+func bucketIndex(hash Hash) int {
+	return int(binary.BigEndian.Uint16(hash[0:2]) % bucketCount)
+}
+
+// hashRequestSize returns the size a file hash adds to a request.
+func hashRequestSize(fr *FileResult) int {
+	buf, _ := json.Marshal(determineVersionHash{Hash: fr.Hash[:], FilePath: fr.Path})
+	// Plus the separating comma.
+	return len(buf) + 1
+}
+
+// chunkFileResults splits file hashes into chunks whose requests fit in
+// maxBytes. The API matches whole buckets, so buckets are never split
+// between chunks; a bucket too large for a request on its own is a chunk of
+// its own. Buckets are packed in order, so the chunks are deterministic, and
+// as files are bucketed by hash, each chunk is an unbiased sample of the
+// files.
+func chunkFileResults(fileResults []*FileResult, maxBytes int) [][]*FileResult {
+	buckets := make([][]*FileResult, bucketCount)
+	for _, fr := range fileResults {
+		idx := bucketIndex(fr.Hash)
+		buckets[idx] = append(buckets[idx], fr)
+	}
+
+	var chunks [][]*FileResult
+	var chunk []*FileResult
+	chunkSize := 0
+	for _, bucket := range buckets {
+		if len(bucket) == 0 {
+			continue
+		}
+		// Sort by path, so chunks don't depend on the walk order.
+		sort.Slice(bucket, func(i, j int) bool { return bucket[i].Path < bucket[j].Path })
+		bucketSize := 0
+		for _, fr := range bucket {
+			bucketSize += hashRequestSize(fr)
+		}
+		if len(chunk) > 0 && chunkSize+bucketSize > maxBytes {
+			chunks = append(chunks, chunk)
+			chunk, chunkSize = nil, 0
+		}
+		chunk = append(chunk, bucket...)
+		chunkSize += bucketSize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// bucketsOf returns the buckets of the given files.
+func bucketsOf(fileResults []*FileResult) map[int]bool {
+	buckets := make(map[int]bool)
+	for _, fr := range fileResults {
+		buckets[bucketIndex(fr.Hash)] = true
+	}
+	return buckets
+}
+
+// inSampledBuckets returns the files in the buckets of the sample, so
+// comparing results with and without them compares the same buckets.
+func inSampledBuckets(fileResults []*FileResult, sample []*FileResult) []*FileResult {
+	buckets := bucketsOf(sample)
+	var in []*FileResult
+	for _, fr := range fileResults {
+		if buckets[bucketIndex(fr.Hash)] {
+			in = append(in, fr)
+		}
+	}
+	return in
+}
+
+// reportSampling explains how sending a sample of the files affects the
+// scores of the matches.
+func reportSampling(sample []*FileResult, total int) {
+	log.Printf("Sampled %d of %d files (%d of %d buckets) to fit the request size limit", len(sample), total, len(bucketsOf(sample)), bucketCount)
+	log.Printf("Scores are lower than for the whole library, as the API counts the buckets not sent as changed")
+}
+
+// determineVersionChunks queries the determineversion API with every chunk
+// of the files, reporting the best match of each and the one most chunks
+// agree on.
+func determineVersionChunks(name string, chunks [][]*FileResult) error {
+	votes := make(map[string]int)
+	best := ""
+	for i, chunk := range chunks {
+		_, resp, err := determineVersion(name, chunk)
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i+1, err)
+		}
+		log.Printf("Chunk %d of %d (%d files): %s", i+1, len(chunks), len(chunk), resp.bestMatch())
+		key := resp.bestMatchKey()
+		if key == "" {
+			continue
+		}
+		votes[key]++
+		if best == "" || votes[key] > votes[best] {
+			best = key
+		}
+	}
+	if best == "" {
+		log.Printf("No chunk matched")
+		return nil
+	}
+	log.Printf("Best match of %d of %d chunks: %s", votes[best], len(chunks), best)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"testing"
+)
+
+func testFileResults(n int) []*FileResult {
+	var fileResults []*FileResult
+	for i := range n {
+		path := fmt.Sprintf("/src/file%d.c", i)
+		fileResults = append(fileResults, &FileResult{Path: path, Hash: md5.Sum([]byte(path))})
+	}
+	return fileResults
+}
+
+func TestChunkFileResults(t *testing.T) {
+	fileResults := testFileResults(2000)
+	total := 0
+	for _, fr := range fileResults {
+		total += hashRequestSize(fr)
+	}
+
+	if chunks := chunkFileResults(fileResults, total); len(chunks) != 1 || len(chunks[0]) != len(fileResults) {
+		t.Fatalf("chunkFileResults() with room for all files returned %d chunks", len(chunks))
+	}
+
+	maxBytes := total / 4
+	chunks := chunkFileResults(fileResults, maxBytes)
+	if len(chunks) < 4 {
+		t.Fatalf("chunkFileResults() returned %d chunks, want at least 4", len(chunks))
+	}
+	seen := make(map[int]int)
+	files := 0
+	for i, chunk := range chunks {
+		size := 0
+		for _, fr := range chunk {
+			size += hashRequestSize(fr)
+			if other, ok := seen[bucketIndex(fr.Hash)]; ok && other != i {
+				t.Errorf("bucket %d split between chunks %d and %d", bucketIndex(fr.Hash), other, i)
+			}
+			seen[bucketIndex(fr.Hash)] = i
+		}
+		if size > maxBytes {
+			t.Errorf("chunk %d is %d bytes, over the %d byte limit", i, size, maxBytes)
+		}
+		files += len(chunk)
+	}
+	if files != len(fileResults) {
+		t.Errorf("chunks hold %d files, want %d", files, len(fileResults))
+	}
+
+	// The chunks don't depend on the order files are hashed in.
+	reversed := make([]*FileResult, len(fileResults))
+	for i, fr := range fileResults {
+		reversed[len(fileResults)-1-i] = fr
+	}
+	again := chunkFileResults(reversed, maxBytes)
+	if len(again) != len(chunks) {
+		t.Fatalf("chunkFileResults() of reordered files returned %d chunks, want %d", len(again), len(chunks))
+	}
+	for i := range chunks {
+		for j := range chunks[i] {
+			if chunks[i][j].Path != again[i][j].Path {
+				t.Fatalf("chunkFileResults() of reordered files differs at chunk %d file %d", i, j)
+			}
+		}
+	}
+}
+
+func TestChunkFileResultsOversizedBucket(t *testing.T) {
+	fileResults := testFileResults(100)
+	// Every bucket is over the limit, so each is a chunk of its own.
+	chunks := chunkFileResults(fileResults, 1)
+	if want := len(bucketsOf(fileResults)); len(chunks) != want {
+		t.Errorf("chunkFileResults() returned %d chunks, want one per bucket (%d)", len(chunks), want)
+	}
+}
+
+func TestInSampledBuckets(t *testing.T) {
+	fileResults := testFileResults(50)
+	sample := fileResults[:10]
+	buckets := bucketsOf(sample)
+	for _, fr := range inSampledBuckets(fileResults, sample) {
+		if !buckets[bucketIndex(fr.Hash)] {
+			t.Errorf("inSampledBuckets() returned %s, outside the sampled buckets", fr.Path)
+		}
+	}
+	if got := inSampledBuckets(fileResults, sample); len(got) < len(sample) {
+		t.Errorf("inSampledBuckets() returned %d files, want at least the %d sampled", len(got), len(sample))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	"github.com/google/osv.dev/tools/indexer-query/versionindex"
+)
+
+var (
+	libDir    = flag.String("lib", "", "library directory")
+	projectID = flag.String("project_id", "", "the gcp project ID of the Datastore to query the index in")
+	snapshot  = flag.String("snapshot", "", "directory or gs://bucket/prefix of an exported snapshot of the index to query instead of Datastore")
+	exts      = flag.String("exts", strings.Join(versionindex.DefaultFileExts, ","), "comma separated extensions of the files to hash")
+	jsonOut   = flag.Bool("json", false, "print the matches as JSON")
+)
+
+func main() {
+	flag.Parse()
+	if *libDir == "" || (*projectID == "") == (*snapshot == "") {
+		fmt.Fprintln(os.Stderr, "-lib and one of -project_id or -snapshot are required")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	ctx := context.Background()
+
+	var src versionindex.Source
+	if *snapshot != "" {
+		var client *storage.Client
+		if strings.HasPrefix(*snapshot, "gs://") {
+			var err error
+			if client, err = storage.NewClient(ctx); err != nil {
+				log.Fatalf("failed to create storage client: %v", err)
+			}
+			defer client.Close()
+		}
+		s, err := versionindex.LoadSnapshot(ctx, client, *snapshot)
+		if err != nil {
+			log.Fatalf("failed to load snapshot: %v", err)
+		}
+		log.Printf("loaded %d documents from snapshot created %s", s.Documents(), s.Index.Created)
+		src = s
+	} else {
+		client, err := datastore.NewClient(ctx, *projectID)
+		if err != nil {
+			log.Fatalf("failed to create datastore client: %v", err)
+		}
+		defer client.Close()
+		src = &versionindex.DatastoreSource{Client: client}
+	}
+
+	files, err := versionindex.HashDir(*libDir, strings.Split(*exts, ","))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("hashed %d files", len(files))
+	matches, err := versionindex.Match(ctx, src, files)
+	if err != nil {
+		log.Fatalf("failed to match: %v", err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(matches); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(matches) == 0 {
+		fmt.Println("no matches")
+		return
+	}
+	for _, m := range matches {
+		fmt.Printf("%5.1f%%  %s@%s (%s) %s, ~%d files differ\n",
+			m.Score*100, m.RepoAddr, m.Version, m.Tag, m.Commit, m.EstimatedDiffFiles)
+	}
+}
@@ -0,0 +1,68 @@
+package versionindex
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+)
+
+// The kinds the indexer stores the index as.
+const (
+	docKind    = "RepoIndex"
+	bucketKind = "RepoIndexBucket"
+)
+
+// DatastoreSource reads the index from the Datastore the indexer writes it
+// to.
+type DatastoreSource struct {
+	Client *datastore.Client
+}
+
+func (s *DatastoreSource) Lookup(ctx context.Context, nodeHash []byte, limit int) ([]BucketMatch, error) {
+	query := datastore.NewQuery(bucketKind).FilterField("node_hash", "=", nodeHash).Limit(limit)
+	var buckets []datastore.PropertyList
+	keys, err := s.Client.GetAll(ctx, query, &buckets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bucket %x: %w", nodeHash, err)
+	}
+	matches := make([]BucketMatch, 0, len(keys))
+	for i, key := range keys {
+		if key.Parent == nil {
+			continue
+		}
+		files, _ := property(buckets[i], "files_contained").(int64)
+		matches = append(matches, BucketMatch{DocKey: key.Parent.Name, FilesContained: int(files)})
+	}
+	return matches, nil
+}
+
+func (s *DatastoreSource) Document(ctx context.Context, key string) (*Document, error) {
+	// Documents are loaded as property lists, so fields the indexer adds
+	// later don't fail loading them.
+	var props datastore.PropertyList
+	if err := s.Client.Get(ctx, datastore.NameKey(docKind, key, nil), &props); err != nil {
+		return nil, fmt.Errorf("failed to get document %s: %w", key, err)
+	}
+	doc := &Document{Key: key}
+	doc.Name, _ = property(props, "name").(string)
+	doc.RepoAddr, _ = property(props, "repo_addr").(string)
+	doc.Tag, _ = property(props, "tag").(string)
+	commit, _ := property(props, "commit").([]byte)
+	doc.Commit = hex.EncodeToString(commit)
+	fileCount, _ := property(props, "file_count").(int64)
+	doc.FileCount = int(fileCount)
+	doc.EmptyBucketBitmap, _ = property(props, "empty_bucket_bitmap").([]byte)
+	return doc, nil
+}
+
+// property returns the value of the named property, or nil.
+func property(props datastore.PropertyList, name string) any {
+	for _, p := range props {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package versionindex
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// BucketCount is the number of buckets the indexer divides file hashes into.
+const BucketCount = 512
+
+// DefaultFileExts are the extensions of the files hashed by default, those
+// the indexer hashes for C/C++ libraries.
+var DefaultFileExts = []string{".hpp", ".h", ".hh", ".cc", ".c", ".cpp"}
+
+// These need to be kept in sync with the indexer's processing stage, so
+// files are hashed as they were when indexed.
+var (
+	vendoredLibNames = map[string]bool{
+		"3rdparty":    true,
+		"dep":         true,
+		"deps":        true,
+		"thirdparty":  true,
+		"third-party": true,
+		"third_party": true,
+		"libs":        true,
+		"external":    true,
+		"externals":   true,
+		"vendor":      true,
+		"vendored":    true,
+	}
+	licenseDirNames = map[string]bool{
+		"license":  true,
+		"licenses": true,
+		"licence":  true,
+		"licences": true,
+	}
+	licenseFileName = regexp.MustCompile(`^(?:un)?(?:licen[cs]e|copying|copyright|notice|patents)`)
+	licenseFileExts = map[string]bool{
+		"": true, ".txt": true, ".md": true, ".markdown": true, ".rst": true,
+		".html": true, ".htm": true, ".lesser": true, ".lib": true, ".gpl": true,
+		".lgpl": true, ".mit": true, ".bsd": true, ".apache": true,
+	}
+	comments       = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	licenseMention = regexp.MustCompile(`(?i)spdx-license-identifier|licen[cs]e|copyright`)
+)
+
+// FileHash is the MD5 hash of a file of a library.
+type FileHash struct {
+	Path string
+	Hash [md5.Size]byte
+}
+
+func isLicenseFile(name string) bool {
+	name = strings.ToLower(name)
+	return licenseFileName.MatchString(name) && licenseFileExts[filepath.Ext(name)]
+}
+
+func isLicenseBoilerplate(buf []byte) bool {
+	if len(bytes.TrimSpace(comments.ReplaceAll(buf, nil))) != 0 {
+		return false
+	}
+	return licenseMention.Match(buf)
+}
+
+// HashDir hashes the files of a library with the given extensions, skipping
+// those the indexer skips: nested vendored libraries, license texts and
+// files of nothing but a license notice.
+func HashDir(dir string, exts []string) ([]FileHash, error) {
+	var hashes []FileHash
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(d.Name())
+		if d.IsDir() {
+			if p != dir && (vendoredLibNames[name] || licenseDirNames[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isLicenseFile(name) {
+			return nil
+		}
+		for _, ext := range exts {
+			if filepath.Ext(p) != ext {
+				continue
+			}
+			buf, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if isLicenseBoilerplate(buf) {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, FileHash{Path: filepath.ToSlash(rel), Hash: md5.Sum(buf)})
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	return hashes, nil
+}
+
+// Bucket is a bucket of file hashes, identified by the hash of its sorted
+// file hashes.
+type Bucket struct {
+	NodeHash       []byte
+	FilesContained int
+}
+
+// Buckets divides file hashes into BucketCount buckets, as the indexer does.
+func Buckets(files []FileHash) []Bucket {
+	grouped := make([][][md5.Size]byte, BucketCount)
+	for _, f := range files {
+		idx := binary.BigEndian.Uint16(f.Hash[0:2]) % BucketCount
+		grouped[idx] = append(grouped[idx], f.Hash)
+	}
+	buckets := make([]Bucket, BucketCount)
+	for i, hashes := range grouped {
+		sort.Slice(hashes, func(a, b int) bool { return bytes.Compare(hashes[a][:], hashes[b][:]) < 0 })
+		hasher := md5.New()
+		for _, h := range hashes {
+			hasher.Write(h[:])
+		}
+		buckets[i] = Bucket{NodeHash: hasher.Sum(nil), FilesContained: len(hashes)}
+	}
+	return buckets
+}
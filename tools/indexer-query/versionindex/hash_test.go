@@ -0,0 +1,46 @@
+package versionindex
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/lib.c":              "int main() {}",
+		"include/lib.h":          "#define LIB 1",
+		"src/README.md":          "not hashed",
+		"third_party/zlib/z.c":   "vendored",
+		"LICENSES/MIT.c":         "license dir",
+		"license.c":              "int license() {}",
+		"src/spdx.h":             "// SPDX-License-Identifier: MIT\n",
+		"vendor/nested/vendor.h": "vendored",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := HashDir(dir, DefaultFileExts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Path)
+	}
+	sort.Strings(got)
+	want := []string{"include/lib.h", "license.c", "src/lib.c"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("HashDir() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,220 @@
+package versionindex
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// These match the determineversion API's, so matches score as they do there.
+const (
+	// maxMatchesToCare is the number of versions beyond which a bucket is
+	// too common to tell versions apart, so it's skipped.
+	maxMatchesToCare = 100
+	// maxResults is the number of best matches scored.
+	maxResults = 10
+	// minScore is the score below which matches aren't returned.
+	minScore  = 0.05
+	tagPrefix = "refs/tags/"
+)
+
+// lookupConcurrency is the number of bucket lookups in flight.
+const lookupConcurrency = 16
+
+// VersionMatch is an indexed version the files matched.
+type VersionMatch struct {
+	Score              float64 `json:"score"`
+	MinimumFileMatches int     `json:"minimum_file_matches"`
+	EstimatedDiffFiles int     `json:"estimated_diff_files"`
+	RepoAddr           string  `json:"repo_addr"`
+	Commit             string  `json:"commit"`
+	Tag                string  `json:"tag"`
+	Version            string  `json:"version"`
+}
+
+// Match returns the indexed versions the files best match, best first,
+// scored as the determineversion API scores them.
+func Match(ctx context.Context, src Source, files []FileHash) ([]VersionMatch, error) {
+	buckets := Buckets(files)
+	results := make([][]BucketMatch, len(buckets))
+	// queryBitmap has a bit set for each bucket of the query holding files.
+	queryBitmap := make([]byte, BucketCount/8)
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(lookupConcurrency)
+	for i, bucket := range buckets {
+		if bucket.FilesContained == 0 {
+			continue
+		}
+		queryBitmap[i/8] |= 1 << (i % 8)
+		g.Go(func() error {
+			matches, err := src.Lookup(gCtx, bucket.NodeHash, maxMatchesToCare)
+			results[i] = matches
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	fileMatches := make(map[string]int)
+	bucketMatches := make(map[string]int)
+	skippedBuckets, skippedFiles := 0, 0
+	for i, matches := range results {
+		if len(matches) == maxMatchesToCare {
+			// There are probably more versions beyond the limit, so the
+			// bucket tells nothing apart.
+			skippedBuckets++
+			skippedFiles += buckets[i].FilesContained
+			continue
+		}
+		for _, m := range matches {
+			fileMatches[m.DocKey] += m.FilesContained
+			bucketMatches[m.DocKey]++
+		}
+	}
+	// Skipped buckets count as matching, so identical files score 100%.
+	for key := range fileMatches {
+		fileMatches[key] += skippedFiles
+	}
+
+	keys := make([]string, 0, len(bucketMatches))
+	for key := range bucketMatches {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if bucketMatches[keys[i]] != bucketMatches[keys[j]] {
+			return bucketMatches[keys[i]] > bucketMatches[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	keys = keys[:min(maxResults, len(keys))]
+
+	docs := make([]*Document, len(keys))
+	g, gCtx = errgroup.WithContext(ctx)
+	g.SetLimit(lookupConcurrency)
+	for i, key := range keys {
+		g.Go(func() error {
+			doc, err := src.Document(gCtx, key)
+			docs[i] = doc
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// emptyCount is the number of buckets of the query without files.
+	emptyCount := BucketCount
+	for _, b := range queryBitmap {
+		emptyCount -= bits.OnesCount8(b)
+	}
+	var output []VersionMatch
+	for i, doc := range docs {
+		if doc.EmptyBucketBitmap == nil {
+			continue
+		}
+		// Buckets empty in the query that hold files in the version are
+		// changed, as they can't have matched.
+		missedEmpty := 0
+		for j, b := range doc.EmptyBucketBitmap {
+			if j < len(queryBitmap) {
+				missedEmpty += bits.OnesCount8(^queryBitmap[j] & b)
+			}
+		}
+		diff := estimateDiff(
+			BucketCount-bucketMatches[keys[i]]-emptyCount+missedEmpty-skippedBuckets,
+			abs(doc.FileCount-len(files)),
+		)
+		maxFiles := max(doc.FileCount, len(files))
+		tag := strings.TrimPrefix(doc.Tag, tagPrefix)
+		version := strings.ReplaceAll(normalizeTag(tag), "-", ".")
+		if version == "" {
+			// The tag isn't a version.
+			continue
+		}
+		score := float64(maxFiles-diff) / float64(maxFiles)
+		if score < minScore {
+			continue
+		}
+		output = append(output, VersionMatch{
+			Score:              score,
+			MinimumFileMatches: fileMatches[keys[i]],
+			EstimatedDiffFiles: diff,
+			RepoAddr:           doc.RepoAddr,
+			Commit:             doc.Commit,
+			Tag:                tag,
+			Version:            version,
+		})
+	}
+	sort.SliceStable(output, func(i, j int) bool { return output[i].Score > output[j].Score })
+	return output, nil
+}
+
+// estimateDiff estimates the number of files that changed from the number of
+// buckets that changed.
+func estimateDiff(bucketsChanged, fileCountDiff int) int {
+	bucketsChanged = min(bucketsChanged, BucketCount)
+	estimate := BucketCount * math.Log(float64(BucketCount+1)/float64(BucketCount-bucketsChanged+1))
+	return fileCountDiff + int(math.RoundToEven(max(estimate-float64(fileCountDiff), 0)/2))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// versionLabels are the pre-release labels kept as version components.
+var versionLabels = []string{"rc", "alpha", "beta", "preview"}
+
+// normalizeTag joins the version components of a tag with '-', as the
+// API's normalize_tag does: runs of digits, and pre-release labels with
+// their number unless they follow a letter.
+func normalizeTag(tag string) string {
+	var components []string
+	lower := strings.ToLower(tag)
+	for i := 0; i < len(lower); {
+		if isDigit(lower[i]) {
+			j := i + 1
+			for j < len(lower) && isDigit(lower[j]) {
+				j++
+			}
+			components = append(components, tag[i:j])
+			i = j
+			continue
+		}
+		label := ""
+		if i == 0 || !isLetter(lower[i-1]) {
+			for _, l := range versionLabels {
+				if strings.HasPrefix(lower[i:], l) {
+					label = l
+					break
+				}
+			}
+		}
+		if label == "" {
+			i++
+			continue
+		}
+		j := i + len(label)
+		for j < len(lower) && isDigit(lower[j]) {
+			j++
+		}
+		components = append(components, tag[i:j])
+		i = j
+	}
+	return strings.Join(components, "-")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
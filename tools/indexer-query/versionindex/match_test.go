@@ -0,0 +1,131 @@
+package versionindex
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"v1.2.3", "1-2-3"},
+		{"release-1_2_3", "1-2-3"},
+		{"v2.0.0-rc1", "2-0-0-rc1"},
+		{"1.0-BETA", "1-0-BETA"},
+		{"curl-7_88_1", "7-88-1"},
+		// Labels following a letter aren't labels, but their number is a
+		// component.
+		{"xrc1", "1"},
+		{"main", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTag(tt.tag); got != tt.want {
+			t.Errorf("normalizeTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateDiff(t *testing.T) {
+	tests := []struct {
+		buckets, files, want int
+	}{
+		{0, 0, 0},
+		{0, 3, 3},
+		{1, 0, 0},
+		{10, 0, 5},
+		{512, 0, 1598},
+	}
+	for _, tt := range tests {
+		if got := estimateDiff(tt.buckets, tt.files); got != tt.want {
+			t.Errorf("estimateDiff(%d, %d) = %d, want %d", tt.buckets, tt.files, got, tt.want)
+		}
+	}
+}
+
+// memSource is a Source of versions indexed from their files.
+type memSource struct {
+	buckets   map[string][]BucketMatch
+	documents map[string]*Document
+}
+
+func newMemSource() *memSource {
+	return &memSource{buckets: make(map[string][]BucketMatch), documents: make(map[string]*Document)}
+}
+
+func (s *memSource) add(key, tag string, files []FileHash) {
+	bitmap := make([]byte, BucketCount/8)
+	for i, b := range Buckets(files) {
+		if b.FilesContained == 0 {
+			continue
+		}
+		bitmap[i/8] |= 1 << (i % 8)
+		s.buckets[string(b.NodeHash)] = append(s.buckets[string(b.NodeHash)], BucketMatch{DocKey: key, FilesContained: b.FilesContained})
+	}
+	s.documents[key] = &Document{Key: key, RepoAddr: "https://example.com/lib", Tag: tag, FileCount: len(files), EmptyBucketBitmap: bitmap}
+}
+
+func (s *memSource) Lookup(_ context.Context, nodeHash []byte, limit int) ([]BucketMatch, error) {
+	matches := s.buckets[string(nodeHash)]
+	return matches[:min(limit, len(matches))], nil
+}
+
+func (s *memSource) Document(_ context.Context, key string) (*Document, error) {
+	return s.documents[key], nil
+}
+
+func fileHashes(prefix string, n int) []FileHash {
+	files := make([]FileHash, n)
+	for i := range files {
+		path := fmt.Sprintf("%s/%d.c", prefix, i)
+		files[i] = FileHash{Path: path, Hash: md5.Sum([]byte(path))}
+	}
+	return files
+}
+
+func TestMatch(t *testing.T) {
+	v1 := fileHashes("v1", 50)
+	// v2 changed 10 of v1's files.
+	v2 := append(fileHashes("v2", 10), v1[10:]...)
+	src := newMemSource()
+	src.add("v1", "refs/tags/v1.0", v1)
+	src.add("v2", "refs/tags/v2.0", v2)
+	src.add("other", "refs/tags/v9.9", fileHashes("other", 50))
+
+	matches, err := Match(context.Background(), src, v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("Match() = %+v, want 2 matches", matches)
+	}
+	if m := matches[0]; m.Version != "1.0" || m.Tag != "v1.0" || m.Score != 1 || m.EstimatedDiffFiles != 0 || m.MinimumFileMatches != 50 {
+		t.Errorf("Match()[0] = %+v, want an exact match of v1.0", m)
+	}
+	if m := matches[1]; m.Version != "2.0" || m.Score >= 1 || m.MinimumFileMatches > 40 {
+		t.Errorf("Match()[1] = %+v, want a partial match of v2.0", m)
+	}
+}
+
+func TestMatchSkipsCommonBuckets(t *testing.T) {
+	files := fileHashes("lib", 20)
+	src := newMemSource()
+	src.add("lib", "refs/tags/1.0", files)
+	// Make a bucket of the library common to too many versions to tell
+	// them apart.
+	common := Buckets(files)[0]
+	for i := len(src.buckets[string(common.NodeHash)]); i < maxMatchesToCare; i++ {
+		src.buckets[string(common.NodeHash)] = append(src.buckets[string(common.NodeHash)], BucketMatch{DocKey: fmt.Sprintf("fork-%d", i)})
+	}
+
+	matches, err := Match(context.Background(), src, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Score != 1 || matches[0].MinimumFileMatches != 20 {
+		t.Errorf("Match() = %+v, want an exact match of 1.0", matches)
+	}
+}
@@ -0,0 +1,285 @@
+package versionindex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// SnapshotFormatVersion is the latest version of the snapshot format, which
+// this package reads along with the earlier ones. Version 2 added deltas.
+const SnapshotFormatVersion = 2
+
+// maxSnapshotDepth bounds the chain of deltas a snapshot is loaded through.
+const maxSnapshotDepth = 1000
+
+// SnapshotIndexName is the name of a snapshot's index, which lists its
+// shards.
+const SnapshotIndexName = "index.json"
+
+// SnapshotLatestName is the name of the object the indexer points at the
+// directory of its latest snapshot with.
+const SnapshotLatestName = "LATEST"
+
+// A snapshot of the index is a directory, or GCS prefix, holding an
+// index.json SnapshotIndex and the shards it lists. Each shard is a gzipped
+// file of SnapshotDocuments, one JSON object per line. A delta snapshot only
+// holds the documents changed since the snapshot it's based on, a sibling
+// directory, and lists the documents removed since.
+
+// SnapshotIndex lists the shards of a snapshot.
+type SnapshotIndex struct {
+	FormatVersion int `json:"format_version"`
+	// Created is when the snapshot was exported, in RFC 3339 format.
+	Created string `json:"created"`
+	// DocumentVersion is the indexer's document version the snapshot's
+	// documents were indexed with.
+	DocumentVersion int             `json:"document_version"`
+	Shards          []SnapshotShard `json:"shards"`
+	// Base is the directory of the snapshot a delta is based on, relative to
+	// the snapshots' directory, or empty for a full snapshot.
+	Base string `json:"base,omitempty"`
+	// Depth is the number of deltas since the last full snapshot.
+	Depth int `json:"depth,omitempty"`
+	// Removed are the keys of the documents removed since Base.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// SnapshotShard is a shard of a snapshot.
+type SnapshotShard struct {
+	Name      string `json:"name"`
+	Documents int    `json:"documents"`
+	// SHA256 is the hex encoded SHA-256 checksum of the shard file.
+	SHA256 string `json:"sha256"`
+}
+
+// SnapshotDocument is an indexed version with its non-empty buckets.
+type SnapshotDocument struct {
+	Key               string           `json:"key"`
+	Name              string           `json:"name"`
+	RepoAddr          string           `json:"repo_addr"`
+	Tag               string           `json:"tag"`
+	Commit            string           `json:"commit"`
+	FileCount         int              `json:"file_count"`
+	EmptyBucketBitmap []byte           `json:"empty_bucket_bitmap"`
+	Buckets           []SnapshotBucket `json:"buckets"`
+}
+
+// SnapshotBucket is a bucket of a SnapshotDocument.
+type SnapshotBucket struct {
+	// Index is the index of the bucket, or -1 if it's unknown.
+	Index int `json:"index"`
+	// NodeHash is the hex encoded hash of the bucket.
+	NodeHash string `json:"node_hash"`
+	Files    int    `json:"files"`
+	// FileHashes are the hex encoded hashes of the bucket's files, if
+	// exported.
+	FileHashes []string `json:"file_hashes,omitempty"`
+}
+
+// SnapshotSource is an index loaded from a snapshot.
+type SnapshotSource struct {
+	// Index is the index of the snapshot loaded, the latest of a chain of
+	// deltas.
+	Index     SnapshotIndex
+	buckets   map[string][]BucketMatch
+	documents map[string]*Document
+	// nodeHashes are the bucket hashes of each document, to remove it.
+	nodeHashes map[string][]string
+}
+
+// LoadSnapshot loads the snapshot at loc, a directory or a gs://bucket/prefix
+// URL. GCS snapshots are read with client. If loc is where the indexer
+// publishes its snapshots, the latest is loaded, applying any deltas to the
+// full snapshot they're based on.
+func LoadSnapshot(ctx context.Context, client *storage.Client, loc string) (*SnapshotSource, error) {
+	open := func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(loc, name))
+	}
+	if rest, ok := strings.CutPrefix(loc, "gs://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("%q has no bucket", loc)
+		}
+		if client == nil {
+			return nil, fmt.Errorf("a storage client is needed to read %s", loc)
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		open = func(name string) (io.ReadCloser, error) {
+			return client.Bucket(bucket).Object(prefix + name).NewReader(ctx)
+		}
+	}
+	return loadSnapshot(open)
+}
+
+func loadSnapshot(open func(name string) (io.ReadCloser, error)) (*SnapshotSource, error) {
+	dir := ""
+	if r, err := open(SnapshotLatestName); err == nil {
+		buf, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", SnapshotLatestName, err)
+		}
+		dir = strings.TrimSpace(string(buf))
+	}
+	s := &SnapshotSource{
+		buckets:    make(map[string][]BucketMatch),
+		documents:  make(map[string]*Document),
+		nodeHashes: make(map[string][]string),
+	}
+	if err := s.load(open, dir, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load loads the snapshot in dir, after the snapshot it's a delta of.
+func (s *SnapshotSource) load(root func(name string) (io.ReadCloser, error), dir string, depth int) error {
+	open := func(name string) (io.ReadCloser, error) {
+		return root(dir + name)
+	}
+	r, err := open(SnapshotIndexName)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot index: %w", err)
+	}
+	var index SnapshotIndex
+	err = json.NewDecoder(r).Decode(&index)
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode snapshot index: %w", err)
+	}
+	if index.FormatVersion < 1 || index.FormatVersion > SnapshotFormatVersion {
+		return fmt.Errorf("unsupported snapshot format version %d, want at most %d", index.FormatVersion, SnapshotFormatVersion)
+	}
+	if index.Base != "" {
+		if dir == "" {
+			return fmt.Errorf("snapshot is a delta of %s, load it from the directory holding both", index.Base)
+		}
+		if depth >= maxSnapshotDepth {
+			return fmt.Errorf("more than %d deltas", maxSnapshotDepth)
+		}
+		if err := s.load(root, index.Base, depth+1); err != nil {
+			return fmt.Errorf("failed to load base %s: %w", index.Base, err)
+		}
+	}
+	for _, key := range index.Removed {
+		s.remove(key)
+	}
+	for _, shard := range index.Shards {
+		if err := s.loadShard(open, shard); err != nil {
+			return fmt.Errorf("failed to load shard %s: %w", shard.Name, err)
+		}
+	}
+	s.Index = index
+	return nil
+}
+
+func (s *SnapshotSource) loadShard(open func(name string) (io.ReadCloser, error), shard SnapshotShard) error {
+	r, err := open(shard.Name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	hasher := sha256.New()
+	gz, err := gzip.NewReader(io.TeeReader(r, hasher))
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(gz)
+	// Documents of large libraries have hundreds of buckets.
+	scanner.Buffer(nil, 16<<20)
+	documents := 0
+	for scanner.Scan() {
+		var doc SnapshotDocument
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			return fmt.Errorf("failed to decode document: %w", err)
+		}
+		s.add(&doc)
+		documents++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	// Read the rest of the file, so all of it is checksummed.
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return err
+	}
+	if _, err := io.Copy(hasher, r); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); shard.SHA256 != "" && sum != shard.SHA256 {
+		return fmt.Errorf("checksum %s doesn't match the index's %s", sum, shard.SHA256)
+	}
+	if documents != shard.Documents {
+		return fmt.Errorf("has %d documents, the index lists %d", documents, shard.Documents)
+	}
+	return nil
+}
+
+func (s *SnapshotSource) add(doc *SnapshotDocument) {
+	// A delta replaces the documents that changed.
+	s.remove(doc.Key)
+	s.documents[doc.Key] = &Document{
+		Key:               doc.Key,
+		Name:              doc.Name,
+		RepoAddr:          doc.RepoAddr,
+		Tag:               doc.Tag,
+		Commit:            doc.Commit,
+		FileCount:         doc.FileCount,
+		EmptyBucketBitmap: doc.EmptyBucketBitmap,
+	}
+	for _, b := range doc.Buckets {
+		hash := strings.ToLower(b.NodeHash)
+		s.buckets[hash] = append(s.buckets[hash], BucketMatch{DocKey: doc.Key, FilesContained: b.Files})
+		s.nodeHashes[doc.Key] = append(s.nodeHashes[doc.Key], hash)
+	}
+}
+
+// remove removes a document and its buckets, if it's loaded.
+func (s *SnapshotSource) remove(key string) {
+	if _, ok := s.documents[key]; !ok {
+		return
+	}
+	for _, hash := range s.nodeHashes[key] {
+		s.buckets[hash] = slices.DeleteFunc(s.buckets[hash], func(m BucketMatch) bool { return m.DocKey == key })
+		if len(s.buckets[hash]) == 0 {
+			delete(s.buckets, hash)
+		}
+	}
+	delete(s.nodeHashes, key)
+	delete(s.documents, key)
+}
+
+// Documents returns the number of documents in the snapshot.
+func (s *SnapshotSource) Documents() int {
+	return len(s.documents)
+}
+
+func (s *SnapshotSource) Lookup(_ context.Context, nodeHash []byte, limit int) ([]BucketMatch, error) {
+	matches := s.buckets[hex.EncodeToString(nodeHash)]
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (s *SnapshotSource) Document(_ context.Context, key string) (*Document, error) {
+	doc, ok := s.documents[key]
+	if !ok {
+		return nil, fmt.Errorf("no document %s in snapshot", key)
+	}
+	return doc, nil
+}
@@ -0,0 +1,186 @@
+package versionindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSnapshot(t *testing.T, dir string, docs []SnapshotDocument, corrupt bool) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	if corrupt {
+		sum[0]++
+	}
+	index := SnapshotIndex{
+		FormatVersion: SnapshotFormatVersion,
+		Created:       "2025-01-01T00:00:00Z",
+		Shards:        []SnapshotShard{{Name: "documents-00000.jsonl.gz", Documents: len(docs), SHA256: hex.EncodeToString(sum[:])}},
+	}
+	indexBuf, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SnapshotIndexName), indexBuf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, index.Shards[0].Name), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snapshotDocument returns the snapshot document of a version with files.
+func snapshotDocument(key, tag string, files []FileHash) SnapshotDocument {
+	doc := SnapshotDocument{Key: key, RepoAddr: "https://example.com/lib", Tag: tag, Commit: "abcdef", FileCount: len(files), EmptyBucketBitmap: make([]byte, BucketCount/8)}
+	for i, b := range Buckets(files) {
+		if b.FilesContained == 0 {
+			continue
+		}
+		doc.EmptyBucketBitmap[i/8] |= 1 << (i % 8)
+		doc.Buckets = append(doc.Buckets, SnapshotBucket{Index: i, NodeHash: hex.EncodeToString(b.NodeHash), Files: b.FilesContained})
+	}
+	return doc
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	files := fileHashes("lib", 30)
+	writeSnapshot(t, dir, []SnapshotDocument{
+		snapshotDocument("lib-1", "refs/tags/v1.2.3", files),
+		snapshotDocument("other", "refs/tags/v0.1", fileHashes("other", 30)),
+	}, false)
+
+	src, err := LoadSnapshot(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.Documents(); got != 2 {
+		t.Errorf("Documents() = %d, want 2", got)
+	}
+	matches, err := Match(context.Background(), src, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Version != "1.2.3" || matches[0].Commit != "abcdef" || matches[0].Score != 1 {
+		t.Errorf("Match() = %+v, want an exact match of 1.2.3", matches)
+	}
+}
+
+func TestSnapshotLatest(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "20250101T000000Z")
+	latest := filepath.Join(root, "20250201T000000Z")
+	for _, dir := range []string{old, latest} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSnapshot(t, old, nil, false)
+	writeSnapshot(t, latest, []SnapshotDocument{snapshotDocument("lib-1", "v1", fileHashes("lib", 3))}, false)
+	if err := os.WriteFile(filepath.Join(root, SnapshotLatestName), []byte("20250201T000000Z/"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := LoadSnapshot(context.Background(), nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.Documents(); got != 1 {
+		t.Errorf("Documents() = %d, want the latest snapshot's 1", got)
+	}
+}
+
+func TestSnapshotDeltas(t *testing.T) {
+	root := t.TempDir()
+	dirs := []string{"20250101T000000Z/", "20250102T000000Z/", "20250103T000000Z/"}
+	for _, dir := range dirs {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	libFiles, newLibFiles := fileHashes("lib", 30), fileHashes("lib-new", 30)
+	writeSnapshot(t, filepath.Join(root, dirs[0]), []SnapshotDocument{
+		snapshotDocument("lib-1", "refs/tags/v1.0", libFiles),
+		snapshotDocument("other", "refs/tags/v0.1", fileHashes("other", 30)),
+	}, false)
+	// The first delta changes lib-1, the second removes other.
+	writeSnapshot(t, filepath.Join(root, dirs[1]), []SnapshotDocument{
+		snapshotDocument("lib-1", "refs/tags/v1.1", newLibFiles),
+	}, false)
+	setDelta(t, filepath.Join(root, dirs[1]), dirs[0], nil)
+	writeSnapshot(t, filepath.Join(root, dirs[2]), nil, false)
+	setDelta(t, filepath.Join(root, dirs[2]), dirs[1], []string{"other"})
+	if err := os.WriteFile(filepath.Join(root, SnapshotLatestName), []byte(dirs[2]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := LoadSnapshot(context.Background(), nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.Documents(); got != 1 {
+		t.Errorf("Documents() = %d, want 1", got)
+	}
+	if matches, err := Match(context.Background(), src, libFiles); err != nil || len(matches) != 0 {
+		t.Errorf("Match() of the replaced version = %+v, %v, want no matches", matches, err)
+	}
+	matches, err := Match(context.Background(), src, newLibFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Version != "1.1" {
+		t.Errorf("Match() = %+v, want an exact match of 1.1", matches)
+	}
+
+	// A delta can't be loaded on its own.
+	if _, err := LoadSnapshot(context.Background(), nil, filepath.Join(root, dirs[2])); err == nil {
+		t.Errorf("LoadSnapshot() of a delta succeeded, want an error")
+	}
+}
+
+// setDelta makes the snapshot in dir a delta of base.
+func setDelta(t *testing.T, dir, base string, removed []string) {
+	t.Helper()
+	buf, err := os.ReadFile(filepath.Join(dir, SnapshotIndexName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index SnapshotIndex
+	if err := json.Unmarshal(buf, &index); err != nil {
+		t.Fatal(err)
+	}
+	index.Base, index.Depth, index.Removed = base, 1, removed
+	if buf, err = json.Marshal(index); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SnapshotIndexName), buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeSnapshot(t, dir, []SnapshotDocument{snapshotDocument("lib-1", "v1", fileHashes("lib", 3))}, true)
+	_, err := LoadSnapshot(context.Background(), nil, dir)
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("LoadSnapshot() error = %v, want a checksum mismatch", err)
+	}
+}
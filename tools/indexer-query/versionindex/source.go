@@ -0,0 +1,36 @@
+// Package versionindex matches the source files of a library against the
+// determineversion index, like the determineversion API does, but with the
+// index read from Datastore or a published snapshot of it, so it can be
+// queried offline.
+package versionindex
+
+import "context"
+
+// BucketMatch is an indexed version with a bucket of the queried hash.
+type BucketMatch struct {
+	// DocKey identifies the version's Document.
+	DocKey         string
+	FilesContained int
+}
+
+// Document is an indexed version of a library.
+type Document struct {
+	Key      string
+	Name     string
+	RepoAddr string
+	Tag      string
+	// Commit is the hex encoded commit hash of the version.
+	Commit    string
+	FileCount int
+	// EmptyBucketBitmap has a bit set for each bucket holding files, with
+	// bucket i at bit i%8 of byte i/8.
+	EmptyBucketBitmap []byte
+}
+
+// Source is the index matches are looked up in.
+type Source interface {
+	// Lookup returns up to limit versions with a bucket of the hash.
+	Lookup(ctx context.Context, nodeHash []byte, limit int) ([]BucketMatch, error)
+	// Document returns the version with the key returned by Lookup.
+	Document(ctx context.Context, key string) (*Document, error)
+}
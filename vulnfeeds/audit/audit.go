@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records one audit event per converted record per run, for
+// longitudinal analysis of converter behavior in BigQuery.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// The number of events buffered before they are inserted.
+const defaultBatchSize = 500
+
+// Decision is a decision point a converter took for a record, e.g. which
+// method derived its repository.
+type Decision struct {
+	Name  string `bigquery:"name"`
+	Value string `bigquery:"value"`
+}
+
+// Event is the audit row of a converted record.
+type Event struct {
+	RunID     string     `bigquery:"run_id"`
+	Converter string     `bigquery:"converter"`
+	RecordID  string     `bigquery:"record_id"`
+	Sources   []string   `bigquery:"sources"`
+	Decisions []Decision `bigquery:"decisions"`
+	Timestamp time.Time  `bigquery:"timestamp"`
+}
+
+// inserter inserts rows, e.g. a *bigquery.Inserter.
+type inserter interface {
+	Put(ctx context.Context, src any) error
+}
+
+// Sink buffers audit events and inserts them in batches.
+// A nil *Sink discards all events, so auditing can be optional.
+type Sink struct {
+	converter string
+	runID     string
+	inserter  inserter
+	batchSize int
+	buf       []Event
+	err       error
+	now       func() time.Time
+}
+
+func newSink(converter string, runID string, ins inserter) *Sink {
+	return &Sink{
+		converter: converter,
+		runID:     runID,
+		inserter:  ins,
+		batchSize: defaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// NewBigQuerySink creates a sink inserting into table, given as
+// "project.dataset.table". The table must already exist with the schema of
+// Event, see InferSchema.
+func NewBigQuerySink(ctx context.Context, table string, converter string, runID string) (*Sink, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid BigQuery table %q, expected project.dataset.table", table)
+	}
+	client, err := bigquery.NewClient(ctx, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return newSink(converter, runID, client.Dataset(parts[1]).Table(parts[2]).Inserter()), nil
+}
+
+// InferSchema returns the BigQuery schema of the audit table.
+func InferSchema() (bigquery.Schema, error) {
+	return bigquery.InferSchema(Event{})
+}
+
+// Record adds an audit event for a converted record, inserting the buffered
+// events once a batch is full. Insertion errors are returned by Close.
+func (s *Sink) Record(ctx context.Context, recordID string, sources []string, decisions ...Decision) {
+	if s == nil {
+		return
+	}
+	s.buf = append(s.buf, Event{
+		RunID:     s.runID,
+		Converter: s.converter,
+		RecordID:  recordID,
+		Sources:   sources,
+		Decisions: decisions,
+		Timestamp: s.now().UTC(),
+	})
+	if len(s.buf) >= s.batchSize {
+		s.flush(ctx)
+	}
+}
+
+func (s *Sink) flush(ctx context.Context) {
+	if len(s.buf) == 0 {
+		return
+	}
+	if err := s.inserter.Put(ctx, s.buf); err != nil && s.err == nil {
+		s.err = fmt.Errorf("failed to insert %d audit events: %w", len(s.buf), err)
+	}
+	s.buf = nil
+}
+
+// Close inserts any buffered events and returns the first insertion error.
+func (s *Sink) Close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.flush(ctx)
+	return s.err
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+type fakeInserter struct {
+	batches [][]Event
+	err     error
+}
+
+func (f *fakeInserter) Put(_ context.Context, src any) error {
+	f.batches = append(f.batches, src.([]Event))
+	return f.err
+}
+
+func TestSink(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	ins := &fakeInserter{}
+	sink := newSink("combine-to-osv", "run-1", ins)
+	sink.batchSize = 2
+	sink.now = func() time.Time { return now }
+
+	sink.Record(ctx, "CVE-2024-0001", []string{"nvd-cve", "alpine"}, Decision{Name: "withdrawn", Value: "false"})
+	sink.Record(ctx, "CVE-2024-0002", []string{"nvd-cve"})
+	sink.Record(ctx, "CVE-2024-0003", nil)
+	if err := sink.Close(ctx); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	want := [][]Event{
+		{
+			{RunID: "run-1", Converter: "combine-to-osv", RecordID: "CVE-2024-0001", Sources: []string{"nvd-cve", "alpine"}, Decisions: []Decision{{Name: "withdrawn", Value: "false"}}, Timestamp: now},
+			{RunID: "run-1", Converter: "combine-to-osv", RecordID: "CVE-2024-0002", Sources: []string{"nvd-cve"}, Timestamp: now},
+		},
+		{
+			{RunID: "run-1", Converter: "combine-to-osv", RecordID: "CVE-2024-0003", Timestamp: now},
+		},
+	}
+	if diff := gocmp.Diff(want, ins.batches); diff != "" {
+		t.Errorf("inserted batches mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	ctx := context.Background()
+	ins := &fakeInserter{err: errors.New("quota exceeded")}
+	sink := newSink("nvd-cve-osv", "run-1", ins)
+	sink.Record(ctx, "CVE-2024-0001", nil)
+	if err := sink.Close(ctx); err == nil {
+		t.Errorf("Close() succeeded, want insertion error")
+	}
+}
+
+func TestNilSink(t *testing.T) {
+	var sink *Sink
+	sink.Record(context.Background(), "CVE-2024-0001", nil)
+	if err := sink.Close(context.Background()); err != nil {
+		t.Errorf("Close() of nil sink error: %v", err)
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema()
+	if err != nil {
+		t.Fatalf("InferSchema() error: %v", err)
+	}
+	var names []string
+	for _, field := range schema {
+		names = append(names, field.Name)
+	}
+	want := []string{"run_id", "converter", "record_id", "sources", "decisions", "timestamp"}
+	if diff := gocmp.Diff(want, names); diff != "" {
+		t.Errorf("InferSchema() fields mismatch (-want +got):\n%s", diff)
+	}
+}
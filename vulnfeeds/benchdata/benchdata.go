@@ -0,0 +1,242 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchdata builds the datasets the combine pipeline is benchmarked
+// on, by scaling up the recorded NVD CVEs and parts of the test fixtures to
+// realistic volumes. Each copy of a fixture CVE gets an ID of its own, and
+// its parts are copied under that ID, so the dataset has as many distinct
+// CVEs, with as many parts each, as the fixtures times the scale.
+package benchdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+// DataEnv is the environment variable naming the dataset the benchmarks run
+// on, rather than one they generate.
+const DataEnv = "VULNFEEDS_BENCH_DATA"
+
+// ScaleEnv is the environment variable setting the scale of the dataset the
+// benchmarks generate, DefaultScale if unset.
+const ScaleEnv = "VULNFEEDS_BENCH_SCALE"
+
+// DefaultScale is the scale of the generated dataset by default.
+const DefaultScale = 50
+
+// The layout of a dataset: the NVD CVEs, in files of up to CVEsPerFile (as
+// download-cves writes them), and the parts, as combine-to-osv reads them.
+const (
+	CVEsDir     = "cves"
+	PartsDir    = "parts"
+	CVEsPerFile = 2000
+)
+
+// Stats describe a generated dataset.
+type Stats struct {
+	CVEs  int `json:"cves"`
+	Parts int `json:"parts"`
+}
+
+// copyID returns the ID of copy n of a CVE: its number with n appended, so
+// copies stay valid CVE IDs of the same year.
+func copyID(id cves.CVEID, n int) (cves.CVEID, error) {
+	year, number, err := ids.CVEYearAndNumber(id)
+	if err != nil {
+		return "", err
+	}
+	return cves.CVEID(fmt.Sprintf("CVE-%s-%s%04d", year, number, n)), nil
+}
+
+// Generate writes scale copies of the CVEs of the NVD files in cvesFixtures
+// and of the parts under partsFixtures (a directory per feed) into dir.
+// Parts are written in the current version of the part format, whatever the
+// version of the fixture.
+func Generate(dir, cvesFixtures, partsFixtures string, scale int) (Stats, error) {
+	var stats Stats
+	if scale < 1 || scale > 9999 {
+		return stats, fmt.Errorf("scale %d isn't between 1 and 9999", scale)
+	}
+	fixtureCVEs, err := loadCVEs(cvesFixtures)
+	if err != nil {
+		return stats, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, CVEsDir), 0755); err != nil {
+		return stats, err
+	}
+	var batch []cves.Vulnerability
+	files := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		files++
+		page := cves.CVEAPIJSON20Schema{
+			ResultsPerPage:  len(batch),
+			TotalResults:    len(batch),
+			Format:          "NVD_CVE",
+			Version:         "2.0",
+			Vulnerabilities: batch,
+		}
+		batch = nil
+		return writeJSON(filepath.Join(dir, CVEsDir, fmt.Sprintf("nvdcve-2.0-%04d.json", files)), page)
+	}
+	for n := range scale {
+		for _, fixture := range fixtureCVEs {
+			id, err := copyID(fixture.CVE.ID, n)
+			if err != nil {
+				return stats, err
+			}
+			copied := fixture
+			copied.CVE.ID = id
+			batch = append(batch, copied)
+			stats.CVEs++
+			if len(batch) == CVEsPerFile {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return stats, err
+	}
+
+	feeds, err := os.ReadDir(partsFixtures)
+	if err != nil {
+		return stats, err
+	}
+	for _, feed := range feeds {
+		if !feed.IsDir() {
+			continue
+		}
+		n, err := copyParts(filepath.Join(partsFixtures, feed.Name()), filepath.Join(dir, PartsDir, feed.Name()), scale)
+		if err != nil {
+			return stats, fmt.Errorf("copying %s parts: %w", feed.Name(), err)
+		}
+		stats.Parts += n
+	}
+	return stats, nil
+}
+
+// loadCVEs loads the CVEs of the NVD files in dir, ordered by ID.
+func loadCVEs(dir string) ([]cves.Vulnerability, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var result []cves.Vulnerability
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var page cves.CVEAPIJSON20Schema
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", entry.Name(), err)
+		}
+		result = append(result, page.Vulnerabilities...)
+	}
+	slices.SortFunc(result, func(a, b cves.Vulnerability) int {
+		return strings.Compare(string(a.CVE.ID), string(b.CVE.ID))
+	})
+	return result, nil
+}
+
+// copyParts writes scale copies of the parts in fixtureDir (named
+// <CVE ID><feed suffix>) into outDir, returning how many it wrote.
+func copyParts(fixtureDir, outDir string, scale int) (int, error) {
+	entries, err := os.ReadDir(fixtureDir)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, err
+	}
+	written := 0
+	for _, entry := range entries {
+		id, suffix, ok := strings.Cut(entry.Name(), ".")
+		if entry.IsDir() || !ok || !strings.HasSuffix(suffix, "json") {
+			continue
+		}
+		cveID, err := ids.ParseCVE(id)
+		if err != nil {
+			continue
+		}
+		file, err := os.Open(filepath.Join(fixtureDir, entry.Name()))
+		if err != nil {
+			return written, err
+		}
+		part, err := parts.Decode(file)
+		file.Close()
+		if err != nil {
+			return written, fmt.Errorf("decoding %s: %w", entry.Name(), err)
+		}
+		for n := range scale {
+			copied, err := copyID(cveID, n)
+			if err != nil {
+				return written, err
+			}
+			if err := parts.Write(filepath.Join(outDir, string(copied)+"."+suffix), part.Packages); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// Use returns the dataset a benchmark runs on: the one in DataEnv if it's
+// set, or else one generated from the fixtures in testData (the vulnfeeds
+// test_data directory) at the scale in ScaleEnv, for the rest of the
+// benchmark.
+func Use(tb testing.TB, testData string) string {
+	tb.Helper()
+	if dir := os.Getenv(DataEnv); dir != "" {
+		return dir
+	}
+	scale := DefaultScale
+	if env := os.Getenv(ScaleEnv); env != "" {
+		var err error
+		if scale, err = strconv.Atoi(env); err != nil {
+			tb.Fatalf("Invalid $%s %q: %v", ScaleEnv, env, err)
+		}
+	}
+	dir := tb.TempDir()
+	if _, err := Generate(dir, filepath.Join(testData, "nvdcve-2.0"), filepath.Join(testData, "parts"), scale); err != nil {
+		tb.Fatalf("Failed to generate the dataset: %v", err)
+	}
+	return dir
+}
+
+func writeJSON(fileName string, v any) error {
+	return utility.WriteFileAtomic(fileName, 0644, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
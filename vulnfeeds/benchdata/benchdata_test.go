@@ -0,0 +1,63 @@
+package benchdata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/parts"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	stats, err := Generate(dir, "../test_data/nvdcve-2.0", "../test_data/parts", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtureCVEs, err := loadCVEs("../test_data/nvdcve-2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CVEs != 3*len(fixtureCVEs) {
+		t.Errorf("Generate() wrote %d CVEs, want %d", stats.CVEs, 3*len(fixtureCVEs))
+	}
+	generated, err := loadCVEs(filepath.Join(dir, CVEsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, v := range generated {
+		seen[string(v.CVE.ID)] = true
+	}
+	if len(seen) != stats.CVEs {
+		t.Errorf("Generate() wrote %d distinct CVEs, want %d", len(seen), stats.CVEs)
+	}
+
+	// Every copy of a part is in the current format, under the ID of the
+	// copy of its CVE.
+	for _, n := range []int{0, 2} {
+		id, err := copyID("CVE-2022-32746", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !seen[string(id)] {
+			t.Errorf("copy %d of CVE-2022-32746 (%s) wasn't generated", n, id)
+		}
+		file, err := os.Open(filepath.Join(dir, PartsDir, "alpine", string(id)+".alpine.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		part, err := parts.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Version != parts.Version || len(part.Packages) == 0 {
+			t.Errorf("part of %s = version %d with %d packages, want version %d with packages", id, part.Version, len(part.Packages), parts.Version)
+		}
+	}
+
+	if _, err := Generate(t.TempDir(), "../test_data/nvdcve-2.0", "../test_data/parts", 0); err == nil {
+		t.Errorf("Generate() at scale 0 succeeded")
+	}
+}
@@ -5,19 +5,38 @@ set -x
 # Set working dir to script dir
 cd "$(dirname "$0")"
 
-docker build -t gcr.io/oss-vdb/alpine-cve-convert -f cmd/alpine/Dockerfile .
-docker build -t gcr.io/oss-vdb/debian-cve-convert -f cmd/debian/Dockerfile .
-docker build -t gcr.io/oss-vdb/combine-to-osv -f cmd/combine-to-osv/Dockerfile .
-docker build -t gcr.io/oss-vdb/debian-copyright-mirror -f cmd/debian-copyright-mirror/Dockerfile .
-docker build -t gcr.io/oss-vdb/cpe-repo-gen -f cmd/cpe-repo-gen/Dockerfile .
+# Embed the revision in the binaries, since the build context has no .git.
+REVISION="$(git rev-parse HEAD)"
+
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/alpine-cve-convert -f cmd/alpine/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/debian-cve-convert -f cmd/debian/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/openeuler-cve-convert -f cmd/openeuler/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/fedora-cve-convert -f cmd/fedora/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/photon-cve-convert -f cmd/photon/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/alas-cve-convert -f cmd/alas/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/linux-cve-convert -f cmd/linux/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/ghsa-cve-convert -f cmd/ghsa/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/osv-mirror -f cmd/osv-mirror/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/combine-to-osv -f cmd/combine-to-osv/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/debian-copyright-mirror -f cmd/debian-copyright-mirror/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/cpe-repo-gen -f cmd/cpe-repo-gen/Dockerfile .
+docker build --build-arg CONVERTER_REVISION="$REVISION" -t gcr.io/oss-vdb/cpe-index -f cmd/cpe-index/Dockerfile .
 
 if [ "$1" = "deploy" ]
 then
   docker push gcr.io/oss-vdb/alpine-cve-convert:latest
   docker push gcr.io/oss-vdb/debian-cve-convert:latest
+  docker push gcr.io/oss-vdb/openeuler-cve-convert:latest
+  docker push gcr.io/oss-vdb/fedora-cve-convert:latest
+  docker push gcr.io/oss-vdb/photon-cve-convert:latest
+  docker push gcr.io/oss-vdb/alas-cve-convert:latest
+  docker push gcr.io/oss-vdb/linux-cve-convert:latest
+  docker push gcr.io/oss-vdb/ghsa-cve-convert:latest
+  docker push gcr.io/oss-vdb/osv-mirror:latest
   docker push gcr.io/oss-vdb/combine-to-osv:latest
   docker push gcr.io/oss-vdb/debian-copyright-mirror:latest
   docker push gcr.io/oss-vdb/cpe-repo-gen:latest
+  docker push gcr.io/oss-vdb/cpe-index:latest
 else
   echo "Run with the deploy command to push built images"
 fi
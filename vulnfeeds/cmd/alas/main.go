@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	alasOutputPathDefault = "parts/alas"
+	amazonLinuxEcosystem  = "Amazon Linux"
+	// alasMirrorListsDefault are the mirror lists of the repositories whose
+	// ALAS advisories are converted, as release=mirror list URL.
+	alasMirrorListsDefault = "1=https://repo.us-west-2.amazonaws.com/2018.03/updates/x86_64/mirror.list," +
+		"2=https://cdn.amazonlinux.com/2/core/latest/x86_64/mirror.list," +
+		"2023=https://cdn.amazonlinux.com/al2023/core/mirrors/latest/x86_64/mirror.list"
+)
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+// releaseUpdateInfo is the updateinfo of an Amazon Linux release.
+type releaseUpdateInfo struct {
+	release    string
+	updateInfo rpm.UpdateInfo
+}
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("alas-osv")
+	defer logCleanup()
+
+	alasOutputPath := flag.String("alasOutput", alasOutputPathDefault, "path to output general Amazon Linux affected package information")
+	mirrorLists := flag.String("mirrorLists", alasMirrorListsDefault, "Comma separated release=mirror list URL Amazon Linux repositories to convert the ALAS advisories of")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	err := os.MkdirAll(*alasOutputPath, 0755)
+	if err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+
+	var advisories []releaseUpdateInfo
+	for _, item := range strings.Split(*mirrorLists, ",") {
+		release, mirrorListURL, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || release == "" || mirrorListURL == "" {
+			Logger.Fatalf("Invalid mirror list %q, want release=URL", item)
+		}
+		repoURL, err := resolveMirror(mirrorListURL)
+		if err != nil {
+			Logger.Warnf("Failed to resolve the Amazon Linux %s repository: %s", release, err)
+			continue
+		}
+		updateInfo, err := rpm.DownloadUpdateInfo(repoURL)
+		if err != nil {
+			Logger.Warnf("Failed to get the updateinfo of Amazon Linux %s: %s", release, err)
+			continue
+		}
+		advisories = append(advisories, releaseUpdateInfo{release: release, updateInfo: updateInfo})
+	}
+
+	cvePkgInfos := generateALASOSV(advisories)
+	if err = writeToOutput(cvePkgInfos, *alasOutputPath); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+	if err := outputFlags.Finish("alas-osv"); err != nil {
+		Logger.Fatalf("Amazon Linux ALAS conversion failed: %s", err)
+	}
+
+	Logger.Infof("Amazon Linux ALAS conversion succeeded.")
+}
+
+// parseMirrorList returns the first mirror of a repository's mirror list.
+func parseMirrorList(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasSuffix(line, "/") {
+			line += "/"
+		}
+		return line, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("empty mirror list")
+}
+
+// resolveMirror downloads a mirror list and returns its first mirror, the
+// URL of the repository of the release's latest version.
+func resolveMirror(mirrorListURL string) (string, error) {
+	res, err := faulttolerant.Get(mirrorListURL)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+
+	return parseMirrorList(res.Body)
+}
+
+// generateALASOSV converts Amazon Linux ALAS advisories to OSV PackageInfo
+// format, with one fixed version per source package and Amazon Linux release.
+func generateALASOSV(advisories []releaseUpdateInfo) map[string][]vulns.PackageInfo {
+	// Sorts releases to ensure results remain consistent between runs.
+	sort.SliceStable(advisories, func(i, j int) bool {
+		return advisories[i].release < advisories[j].release
+	})
+
+	cveFixes := make(rpm.Fixes)
+	for _, advisory := range advisories {
+		Logger.Infof("Converting %d Amazon Linux %s advisories to OSV package infos.", len(advisory.updateInfo.Updates), advisory.release)
+		for _, update := range advisory.updateInfo.Updates {
+			if update.Type != "security" {
+				continue
+			}
+			cveIDs := update.CVEs()
+			if len(cveIDs) == 0 {
+				continue
+			}
+			for _, pkg := range update.Packages {
+				name, _, ok := rpm.SplitSourceRPM(pkg.Src)
+				if !ok {
+					Logger.Warnf("Invalid source RPM %q in %s", pkg.Src, update.ID)
+					continue
+				}
+				evr, err := pkg.EVR()
+				if err != nil {
+					Logger.Warnf("%s in %s", err, update.ID)
+					continue
+				}
+				key := rpm.PackageKey{Ecosystem: amazonLinuxEcosystem + ":" + advisory.release, Name: name}
+				for _, cveID := range cveIDs {
+					cveFixes.Add(cveID, key, rpm.Fix{EVR: evr, Advisory: update.ID, Severity: update.Severity})
+				}
+			}
+		}
+	}
+
+	return cveFixes.PackageInfos(func(key rpm.PackageKey) string {
+		release := strings.TrimPrefix(key.Ecosystem, amazonLinuxEcosystem+":")
+		return purl.RPM("amazon", key.Name, "amazonlinux-"+release)
+	})
+}
+
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, outputPath string) error {
+	Logger.Infof("Writing package infos to the output.")
+	for cveId, pkgInfos := range cvePkgInfos {
+		filePath := path.Join(outputPath, cveId+".alas.json")
+		if err := parts.Write(filePath, pkgInfos); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(pkgInfos))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func Test_parseMirrorList(t *testing.T) {
+	got, err := parseMirrorList(strings.NewReader("# Amazon Linux 2 core\n\nhttps://cdn.amazonlinux.com/2/core/2.0/x86_64/6b0225ccc542f3834c95733dcf321ab9f1e77e6ca6817469771a8af7c49efe6c\n"))
+	if err != nil {
+		t.Fatalf("parseMirrorList() error: %v", err)
+	}
+	if want := "https://cdn.amazonlinux.com/2/core/2.0/x86_64/6b0225ccc542f3834c95733dcf321ab9f1e77e6ca6817469771a8af7c49efe6c/"; got != want {
+		t.Errorf("parseMirrorList() = %q, want %q", got, want)
+	}
+	if _, err := parseMirrorList(strings.NewReader("# no mirrors\n")); err == nil {
+		t.Error("parseMirrorList() of an empty mirror list succeeded")
+	}
+}
+
+func Test_generateALASOSV(t *testing.T) {
+	file, err := os.Open("../../test_data/alas/updateinfo.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	updateInfo, err := rpm.ParseUpdateInfo(file)
+	if err != nil {
+		t.Fatalf("ParseUpdateInfo() error: %v", err)
+	}
+
+	got := generateALASOSV([]releaseUpdateInfo{{release: "2", updateInfo: updateInfo}})
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2023-2650": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Amazon Linux:2",
+				PURL:      "pkg:rpm/amazon/openssl?distro=amazonlinux-2",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:1.0.2k-24.amzn2.0.7"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "ALAS2-2023-2093", "severity": "important"},
+			},
+		},
+		// The earlier advisory fixing the CVE wins.
+		"CVE-2023-0464": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Amazon Linux:2",
+				PURL:      "pkg:rpm/amazon/openssl?distro=amazonlinux-2",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:1.0.2k-24.amzn2.0.6"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "ALAS2-2023-2041", "severity": "important"},
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("generateALASOSV() mismatch (-want +got):\n%s", diff)
+	}
+}
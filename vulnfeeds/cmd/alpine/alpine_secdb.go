@@ -1,15 +1,159 @@
 package main
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
 type AlpineSecDB struct {
-	ApkURL        string   `json:"apkurl"`
-	Archs         []string `json:"archs"`
-	RepoName      string   `json:"reponame"`
-	URLPrefix     string   `json:"urlprefix"`
-	DistroVersion string   `json:"distroversion"`
-	Packages      []struct {
-		Pkg struct {
-			Name     string              `json:"name"`
-			SecFixes map[string][]string `json:"secfixes"`
-		} `json:"pkg"`
-	} `json:"packages"`
+	ApkURL        string               `json:"apkurl"`
+	Archs         []string             `json:"archs"`
+	RepoName      string               `json:"reponame"`
+	URLPrefix     string               `json:"urlprefix"`
+	DistroVersion string               `json:"distroversion"`
+	Packages      []AlpineSecDBPackage `json:"packages"`
+}
+
+type AlpineSecDBPackage struct {
+	Pkg struct {
+		Name     string              `json:"name"`
+		SecFixes map[string][]string `json:"secfixes"`
+	} `json:"pkg"`
+}
+
+// SecDBFormatError reports that a secdb file no longer matches AlpineSecDB,
+// which usually means the upstream format changed.
+type SecDBFormatError struct {
+	Version string
+	Err     error
+}
+
+func (e *SecDBFormatError) Error() string {
+	return fmt.Sprintf("alpine secdb for %s doesn't match the expected format, the upstream format may have changed (see AlpineSecDB): %v", e.Version, e.Err)
+}
+
+func (e *SecDBFormatError) Unwrap() error {
+	return e.Err
+}
+
+// secDBKeys are the keys AlpineSecDB expects, indexed by their shim form.
+var secDBKeys = map[string]string{
+	"apkurl":        "apkurl",
+	"archs":         "archs",
+	"reponame":      "reponame",
+	"urlprefix":     "urlprefix",
+	"distroversion": "distroversion",
+	"packages":      "packages",
+	"pkg":           "pkg",
+	"name":          "name",
+	"secfixes":      "secfixes",
+}
+
+// shimKey maps renamed keys (e.g. "distroVersion" or "distro_version") back
+// to the key AlpineSecDB expects. Unrecognized keys are returned unchanged.
+func shimKey(key string) string {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	if canonical, ok := secDBKeys[normalized]; ok {
+		return canonical
+	}
+	return key
+}
+
+// applySecDBShim rewrites the keys of the secdb structure (but not the
+// version keys of secfixes) to those AlpineSecDB expects.
+// Returns whether anything was rewritten.
+func applySecDBShim(v any, inSecFixes bool) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		if inSecFixes {
+			return false
+		}
+		for key, value := range v {
+			canonical := shimKey(key)
+			if applySecDBShim(value, canonical == "secfixes") {
+				changed = true
+			}
+			if canonical != key {
+				delete(v, key)
+				v[canonical] = value
+				changed = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if applySecDBShim(value, false) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// strictDecodeSecDB decodes data into an AlpineSecDB, failing on unknown fields.
+func strictDecodeSecDB(data []byte) (AlpineSecDB, error) {
+	var secdb AlpineSecDB
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&secdb)
+	return secdb, err
+}
+
+// validateSecDB checks the fields the conversion relies on are present.
+func validateSecDB(secdb AlpineSecDB) error {
+	if secdb.DistroVersion == "" {
+		return errors.New("missing distroversion")
+	}
+	if secdb.Packages == nil {
+		return errors.New("missing packages")
+	}
+	for i, pkg := range secdb.Packages {
+		if pkg.Pkg.Name == "" {
+			return fmt.Errorf("packages[%d]: missing pkg.name", i)
+		}
+		if pkg.Pkg.SecFixes == nil {
+			return fmt.Errorf("packages[%d] (%s): missing pkg.secfixes", i, pkg.Pkg.Name)
+		}
+	}
+	return nil
+}
+
+// decodeAlpineSecDB strictly decodes a secdb file. If it contains unknown
+// fields, renamed keys are mapped back through a compatibility shim before
+// giving up, so small upstream changes don't silently produce empty output.
+func decodeAlpineSecDB(r io.Reader, version string) (AlpineSecDB, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return AlpineSecDB{}, err
+	}
+
+	secdb, err := strictDecodeSecDB(data)
+	if err != nil {
+		var raw any
+		if jsonErr := json.Unmarshal(data, &raw); jsonErr != nil {
+			return AlpineSecDB{}, &SecDBFormatError{Version: version, Err: jsonErr}
+		}
+		if !applySecDBShim(raw, false) {
+			return AlpineSecDB{}, &SecDBFormatError{Version: version, Err: err}
+		}
+		shimmed, marshalErr := json.Marshal(raw)
+		if marshalErr != nil {
+			return AlpineSecDB{}, &SecDBFormatError{Version: version, Err: marshalErr}
+		}
+		secdb, err = strictDecodeSecDB(shimmed)
+		if err != nil {
+			return AlpineSecDB{}, &SecDBFormatError{Version: version, Err: err}
+		}
+		Logger.Warnf("Alpine secdb for %s has renamed fields, decoded through compatibility shim", version)
+	}
+
+	if err := validateSecDB(secdb); err != nil {
+		return AlpineSecDB{}, &SecDBFormatError{Version: version, Err: err}
+	}
+
+	return secdb, nil
 }
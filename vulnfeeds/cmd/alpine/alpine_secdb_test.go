@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func Test_decodeAlpineSecDB(t *testing.T) {
+	want := AlpineSecDB{
+		ApkURL:        "{{urlprefix}}/{{distroversion}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk",
+		Archs:         []string{"x86_64"},
+		RepoName:      "main",
+		URLPrefix:     "https://dl-cdn.alpinelinux.org/alpine",
+		DistroVersion: "v3.19",
+		Packages:      []AlpineSecDBPackage{{}},
+	}
+	want.Packages[0].Pkg.Name = "openssl"
+	want.Packages[0].Pkg.SecFixes = map[string][]string{"3.1.4-r1": {"CVE-2023-5363"}}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    AlpineSecDB
+		wantErr string
+	}{
+		{
+			name: "current format",
+			input: `{"apkurl": "{{urlprefix}}/{{distroversion}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk",
+				"archs": ["x86_64"], "reponame": "main", "urlprefix": "https://dl-cdn.alpinelinux.org/alpine",
+				"distroversion": "v3.19",
+				"packages": [{"pkg": {"name": "openssl", "secfixes": {"3.1.4-r1": ["CVE-2023-5363"]}}}]}`,
+			want: want,
+		},
+		{
+			name: "renamed fields are shimmed",
+			input: `{"apkUrl": "{{urlprefix}}/{{distroversion}}/{{reponame}}/{{arch}}/{{pkg.name}}-{{pkg.ver}}.apk",
+				"archs": ["x86_64"], "repo_name": "main", "urlPrefix": "https://dl-cdn.alpinelinux.org/alpine",
+				"distro_version": "v3.19",
+				"packages": [{"pkg": {"name": "openssl", "secFixes": {"3.1.4-r1": ["CVE-2023-5363"]}}}]}`,
+			want: want,
+		},
+		{
+			name: "unknown field",
+			input: `{"distroversion": "v3.19",
+				"packages": [{"pkg": {"name": "openssl", "fixes": {"3.1.4-r1": ["CVE-2023-5363"]}}}]}`,
+			wantErr: `unknown field "fixes"`,
+		},
+		{
+			name:    "missing packages",
+			input:   `{"distroversion": "v3.19"}`,
+			wantErr: "missing packages",
+		},
+		{
+			name:    "missing secfixes",
+			input:   `{"distroversion": "v3.19", "packages": [{"pkg": {"name": "openssl"}}]}`,
+			wantErr: "packages[0] (openssl): missing pkg.secfixes",
+		},
+		{
+			name:    "not json",
+			input:   `<html></html>`,
+			wantErr: "invalid character",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAlpineSecDB(strings.NewReader(tt.input), "v3.19")
+			if tt.wantErr != "" {
+				var formatErr *SecDBFormatError
+				if !errors.As(err, &formatErr) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeAlpineSecDB() error = %v, want SecDBFormatError containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeAlpineSecDB() unexpected error: %v", err)
+			}
+			if diff := gocmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("decodeAlpineSecDB() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
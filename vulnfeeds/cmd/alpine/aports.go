@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+const (
+	aportsURL = "https://gitlab.alpinelinux.org/alpine/aports.git"
+	// aportsMainBranch is the development branch the stable branches are
+	// forked from.
+	aportsMainBranch = "master"
+)
+
+// apkbuildAssignment matches the top level variable assignments of an
+// APKBUILD, e.g. pkgver=1.2.3.
+var apkbuildAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// apkbuildVariable matches the variable references of an assignment.
+var apkbuildVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// apkbuildPath matches the APKBUILD of a package, in the directory of its
+// repository.
+var apkbuildPath = regexp.MustCompile(`^([^/]+)/([^/]+)/APKBUILD$`)
+
+// openAports opens a bare aports clone, cloning it into dir if it doesn't
+// exist yet and fetching the latest branches otherwise.
+func openAports(ctx context.Context, dir string) (*gogit.Repository, error) {
+	repo, err := gogit.PlainOpen(dir)
+	if errors.Is(err, gogit.ErrRepositoryNotExists) {
+		Logger.Infof("Cloning %s into %s", aportsURL, dir)
+		return gogit.PlainCloneContext(ctx, dir, true, &gogit.CloneOptions{URL: aportsURL})
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = repo.FetchContext(ctx, &gogit.FetchOptions{
+		RefSpecs: []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+	return repo, nil
+}
+
+// aportsBranch returns the aports branch of a secdb version, e.g.
+// 3.19-stable for v3.19. Edge has no branch of its own.
+func aportsBranch(alpineVer string) (string, bool) {
+	if alpineVer == alpineEdge || !strings.HasPrefix(alpineVer, "v") {
+		return "", false
+	}
+	return strings.TrimPrefix(alpineVer, "v") + "-stable", true
+}
+
+// resolveBranch returns the head commit of a branch, preferring the
+// remote-tracking branch updated by openAports.
+func resolveBranch(repo *gogit.Repository, branch string) (*object.Commit, error) {
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewRemoteReferenceName("origin", branch),
+		plumbing.NewBranchReferenceName(branch),
+	} {
+		ref, err := repo.Reference(name, true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return repo.CommitObject(ref.Hash())
+	}
+	return nil, fmt.Errorf("branch %s not found", branch)
+}
+
+// parseAPKBUILD returns the version (pkgver-rpkgrel) an APKBUILD builds. Only
+// top level assignments are evaluated, expanding references to earlier ones.
+func parseAPKBUILD(content string) (string, bool) {
+	vars := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		m := apkbuildAssignment.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := m[2]
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		vars[m[1]] = apkbuildVariable.ReplaceAllStringFunc(value, func(ref string) string {
+			return vars[strings.Trim(ref, "${}")]
+		})
+	}
+	pkgver, pkgrel := vars["pkgver"], vars["pkgrel"]
+	if pkgver == "" || pkgrel == "" {
+		return "", false
+	}
+	version := pkgver + "-r" + pkgrel
+	if !vercmp.ValidAPK(version) {
+		return "", false
+	}
+	return version, true
+}
+
+// apkbuildVersion returns the version of a package's APKBUILD in a tree.
+func apkbuildVersion(tree *object.Tree, path string) (string, bool) {
+	file, err := tree.File(path)
+	if err != nil {
+		return "", false
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return "", false
+	}
+	return parseAPKBUILD(content)
+}
+
+// branchFirstVersions returns the first version of each package of the given
+// repositories (e.g. main) shipped in an aports branch: its version when the
+// branch was forked from master, or, for packages added to the branch later,
+// the version it was added at.
+func branchFirstVersions(repo *gogit.Repository, branch string, repos []string) (map[string]string, error) {
+	head, err := resolveBranch(repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	master, err := resolveBranch(repo, aportsMainBranch)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := head.MergeBase(master)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find where %s was forked: %w", branch, err)
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%s isn't forked from %s", branch, aportsMainBranch)
+	}
+	fork := bases[0]
+
+	first := make(map[string]string)
+	forkTree, err := fork.Tree()
+	if err != nil {
+		return nil, err
+	}
+	for _, alpineRepo := range repos {
+		repoTree, err := forkTree.Tree(alpineRepo)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range repoTree.Entries {
+			if entry.Mode.IsFile() {
+				continue
+			}
+			if version, ok := apkbuildVersion(forkTree, alpineRepo+"/"+entry.Name+"/APKBUILD"); ok {
+				first[entry.Name] = version
+			}
+		}
+	}
+
+	// Replay the branch's own commits, oldest first, for the packages added
+	// after the fork.
+	var commits []*object.Commit
+	iter, err := repo.Log(&gogit.LogOptions{From: head.Hash})
+	if err != nil {
+		return nil, err
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.Hash == fork.Hash {
+			return storer.ErrStop
+		}
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		var parentTree *object.Tree
+		if parent, err := commit.Parent(0); err == nil {
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			m := apkbuildPath.FindStringSubmatch(change.To.Name)
+			if m == nil || !slices.Contains(repos, m[1]) {
+				continue
+			}
+			if _, ok := first[m[2]]; ok {
+				continue
+			}
+			if version, ok := apkbuildVersion(tree, change.To.Name); ok {
+				first[m[2]] = version
+			}
+		}
+	}
+	return first, nil
+}
+
+// applyIntroduced sets the introduced version of each package to the first
+// version its branch shipped, as given by firstVersions (by secdb version
+// and package). Fixes of versions no later than that were made before the
+// branch was forked, so the branch never shipped an affected version and
+// they're dropped. It returns the number of packages dropped.
+func applyIntroduced(allAlpineSecDb map[string][]VersionAndPkg, firstVersions map[string]map[string]string) int {
+	dropped := 0
+	for cveId, verPkgs := range allAlpineSecDb {
+		kept := verPkgs[:0]
+		for _, verPkg := range verPkgs {
+			first, ok := firstVersions[verPkg.AlpineVer][verPkg.Pkg]
+			if !ok {
+				kept = append(kept, verPkg)
+				continue
+			}
+			if verPkg.Ver != "" {
+				if c, err := vercmp.APK(first, verPkg.Ver); err == nil && c >= 0 {
+					dropped++
+					continue
+				}
+			}
+			verPkg.Introduced = first
+			kept = append(kept, verPkg)
+		}
+		if len(kept) == 0 {
+			delete(allAlpineSecDb, cveId)
+			continue
+		}
+		allAlpineSecDb[cveId] = kept
+	}
+	return dropped
+}
+
+// recoverIntroduced uses the history of an aports clone in dir to recover
+// the introduced versions of the packages of the given repositories of each
+// stable branch.
+func recoverIntroduced(ctx context.Context, dir string, repos []string, allAlpineSecDb map[string][]VersionAndPkg) error {
+	repo, err := openAports(ctx, dir)
+	if err != nil {
+		return err
+	}
+	alpineVers := make(map[string]bool)
+	for _, verPkgs := range allAlpineSecDb {
+		for _, verPkg := range verPkgs {
+			alpineVers[verPkg.AlpineVer] = true
+		}
+	}
+	firstVersions := make(map[string]map[string]string)
+	for alpineVer := range alpineVers {
+		branch, ok := aportsBranch(alpineVer)
+		if !ok {
+			continue
+		}
+		first, err := branchFirstVersions(repo, branch, repos)
+		if err != nil {
+			Logger.Warnf("No introduced versions for alpine version '%s': %s", alpineVer, err)
+			continue
+		}
+		firstVersions[alpineVer] = first
+	}
+	dropped := applyIntroduced(allAlpineSecDb, firstVersions)
+	Logger.Infof("Recovered introduced versions of %d branches, dropping %d fixes made before their branch", len(firstVersions), dropped)
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestParseAPKBUILD(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOk  bool
+	}{
+		{
+			name:    "literal",
+			content: "# Maintainer: someone\npkgname=curl\npkgver=8.5.0\npkgrel=1\npkgdesc=\"URL retrieval utility\"\n",
+			want:    "8.5.0-r1",
+			wantOk:  true,
+		},
+		{
+			name:    "variables and quotes",
+			content: "_pkgver=1.36.1\npkgname=busybox\npkgver=\"${_pkgver/-/_}\"\npkgver=\"$_pkgver\" # upstream\npkgrel=0\n\nbuild() {\n\tpkgver=9.9\n}\n",
+			want:    "1.36.1-r0",
+			wantOk:  true,
+		},
+		{
+			name:    "missing pkgrel",
+			content: "pkgname=foo\npkgver=1.0\n",
+		},
+		{
+			name:    "invalid version",
+			content: "pkgver=latest\npkgrel=0\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseAPKBUILD(tc.content)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("parseAPKBUILD() = %q, %v, want %q, %v", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
+
+// commitAPKBUILDs writes the APKBUILDs of the given packages, named with
+// their repository (e.g. community/nodejs) unless they're in main, and
+// commits them.
+func commitAPKBUILDs(t *testing.T, dir string, tree *gogit.Worktree, versions map[string][2]string) {
+	t.Helper()
+	for pkg, version := range versions {
+		repo := "main"
+		if r, name, ok := strings.Cut(pkg, "/"); ok {
+			repo, pkg = r, name
+		}
+		path := filepath.Join(repo, pkg, "APKBUILD")
+		if err := os.MkdirAll(filepath.Join(dir, repo, pkg), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "pkgname=" + pkg + "\npkgver=" + version[0] + "\npkgrel=" + version[1] + "\n"
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tree.Add(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tree.Commit("update", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBranchFirstVersions(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAPKBUILDs(t, dir, tree, map[string][2]string{"curl": {"8.4.0", "0"}, "openssl": {"3.1.4", "0"}, "community/nodejs": {"20.10.0", "0"}})
+	commitAPKBUILDs(t, dir, tree, map[string][2]string{"curl": {"8.5.0", "0"}})
+
+	// Fork 3.19-stable, then keep developing on master.
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("3.19-stable"), Create: true, Hash: head.Hash()}); err != nil {
+		t.Fatal(err)
+	}
+	commitAPKBUILDs(t, dir, tree, map[string][2]string{"curl": {"8.5.0", "1"}, "busybox": {"1.36.1", "2"}})
+	commitAPKBUILDs(t, dir, tree, map[string][2]string{"busybox": {"1.36.1", "3"}, "testing/zig": {"0.11.0", "0"}})
+	if err := tree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(aportsMainBranch)}); err != nil {
+		t.Fatal(err)
+	}
+	commitAPKBUILDs(t, dir, tree, map[string][2]string{"curl": {"8.6.0", "0"}, "zlib": {"1.3.1", "0"}})
+
+	got, err := branchFirstVersions(repo, "3.19-stable", []string{"main", "community"})
+	if err != nil {
+		t.Fatalf("branchFirstVersions() error = %v", err)
+	}
+	want := map[string]string{
+		"curl":    "8.5.0-r0",
+		"openssl": "3.1.4-r0",
+		"busybox": "1.36.1-r2",
+		"nodejs":  "20.10.0-r0",
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("branchFirstVersions() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := branchFirstVersions(repo, "3.20-stable", []string{"main"}); err == nil {
+		t.Errorf("branchFirstVersions() of a missing branch succeeded")
+	}
+}
+
+func TestApplyIntroduced(t *testing.T) {
+	allAlpineSecDb := map[string][]VersionAndPkg{
+		"CVE-2023-38545": {
+			{Pkg: "curl", Ver: "8.4.0-r0", AlpineVer: "v3.18"},
+			// Fixed before 3.19 was forked.
+			{Pkg: "curl", Ver: "8.4.0-r0", AlpineVer: "v3.19"},
+		},
+		"CVE-2024-2398": {
+			{Pkg: "curl", Ver: "8.7.1-r0", AlpineVer: "v3.19"},
+			{Pkg: "curl", AlpineVer: "v3.20", Unfixed: true},
+		},
+		"CVE-2023-0001": {
+			{Pkg: "curl", Ver: "8.5.0-r0", AlpineVer: "v3.19"},
+		},
+	}
+	firstVersions := map[string]map[string]string{
+		"v3.19": {"curl": "8.5.0-r0"},
+		"v3.20": {"curl": "8.7.1-r0"},
+	}
+	if dropped := applyIntroduced(allAlpineSecDb, firstVersions); dropped != 2 {
+		t.Errorf("applyIntroduced() dropped %d packages, want 2", dropped)
+	}
+	want := map[string][]VersionAndPkg{
+		"CVE-2023-38545": {
+			{Pkg: "curl", Ver: "8.4.0-r0", AlpineVer: "v3.18"},
+		},
+		"CVE-2024-2398": {
+			{Pkg: "curl", Ver: "8.7.1-r0", AlpineVer: "v3.19", Introduced: "8.5.0-r0"},
+			{Pkg: "curl", AlpineVer: "v3.20", Unfixed: true, Introduced: "8.7.1-r0"},
+		},
+	}
+	if diff := gocmp.Diff(want, allAlpineSecDb); diff != "" {
+		t.Errorf("applyIntroduced() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAportsBranch(t *testing.T) {
+	if got, ok := aportsBranch("v3.19"); !ok || got != "3.19-stable" {
+		t.Errorf("aportsBranch(v3.19) = %q, %v, want 3.19-stable, true", got, ok)
+	}
+	if _, ok := aportsBranch(alpineEdge); ok {
+		t.Errorf("aportsBranch(edge) succeeded, want no branch")
+	}
+}
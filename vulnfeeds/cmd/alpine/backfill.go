@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/exp/slices"
+)
+
+// waybackURLBase is the Internet Archive's Wayback Machine, which has
+// snapshots of the secdb files of Alpine versions no longer listed on
+// secdb.alpinelinux.org.
+const waybackURLBase = "https://web.archive.org"
+
+// backfillVersions returns the requested versions not already listed on
+// secdb, which is preferred to its snapshots.
+func backfillVersions(requested []string, listed []string) []string {
+	var versions []string
+	for _, v := range requested {
+		if slices.Contains(listed, v) {
+			Logger.Infof("Not backfilling alpine version '%s', it's still listed on secdb", v)
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// latestSnapshot returns the timestamp of the latest successful snapshot of
+// a URL, using the Wayback Machine's CDX API.
+func latestSnapshot(waybackBase string, snapshotURL string) (string, error) {
+	query := url.Values{
+		"url":    {snapshotURL},
+		"output": {"json"},
+		"fl":     {"timestamp"},
+		"filter": {"statuscode:200"},
+		// A negative limit returns the last (latest) results.
+		"limit": {"-1"},
+	}
+	res, err := http.Get(waybackBase + "/cdx/search/cdx?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to list snapshots of %s: %s", snapshotURL, res.Status)
+	}
+	// The first row is the field names.
+	var rows [][]string
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return "", fmt.Errorf("failed to parse snapshots of %s: %w", snapshotURL, err)
+	}
+	if len(rows) < 2 || len(rows[len(rows)-1]) == 0 {
+		return "", errors.New("no snapshots of " + snapshotURL)
+	}
+	return rows[len(rows)-1][0], nil
+}
+
+// downloadArchivedAlpine downloads the latest snapshot of the secdb file of
+// an alpine version.
+func downloadArchivedAlpine(waybackBase string, version string) (AlpineSecDB, error) {
+	secdbURL := fmt.Sprintf(alpineURLBase, version, alpineMainRepo)
+	timestamp, err := latestSnapshot(waybackBase, secdbURL)
+	if err != nil {
+		return AlpineSecDB{}, err
+	}
+	// The id_ suffix returns the snapshot as archived, without the Wayback
+	// Machine's rewriting.
+	res, err := http.Get(fmt.Sprintf("%s/web/%sid_/%s", waybackBase, timestamp, secdbURL))
+	if err != nil {
+		return AlpineSecDB{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AlpineSecDB{}, fmt.Errorf("failed to get the %s snapshot of %s: %s", timestamp, secdbURL, res.Status)
+	}
+	Logger.Infof("Backfilling alpine version '%s' from the %s snapshot", version, timestamp)
+	return decodeAlpineSecDB(res.Body, version)
+}
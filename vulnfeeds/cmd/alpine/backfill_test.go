@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestBackfillVersions(t *testing.T) {
+	got := backfillVersions([]string{"v3.0", "v3.1", "v3.19"}, []string{"v3.18", "v3.19"})
+	if diff := gocmp.Diff([]string{"v3.0", "v3.1"}, got); diff != "" {
+		t.Errorf("backfillVersions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDownloadArchivedAlpine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cdx/search/cdx" && r.URL.Query().Get("url") == "https://secdb.alpinelinux.org/v3.1/main.json":
+			w.Write([]byte(`[["timestamp"], ["20170101000000"]]`))
+		case r.URL.Path == "/cdx/search/cdx":
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/web/20170101000000id_/https://secdb.alpinelinux.org/v3.1/main.json":
+			w.Write([]byte(`{"distroversion": "v3.1", "reponame": "main", "packages": [
+				{"pkg": {"name": "openssl", "secfixes": {"1.0.1m-r0": ["CVE-2015-1788"]}}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	secdb, err := downloadArchivedAlpine(srv.URL, "v3.1")
+	if err != nil {
+		t.Fatalf("downloadArchivedAlpine() error = %v", err)
+	}
+	got := make(map[string][]VersionAndPkg)
+	addSecDB(got, secdb, "v3.1", "main", nil)
+	want := map[string][]VersionAndPkg{
+		"CVE-2015-1788": {{Pkg: "openssl", Ver: "1.0.1m-r0", AlpineVer: "v3.1", Repo: "main"}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("addSecDB() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := downloadArchivedAlpine(srv.URL, "v2.7"); err == nil {
+		t.Errorf("downloadArchivedAlpine() of a version without snapshots succeeded")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/vcr"
+)
+
+// The downloads are replayed from a fixture; set VULNFEEDS_VCR_RECORD to
+// record it again from secdb.alpinelinux.org.
+const secDBFixture = "../../test_data/vcr/alpine-secdb.json"
+
+func TestGetAlpineSecDBData(t *testing.T) {
+	vcr.Use(t, secDBFixture)
+
+	var failed failures.Collector
+	got, err := getAlpineSecDBData(false, false, []string{"main", "community"}, nil, &failed)
+	if err != nil {
+		t.Fatalf("getAlpineSecDBData() error: %v", err)
+	}
+	// v3.19 has no community secdb in the fixture, which isn't a failure.
+	if failed.Total() != 3 || len(failed.Failures()) != 0 {
+		t.Errorf("getAlpineSecDBData() failures = %v of %d, want 3 secdb files without failures", failed.Failures(), failed.Total())
+	}
+	want := map[string][]VersionAndPkg{
+		"CVE-2021-22945": {{Ver: "0", Pkg: "curl", AlpineVer: "v3.18", Repo: "main"}},
+		"CVE-2023-38545": {{Ver: "8.4.0-r0", Pkg: "curl", AlpineVer: "v3.18", Repo: "main"}},
+		"CVE-2023-38546": {{Ver: "8.4.0-r0", Pkg: "curl", AlpineVer: "v3.18", Repo: "main"}},
+		"CVE-2023-5678":  {{Ver: "3.1.4-r1", Pkg: "openssl", AlpineVer: "v3.18", Repo: "main"}},
+		"CVE-2023-44487": {{Ver: "18.18.2-r0", Pkg: "nodejs", AlpineVer: "v3.18", Repo: "community"}},
+		"CVE-2023-46218": {{Ver: "8.5.0-r0", Pkg: "curl", AlpineVer: "v3.19", Repo: "main"}},
+		"CVE-2023-46219": {{Ver: "8.5.0-r0", Pkg: "curl", AlpineVer: "v3.19", Repo: "main"}},
+		"CVE-2023-42363": {{Ver: "1.36.1-r15", Pkg: "busybox", AlpineVer: "v3.19", Repo: "main"}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("getAlpineSecDBData() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDownloadAlpineNotFound(t *testing.T) {
+	vcr.Use(t, secDBFixture)
+
+	if _, err := downloadAlpine("v2.0", "main"); err == nil {
+		t.Errorf("downloadAlpine() of a version not on secdb: want error")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/pkgindex"
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+const (
+	// alpineEdge is the rolling development branch of Alpine. It has no
+	// release version, so its fixes are validated against what it currently
+	// ships.
+	alpineEdge = "edge"
+	// alpineIndexURLBase is the APKINDEX of a repository of a branch. Only
+	// the x86_64 index is read: the few source packages that aren't built
+	// for x86_64 are treated as absent, so their edge fixes are dropped and
+	// their release fixes can't be verified.
+	alpineIndexURLBase = "https://dl-cdn.alpinelinux.org/alpine/%s/%s/x86_64/APKINDEX.tar.gz"
+)
+
+// parseAPKIndex reads an APKINDEX.tar.gz, returning the latest version of
+// each source package (the origin of the binary packages built from it).
+func parseAPKIndex(r io.Reader) (map[string]string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no APKINDEX in archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Name == "APKINDEX" {
+			break
+		}
+	}
+
+	versions := make(map[string]string)
+	var name, origin, version string
+	record := func() {
+		if origin == "" {
+			origin = name
+		}
+		if origin != "" && version != "" {
+			if existing, ok := versions[origin]; !ok {
+				versions[origin] = version
+			} else if c, err := vercmp.APK(version, existing); err == nil && c > 0 {
+				versions[origin] = version
+			}
+		}
+		name, origin, version = "", "", ""
+	}
+	scanner := bufio.NewScanner(tr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			record()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "P":
+			name = value
+		case "o":
+			origin = value
+		case "V":
+			version = value
+		}
+	}
+	record()
+	return versions, scanner.Err()
+}
+
+// downloadAlpineIndex downloads the versions currently in a repository of an
+// alpine branch.
+func downloadAlpineIndex(alpineVer string, repo string) (map[string]string, error) {
+	url := fmt.Sprintf(alpineIndexURLBase, alpineVer, repo)
+	res, err := faulttolerant.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", url, res.Status)
+	}
+	return parseAPKIndex(res.Body)
+}
+
+// downloadAlpineEdgeIndex downloads the versions currently in a repository
+// of edge.
+func downloadAlpineEdgeIndex(repo string) (map[string]string, error) {
+	return downloadAlpineIndex(alpineEdge, repo)
+}
+
+// alpineIndexLoader returns a loader of the index of an Alpine ecosystem,
+// e.g. "Alpine:v3.18", combining its repositories, to verify its fixed
+// versions against.
+func alpineIndexLoader(repos []string) func(ecosystem string) (pkgindex.Index, error) {
+	return func(ecosystem string) (pkgindex.Index, error) {
+		name, err := ecosystems.Parse(ecosystem)
+		if err != nil {
+			return nil, err
+		}
+		index := make(pkgindex.Index)
+		for _, repo := range repos {
+			versions, err := downloadAlpineIndex(name.Release, repo)
+			if err != nil {
+				return nil, err
+			}
+			for pkg, version := range versions {
+				index.Add(pkg, version, vercmp.APK)
+			}
+		}
+		return index, nil
+	}
+}
+
+// edgeAffectedVersion validates a secdb fix of pkg in edge against the
+// versions edge currently ships. A fix edge already ships is a fixed
+// version. A fix edge doesn't ship yet means everything up to the current
+// version is affected. Packages no longer in edge (or not built for x86_64)
+// have nothing to report.
+func edgeAffectedVersion(pkg string, fixed string, edgeIndex map[string]string) (cves.AffectedVersion, bool) {
+	current, ok := edgeIndex[pkg]
+	if !ok {
+		Logger.Warnf("Package %s fixed in %s is no longer in edge", pkg, fixed)
+		return cves.AffectedVersion{}, false
+	}
+	c, err := vercmp.APK(current, fixed)
+	if err != nil {
+		Logger.Warnf("Failed to compare edge versions of %s: %s", pkg, err)
+		return cves.AffectedVersion{}, false
+	}
+	if c < 0 {
+		Logger.Warnf("Package %s fixed in %s, but edge ships %s", pkg, fixed, current)
+		return cves.AffectedVersion{LastAffected: current}, true
+	}
+	return cves.AffectedVersion{Fixed: fixed}, true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func makeAPKIndex(t *testing.T, index string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"DESCRIPTION": "edge", "APKINDEX": index} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestParseAPKIndex(t *testing.T) {
+	index := `C:Q1abc=
+P:curl
+V:8.5.0-r0
+o:curl
+
+P:libcurl
+V:8.5.0-r0
+o:curl
+
+P:openssl
+V:3.1.4-r1
+o:openssl
+
+P:libssl3
+V:3.1.4-r2
+o:openssl
+
+P:busybox
+V:1.36.1-r15
+`
+	got, err := parseAPKIndex(makeAPKIndex(t, index))
+	if err != nil {
+		t.Fatalf("parseAPKIndex() error: %v", err)
+	}
+	want := map[string]string{"curl": "8.5.0-r0", "openssl": "3.1.4-r2", "busybox": "1.36.1-r15"}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("parseAPKIndex() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEdgeAffectedVersion(t *testing.T) {
+	edgeIndex := map[string]string{"curl": "8.5.0-r0"}
+	tests := []struct {
+		pkg    string
+		fixed  string
+		want   cves.AffectedVersion
+		wantOk bool
+	}{
+		{pkg: "curl", fixed: "8.4.0-r0", want: cves.AffectedVersion{Fixed: "8.4.0-r0"}, wantOk: true},
+		{pkg: "curl", fixed: "8.5.0-r0", want: cves.AffectedVersion{Fixed: "8.5.0-r0"}, wantOk: true},
+		{pkg: "curl", fixed: "8.6.0-r0", want: cves.AffectedVersion{LastAffected: "8.5.0-r0"}, wantOk: true},
+		{pkg: "removed", fixed: "1.0-r0", wantOk: false},
+	}
+	for _, tt := range tests {
+		got, ok := edgeAffectedVersion(tt.pkg, tt.fixed, edgeIndex)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("edgeAffectedVersion(%q, %q) = %+v, %v, want %+v, %v", tt.pkg, tt.fixed, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
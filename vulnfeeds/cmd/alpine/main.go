@@ -1,7 +1,9 @@
 package main
 
 import (
-	"encoding/json"
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -12,17 +14,43 @@ import (
 	"strings"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/dryrun"
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/pkgindex"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/server"
 	"github.com/google/osv/vulnfeeds/utility"
 	"github.com/google/osv/vulnfeeds/vulns"
 )
 
 const (
-	alpineURLBase           = "https://secdb.alpinelinux.org/%s/main.json"
+	alpineURLBase           = "https://secdb.alpinelinux.org/%s/%s.json"
 	alpineIndexURL          = "https://secdb.alpinelinux.org/"
 	alpineOutputPathDefault = "parts/alpine"
+	// alpineReposDefault are the repositories converted by default. testing
+	// is only published for edge.
+	alpineReposDefault = "main,community"
+	alpineMainRepo     = "main"
 )
 
+// errSecDBNotFound is returned for secdb files that aren't published, e.g.
+// of repositories that didn't exist yet in an old alpine version.
+var errSecDBNotFound = errors.New("secdb file not found")
+
 var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var serveFlags = server.RegisterFlags(flag.CommandLine)
+var failureFlags = failures.RegisterFlags(flag.CommandLine)
+var dryRunFlags = dryrun.RegisterFlags(flag.CommandLine)
+var verifyFlags = pkgindex.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
 
 func main() {
 	var logCleanup func()
@@ -33,27 +61,98 @@ func main() {
 		"alpineOutput",
 		alpineOutputPathDefault,
 		"path to output general alpine affected package information")
+	includeEdge := flag.Bool(
+		"alpineEdge",
+		false,
+		"also convert the rolling edge branch, validating its fixes against the edge APKINDEX")
+	includeUnfixed := flag.Bool(
+		"alpineUnfixed",
+		false,
+		"also convert the open vulnerabilities of each branch from the Alpine security tracker, where available")
+	backfill := flag.String(
+		"alpineBackfill",
+		"",
+		"comma separated alpine versions (e.g. v3.0,v3.1) no longer listed on secdb to backfill from web archive snapshots")
+	repos := flag.String(
+		"alpineRepos",
+		alpineReposDefault,
+		"comma separated alpine repositories (e.g. main,community,testing) whose secdb files to convert")
+	aportsDir := flag.String(
+		"alpineAports",
+		"",
+		"path to clone aports into (or update an existing clone at) to recover introduced versions from the APKBUILD history of each branch")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if serveFlags.Enabled() {
+		if err := serveFlags.Serve("alpine-osv", flag.CommandLine); err != nil {
+			Logger.Fatalf("Server failed: %s", err)
+		}
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
 
-	err := os.MkdirAll(*alpineOutputPath, 0755)
-	if err != nil {
+	var plan *dryrun.Plan
+	if dryRunFlags.Enabled() {
+		plan = dryrun.NewPlan(*alpineOutputPath, func(name string) bool {
+			return strings.HasSuffix(name, ".alpine.json")
+		})
+	} else if err := os.MkdirAll(*alpineOutputPath, 0755); err != nil {
 		Logger.Fatalf("Can't create output path: %s", err)
 	}
 
-	allAlpineSecDB := getAlpineSecDBData()
-	generateAlpineOSV(allAlpineSecDB, *alpineOutputPath)
+	var failed failures.Collector
+	alpineRepos := utility.SplitList(*repos)
+	allAlpineSecDB, err := getAlpineSecDBData(*includeEdge, *includeUnfixed, alpineRepos, utility.SplitList(*backfill), &failed)
+	if err != nil {
+		Logger.Fatalf("Failed to get alpine secdb data: %s", err)
+	}
+	if *aportsDir != "" {
+		if err := recoverIntroduced(context.Background(), *aportsDir, alpineRepos, allAlpineSecDB); err != nil {
+			Logger.Fatalf("Failed to recover introduced versions from aports: %s", err)
+		}
+	}
+	verifier := verifyFlags.NewVerifier(alpineIndexLoader(alpineRepos), Logger)
+	generateAlpineOSV(allAlpineSecDB, *alpineOutputPath, plan, verifier, &failed)
+	failed.Report(Logger, "secdb files and CVEs")
+	if verifier != nil {
+		verifier.Summary().Report(Logger)
+	}
+	if err := failed.Check(failureFlags.MaxRate()); err != nil {
+		Logger.Fatalf("Alpine conversion failed: %s", err)
+	}
+	if plan != nil {
+		if err := dryRunFlags.Report(plan, os.Stdout); err != nil {
+			Logger.Fatalf("Failed to compare with existing output: %s", err)
+		}
+	} else if err := outputFlags.Finish("alpine-osv"); err != nil {
+		Logger.Fatalf("Alpine conversion failed: %s", err)
+	}
 }
 
 // getAllAlpineVersions gets all available version name in alpine secdb
-func getAllAlpineVersions() []string {
+func getAllAlpineVersions() ([]string, error) {
 	res, err := http.Get(alpineIndexURL)
 	if err != nil {
-		Logger.Fatalf("Failed to get alpine index page: %s", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
 	}
 	buf := new(strings.Builder)
 	_, err = io.Copy(buf, res.Body)
 	if err != nil {
-		Logger.Fatalf("Failed to get alpine index page: %s", err)
+		return nil, err
 	}
 
 	exp := regexp.MustCompile("href=\"(v[\\d.]*)/\"")
@@ -67,92 +166,204 @@ func getAllAlpineVersions() []string {
 		alpineVersions = append(alpineVersions, match[1])
 	}
 
-	return alpineVersions
+	return alpineVersions, nil
 }
 
 type VersionAndPkg struct {
 	Ver       string
 	Pkg       string
 	AlpineVer string
+	// Repo is the repository the package is in, e.g. main or community.
+	Repo string
+	// LastAffected is set instead of Ver for edge packages whose fix isn't in
+	// edge yet.
+	LastAffected string
+	// Unfixed is set instead of Ver for packages the security tracker lists
+	// as vulnerable, with no fix yet.
+	Unfixed bool
+	// Introduced is the first version the branch shipped, if recovered from
+	// the aports history.
+	Introduced string
 }
 
-// getAlpineSecDBData Download from Alpine API
-func getAlpineSecDBData() map[string][]VersionAndPkg {
+// getAlpineSecDBData Download the secdb files of the given repositories from
+// Alpine API, and backfill the given versions no longer listed there from
+// archived snapshots.
+func getAlpineSecDBData(includeEdge bool, includeUnfixed bool, repos []string, backfill []string, failed *failures.Collector) (map[string][]VersionAndPkg, error) {
 	allAlpineSecDb := make(map[string][]VersionAndPkg)
-	allAlpineVers := getAllAlpineVersions()
+	allAlpineVers, err := getAllAlpineVersions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alpine index page: %w", err)
+	}
+	edgeIndexes := make(map[string]map[string]string)
+	if includeEdge {
+		for _, repo := range repos {
+			edgeIndexes[repo], err = downloadAlpineEdgeIndex(repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the edge %s APKINDEX: %w", repo, err)
+			}
+		}
+		allAlpineVers = append(allAlpineVers, alpineEdge)
+	}
 	for _, alpineVer := range allAlpineVers {
-		secdb := downloadAlpine(alpineVer)
-		for _, pkg := range secdb.Packages {
-			for version, cveIds := range pkg.Pkg.SecFixes {
-				for _, cveId := range cveIds {
-					cveId = strings.Split(cveId, " ")[0]
-
-					if !validVersion(version) {
-						Logger.Warnf("Invalid alpine version: '%s', on package: '%s', and alpine version: '%s'",
-							version,
-							pkg.Pkg.Name,
-							alpineVer,
-						)
+		for _, repo := range repos {
+			secdb, err := downloadAlpine(alpineVer, repo)
+			if errors.Is(err, errSecDBNotFound) && repo != alpineMainRepo {
+				Logger.Infof("No %s secdb for alpine version '%s'", repo, alpineVer)
+				continue
+			}
+			if err != nil {
+				failed.Failed("secdb "+alpineVer+"/"+repo, err)
+				continue
+			}
+			failed.Succeeded()
+			addSecDB(allAlpineSecDb, secdb, alpineVer, repo, edgeIndexes[repo])
+		}
+	}
+	// Only main is backfilled, as the other repositories were added to secdb
+	// after the versions it's needed for.
+	for _, alpineVer := range backfillVersions(backfill, allAlpineVers) {
+		secdb, err := downloadArchivedAlpine(waybackURLBase, alpineVer)
+		if err != nil {
+			Logger.Warnf("Failed to backfill alpine version '%s': %s", alpineVer, err)
+			continue
+		}
+		addSecDB(allAlpineSecDb, secdb, alpineVer, alpineMainRepo, nil)
+	}
+	if includeUnfixed {
+		for _, alpineVer := range allAlpineVers {
+			for _, repo := range repos {
+				unfixed, err := downloadUnfixed(alpineVer, repo)
+				if err != nil {
+					Logger.Warnf("No unfixed vulnerabilities for alpine version '%s' %s: %s", alpineVer, repo, err)
+					continue
+				}
+				mergeUnfixed(allAlpineSecDb, unfixed)
+			}
+		}
+	}
+	return allAlpineSecDb, nil
+}
+
+// addSecDB adds the fixes of the secdb file of a repository to
+// allAlpineSecDb. edgeIndex is only used for edge.
+func addSecDB(allAlpineSecDb map[string][]VersionAndPkg, secdb AlpineSecDB, alpineVer string, repo string, edgeIndex map[string]string) {
+	for _, pkg := range secdb.Packages {
+		for version, cveIds := range pkg.Pkg.SecFixes {
+			for _, cveId := range cveIds {
+				cveId = strings.Split(cveId, " ")[0]
+				if !ids.IsCVE(cveId) {
+					Logger.Warnf("Skipping non-CVE ID %q on package: '%s', and alpine version: '%s'", cveId, pkg.Pkg.Name, alpineVer)
+					continue
+				}
+
+				if !validVersion(version) {
+					Logger.Warnf("Invalid alpine version: '%s', on package: '%s', and alpine version: '%s'",
+						version,
+						pkg.Pkg.Name,
+						alpineVer,
+					)
+					continue
+				}
+
+				verPkg := VersionAndPkg{
+					Pkg:       pkg.Pkg.Name,
+					Ver:       version,
+					AlpineVer: alpineVer,
+					Repo:      repo,
+				}
+				if alpineVer == alpineEdge {
+					affected, ok := edgeAffectedVersion(pkg.Pkg.Name, version, edgeIndex)
+					if !ok {
 						continue
 					}
-
-					allAlpineSecDb[cveId] = append(allAlpineSecDb[cveId],
-						VersionAndPkg{
-							Pkg:       pkg.Pkg.Name,
-							Ver:       version,
-							AlpineVer: alpineVer,
-						})
+					verPkg.Ver, verPkg.LastAffected = affected.Fixed, affected.LastAffected
 				}
+				allAlpineSecDb[cveId] = append(allAlpineSecDb[cveId], verPkg)
 			}
 		}
 	}
-	return allAlpineSecDb
 }
 
-// generateAlpineOSV generates the generic PackageInfo package from the information given by alpine advisory
-func generateAlpineOSV(allAlpineSecDb map[string][]VersionAndPkg, alpineOutputPath string) {
+// generateAlpineOSV generates the generic PackageInfo package from the information given by alpine advisory.
+// With a non-nil plan, the part files are recorded in it instead of written,
+// and with a non-nil verifier, their fixed versions are verified first.
+func generateAlpineOSV(allAlpineSecDb map[string][]VersionAndPkg, alpineOutputPath string, plan *dryrun.Plan, verifier *pkgindex.Verifier, failed *failures.Collector) {
 	for cveId, verPkgs := range allAlpineSecDb {
 		pkgInfos := make([]vulns.PackageInfo, 0, len(verPkgs))
 
 		for _, verPkg := range verPkgs {
+			affectedVersion := cves.AffectedVersion{Introduced: verPkg.Introduced, Fixed: verPkg.Ver, LastAffected: verPkg.LastAffected}
+			if verPkg.Unfixed {
+				affectedVersion = cves.AffectedVersion{Introduced: cmp.Or(verPkg.Introduced, "0")}
+			}
+			ecosystem, err := ecosystems.New(ecosystems.Alpine, verPkg.AlpineVer)
+			if err != nil {
+				Logger.Warnf("Skipping %s package %s: %v", cveId, verPkg.Pkg, err)
+				continue
+			}
 			pkgInfo := vulns.PackageInfo{
 				PkgName: verPkg.Pkg,
 				VersionInfo: cves.VersionInfo{
-					AffectedVersions: []cves.AffectedVersion{{Fixed: verPkg.Ver}},
+					AffectedVersions: []cves.AffectedVersion{affectedVersion},
 				},
-				Ecosystem: "Alpine:" + verPkg.AlpineVer,
-				PURL:      "pkg:apk/alpine/" + verPkg.Pkg + "?arch=source",
+				Ecosystem:  ecosystem.String(),
+				PURL:       purl.Alpine(verPkg.Pkg, verPkg.AlpineVer),
+				Unresolved: verPkg.Unfixed,
+			}
+			if verPkg.Repo != "" {
+				pkgInfo.DatabaseSpecific = map[string]any{"repository": verPkg.Repo}
+			}
+			if verifier != nil {
+				verifier.Verify(cveId, &pkgInfo)
 			}
 			pkgInfos = append(pkgInfos, pkgInfo)
 		}
 
-		file, err := os.OpenFile(path.Join(alpineOutputPath, cveId+".alpine.json"), os.O_CREATE|os.O_RDWR, 0644)
-		if err != nil {
-			Logger.Fatalf("Failed to create/write osv output file: %s", err)
+		data, err := parts.Encode(pkgInfos)
+		if err == nil {
+			if plan != nil {
+				plan.Write(cveId+".alpine.json", data)
+			} else {
+				err = writeOutput(path.Join(alpineOutputPath, cveId+".alpine.json"), data, len(pkgInfos))
+			}
 		}
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "  ")
-		err = encoder.Encode(&pkgInfos)
 		if err != nil {
-			Logger.Fatalf("Failed to encode package info output file: %s", err)
+			failed.Failed(cveId, err)
+			continue
 		}
-		_ = file.Close()
+		failed.Succeeded()
 	}
 
 	Logger.Infof("Finished")
 }
 
-// downloadAlpine downloads Alpine SecDB data from their API
-func downloadAlpine(version string) AlpineSecDB {
-	res, err := http.Get(fmt.Sprintf(alpineURLBase, version))
+// writeOutput writes the part of a CVE, recording it in the output manifest.
+func writeOutput(filePath string, data []byte, packages int) error {
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	outputFlags.Add(filePath)
+	outputFlags.Count("packages", packages)
+
+	return nil
+}
+
+// downloadAlpine downloads Alpine SecDB data of a repository from their API
+func downloadAlpine(version string, repo string) (AlpineSecDB, error) {
+	res, err := http.Get(fmt.Sprintf(alpineURLBase, version, repo))
 	if err != nil {
-		Logger.Fatalf("Failed to get alpine file for version '%s' with error %s", version, err)
+		return AlpineSecDB{}, err
 	}
 
-	var decodedSecdb AlpineSecDB
+	defer res.Body.Close()
 
-	if err := json.NewDecoder(res.Body).Decode(&decodedSecdb); err != nil {
-		Logger.Fatalf("Failed to parse alpine json: %s", err)
+	if res.StatusCode == http.StatusNotFound {
+		return AlpineSecDB{}, errSecDBNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return AlpineSecDB{}, fmt.Errorf("HTTP request failed: %s", res.Status)
 	}
-	return decodedSecdb
+
+	return decodeAlpineSecDB(res.Body, version)
 }
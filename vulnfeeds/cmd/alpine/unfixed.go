@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/ids"
+)
+
+// alpineTrackerURLBase is the Alpine security tracker's list of the
+// potentially vulnerable packages of a branch's repository, e.g. 3.19-main.
+const alpineTrackerURLBase = "https://security.alpinelinux.org/branch/%s-%s"
+
+// TrackerBranch is the security tracker's JSON-LD listing of the package
+// versions of a branch that have open (unfixed) vulnerabilities.
+type TrackerBranch struct {
+	ID    string        `json:"id"`
+	Items []TrackerItem `json:"items"`
+}
+
+type TrackerItem struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Repo    string `json:"repo"`
+	// Vulns link to the tracker's page of each vulnerability, named after
+	// its ID, e.g. https://security.alpinelinux.org/vuln/CVE-2024-0727.
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// trackerBranch returns the tracker's name for the repository of a secdb
+// version, e.g. 3.19 for v3.19.
+func trackerBranch(alpineVer string) string {
+	return strings.TrimPrefix(alpineVer, "v")
+}
+
+// decodeUnfixed decodes a tracker listing of a branch's repository into the
+// packages each CVE is unfixed in. Only CVEs are converted, as with secfixes.
+func decodeUnfixed(r io.Reader, alpineVer string, repo string) (map[string][]VersionAndPkg, error) {
+	var branch TrackerBranch
+	if err := json.NewDecoder(r).Decode(&branch); err != nil {
+		return nil, err
+	}
+	unfixed := make(map[string][]VersionAndPkg)
+	for _, item := range branch.Items {
+		if item.Package == "" {
+			continue
+		}
+		for _, vuln := range item.Vulns {
+			cveId := path.Base(vuln.ID)
+			if !ids.IsCVE(cveId) {
+				continue
+			}
+			unfixed[cveId] = append(unfixed[cveId], VersionAndPkg{
+				Pkg:       item.Package,
+				AlpineVer: alpineVer,
+				Repo:      repo,
+				Unfixed:   true,
+			})
+		}
+	}
+	return unfixed, nil
+}
+
+// downloadUnfixed downloads the open vulnerabilities of a repository of a
+// secdb version from the security tracker.
+func downloadUnfixed(alpineVer string, repo string) (map[string][]VersionAndPkg, error) {
+	url := fmt.Sprintf(alpineTrackerURLBase, trackerBranch(alpineVer), repo)
+	header := http.Header{}
+	header.Set("Accept", "application/ld+json, application/json")
+	res, err := faulttolerant.GetWithHeader(url, header)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", url, res.Status)
+	}
+	return decodeUnfixed(res.Body, alpineVer, repo)
+}
+
+// mergeUnfixed adds the packages each CVE is unfixed in to allAlpineSecDb,
+// unless secfixes already records a fix of the package in that version, so
+// records move from unfixed to fixed as fixes appear.
+func mergeUnfixed(allAlpineSecDb map[string][]VersionAndPkg, unfixed map[string][]VersionAndPkg) {
+	for cveId, verPkgs := range unfixed {
+	pkgs:
+		for _, verPkg := range verPkgs {
+			for _, existing := range allAlpineSecDb[cveId] {
+				if existing.Pkg == verPkg.Pkg && existing.AlpineVer == verPkg.AlpineVer {
+					continue pkgs
+				}
+			}
+			allAlpineSecDb[cveId] = append(allAlpineSecDb[cveId], verPkg)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeUnfixed(t *testing.T) {
+	input := `{"id": "https://security.alpinelinux.org/branch/3.19-main",
+		"items": [
+			{"package": "curl", "version": "8.5.0-r0", "repo": "3.19-main",
+			 "vulns": [{"id": "https://security.alpinelinux.org/vuln/CVE-2024-2004"},
+			           {"id": "https://security.alpinelinux.org/vuln/GHSA-xxxx-yyyy-zzzz"}]},
+			{"package": "openssl", "version": "3.1.4-r5", "repo": "3.19-main",
+			 "vulns": [{"id": "https://security.alpinelinux.org/vuln/CVE-2024-2004"}]}
+		]}`
+	want := map[string][]VersionAndPkg{
+		"CVE-2024-2004": {
+			{Pkg: "curl", AlpineVer: "v3.19", Repo: "main", Unfixed: true},
+			{Pkg: "openssl", AlpineVer: "v3.19", Repo: "main", Unfixed: true},
+		},
+	}
+	got, err := decodeUnfixed(strings.NewReader(input), "v3.19", "main")
+	if err != nil {
+		t.Fatalf("decodeUnfixed() error = %v", err)
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("decodeUnfixed() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeUnfixed(t *testing.T) {
+	allAlpineSecDb := map[string][]VersionAndPkg{
+		"CVE-2024-2004": {{Pkg: "curl", Ver: "8.7.1-r0", AlpineVer: "v3.19"}},
+	}
+	unfixed := map[string][]VersionAndPkg{
+		"CVE-2024-2004": {
+			// Fixed since the tracker was last updated.
+			{Pkg: "curl", AlpineVer: "v3.19", Unfixed: true},
+			{Pkg: "curl", AlpineVer: "v3.18", Unfixed: true},
+			{Pkg: "curl", AlpineVer: "v3.18", Unfixed: true},
+		},
+		"CVE-2024-0727": {{Pkg: "openssl", AlpineVer: "v3.19", Unfixed: true}},
+	}
+	want := map[string][]VersionAndPkg{
+		"CVE-2024-2004": {
+			{Pkg: "curl", Ver: "8.7.1-r0", AlpineVer: "v3.19"},
+			{Pkg: "curl", AlpineVer: "v3.18", Unfixed: true},
+		},
+		"CVE-2024-0727": {{Pkg: "openssl", AlpineVer: "v3.19", Unfixed: true}},
+	}
+	mergeUnfixed(allAlpineSecDb, unfixed)
+	if diff := gocmp.Diff(want, allAlpineSecDb); diff != "" {
+		t.Errorf("mergeUnfixed() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The per-CVE metrics the benchmarks report, which thresholds and
+// baselines are given in, so they hold whatever the scale of the dataset.
+const (
+	nsPerCVE    = "ns/cve"
+	bytesPerCVE = "B/cve"
+)
+
+// checkedMetrics are the metrics checked for regressions, which grow when
+// performance regresses.
+var checkedMetrics = []string{nsPerCVE, bytesPerCVE}
+
+// Results are the metrics of each benchmark, e.g. "ns/cve", by benchmark
+// name (without its GOMAXPROCS suffix).
+type Results map[string]map[string]float64
+
+// benchLine matches a result line of go test -bench, e.g.
+// "BenchmarkLoadParts-8  3  21779707 ns/op  12127 B/cve".
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.+)$`)
+
+// parseResults parses the output of go test -bench, taking the median of
+// each metric of benchmarks run more than once (with -count).
+func parseResults(r io.Reader) (Results, error) {
+	samples := make(map[string]map[string][]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := benchLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		fields := strings.Fields(m[2])
+		if len(fields)%2 != 0 {
+			return nil, fmt.Errorf("malformed benchmark result %q", scanner.Text())
+		}
+		if samples[m[1]] == nil {
+			samples[m[1]] = make(map[string][]float64)
+		}
+		for i := 0; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed benchmark result %q: %w", scanner.Text(), err)
+			}
+			samples[m[1]][fields[i+1]] = append(samples[m[1]][fields[i+1]], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make(Results, len(samples))
+	for name, metrics := range samples {
+		results[name] = make(map[string]float64, len(metrics))
+		for metric, values := range metrics {
+			results[name][metric] = median(values)
+		}
+	}
+	return results, nil
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Thresholds are the maximum of each metric of each benchmark.
+type Thresholds map[string]map[string]float64
+
+// checkThresholds returns the metrics of results over their thresholds.
+// Benchmarks and metrics without a threshold aren't checked.
+func checkThresholds(results Results, thresholds Thresholds) []string {
+	var problems []string
+	for _, name := range sortedKeys(thresholds) {
+		for _, metric := range sortedKeys(thresholds[name]) {
+			value, ok := results[name][metric]
+			if !ok {
+				continue
+			}
+			if limit := thresholds[name][metric]; value > limit {
+				problems = append(problems, fmt.Sprintf("%s: %.0f %s is over the threshold of %.0f", name, value, metric, limit))
+			}
+		}
+	}
+	return problems
+}
+
+// checkBaseline returns the checked metrics of results that regressed by
+// more than maxRegression (a fraction, e.g. 0.2 for 20%) from baseline.
+func checkBaseline(results, baseline Results, maxRegression float64) []string {
+	var problems []string
+	for _, name := range sortedKeys(baseline) {
+		for _, metric := range checkedMetrics {
+			value, ok := results[name][metric]
+			previous, inBaseline := baseline[name][metric]
+			if !ok || !inBaseline || previous <= 0 {
+				continue
+			}
+			if change := value/previous - 1; change > maxRegression {
+				problems = append(problems, fmt.Sprintf("%s: %.0f %s regressed by %.0f%% from %.0f", name, value, metric, change*100, previous))
+			}
+		}
+	}
+	return problems
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
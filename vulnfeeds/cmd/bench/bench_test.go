@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: github.com/google/osv/vulnfeeds/cmd/combine-to-osv
+BenchmarkLoadParts-8      	       3	  21779707 ns/op	     12000 B/cve	     29000 ns/cve	 9095237 B/op	   36891 allocs/op
+BenchmarkLoadParts-8      	       3	  23779707 ns/op	     12200 B/cve	     31000 ns/cve	 9095237 B/op	   36891 allocs/op
+BenchmarkLoadParts-8      	       3	  22779707 ns/op	     12100 B/cve	     30000 ns/cve	 9095237 B/op	   36891 allocs/op
+BenchmarkCombine          	       3	 302828969 ns/op	     58213 B/cve	    302829 ns/cve	58213002 B/op	  805365 allocs/op
+PASS
+ok  	github.com/google/osv/vulnfeeds/cmd/combine-to-osv	4.409s
+`
+
+func TestParseResults(t *testing.T) {
+	results, err := parseResults(strings.NewReader(benchOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Results{
+		"BenchmarkLoadParts": {"ns/op": 22779707, "B/cve": 12100, "ns/cve": 30000, "B/op": 9095237, "allocs/op": 36891},
+		"BenchmarkCombine":   {"ns/op": 302828969, "B/cve": 58213, "ns/cve": 302829, "B/op": 58213002, "allocs/op": 805365},
+	}
+	if diff := cmp.Diff(want, results); diff != "" {
+		t.Errorf("parseResults() (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseResults(strings.NewReader("BenchmarkLoadParts-8 3 12 ns/op 7\n")); err == nil {
+		t.Errorf("parseResults() of a malformed line succeeded")
+	}
+}
+
+func TestChecks(t *testing.T) {
+	results := Results{
+		"BenchmarkLoadParts": {"ns/cve": 30000, "B/cve": 12100},
+		"BenchmarkCombine":   {"ns/cve": 302829, "B/cve": 58213},
+	}
+	thresholds := Thresholds{
+		"BenchmarkLoadParts": {"ns/cve": 25000, "B/cve": 20000},
+		"BenchmarkCombine":   {"ns/cve": 1500000},
+		"BenchmarkMissing":   {"ns/cve": 1},
+	}
+	wantThresholds := []string{"BenchmarkLoadParts: 30000 ns/cve is over the threshold of 25000"}
+	if diff := cmp.Diff(wantThresholds, checkThresholds(results, thresholds)); diff != "" {
+		t.Errorf("checkThresholds() (-want +got):\n%s", diff)
+	}
+
+	baseline := Results{
+		"BenchmarkLoadParts": {"ns/cve": 29000, "B/cve": 10000},
+		"BenchmarkCombine":   {"ns/cve": 200000, "B/cve": 58000},
+	}
+	wantBaseline := []string{
+		"BenchmarkCombine: 302829 ns/cve regressed by 51% from 200000",
+		"BenchmarkLoadParts: 12100 B/cve regressed by 21% from 10000",
+	}
+	if diff := cmp.Diff(wantBaseline, checkBaseline(results, baseline, 0.2)); diff != "" {
+		t.Errorf("checkBaseline() (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,131 @@
+// bench benchmarks the combine pipeline (loading the NVD CVEs and parts,
+// and combining them into OSV records) on a dataset scaled up from the
+// recorded fixtures, or a given one, and fails if it got slower or used more
+// memory per CVE than its thresholds, or than a baseline run, so
+// performance-motivated refactors can be validated objectively.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/osv/vulnfeeds/benchdata"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("bench")
+	defer logCleanup()
+
+	pkg := flag.String("pkg", "./cmd/combine-to-osv", "Package of the benchmarks, relative to the vulnfeeds directory it's run from")
+	bench := flag.String("bench", ".", "Regular expression of the benchmarks to run, as for go test -bench")
+	benchtime := flag.String("benchtime", "", "Run time or iterations of each benchmark, as for go test -benchtime")
+	count := flag.Int("count", 5, "Number of runs of each benchmark, whose median is checked")
+	scale := flag.Int("scale", benchdata.DefaultScale, "Number of copies of the fixtures in the generated dataset")
+	data := flag.String("data", "", "Dataset to run on (with cves/ and parts/ directories) instead of generating one")
+	generate := flag.String("generate", "", "Only generate the dataset, into this directory, e.g. to run on with -data")
+	thresholdsPath := flag.String("thresholds", "cmd/bench/thresholds.json", "JSON file of the maximum of each metric of each benchmark (empty to not check)")
+	baselinePath := flag.String("baseline", "", "JSON results of a previous run (see -output) to check for regressions against")
+	maxRegression := flag.Float64("max_regression", 0.2, "Largest increase of a metric over -baseline tolerated, as a fraction")
+	output := flag.String("output", "", "Write the results as JSON to this path, e.g. as the -baseline of a later run")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	if *generate != "" {
+		stats, err := benchdata.Generate(*generate, "test_data/nvdcve-2.0", "test_data/parts", *scale)
+		if err != nil {
+			Logger.Fatalf("Failed to generate the dataset: %v", err)
+		}
+		Logger.Infof("Generated %d CVEs and %d parts into %s", stats.CVEs, stats.Parts, *generate)
+		return
+	}
+
+	env := append(os.Environ(), benchdata.ScaleEnv+"="+strconv.Itoa(*scale))
+	if *data != "" {
+		// Resolved before go test changes into the package's directory.
+		dataDir, err := filepath.Abs(*data)
+		if err != nil {
+			Logger.Fatalf("Invalid -data: %v", err)
+		}
+		env = append(env, benchdata.DataEnv+"="+dataDir)
+	}
+	args := []string{"test", "-run", "^$", "-bench", *bench, "-benchmem", "-count", strconv.Itoa(*count)}
+	if *benchtime != "" {
+		args = append(args, "-benchtime", *benchtime)
+	}
+	args = append(args, *pkg)
+	var out bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Env = env
+	cmd.Stdout = io.MultiWriter(os.Stdout, &out)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		Logger.Fatalf("Benchmarks failed: %v", err)
+	}
+
+	results, err := parseResults(&out)
+	if err != nil {
+		Logger.Fatalf("Failed to parse the results: %v", err)
+	}
+	if len(results) == 0 {
+		Logger.Fatalf("No benchmarks matched %q", *bench)
+	}
+	if *output != "" {
+		if err := writeJSON(*output, results); err != nil {
+			Logger.Fatalf("Failed to write %s: %v", *output, err)
+		}
+	}
+
+	var problems []string
+	if *thresholdsPath != "" {
+		var thresholds Thresholds
+		if err := readJSON(*thresholdsPath, &thresholds); err != nil {
+			Logger.Fatalf("Failed to read the thresholds: %v", err)
+		}
+		problems = append(problems, checkThresholds(results, thresholds)...)
+	}
+	if *baselinePath != "" {
+		var baseline Results
+		if err := readJSON(*baselinePath, &baseline); err != nil {
+			Logger.Fatalf("Failed to read the baseline: %v", err)
+		}
+		problems = append(problems, checkBaseline(results, baseline, *maxRegression)...)
+	}
+	for _, problem := range problems {
+		Logger.Warnf("%s", problem)
+	}
+	if len(problems) > 0 {
+		Logger.Fatalf("%d performance regressions", len(problems))
+	}
+	Logger.Infof("No performance regressions in %d benchmarks", len(results))
+}
+
+func readJSON(fileName string, v any) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(fileName string, v any) error {
+	return utility.WriteFileAtomic(fileName, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/google/osv/vulnfeeds/preflight"
+)
+
+// indexerDiscoveryName is the indexer's discovery config (config.DiscoveryName
+// of gcp/indexer/config), which isn't a repository config.
+const indexerDiscoveryName = "discovery.yaml"
+
+// indexerConfig is the part of the indexer's per-repository configuration
+// (gcp/indexer/config) that is validated. The indexer's own rules are
+// checked against the configs in gcp/indexer/config/testdata, as its parsing
+// is, so they can't drift apart.
+type indexerConfig struct {
+	Address        string   `yaml:"address"`
+	Name           string   `yaml:"name"`
+	Type           string   `yaml:"type"`
+	FileExts       []string `yaml:"file_extensions"`
+	Branches       []string `yaml:"branches"`
+	Commits        []string `yaml:"commits"`
+	HashAllCommits bool     `yaml:"hash_all_commits"`
+	CloneDepth     int      `yaml:"clone_depth"`
+}
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// validateIndexerConfig returns the problems with an indexer configuration.
+func validateIndexerConfig(buf []byte) (name string, problems []string) {
+	var cfg indexerConfig
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return "", []string{err.Error()}
+	}
+	if cfg.Name == "" {
+		problems = append(problems, "missing name")
+	}
+	if cfg.Address == "" {
+		problems = append(problems, "missing address")
+	}
+	if !strings.EqualFold(cfg.Type, "GIT") {
+		problems = append(problems, fmt.Sprintf("unsupported type %q", cfg.Type))
+	}
+	if len(cfg.FileExts) == 0 {
+		problems = append(problems, "no file_extensions")
+	}
+	for _, ext := range cfg.FileExts {
+		if !strings.HasPrefix(ext, ".") {
+			problems = append(problems, fmt.Sprintf("file extension %q doesn't start with a dot", ext))
+		}
+	}
+	for _, branch := range cfg.Branches {
+		if branch == "" {
+			problems = append(problems, "empty branch")
+		}
+	}
+	for _, commit := range cfg.Commits {
+		if !commitRegexp.MatchString(commit) {
+			problems = append(problems, fmt.Sprintf("commit %q isn't a full SHA-1", commit))
+		}
+	}
+	if cfg.CloneDepth < 0 {
+		problems = append(problems, fmt.Sprintf("negative clone_depth %d", cfg.CloneDepth))
+	}
+	if cfg.CloneDepth > 0 && cfg.HashAllCommits {
+		problems = append(problems, "clone_depth and hash_all_commits are exclusive")
+	}
+	return cfg.Name, problems
+}
+
+// indexerConfigChecks returns a check per indexer repository configuration
+// (.yaml, other than the discovery config) in dir, also failing those whose
+// name duplicates an earlier one's.
+func indexerConfigChecks(dir string) ([]preflight.Check, error) {
+	all, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range all {
+		if filepath.Base(p) != indexerDiscoveryName {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no indexer configs in %s", dir)
+	}
+	seen := make(map[string]string)
+	checks := make([]preflight.Check, 0, len(paths))
+	for _, p := range paths {
+		buf, readErr := os.ReadFile(p)
+		var name string
+		var problems []string
+		if readErr == nil {
+			name, problems = validateIndexerConfig(buf)
+			if other, ok := seen[name]; ok && name != "" {
+				problems = append(problems, fmt.Sprintf("duplicate name %q (also in %s)", name, filepath.Base(other)))
+			} else {
+				seen[name] = p
+			}
+		}
+		checks = append(checks, preflight.Check{Kind: "indexer config", Target: p, Run: func(ctx context.Context) error {
+			if readErr != nil {
+				return readErr
+			}
+			if len(problems) > 0 {
+				return errors.New(strings.Join(problems, "; "))
+			}
+			return nil
+		}})
+	}
+	return checks, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/preflight"
+)
+
+func TestValidateIndexerConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "valid",
+			input: `address: "https://github.com/protocolbuffers/protobuf.git"
+name: "protobuf"
+type: "git"
+version_regex: "(\\d+\\.\\d+)"
+branches: ["main"]
+commits: ["d2ba34cb1b4f15bea1c2e5ec2e8d1c0b16db7b11"]
+file_extensions: [".c", ".h"]`,
+		},
+		{
+			name: "invalid",
+			input: `type: "svn"
+commits: ["d2ba34c"]
+file_extensions: ["c"]`,
+			want: []string{
+				"missing name",
+				"missing address",
+				`unsupported type "svn"`,
+				`file extension "c" doesn't start with a dot`,
+				`commit "d2ba34c" isn't a full SHA-1`,
+			},
+		},
+		{
+			name:  "no extensions",
+			input: `{"name": "abc", "address": "https://example.com/abc.git", "type": "GIT"}`,
+			want:  []string{"no file_extensions"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, got := validateIndexerConfig([]byte(tc.input))
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("validateIndexerConfig() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndexerConfigChecks(t *testing.T) {
+	dir := t.TempDir()
+	cfg := "name: \"abc\"\naddress: \"https://example.com/abc.git\"\ntype: \"GIT\"\nfile_extensions: [\".c\"]\n"
+	for _, name := range []string{"a.yaml", "b.yaml", indexerDiscoveryName} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checks, err := indexerConfigChecks(dir)
+	if err != nil {
+		t.Fatalf("indexerConfigChecks() = %v", err)
+	}
+	if len(checks) != 2 {
+		t.Errorf("indexerConfigChecks() = %d checks, want 2 (without %s)", len(checks), indexerDiscoveryName)
+	}
+	report := preflight.Run(context.Background(), checks, time.Second)
+	if !report.Results[0].OK {
+		t.Errorf("first config failed: %v", report.Results[0].Error)
+	}
+	if !strings.Contains(report.Results[1].Error, `duplicate name "abc"`) {
+		t.Errorf("duplicate config error = %q", report.Results[1].Error)
+	}
+
+	if _, err := indexerConfigChecks(t.TempDir()); err == nil {
+		t.Errorf("indexerConfigChecks() of an empty directory succeeded")
+	}
+}
+
+// TestIndexerTestdata checks configs are validated by the indexer's own rules,
+// with the configs it checks its parsing against.
+func TestIndexerTestdata(t *testing.T) {
+	for _, dir := range []string{"valid", "invalid"} {
+		paths, err := filepath.Glob(filepath.Join("../../../gcp/indexer/config/testdata", dir, "*.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) == 0 {
+			t.Fatalf("no %s indexer configs", dir)
+		}
+		for _, path := range paths {
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, problems := validateIndexerConfig(buf)
+			if wantProblems := dir == "invalid"; (len(problems) > 0) != wantProblems {
+				t.Errorf("validateIndexerConfig(%s) = %q, want problems: %v", path, problems, wantProblems)
+			}
+		}
+	}
+}
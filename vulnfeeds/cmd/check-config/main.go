@@ -0,0 +1,109 @@
+// check-config validates the configuration of the conversion pipeline, so
+// misconfigurations are caught at deploy time rather than by the nightly
+// pipeline silently producing nothing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/preflight"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const nvdAPIEndpoint = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+
+// defaultFeeds are the upstream feeds the converters download.
+var defaultFeeds = []string{
+	"https://secdb.alpinelinux.org/",
+	"https://security.alpinelinux.org/branch/edge-main",
+	"https://dl-cdn.alpinelinux.org/alpine/edge/main/x86_64/APKINDEX.tar.gz",
+	"https://debian.pages.debian.net/distro-info-data/debian.csv",
+	"https://security-tracker.debian.org/tracker/data/json",
+	"https://repo.openeuler.org/security/data/cvrf/",
+	nvdAPIEndpoint,
+}
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("check-config")
+	defer logCleanup()
+
+	feeds := flag.String("feeds", strings.Join(defaultFeeds, ","), "Comma separated feed URLs that must be reachable")
+	buckets := flag.String("buckets", "", "Comma separated GCS buckets that must be writable")
+	outputPaths := flag.String("outputPaths", "", "Comma separated local output paths that must be writable")
+	secrets := flag.String("secrets", "", "Comma separated Secret Manager secret versions that must be accessible")
+	nvdAPIKey := flag.String("nvdAPIKey", os.Getenv("NVD_API_KEY"), "NVD API key to validate (defaults to $NVD_API_KEY)")
+	indexerConfigs := flag.String("indexerConfigs", "", "Directory of indexer repository configs (.yaml) to validate")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout for each check")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	// Check the feeds with the client the downloaders use, so a proxy or
+	// user agent they're refused for is caught.
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+
+	ctx := context.Background()
+	httpClient := http.DefaultClient
+	var checks []preflight.Check
+	for _, feed := range utility.SplitList(*feeds) {
+		checks = append(checks, preflight.FeedCheck(httpClient, feed))
+	}
+	if *nvdAPIKey != "" {
+		checks = append(checks, preflight.NVDAPIKeyCheck(httpClient, nvdAPIEndpoint, *nvdAPIKey))
+	}
+	for _, secret := range utility.SplitList(*secrets) {
+		checks = append(checks, preflight.SecretCheck(secret))
+	}
+
+	var storageClient *storage.Client
+	var storageErr error
+	var storageOnce sync.Once
+	newStorageClient := func() (*storage.Client, error) {
+		storageOnce.Do(func() { storageClient, storageErr = storage.NewClient(ctx) })
+		return storageClient, storageErr
+	}
+	for _, bucket := range utility.SplitList(*buckets) {
+		checks = append(checks, preflight.BucketCheck(newStorageClient, strings.TrimPrefix(bucket, "gs://")))
+	}
+	for _, dir := range utility.SplitList(*outputPaths) {
+		checks = append(checks, preflight.OutputPathCheck(dir))
+	}
+	if *indexerConfigs != "" {
+		configChecks, err := indexerConfigChecks(*indexerConfigs)
+		if err != nil {
+			Logger.Fatalf("Failed to load indexer configs: %s", err)
+		}
+		checks = append(checks, configChecks...)
+	}
+
+	report := preflight.Run(ctx, checks, *timeout)
+	if storageClient != nil {
+		storageClient.Close()
+	}
+	if err := preflight.WriteText(os.Stdout, report); err != nil {
+		Logger.Warnf("Failed to write report: %v", err)
+	}
+	if report.Failed > 0 {
+		Logger.Fatalf("%d of %d configuration checks failed", report.Failed, len(report.Results))
+	}
+	Logger.Infof("All %d configuration checks passed", len(report.Results))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"log"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/benchdata"
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// The benchmarks run on a dataset scaled up from the fixtures (see
+// benchdata), or the one in $VULNFEEDS_BENCH_DATA, and report the time and
+// memory allocated per CVE, so runs at different scales compare. cmd/bench
+// runs them and checks them against thresholds.
+
+// benchDataset returns the dataset of a benchmark, silencing the per-file
+// logging of the pipeline for the rest of it.
+func benchDataset(b *testing.B) string {
+	b.Helper()
+	dir := benchdata.Use(b, "../../test_data")
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(previous) })
+	return dir
+}
+
+// loadBenchCVEs loads the CVEs of a dataset, failing the benchmark if it
+// can't.
+func loadBenchCVEs(b *testing.B, dir string) map[cves.CVEID]cves.Vulnerability {
+	b.Helper()
+	loaded, err := loadAllCVEs(path.Join(dir, benchdata.CVEsDir), &failures.Collector{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return loaded
+}
+
+// loadBenchParts loads the parts of a dataset, failing the benchmark if it
+// can't.
+func loadBenchParts(b *testing.B, dir string) (map[cves.CVEID][]vulns.PackageInfo, map[cves.CVEID]time.Time) {
+	b.Helper()
+	allParts, modified, err := loadParts(path.Join(dir, benchdata.PartsDir), defaultPartFeeds, false, &failures.Collector{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return allParts, modified
+}
+
+// measure runs f b.N times, reporting the time and bytes allocated per CVE
+// of the n it handles, and the CVEs handled per second.
+func measure(b *testing.B, n int, f func()) {
+	b.Helper()
+	if n == 0 {
+		b.Fatal("The dataset has no CVEs")
+	}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		f()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	total := float64(b.N * n)
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/total, "ns/cve")
+	b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/total, "B/cve")
+	b.ReportMetric(total/b.Elapsed().Seconds(), "cves/s")
+}
+
+func BenchmarkLoadAllCVEs(b *testing.B) {
+	dir := benchDataset(b)
+	n := len(loadBenchCVEs(b, dir))
+	measure(b, n, func() { loadBenchCVEs(b, dir) })
+}
+
+func BenchmarkLoadParts(b *testing.B) {
+	dir := benchDataset(b)
+	allParts, _ := loadBenchParts(b, dir)
+	measure(b, len(allParts), func() { loadBenchParts(b, dir) })
+}
+
+func BenchmarkCombineIntoOSV(b *testing.B) {
+	dir := benchDataset(b)
+	loaded := loadBenchCVEs(b, dir)
+	allParts, modified := loadBenchParts(b, dir)
+	measure(b, len(loaded), func() { combineIntoOSV(loaded, allParts, "", modified) })
+}
+
+// BenchmarkCombine runs the whole of a conversion: loading the CVEs and
+// parts, combining them and writing the records.
+func BenchmarkCombine(b *testing.B) {
+	dir := benchDataset(b)
+	n := len(loadBenchCVEs(b, dir))
+	outDir := b.TempDir()
+	measure(b, n, func() {
+		loaded := loadBenchCVEs(b, dir)
+		allParts, modified := loadBenchParts(b, dir)
+		if err := writeOSVFile(combineIntoOSV(loaded, allParts, "", modified), outDir, writeOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/repourl"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// addCVE5GitRanges adds the commit ranges CNAs record in the CVE List
+// (versions of versionType "git" in an affected entry with a repo) to the
+// records of their CVEs, unless a record already has a GIT range of the
+// repo (however its URL is written). Only GIT ranges are taken, as the CNA's vendor and product don't
+// name a package in an OSV ecosystem.
+func addCVE5GitRanges(osvData map[cves.CVEID]*vulns.Vulnerability, cveList string) {
+	added := 0
+	for id, osv := range osvData {
+		cve5, err := vulns.LoadCVE5(id, cveList)
+		if err != nil {
+			continue
+		}
+		for _, cna := range cve5.Containers.CNA.Affected {
+			if cna.Repo == "" || hasGitRange(osv, cna.Repo) {
+				continue
+			}
+			var converted vulns.Affected
+			for _, note := range converted.AttachCVE5Versions(cna) {
+				Logger.Infof("%s: %s", id, note)
+			}
+			gitRanges := slices.DeleteFunc(converted.Ranges, func(r vulns.AffectedRange) bool { return r.Type != "GIT" })
+			if len(gitRanges) == 0 {
+				continue
+			}
+			for i := range gitRanges {
+				gitRanges[i].Repo = repourl.Canonical(gitRanges[i].Repo)
+			}
+			osv.Affected = append(osv.Affected, vulns.Affected{Ranges: gitRanges})
+			added++
+		}
+	}
+	Logger.Infof("Added %d GIT ranges from the CVE List", added)
+}
+
+// hasGitRange reports whether osv has a GIT range of repo, comparing their
+// canonical URLs.
+func hasGitRange(osv *vulns.Vulnerability, repo string) bool {
+	for _, affected := range osv.Affected {
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" && repourl.Same(r.Repo, repo) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestAddCVE5GitRanges(t *testing.T) {
+	cveList := t.TempDir()
+	dir := filepath.Join(cveList, vulns.CVEListBasePath, "2024", "1xxx")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	record := `{"containers": {"cna": {"affected": [
+		{"product": "zlib", "repo": "https://github.com/Madler/zlib.git", "versions": [
+			{"version": "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2", "lessThan": "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1", "status": "affected", "versionType": "git"},
+			{"version": "1.2.0", "lessThan": "1.3.0", "status": "affected", "versionType": "semver"}
+		]},
+		{"product": "curl", "repo": "https://github.com/Curl/curl.git", "versions": [
+			{"version": "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2", "lessThan": "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1", "status": "affected", "versionType": "git"}
+		]},
+		{"product": "openssl", "repo": "https://github.com/openssl/openssl", "versions": [
+			{"version": "1da177e", "lessThan": "0f8a5b6", "status": "affected", "versionType": "git"}
+		]}
+	]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "CVE-2024-1234.json"), []byte(record), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	curl := vulns.Affected{Ranges: []vulns.AffectedRange{
+		{Type: "GIT", Repo: "https://github.com/curl/curl", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "c0ffee"}}},
+	}}
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-1234": {ID: "CVE-2024-1234", Affected: []vulns.Affected{curl}},
+		"CVE-2024-1235": {ID: "CVE-2024-1235"},
+	}
+	addCVE5GitRanges(osvData, cveList)
+
+	want := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-1234": {ID: "CVE-2024-1234", Affected: []vulns.Affected{
+			curl,
+			{Ranges: []vulns.AffectedRange{{
+				Type:   "GIT",
+				Repo:   "https://github.com/madler/zlib",
+				Events: []vulns.Event{{Introduced: "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2"}, {Fixed: "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1"}},
+			}}},
+		}},
+		"CVE-2024-1235": {ID: "CVE-2024-1235"},
+	}
+	if diff := gocmp.Diff(want, osvData); diff != "" {
+		t.Errorf("addCVE5GitRanges() mismatch (-want +got):\n%s", diff)
+	}
+}
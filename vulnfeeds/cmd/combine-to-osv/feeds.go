@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// partFeed is a feed of parts, written into its own subdirectory of the parts
+// directory by one of the converters.
+type partFeed struct {
+	// Name is the name of the feed's subdirectory, also recorded as the
+	// feed in the provenance of records.
+	Name string `json:"name"`
+	// Suffix is the suffix of the feed's part files, e.g. ".alpine.json".
+	Suffix string `json:"suffix"`
+	// Ecosystem is the ecosystem the feed's packages must be in (e.g.
+	// "Alpine", allowing "Alpine:v3.18"). Empty for feeds of ranges without
+	// an ecosystem, like the NVD's.
+	Ecosystem ecosystems.Ecosystem `json:"ecosystem,omitempty"`
+}
+
+// partFeedsManifest is the registry of the feeds in the parts directory.
+type partFeedsManifest struct {
+	Feeds []partFeed `json:"feeds"`
+}
+
+// defaultPartFeeds are the feeds written by the converters in the cmd folder.
+var defaultPartFeeds = []partFeed{
+	{Name: "alpine", Suffix: ".alpine.json", Ecosystem: ecosystems.Alpine},
+	{Name: "debian", Suffix: ".debian.json", Ecosystem: ecosystems.Debian},
+	{Name: "openeuler", Suffix: ".openeuler.json", Ecosystem: ecosystems.OpenEuler},
+	{Name: "fedora", Suffix: ".fedora.json", Ecosystem: ecosystems.Fedora},
+	{Name: "centos", Suffix: ".centos.json", Ecosystem: ecosystems.CentOS},
+	{Name: "photon", Suffix: ".photon.json", Ecosystem: ecosystems.PhotonOS},
+	{Name: "alas", Suffix: ".alas.json", Ecosystem: ecosystems.AmazonLinux},
+	{Name: "linux", Suffix: ".linux.json", Ecosystem: ecosystems.Linux},
+	{Name: "nvd", Suffix: ".nvd.json"},
+	{Name: "ghsa", Suffix: ".ghsa.json"},
+}
+
+// loadPartFeeds loads the registry of feeds from a JSON manifest, or returns
+// the default feeds if manifestPath is empty.
+func loadPartFeeds(manifestPath string) ([]partFeed, error) {
+	if manifestPath == "" {
+		return defaultPartFeeds, nil
+	}
+	buf, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var manifest partFeedsManifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestPath, err)
+	}
+	seen := make(map[string]bool)
+	for _, feed := range manifest.Feeds {
+		if feed.Name == "" || strings.ContainsAny(feed.Name, `/\`) || !strings.HasSuffix(feed.Suffix, ".json") {
+			return nil, fmt.Errorf("invalid feed %+v in %s", feed, manifestPath)
+		}
+		if feed.Ecosystem != "" && !feed.Ecosystem.Known() && !feed.Ecosystem.Pending() {
+			return nil, fmt.Errorf("feed %q has unknown ecosystem %q in %s", feed.Name, feed.Ecosystem, manifestPath)
+		}
+		if seen[feed.Name] {
+			return nil, fmt.Errorf("feed %q is registered twice in %s", feed.Name, manifestPath)
+		}
+		seen[feed.Name] = true
+	}
+	return manifest.Feeds, nil
+}
+
+// inNamespace returns whether a package is in the feed's ecosystem, or a
+// release of it.
+func (f partFeed) inNamespace(pkgInfo vulns.PackageInfo) bool {
+	if f.Ecosystem == "" {
+		return true
+	}
+	return f.Ecosystem.Contains(pkgInfo.Ecosystem)
+}
+
+// partFeedStats are the statistics of loading a feed's parts.
+type partFeedStats struct {
+	Files    int
+	Packages int
+	// Rejected counts the files and packages that don't belong to the feed.
+	Rejected int
+	// Unversioned counts the files written before the part format was
+	// versioned.
+	Unversioned int
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func writeTestFile(t *testing.T, filePath string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPartsRejectsUnregisteredFeeds(t *testing.T) {
+	partsPath := t.TempDir()
+	writeTestFile(t, path.Join(partsPath, "alpine", "CVE-2022-33745.alpine.json"), `[]`)
+	writeTestFile(t, path.Join(partsPath, "alpine-old", "CVE-2022-33745.alpine.json"), `[]`)
+
+	_, _, err := loadParts(partsPath, defaultPartFeeds, false, &failures.Collector{})
+	if err == nil || !strings.Contains(err.Error(), "alpine-old") {
+		t.Errorf("loadParts() error = %v, want the unregistered alpine-old feed rejected", err)
+	}
+}
+
+func TestLoadInnerPartsNamespacing(t *testing.T) {
+	feedPath := path.Join(t.TempDir(), "alpine")
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-33745.alpine.json"), `[
+		{"pkg_name": "xen", "ecosystem": "Alpine:v3.16"},
+		{"pkg_name": "xen", "ecosystem": "Debian:12"},
+		{"pkg_name": "xen", "ecosystem": "AlpineLinux"}
+	]`)
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-32746.debian.json"), `[{"pkg_name": "samba", "ecosystem": "Alpine:v3.16"}]`)
+	writeTestFile(t, path.Join(feedPath, "README.md"), `Not a part.`)
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-0001.alpine.json"), `{"truncated`)
+
+	feed := partFeed{Name: "alpine", Suffix: ".alpine.json", Ecosystem: ecosystems.Alpine}
+	output := make(map[cves.CVEID][]vulns.PackageInfo)
+	var stats partFeedStats
+	var failed failures.Collector
+	if err := loadInnerParts(feedPath, feed, output, make(map[cves.CVEID]time.Time), &stats, false, &failed); err != nil {
+		t.Fatalf("loadInnerParts() error: %v", err)
+	}
+
+	want := map[cves.CVEID][]vulns.PackageInfo{
+		"CVE-2022-33745": {{PkgName: "xen", Ecosystem: "Alpine:v3.16"}},
+	}
+	if diff := gocmp.Diff(want, output); diff != "" {
+		t.Errorf("loadInnerParts() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff(partFeedStats{Files: 1, Packages: 1, Rejected: 3, Unversioned: 1}, stats); diff != "" {
+		t.Errorf("loadInnerParts() stats mismatch (-want +got):\n%s", diff)
+	}
+	// The malformed part fails on its own, without failing the others.
+	if got := failed.Failures(); len(got) != 1 || got[0].Record != path.Join(feedPath, "CVE-2022-0001.alpine.json") {
+		t.Errorf("loadInnerParts() failures = %v, want the malformed part", got)
+	}
+}
+
+func TestLoadPartFeeds(t *testing.T) {
+	feeds, err := loadPartFeeds("")
+	if err != nil || len(feeds) != len(defaultPartFeeds) {
+		t.Errorf("loadPartFeeds(\"\") = %v, %v, want the default feeds", feeds, err)
+	}
+
+	dir := t.TempDir()
+	manifestPath := path.Join(dir, "manifest.json")
+	writeTestFile(t, manifestPath, `{"feeds": [
+		{"name": "alpine", "suffix": ".alpine.json", "ecosystem": "Alpine"},
+		{"name": "nvd", "suffix": ".nvd.json"}
+	]}`)
+	feeds, err = loadPartFeeds(manifestPath)
+	if err != nil {
+		t.Fatalf("loadPartFeeds() error: %v", err)
+	}
+	want := []partFeed{
+		{Name: "alpine", Suffix: ".alpine.json", Ecosystem: "Alpine"},
+		{Name: "nvd", Suffix: ".nvd.json"},
+	}
+	if diff := gocmp.Diff(want, feeds); diff != "" {
+		t.Errorf("loadPartFeeds() mismatch (-want +got):\n%s", diff)
+	}
+
+	for name, manifest := range map[string]string{
+		"duplicate.json": `{"feeds": [{"name": "nvd", "suffix": ".nvd.json"}, {"name": "nvd", "suffix": ".nvd.json"}]}`,
+		"suffix.json":    `{"feeds": [{"name": "nvd", "suffix": ".nvd"}]}`,
+		"nested.json":    `{"feeds": [{"name": "nvd/2024", "suffix": ".nvd.json"}]}`,
+		"ecosystem.json": `{"feeds": [{"name": "alpine", "suffix": ".alpine.json", "ecosystem": "Alpnie"}]}`,
+	} {
+		writeTestFile(t, path.Join(dir, name), manifest)
+		if _, err := loadPartFeeds(path.Join(dir, name)); err == nil {
+			t.Errorf("loadPartFeeds(%s) succeeded", name)
+		}
+	}
+}
+
+func TestLoadInnerPartsVersions(t *testing.T) {
+	feedPath := path.Join(t.TempDir(), "alpine")
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-33745.alpine.json"), `{"version": 1, "packages": [{"pkg_name": "xen", "ecosystem": "Alpine:v3.16"}]}`)
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-32746.alpine.json"), `[{"pkg_name": "samba", "ecosystem": "Alpine:v3.16"}]`)
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-0001.alpine.json"), `{"version": 2, "packages": []}`)
+	writeTestFile(t, path.Join(feedPath, "CVE-2022-0002.alpine.json"), `{"version": 1, "packages": [{"pkg_name": "xen", "severity": "high"}]}`)
+	feed := partFeed{Name: "alpine", Suffix: ".alpine.json", Ecosystem: ecosystems.Alpine}
+
+	for _, strict := range []bool{false, true} {
+		output := make(map[cves.CVEID][]vulns.PackageInfo)
+		var stats partFeedStats
+		var failed failures.Collector
+		if err := loadInnerParts(feedPath, feed, output, make(map[cves.CVEID]time.Time), &stats, strict, &failed); err != nil {
+			t.Fatalf("loadInnerParts() error: %v", err)
+		}
+		var got []string
+		for _, f := range failed.Failures() {
+			got = append(got, path.Base(f.Record))
+		}
+		// Later versions and unknown fields always fail, unversioned parts
+		// only when strict.
+		want := []string{"CVE-2022-0001.alpine.json", "CVE-2022-0002.alpine.json"}
+		wantStats := partFeedStats{Files: 2, Packages: 2, Unversioned: 1}
+		if strict {
+			want = []string{"CVE-2022-0001.alpine.json", "CVE-2022-0002.alpine.json", "CVE-2022-32746.alpine.json"}
+			wantStats = partFeedStats{Files: 1, Packages: 1}
+		}
+		if diff := gocmp.Diff(want, got); diff != "" {
+			t.Errorf("loadInnerParts(strict %v) failures mismatch (-want +got):\n%s", strict, diff)
+		}
+		if diff := gocmp.Diff(wantStats, stats); diff != "" {
+			t.Errorf("loadInnerParts(strict %v) stats mismatch (-want +got):\n%s", strict, diff)
+		}
+	}
+}
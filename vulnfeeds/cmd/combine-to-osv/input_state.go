@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/dryrun"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// recordInputs are the content hashes of the inputs a record was combined
+// from, and the record's modified time.
+type recordInputs struct {
+	NVD      string `json:"nvd"`
+	Parts    string `json:"parts"`
+	Modified string `json:"modified,omitempty"`
+}
+
+// inputState is the inputs of the records of a run, so the next run only
+// reconverts the CVEs whose NVD data or parts changed.
+type inputState struct {
+	// Converter is the revision of the converter of the run. All records are
+	// reconverted by a different revision.
+	Converter string                      `json:"converter"`
+	Records   map[cves.CVEID]recordInputs `json:"records"`
+}
+
+// loadInputState loads the input state written by a previous run. A missing
+// file is the empty state of a first run.
+func loadInputState(filePath string) (inputState, error) {
+	state := inputState{Records: map[cves.CVEID]recordInputs{}}
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+	if state.Records == nil {
+		state.Records = map[cves.CVEID]recordInputs{}
+	}
+	return state, nil
+}
+
+// write writes the input state for the next run.
+func (s inputState) write(filePath string) error {
+	return utility.WriteFileAtomic(filePath, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	})
+}
+
+// contentHash returns the SHA-256 of the JSON encoding of v.
+func contentHash(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// inputChanges are the changes between the inputs of the previous run and
+// this one.
+type inputChanges struct {
+	// changed are the CVEs to reconvert: those new since the previous run, or
+	// whose NVD data or parts changed.
+	changed map[cves.CVEID]bool
+	// partsChanged are the changed CVEs of the previous run whose parts
+	// changed.
+	partsChanged map[cves.CVEID]bool
+	// removed are the CVEs of the previous run without parts anymore.
+	removed []cves.CVEID
+	// next is the input state of this run.
+	next inputState
+}
+
+// diffInputs compares the inputs of each CVE with parts with those of the
+// previous run.
+func diffInputs(previous inputState, converter string, allCves map[cves.CVEID]cves.Vulnerability, allParts map[cves.CVEID][]vulns.PackageInfo) (inputChanges, error) {
+	changes := inputChanges{
+		changed:      make(map[cves.CVEID]bool),
+		partsChanged: make(map[cves.CVEID]bool),
+		next:         inputState{Converter: converter, Records: make(map[cves.CVEID]recordInputs)},
+	}
+	for cveId, cve := range allCves {
+		if len(allParts[cveId]) == 0 {
+			continue
+		}
+		nvdHash, err := contentHash(cve)
+		if err != nil {
+			return changes, fmt.Errorf("failed to hash %s: %w", cveId, err)
+		}
+		partsHash, err := contentHash(allParts[cveId])
+		if err != nil {
+			return changes, fmt.Errorf("failed to hash the parts of %s: %w", cveId, err)
+		}
+		inputs := recordInputs{NVD: nvdHash, Parts: partsHash}
+		last, ok := previous.Records[cveId]
+		switch {
+		case !ok || previous.Converter != converter:
+			changes.changed[cveId] = true
+		case last.Parts != partsHash:
+			changes.changed[cveId] = true
+			changes.partsChanged[cveId] = true
+		case last.NVD != nvdHash:
+			changes.changed[cveId] = true
+		default:
+			inputs.Modified = last.Modified
+		}
+		changes.next.Records[cveId] = inputs
+	}
+	for cveId := range previous.Records {
+		if _, ok := changes.next.Records[cveId]; !ok {
+			changes.removed = append(changes.removed, cveId)
+		}
+	}
+	slices.Sort(changes.removed)
+	return changes, nil
+}
+
+// only returns the CVEs to reconvert.
+func (c inputChanges) only(allCves map[cves.CVEID]cves.Vulnerability) map[cves.CVEID]cves.Vulnerability {
+	filtered := make(map[cves.CVEID]cves.Vulnerability, len(c.changed))
+	for cveId := range c.changed {
+		filtered[cveId] = allCves[cveId]
+	}
+	return filtered
+}
+
+// withUnchanged returns the reconverted records, along with stand-ins for
+// the records that weren't, with only their ID and modified time.
+func (c inputChanges) withUnchanged(osvData map[cves.CVEID]*vulns.Vulnerability) map[cves.CVEID]*vulns.Vulnerability {
+	all := maps.Clone(osvData)
+	for cveId, inputs := range c.next.Records {
+		if _, ok := all[cveId]; !ok && inputs.Modified != "" {
+			all[cveId] = &vulns.Vulnerability{ID: string(cveId), Modified: inputs.Modified}
+		}
+	}
+	return all
+}
+
+// recordModified records the modified time of the reconverted records in the
+// next input state.
+func (c inputChanges) recordModified(osvData map[cves.CVEID]*vulns.Vulnerability) {
+	for cveId, osv := range osvData {
+		if inputs, ok := c.next.Records[cveId]; ok {
+			inputs.Modified = osv.Modified
+			c.next.Records[cveId] = inputs
+		}
+	}
+}
+
+// removeRecords removes the records of CVEs from osvOutputPath, and from its
+// ecosystem subdirectories, or records their removal in plan if it's non-nil.
+func removeRecords(osvOutputPath string, removed []cves.CVEID, plan *dryrun.Plan) error {
+	for _, cveId := range removed {
+		var filePaths []string
+		for _, ext := range []string{".json", export.ProtobufExt} {
+			fileName := string(cveId) + ext
+			sharded, err := filepath.Glob(path.Join(osvOutputPath, "*", fileName))
+			if err != nil {
+				return err
+			}
+			filePaths = append(filePaths, sharded...)
+			filePaths = append(filePaths, path.Join(osvOutputPath, fileName))
+		}
+		for _, filePath := range filePaths {
+			if plan != nil {
+				rel, err := filepath.Rel(osvOutputPath, filePath)
+				if err != nil {
+					return err
+				}
+				plan.Remove(rel)
+				continue
+			}
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
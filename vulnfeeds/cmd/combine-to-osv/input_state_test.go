@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestDiffInputs(t *testing.T) {
+	allCves := map[cves.CVEID]cves.Vulnerability{
+		"CVE-2024-0001": {CVE: cves.CVE{ID: "CVE-2024-0001"}},
+		"CVE-2024-0002": {CVE: cves.CVE{ID: "CVE-2024-0002"}},
+		"CVE-2024-0003": {CVE: cves.CVE{ID: "CVE-2024-0003"}},
+		"CVE-2024-0004": {CVE: cves.CVE{ID: "CVE-2024-0004"}},
+		"CVE-2024-0005": {CVE: cves.CVE{ID: "CVE-2024-0005"}},
+	}
+	allParts := map[cves.CVEID][]vulns.PackageInfo{
+		"CVE-2024-0001": {{PkgName: "curl", Ecosystem: "Alpine:v3.19"}},
+		"CVE-2024-0002": {{PkgName: "curl", Ecosystem: "Alpine:v3.19"}},
+		"CVE-2024-0003": {{PkgName: "curl", Ecosystem: "Alpine:v3.19"}},
+		"CVE-2024-0004": {{PkgName: "curl", Ecosystem: "Alpine:v3.19"}},
+	}
+	previous, err := diffInputs(inputState{}, "abc", allCves, allParts)
+	if err != nil {
+		t.Fatalf("diffInputs() error: %v", err)
+	}
+	if len(previous.changed) != 4 {
+		t.Errorf("diffInputs() of a first run changed %d CVEs, want all 4 with parts", len(previous.changed))
+	}
+	previous.recordModified(map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {Modified: "2024-01-01T00:00:00Z"},
+		"CVE-2024-0002": {Modified: "2024-01-01T00:00:00Z"},
+		"CVE-2024-0003": {Modified: "2024-01-01T00:00:00Z"},
+		"CVE-2024-0004": {Modified: "2024-01-01T00:00:00Z"},
+	})
+
+	// CVE-2024-0001 is unchanged, CVE-2024-0002 has new parts,
+	// CVE-2024-0003 new NVD data, CVE-2024-0004 no parts anymore and
+	// CVE-2024-0005 parts for the first time.
+	allParts["CVE-2024-0002"] = append(allParts["CVE-2024-0002"], vulns.PackageInfo{PkgName: "curl", Ecosystem: "Debian:12"})
+	allCves["CVE-2024-0003"] = cves.Vulnerability{CVE: cves.CVE{ID: "CVE-2024-0003", References: []cves.Reference{{Url: "https://curl.se/docs/CVE-2024-0003.html"}}}}
+	delete(allParts, "CVE-2024-0004")
+	allParts["CVE-2024-0005"] = []vulns.PackageInfo{{PkgName: "curl", Ecosystem: "Alpine:v3.19"}}
+
+	got, err := diffInputs(previous.next, "abc", allCves, allParts)
+	if err != nil {
+		t.Fatalf("diffInputs() error: %v", err)
+	}
+	want := map[cves.CVEID]bool{"CVE-2024-0002": true, "CVE-2024-0003": true, "CVE-2024-0005": true}
+	if diff := gocmp.Diff(want, got.changed); diff != "" {
+		t.Errorf("diffInputs() changed mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff(map[cves.CVEID]bool{"CVE-2024-0002": true}, got.partsChanged); diff != "" {
+		t.Errorf("diffInputs() partsChanged mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0004"}, got.removed); diff != "" {
+		t.Errorf("diffInputs() removed mismatch (-want +got):\n%s", diff)
+	}
+	if got := got.only(allCves); len(got) != 3 {
+		t.Errorf("only() = %d CVEs, want the 3 changed", len(got))
+	}
+	indexed := got.withUnchanged(map[cves.CVEID]*vulns.Vulnerability{"CVE-2024-0002": {ID: "CVE-2024-0002"}})
+	if unchanged := indexed["CVE-2024-0001"]; unchanged == nil || unchanged.Modified != "2024-01-01T00:00:00Z" {
+		t.Errorf("withUnchanged() CVE-2024-0001 = %+v, want its previous modified time", unchanged)
+	}
+
+	// A new converter reconverts everything.
+	got, err = diffInputs(previous.next, "def", allCves, allParts)
+	if err != nil {
+		t.Fatalf("diffInputs() error: %v", err)
+	}
+	if len(got.changed) != 4 {
+		t.Errorf("diffInputs() of a new converter changed %d CVEs, want all 4 with parts", len(got.changed))
+	}
+}
+
+func TestInputStateRoundTrip(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "state.json")
+	got, err := loadInputState(filePath)
+	if err != nil {
+		t.Fatalf("loadInputState() of a missing file error: %v", err)
+	}
+	if len(got.Records) != 0 {
+		t.Errorf("loadInputState() of a missing file = %+v, want an empty state", got)
+	}
+
+	want := inputState{Converter: "abc", Records: map[cves.CVEID]recordInputs{
+		"CVE-2024-0001": {NVD: "1", Parts: "2", Modified: "2024-01-01T00:00:00Z"},
+	}}
+	if err := want.write(filePath); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	got, err = loadInputState(filePath)
+	if err != nil {
+		t.Fatalf("loadInputState() error: %v", err)
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("loadInputState() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRemoveRecords(t *testing.T) {
+	outputPath := t.TempDir()
+	for _, dir := range []string{outputPath, path.Join(outputPath, "Alpine"), path.Join(outputPath, "Debian")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"CVE-2024-0001", "CVE-2024-0002"} {
+			if err := os.WriteFile(path.Join(dir, id+".json"), []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// The protobuf encoding of a record goes with it.
+	if err := os.WriteFile(path.Join(outputPath, "Alpine", "CVE-2024-0001"+export.ProtobufExt), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeRecords(outputPath, []cves.CVEID{"CVE-2024-0001", "CVE-2024-0003"}, nil); err != nil {
+		t.Fatalf("removeRecords() error: %v", err)
+	}
+	for _, dir := range []string{outputPath, path.Join(outputPath, "Alpine"), path.Join(outputPath, "Debian")} {
+		if _, err := os.Stat(path.Join(dir, "CVE-2024-0001.json")); !os.IsNotExist(err) {
+			t.Errorf("removeRecords() left CVE-2024-0001 in %s", dir)
+		}
+		if _, err := os.Stat(path.Join(dir, "CVE-2024-0002.json")); err != nil {
+			t.Errorf("removeRecords() removed CVE-2024-0002 from %s", dir)
+		}
+	}
+	if _, err := os.Stat(path.Join(outputPath, "Alpine", "CVE-2024-0001"+export.ProtobufExt)); !os.IsNotExist(err) {
+		t.Errorf("removeRecords() left the protobuf encoding of CVE-2024-0001")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// distroRecordIDs returns the IDs of the distribution records for a CVE
+// that the given parts were derived from.
+func distroRecordIDs(cveId cves.CVEID, pkgInfos []vulns.PackageInfo) []string {
+	var recordIDs []string
+	for _, pkgInfo := range pkgInfos {
+		if prefix, ok := ids.DistroPrefix(pkgInfo.Ecosystem); ok {
+			recordIDs = append(recordIDs, ids.DistroCVE(prefix, cveId))
+		}
+	}
+	return recordIDs
+}
+
+// sortedUnique sorts ids and removes duplicates and the given self ID.
+func sortedUnique(ids []string, self string) []string {
+	seen := make(map[string]bool, len(ids))
+	var result []string
+	for _, id := range ids {
+		if id == "" || id == self || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// linkRecords populates aliases and related IDs consistently across all
+// combined records:
+//   - each record is related to the distribution records of the parts it was combined from
+//   - aliases are transitive and symmetric among the combined records
+//   - related IDs are symmetric among the combined records
+func linkRecords(osvData map[cves.CVEID]*vulns.Vulnerability, allParts map[cves.CVEID][]vulns.PackageInfo) {
+	// Union-find over record IDs, joined by aliases.
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		// Keep the smallest ID as root so the result is independent of map order.
+		if rb < ra {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+	}
+
+	for cveId, osv := range osvData {
+		osv.Related = append(osv.Related, distroRecordIDs(cveId, allParts[cveId])...)
+		for _, alias := range osv.Aliases {
+			union(osv.ID, alias)
+		}
+	}
+
+	aliasGroups := make(map[string][]string)
+	for id := range parent {
+		root := find(id)
+		aliasGroups[root] = append(aliasGroups[root], id)
+	}
+
+	related := make(map[string][]string)
+	for _, osv := range osvData {
+		for _, id := range osv.Related {
+			related[id] = append(related[id], osv.ID)
+		}
+	}
+
+	for _, osv := range osvData {
+		if _, ok := parent[osv.ID]; ok {
+			osv.Aliases = sortedUnique(aliasGroups[find(osv.ID)], osv.ID)
+		}
+		osv.Related = sortedUnique(append(osv.Related, related[osv.ID]...), osv.ID)
+		// An ID can't be both an alias and merely related.
+		osv.Related = slices.DeleteFunc(osv.Related, func(id string) bool {
+			return slices.Contains(osv.Aliases, id)
+		})
+	}
+}
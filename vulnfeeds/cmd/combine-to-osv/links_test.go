@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestLinkRecords(t *testing.T) {
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2023-1234": {ID: "CVE-2023-1234"},
+		"CVE-2023-2000": {ID: "CVE-2023-2000", Aliases: []string{"GHSA-xxxx-yyyy-zzzz"}},
+		"CVE-2023-2001": {ID: "CVE-2023-2001", Aliases: []string{"GHSA-xxxx-yyyy-zzzz"}},
+		"CVE-2023-3000": {ID: "CVE-2023-3000", Related: []string{"CVE-2023-1234"}},
+	}
+	allParts := map[cves.CVEID][]vulns.PackageInfo{
+		"CVE-2023-1234": {
+			{PkgName: "openssl", Ecosystem: "Alpine:v3.18"},
+			{PkgName: "openssl", Ecosystem: "Alpine:v3.19"},
+			{PkgName: "openssl", Ecosystem: "Debian:12"},
+			{PkgName: "openssl", Ecosystem: "openEuler:22.03-LTS"},
+		},
+		"CVE-2023-3000": {
+			{PkgName: "curl", Ecosystem: "Debian:11"},
+		},
+	}
+
+	linkRecords(osvData, allParts)
+
+	want := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2023-1234": {
+			ID:      "CVE-2023-1234",
+			Related: []string{"ALPINE-CVE-2023-1234", "CVE-2023-3000", "DEBIAN-CVE-2023-1234"},
+		},
+		"CVE-2023-2000": {
+			ID:      "CVE-2023-2000",
+			Aliases: []string{"CVE-2023-2001", "GHSA-xxxx-yyyy-zzzz"},
+		},
+		"CVE-2023-2001": {
+			ID:      "CVE-2023-2001",
+			Aliases: []string{"CVE-2023-2000", "GHSA-xxxx-yyyy-zzzz"},
+		},
+		"CVE-2023-3000": {
+			ID:      "CVE-2023-3000",
+			Related: []string{"CVE-2023-1234", "DEBIAN-CVE-2023-3000"},
+		},
+	}
+	if diff := gocmp.Diff(want, osvData); diff != "" {
+		t.Errorf("linkRecords() mismatch (-want +got):\n%s", diff)
+	}
+}
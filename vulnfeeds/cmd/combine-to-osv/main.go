@@ -1,16 +1,44 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/google/osv/vulnfeeds/audit"
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/details"
+	"github.com/google/osv/vulnfeeds/dryrun"
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/osvapi"
+	"github.com/google/osv/vulnfeeds/osvpb"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/server"
 	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/validation"
 	"github.com/google/osv/vulnfeeds/vulns"
 )
 
@@ -20,13 +48,23 @@ const (
 	defaultOSVOutputPath  = "osv_output"
 	defaultCVEListPath    = "."
 
-	alpineEcosystem          = "Alpine"
+	// How many OSV files are written at once.
+	writeWorkers = 16
+
+	// The feed name of the NVD CVE records parts are combined with.
+	nvdCVEFeed = "nvd-cve"
+
 	alpineSecurityTrackerURL = "https://security.alpinelinux.org/vuln"
-	debianEcosystem          = "Debian"
 	debianSecurityTrackerURL = "https://security-tracker.debian.org/tracker"
 )
 
 var Logger utility.LoggerWrapper
+var serveFlags = server.RegisterFlags(flag.CommandLine)
+var failureFlags = failures.RegisterFlags(flag.CommandLine)
+var dryRunFlags = dryrun.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
 
 func main() {
 	var logCleanup func()
@@ -34,10 +72,51 @@ func main() {
 	defer logCleanup()
 
 	cvePath := flag.String("cvePath", defaultCvePath, "Path to CVE file")
+	cveHistoryPath := flag.String("cveHistory", "", "Path to the time of the last meaningful change of each CVE, as kept by download-cves -history, so records are only modified when their CVE meaningfully changes")
 	partsInputPath := flag.String("partsPath", defaultPartsInputPath, "Path to CVE file")
+	partsManifest := flag.String("partsManifest", "", "Path to a JSON manifest of the feeds in partsPath (defaults to the feeds of the converters in this repository)")
+	osvPartsPath := flag.String("osvPartsPath", "", "Path to a directory of OSV records (e.g. a clone of https://github.com/pypa/advisory-database) whose affected packages are merged into the CVEs they alias")
 	osvOutputPath := flag.String("osvOutputPath", defaultOSVOutputPath, "Path to CVE file")
 	cveListPath := flag.String("cveListPath", defaultCVEListPath, "Path to clone of https://github.com/CVEProject/cvelistV5")
+	reproducible := flag.Bool("reproducible", false, "Keep the modified time of records in osvOutputPath whose content hasn't changed")
+	overridesPath := flag.String("overridesPath", "", "Path to a directory of overrides named <record ID>.json or .yaml (JSON merge patches, optionally adding and removing affected entries and references), applied to the combined records")
+	strictOverrides := flag.Bool("strictOverrides", false, "Fail if any override no longer applies cleanly")
+	auditTable := flag.String("auditTable", "", "BigQuery table (project.dataset.table) to write an audit row per combined record to")
+	strictParts := flag.Bool("strictParts", false, "Reject parts written before the part format was versioned, rather than warning about them")
+	strictValidation := flag.Bool("strictValidation", false, "Fail if any combined record would be rejected by the importer")
+	shardByEcosystem := flag.Bool("shardByEcosystem", false, "Write records into a subdirectory of osvOutputPath per ecosystem they affect")
+	zipShards := flag.Bool("zipShards", false, "Bundle each ecosystem subdirectory into an all.zip (with -shardByEcosystem)")
+	protobuf := flag.Bool("protobuf", false, "Also write each record as a binary osv.Vulnerability protobuf message (<ID>.pb, see osvpb) next to its JSON")
+	mergeWith := flag.String("mergeWith", "", "Path to the published records (e.g. synced from the output bucket) to merge the generated records into, keeping edits made by hand")
+	mergeBasePath := flag.String("mergeBasePath", "", "Path to the records generated by the previous run, the base of the merge with -mergeWith; this run's are written there for the next")
+	inputStatePath := flag.String("inputState", "", "Path to the content hashes of the NVD data and parts of each record of the previous run, to only reconvert the CVEs whose inputs changed into the records already in osvOutputPath; rewritten for the next run")
+	composeDetails := flag.Bool("composeDetails", false, "Compose each record's details from a template of its affected packages and the CVE's description, rather than only the description")
+	detailsTemplate := flag.String("detailsTemplate", "", "Path to the text/template the details are composed with (with -composeDetails; defaults to details.DefaultTemplate)")
+	upstream := flag.Bool("upstream", false, "Look up the aliases of each record on osv.dev, attaching the GIT ranges of upstream records to its database_specific and adding the IDs they're related to")
+	upstreamAPI := flag.String("upstreamAPI", osvapi.APIURL, "Base URL of the osv.dev API the aliases are looked up in (with -upstream)")
+	upstreamWorkers := flag.Int("upstreamWorkers", 8, "How many aliases are looked up at once (with -upstream)")
+	credits := flag.Bool("credits", false, "Credit the people acknowledged by each CVE's record in cveListPath and in its description")
+	cve5GitRanges := flag.Bool("cve5GitRanges", false, "Add the commit ranges recorded by each CVE's CNA in cveListPath to its record, unless it already has a GIT range of the repo")
+	runID := flag.String("runID", "", "ID of this run, recorded in each record's generator and audit rows (defaults to the start time)")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if serveFlags.Enabled() {
+		if err := serveFlags.Serve("combine-to-osv", flag.CommandLine); err != nil {
+			Logger.Fatalf("Server failed: %s", err)
+		}
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
 
 	err := os.MkdirAll(*cvePath, 0755)
 	if err != nil {
@@ -48,10 +127,286 @@ func main() {
 		Logger.Fatalf("Can't create output path: %s", err)
 	}
 
-	allCves := loadAllCVEs(*cvePath)
-	allParts, cveModifiedMap := loadParts(*partsInputPath)
+	var failed failures.Collector
+	allCves, err := loadAllCVEs(*cvePath, &failed)
+	if err != nil {
+		Logger.Fatalf("Failed to load CVEs: %s", err)
+	}
+	feeds, err := loadPartFeeds(*partsManifest)
+	if err != nil {
+		Logger.Fatalf("Failed to load the parts manifest: %s", err)
+	}
+	allParts, cveModifiedMap, err := loadParts(*partsInputPath, feeds, *strictParts, &failed)
+	if err != nil {
+		Logger.Fatalf("Failed to load parts: %s", err)
+	}
+	failed.Report(Logger, "CVE and part files")
+	if err := failed.Check(failureFlags.MaxRate()); err != nil {
+		Logger.Fatalf("Too many files failed to load: %s", err)
+	}
+	now := time.Now().UTC()
+	var changes *inputChanges
+	if *inputStatePath != "" {
+		previous, err := loadInputState(*inputStatePath)
+		if err != nil {
+			Logger.Fatalf("Failed to load the input state: %s", err)
+		}
+		c, err := diffInputs(previous, utility.ReadBuildInfo().Revision, allCves, allParts)
+		if err != nil {
+			Logger.Fatalf("Failed to compare inputs with the previous run: %s", err)
+		}
+		changes = &c
+		Logger.Infof("Reconverting %d of %d CVEs with parts (%d with changed parts), removing %d", len(changes.changed), len(changes.next.Records), len(changes.partsChanged), len(changes.removed))
+		allCves = changes.only(allCves)
+		// Changed parts are a change of the record, even if their files are
+		// older than the record.
+		for cveId := range changes.partsChanged {
+			cveModifiedMap[cveId] = now
+		}
+	}
+	if *cveHistoryPath != "" {
+		history, err := cves.LoadChangeHistory(*cveHistoryPath)
+		if err != nil {
+			Logger.Fatalf("Failed to load the CVE change history: %s", err)
+		}
+		Logger.Infof("Moved the modified time of %d CVEs back to their last meaningful change", applyChangeHistory(allCves, history))
+	}
 	combinedData := combineIntoOSV(allCves, allParts, *cveListPath, cveModifiedMap)
-	writeOSVFile(combinedData, *osvOutputPath)
+	sources := partSources(*partsInputPath, feeds)
+	if *osvPartsPath != "" {
+		osvParts, err := loadOSVParts(*osvPartsPath)
+		if err != nil {
+			Logger.Fatalf("Failed to load OSV parts: %s", err)
+		}
+		for cveId, osvSources := range mergeOSVParts(combinedData, allCves, osvParts, *cveListPath) {
+			sources[cveId] = append(sources[cveId], osvSources...)
+		}
+		Logger.Infof("Loaded OSV parts for %d CVEs", len(osvParts))
+	}
+	if *upstream {
+		// Before the records are linked, so the related IDs are made mutual.
+		upstreamSources, stats := enrichFromUpstream(context.Background(), combinedData, osvapi.NewClient(*upstreamAPI), *upstreamWorkers)
+		for cveId, s := range upstreamSources {
+			sources[cveId] = append(sources[cveId], s...)
+		}
+		Logger.Infof("Looked up %d aliases on osv.dev (%d found, %d failed), enriching %d records", stats.Lookups, stats.Found, stats.Failed, stats.Enriched)
+	}
+	linkRecords(combinedData, allParts)
+	if *cve5GitRanges && *cveListPath != "" {
+		addCVE5GitRanges(combinedData, *cveListPath)
+	}
+	if *credits {
+		// Before the details are composed, as the acknowledgments are in
+		// the CVE's own description.
+		addRecordCredits(combinedData, *cveListPath)
+	}
+	if *composeDetails {
+		generator, err := details.Load(*detailsTemplate)
+		if err != nil {
+			Logger.Fatalf("Failed to load the details template: %s", err)
+		}
+		composeRecordDetails(combinedData, generator)
+	}
+	if *overridesPath != "" {
+		overrides, err := loadOverrides(*overridesPath)
+		if err != nil {
+			Logger.Fatalf("Failed to load overrides: %s", err)
+		}
+		applied, problems := applyOverrides(combinedData, overrides)
+		for _, problem := range problems {
+			Logger.Warnf("Override problem: %s", problem)
+		}
+		if *strictOverrides && len(problems) > 0 {
+			Logger.Fatalf("%d overrides no longer apply cleanly", len(problems))
+		}
+		for _, id := range applied {
+			sources[id] = append(sources[id], vulns.ProvenanceSource{Feed: overridesFeed, RecordID: string(id)})
+		}
+		Logger.Infof("Applied %d of %d overrides", len(applied), len(overrides))
+	}
+	if *mergeWith != "" {
+		if *mergeBasePath == "" {
+			Logger.Fatalf("-mergeWith requires -mergeBasePath")
+		}
+		base, err := loadRecords(*mergeBasePath)
+		if err != nil {
+			Logger.Fatalf("Failed to load the records of the previous run: %s", err)
+		}
+		published, err := loadRecords(*mergeWith)
+		if err != nil {
+			Logger.Fatalf("Failed to load the published records: %s", err)
+		}
+		// This run's generated records are the base of the next run's merge,
+		// which a dry run doesn't have.
+		if !dryRunFlags.Enabled() {
+			if err := writeOSVFile(combinedData, *mergeBasePath, writeOptions{}); err != nil {
+				Logger.Fatalf("Failed to write the generated records to %s: %s", *mergeBasePath, err)
+			}
+		}
+		merged, conflicts := mergePublished(combinedData, base, published)
+		for _, conflict := range conflicts {
+			Logger.Warnf("Merge conflict: %s", conflict)
+		}
+		for _, id := range merged {
+			sources[id] = append(sources[id], vulns.ProvenanceSource{Feed: publishedFeed, RecordID: string(id)})
+		}
+		Logger.Infof("Merged %d generated records with the %d published, with %d conflicts", len(merged), len(published), len(conflicts))
+	}
+	if *runID == "" {
+		*runID = now.Format(time.RFC3339)
+	}
+	stampProvenance(combinedData, sources, utility.ReadBuildInfo(), now)
+	stampGenerator(combinedData, vulns.NewGenerator("combine-to-osv", *runID, utility.ReadBuildInfo()))
+	if problems := validateRecords(combinedData, now); *strictValidation && len(problems) > 0 {
+		Logger.Fatalf("%d problems the importer would reject", len(problems))
+	}
+	var plan *dryrun.Plan
+	if dryRunFlags.Enabled() {
+		// Records of CVEs not reconverted are kept, so with an input state
+		// only the records of CVEs without parts anymore are removed.
+		var stale func(name string) bool
+		if changes == nil {
+			stale = isRecordFile
+		}
+		plan = dryrun.NewPlan(*osvOutputPath, stale)
+	}
+	if changes != nil {
+		if err := removeRecords(*osvOutputPath, changes.removed, plan); err != nil {
+			Logger.Fatalf("Failed to remove the records of CVEs without parts: %s", err)
+		}
+	}
+	err = writeOSVFile(combinedData, *osvOutputPath, writeOptions{
+		reproducible:     *reproducible,
+		shardByEcosystem: *shardByEcosystem,
+		zipShards:        *zipShards,
+		protobuf:         *protobuf,
+		plan:             plan,
+		outputs:          outputFlags,
+	})
+	if err != nil {
+		Logger.Fatalf("Failed to write OSV files: %s", err)
+	}
+	if plan != nil {
+		if err := dryRunFlags.Report(plan, os.Stdout); err != nil {
+			Logger.Fatalf("Failed to compare with existing output: %s", err)
+		}
+		return
+	}
+	indexed := combinedData
+	if changes != nil {
+		indexed = changes.withUnchanged(combinedData)
+	}
+	if err := writeModifiedSinceIndexes(indexed, *osvOutputPath, now); err != nil {
+		Logger.Fatalf("Failed to write modified-since index files: %s", err)
+	}
+	if changes != nil {
+		changes.recordModified(combinedData)
+		if err := changes.next.write(*inputStatePath); err != nil {
+			Logger.Fatalf("Failed to write the input state: %s", err)
+		}
+	}
+
+	if *auditTable != "" {
+		ctx := context.Background()
+		auditSink, err := audit.NewBigQuerySink(ctx, *auditTable, "combine-to-osv", *runID)
+		if err != nil {
+			Logger.Fatalf("Failed to create audit sink: %s", err)
+		}
+		auditRecords(ctx, auditSink, combinedData)
+		if err := auditSink.Close(ctx); err != nil {
+			Logger.Warnf("Failed to write audit events: %s", err)
+		}
+	}
+	if err := outputFlags.Finish("combine-to-osv"); err != nil {
+		Logger.Fatalf("Combining failed: %s", err)
+	}
+}
+
+// applyChangeHistory moves the LastModified time of each CVE back to its
+// last meaningful change in history, where that's known, returning how many
+// were moved.
+func applyChangeHistory(allCves map[cves.CVEID]cves.Vulnerability, history *cves.ChangeHistory) int {
+	moved := 0
+	for cveId, v := range allCves {
+		lastModified := v.CVE.LastModified
+		history.Apply(&v.CVE)
+		if !v.CVE.LastModified.Equal(lastModified.Time) {
+			allCves[cveId] = v
+			moved++
+		}
+	}
+	return moved
+}
+
+// composeRecordDetails replaces the details of the combined records with
+// those composed by generator, keeping the details of any it fails for.
+func composeRecordDetails(osvData map[cves.CVEID]*vulns.Vulnerability, generator *details.Generator) {
+	for id, osv := range osvData {
+		composed, err := generator.Generate(osv)
+		if err != nil {
+			Logger.Warnf("Failed to compose the details of %s: %v", id, err)
+			continue
+		}
+		osv.Details = composed
+	}
+}
+
+// addRecordCredits credits the people acknowledged by each CVE's CNA in the
+// CVE List, or failing that, in the description of the CVE.
+func addRecordCredits(osvData map[cves.CVEID]*vulns.Vulnerability, cveList string) {
+	credited := 0
+	for id, osv := range osvData {
+		var credits []vulns.Credit
+		if cveList != "" {
+			if cve5, err := vulns.LoadCVE5(id, cveList); err == nil {
+				credits = vulns.CreditsFromCVE5(*cve5)
+			}
+		}
+		if len(credits) == 0 {
+			credits = vulns.CreditsFromDescription(osv.Details)
+		}
+		osv.AddCredits(credits...)
+		if len(osv.Credits) > 0 {
+			credited++
+		}
+	}
+	Logger.Infof("Credited %d of %d records", credited, len(osvData))
+}
+
+// validateRecords checks the combined records against the importer's
+// acceptance rules, logging every problem found.
+func validateRecords(osvData map[cves.CVEID]*vulns.Vulnerability, now time.Time) []validation.Problem {
+	ids := maps.Keys(osvData)
+	slices.Sort(ids)
+	var problems []validation.Problem
+	for _, id := range ids {
+		problems = append(problems, validation.Check(osvData[id], now)...)
+	}
+	for _, problem := range problems {
+		Logger.Warnf("Validation problem: %s", problem)
+	}
+	Logger.Infof("%d validation problems in %d records", len(problems), len(osvData))
+	return problems
+}
+
+// auditRecords records the sources and decision points of each combined record.
+func auditRecords(ctx context.Context, auditSink *audit.Sink, osvData map[cves.CVEID]*vulns.Vulnerability) {
+	ids := maps.Keys(osvData)
+	slices.Sort(ids)
+	for _, id := range ids {
+		osv := osvData[id]
+		var sources []string
+		if provenance, ok := osv.DatabaseSpecific["provenance"].(vulns.Provenance); ok {
+			for _, source := range provenance.Sources {
+				sources = append(sources, source.Feed)
+			}
+		}
+		auditSink.Record(ctx, osv.ID, sources,
+			audit.Decision{Name: "withdrawn", Value: strconv.FormatBool(osv.Withdrawn != "")},
+			audit.Decision{Name: "affected", Value: strconv.Itoa(len(osv.Affected))},
+			audit.Decision{Name: "override", Value: strconv.FormatBool(slices.Contains(sources, overridesFeed))},
+		)
+	}
 }
 
 // getModifiedTime gets the modification time of a given file
@@ -71,32 +426,57 @@ func getModifiedTime(filePath string) (time.Time, error) {
 //
 // Parameters:
 //   - innerPartInputPath: The inner part path, such as "parts/alpine"
+//   - feed: The feed the folder is registered as
 //   - output: A map to store all PackageInfos for each CVE ID
 //   - cvePartsModifiedTime: A map tracking the latest modification time of each CVE part files
-func loadInnerParts(innerPartInputPath string, output map[cves.CVEID][]vulns.PackageInfo, cvePartsModifiedTime map[cves.CVEID]time.Time) {
+//   - stats: The statistics of the feed, updated with the parts loaded and rejected
+//   - strict: Whether to reject unversioned parts, rather than warn about them
+func loadInnerParts(innerPartInputPath string, feed partFeed, output map[cves.CVEID][]vulns.PackageInfo, cvePartsModifiedTime map[cves.CVEID]time.Time, stats *partFeedStats, strict bool, failed *failures.Collector) error {
 	dirInner, err := os.ReadDir(innerPartInputPath)
 	if err != nil {
-		Logger.Fatalf("Failed to read dir %q: %s", innerPartInputPath, err)
+		return err
 	}
 	for _, entryInner := range dirInner {
 		if !strings.HasSuffix(entryInner.Name(), ".json") {
 			continue
 		}
 		filePath := path.Join(innerPartInputPath, entryInner.Name())
-		file, err := os.Open(filePath)
+		if !strings.HasSuffix(entryInner.Name(), feed.Suffix) {
+			Logger.Warnf("Rejecting part %q: %s parts must end in %q", filePath, feed.Name, feed.Suffix)
+			stats.Rejected++
+			continue
+		}
+		// Turns CVE-2022-12345.alpine.json into CVE-2022-12345
+		cveId, err := ids.ParseCVE(strings.TrimSuffix(entryInner.Name(), feed.Suffix))
 		if err != nil {
-			Logger.Fatalf("Failed to open PackageInfo JSON %q: %s", path.Join(innerPartInputPath, entryInner.Name()), err)
+			Logger.Warnf("Skipping part %q: %s", filePath, err)
+			stats.Rejected++
+			continue
+		}
+		part, err := loadPart(filePath)
+		if err == nil && part.Version == 0 && strict {
+			err = errUnversionedPart
 		}
-		defer file.Close()
-		var pkgInfos []vulns.PackageInfo
-		err = json.NewDecoder(file).Decode(&pkgInfos)
 		if err != nil {
-			Logger.Fatalf("Failed to decode %q: %s", file.Name(), err)
+			failed.Failed(filePath, err)
+			continue
+		}
+		if part.Version == 0 {
+			Logger.Warnf("Part %q is unversioned, rerun the %s converter to write it in version %d of the part format", filePath, feed.Name, parts.Version)
+			stats.Unversioned++
 		}
 
-		// Turns CVE-2022-12345.alpine.json into CVE-2022-12345
-		cveId := cves.CVEID(strings.Split(entryInner.Name(), ".")[0])
-		output[cveId] = append(output[cveId], pkgInfos...)
+		failed.Succeeded()
+		stats.Files++
+		for _, pkgInfo := range part.Packages {
+			if !feed.inNamespace(pkgInfo) {
+				Logger.Warnf("Rejecting %s package %q of %s: ecosystem %q isn't in %q", feed.Name, pkgInfo.PkgName, cveId, pkgInfo.Ecosystem, feed.Ecosystem)
+				stats.Rejected++
+				continue
+			}
+			output[cveId] = append(output[cveId], pkgInfo)
+			stats.Packages++
+		}
 
 		Logger.Infof(
 			"Loaded Item: %s", entryInner.Name())
@@ -112,6 +492,25 @@ func loadInnerParts(innerPartInputPath string, output map[cves.CVEID][]vulns.Pac
 			cvePartsModifiedTime[cveId] = modifiedTime
 		}
 	}
+	return nil
+}
+
+// errUnversionedPart rejects parts written before the part format was
+// versioned, with -strictParts.
+var errUnversionedPart = errors.New("unversioned part")
+
+// loadPart loads a part file.
+func loadPart(filePath string) (parts.File, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return parts.File{}, err
+	}
+	defer file.Close()
+	part, err := parts.Decode(file)
+	if err != nil {
+		return parts.File{}, fmt.Errorf("failed to decode: %w", err)
+	}
+	return part, nil
 }
 
 // loadParts loads files generated by other executables in the cmd folder.
@@ -119,20 +518,39 @@ func loadInnerParts(innerPartInputPath string, output map[cves.CVEID][]vulns.Pac
 // Expects directory structure of:
 //
 // - <partsInputPath>/
-//   - alpineParts/
+//   - alpine/
 //   - CVE-2020-1234.alpine.json
 //   - ...
-//   - debianParts/
+//   - debian/
 //   - ...
 //
+// Every subdirectory must be one of the registered feeds, whose parts are
+// only loaded from files with the feed's suffix, and only for packages in its
+// ecosystem. Unversioned parts are rejected if strict.
+//
 // ## Returns
 // A mapping of "CVE-ID": []<Affected Package Information>
 // A mapping of "CVE-ID": time.Time (the latest modified time of its part files)
-func loadParts(partsInputPath string) (map[cves.CVEID][]vulns.PackageInfo, map[cves.CVEID]time.Time) {
+// An error if the directory has unregistered feeds
+func loadParts(partsInputPath string, feeds []partFeed, strict bool, failed *failures.Collector) (map[cves.CVEID][]vulns.PackageInfo, map[cves.CVEID]time.Time, error) {
 	dir, err := os.ReadDir(partsInputPath)
 	if err != nil {
-		Logger.Fatalf("Failed to read dir %q: %s", partsInputPath, err)
+		return nil, nil, err
+	}
+	registered := make(map[string]partFeed, len(feeds))
+	for _, feed := range feeds {
+		registered[feed.Name] = feed
 	}
+	var unexpected []string
+	for _, entry := range dir {
+		if _, ok := registered[entry.Name()]; !ok && entry.IsDir() {
+			unexpected = append(unexpected, entry.Name())
+		}
+	}
+	if len(unexpected) > 0 {
+		return nil, nil, fmt.Errorf("unregistered feeds in %s: %s", partsInputPath, strings.Join(unexpected, ", "))
+	}
+
 	output := map[cves.CVEID][]vulns.PackageInfo{}
 	cvePartsModifiedTime := make(map[cves.CVEID]time.Time)
 	for _, entry := range dir {
@@ -140,10 +558,31 @@ func loadParts(partsInputPath string) (map[cves.CVEID][]vulns.PackageInfo, map[c
 			Logger.Warnf("Unexpected file entry %q in %s", entry.Name(), partsInputPath)
 			continue
 		}
+		feed := registered[entry.Name()]
+		var stats partFeedStats
 		// map is already a reference type, so no need to pass in a pointer
-		loadInnerParts(path.Join(partsInputPath, entry.Name()), output, cvePartsModifiedTime)
+		if err := loadInnerParts(path.Join(partsInputPath, entry.Name()), feed, output, cvePartsModifiedTime, &stats, strict, failed); err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s parts: %w", feed.Name, err)
+		}
+		Logger.Infof("Loaded %d packages from %d %s parts (%d unversioned), rejected %d", stats.Packages, stats.Files, feed.Name, stats.Unversioned, stats.Rejected)
+	}
+	return output, cvePartsModifiedTime, nil
+}
+
+// newCombinedRecord creates the OSV record of a CVE that parts are combined into.
+func newCombinedRecord(cveId cves.CVEID, cve cves.Vulnerability, cveList string) *vulns.Vulnerability {
+	convertedCve, _ := vulns.FromCVE(cveId, cve.CVE)
+	if len(cveList) > 0 {
+		// Best-effort attempt to mark a disputed CVE as withdrawn.
+		modified, err := vulns.CVEIsDisputed(convertedCve, cveList)
+		if err != nil {
+			Logger.Warnf("Unable to determine CVE dispute status of %s: %v", convertedCve.ID, err)
+		}
+		if err == nil && modified != "" {
+			convertedCve.Withdrawn = modified
+		}
 	}
-	return output, cvePartsModifiedTime
+	return convertedCve
 }
 
 // combineIntoOSV creates OSV entry by combining loaded CVEs from NVD and PackageInfo information from security advisories.
@@ -154,27 +593,19 @@ func combineIntoOSV(loadedCves map[cves.CVEID]cves.Vulnerability, allParts map[c
 		if len(allParts[cveId]) == 0 {
 			continue
 		}
-		convertedCve, _ := vulns.FromCVE(cveId, cve.CVE)
-		if len(cveList) > 0 {
-			// Best-effort attempt to mark a disputed CVE as withdrawn.
-			modified, err := vulns.CVEIsDisputed(convertedCve, cveList)
-			if err != nil {
-				Logger.Warnf("Unable to determine CVE dispute status of %s: %v", convertedCve.ID, err)
-			}
-			if err == nil && modified != "" {
-				convertedCve.Withdrawn = modified
-			}
-		}
+		convertedCve := newCombinedRecord(cveId, cve, cveList)
 
 		addedDebianURL := false
 		addedAlpineURL := false
 		for _, pkgInfo := range allParts[cveId] {
-			convertedCve.AddPkgInfo(pkgInfo)
-			if strings.HasPrefix(pkgInfo.Ecosystem, debianEcosystem) && !addedDebianURL {
-				addReference(string(cveId), debianEcosystem, convertedCve)
+			if err := convertedCve.AddPkgInfo(pkgInfo); err != nil {
+				Logger.Warnf("%v", err)
+			}
+			if ecosystems.Debian.Contains(pkgInfo.Ecosystem) && !addedDebianURL {
+				addReference(string(cveId), ecosystems.Debian, convertedCve)
 				addedDebianURL = true
-			} else if strings.HasPrefix(pkgInfo.Ecosystem, alpineEcosystem) && !addedAlpineURL {
-				addReference(string(cveId), alpineEcosystem, convertedCve)
+			} else if ecosystems.Alpine.Contains(pkgInfo.Ecosystem) && !addedAlpineURL {
+				addReference(string(cveId), ecosystems.Alpine, convertedCve)
 				addedAlpineURL = true
 			}
 		}
@@ -190,29 +621,280 @@ func combineIntoOSV(loadedCves map[cves.CVEID]cves.Vulnerability, allParts map[c
 }
 
 // writeOSVFile writes out the given osv objects into individual json files
-func writeOSVFile(osvData map[cves.CVEID]*vulns.Vulnerability, osvOutputPath string) {
+// after normalizing them and removing any duplicate affected entries
+// introduced by merging parts.
+// When reproducible is set, records whose content matches the existing file
+// keep that file's modified time. When sharding by ecosystem, each record is
+// written into the directory of every ecosystem it's exported under, which
+// can also be bundled into an all.zip. Files are written concurrently, each
+// atomically, so a failed run never leaves a truncated record behind. With a
+// plan, the records are only recorded in it, and not bundled.
+func writeOSVFile(osvData map[cves.CVEID]*vulns.Vulnerability, osvOutputPath string, opts writeOptions) error {
+	deduplicated := 0
+	recordDirs := make(map[cves.CVEID][]string, len(osvData))
+	ecosystemDirs := make(map[string]bool)
 	for vId, osv := range osvData {
-		file, err := os.OpenFile(path.Join(osvOutputPath, string(vId)+".json"), os.O_CREATE|os.O_RDWR, 0644)
-		if err != nil {
-			Logger.Fatalf("Failed to create/open file to write: %s", err)
+		osv.Normalize()
+		if removed := osv.DeduplicateAffected(); removed > 0 {
+			Logger.Infof("Removed %d duplicate affected entries/ranges from %s", removed, vId)
+			deduplicated += removed
 		}
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "  ")
-		err = encoder.Encode(osv)
-		if err != nil {
-			Logger.Fatalf("Failed to encode OSVs")
+		if !opts.shardByEcosystem {
+			recordDirs[vId] = []string{osvOutputPath}
+			continue
+		}
+		for _, ecosystem := range export.Ecosystems(osv) {
+			dir := path.Join(osvOutputPath, ecosystem)
+			if !ecosystemDirs[dir] && opts.plan == nil {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return err
+				}
+				ecosystemDirs[dir] = true
+			}
+			recordDirs[vId] = append(recordDirs[vId], dir)
 		}
-		file.Close()
 	}
 
+	var unchanged atomic.Int64
+	var g errgroup.Group
+	g.SetLimit(writeWorkers)
+	for vId, osv := range osvData {
+		g.Go(func() error {
+			dirs := recordDirs[vId]
+			if opts.reproducible && keepStableModified(osv, path.Join(dirs[0], string(vId)+".json")) {
+				unchanged.Add(1)
+			}
+			var pb []byte
+			if opts.protobuf {
+				var err error
+				if pb, err = osvpb.Marshal(osv); err != nil {
+					return fmt.Errorf("failed to encode %s as protobuf: %w", vId, err)
+				}
+			}
+			for _, dir := range dirs {
+				if opts.plan != nil {
+					var buf bytes.Buffer
+					if err := encodeOSV(&buf, osv); err != nil {
+						return fmt.Errorf("failed to encode %s: %w", vId, err)
+					}
+					rel, err := filepath.Rel(osvOutputPath, path.Join(dir, string(vId)+".json"))
+					if err != nil {
+						return err
+					}
+					opts.plan.Write(rel, buf.Bytes())
+					if pb != nil {
+						opts.plan.Write(strings.TrimSuffix(rel, ".json")+export.ProtobufExt, pb)
+					}
+					continue
+				}
+				filePath := path.Join(dir, string(vId)+".json")
+				err := utility.WriteFileAtomic(filePath, 0644, func(w io.Writer) error {
+					return encodeOSV(w, osv)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to write %s: %w", vId, err)
+				}
+				if opts.outputs != nil {
+					opts.outputs.Add(filePath)
+				}
+				if pb == nil {
+					continue
+				}
+				pbPath := path.Join(dir, string(vId)+export.ProtobufExt)
+				err = utility.WriteFileAtomic(pbPath, 0644, func(w io.Writer) error {
+					_, err := w.Write(pb)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("failed to write %s: %w", pbPath, err)
+				}
+				if opts.outputs != nil {
+					opts.outputs.Add(pbPath)
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	Logger.Infof("Deduplicated %d affected entries/ranges", deduplicated)
+	if opts.reproducible {
+		Logger.Infof("Kept the modified time of %d unchanged OSV files", unchanged.Load())
+	}
+	if opts.plan != nil {
+		return nil
+	}
 	Logger.Infof("Successfully written %d OSV files", len(osvData))
+	if opts.outputs != nil {
+		opts.outputs.Count("records", len(osvData))
+	}
+
+	if opts.shardByEcosystem && opts.zipShards {
+		dirs := maps.Keys(ecosystemDirs)
+		slices.Sort(dirs)
+		for _, dir := range dirs {
+			if err := writeEcosystemZip(dir); err != nil {
+				return fmt.Errorf("failed to write %s of %s: %w", export.ZipFileName, dir, err)
+			}
+			if opts.outputs != nil {
+				opts.outputs.Add(path.Join(dir, export.ZipFileName))
+			}
+		}
+		Logger.Infof("Successfully written %d %s files", len(dirs), export.ZipFileName)
+	}
+	return nil
+}
+
+// isRecordFile reports whether name, relative to the output directory, is
+// the file of a CVE's record (as JSON or protobuf), at the top level or in
+// an ecosystem subdirectory.
+func isRecordFile(name string) bool {
+	fileName, ok := strings.CutSuffix(path.Base(name), ".json")
+	if !ok {
+		fileName, ok = strings.CutSuffix(path.Base(name), export.ProtobufExt)
+	}
+	if !ok || strings.Count(name, "/") > 1 {
+		return false
+	}
+	_, err := ids.ParseCVE(fileName)
+	return err == nil
+}
+
+// encodeOSV writes a record as indented JSON.
+var encodeOSV = func(w io.Writer, osv *vulns.Vulnerability) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(osv)
+}
+
+// partSources finds the feeds each CVE has parts from, as registered in feeds.
+func partSources(partsInputPath string, feeds []partFeed) map[cves.CVEID][]vulns.ProvenanceSource {
+	sources := make(map[cves.CVEID][]vulns.ProvenanceSource)
+	for _, feed := range feeds {
+		dirInner, err := os.ReadDir(path.Join(partsInputPath, feed.Name))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				Logger.Warnf("Failed to read dir %q: %s", path.Join(partsInputPath, feed.Name), err)
+			}
+			continue
+		}
+		for _, entryInner := range dirInner {
+			if !strings.HasSuffix(entryInner.Name(), feed.Suffix) {
+				continue
+			}
+			cveId, err := ids.ParseCVE(strings.TrimSuffix(entryInner.Name(), feed.Suffix))
+			if err != nil {
+				continue
+			}
+			sources[cveId] = append(sources[cveId], vulns.ProvenanceSource{Feed: feed.Name, RecordID: string(cveId)})
+		}
+	}
+	return sources
+}
+
+// stampProvenance records the sources, converter build and conversion time of each record.
+func stampProvenance(osvData map[cves.CVEID]*vulns.Vulnerability, sources map[cves.CVEID][]vulns.ProvenanceSource, build utility.BuildInfo, now time.Time) {
+	for cveId, osv := range osvData {
+		if isManual(osv) {
+			continue
+		}
+		provenance := vulns.Provenance{
+			Sources:           append([]vulns.ProvenanceSource{{Feed: nvdCVEFeed, RecordID: string(cveId)}}, sources[cveId]...),
+			ConverterVersion:  build.Version,
+			ConverterRevision: build.Revision,
+			ConvertedAt:       now.Format(time.RFC3339),
+		}
+		osv.SetProvenance(provenance)
+	}
+}
+
+// stampGenerator records the schema version and the run that generated each record.
+func stampGenerator(osvData map[cves.CVEID]*vulns.Vulnerability, generator vulns.Generator) {
+	for _, osv := range osvData {
+		if isManual(osv) {
+			continue
+		}
+		osv.SetGenerator(generator)
+	}
+}
+
+// keepStableModified compares osv with the existing record at filePath and,
+// if nothing but volatile timestamps differ, reuses the existing timestamps.
+// Returns whether the record was unchanged.
+func keepStableModified(osv *vulns.Vulnerability, filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	existing, err := vulns.FromJSON(file)
+	if err != nil {
+		Logger.Warnf("Failed to decode existing record %q: %s", filePath, err)
+		return false
+	}
+
+	existingContent, err := stableContent(existing)
+	if err != nil {
+		return false
+	}
+	content, err := stableContent(osv)
+	if err != nil {
+		return false
+	}
+	if !reflect.DeepEqual(existingContent, content) {
+		return false
+	}
+
+	osv.Modified = existing.Modified
+	if generator, ok := osv.DatabaseSpecific["generator"].(vulns.Generator); ok {
+		if existingGenerator, ok := existing.DatabaseSpecific["generator"].(map[string]any); ok {
+			if runID, ok := existingGenerator["run_id"].(string); ok {
+				generator.RunID = runID
+				osv.SetGenerator(generator)
+			}
+		}
+	}
+	if provenance, ok := osv.DatabaseSpecific["provenance"].(vulns.Provenance); ok {
+		if existingProvenance, ok := existing.DatabaseSpecific["provenance"].(map[string]any); ok {
+			if convertedAt, ok := existingProvenance["converted_at"].(string); ok {
+				provenance.ConvertedAt = convertedAt
+				osv.SetProvenance(provenance)
+			}
+		}
+	}
+	return true
+}
+
+// stableContent returns the generic JSON form of a record without the fields
+// that change on every run.
+func stableContent(osv *vulns.Vulnerability) (map[string]any, error) {
+	buf, err := json.Marshal(osv)
+	if err != nil {
+		return nil, err
+	}
+	var content map[string]any
+	if err := json.Unmarshal(buf, &content); err != nil {
+		return nil, err
+	}
+	delete(content, "modified")
+	if databaseSpecific, ok := content["database_specific"].(map[string]any); ok {
+		if provenance, ok := databaseSpecific["provenance"].(map[string]any); ok {
+			delete(provenance, "converted_at")
+		}
+		if generator, ok := databaseSpecific["generator"].(map[string]any); ok {
+			delete(generator, "run_id")
+		}
+	}
+	return content, nil
 }
 
 // loadAllCVEs loads the downloaded CVE's from the NVD database into memory.
-func loadAllCVEs(cvePath string) map[cves.CVEID]cves.Vulnerability {
+func loadAllCVEs(cvePath string, failed *failures.Collector) (map[cves.CVEID]cves.Vulnerability, error) {
 	dir, err := os.ReadDir(cvePath)
 	if err != nil {
-		Logger.Fatalf("Failed to read dir %s: %s", cvePath, err)
+		return nil, err
 	}
 
 	result := make(map[cves.CVEID]cves.Vulnerability)
@@ -221,31 +903,42 @@ func loadAllCVEs(cvePath string) map[cves.CVEID]cves.Vulnerability {
 		if !strings.HasSuffix(entry.Name(), ".json") {
 			continue
 		}
-		file, err := os.Open(path.Join(cvePath, entry.Name()))
-		if err != nil {
-			Logger.Fatalf("Failed to open CVE JSON %q: %s", path.Join(cvePath, entry.Name()), err)
-		}
-		var nvdcve cves.CVEAPIJSON20Schema
-		err = json.NewDecoder(file).Decode(&nvdcve)
+		filePath := path.Join(cvePath, entry.Name())
+		nvdcve, err := loadCVEFile(filePath)
 		if err != nil {
-			Logger.Fatalf("Failed to decode JSON in %q: %s", file.Name(), err)
+			failed.Failed(filePath, err)
+			continue
 		}
+		failed.Succeeded()
 
 		for _, item := range nvdcve.Vulnerabilities {
 			result[item.CVE.ID] = item
 		}
 		Logger.Infof("Loaded CVE: %s", entry.Name())
-		file.Close()
 	}
-	return result
+	return result, nil
+}
+
+// loadCVEFile loads a file of NVD CVEs.
+func loadCVEFile(filePath string) (cves.CVEAPIJSON20Schema, error) {
+	var nvdcve cves.CVEAPIJSON20Schema
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nvdcve, err
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&nvdcve); err != nil {
+		return nvdcve, fmt.Errorf("failed to decode: %w", err)
+	}
+	return nvdcve, nil
 }
 
 // addReference adds the related security tracker URL to a given vulnerability's references
-func addReference(cveId string, ecosystem string, convertedCve *vulns.Vulnerability) {
+func addReference(cveId string, ecosystem ecosystems.Ecosystem, convertedCve *vulns.Vulnerability) {
 	securityReference := vulns.Reference{Type: "ADVISORY"}
-	if ecosystem == alpineEcosystem {
+	if ecosystem == ecosystems.Alpine {
 		securityReference.URL, _ = url.JoinPath(alpineSecurityTrackerURL, cveId)
-	} else if ecosystem == debianEcosystem {
+	} else if ecosystem == ecosystems.Debian {
 		securityReference.URL, _ = url.JoinPath(debianSecurityTrackerURL, cveId)
 	}
 
@@ -2,16 +2,27 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
+	"strings"
 	"testing"
 	"time"
 
+	gocmp "github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/exp/maps"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/details"
+	"github.com/google/osv/vulnfeeds/dryrun"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/failures"
 	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
 )
 
 func loadTestData2(cveName string) cves.Vulnerability {
@@ -35,7 +46,10 @@ func loadTestData2(cveName string) cves.Vulnerability {
 }
 
 func TestLoadParts(t *testing.T) {
-	allParts, _ := loadParts("../../test_data/parts")
+	allParts, _, err := loadParts("../../test_data/parts", defaultPartFeeds, false, &failures.Collector{})
+	if err != nil {
+		t.Fatalf("loadParts() error: %v", err)
+	}
 	expectedPartCount := 15
 	actualPartCount := len(allParts)
 
@@ -88,7 +102,10 @@ func TestCombineIntoOSV(t *testing.T) {
 		"CVE-2022-32746":   loadTestData2("CVE-2022-32746"),
 		"CVE-2018-1000500": loadTestData2("CVE-2018-1000500"),
 	}
-	allParts, cveModifiedTime := loadParts("../../test_data/parts")
+	allParts, cveModifiedTime, err := loadParts("../../test_data/parts", defaultPartFeeds, false, &failures.Collector{})
+	if err != nil {
+		t.Fatalf("loadParts() error: %v", err)
+	}
 
 	combinedOSV := combineIntoOSV(cveStuff, allParts, "", cveModifiedTime)
 
@@ -148,7 +165,10 @@ func TestUpdateModifiedDate(t *testing.T) {
 		cveId1: loadTestData2("CVE-2022-33745"),
 		cveId2: loadTestData2("CVE-2022-32746"),
 	}
-	allParts, _ := loadParts("../../test_data/parts")
+	allParts, _, err := loadParts("../../test_data/parts", defaultPartFeeds, false, &failures.Collector{})
+	if err != nil {
+		t.Fatalf("loadParts() error: %v", err)
+	}
 
 	cveModifiedTimeMock := make(map[cves.CVEID]time.Time)
 	time1 := "0001-00-00T00:00:00Z"
@@ -177,3 +197,280 @@ func TestUpdateModifiedDate(t *testing.T) {
 		t.Errorf("Wrong modified time, expected: %s, got: %s", time2, combinedOSV["CVE-2022-32746"].Modified)
 	}
 }
+
+func TestStampProvenance(t *testing.T) {
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2022-33745": {ID: "CVE-2022-33745"},
+	}
+	sources := partSources("../../test_data/parts", defaultPartFeeds)
+	now := time.Date(2024, 4, 30, 0, 38, 53, 0, time.UTC)
+	stampProvenance(osvData, sources, utility.BuildInfo{Version: "v1.2.3", Revision: "abc123"}, now)
+
+	want := vulns.Provenance{
+		Sources: []vulns.ProvenanceSource{
+			{Feed: "nvd-cve", RecordID: "CVE-2022-33745"},
+			{Feed: "alpine", RecordID: "CVE-2022-33745"},
+		},
+		ConverterVersion:  "v1.2.3",
+		ConverterRevision: "abc123",
+		ConvertedAt:       "2024-04-30T00:38:53Z",
+	}
+	if diff := gocmp.Diff(want, osvData["CVE-2022-33745"].DatabaseSpecific["provenance"]); diff != "" {
+		t.Errorf("stampProvenance() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStampGenerator(t *testing.T) {
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2022-33745": {ID: "CVE-2022-33745"},
+	}
+	stampGenerator(osvData, vulns.NewGenerator("combine-to-osv", "run-1", utility.BuildInfo{Version: "v1.2.3", Revision: "abc123"}))
+
+	got := osvData["CVE-2022-33745"]
+	if got.SchemaVersion != vulns.SchemaVersion {
+		t.Errorf("stampGenerator() schema_version = %q, want %q", got.SchemaVersion, vulns.SchemaVersion)
+	}
+	want := vulns.Generator{Tool: "combine-to-osv", Version: "v1.2.3", Revision: "abc123", RunID: "run-1"}
+	if diff := gocmp.Diff(want, got.DatabaseSpecific["generator"]); diff != "" {
+		t.Errorf("stampGenerator() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyChangeHistory(t *testing.T) {
+	parse := func(s string) cves.NVDTime {
+		t, _ := time.Parse(time.RFC3339, s)
+		return cves.NVDTime{Time: t}
+	}
+	allCves := map[cves.CVEID]cves.Vulnerability{
+		"CVE-2024-0001": {CVE: cves.CVE{ID: "CVE-2024-0001", Published: parse("2024-01-01T00:00:00Z"), LastModified: parse("2024-03-01T00:00:00Z")}},
+		"CVE-2023-0001": {CVE: cves.CVE{ID: "CVE-2023-0001", Published: parse("2023-01-01T00:00:00Z"), LastModified: parse("2024-03-01T00:00:00Z")}},
+	}
+	history := cves.NewChangeHistory(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	history.Updated = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	history.Changed["CVE-2024-0001"] = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := applyChangeHistory(allCves, history); got != 1 {
+		t.Errorf("applyChangeHistory() = %d, want 1", got)
+	}
+	if got := allCves["CVE-2024-0001"].CVE.LastModified.Format(time.RFC3339); got != "2024-02-01T00:00:00Z" {
+		t.Errorf("applyChangeHistory() CVE-2024-0001 LastModified = %s, want its last meaningful change", got)
+	}
+	if got := allCves["CVE-2023-0001"].CVE.LastModified.Format(time.RFC3339); got != "2024-03-01T00:00:00Z" {
+		t.Errorf("applyChangeHistory() CVE-2023-0001 LastModified = %s, want it unchanged", got)
+	}
+}
+
+func TestComposeRecordDetails(t *testing.T) {
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2022-33745": {
+			ID:      "CVE-2022-33745",
+			Details: "Insufficient TLB flush for x86 PV guests in shadow mode.",
+			Affected: []vulns.Affected{{
+				Package: &vulns.AffectedPackage{Name: "xen", Ecosystem: "Debian:11"},
+				Ranges:  []vulns.AffectedRange{{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "4.14.5+24-g87d90d511c-1"}}}},
+			}},
+		},
+		"CVE-2022-0001": {ID: "CVE-2022-0001", Details: "Without affected packages."},
+	}
+	generator, err := details.New(details.DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	composeRecordDetails(osvData, generator)
+
+	want := "xen in Debian 11 is affected by CVE-2022-33745, and fixed in 4.14.5+24-g87d90d511c-1.\n\nUpstream description:\n\nInsufficient TLB flush for x86 PV guests in shadow mode."
+	if got := osvData["CVE-2022-33745"].Details; got != want {
+		t.Errorf("composeRecordDetails() = %q, want %q", got, want)
+	}
+	if got := osvData["CVE-2022-0001"].Details; got != "Without affected packages." {
+		t.Errorf("composeRecordDetails() of a record without packages = %q", got)
+	}
+}
+
+func TestAddRecordCredits(t *testing.T) {
+	cveList := t.TempDir()
+	writeTestFile(t, path.Join(cveList, "cves", "2022", "33xxx", "CVE-2022-33745.json"),
+		`{"containers": {"cna": {"credits": [{"lang": "en", "value": "Jann Horn", "type": "finder"}]}}}`)
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2022-33745": {ID: "CVE-2022-33745", Details: "Reported by Someone Else."},
+		"CVE-2022-0001":  {ID: "CVE-2022-0001", Details: "A flaw, reported by Jane Doe of Example Corp."},
+		"CVE-2022-0002":  {ID: "CVE-2022-0002", Details: "No acknowledgments."},
+	}
+	addRecordCredits(osvData, cveList)
+
+	// The CNA's credits take precedence over the description's.
+	want := map[cves.CVEID][]vulns.Credit{
+		"CVE-2022-33745": {{Name: "Jann Horn", Type: vulns.CreditFinder}},
+		"CVE-2022-0001":  {{Name: "Jane Doe", Type: vulns.CreditReporter}},
+		"CVE-2022-0002":  nil,
+	}
+	for id, credits := range want {
+		if diff := gocmp.Diff(credits, osvData[id].Credits); diff != "" {
+			t.Errorf("addRecordCredits() of %s mismatch (-want +got):\n%s", id, diff)
+		}
+	}
+}
+
+func TestWriteOSVFileReproducible(t *testing.T) {
+	outputPath := t.TempDir()
+	newRecord := func(modified string, convertedAt time.Time, details string) map[cves.CVEID]*vulns.Vulnerability {
+		v := &vulns.Vulnerability{ID: "CVE-2022-33745", Details: details, Modified: modified}
+		osvData := map[cves.CVEID]*vulns.Vulnerability{"CVE-2022-33745": v}
+		stampProvenance(osvData, nil, utility.BuildInfo{}, convertedAt)
+		stampGenerator(osvData, vulns.NewGenerator("combine-to-osv", convertedAt.Format(time.RFC3339), utility.BuildInfo{}))
+		return osvData
+	}
+	readRecord := func() *vulns.Vulnerability {
+		file, err := os.Open(path.Join(outputPath, "CVE-2022-33745.json"))
+		if err != nil {
+			t.Fatalf("Failed to open written record: %v", err)
+		}
+		defer file.Close()
+		v, err := vulns.FromJSON(file)
+		if err != nil {
+			t.Fatalf("Failed to decode written record: %v", err)
+		}
+		return v
+	}
+
+	firstRun := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+	writeOSVFile(newRecord("2024-04-30T00:00:00Z", firstRun, "details"), outputPath, writeOptions{reproducible: true})
+
+	// Same content on a later run keeps the original timestamps.
+	writeOSVFile(newRecord("2024-05-01T00:00:00Z", firstRun.Add(24*time.Hour), "details"), outputPath, writeOptions{reproducible: true})
+	got := readRecord()
+	if got.Modified != "2024-04-30T00:00:00Z" {
+		t.Errorf("Unchanged record modified = %s, want 2024-04-30T00:00:00Z", got.Modified)
+	}
+	if convertedAt := got.DatabaseSpecific["provenance"].(map[string]any)["converted_at"]; convertedAt != "2024-04-30T00:00:00Z" {
+		t.Errorf("Unchanged record converted_at = %s, want 2024-04-30T00:00:00Z", convertedAt)
+	}
+	if runID := got.DatabaseSpecific["generator"].(map[string]any)["run_id"]; runID != "2024-04-30T00:00:00Z" {
+		t.Errorf("Unchanged record run_id = %s, want 2024-04-30T00:00:00Z", runID)
+	}
+
+	// Changed content takes the new timestamps.
+	writeOSVFile(newRecord("2024-05-02T00:00:00Z", firstRun.Add(48*time.Hour), "new details"), outputPath, writeOptions{reproducible: true})
+	got = readRecord()
+	if got.Modified != "2024-05-02T00:00:00Z" {
+		t.Errorf("Changed record modified = %s, want 2024-05-02T00:00:00Z", got.Modified)
+	}
+}
+
+func TestWriteOSVFileAtomic(t *testing.T) {
+	outputPath := t.TempDir()
+	osvData := make(map[cves.CVEID]*vulns.Vulnerability)
+	for i := range 50 {
+		id := cves.CVEID(fmt.Sprintf("CVE-2024-%04d", i))
+		osvData[id] = &vulns.Vulnerability{ID: string(id), Modified: "2024-01-01T00:00:00Z", Details: "first"}
+	}
+	if err := writeOSVFile(osvData, outputPath, writeOptions{}); err != nil {
+		t.Fatalf("writeOSVFile() error: %v", err)
+	}
+
+	// Fail partway through encoding one record on the next run.
+	defer func(encode func(io.Writer, *vulns.Vulnerability) error) { encodeOSV = encode }(encodeOSV)
+	encodeOSV = func(w io.Writer, osv *vulns.Vulnerability) error {
+		if osv.ID == "CVE-2024-0025" {
+			io.WriteString(w, `{"id": "CVE-2024-0025", "det`)
+			return errors.New("injected failure")
+		}
+		return json.NewEncoder(w).Encode(osv)
+	}
+	osvData["CVE-2024-0025"].Details = "second"
+	osvData["CVE-2024-0050"] = &vulns.Vulnerability{ID: "CVE-2024-0050", Modified: "2024-01-01T00:00:00Z", Details: "second"}
+	if err := writeOSVFile(osvData, outputPath, writeOptions{}); err == nil {
+		t.Fatalf("writeOSVFile() with an injected failure succeeded")
+	}
+
+	entries, err := os.ReadDir(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "CVE-") || !strings.HasSuffix(entry.Name(), ".json") {
+			t.Errorf("Partial file %s left behind", entry.Name())
+			continue
+		}
+		data, err := os.ReadFile(path.Join(outputPath, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Errorf("Truncated record %s: %v", entry.Name(), err)
+		}
+	}
+	data, err := os.ReadFile(path.Join(outputPath, "CVE-2024-0025.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"first"`) {
+		t.Errorf("Record that failed to write = %s, want the previous record", data)
+	}
+}
+
+func TestWriteOSVFileDryRun(t *testing.T) {
+	outputPath := t.TempDir()
+	newRecords := func(details string) map[cves.CVEID]*vulns.Vulnerability {
+		return map[cves.CVEID]*vulns.Vulnerability{
+			"CVE-2024-0001": {ID: "CVE-2024-0001", Modified: "2024-01-01T00:00:00Z", Details: "unchanged",
+				Affected: []vulns.Affected{{Package: &vulns.AffectedPackage{Ecosystem: "PyPI", Name: "foo"}}}},
+			"CVE-2024-0002": {ID: "CVE-2024-0002", Modified: "2024-01-01T00:00:00Z", Details: details,
+				Affected: []vulns.Affected{{Package: &vulns.AffectedPackage{Ecosystem: "PyPI", Name: "bar"}}}},
+		}
+	}
+	opts := writeOptions{shardByEcosystem: true, zipShards: true}
+	if err := writeOSVFile(newRecords("first"), outputPath, opts); err != nil {
+		t.Fatalf("writeOSVFile() error: %v", err)
+	}
+	zipInfo, err := os.Stat(path.Join(outputPath, "PyPI", export.ZipFileName))
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", export.ZipFileName, err)
+	}
+
+	osvData := newRecords("second")
+	osvData["CVE-2024-0003"] = &vulns.Vulnerability{ID: "CVE-2024-0003", Modified: "2024-01-01T00:00:00Z", Details: "new"}
+	delete(osvData, "CVE-2024-0001")
+	plan := dryrun.NewPlan(outputPath, isRecordFile)
+	opts.plan = plan
+	if err := writeOSVFile(osvData, outputPath, opts); err != nil {
+		t.Fatalf("writeOSVFile() dry run error: %v", err)
+	}
+	got, err := plan.Summarize()
+	if err != nil {
+		t.Fatalf("Summarize() error: %v", err)
+	}
+	want := &dryrun.Summary{
+		Created:  []string{"[EMPTY]/CVE-2024-0003.json"},
+		Modified: []string{"PyPI/CVE-2024-0002.json"},
+		Removed:  []string{"PyPI/CVE-2024-0001.json"},
+	}
+	if diff := gocmp.Diff(want, got, cmpopts.IgnoreFields(dryrun.Summary{}, "Diffs")); diff != "" {
+		t.Errorf("Summarize() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Nothing was written.
+	if _, err := os.Stat(path.Join(outputPath, "[EMPTY]")); !os.IsNotExist(err) {
+		t.Errorf("dry run created the [EMPTY] directory")
+	}
+	if info, err := os.Stat(path.Join(outputPath, "PyPI", export.ZipFileName)); err != nil || !info.ModTime().Equal(zipInfo.ModTime()) {
+		t.Errorf("dry run rewrote %s", export.ZipFileName)
+	}
+}
+
+func TestIsRecordFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"CVE-2024-0001.json":      true,
+		"PyPI/CVE-2024-0001.json": true,
+		"PyPI/CVE-2024-0001.pb":   true,
+		"PyPI/all.zip":            false,
+		"modified-since-1d.txt":   false,
+		"input_state.json":        false,
+		"a/b/CVE-2024-0001.json":  false,
+	} {
+		if got := isRecordFile(name); got != want {
+			t.Errorf("isRecordFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
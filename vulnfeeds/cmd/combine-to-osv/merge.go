@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// The feed name recorded in the provenance of records merged with the
+// published record.
+const publishedFeed = "published"
+
+// manualKey is the database_specific key of a published record marking it as
+// edited by hand: true for the whole record, or a list of the fields edited.
+const manualKey = "manual"
+
+// generatedFields are always taken from the generated record.
+var generatedFields = map[string]bool{
+	"id":                true,
+	"modified":          true,
+	"schema_version":    true,
+	"database_specific": true,
+}
+
+// setFields are the fields whose items are merged as sets, so additions by
+// hand and by the generator are both kept.
+var setFields = map[string]bool{
+	"aliases":    true,
+	"related":    true,
+	"upstream":   true,
+	"references": true,
+	"credits":    true,
+}
+
+// record is the generic JSON form of a record, which merges field by field.
+type record = map[string]any
+
+// loadRecords loads the records in dir and its subdirectories (e.g. the
+// ecosystem shards of a published output), keyed by ID. A missing dir has no
+// records.
+func loadRecords(dir string) (map[cves.CVEID]record, error) {
+	records := make(map[cves.CVEID]record)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		id := strings.TrimSuffix(d.Name(), ".json")
+		if !ids.Valid(id) {
+			return nil
+		}
+		if _, ok := records[cves.CVEID(id)]; ok {
+			// Another shard's copy.
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("%s is not a record: %w", path, err)
+		}
+		records[cves.CVEID(id)] = r
+		return nil
+	})
+	return records, err
+}
+
+func toRecord(osv *vulns.Vulnerability) (record, error) {
+	buf, err := json.Marshal(osv)
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	return r, json.Unmarshal(buf, &r)
+}
+
+func fromRecord(r record) (*vulns.Vulnerability, error) {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.DisallowUnknownFields()
+	osv := &vulns.Vulnerability{}
+	return osv, decoder.Decode(osv)
+}
+
+// manualFields returns whether a published record is edited by hand as a
+// whole, or else which of its fields are.
+func manualFields(published record) (bool, map[string]bool) {
+	databaseSpecific, _ := published["database_specific"].(map[string]any)
+	switch manual := databaseSpecific[manualKey].(type) {
+	case bool:
+		return manual, nil
+	case []any:
+		fields := make(map[string]bool)
+		for _, field := range manual {
+			if field, ok := field.(string); ok {
+				fields[field] = true
+			}
+		}
+		return false, fields
+	}
+	return false, nil
+}
+
+// isManual returns whether a record is edited by hand as a whole, so isn't
+// stamped as generated.
+func isManual(osv *vulns.Vulnerability) bool {
+	manual, _ := osv.DatabaseSpecific[manualKey].(bool)
+	return manual
+}
+
+// merge3 three-way merges a value changed by hand (published) and by the
+// generator (generated) since base. If both changed it differently, the
+// published value is kept and it reports a conflict.
+func merge3(base, published, generated any) (any, bool) {
+	switch {
+	case reflect.DeepEqual(published, base), reflect.DeepEqual(published, generated):
+		return generated, false
+	case reflect.DeepEqual(generated, base):
+		return published, false
+	}
+	return published, true
+}
+
+// itemKey identifies an item of a set field by its content.
+func itemKey(item any) string {
+	// Maps are marshaled with sorted keys, so equal items have equal keys.
+	buf, _ := json.Marshal(item)
+	return string(buf)
+}
+
+// mergeSet three-way merges the items of a set field: items the generator
+// added are added, items it removed are removed, and items added or removed
+// by hand stay that way.
+func mergeSet(base, published, generated any) any {
+	inBase := make(map[string]bool)
+	for _, item := range asList(base) {
+		inBase[itemKey(item)] = true
+	}
+	inGenerated := make(map[string]bool)
+	for _, item := range asList(generated) {
+		inGenerated[itemKey(item)] = true
+	}
+	var merged []any
+	seen := make(map[string]bool)
+	for _, item := range asList(published) {
+		key := itemKey(item)
+		if inBase[key] && !inGenerated[key] {
+			continue
+		}
+		merged = append(merged, item)
+		seen[key] = true
+	}
+	for _, item := range asList(generated) {
+		key := itemKey(item)
+		if !inBase[key] && !seen[key] {
+			merged = append(merged, item)
+			seen[key] = true
+		}
+	}
+	if merged == nil {
+		return nil
+	}
+	return merged
+}
+
+func asList(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
+
+// affectedKey identifies an affected entry by its package or, for entries
+// without one, the repos of its ranges.
+func affectedKey(entry any) string {
+	object, _ := entry.(map[string]any)
+	if pkg, ok := object["package"].(map[string]any); ok {
+		return fmt.Sprintf("%v/%v", pkg["ecosystem"], pkg["name"])
+	}
+	var repos []string
+	for _, r := range asList(object["ranges"]) {
+		if r, ok := r.(map[string]any); ok {
+			repos = append(repos, fmt.Sprint(r["repo"]))
+		}
+	}
+	sort.Strings(repos)
+	return "GIT/" + strings.Join(repos, " ")
+}
+
+// keyedEntries returns the affected entries keyed by affectedKey, numbering
+// repeated keys in order, and the keys in order.
+func keyedEntries(affected any) (map[string]any, []string) {
+	entries := make(map[string]any)
+	var keys []string
+	counts := make(map[string]int)
+	for _, entry := range asList(affected) {
+		key := affectedKey(entry)
+		counts[key]++
+		if counts[key] > 1 {
+			key = fmt.Sprintf("%s#%d", key, counts[key])
+		}
+		entries[key] = entry
+		keys = append(keys, key)
+	}
+	return entries, keys
+}
+
+// mergeAffected three-way merges the affected entries by package, returning
+// the packages that conflict.
+func mergeAffected(base, published, generated any) (any, []string) {
+	baseEntries, _ := keyedEntries(base)
+	publishedEntries, publishedKeys := keyedEntries(published)
+	generatedEntries, generatedKeys := keyedEntries(generated)
+
+	var merged []any
+	var conflicts []string
+	for _, key := range publishedKeys {
+		b, inBase := baseEntries[key]
+		g, inGenerated := generatedEntries[key]
+		if !inBase && !inGenerated {
+			// Added by hand.
+			merged = append(merged, publishedEntries[key])
+			continue
+		}
+		entry, conflict := merge3(b, publishedEntries[key], g)
+		if conflict {
+			conflicts = append(conflicts, key)
+		}
+		if entry != nil {
+			merged = append(merged, entry)
+		}
+	}
+	for _, key := range generatedKeys {
+		if _, ok := publishedEntries[key]; ok {
+			continue
+		}
+		b, inBase := baseEntries[key]
+		if !inBase {
+			// Added by the generator.
+			merged = append(merged, generatedEntries[key])
+			continue
+		}
+		// Removed by hand.
+		if _, conflict := merge3(b, nil, generatedEntries[key]); conflict {
+			conflicts = append(conflicts, key)
+		}
+	}
+	if merged == nil {
+		return nil, conflicts
+	}
+	return merged, conflicts
+}
+
+// mergeRecord three-way merges a generated record into the published one,
+// given the record generated by the previous run (base), field by field. Edits
+// by hand are kept, and fields both edited by hand and changed by the
+// generator are reported as conflicts, keeping the edits. Without a base,
+// the published record is taken to be the previous run's.
+func mergeRecord(base, published, generated record) (record, []string) {
+	if whole, _ := manualFields(published); whole {
+		return published, nil
+	}
+	_, manual := manualFields(published)
+	if base == nil {
+		base = published
+	}
+
+	merged := make(record)
+	var conflicts []string
+	fields := make(map[string]bool)
+	for field := range published {
+		fields[field] = true
+	}
+	for field := range generated {
+		fields[field] = true
+	}
+	for field := range fields {
+		var value any
+		switch {
+		case generatedFields[field]:
+			value = generated[field]
+		case manual[field]:
+			value = published[field]
+		case setFields[field]:
+			value = mergeSet(base[field], published[field], generated[field])
+		case field == "affected":
+			var packages []string
+			value, packages = mergeAffected(base[field], published[field], generated[field])
+			for _, pkg := range packages {
+				conflicts = append(conflicts, fmt.Sprintf("affected %s", pkg))
+			}
+		default:
+			var conflict bool
+			value, conflict = merge3(base[field], published[field], generated[field])
+			if conflict {
+				conflicts = append(conflicts, field)
+			}
+		}
+		if value != nil {
+			merged[field] = value
+		}
+	}
+
+	// Keep the marker of the fields edited by hand.
+	if manual != nil {
+		databaseSpecific, _ := merged["database_specific"].(map[string]any)
+		if databaseSpecific == nil {
+			databaseSpecific = make(map[string]any)
+		}
+		databaseSpecific[manualKey] = published["database_specific"].(map[string]any)[manualKey]
+		merged["database_specific"] = databaseSpecific
+	}
+
+	// The record is only modified if its content changed.
+	modified, _ := generated["modified"].(string)
+	if publishedModified, ok := published["modified"].(string); ok {
+		if sameContent(merged, published) || publishedModified > modified {
+			modified = publishedModified
+		}
+	}
+	merged["modified"] = modified
+
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// sameContent returns whether two records differ in more than their
+// generated fields.
+func sameContent(a, b record) bool {
+	for field := range a {
+		if !generatedFields[field] && !reflect.DeepEqual(a[field], b[field]) {
+			return false
+		}
+	}
+	for field := range b {
+		if _, ok := a[field]; !ok && !generatedFields[field] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergePublished merges the generated records with the published ones, given
+// the records the previous run generated (base), and carries over published
+// records edited by hand that are no longer generated. It returns the IDs of
+// the records merged, and a description of every conflict.
+func mergePublished(osvData map[cves.CVEID]*vulns.Vulnerability, base, published map[cves.CVEID]record) (merged []cves.CVEID, conflicts []string) {
+	for id, p := range published {
+		osv, ok := osvData[id]
+		if !ok {
+			if whole, _ := manualFields(p); whole {
+				kept, err := fromRecord(p)
+				if err != nil {
+					conflicts = append(conflicts, fmt.Sprintf("%s: published record edited by hand isn't valid: %v", id, err))
+					continue
+				}
+				osvData[id] = kept
+				merged = append(merged, id)
+			}
+			continue
+		}
+		generated, err := toRecord(osv)
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		m, fields := mergeRecord(base[id], p, generated)
+		for _, field := range fields {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s changed by hand and by the generator, keeping the published edit", id, field))
+		}
+		mergedOSV, err := fromRecord(m)
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s: merge isn't a valid record, keeping the generated record: %v", id, err))
+			continue
+		}
+		osvData[id] = mergedOSV
+		merged = append(merged, id)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func affectedEntry(name string, fixed string) map[string]any {
+	return map[string]any{
+		"package": map[string]any{"ecosystem": "PyPI", "name": name},
+		"ranges": []any{map[string]any{
+			"type":   "ECOSYSTEM",
+			"events": []any{map[string]any{"introduced": "0"}, map[string]any{"fixed": fixed}},
+		}},
+	}
+}
+
+func TestMergeRecord(t *testing.T) {
+	base := record{
+		"id":         "CVE-2024-0001",
+		"modified":   "2024-01-01T00:00:00Z",
+		"summary":    "summary",
+		"details":    "details",
+		"aliases":    []any{"GHSA-aaaa-bbbb-cccc"},
+		"affected":   []any{affectedEntry("a", "1.0"), affectedEntry("b", "2.0"), affectedEntry("c", "3.0")},
+		"references": []any{map[string]any{"type": "WEB", "url": "https://example.com/1"}},
+	}
+	published := record{
+		"id":       "CVE-2024-0001",
+		"modified": "2024-01-02T00:00:00Z",
+		// Edited by hand.
+		"summary": "better summary",
+		"details": "hand-edited details",
+		"aliases": []any{"GHSA-aaaa-bbbb-cccc", "PYSEC-2024-1"},
+		// a is corrected and c removed by hand.
+		"affected":   []any{affectedEntry("a", "1.1"), affectedEntry("b", "2.0")},
+		"references": []any{map[string]any{"type": "WEB", "url": "https://example.com/1"}},
+	}
+	generated := record{
+		"id":       "CVE-2024-0001",
+		"modified": "2024-02-01T00:00:00Z",
+		"summary":  "summary",
+		// Changed by the generator too.
+		"details": "new details",
+		"aliases": []any{"GHSA-aaaa-bbbb-cccc"},
+		// b is changed and d added by the generator, c is unchanged.
+		"affected":   []any{affectedEntry("a", "1.0"), affectedEntry("b", "2.1"), affectedEntry("c", "3.0"), affectedEntry("d", "4.0")},
+		"references": []any{map[string]any{"type": "WEB", "url": "https://example.com/2"}},
+	}
+
+	got, conflicts := mergeRecord(base, published, generated)
+	want := record{
+		"id":         "CVE-2024-0001",
+		"modified":   "2024-02-01T00:00:00Z",
+		"summary":    "better summary",
+		"details":    "hand-edited details",
+		"aliases":    []any{"GHSA-aaaa-bbbb-cccc", "PYSEC-2024-1"},
+		"affected":   []any{affectedEntry("a", "1.1"), affectedEntry("b", "2.1"), affectedEntry("d", "4.0")},
+		"references": []any{map[string]any{"type": "WEB", "url": "https://example.com/2"}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("mergeRecord() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff([]string{"details"}, conflicts); diff != "" {
+		t.Errorf("mergeRecord() conflicts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeRecordAffectedConflict(t *testing.T) {
+	base := record{"affected": []any{affectedEntry("a", "1.0"), affectedEntry("b", "2.0")}}
+	published := record{"affected": []any{affectedEntry("a", "1.1")}}
+	generated := record{"affected": []any{affectedEntry("a", "1.2"), affectedEntry("b", "2.1")}}
+
+	got, conflicts := mergeRecord(base, published, generated)
+	if diff := gocmp.Diff([]any{affectedEntry("a", "1.1")}, got["affected"]); diff != "" {
+		t.Errorf("mergeRecord() affected mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff([]string{"affected PyPI/a", "affected PyPI/b"}, conflicts); diff != "" {
+		t.Errorf("mergeRecord() conflicts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeRecordManual(t *testing.T) {
+	generated := record{
+		"id":       "CVE-2024-0001",
+		"modified": "2024-02-01T00:00:00Z",
+		"summary":  "new summary",
+		"details":  "new details",
+	}
+
+	whole := record{
+		"id":                "CVE-2024-0001",
+		"modified":          "2024-01-01T00:00:00Z",
+		"details":           "hand-written",
+		"database_specific": map[string]any{manualKey: true},
+	}
+	got, conflicts := mergeRecord(nil, whole, generated)
+	if diff := gocmp.Diff(whole, got); diff != "" {
+		t.Errorf("mergeRecord() of a manual record mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("mergeRecord() of a manual record conflicts = %q, want none", conflicts)
+	}
+
+	fields := record{
+		"id":                "CVE-2024-0001",
+		"modified":          "2024-01-01T00:00:00Z",
+		"summary":           "summary",
+		"details":           "hand-written",
+		"database_specific": map[string]any{manualKey: []any{"details"}},
+	}
+	got, conflicts = mergeRecord(nil, fields, generated)
+	want := record{
+		"id":                "CVE-2024-0001",
+		"modified":          "2024-02-01T00:00:00Z",
+		"summary":           "new summary",
+		"details":           "hand-written",
+		"database_specific": map[string]any{manualKey: []any{"details"}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("mergeRecord() of manual fields mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("mergeRecord() of manual fields conflicts = %q, want none", conflicts)
+	}
+}
+
+func TestMergeRecordKeepsModified(t *testing.T) {
+	published := record{"id": "CVE-2024-0001", "modified": "2024-01-01T00:00:00Z", "details": "details"}
+	generated := record{"id": "CVE-2024-0001", "modified": "2024-02-01T00:00:00Z", "details": "details"}
+	got, _ := mergeRecord(published, published, generated)
+	if got["modified"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("mergeRecord() modified = %v, want the published modified of the unchanged record", got["modified"])
+	}
+}
+
+func TestMergePublished(t *testing.T) {
+	publishedPath := t.TempDir()
+	files := map[string]string{
+		"PyPI/CVE-2024-0001.json": `{"id": "CVE-2024-0001", "modified": "2024-01-01T00:00:00Z", "summary": "hand-written summary", "details": "details"}`,
+		"CVE-2024-0002.json":      `{"id": "CVE-2024-0002", "modified": "2024-01-01T00:00:00Z", "details": "kept", "database_specific": {"manual": true}}`,
+		"CVE-2024-0003.json":      `{"id": "CVE-2024-0003", "modified": "2024-01-01T00:00:00Z", "details": "no longer generated"}`,
+		"modified-since-1h.txt":   `CVE-2024-0001`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(publishedPath, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path.Join(publishedPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	published, err := loadRecords(publishedPath)
+	if err != nil {
+		t.Fatalf("loadRecords() error: %v", err)
+	}
+	if len(published) != 3 {
+		t.Errorf("loadRecords() loaded %d records, want 3", len(published))
+	}
+	if missing, err := loadRecords(path.Join(publishedPath, "missing")); err != nil || len(missing) != 0 {
+		t.Errorf("loadRecords() of a missing directory = %v, %v, want no records", missing, err)
+	}
+	base := map[cves.CVEID]record{
+		"CVE-2024-0001": {"id": "CVE-2024-0001", "modified": "2024-01-01T00:00:00Z", "details": "details"},
+	}
+
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {ID: "CVE-2024-0001", Modified: "2024-02-01T00:00:00Z", Details: "new details"},
+	}
+	merged, conflicts := mergePublished(osvData, base, published)
+
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0001", "CVE-2024-0002"}, merged); diff != "" {
+		t.Errorf("mergePublished() merged mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("mergePublished() conflicts = %q, want none", conflicts)
+	}
+	want := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {ID: "CVE-2024-0001", Modified: "2024-02-01T00:00:00Z", Summary: "hand-written summary", Details: "new details"},
+		"CVE-2024-0002": {ID: "CVE-2024-0002", Modified: "2024-01-01T00:00:00Z", Details: "kept", DatabaseSpecific: map[string]any{"manual": true}},
+	}
+	if diff := gocmp.Diff(want, osvData); diff != "" {
+		t.Errorf("mergePublished() records mismatch (-want +got):\n%s", diff)
+	}
+	if !isManual(osvData["CVE-2024-0002"]) {
+		t.Errorf("isManual() = false for a record marked manual")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// modifiedSinceHorizons are the horizons modified-since index files are
+// written for, so downstream mirrors can fetch only the records that changed
+// since their last sync instead of listing the whole bucket.
+var modifiedSinceHorizons = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// modifiedSinceFileName returns the name of the index file for a horizon.
+// The .txt extension keeps it from being mistaken for an OSV record.
+func modifiedSinceFileName(horizon string) string {
+	return "modified-since-" + horizon + ".txt"
+}
+
+// modifiedSince returns the sorted IDs of the records modified at or after cutoff.
+func modifiedSince(osvData map[cves.CVEID]*vulns.Vulnerability, cutoff time.Time) []string {
+	var ids []string
+	for _, osv := range osvData {
+		modified, err := time.Parse(time.RFC3339, osv.Modified)
+		if err != nil {
+			Logger.Warnf("Failed to parse modified time %q of %s: %s", osv.Modified, osv.ID, err)
+			continue
+		}
+		if !modified.Before(cutoff) {
+			ids = append(ids, osv.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// writeModifiedSinceIndexes writes one index file per horizon to
+// osvOutputPath, listing the IDs of the records modified within that horizon
+// of now, one per line.
+func writeModifiedSinceIndexes(osvData map[cves.CVEID]*vulns.Vulnerability, osvOutputPath string, now time.Time) error {
+	for _, horizon := range modifiedSinceHorizons {
+		ids := modifiedSince(osvData, now.Add(-horizon.duration))
+		err := utility.WriteFileAtomic(path.Join(osvOutputPath, modifiedSinceFileName(horizon.name)), 0644, func(w io.Writer) error {
+			writer := bufio.NewWriter(w)
+			for _, id := range ids {
+				if _, err := writer.WriteString(id + "\n"); err != nil {
+					return err
+				}
+			}
+			return writer.Flush()
+		})
+		if err != nil {
+			return err
+		}
+		Logger.Infof("%d records modified in the last %s", len(ids), horizon.name)
+	}
+	return nil
+}
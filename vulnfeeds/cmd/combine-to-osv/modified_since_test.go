@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestWriteModifiedSinceIndexes(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {ID: "CVE-2024-0001", Modified: "2024-05-10T11:30:00Z"},
+		"CVE-2024-0002": {ID: "CVE-2024-0002", Modified: "2024-05-10T00:00:00Z"},
+		"CVE-2024-0003": {ID: "CVE-2024-0003", Modified: "2024-05-05T00:00:00Z"},
+		"CVE-2024-0004": {ID: "CVE-2024-0004", Modified: "2024-01-01T00:00:00Z"},
+		"CVE-2024-0005": {ID: "CVE-2024-0005", Modified: "2024-05-10T11:00:00Z"},
+	}
+	outputPath := t.TempDir()
+
+	if err := writeModifiedSinceIndexes(osvData, outputPath, now); err != nil {
+		t.Fatalf("writeModifiedSinceIndexes() error: %v", err)
+	}
+
+	want := map[string]string{
+		"modified-since-1h.txt":  "CVE-2024-0001\nCVE-2024-0005\n",
+		"modified-since-24h.txt": "CVE-2024-0001\nCVE-2024-0002\nCVE-2024-0005\n",
+		"modified-since-7d.txt":  "CVE-2024-0001\nCVE-2024-0002\nCVE-2024-0003\nCVE-2024-0005\n",
+	}
+	for fileName, wantContent := range want {
+		got, err := os.ReadFile(path.Join(outputPath, fileName))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", fileName, err)
+		}
+		if string(got) != wantContent {
+			t.Errorf("%s = %q, want %q", fileName, got, wantContent)
+		}
+	}
+}
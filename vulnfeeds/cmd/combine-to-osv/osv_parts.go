@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v2"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// osvPart is a pre-existing OSV record (e.g. a PYSEC advisory) whose affected
+// packages are merged into the records of the CVEs it aliases.
+type osvPart struct {
+	ID       string
+	Feed     string
+	Modified time.Time
+	Affected []vulns.Affected
+}
+
+// stringKeys converts the map[interface{}]interface{} values decoded by
+// yaml.v2 into map[string]any, so they can be re-encoded as JSON.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case []any:
+		for i, val := range v {
+			v[i] = stringKeys(val)
+		}
+	}
+	return v
+}
+
+// parseOSVRecord parses an OSV record in JSON or YAML.
+func parseOSVRecord(fileName string, data []byte) (*vulns.Vulnerability, error) {
+	if ext := filepath.Ext(fileName); ext == ".yaml" || ext == ".yml" {
+		var record any
+		if err := yaml.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		var err error
+		data, err = json.Marshal(stringKeys(record))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var record vulns.Vulnerability
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.ID == "" {
+		return nil, fmt.Errorf("missing id")
+	}
+	return &record, nil
+}
+
+// recordCVEs returns the CVEs an OSV record is, or aliases.
+func recordCVEs(record *vulns.Vulnerability) []cves.CVEID {
+	var cveIds []cves.CVEID
+	for _, id := range append([]string{record.ID}, record.Aliases...) {
+		if ids.IsCVE(id) && !slices.Contains(cveIds, cves.CVEID(id)) {
+			cveIds = append(cveIds, cves.CVEID(id))
+		}
+	}
+	return cveIds
+}
+
+// loadOSVParts loads the OSV records (.json, .yaml or .yml) anywhere under
+// osvPartsPath, keyed by the CVEs they alias. Withdrawn records, and records
+// that don't alias a CVE, are skipped. The parts' feed is named after
+// osvPartsPath.
+func loadOSVParts(osvPartsPath string) (map[cves.CVEID][]osvPart, error) {
+	feed := filepath.Base(filepath.Clean(osvPartsPath))
+	output := make(map[cves.CVEID][]osvPart)
+	err := filepath.WalkDir(osvPartsPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains([]string{".json", ".yaml", ".yml"}, filepath.Ext(filePath)) {
+			return nil
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		record, err := parseOSVRecord(filePath, data)
+		if err != nil {
+			Logger.Warnf("Failed to parse OSV record %q: %s", filePath, err)
+			return nil
+		}
+		if record.Withdrawn != "" {
+			return nil
+		}
+		modified, err := time.Parse(time.RFC3339, record.Modified)
+		if err != nil {
+			Logger.Warnf("Failed to parse modified time %q of %s: %s", record.Modified, record.ID, err)
+		}
+		part := osvPart{ID: record.ID, Feed: feed, Modified: modified, Affected: record.Affected}
+		for _, cveId := range recordCVEs(record) {
+			output[cveId] = append(output[cveId], part)
+		}
+		return nil
+	})
+	return output, err
+}
+
+// mergeOSVParts merges the affected packages of OSV parts into the combined
+// records, creating the records of CVEs that had no other parts. The part's
+// ID is added to the record's aliases. It returns the provenance of the
+// merged parts.
+func mergeOSVParts(osvData map[cves.CVEID]*vulns.Vulnerability, loadedCves map[cves.CVEID]cves.Vulnerability, osvParts map[cves.CVEID][]osvPart, cveList string) map[cves.CVEID][]vulns.ProvenanceSource {
+	sources := make(map[cves.CVEID][]vulns.ProvenanceSource)
+	for cveId, parts := range osvParts {
+		osv, ok := osvData[cveId]
+		if !ok {
+			cve, ok := loadedCves[cveId]
+			if !ok {
+				continue
+			}
+			osv = newCombinedRecord(cveId, cve, cveList)
+			osvData[cveId] = osv
+		}
+		for _, part := range parts {
+			for _, affected := range part.Affected {
+				if !slices.ContainsFunc(osv.Affected, func(a vulns.Affected) bool { return reflect.DeepEqual(a, affected) }) {
+					osv.Affected = append(osv.Affected, affected)
+				}
+			}
+			if part.ID != string(cveId) && !slices.Contains(osv.Aliases, part.ID) {
+				osv.Aliases = append(osv.Aliases, part.ID)
+			}
+			if modified, err := time.Parse(time.RFC3339, osv.Modified); err == nil && part.Modified.After(modified) {
+				osv.Modified = part.Modified.Format(time.RFC3339)
+			}
+			sources[cveId] = append(sources[cveId], vulns.ProvenanceSource{Feed: part.Feed, RecordID: part.ID})
+		}
+		slices.Sort(osv.Aliases)
+	}
+	return sources
+}
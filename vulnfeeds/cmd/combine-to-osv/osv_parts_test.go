@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestLoadOSVParts(t *testing.T) {
+	osvParts, err := loadOSVParts("../../test_data/osv-parts/advisory-database")
+	if err != nil {
+		t.Fatalf("loadOSVParts() error: %v", err)
+	}
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2022-29194"}, maps.Keys(osvParts)); diff != "" {
+		t.Fatalf("loadOSVParts() keys mismatch (-want +got):\n%s", diff)
+	}
+	parts := osvParts["CVE-2022-29194"]
+	if len(parts) != 1 || parts[0].ID != "PYSEC-2022-196" || parts[0].Feed != "advisory-database" {
+		t.Fatalf("loadOSVParts() = %+v", parts)
+	}
+	want := []vulns.Affected{{
+		Package: &vulns.AffectedPackage{Name: "tensorflow", Ecosystem: "PyPI", Purl: "pkg:pypi/tensorflow"},
+		Ranges: []vulns.AffectedRange{{
+			Type:   "ECOSYSTEM",
+			Events: []vulns.Event{{Introduced: "0"}, {Fixed: "2.7.2"}},
+		}},
+		Versions:          []string{"2.7.0", "2.7.1"},
+		EcosystemSpecific: map[string]any{"affected_functions": map[string]any{"module": "tf.raw_ops"}},
+	}}
+	if diff := gocmp.Diff(want, parts[0].Affected); diff != "" {
+		t.Errorf("loadOSVParts() affected mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeOSVParts(t *testing.T) {
+	loadedCves := map[cves.CVEID]cves.Vulnerability{
+		"CVE-2022-29194": loadTestData2("CVE-2022-29194"),
+	}
+	osvParts, err := loadOSVParts("../../test_data/osv-parts/advisory-database")
+	if err != nil {
+		t.Fatalf("loadOSVParts() error: %v", err)
+	}
+	osvData := map[cves.CVEID]*vulns.Vulnerability{}
+
+	sources := mergeOSVParts(osvData, loadedCves, osvParts, "")
+
+	osv, ok := osvData["CVE-2022-29194"]
+	if !ok {
+		t.Fatalf("mergeOSVParts() didn't create a record for CVE-2022-29194")
+	}
+	if !slices.ContainsFunc(osv.Affected, func(a vulns.Affected) bool { return a.Package != nil && a.Package.Name == "tensorflow" }) {
+		t.Errorf("mergeOSVParts() didn't merge the affected packages: %+v", osv.Affected)
+	}
+	if !slices.Contains(osv.Aliases, "PYSEC-2022-196") {
+		t.Errorf("mergeOSVParts() aliases = %v, want PYSEC-2022-196", osv.Aliases)
+	}
+	if osv.Modified != "2023-01-01T00:00:00Z" {
+		t.Errorf("mergeOSVParts() modified = %s, want the part's", osv.Modified)
+	}
+	wantSources := map[cves.CVEID][]vulns.ProvenanceSource{
+		"CVE-2022-29194": {{Feed: "advisory-database", RecordID: "PYSEC-2022-196"}},
+	}
+	if diff := gocmp.Diff(wantSources, sources); diff != "" {
+		t.Errorf("mergeOSVParts() sources mismatch (-want +got):\n%s", diff)
+	}
+
+	// Merging again doesn't duplicate anything.
+	affected, aliases := len(osv.Affected), len(osv.Aliases)
+	mergeOSVParts(osvData, loadedCves, osvParts, "")
+	if len(osv.Affected) != affected || len(osv.Aliases) != aliases {
+		t.Errorf("mergeOSVParts() isn't idempotent: %+v", osv)
+	}
+}
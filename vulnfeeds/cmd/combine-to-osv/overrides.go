@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// The feed name recorded in the provenance of records changed by an override patch.
+const overridesFeed = "overrides"
+
+// mergePatch applies a JSON merge patch (RFC 7396) to target.
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for k, v := range patchObject {
+		if v == nil {
+			delete(targetObject, k)
+			continue
+		}
+		targetObject[k] = mergePatch(targetObject[k], v)
+	}
+	return targetObject
+}
+
+// The keys of override files that hold operations on the record's lists,
+// rather than fields of its merge patch, by the field they operate on.
+var overrideOperations = map[string]struct {
+	field  string
+	remove bool
+}{
+	"add_affected":      {field: "affected"},
+	"remove_affected":   {field: "affected", remove: true},
+	"add_references":    {field: "references"},
+	"remove_references": {field: "references", remove: true},
+}
+
+// override is an override file's changes to a record: the entries removed
+// from and added to its affected and references lists, then a JSON merge
+// patch of the rest.
+type override struct {
+	// removals and additions are keyed by the field they operate on.
+	removals  map[string][]any
+	additions map[string][]any
+	patch     map[string]any
+}
+
+// loadOverrides loads the override files in overridesPath, keyed by the
+// record ID they apply to (their file name without the .json, .yaml or .yml
+// extension).
+func loadOverrides(overridesPath string) (map[cves.CVEID]override, error) {
+	dir, err := os.ReadDir(overridesPath)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[cves.CVEID]override)
+	for _, entry := range dir {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(path.Join(overridesPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(entry.Name(), ext)
+		if !ids.Valid(id) {
+			return nil, fmt.Errorf("%s is not named after a record ID: %w", entry.Name(), ids.ErrInvalidID)
+		}
+		if _, ok := overrides[cves.CVEID(id)]; ok {
+			return nil, fmt.Errorf("%s: more than one override file for %s", entry.Name(), id)
+		}
+		o, err := parseOverride(ext, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid override: %w", entry.Name(), err)
+		}
+		overrides[cves.CVEID(id)] = o
+	}
+	return overrides, nil
+}
+
+// parseOverride parses an override file in JSON, or in YAML if ext is .yaml
+// or .yml.
+func parseOverride(ext string, data []byte) (override, error) {
+	if ext == ".yaml" || ext == ".yml" {
+		var content any
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return override{}, err
+		}
+		var err error
+		data, err = json.Marshal(stringKeys(content))
+		if err != nil {
+			return override{}, err
+		}
+	}
+	o := override{removals: make(map[string][]any), additions: make(map[string][]any)}
+	if err := json.Unmarshal(data, &o.patch); err != nil {
+		return override{}, fmt.Errorf("not an object: %w", err)
+	}
+	for key, op := range overrideOperations {
+		value, ok := o.patch[key]
+		if !ok {
+			continue
+		}
+		delete(o.patch, key)
+		entries, ok := value.([]any)
+		if !ok || slices.ContainsFunc(entries, func(e any) bool { _, ok := e.(map[string]any); return !ok }) {
+			return override{}, fmt.Errorf("%s is not a list of objects", key)
+		}
+		if op.remove {
+			o.removals[op.field] = entries
+		} else {
+			o.additions[op.field] = entries
+		}
+	}
+	return o, nil
+}
+
+// matches reports whether value has all the fields of pattern, recursively:
+// an entry matches a pattern naming only the fields that identify it.
+// Lists must be equal.
+func matches(value, pattern any) bool {
+	patternObject, ok := pattern.(map[string]any)
+	if !ok {
+		return reflect.DeepEqual(value, pattern)
+	}
+	valueObject, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, v := range patternObject {
+		if !matches(valueObject[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyListOperations removes the entries matching the removals from the
+// record's lists, then appends the additions, returning a description of
+// each operation that's stale: removals that match nothing and additions
+// that are already in the record.
+func applyListOperations(record map[string]any, o override) (stale []string, err error) {
+	for _, field := range []string{"affected", "references"} {
+		if len(o.removals[field]) == 0 && len(o.additions[field]) == 0 {
+			continue
+		}
+		list, ok := record[field].([]any)
+		if record[field] != nil && !ok {
+			return nil, fmt.Errorf("%s is not a list", field)
+		}
+		for _, pattern := range o.removals[field] {
+			kept := slices.DeleteFunc(slices.Clone(list), func(entry any) bool { return matches(entry, pattern) })
+			if len(kept) == len(list) {
+				stale = append(stale, fmt.Sprintf("remove_%s %s matches nothing", field, compactJSON(pattern)))
+			}
+			list = kept
+		}
+		for _, entry := range o.additions[field] {
+			if slices.ContainsFunc(list, func(e any) bool { return reflect.DeepEqual(e, entry) }) {
+				stale = append(stale, fmt.Sprintf("add_%s %s is already in the record", field, compactJSON(entry)))
+				continue
+			}
+			list = append(list, entry)
+		}
+		record[field] = list
+	}
+	return stale, nil
+}
+
+// compactJSON formats a decoded JSON value for a message.
+func compactJSON(v any) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
+
+// applyOverride returns the record with the override applied, and the
+// description of each of its operations that's stale, failing if the result
+// is no longer a valid record.
+func applyOverride(osv *vulns.Vulnerability, o override) (patched *vulns.Vulnerability, changed bool, stale []string, err error) {
+	buf, err := json.Marshal(osv)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	var before, after map[string]any
+	if err := json.Unmarshal(buf, &before); err != nil {
+		return nil, false, nil, err
+	}
+	if err := json.Unmarshal(buf, &after); err != nil {
+		return nil, false, nil, err
+	}
+	stale, err = applyListOperations(after, o)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	patchedRecord := mergePatch(after, o.patch)
+	if reflect.DeepEqual(any(before), patchedRecord) {
+		return osv, false, stale, nil
+	}
+
+	buf, err = json.Marshal(patchedRecord)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.DisallowUnknownFields()
+	patched = &vulns.Vulnerability{}
+	if err := decoder.Decode(patched); err != nil {
+		return nil, false, nil, err
+	}
+	if patched.ID != osv.ID {
+		return nil, false, nil, fmt.Errorf("patch changes the record ID to %q", patched.ID)
+	}
+	return patched, true, stale, nil
+}
+
+// applyOverrides applies the overrides to the combined records. It returns
+// the IDs of the records changed, and a description of every override that
+// no longer applies cleanly: those without a record, those that would make
+// the record invalid, those that no longer change anything and those with
+// stale operations.
+func applyOverrides(osvData map[cves.CVEID]*vulns.Vulnerability, overrides map[cves.CVEID]override) (applied []cves.CVEID, problems []string) {
+	for id, o := range overrides {
+		osv, ok := osvData[id]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no record generated to apply the override to", id))
+			continue
+		}
+		patched, changed, stale, err := applyOverride(osv, o)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: override doesn't apply cleanly: %v", id, err))
+			continue
+		}
+		for _, s := range stale {
+			problems = append(problems, fmt.Sprintf("%s: override is stale: %s", id, s))
+		}
+		if !changed {
+			problems = append(problems, fmt.Sprintf("%s: override no longer changes the record and can be removed", id))
+			continue
+		}
+		osvData[id] = patched
+		applied = append(applied, id)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i] < applied[j] })
+	sort.Strings(problems)
+	return applied, problems
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/internal/vulnstest"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]any{
+		"a": "b",
+		"c": map[string]any{"d": "e", "f": "g"},
+		"h": []any{"i"},
+	}
+	patch := map[string]any{
+		"a": "z",
+		"c": map[string]any{"f": nil},
+		"h": []any{"j", "k"},
+		"l": "m",
+	}
+	want := map[string]any{
+		"a": "z",
+		"c": map[string]any{"d": "e"},
+		"h": []any{"j", "k"},
+		"l": "m",
+	}
+	if diff := gocmp.Diff(want, mergePatch(target, patch)); diff != "" {
+		t.Errorf("mergePatch() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	overridesPath := t.TempDir()
+	patches := map[string]string{
+		"CVE-2024-0001.json": `{"withdrawn": "2024-05-01T00:00:00Z", "aliases": null}`,
+		"CVE-2024-0002.json": `{"details": "already fixed upstream"}`,
+		"CVE-2024-0003.json": `{"affected": "not a list"}`,
+		"CVE-2024-0004.json": `{"summary": "no such record"}`,
+		"README.md":          `not a patch`,
+	}
+	for name, content := range patches {
+		if err := os.WriteFile(path.Join(overridesPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := loadOverrides(overridesPath)
+	if err != nil {
+		t.Fatalf("loadOverrides() error: %v", err)
+	}
+
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {ID: "CVE-2024-0001", Details: "details", Aliases: []string{"GHSA-xxxx-yyyy-zzzz"}, Modified: "2024-04-01T00:00:00Z"},
+		"CVE-2024-0002": {ID: "CVE-2024-0002", Details: "already fixed upstream"},
+		"CVE-2024-0003": {ID: "CVE-2024-0003", Details: "details"},
+	}
+	applied, problems := applyOverrides(osvData, loaded)
+
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0001"}, applied); diff != "" {
+		t.Errorf("applyOverrides() applied mismatch (-want +got):\n%s", diff)
+	}
+	if len(problems) != 3 {
+		t.Errorf("applyOverrides() problems = %q, want 3", problems)
+	}
+	wantRecord := &vulns.Vulnerability{
+		ID:        "CVE-2024-0001",
+		Details:   "details",
+		Withdrawn: "2024-05-01T00:00:00Z",
+		Modified:  "2024-04-01T00:00:00Z",
+	}
+	if diff := gocmp.Diff(wantRecord, osvData["CVE-2024-0001"]); diff != "" {
+		t.Errorf("applyOverrides() record mismatch (-want +got):\n%s", diff)
+	}
+	if osvData["CVE-2024-0003"].Details != "details" {
+		t.Errorf("applyOverrides() changed a record the override doesn't apply cleanly to")
+	}
+}
+
+func TestApplyOverrideOperations(t *testing.T) {
+	overridesPath := t.TempDir()
+	overrides := map[string]string{
+		"CVE-2024-0001.yaml": `
+remove_affected:
+  - package: {ecosystem: PyPI, name: wrong-name}
+add_affected:
+  - package: {ecosystem: PyPI, name: right-name}
+    ranges:
+      - type: ECOSYSTEM
+        events: [{introduced: "0"}, {fixed: "1.2"}]
+remove_references:
+  - url: https://example.com/broken
+add_references:
+  - type: FIX
+    url: https://example.com/fix
+withdrawn: "2024-05-01T00:00:00Z"
+`,
+		// Stale: the affected entry was already removed upstream.
+		"CVE-2024-0002.yml": `
+remove_affected:
+  - package: {name: gone}
+summary: still changes something
+`,
+		"CVE-2024-0003.json": `{"add_references": "not a list"}`,
+	}
+	for name, content := range overrides {
+		if err := os.WriteFile(path.Join(overridesPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := loadOverrides(overridesPath); err == nil {
+		t.Errorf("loadOverrides() with an invalid operation succeeded")
+	}
+	if err := os.Remove(path.Join(overridesPath, "CVE-2024-0003.json")); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadOverrides(overridesPath)
+	if err != nil {
+		t.Fatalf("loadOverrides() error: %v", err)
+	}
+
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": vulnstest.NewVulnerability("CVE-2024-0001").
+			Reference("WEB", "https://example.com/broken").
+			Reference("ADVISORY", "https://example.com/advisory").
+			Affected(
+				vulnstest.NewAffected().Package("PyPI", "wrong-name").Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.2"))),
+				vulnstest.NewAffected().Package("PyPI", "other").Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"))),
+			).Build(),
+		"CVE-2024-0002": vulnstest.NewVulnerability("CVE-2024-0002").Build(),
+	}
+	applied, problems := applyOverrides(osvData, loaded)
+
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0001", "CVE-2024-0002"}, applied); diff != "" {
+		t.Errorf("applyOverrides() applied mismatch (-want +got):\n%s", diff)
+	}
+	wantProblems := []string{`CVE-2024-0002: override is stale: remove_affected {"package":{"name":"gone"}} matches nothing`}
+	if diff := gocmp.Diff(wantProblems, problems); diff != "" {
+		t.Errorf("applyOverrides() problems mismatch (-want +got):\n%s", diff)
+	}
+	want := vulnstest.NewVulnerability("CVE-2024-0001").
+		Withdrawn("2024-05-01T00:00:00Z").
+		Reference("ADVISORY", "https://example.com/advisory").
+		Reference("FIX", "https://example.com/fix").
+		Affected(
+			vulnstest.NewAffected().Package("PyPI", "other").Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"))),
+			vulnstest.NewAffected().Package("PyPI", "right-name").Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.2"))),
+		).Build()
+	if diff := gocmp.Diff(want, osvData["CVE-2024-0001"]); diff != "" {
+		t.Errorf("applyOverrides() record mismatch (-want +got):\n%s", diff)
+	}
+
+	// Applying it again, the operations are all stale.
+	_, problems = applyOverrides(osvData, map[cves.CVEID]override{"CVE-2024-0001": loaded["CVE-2024-0001"]})
+	if len(problems) != 5 {
+		t.Errorf("applyOverrides() again problems = %q, want 4 stale operations and no change", problems)
+	}
+}
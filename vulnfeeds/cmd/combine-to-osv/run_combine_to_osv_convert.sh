@@ -22,6 +22,13 @@ OSV_PARTS_ROOT="parts/"
 OSV_OUTPUT="osv_output/"
 CVE_OUTPUT="cve_jsons/"
 CVELIST="${CVELIST_PATH:=cvelistV5/}"
+MERGE="${MERGE_WITH_PUBLISHED:=}"
+OSV_PUBLISHED="osv_published/"
+OSV_GENERATED="osv_generated/"
+PUBSUB_TOPIC="${CHANGES_PUBSUB_TOPIC:=}"
+INPUT_STATE="${INPUT_STATE_OBJECT:=}"
+INPUT_STATE_FILE="input-state.json"
+CVE_HISTORY_FILE="nvdcve-2.0-history.json"
 
 echo "Setup initial directories"
 rm -rf $OSV_PARTS_ROOT && mkdir -p $OSV_PARTS_ROOT
@@ -37,17 +44,59 @@ echo "Begin syncing NVD data from GCS bucket ${INPUT_BUCKET}"
 gcloud --no-user-output-enabled storage -q cp "gs://${INPUT_BUCKET}/nvd/*-????.json" "${CVE_OUTPUT}"
 echo "Successfully synced from GCS bucket"
 
+HISTORY_FLAGS=()
+rm -f "$CVE_HISTORY_FILE"
+if gcloud --no-user-output-enabled storage -q cp "gs://${INPUT_BUCKET}/nvd/${CVE_HISTORY_FILE}" "$CVE_HISTORY_FILE"; then
+    HISTORY_FLAGS=(-cveHistory "$CVE_HISTORY_FILE")
+else
+    echo "No NVD change history, using the NVD's modified times"
+fi
+
 if [[ -n "$CVELIST" ]]; then
     echo "Clone CVE List"
     git clone --quiet https://github.com/CVEProject/cvelistV5
 fi
 
+MERGE_FLAGS=()
+if [[ -n "$MERGE" ]]; then
+    echo "Begin syncing published and previously generated records from GCS bucket ${OUTPUT_BUCKET}"
+    rm -rf $OSV_PUBLISHED && mkdir -p $OSV_PUBLISHED
+    rm -rf $OSV_GENERATED && mkdir -p $OSV_GENERATED
+    gcloud --no-user-output-enabled storage rsync "gs://${OUTPUT_BUCKET}/osv-output/" "$OSV_PUBLISHED" -r -q
+    gcloud --no-user-output-enabled storage rsync "gs://${OUTPUT_BUCKET}/osv-generated/" "$OSV_GENERATED" -r -q
+    MERGE_FLAGS=(-mergeWith "$OSV_PUBLISHED" -mergeBasePath "$OSV_GENERATED")
+fi
+
+STATE_FLAGS=()
+if [[ -n "$INPUT_STATE" ]]; then
+    echo "Begin syncing previous output and input state from GCS bucket ${OUTPUT_BUCKET}"
+    gcloud --no-user-output-enabled storage rsync "gs://${OUTPUT_BUCKET}/osv-output/" "$OSV_OUTPUT" -r -q
+    rm -f "$INPUT_STATE_FILE"
+    gcloud --no-user-output-enabled storage cp "gs://${OUTPUT_BUCKET}/${INPUT_STATE}" "$INPUT_STATE_FILE" || echo "No input state yet, converting everything"
+    STATE_FLAGS=(-inputState "$INPUT_STATE_FILE")
+fi
+
 echo "Run combine-to-osv"
-./combine-to-osv -cvePath "$CVE_OUTPUT" -partsPath "$OSV_PARTS_ROOT" -osvOutputPath "$OSV_OUTPUT" -cveListPath "$CVELIST"
+./combine-to-osv -cvePath "$CVE_OUTPUT" -partsPath "$OSV_PARTS_ROOT" -osvOutputPath "$OSV_OUTPUT" -cveListPath "$CVELIST" "${MERGE_FLAGS[@]}" "${STATE_FLAGS[@]}" "${HISTORY_FLAGS[@]}"
+
+if [[ -n "$MERGE" ]]; then
+    echo "Begin syncing generated records to GCS bucket ${OUTPUT_BUCKET}"
+    ./gcs-upload -source "$OSV_GENERATED" -destination "gs://${OUTPUT_BUCKET}/osv-generated/" -deleteExtraneous
+fi
 
 echo "Override"
 gcloud --no-user-output-enabled storage rsync "gs://${INPUT_BUCKET}/osv-output-overrides/" $OSV_OUTPUT
 
+UPLOAD_FLAGS=()
+if [[ -n "$PUBSUB_TOPIC" ]]; then
+    UPLOAD_FLAGS=(-pubsubTopic "$PUBSUB_TOPIC")
+fi
+
 echo "Begin syncing output to GCS bucket ${OUTPUT_BUCKET}"
-gsutil -q -m rsync -c -d "${OSV_OUTPUT}" "gs://${OUTPUT_BUCKET}/osv-output/"
+./gcs-upload -source "$OSV_OUTPUT" -destination "gs://${OUTPUT_BUCKET}/osv-output/" -deleteExtraneous -manifest upload-manifest.jsonl "${UPLOAD_FLAGS[@]}"
 echo "Successfully synced to GCS bucket"
+
+if [[ -n "$INPUT_STATE" ]]; then
+    echo "Begin uploading input state to GCS bucket ${OUTPUT_BUCKET}"
+    gcloud --no-user-output-enabled storage cp "$INPUT_STATE_FILE" "gs://${OUTPUT_BUCKET}/${INPUT_STATE}"
+fi
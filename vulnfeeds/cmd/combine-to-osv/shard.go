@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/dryrun"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/manifest"
+)
+
+// writeOptions controls how writeOSVFile lays out its output.
+type writeOptions struct {
+	// Keep the modified time of records whose content hasn't changed.
+	reproducible bool
+	// Write each record into a subdirectory per ecosystem it affects,
+	// rather than all into one directory.
+	shardByEcosystem bool
+	// Bundle each ecosystem's records into an all.zip.
+	zipShards bool
+	// Also write each record as a binary osv.Vulnerability protobuf.
+	protobuf bool
+	// Record the files in plan instead of writing them.
+	plan *dryrun.Plan
+	// Record the files written in the output manifest, if non-nil.
+	outputs *manifest.Flags
+}
+
+// writeEcosystemZip bundles the records in ecosystemDir into its all.zip,
+// ordered by file name.
+func writeEcosystemZip(ecosystemDir string) error {
+	dirEntries, err := os.ReadDir(ecosystemDir)
+	if err != nil {
+		return err
+	}
+	// os.ReadDir sorts its entries by file name.
+	var entries []export.ZipEntry
+	for _, entry := range dirEntries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			entries = append(entries, export.ZipEntry{Name: entry.Name(), Path: path.Join(ecosystemDir, entry.Name())})
+		}
+	}
+	return export.WriteZip(path.Join(ecosystemDir, export.ZipFileName), entries)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/osvpb"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestWriteOSVFileSharded(t *testing.T) {
+	outputPath := t.TempDir()
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0002": {
+			ID:       "CVE-2024-0002",
+			Modified: "2024-01-01T00:00:00Z",
+			Affected: []vulns.Affected{{Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Alpine:v3.18"}}},
+		},
+		"CVE-2024-0001": {
+			ID:       "CVE-2024-0001",
+			Modified: "2024-01-01T00:00:00Z",
+			Affected: []vulns.Affected{{Package: &vulns.AffectedPackage{Name: "zlib", Ecosystem: "Alpine:v3.19"}}},
+		},
+	}
+	writeOSVFile(osvData, outputPath, writeOptions{shardByEcosystem: true, zipShards: true})
+
+	for _, filePath := range []string{
+		"Alpine/CVE-2024-0001.json",
+		"Alpine/CVE-2024-0002.json",
+		"Alpine:v3.18/CVE-2024-0002.json",
+		"Alpine:v3.19/CVE-2024-0001.json",
+	} {
+		if _, err := os.Stat(path.Join(outputPath, filePath)); err != nil {
+			t.Errorf("Missing sharded record: %v", err)
+		}
+	}
+	if _, err := os.Stat(path.Join(outputPath, "CVE-2024-0001.json")); err == nil {
+		t.Errorf("Sharded record also written to the top level")
+	}
+
+	zipPath := path.Join(outputPath, "Alpine", export.ZipFileName)
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", zipPath, err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	if diff := gocmp.Diff([]string{"CVE-2024-0001.json", "CVE-2024-0002.json"}, names); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", export.ZipFileName, diff)
+	}
+
+	// Writing the same records again produces an identical zip.
+	before, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeOSVFile(osvData, outputPath, writeOptions{shardByEcosystem: true, zipShards: true})
+	after, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("%s changed when rewritten with the same records", export.ZipFileName)
+	}
+}
+
+func TestWriteOSVFileProtobuf(t *testing.T) {
+	outputPath := t.TempDir()
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2024-0001": {
+			ID:       "CVE-2024-0001",
+			Modified: "2024-01-01T00:00:00Z",
+			Affected: []vulns.Affected{{
+				Package: &vulns.AffectedPackage{Name: "zlib", Ecosystem: "Alpine:v3.19"},
+				Ranges:  []vulns.AffectedRange{{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "1.3.1-r0"}}}},
+			}},
+		},
+	}
+	if err := writeOSVFile(osvData, outputPath, writeOptions{shardByEcosystem: true, zipShards: true, protobuf: true}); err != nil {
+		t.Fatalf("writeOSVFile() error: %v", err)
+	}
+
+	for _, filePath := range []string{"Alpine/CVE-2024-0001.pb", "Alpine:v3.19/CVE-2024-0001.pb"} {
+		data, err := os.ReadFile(path.Join(outputPath, filePath))
+		if err != nil {
+			t.Fatalf("Missing protobuf record: %v", err)
+		}
+		got, err := osvpb.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", filePath, err)
+		}
+		if diff := gocmp.Diff(osvData["CVE-2024-0001"], got); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", filePath, diff)
+		}
+	}
+
+	// The zip only bundles the JSON records.
+	r, err := zip.OpenReader(path.Join(outputPath, "Alpine", export.ZipFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	if diff := gocmp.Diff([]string{"CVE-2024-0001.json"}, names); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", export.ZipFileName, diff)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/osvapi"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	// The provenance feed of the upstream records a record is enriched from.
+	upstreamFeed = "osv.dev"
+	// The database_specific key of the GIT ranges of upstream records.
+	upstreamDatabaseSpecificKey = "upstream_git"
+)
+
+// upstreamGetter gets published records, e.g. an osvapi.Client.
+type upstreamGetter interface {
+	Get(ctx context.Context, id string) (*vulns.Vulnerability, error)
+}
+
+// upstreamRange is a GIT range of an upstream record, as attached to the
+// records aliasing it.
+type upstreamRange struct {
+	ID     string        `json:"id"`
+	Repo   string        `json:"repo"`
+	Events []vulns.Event `json:"events"`
+}
+
+// upstreamStats summarize an enrichment.
+type upstreamStats struct {
+	Lookups  int
+	Found    int
+	Failed   int
+	Enriched int
+}
+
+// enrichFromUpstream looks up the aliases of each record on osv.dev (other
+// than the records generated alongside it), with up to workers lookups at a
+// time. For each upstream record with a GIT range, the ranges are attached
+// to the aliasing record's database_specific, and the IDs the upstream
+// record is related to are added to its related IDs. Lookups that fail are
+// skipped, so osv.dev being unavailable never fails a conversion. It
+// returns the upstream records each record was enriched from.
+func enrichFromUpstream(ctx context.Context, osvData map[cves.CVEID]*vulns.Vulnerability, getter upstreamGetter, workers int) (map[cves.CVEID][]vulns.ProvenanceSource, upstreamStats) {
+	var stats upstreamStats
+	var aliases []string
+	for _, v := range osvData {
+		for _, alias := range v.Aliases {
+			if _, generated := osvData[cves.CVEID(alias)]; !generated {
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	slices.Sort(aliases)
+	aliases = slices.Compact(aliases)
+	stats.Lookups = len(aliases)
+
+	upstream := make(map[string]*vulns.Vulnerability)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ids := make(chan string)
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				v, err := getter.Get(ctx, id)
+				mu.Lock()
+				switch {
+				case err == nil:
+					upstream[id] = v
+					stats.Found++
+				case !errors.Is(err, osvapi.ErrNotFound):
+					Logger.Warnf("Failed to look up %s on osv.dev: %s", id, err)
+					stats.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range aliases {
+		ids <- id
+	}
+	close(ids)
+	wg.Wait()
+
+	sources := make(map[cves.CVEID][]vulns.ProvenanceSource)
+	for cveId, v := range osvData {
+		var ranges []upstreamRange
+		var related []string
+		for _, alias := range v.Aliases {
+			u, ok := upstream[alias]
+			if !ok {
+				continue
+			}
+			found := gitRanges(u)
+			if len(found) == 0 {
+				continue
+			}
+			ranges = append(ranges, found...)
+			related = append(related, u.Related...)
+			sources[cveId] = append(sources[cveId], vulns.ProvenanceSource{Feed: upstreamFeed, RecordID: u.ID})
+		}
+		if len(ranges) == 0 {
+			continue
+		}
+		slices.SortFunc(ranges, func(a, b upstreamRange) int {
+			return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.Repo, b.Repo))
+		})
+		v.SetDatabaseSpecific(upstreamDatabaseSpecificKey, ranges)
+		// Related IDs that are also aliases would be contradictory.
+		related = slices.DeleteFunc(related, func(id string) bool { return slices.Contains(v.Aliases, id) })
+		v.Related = sortedUnique(append(v.Related, related...), v.ID)
+		stats.Enriched++
+	}
+	return sources, stats
+}
+
+// gitRanges returns the GIT ranges of an upstream record.
+func gitRanges(u *vulns.Vulnerability) []upstreamRange {
+	var ranges []upstreamRange
+	for _, affected := range u.Affected {
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" && r.Repo != "" {
+				ranges = append(ranges, upstreamRange{ID: u.ID, Repo: r.Repo, Events: r.Events})
+			}
+		}
+	}
+	return ranges
+}
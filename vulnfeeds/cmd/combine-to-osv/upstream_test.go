@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/osvapi"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+type fakeUpstream map[string]*vulns.Vulnerability
+
+func (f fakeUpstream) Get(_ context.Context, id string) (*vulns.Vulnerability, error) {
+	if id == "GHSA-fail-fail-fail" {
+		return nil, errors.New("bad response: 503 Service Unavailable")
+	}
+	v, ok := f[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, osvapi.ErrNotFound)
+	}
+	return v, nil
+}
+
+func TestEnrichFromUpstream(t *testing.T) {
+	zlibEvents := []vulns.Event{{Introduced: "0"}, {Fixed: "abc123"}}
+	getter := fakeUpstream{
+		"GHSA-xxxx-yyyy-zzzz": {
+			ID:      "GHSA-xxxx-yyyy-zzzz",
+			Related: []string{"CVE-2023-2000", "GO-2023-0001", "DEBIAN-CVE-2023-2000"},
+			Affected: []vulns.Affected{{Ranges: []vulns.AffectedRange{
+				{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}}},
+				{Type: "GIT", Repo: "https://github.com/madler/zlib", Events: zlibEvents},
+			}}},
+		},
+		"GHSA-no-git-range": {
+			ID:       "GHSA-no-git-range",
+			Related:  []string{"GO-2023-0002"},
+			Affected: []vulns.Affected{{Ranges: []vulns.AffectedRange{{Type: "SEMVER", Events: []vulns.Event{{Introduced: "0"}}}}}},
+		},
+		// Generated records aren't looked up.
+		"CVE-2023-3000": {
+			ID:       "CVE-2023-3000",
+			Affected: []vulns.Affected{{Ranges: []vulns.AffectedRange{{Type: "GIT", Repo: "https://github.com/curl/curl"}}}},
+		},
+	}
+	osvData := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2023-2000": {ID: "CVE-2023-2000", Aliases: []string{"GHSA-xxxx-yyyy-zzzz", "GO-2023-0001"}},
+		"CVE-2023-2001": {ID: "CVE-2023-2001", Aliases: []string{"GHSA-no-git-range", "GHSA-fail-fail-fail", "GHSA-unknown"}},
+		"CVE-2023-3000": {ID: "CVE-2023-3000", Aliases: []string{"CVE-2023-2000"}},
+	}
+
+	sources, stats := enrichFromUpstream(context.Background(), osvData, getter, 2)
+
+	want := map[cves.CVEID]*vulns.Vulnerability{
+		"CVE-2023-2000": {
+			ID:      "CVE-2023-2000",
+			Aliases: []string{"GHSA-xxxx-yyyy-zzzz", "GO-2023-0001"},
+			Related: []string{"DEBIAN-CVE-2023-2000"},
+			DatabaseSpecific: map[string]any{
+				upstreamDatabaseSpecificKey: []upstreamRange{{ID: "GHSA-xxxx-yyyy-zzzz", Repo: "https://github.com/madler/zlib", Events: zlibEvents}},
+			},
+		},
+		"CVE-2023-2001": {ID: "CVE-2023-2001", Aliases: []string{"GHSA-no-git-range", "GHSA-fail-fail-fail", "GHSA-unknown"}},
+		"CVE-2023-3000": {ID: "CVE-2023-3000", Aliases: []string{"CVE-2023-2000"}},
+	}
+	if diff := gocmp.Diff(want, osvData); diff != "" {
+		t.Errorf("enrichFromUpstream() records mismatch (-want +got):\n%s", diff)
+	}
+	wantSources := map[cves.CVEID][]vulns.ProvenanceSource{
+		"CVE-2023-2000": {{Feed: upstreamFeed, RecordID: "GHSA-xxxx-yyyy-zzzz"}},
+	}
+	if diff := gocmp.Diff(wantSources, sources); diff != "" {
+		t.Errorf("enrichFromUpstream() sources mismatch (-want +got):\n%s", diff)
+	}
+	wantStats := upstreamStats{Lookups: 5, Found: 2, Failed: 1, Enriched: 1}
+	if stats != wantStats {
+		t.Errorf("enrichFromUpstream() stats = %+v, want %+v", stats, wantStats)
+	}
+}
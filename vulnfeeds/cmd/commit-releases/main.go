@@ -0,0 +1,150 @@
+/*
+commit-releases precomputes, for each repository in the CPE to repository map,
+the earliest release tag containing each of its commits, so GIT ranges can be
+annotated with equivalent versions when records are generated.
+
+Usage:
+
+	go run cmd/commit-releases/main.go [flags]
+
+The flags are:
+
+	  --cpe_repos
+		The path to the cpe_product_to_repo.json generated by cpe-repo-gen
+
+	  --output_bucket
+		The GCS bucket to store the tables in, as read by git.LoadCommitReleases
+
+	  --output_dir
+		The local directory to store the tables in, if --output_bucket is unset
+
+	  --work_dir
+		The directory to clone repositories into
+
+	  --workers
+		The number of repositories to process concurrently
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	gogit "github.com/go-git/go-git/v5"
+
+	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var (
+	Logger       utility.LoggerWrapper
+	CPERepos     = flag.String("cpe_repos", "", "Path to JSON mapping of CPEs to repos generated by cpe-repo-gen")
+	OutputBucket = flag.String("output_bucket", "", "GCS bucket to store the commit release tables in")
+	OutputPrefix = flag.String("output_prefix", "", "Prefix of the objects in --output_bucket")
+	OutputDir    = flag.String("output_dir", "commit_releases", "Local directory to store the commit release tables in, if --output_bucket is unset")
+	WorkDir      = flag.String("work_dir", os.TempDir(), "Directory to clone repositories into")
+	Workers      = flag.Int("workers", 4, "Number of repositories to process concurrently")
+)
+
+// uniqueRepos returns the distinct repositories of the CPE to repository map,
+// sorted.
+func uniqueRepos(cpeRepos map[string][]string) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, rs := range cpeRepos {
+		for _, r := range rs {
+			if !seen[r] {
+				seen[r] = true
+				repos = append(repos, r)
+			}
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// processRepo bare clones a repository and stores its commit releases.
+func processRepo(ctx context.Context, store git.TagStore, repoURL string, workDir string) error {
+	dir, err := os.MkdirTemp(workDir, "commit-releases-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	repo, err := gogit.PlainCloneContext(ctx, dir, true, &gogit.CloneOptions{URL: repoURL, Tags: gogit.AllTags})
+	if err != nil {
+		return fmt.Errorf("failed to clone: %w", err)
+	}
+	cr, err := git.BuildCommitReleases(repo, repoURL)
+	if err != nil {
+		return err
+	}
+	Logger.Infof("%s: %d commits in %d releases", repoURL, len(cr.Commits), len(cr.Tags))
+	return git.StoreCommitReleases(ctx, store, cr)
+}
+
+func main() {
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("commit-releases")
+	defer logCleanup()
+
+	data, err := os.ReadFile(*CPERepos)
+	if err != nil {
+		Logger.Fatalf("Failed to read CPE repos: %v", err)
+	}
+	var cpeRepos map[string][]string
+	if err := json.Unmarshal(data, &cpeRepos); err != nil {
+		Logger.Fatalf("Failed to parse CPE repos: %v", err)
+	}
+
+	ctx := context.Background()
+	var store git.TagStore = git.DirTagStore(filepath.Clean(*OutputDir))
+	if *OutputBucket != "" {
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			Logger.Fatalf("Failed to create storage client: %v", err)
+		}
+		defer client.Close()
+		store = git.GCSTagStore{Bucket: client.Bucket(strings.TrimPrefix(*OutputBucket, "gs://")), Prefix: *OutputPrefix}
+	}
+
+	repos := uniqueRepos(cpeRepos)
+	work := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for range *Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repoURL := range work {
+				if err := processRepo(ctx, store, repoURL, *WorkDir); err != nil {
+					Logger.Warnf("%s: %v", repoURL, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, repoURL := range repos {
+		work <- repoURL
+	}
+	close(work)
+	wg.Wait()
+	Logger.Infof("Stored commit releases of %d of %d repos", len(repos)-failed, len(repos))
+}
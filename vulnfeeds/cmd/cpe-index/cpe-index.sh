@@ -0,0 +1,42 @@
+#!/bin/bash
+
+# Copyright 2025 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+#
+# Maintain a regularly generated CPE dictionary index in a GCS bucket.
+#
+# Inputs:
+# * A local work directory
+# * A GCS bucket name + path for the cpe-repo-gen CPE to repo map (optional)
+# * A GCS bucket name + path for the resulting index file
+
+# Setting BE_VERBOSE to an empty string or null value suppresses silencing of
+# commands
+
+set -e
+
+mkdir -p "${WORK_DIR}" || true
+
+MAYBE_USE_CPE_REPOS=""
+if [[ -n "${CPEREPO_GCS_PATH}" ]]; then
+  gsutil ${BE_VERBOSE="-q"} cp "${CPEREPO_GCS_PATH}" "${WORK_DIR}/cpe_product_to_repo.json"
+  MAYBE_USE_CPE_REPOS="--cpe_repos ${WORK_DIR}/cpe_product_to_repo.json"
+fi
+
+/cpe-index \
+  ${MAYBE_USE_CPE_REPOS} \
+  --output "${WORK_DIR}/cpe_index.json"
+
+gsutil ${BE_VERBOSE="-q"} cp "${WORK_DIR}/cpe_index.json" "${CPEINDEX_GCS_PATH}"
@@ -0,0 +1,136 @@
+/*
+cpe-index builds a normalized vendor/product index of the NVD CPE Dictionary.
+
+The index is read by converters through the cpedict package to map CPE URIs in
+CVE configurations to package names and repositories.
+
+Usage:
+
+	go run cmd/cpe-index/main.go [flags]
+
+The flags are:
+
+	  --cpe_dictionary
+		The path to the uncompressed NVD CPE Dictionary XML file, downloaded from NVD if unset
+
+	  --cpe_repos
+		The path to the cpe_product_to_repo.json generated by cpe-repo-gen, to annotate products with repositories
+
+	  --output
+		The path to write the index to
+*/
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/google/osv/vulnfeeds/cpedict"
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const (
+	cpeDictionaryURL = "https://nvd.nist.gov/feeds/xml/cpe/dictionary/official-cpe-dictionary_v2.3.xml.gz"
+	outputDefault    = "cpe_index.json"
+)
+
+var (
+	Logger            utility.LoggerWrapper
+	CPEDictionaryFile = flag.String("cpe_dictionary", "", "CPE Dictionary file to parse, downloaded from NVD if unset")
+	CPERepos          = flag.String("cpe_repos", "", "Path to JSON mapping of CPEs to repos generated by cpe-repo-gen")
+	Output            = flag.String("output", outputDefault, "Path to write the CPE dictionary index to")
+	httpFlags         = httpclient.RegisterFlags(flag.CommandLine)
+	rateLimits        = ratelimit.RegisterFlags(flag.CommandLine)
+)
+
+// openCPEDictionary opens the local CPE dictionary, or downloads it from NVD.
+func openCPEDictionary(f string) (io.ReadCloser, error) {
+	if f != "" {
+		return os.Open(f)
+	}
+
+	res, err := faulttolerant.Get(cpeDictionaryURL)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, res.Body}, nil
+}
+
+func loadCPERepos(f string) (map[string][]string, error) {
+	data, err := os.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var repos map[string][]string
+	return repos, json.Unmarshal(data, &repos)
+}
+
+func main() {
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("cpe-index")
+	defer logCleanup()
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	dictionary, err := openCPEDictionary(*CPEDictionaryFile)
+	if err != nil {
+		Logger.Fatalf("Failed to open CPE dictionary: %v", err)
+	}
+	products, err := cpedict.ParseDictionary(dictionary)
+	dictionary.Close()
+	if err != nil {
+		Logger.Fatalf("Failed to parse CPE dictionary: %v", err)
+	}
+
+	var repos map[string][]string
+	if *CPERepos != "" {
+		repos, err = loadCPERepos(*CPERepos)
+		if err != nil {
+			Logger.Fatalf("Failed to load %s: %v", *CPERepos, err)
+		}
+	}
+
+	idx := cpedict.NewIndex(products, repos)
+
+	file, err := os.Create(*Output)
+	if err != nil {
+		Logger.Fatalf("%v", err)
+	}
+	defer file.Close()
+	if err := idx.Write(file); err != nil {
+		Logger.Fatalf("Failed to write CPE dictionary index: %v", err)
+	}
+	Logger.Infof("Wrote CPE dictionary index of %d products to %s", idx.Len(), *Output)
+}
@@ -49,6 +49,8 @@ import (
 
 	"github.com/google/osv/vulnfeeds/cves"
 	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/repourl"
 	"github.com/google/osv/vulnfeeds/utility"
 
 	"golang.org/x/exp/slices"
@@ -124,20 +126,26 @@ var (
 	DebianMetadataPath = flag.String("debian_metadata_path", "", "Path to Debian copyright metadata")
 	Validate           = flag.Bool("validate", true, "Attempt to validate the repository is communicable")
 	Verbose            = flag.Bool("verbose", false, "Output some telemetry to stdout during execution")
+	outputFlags        = manifest.RegisterFlags(flag.CommandLine)
 )
 
 func LoadCPEDictionary(f string) (CPEDict, error) {
 	xmlFile, err := os.Open(f)
 	if err != nil {
-		Logger.Fatalf("Failed to open %s: %v", f, err)
+		return CPEDict{}, err
 	}
 
 	defer xmlFile.Close()
 
-	byteValue, _ := ioutil.ReadAll(xmlFile)
+	byteValue, err := ioutil.ReadAll(xmlFile)
+	if err != nil {
+		return CPEDict{}, err
+	}
 
 	var c CPEDict
-	xml.Unmarshal(byteValue, &c)
+	if err := xml.Unmarshal(byteValue, &c); err != nil {
+		return CPEDict{}, err
+	}
 
 	return c, nil
 }
@@ -262,7 +270,8 @@ func MaybeGetSourceFromDebianCopyright(copyrightFile string) (string, bool) {
 
 	file, err := os.Open(copyrightFile)
 	if err != nil {
-		Logger.Fatalf("%v", err)
+		Logger.Warnf("Failed to open %s: %v", copyrightFile, err)
+		return "", false
 	}
 	defer file.Close()
 
@@ -341,9 +350,7 @@ func analyzeCPEDictionary(d CPEDict) (ProductToRepo VendorProductToRepoMap, Desc
 				Logger.Infof("Disregarding %q for %s:%s (%s) because %v", r.URL, CPE.Vendor, CPE.Product, r.Description, err)
 				continue
 			}
-			if IsGitHubURL(repo) {
-				repo = strings.ToLower(repo)
-			}
+			repo = repourl.Canonical(repo)
 			// If we already have an entry for this repo, don't add it again.
 			if slices.Contains(ProductToRepo[VendorProduct{CPE.Vendor, CPE.Product}], repo) {
 				continue
@@ -384,6 +391,7 @@ func analyzeCPEDictionary(d CPEDict) (ProductToRepo VendorProductToRepoMap, Desc
 					Logger.Infof("Disregarding derived repo %s for %s:%s because %v", repo, vp.Vendor, vp.Product, err)
 					continue
 				}
+				repo = repourl.Canonical(repo)
 				if !git.ValidRepoAndHasUsableRefs(repo) {
 					Logger.Infof("Disregarding derived repo %s for %s:%s because it is unusable for version resolution", repo, vp.Vendor, vp.Product)
 					continue
@@ -422,7 +430,12 @@ func main() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
 		flag.PrintDefaults()
 	}
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
 
 	var logCleanup func()
 	Logger, logCleanup = utility.CreateLoggerWrapper("cpe-repo-gen")
@@ -438,7 +451,8 @@ func main() {
 		productToRepo = validateRepos(productToRepo)
 	}
 
-	mappingFile, err := os.Create(filepath.Join(*OutputDir, "cpe_product_to_repo.json"))
+	mappingPath := filepath.Join(*OutputDir, "cpe_product_to_repo.json")
+	mappingFile, err := os.Create(mappingPath)
 	if err != nil {
 		Logger.Fatalf("%v", err)
 	}
@@ -447,7 +461,10 @@ func main() {
 	if err != nil {
 		Logger.Fatalf("%v", err)
 	}
-	frequencyFile, err := os.Create(filepath.Join(*OutputDir, "cpe_reference_description_frequency.csv"))
+	outputFlags.Add(mappingPath)
+	outputFlags.Count("products", len(productToRepo))
+	frequencyPath := filepath.Join(*OutputDir, "cpe_reference_description_frequency.csv")
+	frequencyFile, err := os.Create(frequencyPath)
 	if err != nil {
 		Logger.Fatalf("%v", err)
 	}
@@ -456,4 +473,8 @@ func main() {
 	if err != nil {
 		Logger.Fatalf("%v", err)
 	}
+	outputFlags.Add(frequencyPath)
+	if err := outputFlags.Finish("cpe-repo-gen"); err != nil {
+		Logger.Fatalf("CPE repo generation failed: %v", err)
+	}
 }
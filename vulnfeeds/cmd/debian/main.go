@@ -3,15 +3,25 @@ package main
 import (
 	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/dryrun"
 	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/pkgindex"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/server"
 	"github.com/google/osv/vulnfeeds/utility"
 	"github.com/google/osv/vulnfeeds/vulns"
 )
@@ -23,14 +33,46 @@ const (
 )
 
 var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var serveFlags = server.RegisterFlags(flag.CommandLine)
+var dryRunFlags = dryrun.RegisterFlags(flag.CommandLine)
+var verifyFlags = pkgindex.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
 
 func main() {
 	var logCleanup func()
 	Logger, logCleanup = utility.CreateLoggerWrapper("debian-osv")
 	defer logCleanup()
 
-	err := os.MkdirAll(debianOutputPathDefault, 0755)
-	if err != nil {
+	ovalCrossCheck := flag.Bool("ovalCrossCheck", false, "cross-check fixed versions against Debian's OVAL definitions")
+	ovalStrict := flag.Bool("ovalStrict", false, "fail without writing output if the OVAL cross-check finds disagreements")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if serveFlags.Enabled() {
+		if err := serveFlags.Serve("debian-osv", flag.CommandLine); err != nil {
+			Logger.Fatalf("Server failed: %s", err)
+		}
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	var plan *dryrun.Plan
+	if dryRunFlags.Enabled() {
+		plan = dryrun.NewPlan(debianOutputPathDefault, func(name string) bool {
+			return strings.HasSuffix(name, ".debian.json")
+		})
+	} else if err := os.MkdirAll(debianOutputPathDefault, 0755); err != nil {
 		Logger.Fatalf("Can't create output path: %s", err)
 	}
 
@@ -45,9 +87,28 @@ func main() {
 	}
 
 	cvePkgInfos := generateDebianSecurityTrackerOSV(debianData, debianReleaseMap)
-	if err = writeToOutput(cvePkgInfos); err != nil {
+	if *ovalCrossCheck {
+		disagreements := crossCheckDebianOVAL(cvePkgInfos, debianReleaseMap)
+		if *ovalStrict && len(disagreements) > 0 {
+			Logger.Fatalf("OVAL cross-check found %d disagreements", len(disagreements))
+		}
+	}
+	if verifier := verifyFlags.NewVerifier(debianIndexLoader(debianReleaseMap), Logger); verifier != nil {
+		verifyFixedVersions(cvePkgInfos, verifier)
+		verifier.Summary().Report(Logger)
+	}
+	if err = writeToOutput(cvePkgInfos, plan); err != nil {
 		Logger.Fatalf("Failed to write OSV output file: %s", err)
 	}
+	if plan != nil {
+		if err := dryRunFlags.Report(plan, os.Stdout); err != nil {
+			Logger.Fatalf("Failed to compare with existing output: %s", err)
+		}
+		return
+	}
+	if err := outputFlags.Finish("debian-osv"); err != nil {
+		Logger.Fatalf("Debian CVE conversion failed: %s", err)
+	}
 
 	Logger.Infof("Debian CVE conversion succeeded.")
 }
@@ -119,6 +180,7 @@ func updateOSVPkgInfos(pkgName string, cveId string, releases map[string]Release
 		pkgInfo := vulns.PackageInfo{
 			PkgName:   pkgName,
 			Ecosystem: "Debian:" + debianVersion,
+			PURL:      purl.Debian(pkgName, debianVersion),
 		}
 		pkgInfo.EcosystemSpecific = make(map[string]string)
 
@@ -130,6 +192,9 @@ func updateOSVPkgInfos(pkgName string, cveId string, releases map[string]Release
 				continue
 			}
 			pkgInfo.VersionInfo.AffectedVersions = append(pkgInfo.VersionInfo.AffectedVersions, cves.AffectedVersion{Fixed: release.FixedVersion})
+		} else {
+			// Open and undetermined issues have no fix yet.
+			pkgInfo.Unresolved = true
 		}
 		pkgInfo.EcosystemSpecific["urgency"] = release.Urgency
 		pkgInfos = append(pkgInfos, pkgInfo)
@@ -173,21 +238,34 @@ func generateDebianSecurityTrackerOSV(debianData DebianSecurityTrackerData, debi
 	return osvPkgInfos
 }
 
-func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo) error {
+// verifyFixedVersions verifies the fixed versions of every package info.
+func verifyFixedVersions(cvePkgInfos map[string][]vulns.PackageInfo, verifier *pkgindex.Verifier) {
+	for cveId, pkgInfos := range cvePkgInfos {
+		for i := range pkgInfos {
+			verifier.Verify(cveId, &pkgInfos[i])
+		}
+	}
+}
+
+// writeToOutput writes the package infos of each CVE to its part file, or
+// records them in plan instead if it's non-nil.
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, plan *dryrun.Plan) error {
 	Logger.Infof("Writing package infos to the output.")
 	for cveId := range cvePkgInfos {
-		pkgInfos := cvePkgInfos[cveId]
-		file, err := os.OpenFile(path.Join(debianOutputPathDefault, cveId+".debian.json"), os.O_CREATE|os.O_RDWR, 0644)
+		data, err := parts.Encode(cvePkgInfos[cveId])
 		if err != nil {
 			return err
 		}
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "  ")
-		err = encoder.Encode(&pkgInfos)
-		if err != nil {
+		if plan != nil {
+			plan.Write(cveId+".debian.json", data)
+			continue
+		}
+		filePath := path.Join(debianOutputPathDefault, cveId+".debian.json")
+		if err := os.WriteFile(filePath, data, 0644); err != nil {
 			return err
 		}
-		_ = file.Close()
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(cvePkgInfos[cveId]))
 	}
 
 	return nil
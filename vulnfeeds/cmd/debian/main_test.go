@@ -41,5 +41,13 @@ func Test_generateDebianSecurityTrackerOSV(t *testing.T) {
 		if len(pkgInfos) != len(expectedPackageInfos) || pkgInfos[0].EcosystemSpecific["urgency"] != expectedPackageInfos[0].EcosystemSpecific["urgency"] {
 			t.Errorf("Expected Debian OSV data %v, got %v", expectedPackageInfos, pkgInfos)
 		}
+		for i := range min(len(pkgInfos), len(expectedPackageInfos)) {
+			if pkgInfos[i].Unresolved != expectedPackageInfos[i].Unresolved {
+				t.Errorf("%s %s: expected unresolved %v, got %v", cveId, pkgInfos[i].Ecosystem, expectedPackageInfos[i].Unresolved, pkgInfos[i].Unresolved)
+			}
+			if pkgInfos[i].PURL != expectedPackageInfos[i].PURL {
+				t.Errorf("%s %s: expected purl %q, got %q", cveId, pkgInfos[i].Ecosystem, expectedPackageInfos[i].PURL, pkgInfos[i].PURL)
+			}
+		}
 	}
 }
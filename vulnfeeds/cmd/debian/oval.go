@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/oval"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const debianOVALURLBase = "https://www.debian.org/security/oval/oval-definitions-%s.xml"
+
+// ovalKey identifies a package affected by a CVE.
+type ovalKey struct {
+	CVE     string
+	Package string
+}
+
+// OVALDisagreement describes a fixed version mismatch between the Debian
+// Security Tracker and the Debian OVAL definitions.
+type OVALDisagreement struct {
+	CVE          string
+	Package      string
+	Ecosystem    string
+	TrackerFixed string
+	OVALFixed    string
+}
+
+func (d OVALDisagreement) String() string {
+	trackerFixed := d.TrackerFixed
+	if trackerFixed == "" {
+		trackerFixed = "<unfixed>"
+	}
+	return fmt.Sprintf("%s %s (%s): security tracker fixed version %s, OVAL fixed version %s", d.CVE, d.Package, d.Ecosystem, trackerFixed, d.OVALFixed)
+}
+
+// parseOVAL extracts the fixed version of each package for each CVE from Debian OVAL definitions.
+func parseOVAL(r io.Reader) (map[ovalKey]string, error) {
+	defs, err := oval.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fixes := make(map[ovalKey]string)
+	for _, affected := range defs.Affected() {
+		if affected.Class != "vulnerability" || affected.Fixed == "" {
+			continue
+		}
+		for _, cveID := range affected.CVEs {
+			fixes[ovalKey{CVE: cveID, Package: affected.Package}] = affected.Fixed
+		}
+	}
+
+	return fixes, nil
+}
+
+// normalizeDebianVersion strips the implicit zero epoch so versions from
+// OVAL (which always carry an epoch) compare equal to the security tracker's.
+func normalizeDebianVersion(version string) string {
+	return strings.TrimPrefix(version, "0:")
+}
+
+// crossCheckOVAL compares the fixed versions derived from the security tracker
+// for a Debian release ecosystem with those in the release's OVAL definitions.
+func crossCheckOVAL(ovalFixes map[ovalKey]string, cvePkgInfos map[string][]vulns.PackageInfo, ecosystem string) []OVALDisagreement {
+	var disagreements []OVALDisagreement
+	for cveID, pkgInfos := range cvePkgInfos {
+		for _, pkgInfo := range pkgInfos {
+			if pkgInfo.Ecosystem != ecosystem {
+				continue
+			}
+			ovalFixed, ok := ovalFixes[ovalKey{CVE: cveID, Package: pkgInfo.PkgName}]
+			if !ok {
+				continue
+			}
+			trackerFixed := ""
+			for _, av := range pkgInfo.VersionInfo.AffectedVersions {
+				if av.Fixed != "" {
+					trackerFixed = av.Fixed
+				}
+			}
+			if normalizeDebianVersion(trackerFixed) == normalizeDebianVersion(ovalFixed) {
+				continue
+			}
+			disagreements = append(disagreements, OVALDisagreement{
+				CVE:          cveID,
+				Package:      pkgInfo.PkgName,
+				Ecosystem:    ecosystem,
+				TrackerFixed: trackerFixed,
+				OVALFixed:    ovalFixed,
+			})
+		}
+	}
+
+	sort.Slice(disagreements, func(i, j int) bool {
+		if disagreements[i].CVE != disagreements[j].CVE {
+			return disagreements[i].CVE < disagreements[j].CVE
+		}
+		return disagreements[i].Package < disagreements[j].Package
+	})
+
+	return disagreements
+}
+
+// downloadDebianOVAL downloads and parses the OVAL definitions of a Debian release.
+func downloadDebianOVAL(releaseName string) (map[ovalKey]string, error) {
+	res, err := faulttolerant.Get(fmt.Sprintf(debianOVALURLBase, releaseName))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+
+	return parseOVAL(res.Body)
+}
+
+// crossCheckDebianOVAL cross-checks the generated package infos of every
+// Debian release against its OVAL definitions, logging each disagreement.
+func crossCheckDebianOVAL(cvePkgInfos map[string][]vulns.PackageInfo, debianReleaseMap map[string]string) []OVALDisagreement {
+	var releaseNames []string
+	for releaseName := range debianReleaseMap {
+		releaseNames = append(releaseNames, releaseName)
+	}
+	sort.Strings(releaseNames)
+
+	var disagreements []OVALDisagreement
+	for _, releaseName := range releaseNames {
+		ovalFixes, err := downloadDebianOVAL(releaseName)
+		if err != nil {
+			// Not every release has OVAL definitions published.
+			Logger.Warnf("Skipping OVAL cross-check for %s: %s", releaseName, err)
+			continue
+		}
+		releaseDisagreements := crossCheckOVAL(ovalFixes, cvePkgInfos, "Debian:"+debianReleaseMap[releaseName])
+		for _, d := range releaseDisagreements {
+			Logger.Warnf("OVAL disagreement: %s", d)
+		}
+		disagreements = append(disagreements, releaseDisagreements...)
+	}
+	Logger.Infof("OVAL cross-check found %d disagreements", len(disagreements))
+
+	return disagreements
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseOVAL(t *testing.T) {
+	file, err := os.Open("../../test_data/debian/oval-definitions-mock.xml")
+	if err != nil {
+		t.Fatalf("Failed to open OVAL test data: %v", err)
+	}
+	defer file.Close()
+
+	got, err := parseOVAL(file)
+	if err != nil {
+		t.Fatalf("parseOVAL() returned an unexpected error: %v", err)
+	}
+	want := map[ovalKey]string{
+		{CVE: "CVE-2017-6507", Package: "apparmor"}:   "0:2.11.0-3",
+		{CVE: "CVE-2016-1585", Package: "apparmor"}:   "0:3.0.8-3",
+		{CVE: "CVE-2018-1000500", Package: "busybox"}: "1:1.35.0-5",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseOVAL() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+}
+
+func Test_crossCheckOVAL(t *testing.T) {
+	var decodedDebianData DebianSecurityTrackerData
+	file, err := os.Open("../../test_data/debian/debian_security_tracker_mock.json")
+	if err != nil {
+		t.Fatalf("Failed to open Debian test data: %v", err)
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&decodedDebianData); err != nil {
+		t.Fatalf("Failed to decode Debian test data: %v", err)
+	}
+	debianReleaseMap := map[string]string{"bookworm": "12"}
+	cvePkgInfos := generateDebianSecurityTrackerOSV(decodedDebianData, debianReleaseMap)
+
+	ovalFile, err := os.Open("../../test_data/debian/oval-definitions-mock.xml")
+	if err != nil {
+		t.Fatalf("Failed to open OVAL test data: %v", err)
+	}
+	defer ovalFile.Close()
+	ovalFixes, err := parseOVAL(ovalFile)
+	if err != nil {
+		t.Fatalf("parseOVAL() returned an unexpected error: %v", err)
+	}
+
+	got := crossCheckOVAL(ovalFixes, cvePkgInfos, "Debian:12")
+	want := []OVALDisagreement{
+		{CVE: "CVE-2016-1585", Package: "apparmor", Ecosystem: "Debian:12", OVALFixed: "0:3.0.8-3"},
+		{CVE: "CVE-2018-1000500", Package: "busybox", Ecosystem: "Debian:12", OVALFixed: "1:1.35.0-5"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("crossCheckOVAL() returned an unexpected diff (-want, +got):\n%s", diff)
+	}
+
+	if got := crossCheckOVAL(ovalFixes, cvePkgInfos, "Debian:11"); len(got) != 0 {
+		t.Errorf("crossCheckOVAL() for an unrelated release returned %v, want none", got)
+	}
+}
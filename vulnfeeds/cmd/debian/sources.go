@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/pkgindex"
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+const (
+	debianSourcesURLBase         = "https://deb.debian.org/debian/dists/%s/main/source/Sources.gz"
+	debianSecuritySourcesURLBase = "https://security.debian.org/debian-security/dists/%s-security/main/source/Sources.gz"
+)
+
+// parseDebianSources adds the version of each source package in a Sources
+// file to index.
+func parseDebianSources(r io.Reader, index pkgindex.Index) error {
+	var name, version string
+	record := func() {
+		if name != "" && version != "" {
+			index.Add(name, version, vercmp.Dpkg)
+		}
+		name, version = "", ""
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			record()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		switch key {
+		case "Package":
+			name = strings.TrimSpace(value)
+		case "Version":
+			version = strings.TrimSpace(value)
+		}
+	}
+	record()
+	return scanner.Err()
+}
+
+// downloadDebianSources adds the source packages of a Sources.gz to index.
+func downloadDebianSources(url string, index pkgindex.Index) error {
+	res, err := faulttolerant.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", url, res.Status)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return parseDebianSources(gz, index)
+}
+
+// debianIndexLoader returns a loader of the index of a Debian ecosystem,
+// e.g. "Debian:12", from the Sources of its release and of its security
+// updates (where fixes are usually published first).
+func debianIndexLoader(debianReleaseMap map[string]string) func(ecosystem string) (pkgindex.Index, error) {
+	return func(ecosystem string) (pkgindex.Index, error) {
+		version := strings.TrimPrefix(ecosystem, "Debian:")
+		for releaseName, v := range debianReleaseMap {
+			if v != version {
+				continue
+			}
+			index := make(pkgindex.Index)
+			if err := downloadDebianSources(fmt.Sprintf(debianSourcesURLBase, releaseName), index); err != nil {
+				return nil, err
+			}
+			if err := downloadDebianSources(fmt.Sprintf(debianSecuritySourcesURLBase, releaseName), index); err != nil {
+				Logger.Warnf("No security updates to verify %s against: %s", releaseName, err)
+			}
+			return index, nil
+		}
+		return nil, fmt.Errorf("unknown Debian release %q", version)
+	}
+}
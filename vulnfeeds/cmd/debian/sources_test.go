@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/pkgindex"
+)
+
+func Test_parseDebianSources(t *testing.T) {
+	sources := `Package: curl
+Binary: curl, libcurl4
+Version: 7.88.1-10+deb12u5
+Checksums-Sha256:
+ 0123 456 curl_7.88.1.orig.tar.gz
+Directory: pool/main/c/curl
+
+Package: openssl
+Version: 3.0.11-1~deb12u2
+`
+	security := `Package: curl
+Version: 7.88.1-10+deb12u7
+
+Package: openssl
+Version: 3.0.9-1
+`
+	index := make(pkgindex.Index)
+	for _, s := range []string{sources, security} {
+		if err := parseDebianSources(strings.NewReader(s), index); err != nil {
+			t.Fatalf("parseDebianSources() error = %v", err)
+		}
+	}
+	want := pkgindex.Index{"curl": "7.88.1-10+deb12u7", "openssl": "3.0.11-1~deb12u2"}
+	if diff := gocmp.Diff(want, index); diff != "" {
+		t.Errorf("parseDebianSources() mismatch (-want +got):\n%s", diff)
+	}
+}
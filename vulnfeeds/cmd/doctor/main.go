@@ -0,0 +1,106 @@
+// doctor is a pre-flight check of the scheduled pipeline: it verifies the
+// upstream feeds are reachable and still parse, and that the pipeline's GCS
+// and Datastore permissions are in place, exiting non-zero with a structured
+// report otherwise.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/preflight"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const (
+	nvdAPIEndpoint    = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+	alpineSecDBURL    = "https://secdb.alpinelinux.org/edge/main.json"
+	debianTrackerURL  = "https://security-tracker.debian.org/tracker/data/json"
+	defaultOtherFeeds = "https://secdb.alpinelinux.org/,https://security.alpinelinux.org/branch/edge-main,https://debian.pages.debian.net/distro-info-data/debian.csv,https://repo.openeuler.org/security/data/cvrf/"
+)
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("doctor")
+	defer logCleanup()
+
+	feeds := flag.String("feeds", defaultOtherFeeds, "Comma separated feed URLs that must be reachable, in addition to those sample-parsed")
+	nvdAPIKey := flag.String("nvdAPIKey", os.Getenv("NVD_API_KEY"), "NVD API key to sample NVD with (defaults to $NVD_API_KEY)")
+	buckets := flag.String("buckets", "", "Comma separated GCS buckets that must be writable")
+	datastoreProject := flag.String("datastoreProject", "", "GCP project of the Datastore kinds to check")
+	datastoreKinds := flag.String("datastoreKinds", "", "Comma separated Datastore kinds that must be readable")
+	timeout := flag.Duration("timeout", time.Minute, "Timeout for each check")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	// Check the feeds with the client the downloaders use, so a proxy or
+	// user agent they're refused for is caught.
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+
+	ctx := context.Background()
+	httpClient := http.DefaultClient
+	checks := []preflight.Check{
+		preflight.NVDSampleCheck(httpClient, nvdAPIEndpoint, *nvdAPIKey),
+		preflight.AlpineSampleCheck(httpClient, alpineSecDBURL),
+		preflight.DebianSampleCheck(httpClient, debianTrackerURL),
+	}
+	for _, feed := range utility.SplitList(*feeds) {
+		checks = append(checks, preflight.FeedCheck(httpClient, feed))
+	}
+
+	var storageClient *storage.Client
+	var storageErr error
+	var storageOnce sync.Once
+	newStorageClient := func() (*storage.Client, error) {
+		storageOnce.Do(func() { storageClient, storageErr = storage.NewClient(ctx) })
+		return storageClient, storageErr
+	}
+	for _, bucket := range utility.SplitList(*buckets) {
+		checks = append(checks, preflight.BucketCheck(newStorageClient, strings.TrimPrefix(bucket, "gs://")))
+	}
+
+	var datastoreClient *datastore.Client
+	var datastoreErr error
+	var datastoreOnce sync.Once
+	newDatastoreClient := func() (*datastore.Client, error) {
+		datastoreOnce.Do(func() { datastoreClient, datastoreErr = datastore.NewClient(ctx, *datastoreProject) })
+		return datastoreClient, datastoreErr
+	}
+	for _, kind := range utility.SplitList(*datastoreKinds) {
+		checks = append(checks, preflight.DatastoreCheck(newDatastoreClient, kind))
+	}
+
+	report := preflight.Run(ctx, checks, *timeout)
+	if storageClient != nil {
+		storageClient.Close()
+	}
+	if datastoreClient != nil {
+		datastoreClient.Close()
+	}
+	if err := preflight.WriteJSON(os.Stdout, report); err != nil {
+		Logger.Warnf("Failed to write report: %v", err)
+	}
+	if report.Failed > 0 {
+		Logger.Fatalf("%d of %d pre-flight checks failed", report.Failed, len(report.Results))
+	}
+	Logger.Infof("All %d pre-flight checks passed", len(report.Results))
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const (
+	// backfillDir is the directory of CVEPath the checkpoints of a backfill
+	// are kept in.
+	backfillDir = "backfill"
+	// nvdTimeFormat is the format of the API's date parameters.
+	nvdTimeFormat = "2006-01-02T15:04:05.000"
+	// maxWindowDays is the longest publication date range the API accepts.
+	maxWindowDays = 120
+)
+
+// backfillWindow is a range of publication dates, from Start up to End,
+// that's downloaded and checkpointed on its own.
+type backfillWindow struct {
+	Start, End time.Time
+}
+
+// name identifies the window, and names its checkpoint.
+func (w backfillWindow) name() string {
+	return w.Start.Format("2006-01-02") + "_" + w.End.Format("2006-01-02")
+}
+
+// params returns the query parameters of the CVEs published in the window.
+// The API's end dates are inclusive, so the window ends a millisecond before
+// the next begins.
+func (w backfillWindow) params() url.Values {
+	return url.Values{
+		"pubStartDate": {w.Start.UTC().Format(nvdTimeFormat)},
+		"pubEndDate":   {w.End.Add(-time.Millisecond).UTC().Format(nvdTimeFormat)},
+	}
+}
+
+// backfillWindows splits the publication dates from start up to end into
+// windows of at most days days.
+func backfillWindows(start, end time.Time, days int) []backfillWindow {
+	var windows []backfillWindow
+	for s := start; s.Before(end); s = s.AddDate(0, 0, days) {
+		e := s.AddDate(0, 0, days)
+		if e.After(end) {
+			e = end
+		}
+		windows = append(windows, backfillWindow{Start: s, End: e})
+	}
+	return windows
+}
+
+// downloadWindow downloads all the pages of the CVEs published in w, and
+// verifies it got as many distinct CVEs as the API said there are.
+func downloadWindow(APIKey string, w backfillWindow) (*cves.CVEAPIJSON20Schema, error) {
+	var window *cves.CVEAPIJSON20Schema
+	seen := make(map[cves.CVEID]bool)
+	for offset := 0; ; {
+		page, err := downloadCVE2Page(APIKey, w.params(), offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download at offset %d: %w", offset, err)
+		}
+		if window == nil {
+			first := *page
+			window = &first
+			// Empty rather than nil, so a window without CVEs is written as
+			// such.
+			window.Vulnerabilities = []cves.Vulnerability{}
+		} else if page.TotalResults != window.TotalResults {
+			return nil, fmt.Errorf("total results changed from %d to %d during the download", window.TotalResults, page.TotalResults)
+		}
+		for _, v := range page.Vulnerabilities {
+			if !seen[v.CVE.ID] {
+				seen[v.CVE.ID] = true
+				window.Vulnerabilities = append(window.Vulnerabilities, v)
+			}
+		}
+		offset += len(page.Vulnerabilities)
+		if len(page.Vulnerabilities) == 0 || offset >= window.TotalResults {
+			break
+		}
+		time.Sleep(pageDelay)
+	}
+	if len(window.Vulnerabilities) != window.TotalResults {
+		return nil, fmt.Errorf("got %d CVEs, want %d", len(window.Vulnerabilities), window.TotalResults)
+	}
+	window.StartIndex = 0
+	window.ResultsPerPage = window.TotalResults
+	return window, nil
+}
+
+// loadCheckpoint returns the CVEs of a window's checkpoint, and whether it
+// has one that's complete.
+func loadCheckpoint(path string) (*cves.CVEAPIJSON20Schema, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var window cves.CVEAPIJSON20Schema
+	if err := json.Unmarshal(data, &window); err != nil || len(window.Vulnerabilities) != window.TotalResults {
+		return nil, false
+	}
+	return &window, true
+}
+
+// backfill downloads all the CVEs published from start up to end using the
+// 2.0 API, in windows of days days downloaded by up to workers concurrently.
+// Each window is checkpointed once it's downloaded and verified, so a
+// backfill that fails is resumed by running it again. Once every window is
+// downloaded, they're merged into nvdcve-2.0.json, as downloadCVE2 writes.
+func backfill(APIKey string, CVEPath string, start, end time.Time, days int, workers int) error {
+	if days < 1 || days > maxWindowDays {
+		return fmt.Errorf("windows must be between 1 and %d days, not %d", maxWindowDays, days)
+	}
+	dir := filepath.Join(CVEPath, backfillDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	windows := backfillWindows(start, end, days)
+
+	var mu sync.Mutex
+	var errs []error
+	var g errgroup.Group
+	g.SetLimit(workers)
+	for _, w := range windows {
+		g.Go(func() error {
+			checkpoint := filepath.Join(dir, w.name()+".json")
+			if _, ok := loadCheckpoint(checkpoint); ok {
+				Logger.Infof("Skipping window %s, already downloaded", w.name())
+				return nil
+			}
+			window, err := downloadWindow(APIKey, w)
+			if err == nil {
+				err = utility.WriteFileAtomic(checkpoint, 0644, window.ToJSON)
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("window %s: %w", w.name(), err))
+				mu.Unlock()
+				return nil
+			}
+			Logger.Infof("Downloaded %d CVEs of window %s", window.TotalResults, w.name())
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d windows failed, run the backfill again to resume: %w", len(errs), len(windows), errors.Join(errs...))
+	}
+
+	var merged *cves.CVEAPIJSON20Schema
+	seen := make(map[cves.CVEID]bool)
+	for _, w := range windows {
+		window, ok := loadCheckpoint(filepath.Join(dir, w.name()+".json"))
+		if !ok {
+			return fmt.Errorf("window %s has no complete checkpoint", w.name())
+		}
+		if merged == nil {
+			first := *window
+			merged = &first
+			merged.Vulnerabilities = []cves.Vulnerability{}
+		}
+		for _, v := range window.Vulnerabilities {
+			if !seen[v.CVE.ID] {
+				seen[v.CVE.ID] = true
+				merged.Vulnerabilities = append(merged.Vulnerabilities, v)
+			}
+		}
+	}
+	if merged == nil {
+		return errors.New("no windows to backfill")
+	}
+	merged.StartIndex = 0
+	merged.TotalResults = len(merged.Vulnerabilities)
+	merged.ResultsPerPage = merged.TotalResults
+	filePath := filepath.Join(CVEPath, "nvdcve-2.0.json")
+	if err := utility.WriteFileAtomic(filePath, 0644, merged.ToJSON); err != nil {
+		return err
+	}
+	outputFlags.Add(filePath)
+	outputFlags.Count("cves", merged.TotalResults)
+	return nil
+}
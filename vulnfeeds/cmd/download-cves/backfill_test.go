@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vcr"
+)
+
+// The fixture has the API's pages of two windows: one of 2024-01-03 and
+// 2024-01-04, and one of 2024-02-01 and 2024-02-02 whose total results
+// change between its pages.
+const backfillFixture = "../../test_data/vcr/nvd-backfill.json"
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBackfillWindows(t *testing.T) {
+	got := backfillWindows(date("2024-01-01"), date("2024-09-01"), 120)
+	want := []backfillWindow{
+		{date("2024-01-01"), date("2024-04-30")},
+		{date("2024-04-30"), date("2024-08-28")},
+		{date("2024-08-28"), date("2024-09-01")},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("backfillWindows() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	vcr.Use(t, backfillFixture)
+	pageDelay = 0
+	dir := t.TempDir()
+
+	// The first window is already checkpointed, so isn't downloaded again.
+	checkpoint := `{"resultsPerPage": 1, "startIndex": 0, "totalResults": 1, "format": "NVD_CVE", "version": "2.0",
+		"timestamp": "2024-03-25T05:17:38.960", "vulnerabilities": [{"cve": {"id": "CVE-2024-0001",
+		"published": "2024-01-01T15:15:08.000", "lastModified": "2024-01-09T18:15:08.000",
+		"descriptions": [{"lang": "en", "value": "Example vulnerability 1."}], "references": []}}]}`
+	if err := os.MkdirAll(filepath.Join(dir, backfillDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, backfillDir, "2024-01-01_2024-01-03.json"), []byte(checkpoint), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backfill("", dir, date("2024-01-01"), date("2024-01-05"), 2, 2); err != nil {
+		t.Fatalf("backfill() error: %v", err)
+	}
+
+	got, ok := loadCheckpoint(filepath.Join(dir, "nvdcve-2.0.json"))
+	if !ok {
+		t.Fatalf("backfill() didn't write all the CVEs")
+	}
+	var ids []cves.CVEID
+	for _, v := range got.Vulnerabilities {
+		ids = append(ids, v.CVE.ID)
+	}
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0001", "CVE-2024-0002", "CVE-2024-0003", "CVE-2024-0004"}, ids); diff != "" {
+		t.Errorf("backfill() CVEs mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := loadCheckpoint(filepath.Join(dir, backfillDir, "2024-01-03_2024-01-05.json")); !ok {
+		t.Errorf("backfill() didn't checkpoint the downloaded window")
+	}
+}
+
+func TestBackfillTotalChanged(t *testing.T) {
+	vcr.Use(t, backfillFixture)
+	pageDelay = 0
+	dir := t.TempDir()
+
+	if err := backfill("", dir, date("2024-02-01"), date("2024-02-03"), 2, 1); err == nil {
+		t.Fatalf("backfill() of a window whose total results changed: want error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, backfillDir, "2024-02-01_2024-02-03.json")); !os.IsNotExist(err) {
+		t.Errorf("backfill() checkpointed a window that failed verification")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nvdcve-2.0.json")); !os.IsNotExist(err) {
+		t.Errorf("backfill() wrote the CVEs despite a failed window")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const (
+	NVDHistoryEndpoint = "https://services.nvd.nist.gov/rest/json/cvehistory/2.0"
+	// historyFileName is the file of CVEPath the change history is kept in,
+	// which isn't matched by the nvdcve-2.0-YYYY.json files of CVEs.
+	historyFileName = "nvdcve-2.0-history.json"
+)
+
+// changeParams returns the query parameters of the changes made in the
+// window.
+func (w backfillWindow) changeParams() url.Values {
+	return url.Values{
+		"changeStartDate": {w.Start.UTC().Format(nvdTimeFormat)},
+		"changeEndDate":   {w.End.Add(-time.Millisecond).UTC().Format(nvdTimeFormat)},
+	}
+}
+
+// downloadHistoryPage downloads the page at offset of the CVE changes
+// matching the query parameters params.
+func downloadHistoryPage(APIKey string, params url.Values, offset int) (page *cves.CVEHistoryAPIJSON20Schema, err error) {
+	if err := downloadNVDPage(APIKey, NVDHistoryEndpoint, params, offset, &page); err != nil {
+		return page, err
+	}
+	Logger.Infof("Retrieved offset %d of %d total changes", page.StartIndex, page.TotalResults)
+	return page, nil
+}
+
+// updateHistory records the meaningful changes made to CVEs since the change
+// history in CVEPath was last updated, up to end, starting a new history
+// from start if there's none. The history is written after each window of
+// changes, so an update that fails is resumed by running it again.
+func updateHistory(APIKey string, CVEPath string, start, end time.Time) error {
+	filePath := filepath.Join(CVEPath, historyFileName)
+	history, err := cves.LoadChangeHistory(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		history = cves.NewChangeHistory(start)
+	} else if err != nil {
+		return fmt.Errorf("failed to load %s: %w", filePath, err)
+	}
+	for _, w := range backfillWindows(history.Updated, end, maxWindowDays) {
+		changes := 0
+		for offset := 0; ; {
+			page, err := downloadHistoryPage(APIKey, w.changeParams(), offset)
+			if err != nil {
+				return fmt.Errorf("failed to download the changes of window %s at offset %d: %w", w.name(), offset, err)
+			}
+			history.Record(page.CVEChanges)
+			changes += len(page.CVEChanges)
+			offset += len(page.CVEChanges)
+			if len(page.CVEChanges) == 0 || offset >= page.TotalResults {
+				break
+			}
+			time.Sleep(pageDelay)
+		}
+		history.Updated = w.End
+		if err := utility.WriteFileAtomic(filePath, 0644, history.ToJSON); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		Logger.Infof("Recorded %d changes of window %s", changes, w.name())
+		time.Sleep(pageDelay)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vcr"
+)
+
+// The fixture has the two pages of the changes made on 2024-01-01 and
+// 2024-01-02: an analysis and a CWE remap of CVE-2024-0001, and a new
+// reference of CVE-2024-0002.
+const historyFixture = "../../test_data/vcr/nvd-history.json"
+
+func TestUpdateHistory(t *testing.T) {
+	vcr.Use(t, historyFixture)
+	pageDelay = 0
+	dir := t.TempDir()
+
+	if err := updateHistory("", dir, date("2024-01-01"), date("2024-01-03")); err != nil {
+		t.Fatalf("updateHistory() error: %v", err)
+	}
+	got, err := cves.LoadChangeHistory(filepath.Join(dir, historyFileName))
+	if err != nil {
+		t.Fatalf("updateHistory() didn't write the history: %v", err)
+	}
+	want := &cves.ChangeHistory{
+		Start:   date("2024-01-01"),
+		Updated: date("2024-01-03"),
+		Changed: map[cves.CVEID]time.Time{
+			// The CWE remap isn't a meaningful change.
+			"CVE-2024-0001": time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			"CVE-2024-0002": time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("updateHistory() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Already up to date, so nothing more is downloaded (the fixture has no
+	// other responses).
+	if err := updateHistory("", dir, date("2023-01-01"), date("2024-01-03")); err != nil {
+		t.Errorf("updateHistory() of an up to date history error: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,6 +16,9 @@ import (
 	"time"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/ratelimit"
 	"github.com/google/osv/vulnfeeds/utility"
 	"github.com/sethvargo/go-retry"
 )
@@ -29,24 +33,79 @@ const (
 )
 
 var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
 var apiKey = flag.String("api_key", "", "API key for accessing NVD API 2.0")
 var CVEPath = flag.String("cvePath", CVEPathDefault, "Where to download CVEs to")
+var backfillMode = flag.Bool("backfill", false, "Download every CVE with the 2.0 API in checkpointed windows of publication dates, resuming any earlier backfill to cvePath")
+var backfillStart = flag.String("backfill_start", "1988-01-01", "Publication date (YYYY-MM-DD) to backfill from")
+var backfillWindowDays = flag.Int("backfill_window_days", maxWindowDays, "Days of publication dates in each backfill window")
+var backfillWorkers = flag.Int("backfill_workers", 2, "Number of backfill windows to download concurrently")
+var historyMode = flag.Bool("history", false, "Update the time of the last meaningful change of each CVE in cvePath from the 2.0 change history API, for the converters' -cveHistory")
+var historyStart = flag.String("history_start", "", "Date (YYYY-MM-DD) to start the change history from, if cvePath has none yet (defaults to 120 days ago)")
+
+// pageDelay is the delay between requests for pages of the 2.0 API
+// recommended by NVD, and the initial backoff of retries.
+var pageDelay = 6 * time.Second
 
 func main() {
 	var logCleanup func()
 	Logger, logCleanup = utility.CreateLoggerWrapper("download-cves")
 	defer logCleanup()
 
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
-	if *apiKey != "" {
-		downloadCVE2(*apiKey, *CVEPath)
-	} else {
-		currentYear := time.Now().Year()
-		for i := startingYear; i <= currentYear; i++ {
-			downloadCVE(strconv.Itoa(i), *CVEPath)
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+	switch {
+	case *backfillMode:
+		start, err := time.Parse("2006-01-02", *backfillStart)
+		if err != nil {
+			Logger.Fatalf("Invalid -backfill_start: %v", err)
+		}
+		if err := backfill(*apiKey, *CVEPath, start, time.Now().UTC(), *backfillWindowDays, *backfillWorkers); err != nil {
+			Logger.Fatalf("Failed to backfill CVEs: %v", err)
+		}
+	case *historyMode:
+		now := time.Now().UTC()
+		start := now.AddDate(0, 0, -maxWindowDays)
+		if *historyStart != "" {
+			var err error
+			if start, err = time.Parse("2006-01-02", *historyStart); err != nil {
+				Logger.Fatalf("Invalid -history_start: %v", err)
+			}
+		}
+		if err := updateHistory(*apiKey, *CVEPath, start, now); err != nil {
+			Logger.Fatalf("Failed to update the change history: %v", err)
+		}
+	case *apiKey != "":
+		if err := downloadCVE2(*apiKey, *CVEPath); err != nil {
+			Logger.Fatalf("Failed to download CVEs: %v", err)
+		}
+	default:
+		versions := []string{}
+		for i := startingYear; i <= time.Now().Year(); i++ {
+			versions = append(versions, strconv.Itoa(i))
+		}
+		versions = append(versions, "modified", "recent")
+		for _, version := range versions {
+			if err := downloadCVE(version, *CVEPath); err != nil {
+				Logger.Fatalf("Failed to download CVEs: %v", err)
+			}
 		}
-		downloadCVE("modified", *CVEPath)
-		downloadCVE("recent", *CVEPath)
+	}
+	if err := outputFlags.Finish("download-cves"); err != nil {
+		Logger.Fatalf("Download failed: %v", err)
 	}
 }
 
@@ -55,28 +114,50 @@ func main() {
 // Maintaining the recommended 6 seconds betweens calls is left to the caller.
 // See https://nvd.nist.gov/developers/vulnerabilities
 func downloadCVE2WithOffset(APIKey string, offset int) (page *cves.CVEAPIJSON20Schema, err error) {
-	client := &http.Client{}
-	APIURL, err := url.Parse(NVDAPIEndpoint)
+	return downloadCVE2Page(APIKey, url.Values{}, offset)
+}
+
+// downloadCVE2Page downloads the page at offset of the CVEs matching the
+// query parameters params.
+func downloadCVE2Page(APIKey string, params url.Values, offset int) (page *cves.CVEAPIJSON20Schema, err error) {
+	if err := downloadNVDPage(APIKey, NVDAPIEndpoint, params, offset, &page); err != nil {
+		return page, err
+	}
+	Logger.Infof("Retrieved offset %d of %d total results", page.StartIndex, page.TotalResults)
+	return page, nil
+}
+
+// downloadNVDPage downloads the page at offset of the results of an NVD 2.0
+// API endpoint matching the query parameters params, decoding it into page.
+func downloadNVDPage(APIKey string, endpoint string, params url.Values, offset int, page any) error {
+	client := http.DefaultClient
+	APIURL, err := url.Parse(endpoint)
 	if err != nil {
-		return page, fmt.Errorf("failed to parse %s: %+v", NVDAPIEndpoint, err)
+		return fmt.Errorf("failed to parse %s: %+v", endpoint, err)
 	}
-	params := url.Values{}
+	params = maps.Clone(params)
 	if offset > 0 {
 		params.Add("startIndex", strconv.Itoa(offset))
 	}
 	APIURL.RawQuery = params.Encode()
 	req, err := http.NewRequest("GET", fmt.Sprint(APIURL), nil)
 	if err != nil {
-		return page, fmt.Errorf("request creation for %q failed: %+v", APIURL, err)
+		return fmt.Errorf("request creation for %q failed: %+v", APIURL, err)
 	}
 	if APIKey != "" {
 		req.Header.Add("apiKey", APIKey)
 	}
-	backoff := retry.NewExponential(6 * time.Second)
+	attempt := 0
+	backoff := retry.NewExponential(max(pageDelay, time.Millisecond))
 	if err := retry.Do(context.Background(), retry.WithMaxRetries(3, backoff), func(ctx context.Context) error {
+		if attempt > 0 {
+			httpclient.Retried(APIURL.String())
+		}
+		attempt++
 		resp, err := client.Do(req)
 		if err != nil {
-			return nil
+			Logger.Warnf("Failed to request %q: %+v, retrying", APIURL, err)
+			return retry.RetryableError(err)
 		}
 		defer resp.Body.Close()
 
@@ -92,7 +173,7 @@ func downloadCVE2WithOffset(APIKey string, offset int) (page *cves.CVEAPIJSON20S
 				Logger.Warnf("Error reading response body for request for %q: %+v, retrying", resp.Request.URL, err)
 				return retry.RetryableError(fmt.Errorf("error reading response body for request for %q: %q", resp.Request.URL, resp.Status))
 			}
-			err = json.Unmarshal(body, &page)
+			err = json.Unmarshal(body, page)
 			if err != nil {
 				Logger.Warnf("Failed to decode NVD data: %q", err)
 				return fmt.Errorf("failed to decode NVD data from %q: %+v", resp.Request.URL, err)
@@ -101,18 +182,17 @@ func downloadCVE2WithOffset(APIKey string, offset int) (page *cves.CVEAPIJSON20S
 		}
 	}); err != nil {
 		Logger.Warnf("Unable to retrieve %q: %v", APIURL, err)
-		return page, fmt.Errorf("unable to retrieve %q: %v", APIURL, err)
+		return fmt.Errorf("unable to retrieve %q: %v", APIURL, err)
 	}
-	Logger.Infof("Retrieved offset %d of %d total results", page.StartIndex, page.TotalResults)
-	return page, nil
+	return nil
 }
 
 // Download all of the CVE data using the 2.0 API
 // See https://nvd.nist.gov/developers/vulnerabilities
-func downloadCVE2(APIKey string, CVEPath string) {
+func downloadCVE2(APIKey string, CVEPath string) error {
 	file, err := os.OpenFile(path.Join(CVEPath, "nvdcve-2.0.json.new"), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
 	if err != nil { // There's an existing file, check if it matches server file
-		Logger.Fatalf("Something went wrong when creating/opening file: %+v", err)
+		return fmt.Errorf("something went wrong when creating/opening file: %w", err)
 	}
 	defer file.Close()
 	var vulnerabilities []cves.Vulnerability
@@ -121,14 +201,14 @@ func downloadCVE2(APIKey string, CVEPath string) {
 	for {
 		page, err = downloadCVE2WithOffset(APIKey, offset)
 		if err != nil {
-			Logger.Fatalf("Failed to download at offset %d: %+v", offset, err)
+			return fmt.Errorf("failed to download at offset %d: %w", offset, err)
 		}
 		vulnerabilities = append(vulnerabilities, page.Vulnerabilities...)
 		offset += PageSize
 		if offset > page.TotalResults {
 			break
 		}
-		time.Sleep(6 * time.Second)
+		time.Sleep(pageDelay)
 	}
 	// Make this look like one giant page of results from the API call
 	page.Vulnerabilities = vulnerabilities
@@ -136,39 +216,45 @@ func downloadCVE2(APIKey string, CVEPath string) {
 	page.ResultsPerPage = page.TotalResults
 	err = page.ToJSON(file)
 	if err != nil {
-		Logger.Fatalf("Failed to write %s: %+v", path.Join(CVEPath, "nvdcve-2.0.json.new"), err)
+		return fmt.Errorf("failed to write %s: %w", path.Join(CVEPath, "nvdcve-2.0.json.new"), err)
 	}
 	file.Close()
 	err = os.Rename(path.Join(CVEPath, "nvdcve-2.0.json.new"), path.Join(CVEPath, "nvdcve-2.0.json"))
 	if err != nil {
-		Logger.Fatalf("Failed to rename temporary file: %+v", err)
+		return fmt.Errorf("failed to rename temporary file: %w", err)
 	}
+	outputFlags.Add(path.Join(CVEPath, "nvdcve-2.0.json"))
+	outputFlags.Count("cves", len(vulnerabilities))
+	return nil
 }
 
-func downloadCVE(version string, CVEPath string) {
+func downloadCVE(version string, CVEPath string) error {
 	file, err := os.OpenFile(path.Join(CVEPath, fileNameBase+version+".json"), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
 	if err != nil { // There's an existing file, check if it matches server file
-		Logger.Fatalf("Something went wrong when creating/opening file %s, %s", version, err)
+		return fmt.Errorf("something went wrong when creating/opening file %s: %w", version, err)
 	}
 	defer file.Close()
 
 	res, err := http.Get(CVEURLBase + fileNameBase + version + ".json.gz")
 	if err != nil {
-		Logger.Fatalf("Failed to retrieve cve json with: %d, for version: %s", err, version)
+		return fmt.Errorf("failed to retrieve cve json for version %s: %w", version, err)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
-		Logger.Fatalf("Failed to retrieve cve json with: %d, for version: %s", res.StatusCode, version)
+		return fmt.Errorf("failed to retrieve cve json with: %d, for version: %s", res.StatusCode, version)
 	}
 
 	reader, err := gzip.NewReader(res.Body)
 	if err != nil {
-		Logger.Fatalf("Failed to create gzip reader: %s", err)
+		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 
 	if _, err := io.Copy(file, reader); err != nil {
-		Logger.Fatalf("Failed to write to file %s: %s", version, err)
+		return fmt.Errorf("failed to write to file %s: %w", version, err)
 	}
+	outputFlags.Add(path.Join(CVEPath, fileNameBase+version+".json"))
 	Logger.Infof(
 		"Successfully downloaded CVE %s\n", version)
+	return nil
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vcr"
+)
+
+// The API is replayed from a fixture, whose second page fails once before
+// succeeding; set VULNFEEDS_VCR_RECORD to record it again from NVD.
+const nvdFixture = "../../test_data/vcr/nvd-api.json"
+
+func TestDownloadCVE2(t *testing.T) {
+	vcr.Use(t, nvdFixture)
+	pageDelay = 0
+	dir := t.TempDir()
+
+	if err := downloadCVE2("", dir); err != nil {
+		t.Fatalf("downloadCVE2() error: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "nvdcve-2.0.json"))
+	if err != nil {
+		t.Fatalf("downloadCVE2() didn't write the CVEs: %v", err)
+	}
+	defer f.Close()
+	var got cves.CVEAPIJSON20Schema
+	if err := json.NewDecoder(f).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	var ids []cves.CVEID
+	for _, v := range got.Vulnerabilities {
+		ids = append(ids, v.CVE.ID)
+	}
+	if diff := gocmp.Diff([]cves.CVEID{"CVE-2024-0001", "CVE-2024-0002", "CVE-2024-0003"}, ids); diff != "" {
+		t.Errorf("downloadCVE2() CVEs mismatch (-want +got):\n%s", diff)
+	}
+	if got.StartIndex != 0 || got.ResultsPerPage != got.TotalResults {
+		t.Errorf("downloadCVE2() = start index %d and %d results per page of %d, want a single page", got.StartIndex, got.ResultsPerPage, got.TotalResults)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nvdcve-2.0.json.new")); !os.IsNotExist(err) {
+		t.Errorf("downloadCVE2() left its temporary file behind")
+	}
+}
+
+func TestDownloadCVE2WithOffsetUnavailable(t *testing.T) {
+	vcr.Use(t, nvdFixture)
+	pageDelay = 0
+
+	// An offset the fixture has no response for fails like an unreachable
+	// API, after retrying.
+	if _, err := downloadCVE2WithOffset("", 4000); err == nil {
+		t.Errorf("downloadCVE2WithOffset() of an unavailable page: want error")
+	}
+}
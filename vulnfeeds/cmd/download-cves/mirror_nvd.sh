@@ -34,6 +34,11 @@ mkdir -p "${WORK_DIR}/nvd"
 APIKEY="$(gcloud --project "$GOOGLE_CLOUD_PROJECT" secrets versions access latest --secret=nvd-api --format='get(payload.data)' | base64 -d)"
 /usr/local/bin/download-cves --api_key "$APIKEY" --cvePath "${WORK_DIR}/nvd"
 
+echo "Updating the change history of the NVD"
+gcloud --no-user-output-enabled storage -q cp "gs://${BUCKET}/nvd/nvdcve-2.0-history.json" "${WORK_DIR}/nvd/" \
+  || echo "No change history yet, starting one"
+/usr/local/bin/download-cves --api_key "$APIKEY" --cvePath "${WORK_DIR}/nvd" --history
+
 echo "Splitting monolithic file into years"
 for (( YEAR = 2002 ; YEAR <= $(date +%Y) ; YEAR++ ))
 do
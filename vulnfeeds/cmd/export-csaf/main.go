@@ -0,0 +1,115 @@
+// export-csaf converts a directory of OSV records into CSAF 2.0 security advisories.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/csaf"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	defaultOSVPath    = "osv_output"
+	defaultCSAFOutput = "csaf_output"
+	// The index of a CSAF directory-based distribution.
+	indexFileName = "index.txt"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("export-csaf")
+	defer logCleanup()
+
+	osvPath := flag.String("osvPath", defaultOSVPath, "Path to a directory of OSV records")
+	csafOutput := flag.String("csafOutput", defaultCSAFOutput, "Path to write the CSAF advisories to")
+	publisherName := flag.String("publisherName", csaf.DefaultPublisher.Name, "Name of the publisher of the advisories")
+	publisherNamespace := flag.String("publisherNamespace", csaf.DefaultPublisher.Namespace, "Namespace (URL) of the publisher of the advisories")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	if err := os.MkdirAll(*csafOutput, 0755); err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+	publisher := csaf.DefaultPublisher
+	publisher.Name = *publisherName
+	publisher.Namespace = *publisherNamespace
+
+	fileNames, err := exportDir(*osvPath, *csafOutput, publisher)
+	if err != nil {
+		Logger.Fatalf("Failed to export CSAF advisories: %s", err)
+	}
+	if err := writeIndex(*csafOutput, fileNames); err != nil {
+		Logger.Fatalf("Failed to write %s: %s", indexFileName, err)
+	}
+	Logger.Infof("Exported %d CSAF advisories", len(fileNames))
+}
+
+// exportDir converts every OSV record in osvPath into a CSAF advisory in
+// csafOutput, returning the file names written. Records that can't be
+// converted are skipped with a warning.
+func exportDir(osvPath string, csafOutput string, publisher csaf.Publisher) ([]string, error) {
+	dir, err := os.ReadDir(osvPath)
+	if err != nil {
+		return nil, err
+	}
+	var fileNames []string
+	for _, entry := range dir {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(path.Join(osvPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			Logger.Warnf("Failed to decode %s: %s", entry.Name(), err)
+			continue
+		}
+		doc, err := csaf.FromOSV(&v, publisher)
+		if err != nil {
+			Logger.Warnf("Failed to convert %s: %s", entry.Name(), err)
+			continue
+		}
+		fileName := csaf.FileName(v.ID)
+		file, err := os.Create(path.Join(csafOutput, fileName))
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			file.Close()
+			return nil, err
+		}
+		if err := file.Close(); err != nil {
+			return nil, err
+		}
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
+}
+
+// writeIndex writes the index.txt listing the advisories, as expected of a
+// CSAF directory-based distribution.
+func writeIndex(csafOutput string, fileNames []string) error {
+	var b strings.Builder
+	for _, fileName := range fileNames {
+		b.WriteString(fileName + "\n")
+	}
+	return os.WriteFile(path.Join(csafOutput, indexFileName), []byte(b.String()), 0644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/csaf"
+)
+
+func TestExportDir(t *testing.T) {
+	osvPath := t.TempDir()
+	csafOutput := t.TempDir()
+	records := map[string]string{
+		"CVE-2024-1234.json":    `{"id": "CVE-2024-1234", "details": "x", "published": "2024-01-01T00:00:00Z", "modified": "2024-01-01T00:00:00Z", "affected": [{"package": {"name": "curl", "ecosystem": "Debian:12"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "8.0"}]}]}]}`,
+		"CVE-2024-5678.json":    `{"id": "CVE-2024-5678", "details": "no timestamps"}`,
+		"modified-since-1h.txt": "CVE-2024-1234\n",
+	}
+	for name, content := range records {
+		if err := os.WriteFile(path.Join(osvPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fileNames, err := exportDir(osvPath, csafOutput, csaf.DefaultPublisher)
+	if err != nil {
+		t.Fatalf("exportDir() error: %v", err)
+	}
+	if diff := gocmp.Diff([]string{"cve-2024-1234.json"}, fileNames); diff != "" {
+		t.Errorf("exportDir() mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := os.ReadFile(path.Join(csafOutput, "cve-2024-1234.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc csaf.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode the advisory: %v", err)
+	}
+	if doc.Document.Tracking.ID != "CVE-2024-1234" || doc.Vulnerabilities[0].CVE != "CVE-2024-1234" {
+		t.Errorf("exportDir() wrote %+v", doc)
+	}
+
+	if err := writeIndex(csafOutput, fileNames); err != nil {
+		t.Fatalf("writeIndex() error: %v", err)
+	}
+	index, err := os.ReadFile(path.Join(csafOutput, indexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(index) != "cve-2024-1234.json\n" {
+		t.Errorf("index.txt = %q", index)
+	}
+}
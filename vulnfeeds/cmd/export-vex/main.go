@@ -0,0 +1,124 @@
+// export-vex emits VEX statements for the components of an SBOM from a directory of OSV records.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vex"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	defaultOSVPath   = "osv_output"
+	defaultVEXOutput = "vex.json"
+	defaultAuthor    = "OSV"
+	formatOpenVEX    = "openvex"
+	formatCycloneDX  = "cyclonedx"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("export-vex")
+	defer logCleanup()
+
+	osvPath := flag.String("osvPath", defaultOSVPath, "Path to a directory of OSV records")
+	sbomPath := flag.String("sbom", "", "Path to a CycloneDX or SPDX JSON SBOM")
+	vexOutput := flag.String("vexOutput", defaultVEXOutput, "Path to write the VEX document to")
+	format := flag.String("format", formatOpenVEX, "Format of the VEX document: openvex or cyclonedx")
+	author := flag.String("author", defaultAuthor, "Author of the OpenVEX document")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	if *sbomPath == "" {
+		Logger.Fatalf("-sbom is required")
+	}
+	data, err := os.ReadFile(*sbomPath)
+	if err != nil {
+		Logger.Fatalf("Failed to read SBOM: %s", err)
+	}
+	components, err := vex.ParseSBOM(data)
+	if err != nil {
+		Logger.Fatalf("Failed to parse SBOM %s: %s", *sbomPath, err)
+	}
+	records, err := loadRecords(*osvPath)
+	if err != nil {
+		Logger.Fatalf("Failed to load OSV records: %s", err)
+	}
+	statements, err := vex.Match(records, components)
+	if err != nil {
+		Logger.Warnf("Some components couldn't be matched: %s", err)
+	}
+	doc, err := vexDocument(statements, *format, *author, time.Now())
+	if err != nil {
+		Logger.Fatalf("%s", err)
+	}
+	if err := writeJSON(*vexOutput, doc); err != nil {
+		Logger.Fatalf("Failed to write %s: %s", *vexOutput, err)
+	}
+	Logger.Infof("Wrote %d VEX statements for %d components", len(statements), len(components))
+}
+
+// loadRecords loads the OSV records in osvPath. Records that can't be
+// decoded are skipped with a warning.
+func loadRecords(osvPath string) ([]*vulns.Vulnerability, error) {
+	dir, err := os.ReadDir(osvPath)
+	if err != nil {
+		return nil, err
+	}
+	var records []*vulns.Vulnerability
+	for _, entry := range dir {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(path.Join(osvPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			Logger.Warnf("Failed to decode %s: %s", entry.Name(), err)
+			continue
+		}
+		records = append(records, &v)
+	}
+	return records, nil
+}
+
+// vexDocument returns statements as a VEX document in format.
+func vexDocument(statements []vex.Statement, format string, author string, now time.Time) (any, error) {
+	switch format {
+	case formatOpenVEX:
+		id := fmt.Sprintf("https://osv.dev/vex/%d", now.Unix())
+		return vex.ToOpenVEX(statements, id, author, now), nil
+	case formatCycloneDX:
+		return vex.ToCycloneDX(statements, now), nil
+	}
+	return nil, fmt.Errorf("unknown VEX format %q", format)
+}
+
+func writeJSON(fileName string, v any) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
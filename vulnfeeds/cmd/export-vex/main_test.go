@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/vex"
+)
+
+func TestExportVEX(t *testing.T) {
+	data, err := os.ReadFile("../../test_data/vex/spdx.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	components, err := vex.ParseSBOM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := loadRecords("../../test_data/vex/osv")
+	if err != nil {
+		t.Fatalf("loadRecords() error: %v", err)
+	}
+	statements, err := vex.Match(records, components)
+	if err != nil {
+		t.Fatalf("Match() error: %v", err)
+	}
+	doc, err := vexDocument(statements, formatOpenVEX, defaultAuthor, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("vexDocument() error: %v", err)
+	}
+	want := []vex.OpenVEXStatement{
+		{
+			Vulnerability:   vex.OpenVEXVulnerability{Name: "CVE-2024-2222"},
+			Products:        []vex.OpenVEXProduct{{ID: "pkg:golang/github.com/gin-gonic/gin"}},
+			Status:          vex.Affected,
+			ActionStatement: "Upgrade to a fixed version",
+		},
+		{
+			Vulnerability: vex.OpenVEXVulnerability{Name: "PYSEC-2024-1", Aliases: []string{"CVE-2024-1111"}},
+			Products:      []vex.OpenVEXProduct{{ID: "pkg:pypi/requests@2.31.0"}},
+			Status:        vex.Fixed,
+		},
+	}
+	if diff := gocmp.Diff(want, doc.(vex.OpenVEXDocument).Statements); diff != "" {
+		t.Errorf("vexDocument() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := vexDocument(statements, "csaf", defaultAuthor, time.Now()); err == nil {
+		t.Errorf("vexDocument() with an unknown format succeeded")
+	}
+}
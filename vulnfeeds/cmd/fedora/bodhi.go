@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	bodhiURLBase = "https://bodhi.fedoraproject.org"
+	// bodhiPageSize is the maximum page size Bodhi allows.
+	bodhiPageSize = 100
+	// fedoraIDPrefix is the update ID prefix of Fedora releases, as opposed
+	// to EPEL, Flatpak and container releases.
+	fedoraIDPrefix = "FEDORA"
+)
+
+// BodhiPage is a page of Bodhi's updates API.
+type BodhiPage struct {
+	Updates []BodhiUpdate `json:"updates"`
+	Page    int           `json:"page"`
+	Pages   int           `json:"pages"`
+}
+
+// BodhiUpdate is the subset of a Fedora update needed to determine the fixed
+// source package versions of each CVE.
+type BodhiUpdate struct {
+	Alias    string `json:"alias"`
+	Title    string `json:"title"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Severity string `json:"severity"`
+	Notes    string `json:"notes"`
+	Release  struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		IDPrefix string `json:"id_prefix"`
+	} `json:"release"`
+	Builds []struct {
+		NVR   string `json:"nvr"`
+		Epoch int    `json:"epoch"`
+		Type  string `json:"type"`
+	} `json:"builds"`
+	Bugs []struct {
+		Title    string `json:"title"`
+		Security bool   `json:"security"`
+	} `json:"bugs"`
+}
+
+// CVEs returns the CVEs an update fixes. Bodhi has no CVE field, so they're
+// taken from the titles of the security bugs the update closes, which the
+// Product Security team names after the CVE, and the update notes.
+func (u BodhiUpdate) CVEs() []string {
+	found := make(map[string]bool)
+	for _, bug := range u.Bugs {
+		if !bug.Security {
+			continue
+		}
+		for _, id := range cveIDPattern.FindAllString(bug.Title, -1) {
+			found[id] = true
+		}
+	}
+	for _, id := range cveIDPattern.FindAllString(u.Notes, -1) {
+		found[id] = true
+	}
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// bodhiUpdatesURL returns the URL of a page of stable security updates.
+func bodhiUpdatesURL(base string, releases []string, page int) string {
+	query := url.Values{
+		"type":          {"security"},
+		"status":        {"stable"},
+		"rows_per_page": {strconv.Itoa(bodhiPageSize)},
+		"page":          {strconv.Itoa(page)},
+	}
+	if len(releases) > 0 {
+		query["releases"] = releases
+	}
+	return base + "/updates/?" + query.Encode()
+}
+
+// parseBodhiPage decodes a page of Bodhi's updates API.
+func parseBodhiPage(r io.Reader) (BodhiPage, error) {
+	var page BodhiPage
+	err := json.NewDecoder(r).Decode(&page)
+	return page, err
+}
+
+// downloadBodhiUpdates downloads all stable security updates of the given
+// Bodhi releases, or of all releases if none are given.
+func downloadBodhiUpdates(base string, releases []string) ([]BodhiUpdate, error) {
+	var updates []BodhiUpdate
+	for pageNumber := 1; ; pageNumber++ {
+		res, err := faulttolerant.Get(bodhiUpdatesURL(base, releases, pageNumber))
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
+		}
+		page, err := parseBodhiPage(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse page %d of updates: %w", pageNumber, err)
+		}
+		updates = append(updates, page.Updates...)
+		if pageNumber >= page.Pages {
+			break
+		}
+	}
+	Logger.Infof("Downloaded %d Fedora security updates", len(updates))
+	return updates, nil
+}
+
+// bodhiSeverity returns the severity of an update, if it was specified.
+func bodhiSeverity(severity string) string {
+	if severity == "" || severity == "unspecified" {
+		return ""
+	}
+	return strings.ToUpper(severity[:1]) + severity[1:]
+}
+
+// generateFedoraOSV converts Fedora updates to OSV PackageInfo format, with
+// one fixed version per source package and Fedora release. Bodhi doesn't say
+// which build of an update fixes which CVE, so every build of an update is
+// attributed all its CVEs.
+func generateFedoraOSV(updates []BodhiUpdate) map[string][]vulns.PackageInfo {
+	Logger.Infof("Converting %d Fedora updates to OSV package infos.", len(updates))
+
+	cveFixes := make(rpm.Fixes)
+	for _, update := range updates {
+		if update.Release.IDPrefix != fedoraIDPrefix || update.Type != "security" || update.Status != "stable" {
+			continue
+		}
+		cveIDs := update.CVEs()
+		if len(cveIDs) == 0 {
+			continue
+		}
+		for _, build := range update.Builds {
+			if build.Type != "rpm" {
+				continue
+			}
+			name, version, ok := rpm.SplitSourceRPM(build.NVR)
+			if !ok {
+				Logger.Warnf("Invalid build NVR %q in %s", build.NVR, update.Alias)
+				continue
+			}
+			evr, ok := rpm.ParseEVR(version)
+			if !ok {
+				Logger.Warnf("Invalid build NVR %q in %s", build.NVR, update.Alias)
+				continue
+			}
+			evr.Epoch = build.Epoch
+			key := rpm.PackageKey{Ecosystem: fedoraEcosystem + ":" + update.Release.Version, Name: name}
+			for _, cveID := range cveIDs {
+				cveFixes.Add(cveID, key, rpm.Fix{EVR: evr, Advisory: update.Alias, Severity: bodhiSeverity(update.Severity)})
+			}
+		}
+	}
+
+	return cveFixes.PackageInfos(func(key rpm.PackageKey) string {
+		release := strings.TrimPrefix(key.Ecosystem, fedoraEcosystem+":")
+		return purl.RPM("fedora", key.Name, "fedora-"+release)
+	})
+}
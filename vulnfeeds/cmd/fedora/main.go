@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	fedoraOutputPathDefault = "parts/fedora"
+	centOSOutputPathDefault = "parts/centos"
+	fedoraEcosystem         = "Fedora"
+	centOSEcosystem         = "CentOS"
+	// centOSReposDefault are the CentOS Stream repositories whose updateinfo
+	// advisories are converted, as release=repository URL.
+	centOSReposDefault = "9-stream=https://mirror.stream.centos.org/9-stream/BaseOS/x86_64/os/," +
+		"9-stream=https://mirror.stream.centos.org/9-stream/AppStream/x86_64/os/," +
+		"10-stream=https://mirror.stream.centos.org/10-stream/BaseOS/x86_64/os/," +
+		"10-stream=https://mirror.stream.centos.org/10-stream/AppStream/x86_64/os/"
+)
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+// cveIDPattern matches the CVE IDs mentioned in advisory texts.
+var cveIDPattern = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("fedora-osv")
+	defer logCleanup()
+
+	fedoraOutputPath := flag.String("fedoraOutput", fedoraOutputPathDefault, "path to output general Fedora affected package information")
+	centOSOutputPath := flag.String("centosOutput", centOSOutputPathDefault, "path to output general CentOS Stream affected package information")
+	bodhiReleases := flag.String("bodhiReleases", "", "Comma separated Bodhi releases to convert, e.g. F40,F41 (defaults to all Fedora releases)")
+	centOSRepos := flag.String("centosRepos", centOSReposDefault, "Comma separated release=repository URL CentOS Stream repositories to convert the updateinfo of")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	for _, outputPath := range []string{*fedoraOutputPath, *centOSOutputPath} {
+		if err := os.MkdirAll(outputPath, 0755); err != nil {
+			Logger.Fatalf("Can't create output path: %s", err)
+		}
+	}
+
+	updates, err := downloadBodhiUpdates(bodhiURLBase, utility.SplitList(*bodhiReleases))
+	if err != nil {
+		Logger.Fatalf("Failed to get Fedora updates from Bodhi: %s", err)
+	}
+	if err := writeToOutput(generateFedoraOSV(updates), *fedoraOutputPath, "fedora"); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+
+	repos, err := parseRepos(*centOSRepos)
+	if err != nil {
+		Logger.Fatalf("Invalid -centosRepos: %s", err)
+	}
+	var advisories []releaseUpdateInfo
+	for _, repo := range repos {
+		updateInfo, err := rpm.DownloadUpdateInfo(repo.url)
+		if err != nil {
+			Logger.Warnf("Failed to get the updateinfo of %s: %s", repo.url, err)
+			continue
+		}
+		advisories = append(advisories, releaseUpdateInfo{release: repo.release, updateInfo: updateInfo})
+	}
+	if err := writeToOutput(generateCentOSOSV(advisories), *centOSOutputPath, "centos"); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+	if err := outputFlags.Finish("fedora-osv"); err != nil {
+		Logger.Fatalf("Fedora and CentOS Stream conversion failed: %s", err)
+	}
+
+	Logger.Infof("Fedora and CentOS Stream conversion succeeded.")
+}
+
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, outputPath string, feed string) error {
+	Logger.Infof("Writing %s package infos to the output.", feed)
+	for cveId, pkgInfos := range cvePkgInfos {
+		filePath := path.Join(outputPath, cveId+"."+feed+".json")
+		if err := parts.Write(filePath, pkgInfos); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(pkgInfos))
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func Test_generateFedoraOSV(t *testing.T) {
+	file, err := os.Open("../../test_data/fedora/bodhi-updates.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	page, err := parseBodhiPage(file)
+	if err != nil {
+		t.Fatalf("parseBodhiPage() error: %v", err)
+	}
+
+	got := generateFedoraOSV(page.Updates)
+	// Bodhi doesn't say which build of an update fixes which CVE, so all
+	// builds are attributed all of the update's CVEs.
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2023-2650": {
+			{
+				PkgName:   "curl",
+				Ecosystem: "Fedora:37",
+				PURL:      "pkg:rpm/fedora/curl?distro=fedora-37",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "7.85.0-10.fc37"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-9f8e7d6c5b"},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:37",
+				PURL:      "pkg:rpm/fedora/openssl?distro=fedora-37",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:3.0.9-2.fc37"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-9f8e7d6c5b"},
+			},
+			{
+				// The earlier of the two updates fixing the CVE wins.
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/openssl?distro=fedora-38",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:3.0.8-1.fc38"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-1a2b3c4d5e", "severity": "Medium"},
+			},
+		},
+		"CVE-2023-28320": {
+			{
+				PkgName:   "curl",
+				Ecosystem: "Fedora:37",
+				PURL:      "pkg:rpm/fedora/curl?distro=fedora-37",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "7.85.0-10.fc37"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-9f8e7d6c5b"},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:37",
+				PURL:      "pkg:rpm/fedora/openssl?distro=fedora-37",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:3.0.9-2.fc37"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-9f8e7d6c5b"},
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("generateFedoraOSV() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_downloadBodhiUpdates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query()["releases"]; len(got) != 2 {
+			t.Errorf("releases = %v, want F37 and F38", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"updates": [{"alias": "FEDORA-2023-1"}], "page": 1, "pages": 2}`))
+		case "2":
+			w.Write([]byte(`{"updates": [{"alias": "FEDORA-2023-2"}], "page": 2, "pages": 2}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	updates, err := downloadBodhiUpdates(srv.URL, []string{"F37", "F38"})
+	if err != nil {
+		t.Fatalf("downloadBodhiUpdates() error: %v", err)
+	}
+	if len(updates) != 2 || updates[0].Alias != "FEDORA-2023-1" || updates[1].Alias != "FEDORA-2023-2" {
+		t.Errorf("downloadBodhiUpdates() = %+v, want both pages of updates", updates)
+	}
+}
+
+func Test_downloadUpdateInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/9-stream/BaseOS/repodata/repomd.xml":
+			http.ServeFile(w, r, "../../test_data/fedora/repomd.xml")
+		case "/9-stream/BaseOS/repodata/2c3d-updateinfo.xml.gz":
+			data, err := os.ReadFile("../../test_data/fedora/updateinfo.xml")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			gz := gzip.NewWriter(w)
+			gz.Write(data)
+			gz.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	repos, err := parseRepos("9-stream=" + srv.URL + "/9-stream/BaseOS")
+	if err != nil {
+		t.Fatalf("parseRepos() error: %v", err)
+	}
+	updateInfo, err := rpm.DownloadUpdateInfo(repos[0].url)
+	if err != nil {
+		t.Fatalf("downloadUpdateInfo() error: %v", err)
+	}
+
+	got := generateCentOSOSV([]releaseUpdateInfo{{release: repos[0].release, updateInfo: updateInfo}})
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2023-2650": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "CentOS:9-stream",
+				PURL:      "pkg:rpm/centos/openssl?distro=centos-9-stream",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:3.0.7-16.el9"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "CESA-2023:3722", "severity": "Important"},
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("generateCentOSOSV() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_parseRepos(t *testing.T) {
+	for _, invalid := range []string{"9-stream", "=https://example.com/", "9-stream="} {
+		if _, err := parseRepos(invalid); err == nil {
+			t.Errorf("parseRepos(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+#!/bin/bash
+
+## Converts Fedora updates and CentOS Stream advisories into general affected package information
+## Then uploads the results to google cloud store.
+##
+## This script is intended to be the entrypoint of the docker image.
+## with the working directory being the root of the repository
+
+set -e
+
+FEDORA_PARTS_OUTPUT="parts/fedora"
+CENTOS_PARTS_OUTPUT="parts/centos"
+OUTPUT_BUCKET="${OUTPUT_GCS_BUCKET:=cve-osv-conversion}"
+
+echo "Setup initial directories ${FEDORA_PARTS_OUTPUT} ${CENTOS_PARTS_OUTPUT}"
+rm -rf $FEDORA_PARTS_OUTPUT && mkdir -p $FEDORA_PARTS_OUTPUT
+rm -rf $CENTOS_PARTS_OUTPUT && mkdir -p $CENTOS_PARTS_OUTPUT
+
+./fedora-osv -fedoraOutput "$FEDORA_PARTS_OUTPUT" -centosOutput "$CENTOS_PARTS_OUTPUT"
+echo "Begin Syncing with cloud, GCS bucket: ${OUTPUT_BUCKET}"
+gsutil -q -m rsync -c -d $FEDORA_PARTS_OUTPUT "gs://$OUTPUT_BUCKET/$FEDORA_PARTS_OUTPUT"
+gsutil -q -m rsync -c -d $CENTOS_PARTS_OUTPUT "gs://$OUTPUT_BUCKET/$CENTOS_PARTS_OUTPUT"
+echo "Successfully synced with cloud"
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// repo is a repository of a CentOS Stream release.
+type repo struct {
+	release string
+	url     string
+}
+
+// parseRepos parses comma separated release=repository URL repositories.
+func parseRepos(s string) ([]repo, error) {
+	var repos []repo
+	for _, item := range utility.SplitList(s) {
+		release, repoURL, ok := strings.Cut(item, "=")
+		if !ok || release == "" || repoURL == "" {
+			return nil, fmt.Errorf("invalid repository %q, want release=URL", item)
+		}
+		if !strings.HasSuffix(repoURL, "/") {
+			repoURL += "/"
+		}
+		repos = append(repos, repo{release: release, url: repoURL})
+	}
+	return repos, nil
+}
+
+// releaseUpdateInfo is the updateinfo of a repository of a release.
+type releaseUpdateInfo struct {
+	release    string
+	updateInfo rpm.UpdateInfo
+}
+
+// generateCentOSOSV converts CentOS Stream updateinfo advisories to OSV
+// PackageInfo format, with one fixed version per source package and CentOS
+// Stream release.
+func generateCentOSOSV(advisories []releaseUpdateInfo) map[string][]vulns.PackageInfo {
+	cveFixes := make(rpm.Fixes)
+	for _, advisory := range advisories {
+		Logger.Infof("Converting %d CentOS %s advisories to OSV package infos.", len(advisory.updateInfo.Updates), advisory.release)
+		for _, update := range advisory.updateInfo.Updates {
+			if update.Type != "security" {
+				continue
+			}
+			cveIDs := update.CVEs()
+			if len(cveIDs) == 0 {
+				continue
+			}
+			for _, pkg := range update.Packages {
+				// The binary packages of an advisory share their source
+				// package's version-release.
+				name, _, ok := rpm.SplitSourceRPM(pkg.Src)
+				if !ok {
+					Logger.Warnf("Invalid source RPM %q in %s", pkg.Src, update.ID)
+					continue
+				}
+				evr, err := pkg.EVR()
+				if err != nil {
+					Logger.Warnf("%s in %s", err, update.ID)
+					continue
+				}
+				key := rpm.PackageKey{Ecosystem: centOSEcosystem + ":" + advisory.release, Name: name}
+				for _, cveID := range cveIDs {
+					cveFixes.Add(cveID, key, rpm.Fix{EVR: evr, Advisory: update.ID, Severity: update.Severity})
+				}
+			}
+		}
+	}
+
+	return cveFixes.PackageInfos(func(key rpm.PackageKey) string {
+		release := strings.TrimPrefix(key.Ecosystem, centOSEcosystem+":")
+		return purl.RPM("centos", key.Name, "centos-"+release)
+	})
+}
@@ -0,0 +1,101 @@
+// gcs-upload publishes a directory of records to a GCS prefix, uploading only
+// the records that changed, with bounded concurrency and rate, retrying
+// transient failures, verifying the CRC32C checksum of each upload, and
+// recording completed uploads in a manifest so a run cut short by the job's
+// timeout resumes where it left off.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+
+	"github.com/google/osv/vulnfeeds/upload"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("gcs-upload")
+	defer logCleanup()
+
+	source := flag.String("source", "", "Directory of the records to upload")
+	destination := flag.String("destination", "", "gs://bucket/prefix to upload the records to")
+	concurrency := flag.Int("concurrency", 32, "Maximum number of uploads in flight")
+	requestsPerSecond := flag.Float64("rate", 200, "Maximum uploads and deletions per second, 0 for no limit")
+	retries := flag.Uint64("retries", 5, "Times a failed upload is retried, with an exponential backoff")
+	manifestPath := flag.String("manifest", "", "File to record completed uploads in, so an interrupted run resumes where it left off")
+	deleteExtraneous := flag.Bool("deleteExtraneous", false, "Delete objects under the prefix without a record in -source, like rsync -d")
+	maxDeleteFraction := flag.Float64("maxDeleteFraction", 0.1, "With -deleteExtraneous, refuse to delete more than this fraction of the objects under the prefix, 0 for no limit")
+	cacheControl := flag.String("cacheControl", "no-cache", "Cache-Control header of the uploaded objects, empty for GCS's default")
+	pubsubTopic := flag.String("pubsubTopic", "", "Pub/Sub topic (projects/<project>/topics/<topic>) to publish a message to per record created, updated, withdrawn or deleted")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if *source == "" || *destination == "" {
+		Logger.Fatalf("Both -source and -destination are required")
+	}
+	bucket, prefix, err := upload.ParseGCSURL(*destination)
+	if err != nil {
+		Logger.Fatalf("Invalid -destination: %v", err)
+	}
+
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		Logger.Fatalf("Failed to create storage client: %v", err)
+	}
+	defer client.Close()
+
+	uploader := &upload.Uploader{
+		Dest:              &upload.GCS{Bucket: client.Bucket(bucket), Prefix: prefix, CacheControl: *cacheControl},
+		Concurrency:       *concurrency,
+		RequestsPerSecond: *requestsPerSecond,
+		MaxRetries:        *retries,
+		Backoff:           time.Second,
+		ManifestPath:      *manifestPath,
+		Delete:            *deleteExtraneous,
+		MaxDeleteFraction: *maxDeleteFraction,
+	}
+	if *pubsubTopic != "" {
+		project, topicID, err := parseTopic(*pubsubTopic)
+		if err != nil {
+			Logger.Fatalf("Invalid -pubsubTopic: %v", err)
+		}
+		psClient, err := pubsub.NewClient(ctx, project)
+		if err != nil {
+			Logger.Fatalf("Failed to create Pub/Sub client: %v", err)
+		}
+		defer psClient.Close()
+		topic := psClient.Topic(topicID)
+		defer topic.Stop()
+		uploader.OnChange = (&upload.PubSub{Topic: topic}).OnChange
+	}
+	start := time.Now()
+	stats, err := uploader.Upload(ctx, *source)
+	Logger.Infof("Uploaded %d, deleted %d, skipped %d unchanged and %d already uploaded, notified %d pending changes, %d failed, in %s",
+		stats.Uploaded, stats.Deleted, stats.Unchanged, stats.Resumed, stats.Notified, stats.Failed, time.Since(start).Round(time.Second))
+	if err != nil {
+		Logger.Fatalf("Failed to upload %s to %s: %v", *source, *destination, err)
+	}
+}
+
+// parseTopic returns the project and ID of a projects/<project>/topics/<topic>
+// topic name.
+func parseTopic(name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != "projects" || parts[1] == "" || parts[2] != "topics" || parts[3] == "" {
+		return "", "", fmt.Errorf("%q is not of the form projects/<project>/topics/<topic>", name)
+	}
+	return parts[1], parts[3], nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/fixcommits"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/repourl"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// The reasons advisories aren't converted.
+var (
+	errWithdrawn = errors.New("withdrawn")
+	errPackage   = errors.New("affects a package of an ecosystem")
+	errNoCVE     = errors.New("has no CVE")
+	errNoCommits = errors.New("no fix commits determined")
+)
+
+// RefResolver resolves a tag (or any other ref) of a repo to its commit.
+// github.Feature implements it for GitHub repos.
+type RefResolver interface {
+	ResolveRef(ctx context.Context, repoURL string, ref string) (string, error)
+}
+
+// converter converts GitHub advisories affecting no package of an ecosystem,
+// such as those of C/C++ projects, into parts with GIT ranges.
+type converter struct {
+	verifier fixcommits.Verifier
+	resolver RefResolver
+}
+
+// hasPackage returns whether an advisory affects a package of an ecosystem,
+// in which case its packages are covered by the ecosystem's own records.
+func hasPackage(a github.Advisory) bool {
+	return slices.ContainsFunc(a.Vulnerabilities, func(v github.AdvisoryVulnerability) bool {
+		return v.Package != nil && v.Package.Ecosystem != ""
+	})
+}
+
+// advisoryCVE returns the CVE of an advisory, with the advisory's references
+// and description, so its fix commits and versions are extracted as the
+// NVD's are.
+func advisoryCVE(a github.Advisory) cves.CVE {
+	cve := cves.CVE{ID: cves.CVEID(a.CVEID)}
+	description := strings.TrimSpace(a.Summary + "\n\n" + a.Description)
+	cve.Descriptions = []cves.LangString{{Lang: "en", Value: description}}
+	for _, link := range a.References {
+		cve.References = append(cve.References, cves.Reference{Url: link})
+	}
+	return cve
+}
+
+// advisoryRepos returns the repos of an advisory's source code location and
+// references, canonicalized, in the order found.
+func advisoryRepos(a github.Advisory) []string {
+	var repos []string
+	for _, link := range append([]string{a.SourceCodeLocation}, a.References...) {
+		if link == "" {
+			continue
+		}
+		repo, err := cves.Repo(link)
+		if err != nil {
+			continue
+		}
+		repo = repourl.Canonical(repo)
+		if !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// releaseTags returns the tags of the GitHub releases an advisory
+// references (e.g. https://github.com/owner/repo/releases/tag/v1.2.3), by
+// canonical repo.
+func releaseTags(a github.Advisory) map[string][]string {
+	tags := make(map[string][]string)
+	for _, link := range a.References {
+		u, err := url.Parse(link)
+		if err != nil || u.Hostname() != "github.com" {
+			continue
+		}
+		_, tag, ok := strings.Cut(u.Path, "/releases/tag/")
+		if !ok || tag == "" {
+			continue
+		}
+		tag, err = url.PathUnescape(tag)
+		if err != nil {
+			continue
+		}
+		repo, err := cves.Repo(link)
+		if err != nil {
+			continue
+		}
+		repo = repourl.Canonical(repo)
+		if !slices.Contains(tags[repo], tag) {
+			tags[repo] = append(tags[repo], tag)
+		}
+	}
+	return tags
+}
+
+// fixRefs returns the refs that may be the fix of a repo: the tags of the
+// releases referenced, then the tags of the fixed versions the description
+// gives.
+func fixRefs(releases []string, versions cves.VersionInfo) []string {
+	refs := slices.Clone(releases)
+	for _, av := range versions.AffectedVersions {
+		if av.Fixed == "" {
+			continue
+		}
+		for _, ref := range []string{av.Fixed, "v" + av.Fixed} {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// convert returns the PackageInfo of an advisory, with the fix commits it
+// references, or else those of the releases it references or the fixed
+// versions its description gives, and notes on what couldn't be determined.
+func (c *converter) convert(ctx context.Context, a github.Advisory) ([]vulns.PackageInfo, []string, error) {
+	switch {
+	case a.WithdrawnAt != "":
+		return nil, nil, errWithdrawn
+	case hasPackage(a):
+		return nil, nil, errPackage
+	case !ids.IsCVE(a.CVEID):
+		return nil, nil, errNoCVE
+	}
+
+	cve := advisoryCVE(a)
+	versions, notes := cves.ExtractVersionInfo(cve, nil)
+	commits, commitNotes := fixcommits.Detect(ctx, c.verifier, cve.References)
+	notes = append(notes, commitNotes...)
+	versions.AffectedCommits = commits
+
+	tags := releaseTags(a)
+	for _, repo := range advisoryRepos(a) {
+		if versions.HasFixedCommits(repo) {
+			continue
+		}
+		for _, ref := range fixRefs(tags[repo], versions) {
+			commit, err := c.resolver.ResolveRef(ctx, repo, ref)
+			if err != nil {
+				if errors.Is(err, github.ErrBudgetExhausted) {
+					notes = append(notes, fmt.Sprintf("Unresolved fix %s in %s: %v", ref, repo, err))
+					break
+				}
+				continue
+			}
+			ac := cves.AffectedCommit{Repo: repo}
+			ac.SetFixed(commit)
+			versions.AffectedCommits = append(versions.AffectedCommits, ac)
+			break
+		}
+	}
+	if len(versions.AffectedCommits) == 0 {
+		return nil, notes, errNoCommits
+	}
+
+	versions.AffectedVersions = nil
+	slices.SortStableFunc(versions.AffectedCommits, cves.AffectedCommitCompare)
+	return []vulns.PackageInfo{{VersionInfo: versions}}, notes, nil
+}
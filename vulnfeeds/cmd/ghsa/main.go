@@ -0,0 +1,162 @@
+// ghsa converts the advisories of the GitHub Advisory Database that affect no
+// package of an ecosystem, such as most of those of C/C++ projects, into
+// parts with GIT ranges of their fix commits, so their CVEs can be queried by
+// commit and version.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/fixcommits"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	ghsaOutputPathDefault = "parts/ghsa"
+	ghsaTypesDefault      = "reviewed,unreviewed"
+)
+
+var Logger utility.LoggerWrapper
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("ghsa-osv")
+	defer logCleanup()
+
+	ghsaOutputPath := flag.String("ghsaOutput", ghsaOutputPathDefault, "path to output general GHSA affected package information")
+	ghsaInput := flag.String("ghsaInput", "", "JSON file of advisories as returned by the GitHub global advisories API, to convert instead of querying the API")
+	ghsaTypes := flag.String("ghsaTypes", ghsaTypesDefault, "Comma separated types of advisories to query")
+	modifiedSince := flag.String("modifiedSince", "", "Only query advisories modified since this date (YYYY-MM-DD)")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	if err := os.MkdirAll(*ghsaOutputPath, 0755); err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+
+	ctx := context.Background()
+	gh := github.NewClientFromEnv()
+	var advisories []github.Advisory
+	var err error
+	if *ghsaInput != "" {
+		advisories, err = loadAdvisories(*ghsaInput)
+	} else {
+		advisories, err = queryAdvisories(ctx, gh.Feature("ghsa", 0), *ghsaTypes, *modifiedSince)
+	}
+	if err != nil {
+		Logger.Fatalf("Failed to get advisories: %s", err)
+	}
+
+	c := &converter{
+		verifier: fixcommits.NewHTTPVerifier(gh),
+		resolver: gh.Feature("ghsa-tags", 0),
+	}
+	cvePkgInfos := convertAdvisories(ctx, c, advisories)
+	if err := writeToOutput(cvePkgInfos, *ghsaOutputPath); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+	if err := outputFlags.Finish("ghsa-osv"); err != nil {
+		Logger.Fatalf("GHSA conversion failed: %s", err)
+	}
+
+	Logger.Infof("GHSA conversion succeeded.")
+}
+
+// loadAdvisories loads advisories from a JSON file.
+func loadAdvisories(path string) ([]github.Advisory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var advisories []github.Advisory
+	if err := json.Unmarshal(data, &advisories); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return advisories, nil
+}
+
+// queryAdvisories queries the advisories of each type, modified since a
+// date if one is given.
+func queryAdvisories(ctx context.Context, f *github.Feature, types string, modifiedSince string) ([]github.Advisory, error) {
+	var advisories []github.Advisory
+	for _, t := range strings.Split(types, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		query := url.Values{"type": {t}}
+		if modifiedSince != "" {
+			query.Set("modified", ">="+modifiedSince)
+		}
+		page, err := f.Advisories(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s advisories: %w", t, err)
+		}
+		Logger.Infof("Queried %d %s advisories", len(page), t)
+		advisories = append(advisories, page...)
+	}
+	return advisories, nil
+}
+
+// convertAdvisories converts advisories to PackageInfos, by CVE. The
+// PackageInfos of advisories of the same CVE are combined.
+func convertAdvisories(ctx context.Context, c *converter, advisories []github.Advisory) map[string][]vulns.PackageInfo {
+	cvePkgInfos := make(map[string][]vulns.PackageInfo)
+	skipped := make(map[string]int)
+	for _, a := range advisories {
+		pkgInfos, notes, err := c.convert(ctx, a)
+		for _, note := range notes {
+			Logger.Infof("[%s]: %s", a.GHSAID, note)
+		}
+		if err != nil {
+			skipped[err.Error()]++
+			if errors.Is(err, errNoCommits) {
+				Logger.Infof("[%s]: Skipping %s: %s", a.GHSAID, a.CVEID, err)
+			}
+			continue
+		}
+		cvePkgInfos[a.CVEID] = append(cvePkgInfos[a.CVEID], pkgInfos...)
+	}
+	reasons := make([]string, 0, len(skipped))
+	for reason := range skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		Logger.Infof("Skipped %d advisories: %s", skipped[reason], reason)
+	}
+	Logger.Infof("Converted the advisories of %d CVEs", len(cvePkgInfos))
+	return cvePkgInfos
+}
+
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, outputPath string) error {
+	Logger.Infof("Writing package infos to the output.")
+	for cveId, pkgInfos := range cvePkgInfos {
+		filePath := filepath.Join(outputPath, cveId+".ghsa.json")
+		if err := parts.Write(filePath, pkgInfos); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(pkgInfos))
+	}
+
+	return nil
+}
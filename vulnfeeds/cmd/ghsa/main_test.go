@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/fixcommits"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+type fakeVerifier map[string]string
+
+func (f fakeVerifier) Verify(_ context.Context, c fixcommits.Candidate) (string, error) {
+	hash, ok := f[c.Repo+"@"+c.Commit]
+	if !ok {
+		return "", fixcommits.ErrCommitNotFound
+	}
+	return hash, nil
+}
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) ResolveRef(_ context.Context, repoURL string, ref string) (string, error) {
+	hash, ok := f[repoURL+"@"+ref]
+	if !ok {
+		return "", github.ErrNotFound
+	}
+	return hash, nil
+}
+
+func fixed(repo, commit string) cves.AffectedCommit {
+	ac := cves.AffectedCommit{Repo: repo}
+	ac.SetFixed(commit)
+	return ac
+}
+
+func TestConvertAdvisories(t *testing.T) {
+	advisories, err := loadAdvisories("../../test_data/ghsa/advisories.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &converter{
+		verifier: fakeVerifier{
+			"https://github.com/foo/libfoo@4367a20cc4bd5e6a1b4a0ac8f3b6a4ab6a7dca11":        "4367a20cc4bd5e6a1b4a0ac8f3b6a4ab6a7dca11",
+			"https://github.com/left-pad/left-pad@1111111111111111111111111111111111111111": "1111111111111111111111111111111111111111",
+			"https://github.com/baz/libbaz@2222222222222222222222222222222222222222":        "2222222222222222222222222222222222222222",
+		},
+		resolver: fakeResolver{
+			"https://github.com/bar/libbar@v2.0.1": "5555555555555555555555555555555555555555",
+		},
+	}
+
+	got := convertAdvisories(context.Background(), c, advisories)
+	// Advisories of packages of an ecosystem, without a CVE, withdrawn or
+	// without a fix are skipped.
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2024-1001": {{VersionInfo: cves.VersionInfo{AffectedCommits: []cves.AffectedCommit{
+			fixed("https://github.com/foo/libfoo", "4367a20cc4bd5e6a1b4a0ac8f3b6a4ab6a7dca11"),
+		}}}},
+		"CVE-2024-1002": {{VersionInfo: cves.VersionInfo{AffectedCommits: []cves.AffectedCommit{
+			fixed("https://github.com/bar/libbar", "5555555555555555555555555555555555555555"),
+		}}}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("convertAdvisories() mismatch (-want +got):\n%s", diff)
+	}
+
+	dir := t.TempDir()
+	if err := writeToOutput(got, dir); err != nil {
+		t.Fatalf("writeToOutput() error: %v", err)
+	}
+	file, err := os.Open(filepath.Join(dir, "CVE-2024-1001.ghsa.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	part, err := parts.Decode(file)
+	if err != nil {
+		t.Fatalf("parts.Decode() error: %v", err)
+	}
+	if diff := gocmp.Diff(want["CVE-2024-1001"], part.Packages); diff != "" {
+		t.Errorf("written part mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConvertFixedVersion(t *testing.T) {
+	a := github.Advisory{
+		GHSAID:             "GHSA-8h9a-0b1c-2d3e",
+		CVEID:              "CVE-2024-1007",
+		Description:        "An integer overflow in libcorge before 1.4.2 allows attackers to crash it.",
+		SourceCodeLocation: "https://github.com/corge/libcorge",
+	}
+	c := &converter{
+		verifier: fakeVerifier{},
+		resolver: fakeResolver{"https://github.com/corge/libcorge@v1.4.2": "6666666666666666666666666666666666666666"},
+	}
+	got, _, err := c.convert(context.Background(), a)
+	if err != nil {
+		t.Fatalf("convert() error: %v", err)
+	}
+	want := []vulns.PackageInfo{{VersionInfo: cves.VersionInfo{AffectedCommits: []cves.AffectedCommit{
+		fixed("https://github.com/corge/libcorge", "6666666666666666666666666666666666666666"),
+	}}}}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("convert() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -10,13 +10,15 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/osv-scanner/pkg/models"
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v2"
+
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/utility"
 )
 
 const (
@@ -45,7 +47,12 @@ func main() {
 	dir := flag.String("dir", "", "Path to vulnerabilites.")
 	format := flag.String("format", string(fileFormatYAML), "Format of OSV reports in the repository. Must be \"json\" or \"yaml\".")
 
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
 
 	if *prefix == "" || *dir == "" {
 		flag.Usage()
@@ -65,21 +72,7 @@ func main() {
 
 func extractYearAndNum(prefix, filename string, format fileFormat) (int, int) {
 	// Extract year and num from "PREFIX-YEAR-NUM"
-	parts := strings.Split(strings.TrimSuffix(filename, formatToExtension[format]), "-")
-	if len(parts) != 3 {
-		return 0, 0
-	}
-
-	if parts[0] != prefix {
-		return 0, 0
-	}
-
-	year, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0
-	}
-
-	num, err := strconv.Atoi(parts[2])
+	year, num, err := ids.ParseYearly(strings.TrimSuffix(filename, formatToExtension[format]), ids.Prefix(prefix))
 	if err != nil {
 		return 0, 0
 	}
@@ -87,10 +80,6 @@ func extractYearAndNum(prefix, filename string, format fileFormat) (int, int) {
 	return year, num
 }
 
-func isUnassigned(prefix, filename string) bool {
-	return strings.HasPrefix(filename, prefix+"-0000-")
-}
-
 func assignID(prefix, path string, format fileFormat, yearCounters map[int]int, defaultYear int) error {
 	// Parse the existing vulnerability.
 	readf, err := os.Open(path)
@@ -116,7 +105,7 @@ func assignID(prefix, path string, format fileFormat, yearCounters map[int]int,
 	id := yearCounters[year] + 1
 	yearCounters[year] = id
 
-	vuln.ID = fmt.Sprintf("%s-%d-%d", prefix, year, id)
+	vuln.ID = ids.Yearly(ids.Prefix(prefix), year, id)
 	newPath := filepath.Join(filepath.Dir(path), vuln.ID+formatToExtension[format])
 
 	writef, err := os.Create(newPath)
@@ -148,7 +137,7 @@ func assignIDs(prefix, dir string, format fileFormat) error {
 		}
 
 		filename := filepath.Base(path)
-		if isUnassigned(prefix, filename) {
+		if ids.IsUnassigned(filename, ids.Prefix(prefix)) {
 			unassigned = append(unassigned, path)
 			return nil
 		}
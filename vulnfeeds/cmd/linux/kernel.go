@@ -0,0 +1,193 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vercmp"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	linuxEcosystem = "Linux"
+	linuxPackage   = "Kernel"
+	// linuxProduct is the product of the kernel CNA's affected entries.
+	linuxProduct = "Linux"
+
+	statusAffected   = "affected"
+	statusUnaffected = "unaffected"
+)
+
+// branchFix is the first version of a stable branch with a fix, e.g. 6.1.80
+// of the 6.1 branch.
+type branchFix struct {
+	branch  string
+	version string
+}
+
+// kernelVersions are the affected versions of a kernel CNA record, as
+// described by its versions that aren't commits.
+type kernelVersions struct {
+	// firstAffected is the first mainline release the issue was in.
+	firstAffected string
+	// branchFixes are the fixes backported to stable branches.
+	branchFixes []branchFix
+	// mainlineFix is the first mainline release with the fix.
+	mainlineFix string
+}
+
+// add adds a version entry. The kernel CNA describes the affected versions as
+// affected from the first mainline release the issue was in (or unaffected
+// before it), and unaffected from each fix of a stable branch to the end of
+// the branch ("6.1.*"), or from the mainline fix on ("*").
+func (k *kernelVersions) add(v cves.CVE5Version) error {
+	status := strings.ToLower(strings.TrimSpace(v.Status))
+	switch {
+	case status == statusAffected && v.LessThan == "" && v.LessThanOrEqual == "":
+		if err := checkKernelVersion(v.Version); err != nil {
+			return err
+		}
+		k.firstAffected = v.Version
+	case status == statusUnaffected && v.Version == "0" && v.LessThan != "":
+		if err := checkKernelVersion(v.LessThan); err != nil {
+			return err
+		}
+		k.firstAffected = cmp.Or(k.firstAffected, v.LessThan)
+	case status == statusUnaffected && v.LessThanOrEqual == "*":
+		if err := checkKernelVersion(v.Version); err != nil {
+			return err
+		}
+		k.mainlineFix = v.Version
+	case status == statusUnaffected && strings.HasSuffix(v.LessThanOrEqual, ".*"):
+		branch := strings.TrimSuffix(v.LessThanOrEqual, ".*")
+		if err := checkKernelVersion(branch); err != nil {
+			return err
+		}
+		if err := checkKernelVersion(v.Version); err != nil {
+			return err
+		}
+		k.branchFixes = append(k.branchFixes, branchFix{branch: branch, version: v.Version})
+	default:
+		return fmt.Errorf("unsupported version %+v", v)
+	}
+	return nil
+}
+
+// affectedVersions returns the affected versions as ranges of the Linux
+// ecosystem: from the first affected release to the fix of each stable
+// branch, then from the next branch on, up to the mainline fix.
+func (k kernelVersions) affectedVersions() []cves.AffectedVersion {
+	if k.firstAffected == "" && k.mainlineFix == "" && len(k.branchFixes) == 0 {
+		return nil
+	}
+	fixes := slices.Clone(k.branchFixes)
+	slices.SortFunc(fixes, func(a, b branchFix) int {
+		n, _ := vercmp.Kernel(a.version, b.version)
+		return n
+	})
+	var affected []cves.AffectedVersion
+	introduced := cmp.Or(k.firstAffected, "0")
+	for _, fix := range fixes {
+		if k.firstAffected != "" && kernelLess(fix.version, k.firstAffected) {
+			// The introducing commit was backported to a branch older than
+			// the first affected release, so the branch was affected too.
+			affected = append(affected, cves.AffectedVersion{Introduced: fix.branch, Fixed: fix.version})
+			continue
+		}
+		affected = append(affected, cves.AffectedVersion{Introduced: introduced, Fixed: fix.version})
+		// Whether or not the next branch exists (e.g. 6.20 after 6.19, as
+		// 7.0 follows it), everything after this branch is introduced by it.
+		introduced = nextBranch(fix.branch)
+	}
+	switch {
+	case k.mainlineFix == "":
+		affected = append(affected, cves.AffectedVersion{Introduced: introduced})
+	case introduced == "0" || kernelLess(introduced, k.mainlineFix):
+		affected = append(affected, cves.AffectedVersion{Introduced: introduced, Fixed: k.mainlineFix})
+	}
+	return affected
+}
+
+// branchCommits returns the commits of a git version entry of the kernel
+// CNA, which is the introducing commit of a branch and the commit fixing it
+// in the branch.
+func branchCommits(repo string, v cves.CVE5Version) (cves.AffectedCommit, error) {
+	var ac cves.AffectedCommit
+	if !strings.EqualFold(strings.TrimSpace(v.Status), statusAffected) || v.Version == "" {
+		return ac, fmt.Errorf("unsupported git version %+v", v)
+	}
+	if repo == "" {
+		return ac, fmt.Errorf("git version %+v without a repo", v)
+	}
+	ac.SetRepo(repo)
+	ac.Introduced = v.Version
+	switch {
+	case v.LessThan != "":
+		ac.Fixed = v.LessThan
+	case v.LessThanOrEqual != "":
+		ac.LastAffected = v.LessThanOrEqual
+	default:
+		return ac, fmt.Errorf("git version %+v without a fix", v)
+	}
+	return ac, nil
+}
+
+// convertRecord converts a kernel CNA record to the PackageInfo of the
+// kernel, with the commits introducing and fixing the issue in each stable
+// branch, and the affected versions of the Linux ecosystem. Returns notes
+// about the versions that couldn't be converted.
+func convertRecord(record cves.CVE5) (vulns.PackageInfo, []string) {
+	pkgInfo := vulns.PackageInfo{PkgName: linuxPackage, Ecosystem: linuxEcosystem}
+	var notes []string
+	var versions kernelVersions
+	for _, affected := range record.Containers.CNA.Affected {
+		if !strings.EqualFold(affected.Product, linuxProduct) {
+			notes = append(notes, fmt.Sprintf("Skipping affected product %q", affected.Product))
+			continue
+		}
+		for _, v := range affected.Versions {
+			if strings.EqualFold(v.VersionType, "git") {
+				ac, err := branchCommits(affected.Repo, v)
+				if err != nil {
+					notes = append(notes, fmt.Sprintf("Skipping commits: %v", err))
+					continue
+				}
+				if !slices.Contains(pkgInfo.VersionInfo.AffectedCommits, ac) {
+					pkgInfo.VersionInfo.AffectedCommits = append(pkgInfo.VersionInfo.AffectedCommits, ac)
+				}
+				continue
+			}
+			if err := versions.add(v); err != nil {
+				notes = append(notes, fmt.Sprintf("Skipping version: %v", err))
+			}
+		}
+	}
+	pkgInfo.VersionInfo.AffectedVersions = versions.affectedVersions()
+	return pkgInfo, notes
+}
+
+// checkKernelVersion checks that a version is a kernel version.
+func checkKernelVersion(version string) error {
+	_, err := vercmp.Kernel(version, version)
+	return err
+}
+
+// kernelLess reports whether kernel version a precedes b.
+func kernelLess(a, b string) bool {
+	n, err := vercmp.Kernel(a, b)
+	return err == nil && n < 0
+}
+
+// nextBranch returns the branch following a stable branch, e.g. 6.2 for 6.1.
+func nextBranch(branch string) string {
+	i := strings.LastIndex(branch, ".")
+	n, err := strconv.Atoi(branch[i+1:])
+	if err != nil {
+		return branch
+	}
+	return branch[:i+1] + strconv.Itoa(n+1)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	linuxVulnsURL          = "https://git.kernel.org/pub/scm/linux/security/vulns.git"
+	linuxVulnsDefault      = "linux-vulns"
+	linuxOutputPathDefault = "parts/linux"
+	// linuxPublishedPath is the directory of the published CVE records of the
+	// vulns repo, by year (e.g. cve/published/2024/CVE-2024-26581.json).
+	// Rejected records are in cve/rejected instead.
+	linuxPublishedPath = "cve/published"
+)
+
+var Logger utility.LoggerWrapper
+var failureFlags = failures.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("linux-osv")
+	defer logCleanup()
+
+	linuxOutputPath := flag.String("linuxOutput", linuxOutputPathDefault, "path to output general Linux kernel affected package information")
+	linuxVulns := flag.String("linuxVulns", linuxVulnsDefault, "path to clone the kernel CNA's vulns repo into (or update an existing clone at)")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of records to convert concurrently")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	if err := os.MkdirAll(*linuxOutputPath, 0755); err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+	Logger.Infof("Updating %s from %s", *linuxVulns, linuxVulnsURL)
+	if err := git.SyncCheckout(context.Background(), linuxVulnsURL, *linuxVulns); err != nil {
+		Logger.Fatalf("Failed to update the vulns repo: %s", err)
+	}
+
+	var failed failures.Collector
+	if err := convertAll(path.Join(*linuxVulns, linuxPublishedPath), *linuxOutputPath, *workers, &failed); err != nil {
+		Logger.Fatalf("Failed to convert the kernel CVEs: %s", err)
+	}
+	failed.Report(Logger, "kernel CVE records")
+	if err := failed.Check(failureFlags.MaxRate()); err != nil {
+		Logger.Fatalf("Linux kernel conversion failed: %s", err)
+	}
+	if err := outputFlags.Finish("linux-osv"); err != nil {
+		Logger.Fatalf("Linux kernel conversion failed: %s", err)
+	}
+	Logger.Infof("Linux kernel CVE conversion succeeded.")
+}
+
+// convertAll converts the records under publishedPath, writing a part per
+// CVE into outputPath. There are tens of thousands of records, so they're
+// converted by concurrent workers, each reading, converting and writing a
+// record at a time.
+func convertAll(publishedPath string, outputPath string, workers int, failed *failures.Collector) error {
+	var g errgroup.Group
+	g.SetLimit(max(workers, 1))
+	err := filepath.WalkDir(publishedPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		cveId := strings.TrimSuffix(d.Name(), ".json")
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") || !ids.IsCVE(cveId) {
+			return nil
+		}
+		g.Go(func() error {
+			partPath := path.Join(outputPath, cveId+".linux.json")
+			if err := convertFile(cveId, filePath, partPath); err != nil {
+				failed.Failed(cveId, err)
+			} else {
+				outputFlags.Add(partPath)
+				failed.Succeeded()
+			}
+			return nil
+		})
+		return nil
+	})
+	return errors.Join(err, g.Wait())
+}
+
+// convertFile converts the kernel CNA record of a CVE to a part.
+func convertFile(cveId string, recordPath string, partPath string) error {
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		return err
+	}
+	var record cves.CVE5
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("failed to decode: %w", err)
+	}
+	pkgInfo, notes := convertRecord(record)
+	for _, note := range notes {
+		Logger.Warnf("%s: %s", cveId, note)
+	}
+	if len(pkgInfo.VersionInfo.AffectedCommits) == 0 && len(pkgInfo.VersionInfo.AffectedVersions) == 0 {
+		return errors.New("no affected commits or versions")
+	}
+	return parts.Write(partPath, []vulns.PackageInfo{pkgInfo})
+}
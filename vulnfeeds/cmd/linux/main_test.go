@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	testPublishedPath = "../../test_data/linux/cve/published"
+	stableRepo        = "https://git.kernel.org/pub/scm/linux/kernel/git/stable/linux.git"
+)
+
+func TestMain(m *testing.M) {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("linux-osv-test")
+	code := m.Run()
+	logCleanup()
+	os.Exit(code)
+}
+
+var wantCVE202426581 = vulns.PackageInfo{
+	PkgName:   "Kernel",
+	Ecosystem: "Linux",
+	VersionInfo: cves.VersionInfo{
+		AffectedCommits: []cves.AffectedCommit{
+			{Repo: stableRepo, Introduced: "1e12d6c9ae8eba1d4e8a8fc9d46a4b07a4c7a9e2", Fixed: "10e9cb39313627f2eae4cd70c4b742074e998fd8"},
+			{Repo: stableRepo, Introduced: "f718863aca469a109895cb855e6b81fff4827d71", Fixed: "1296c110c5a0b45a4fc9bc6ad2da0c9c3ee8eb0e"},
+			{Repo: stableRepo, Introduced: "f718863aca469a109895cb855e6b81fff4827d71", Fixed: "4cee42fcf54fec46b344681e7cc4f234bb22f85a"},
+			{Repo: stableRepo, Introduced: "f718863aca469a109895cb855e6b81fff4827d71", Fixed: "60c0c230c6f046da536d3df8b39a20b9a9fd6af0"},
+		},
+		AffectedVersions: []cves.AffectedVersion{
+			// 6.1 was affected by a backport of the introducing commit.
+			{Introduced: "6.1", Fixed: "6.1.78"},
+			{Introduced: "6.5", Fixed: "6.6.17"},
+			{Introduced: "6.7", Fixed: "6.7.5"},
+		},
+	},
+}
+
+func TestConvertRecord(t *testing.T) {
+	data, err := os.ReadFile(path.Join(testPublishedPath, "2024", "CVE-2024-26581.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record cves.CVE5
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	got, notes := convertRecord(record)
+	if diff := gocmp.Diff(wantCVE202426581, got); diff != "" {
+		t.Errorf("convertRecord() mismatch (-want +got):\n%s", diff)
+	}
+	if len(notes) != 0 {
+		t.Errorf("convertRecord() notes = %q, want none", notes)
+	}
+}
+
+func TestAffectedVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions kernelVersions
+		want     []cves.AffectedVersion
+	}{
+		{
+			name:     "unfixed",
+			versions: kernelVersions{firstAffected: "6.5"},
+			want:     []cves.AffectedVersion{{Introduced: "6.5"}},
+		},
+		{
+			name:     "only fixed in mainline",
+			versions: kernelVersions{firstAffected: "6.5", mainlineFix: "6.8-rc3"},
+			want:     []cves.AffectedVersion{{Introduced: "6.5", Fixed: "6.8-rc3"}},
+		},
+		{
+			name: "stable branches not fixed in mainline yet",
+			versions: kernelVersions{
+				firstAffected: "5.10",
+				branchFixes:   []branchFix{{"6.6", "6.6.30"}, {"5.15", "5.15.150"}},
+			},
+			want: []cves.AffectedVersion{
+				{Introduced: "5.10", Fixed: "5.15.150"},
+				{Introduced: "5.16", Fixed: "6.6.30"},
+				{Introduced: "6.7"},
+			},
+		},
+		{
+			name: "major version bump",
+			versions: kernelVersions{
+				firstAffected: "5.15",
+				branchFixes:   []branchFix{{"5.19", "5.19.17"}},
+				mainlineFix:   "6.0",
+			},
+			want: []cves.AffectedVersion{
+				{Introduced: "5.15", Fixed: "5.19.17"},
+				{Introduced: "5.20", Fixed: "6.0"},
+			},
+		},
+		{
+			name:     "since the beginning of git",
+			versions: kernelVersions{branchFixes: []branchFix{{"4.19", "4.19.300"}}, mainlineFix: "6.7"},
+			want: []cves.AffectedVersion{
+				{Introduced: "0", Fixed: "4.19.300"},
+				{Introduced: "4.20", Fixed: "6.7"},
+			},
+		},
+		{
+			name:     "no versions",
+			versions: kernelVersions{},
+			want:     nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := gocmp.Diff(tc.want, tc.versions.affectedVersions()); diff != "" {
+				t.Errorf("affectedVersions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertRecordSkipsUnsupportedVersions(t *testing.T) {
+	var record cves.CVE5
+	record.Containers.CNA.Affected = []cves.CVE5Affected{
+		{Product: "Linux", Repo: stableRepo, Versions: []cves.CVE5Version{
+			{Version: "f718863aca469a109895cb855e6b81fff4827d71", Status: "affected", VersionType: "git"},
+			{Version: "6.5", Status: "affected"},
+			{Version: "6.x", LessThanOrEqual: "*", Status: "unaffected"},
+		}},
+		{Product: "Other", Versions: []cves.CVE5Version{{Version: "1.0", Status: "affected"}}},
+	}
+	got, notes := convertRecord(record)
+	want := vulns.PackageInfo{
+		PkgName:     "Kernel",
+		Ecosystem:   "Linux",
+		VersionInfo: cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Introduced: "6.5"}}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("convertRecord() mismatch (-want +got):\n%s", diff)
+	}
+	if len(notes) != 3 {
+		t.Errorf("convertRecord() notes = %q, want 3", notes)
+	}
+}
+
+func TestConvertAll(t *testing.T) {
+	outputPath := t.TempDir()
+	var failed failures.Collector
+	if err := convertAll(testPublishedPath, outputPath, 2, &failed); err != nil {
+		t.Fatalf("convertAll() failed: %v", err)
+	}
+	if got := failed.Total(); got != 2 {
+		t.Errorf("convertAll() handled %d records, want 2", got)
+	}
+	if failures := failed.Failures(); len(failures) != 1 || failures[0].Record != "CVE-2024-99999" {
+		t.Errorf("convertAll() failures = %v, want CVE-2024-99999", failures)
+	}
+
+	file, err := os.Open(path.Join(outputPath, "CVE-2024-26581.linux.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	got, err := parts.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := gocmp.Diff(parts.File{Version: parts.Version, Packages: []vulns.PackageInfo{wantCVE202426581}}, got); diff != "" {
+		t.Errorf("convertAll() part mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -9,11 +10,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"cloud.google.com/go/storage"
 	"golang.org/x/exp/slices"
 
+	"github.com/google/osv/vulnfeeds/audit"
+	"github.com/google/osv/vulnfeeds/cpedict"
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/enrichment"
+	"github.com/google/osv/vulnfeeds/fixcommits"
 	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/repourl"
+	"github.com/google/osv/vulnfeeds/upload"
 	"github.com/google/osv/vulnfeeds/utility"
 	"github.com/google/osv/vulnfeeds/vulns"
 )
@@ -39,7 +51,7 @@ var ErrNoRanges = errors.New("no ranges")
 var ErrUnresolvedFix = errors.New("fixes not resolved to commits")
 
 func (c ConversionOutcome) String() string {
-	return [...]string{"ConversionUnknown", "Successful", "Rejected", "NoSoftware", "NoRepos", "NoRanges", "FixUnresolvable"}[c]
+	return [...]string{"ConversionUnknown", "Successful", "Rejected", "NoSoftware", "NoRepos", "NoRanges", "FixUnresolvable", "NeedsReview"}[c]
 }
 
 const (
@@ -55,21 +67,59 @@ const (
 	NoRepos                                    // The CPE Vendor/Product had no repositories derived for it.
 	NoRanges                                   // No viable commit ranges could be calculated from the repository for the CVE's CPE(s).
 	FixUnresolvable                            // Partial resolution of versions, resulting in a false positive.
+	NeedsReview                                // The record was generated with too little confidence to publish without review.
 )
 
 var (
 	jsonPath            = flag.String("nvd_json", "", "Path to NVD CVE JSON to examine.")
+	cveHistoryPath      = flag.String("cve_history", "", "Path to the time of the last meaningful change of each CVE, as kept by download-cves -history, so records are only modified when their CVE meaningfully changes")
 	parsedCPEDictionary = flag.String("cpe_repos", "", "Path to JSON mapping of CPEs to repos generated by cpe-repo-gen")
 	outDir              = flag.String("out_dir", "", "Path to output results.")
 	outFormat           = flag.String("out_format", "OSV", "Format to output {OSV,PackageInfo}")
+	cpeIndexPath        = flag.String("cpe_index", "", "Path to CPE dictionary index generated by cpe-index, used for CPEs not in cpe_repos")
+	cpeOverridesPath    = flag.String("cpe_overrides", "", "Path to JSON file of manual CPE vendor:product overrides for the CPE dictionary index")
+	cpeMinConfidence    = flag.Float64("cpe_index_min_confidence", cpedict.ConfidenceExact, "Minimum confidence of a CPE dictionary index match to use its repos")
+	auditTable          = flag.String("audit_table", "", "BigQuery table (project.dataset.table) to write an audit row per CVE to")
+	enrichmentURL       = flag.String("enrichment_url", "", "URL of a service proposing affected packages for CVEs without repos; enrichment is disabled if empty")
+	enrichmentCache     = flag.String("enrichment_cache", "", "Path to cache enrichment proposals in")
+	enrichmentQueue     = flag.String("enrichment_queue", "", "Path to the curation queue to write enrichment proposals to (defaults to curation_queue in out_dir)")
+	enrichmentBatchSize = flag.Int("enrichment_batch_size", 50, "Number of CVEs to send to the enrichment service per request")
+	enrichmentTimeout   = flag.Duration("enrichment_timeout", 30*time.Second, "Timeout of each enrichment request")
+	enrichmentMinConf   = flag.Float64("enrichment_min_confidence", 0.5, "Minimum confidence of an enrichment proposal to queue it for curation")
+	curationReport      = flag.String("curation_report", "curation_report.md", "Name of the report of CVEs with failed or suspicious version extraction to write in out_dir, as HTML if it ends in .html; disabled if empty")
+	reviewDir           = flag.String("review_dir", "", "Path to write records converted with less than review_min_confidence to for review, instead of out_dir (defaults to needs_review in out_dir)")
+	reviewMinConf       = flag.Float64("review_min_confidence", 0.7, "Minimum confidence of a converted record's ranges to write it to out_dir rather than for review; 0 disables review")
+	verifyFixCommits    = flag.Bool("verify_fix_commits", true, "Verify the fix commits referenced by CVEs exist (with the GitHub API authenticated by $GITHUB_TOKEN for GitHub repos), and expand abbreviated ones")
+	credits             = flag.Bool("credits", false, "Credit the people acknowledged by \"discovered by\" and \"reported by\" phrases in each CVE's description")
+	runID               = flag.String("run_id", "", "ID of this run, recorded in each OSV record's generator and audit rows (defaults to the NVD JSON file name and start time)")
+	tagCacheDir         = flag.String("tag_cache_dir", "", "Path to cache the tags of repos in, listing each repo at most once a day")
+	tagCacheGCS         = flag.String("tag_cache_gcs", "", "gs://bucket/prefix to share the tags of repos in between runs, behind tag_cache_dir if both are set")
+	commitReleasesDir   = flag.String("commit_releases_dir", "", "Path to the commit release tables of commit-releases, to annotate GIT ranges with the releases of their commits")
+	commitReleasesGCS   = flag.String("commit_releases_gcs", "", "gs://bucket/prefix of the commit release tables of commit-releases, instead of commit_releases_dir")
+	showVersion         = flag.Bool("version", false, "Print the converter build and exit")
 )
 var Logger utility.LoggerWrapper
-var RepoTagsCache git.RepoTagsCache
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+// TagCache memoizes the tags of the repos that versions are resolved to
+// commits in.
+var TagCache *git.TagCache
+
+// CommitReleases annotates GIT ranges with the releases of their commits, if
+// commit release tables were given.
+var CommitReleases *commitReleases
+
+// Generator identifies this run in the OSV records it generates.
+var Generator vulns.Generator
+
+// FixCommitVerifier verifies the fix commits referenced by CVEs, if set.
+var FixCommitVerifier fixcommits.Verifier
 var Metrics struct {
 	TotalCVEs           int
 	CVEsForApplications int
 	CVEsForKnownRepos   int
 	OSVRecordsGenerated int
+	RecordsForReview    int
 	Outcomes            map[cves.CVEID]ConversionOutcome // Per-CVE-ID record of conversion result.
 }
 
@@ -103,12 +153,12 @@ var VendorProductDenyList = []VendorProduct{
 // Takes a CVE ID string (for logging), cves.VersionInfo with AffectedVersions and
 // typically no AffectedCommits and attempts to add AffectedCommits (including Fixed commits) where there aren't any.
 // Refuses to add the same commit to AffectedCommits more than once.
-func GitVersionsToCommits(CVE cves.CVEID, versions cves.VersionInfo, repos []string, cache git.RepoTagsCache) (v cves.VersionInfo, e error) {
+func GitVersionsToCommits(CVE cves.CVEID, versions cves.VersionInfo, repos []string, cache *git.TagCache) (v cves.VersionInfo, e error) {
 	// versions is a VersionInfo with AffectedVersions and typically no AffectedCommits
 	// v is a VersionInfo with AffectedCommits (containing Fixed commits) included
 	v = versions
 	for _, repo := range repos {
-		normalizedTags, err := git.NormalizeRepoTags(repo, cache)
+		normalizedTags, err := cache.NormalizedTags(context.Background(), repo)
 		if err != nil {
 			Logger.Warnf("[%s]: Failed to normalize tags for %s: %v", CVE, repo, err)
 			continue
@@ -186,6 +236,21 @@ func GitVersionsToCommits(CVE cves.CVEID, versions cves.VersionInfo, repos []str
 	return v, nil
 }
 
+// verifyFixCommitsOf replaces the fix commits cves.ExtractVersionInfo()
+// assumed from the references of the CVE with those FixCommitVerifier
+// verifies, and returns notes on those it couldn't.
+func verifyFixCommitsOf(CVE cves.CVE, versions *cves.VersionInfo) []string {
+	if FixCommitVerifier == nil {
+		return nil
+	}
+	commits, notes := fixcommits.Detect(context.Background(), FixCommitVerifier, CVE.References)
+	for _, note := range notes {
+		Logger.Warnf("[%s]: %s", CVE.ID, note)
+	}
+	versions.AffectedCommits = commits
+	return notes
+}
+
 func refAcceptable(ref cves.Reference, tagDenyList []string) bool {
 	for _, deniedTag := range tagDenyList {
 		if slices.Contains(ref.Tags, deniedTag) {
@@ -210,6 +275,7 @@ func ReposFromReferences(CVE string, cache VendorProductToRepoMap, vp *VendorPro
 			// Failed to parse as a valid repo.
 			continue
 		}
+		repo = repourl.Canonical(repo)
 		if slices.Contains(repos, repo) {
 			continue
 		}
@@ -231,8 +297,10 @@ func ReposFromReferences(CVE string, cache VendorProductToRepoMap, vp *VendorPro
 	return repos
 }
 
-// Takes an NVD CVE record and outputs an OSV file in the specified directory.
-func CVEToOSV(CVE cves.CVE, repos []string, cache git.RepoTagsCache, directory string) error {
+// Takes an NVD CVE record and outputs an OSV file in the specified directory,
+// or in the review directory if its ranges aren't confident enough, in which
+// case it returns true.
+func CVEToOSV(CVE cves.CVE, repos []string, repoConfidence float64, cache *git.TagCache, directory string, review reviewPolicy) (bool, error) {
 	CPEs := cves.CPEs(CVE)
 	// The vendor name and product name are used to construct the output `vulnDir` below, so need to be set to *something* to keep the output tidy.
 	maybeVendorName := "ENOCPE"
@@ -243,24 +311,28 @@ func CVEToOSV(CVE cves.CVE, repos []string, cache git.RepoTagsCache, directory s
 		maybeVendorName = CPE.Vendor
 		maybeProductName = CPE.Product
 		if err != nil {
-			return fmt.Errorf("[%s]: Can't generate an OSV record without valid CPE data", CVE.ID)
+			return false, fmt.Errorf("[%s]: Can't generate an OSV record without valid CPE data", CVE.ID)
 		}
 	}
 
 	v, notes := vulns.FromCVE(CVE.ID, CVE)
+	if *credits {
+		v.AddCredits(vulns.CreditsFromDescription(v.Details)...)
+	}
 	versions, versionNotes := cves.ExtractVersionInfo(CVE, nil)
 	notes = append(notes, versionNotes...)
+	notes = append(notes, verifyFixCommitsOf(CVE, &versions)...)
 
 	if len(versions.AffectedVersions) != 0 {
 		var err error
 		// There are some AffectedVersions to try and resolve to AffectedCommits.
 		if len(repos) == 0 {
-			return fmt.Errorf("[%s]: No affected ranges for %q, and no repos to try and convert %+v to tags with", CVE.ID, maybeProductName, versions.AffectedVersions)
+			return false, fmt.Errorf("[%s]: No affected ranges for %q, and no repos to try and convert %+v to tags with", CVE.ID, maybeProductName, versions.AffectedVersions)
 		}
 		Logger.Infof("[%s]: Trying to convert version tags %+v to commits using %v", CVE.ID, versions, repos)
 		versions, err = GitVersionsToCommits(CVE.ID, versions, repos, cache)
 		if err != nil {
-			return fmt.Errorf("[%s]: Failed to convert version tags to commits: %#v", CVE.ID, err)
+			return false, fmt.Errorf("[%s]: Failed to convert version tags to commits: %#v", CVE.ID, err)
 		}
 		hasAnyFixedCommits := false
 		for _, repo := range repos {
@@ -271,7 +343,7 @@ func CVEToOSV(CVE cves.CVE, repos []string, cache git.RepoTagsCache, directory s
 		}
 
 		if versions.HasFixedVersions() && !hasAnyFixedCommits {
-			return fmt.Errorf("[%s]: Failed to convert fixed version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
+			return false, fmt.Errorf("[%s]: Failed to convert fixed version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
 		}
 
 		hasAnyLastAffectedCommits := false
@@ -283,51 +355,67 @@ func CVEToOSV(CVE cves.CVE, repos []string, cache git.RepoTagsCache, directory s
 		}
 
 		if versions.HasLastAffectedVersions() && !hasAnyLastAffectedCommits && !hasAnyFixedCommits {
-			return fmt.Errorf("[%s]: Failed to convert last_affected version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
+			return false, fmt.Errorf("[%s]: Failed to convert last_affected version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
 		}
 	}
 
 	slices.SortStableFunc(versions.AffectedCommits, cves.AffectedCommitCompare)
 
+	v.SetGenerator(Generator)
+	confidence := conversionConfidence(versions, repoConfidence)
 	affected := vulns.Affected{}
 	affected.AttachExtractedVersionInfo(versions)
+	setRangeConfidence(&affected, confidence)
+	CommitReleases.annotate(context.Background(), &affected)
 	v.Affected = append(v.Affected, affected)
 
 	if len(v.Affected[0].Ranges) == 0 {
-		return fmt.Errorf("[%s]: No affected ranges detected for %q %w", CVE.ID, maybeProductName, ErrNoRanges)
+		return false, fmt.Errorf("[%s]: No affected ranges detected for %q %w", CVE.ID, maybeProductName, ErrNoRanges)
 	}
 
+	directory, needsReview := review.directory(directory, confidence)
+	if needsReview {
+		notes = append(notes, fmt.Sprintf("Needs review: confidence %.2f is below %.2f", confidence, review.minConfidence))
+	}
 	vulnDir := filepath.Join(directory, maybeVendorName, maybeProductName)
 	err := os.MkdirAll(vulnDir, 0755)
 	if err != nil {
 		Logger.Warnf("Failed to create dir: %v", err)
-		return fmt.Errorf("failed to create dir: %v", err)
+		return false, fmt.Errorf("failed to create dir: %v", err)
 	}
 	outputFile := filepath.Join(vulnDir, v.ID+extension)
 	notesFile := filepath.Join(vulnDir, v.ID+".notes")
 	f, err := os.Create(outputFile)
 	if err != nil {
 		Logger.Warnf("Failed to open %s for writing: %v", outputFile, err)
-		return fmt.Errorf("failed to open %s for writing: %v", outputFile, err)
+		return false, fmt.Errorf("failed to open %s for writing: %v", outputFile, err)
 	}
 	defer f.Close()
+	v.Normalize()
 	err = v.ToJSON(f)
 	if err != nil {
 		Logger.Warnf("Failed to write %s: %v", outputFile, err)
-		return fmt.Errorf("failed to write %s: %v", outputFile, err)
+		return false, fmt.Errorf("failed to write %s: %v", outputFile, err)
+	}
+	recordOutput(outputFile, needsReview)
+	if needsReview {
+		Logger.Infof("[%s]: Generated OSV record for %q for review (confidence %.2f)", CVE.ID, maybeProductName, confidence)
+	} else {
+		Logger.Infof("[%s]: Generated OSV record for %q", CVE.ID, maybeProductName)
 	}
-	Logger.Infof("[%s]: Generated OSV record for %q", CVE.ID, maybeProductName)
 	if len(notes) > 0 {
 		err = os.WriteFile(notesFile, []byte(strings.Join(notes, "\n")), 0660)
 		if err != nil {
 			Logger.Warnf("[%s]: Failed to write %s: %v", CVE.ID, notesFile, err)
 		}
 	}
-	return nil
+	return needsReview, nil
 }
 
-// Takes an NVD CVE record and outputs a PackageInfo struct in a file in the specified directory.
-func CVEToPackageInfo(CVE cves.CVE, repos []string, cache git.RepoTagsCache, directory string) error {
+// Takes an NVD CVE record and outputs a PackageInfo struct in a file in the
+// specified directory, or in the review directory if its ranges aren't
+// confident enough, in which case it returns true.
+func CVEToPackageInfo(CVE cves.CVE, repos []string, repoConfidence float64, cache *git.TagCache, directory string, review reviewPolicy) (bool, error) {
 	CPEs := cves.CPEs(CVE)
 	// The vendor name and product name are used to construct the output `vulnDir` below, so need to be set to *something* to keep the output tidy.
 	maybeVendorName := "ENOCPE"
@@ -338,23 +426,24 @@ func CVEToPackageInfo(CVE cves.CVE, repos []string, cache git.RepoTagsCache, dir
 		maybeVendorName = CPE.Vendor
 		maybeProductName = CPE.Product
 		if err != nil {
-			return fmt.Errorf("[%s]: Can't generate an OSV record without valid CPE data", CVE.ID)
+			return false, fmt.Errorf("[%s]: Can't generate an OSV record without valid CPE data", CVE.ID)
 		}
 	}
 
 	// more often than not, this yields a VersionInfo with AffectedVersions and no AffectedCommits.
 	versions, notes := cves.ExtractVersionInfo(CVE, nil)
+	notes = append(notes, verifyFixCommitsOf(CVE, &versions)...)
 
 	if len(versions.AffectedVersions) != 0 {
 		var err error
 		// There are some AffectedVersions to try and resolve to AffectedCommits.
 		if len(repos) == 0 {
-			return fmt.Errorf("[%s]: No affected ranges for %q, and no repos to try and convert %+v to tags with", CVE.ID, maybeProductName, versions.AffectedVersions)
+			return false, fmt.Errorf("[%s]: No affected ranges for %q, and no repos to try and convert %+v to tags with", CVE.ID, maybeProductName, versions.AffectedVersions)
 		}
 		Logger.Infof("[%s]: Trying to convert version tags %+v to commits using %v", CVE.ID, versions, repos)
 		versions, err = GitVersionsToCommits(CVE.ID, versions, repos, cache)
 		if err != nil {
-			return fmt.Errorf("[%s]: Failed to convert version tags to commits: %#v", CVE.ID, err)
+			return false, fmt.Errorf("[%s]: Failed to convert version tags to commits: %#v", CVE.ID, err)
 		}
 	}
 
@@ -366,7 +455,7 @@ func CVEToPackageInfo(CVE cves.CVE, repos []string, cache git.RepoTagsCache, dir
 	}
 
 	if versions.HasFixedVersions() && !hasAnyFixedCommits {
-		return fmt.Errorf("[%s]: Failed to convert fixed version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
+		return false, fmt.Errorf("[%s]: Failed to convert fixed version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
 	}
 
 	hasAnyLastAffectedCommits := false
@@ -377,48 +466,45 @@ func CVEToPackageInfo(CVE cves.CVE, repos []string, cache git.RepoTagsCache, dir
 	}
 
 	if versions.HasLastAffectedVersions() && !hasAnyLastAffectedCommits && !hasAnyFixedCommits {
-		return fmt.Errorf("[%s]: Failed to convert last_affected version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
+		return false, fmt.Errorf("[%s]: Failed to convert last_affected version tags to commits: %#v %w", CVE.ID, versions, ErrUnresolvedFix)
 	}
 
 	if len(versions.AffectedCommits) == 0 {
-		return fmt.Errorf("[%s]: No affected commit ranges determined for %q %w", CVE.ID, maybeProductName, ErrNoRanges)
+		return false, fmt.Errorf("[%s]: No affected commit ranges determined for %q %w", CVE.ID, maybeProductName, ErrNoRanges)
 	}
 
+	confidence := conversionConfidence(versions, repoConfidence)
 	versions.AffectedVersions = nil // these have served their purpose and are not required in the resulting output.
 
 	slices.SortStableFunc(versions.AffectedCommits, cves.AffectedCommitCompare)
 
-	var pkgInfos []vulns.PackageInfo
-	pi := vulns.PackageInfo{VersionInfo: versions}
-	pkgInfos = append(pkgInfos, pi) // combine-to-osv expects a serialised *array* of PackageInfo
+	pkgInfos := []vulns.PackageInfo{{VersionInfo: versions}}
 
+	directory, needsReview := review.directory(directory, confidence)
+	if needsReview {
+		notes = append(notes, fmt.Sprintf("Needs review: confidence %.2f is below %.2f", confidence, review.minConfidence))
+	}
 	vulnDir := filepath.Join(directory, maybeVendorName, maybeProductName)
 	err := os.MkdirAll(vulnDir, 0755)
 	if err != nil {
 		Logger.Warnf("Failed to create dir: %v", err)
-		return fmt.Errorf("failed to create dir: %v", err)
+		return false, fmt.Errorf("failed to create dir: %v", err)
 	}
 
 	outputFile := filepath.Join(vulnDir, string(CVE.ID)+".nvd"+extension)
 	notesFile := filepath.Join(vulnDir, string(CVE.ID)+".nvd.notes")
-	f, err := os.Create(outputFile)
-	if err != nil {
-		Logger.Warnf("Failed to open %s for writing: %v", outputFile, err)
-		return fmt.Errorf("failed to open %s for writing: %v", outputFile, err)
+	if err := parts.Write(outputFile, pkgInfos); err != nil {
+		Logger.Warnf("Failed to write PackageInfo to %s: %v", outputFile, err)
+		return false, fmt.Errorf("failed to write PackageInfo to %s: %v", outputFile, err)
 	}
-	defer f.Close()
-
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	err = encoder.Encode(&pkgInfos)
+	recordOutput(outputFile, needsReview)
 
-	if err != nil {
-		Logger.Warnf("Failed to encode PackageInfo to %s: %v", outputFile, err)
-		return fmt.Errorf("failed to encode PackageInfo to %s: %v", outputFile, err)
+	if needsReview {
+		Logger.Infof("[%s]: Generated PackageInfo record for %q for review (confidence %.2f)", CVE.ID, maybeProductName, confidence)
+	} else {
+		Logger.Infof("[%s]: Generated PackageInfo record for %q", CVE.ID, maybeProductName)
 	}
 
-	Logger.Infof("[%s]: Generated PackageInfo record for %q", CVE.ID, maybeProductName)
-
 	if len(notes) > 0 {
 		err = os.WriteFile(notesFile, []byte(strings.Join(notes, "\n")), 0660)
 		if err != nil {
@@ -426,7 +512,16 @@ func CVEToPackageInfo(CVE cves.CVE, repos []string, cache git.RepoTagsCache, dir
 		}
 	}
 
-	return nil
+	return needsReview, nil
+}
+
+// recordOutput records a written record or part in the output manifest.
+func recordOutput(outputFile string, needsReview bool) {
+	outputFlags.Add(outputFile)
+	outputFlags.Count("records", 1)
+	if needsReview {
+		outputFlags.Count("needs_review", 1)
+	}
 }
 
 func loadCPEDictionary(ProductToRepo *VendorProductToRepoMap, f string) error {
@@ -434,7 +529,58 @@ func loadCPEDictionary(ProductToRepo *VendorProductToRepoMap, f string) error {
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, &ProductToRepo)
+	if err := json.Unmarshal(data, &ProductToRepo); err != nil {
+		return err
+	}
+	// Maps generated before repos were canonicalized may list a repo more
+	// than once.
+	for vp, repos := range *ProductToRepo {
+		(*ProductToRepo)[vp] = repourl.Dedup(repos)
+	}
+	return nil
+}
+
+// loadCPEIndex loads the CPE dictionary index and, optionally, manual overrides for it.
+func loadCPEIndex(indexPath string, overridesPath string) (*cpedict.Index, error) {
+	file, err := os.Open(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	idx, err := cpedict.ReadIndex(file)
+	if err != nil {
+		return nil, err
+	}
+	if overridesPath == "" {
+		return idx, nil
+	}
+	overrides, err := os.Open(overridesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer overrides.Close()
+	return idx, idx.LoadOverrides(overrides)
+}
+
+// reposFromCPEIndex returns the repos of the most confident CPE dictionary
+// index match for a CPE, if it is at least minConfidence.
+func reposFromCPEIndex(idx *cpedict.Index, CPEstr string, minConfidence float64) ([]string, *cpedict.Match) {
+	if idx == nil {
+		return nil, nil
+	}
+	matches, err := idx.Match(CPEstr)
+	if err != nil {
+		return nil, nil
+	}
+	for _, m := range matches {
+		if m.Confidence < minConfidence {
+			break
+		}
+		if len(m.Repos) > 0 {
+			return m.Repos, &m
+		}
+	}
+	return nil, nil
 }
 
 // Adds the repo to the cache for the Vendor/Product combination if not already present.
@@ -501,19 +647,120 @@ func outputOutcomes(outcomes map[cves.CVEID]ConversionOutcome, reposForCVE map[c
 	return nil
 }
 
+// auditOutcomes records the conversion outcome of each CVE and how its repos were derived.
+func auditOutcomes(ctx context.Context, auditSink *audit.Sink, outcomes map[cves.CVEID]ConversionOutcome, repoSources map[cves.CVEID]string) {
+	CVEIDs := make([]cves.CVEID, 0, len(outcomes))
+	for CVEID := range outcomes {
+		CVEIDs = append(CVEIDs, CVEID)
+	}
+	slices.Sort(CVEIDs)
+	for _, CVEID := range CVEIDs {
+		auditSink.Record(ctx, string(CVEID), []string{"nvd-cve"},
+			audit.Decision{Name: "outcome", Value: outcomes[CVEID].String()},
+			audit.Decision{Name: "repo_source", Value: repoSources[CVEID]},
+		)
+	}
+}
+
+// enrichmentRequests describes the CVEs that had no repos, to ask the enrichment service about.
+func enrichmentRequests(vulnerabilities []cves.Vulnerability, outcomes map[cves.CVEID]ConversionOutcome) []enrichment.Request {
+	var requests []enrichment.Request
+	for _, cve := range vulnerabilities {
+		if outcomes[cve.CVE.ID] != NoRepos {
+			continue
+		}
+		r := enrichment.Request{
+			CVE:         cve.CVE.ID,
+			Description: cves.EnglishDescription(cve.CVE),
+			CPEs:        cves.CPEs(cve.CVE),
+		}
+		for _, ref := range cve.CVE.References {
+			r.References = append(r.References, ref.Url)
+		}
+		requests = append(requests, r)
+	}
+	return requests
+}
+
+// enrichUnmappable asks the enrichment service for proposals for the CVEs
+// that had no repos, and queues them for curation. Failures are logged, never fatal.
+func enrichUnmappable(ctx context.Context, enricher enrichment.Enricher, vulnerabilities []cves.Vulnerability, outcomes map[cves.CVEID]ConversionOutcome, queueDir string) {
+	requests := enrichmentRequests(vulnerabilities, outcomes)
+	if len(requests) == 0 {
+		return
+	}
+	proposals, err := enrichment.ProposeInBatches(ctx, enricher, requests, *enrichmentBatchSize, *enrichmentTimeout)
+	if err != nil {
+		Logger.Warnf("Enrichment partially failed: %v", err)
+	}
+	queued, err := enrichment.WriteQueue(queueDir, proposals, *enrichmentMinConf)
+	if err != nil {
+		Logger.Warnf("Failed to write enrichment proposals to %s: %v", queueDir, err)
+		return
+	}
+	Logger.Infof("Queued enrichment proposals for %d of %d CVEs without repos", queued, len(requests))
+}
+
 func main() {
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
 	if !slices.Contains([]string{"OSV", "PackageInfo"}, *outFormat) {
 		fmt.Fprintf(os.Stderr, "Unsupported output format: %s\n", *outFormat)
 		os.Exit(1)
 	}
 
 	Metrics.Outcomes = make(map[cves.CVEID]ConversionOutcome)
+	if *runID == "" {
+		*runID = filepath.Base(*jsonPath) + "@" + time.Now().UTC().Format(time.RFC3339)
+	}
+	Generator = vulns.NewGenerator("nvd-cve-osv", *runID, utility.ReadBuildInfo())
 
 	var logCleanup func()
 	Logger, logCleanup = utility.CreateLoggerWrapper("nvd-cve-osv")
 	defer logCleanup()
 
+	var storageClient *storage.Client
+	// gcsStore returns the TagStore of a gs://bucket/prefix flag value.
+	gcsStore := func(name string, url string) git.TagStore {
+		bucket, prefix, err := upload.ParseGCSURL(url)
+		if err != nil {
+			Logger.Fatalf("Invalid -%s: %v", name, err)
+		}
+		if storageClient == nil {
+			if storageClient, err = storage.NewClient(context.Background()); err != nil {
+				Logger.Fatalf("Failed to create storage client: %v", err)
+			}
+		}
+		return git.GCSTagStore{Bucket: storageClient.Bucket(bucket), Prefix: prefix}
+	}
+	defer func() {
+		if storageClient != nil {
+			storageClient.Close()
+		}
+	}()
+
+	var tagStores []git.TagStore
+	if *tagCacheDir != "" {
+		tagStores = append(tagStores, git.DirTagStore(*tagCacheDir))
+	}
+	if *tagCacheGCS != "" {
+		tagStores = append(tagStores, gcsStore("tag_cache_gcs", *tagCacheGCS))
+	}
+	TagCache = git.NewTagCache(tagStores...)
+	switch {
+	case *commitReleasesGCS != "":
+		CommitReleases = newCommitReleases(gcsStore("commit_releases_gcs", *commitReleasesGCS))
+	case *commitReleasesDir != "":
+		CommitReleases = newCommitReleases(git.DirTagStore(*commitReleasesDir))
+	}
+
+	if *verifyFixCommits {
+		FixCommitVerifier = fixcommits.NewHTTPVerifier(github.NewClientFromEnv())
+	}
+
 	data, err := os.ReadFile(*jsonPath)
 	if err != nil {
 		Logger.Fatalf("Failed to open file: %v", err) // double check this is best practice output
@@ -524,6 +771,15 @@ func main() {
 	if err != nil {
 		Logger.Fatalf("Failed to parse NVD CVE JSON: %v", err)
 	}
+	if *cveHistoryPath != "" {
+		history, err := cves.LoadChangeHistory(*cveHistoryPath)
+		if err != nil {
+			Logger.Fatalf("Failed to load the CVE change history: %v", err)
+		}
+		for i := range parsed.Vulnerabilities {
+			history.Apply(&parsed.Vulnerabilities[i].CVE)
+		}
+	}
 
 	VPRepoCache := make(VendorProductToRepoMap)
 
@@ -535,13 +791,38 @@ func main() {
 		Logger.Infof("VendorProductToRepoMap cache has %d entries preloaded", len(VPRepoCache))
 	}
 
+	var CPEIndex *cpedict.Index
+	if *cpeIndexPath != "" {
+		CPEIndex, err = loadCPEIndex(*cpeIndexPath, *cpeOverridesPath)
+		if err != nil {
+			Logger.Fatalf("Failed to load CPE dictionary index: %v", err)
+		}
+		Logger.Infof("CPE dictionary index has %d products", CPEIndex.Len())
+	}
+
 	ReposForCVE := make(map[cves.CVEID][]string)
+	// Records how the repos of each CVE were derived, for auditing.
+	RepoSourceForCVE := make(map[cves.CVEID]string)
+	// The VendorProducts added to VPRepoCache from the CPE dictionary index.
+	CPEIndexDerived := make(map[VendorProduct]bool)
+	// The confidence of the CPE dictionary index match of those VendorProducts.
+	CPEIndexConfidence := make(map[VendorProduct]float64)
+	review := reviewPolicy{minConfidence: *reviewMinConf, dir: *reviewDir}
+	if review.dir == "" {
+		review.dir = filepath.Join(*outDir, "needs_review")
+	}
 
 	for _, cve := range parsed.Vulnerabilities {
 		refs := cve.CVE.References
 		CPEs := cves.CPEs(cve.CVE)
 		CVEID := cve.CVE.ID
 
+		if cves.IsRejected(cve.CVE) {
+			Logger.Infof("[%s]: skipping rejected CVE", CVEID)
+			Metrics.Outcomes[CVEID] = Rejected
+			continue
+		}
+
 		if len(refs) == 0 && len(CPEs) == 0 {
 			Logger.Infof("[%s]: skipping due to lack of CPEs and lack of references", CVEID)
 			// 100% of these in 2022 were rejected CVEs
@@ -558,6 +839,7 @@ func main() {
 			}
 			Logger.Infof("[%s]: Derived %q for CVE with no CPEs", CVEID, repos)
 			ReposForCVE[CVEID] = repos
+			RepoSourceForCVE[CVEID] = "references"
 		}
 
 		// Does it have any application CPEs? Look for pre-computed repos based on VendorProduct.
@@ -572,10 +854,22 @@ func main() {
 			if CPE.Part == "a" {
 				appCPECount += 1
 			}
+			if _, ok := VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}]; !ok && CPE.Part == "a" {
+				if repos, match := reposFromCPEIndex(CPEIndex, CPEstr, *cpeMinConfidence); len(repos) > 0 {
+					Logger.Infof("[%s]: Pre-references, derived %q for %q %q using CPE dictionary index (%s match of %q %q, confidence %.2f)", CVEID, repos, CPE.Vendor, CPE.Product, match.Reason, match.Vendor, match.Product, match.Confidence)
+					VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}] = repos
+					CPEIndexDerived[VendorProduct{CPE.Vendor, CPE.Product}] = true
+					CPEIndexConfidence[VendorProduct{CPE.Vendor, CPE.Product}] = match.Confidence
+				}
+			}
 			if _, ok := VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}]; ok {
 				Logger.Infof("[%s]: Pre-references, derived %q for %q %q using cache", CVEID, VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}], CPE.Vendor, CPE.Product)
 				if _, ok := ReposForCVE[CVEID]; !ok {
 					ReposForCVE[CVEID] = VPRepoCache[VendorProduct{CPE.Vendor, CPE.Product}]
+					RepoSourceForCVE[CVEID] = "cpe_repos"
+					if CPEIndexDerived[VendorProduct{CPE.Vendor, CPE.Product}] {
+						RepoSourceForCVE[CVEID] = "cpe_index"
+					}
 					continue
 				}
 				// Don't append duplicates.
@@ -622,6 +916,7 @@ func main() {
 				}
 				Logger.Infof("[%s]: Derived %q for %q %q", CVEID, repos, CPE.Vendor, CPE.Product)
 				ReposForCVE[CVEID] = repos
+				RepoSourceForCVE[CVEID] = "references"
 			}
 		}
 
@@ -648,11 +943,24 @@ func main() {
 
 		Metrics.CVEsForKnownRepos++
 
+		repoConfidence := repoSourceConfidence[RepoSourceForCVE[CVEID]]
+		if RepoSourceForCVE[CVEID] == "cpe_index" {
+			repoConfidence = 1.0
+			for _, CPEstr := range CPEs {
+				if CPE, err := cves.ParseCPE(CPEstr); err == nil {
+					if c, ok := CPEIndexConfidence[VendorProduct{CPE.Vendor, CPE.Product}]; ok {
+						repoConfidence = min(repoConfidence, c)
+					}
+				}
+			}
+		}
+
+		var needsReview bool
 		switch *outFormat {
 		case "OSV":
-			err = CVEToOSV(cve.CVE, ReposForCVE[CVEID], RepoTagsCache, *outDir)
+			needsReview, err = CVEToOSV(cve.CVE, ReposForCVE[CVEID], repoConfidence, TagCache, *outDir, review)
 		case "PackageInfo":
-			err = CVEToPackageInfo(cve.CVE, ReposForCVE[CVEID], RepoTagsCache, *outDir)
+			needsReview, err = CVEToPackageInfo(cve.CVE, ReposForCVE[CVEID], repoConfidence, TagCache, *outDir, review)
 		}
 		// Parse this error to determine which failure mode it was
 		if err != nil {
@@ -668,16 +976,54 @@ func main() {
 			Metrics.Outcomes[CVEID] = ConversionUnknown
 			continue
 		}
+		if needsReview {
+			Metrics.RecordsForReview++
+			Metrics.Outcomes[CVEID] = NeedsReview
+			continue
+		}
 		Metrics.OSVRecordsGenerated++
 		Metrics.Outcomes[CVEID] = Successful
 	}
 	Metrics.TotalCVEs = len(parsed.Vulnerabilities)
+	if *enrichmentURL != "" {
+		var enricher enrichment.Enricher = &enrichment.HTTPEnricher{URL: *enrichmentURL}
+		if *enrichmentCache != "" {
+			enricher = &enrichment.CachingEnricher{Enricher: enricher, Dir: *enrichmentCache}
+		}
+		queueDir := *enrichmentQueue
+		if queueDir == "" {
+			queueDir = filepath.Join(*outDir, "curation_queue")
+		}
+		enrichUnmappable(context.Background(), enricher, parsed.Vulnerabilities, Metrics.Outcomes, queueDir)
+	}
 	err = outputOutcomes(Metrics.Outcomes, ReposForCVE, *outDir)
 	if err != nil {
 		// Log entry with size 1.15M exceeds maximum size of 256.0K
 		fmt.Fprintf(os.Stderr, "Failed to write out metrics: %v", err)
 	}
+	if *curationReport != "" {
+		entries := curationEntries(parsed.Vulnerabilities, Metrics.Outcomes)
+		if err := writeCurationReport(filepath.Join(*outDir, *curationReport), entries); err != nil {
+			Logger.Warnf("Failed to write curation report: %v", err)
+		} else {
+			Logger.Infof("%d CVEs need curation", len(entries))
+		}
+	}
+	if *auditTable != "" {
+		ctx := context.Background()
+		auditSink, err := audit.NewBigQuerySink(ctx, *auditTable, "nvd-cve-osv", *runID)
+		if err != nil {
+			Logger.Fatalf("Failed to create audit sink: %v", err)
+		}
+		auditOutcomes(ctx, auditSink, Metrics.Outcomes, RepoSourceForCVE)
+		if err := auditSink.Close(ctx); err != nil {
+			Logger.Warnf("Failed to write audit events: %v", err)
+		}
+	}
 	// Outcomes is too big to log, so zero it out.
 	Metrics.Outcomes = nil
 	Logger.Infof("%s Metrics: %+v", filepath.Base(*jsonPath), Metrics)
+	if err := outputFlags.Finish("nvd-cve-osv"); err != nil {
+		Logger.Fatalf("Conversion of %s failed: %v", filepath.Base(*jsonPath), err)
+	}
 }
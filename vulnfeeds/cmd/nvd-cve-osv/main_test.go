@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"os"
 	"reflect"
 	"testing"
 
+	gocmp "github.com/google/go-cmp/cmp"
+
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/enrichment"
 )
 
 func TestReposFromReferences(t *testing.T) {
@@ -172,3 +177,47 @@ func Test_maybeUpdateVPRepoCache(t *testing.T) {
 		})
 	}
 }
+
+type fakeEnricher struct{}
+
+func (fakeEnricher) Propose(_ context.Context, requests []enrichment.Request) ([]enrichment.Proposal, error) {
+	var proposals []enrichment.Proposal
+	for _, r := range requests {
+		proposals = append(proposals, enrichment.Proposal{CVE: r.CVE, PackageName: "libfoo", Confidence: 0.9})
+	}
+	return proposals, nil
+}
+
+func TestEnrichUnmappable(t *testing.T) {
+	vulnerabilities := []cves.Vulnerability{
+		{CVE: cves.CVE{
+			ID:           "CVE-2024-0001",
+			Descriptions: []cves.LangString{{Lang: "en", Value: "A flaw in libfoo."}},
+			References:   []cves.Reference{{Url: "https://example.com/advisory"}},
+		}},
+		{CVE: cves.CVE{ID: "CVE-2024-0002"}},
+	}
+	outcomes := map[cves.CVEID]ConversionOutcome{
+		"CVE-2024-0001": NoRepos,
+		"CVE-2024-0002": Successful,
+	}
+
+	want := []enrichment.Request{{
+		CVE:         "CVE-2024-0001",
+		Description: "A flaw in libfoo.",
+		References:  []string{"https://example.com/advisory"},
+	}}
+	if diff := gocmp.Diff(want, enrichmentRequests(vulnerabilities, outcomes)); diff != "" {
+		t.Errorf("enrichmentRequests() mismatch (-want +got):\n%s", diff)
+	}
+
+	queueDir := t.TempDir()
+	enrichUnmappable(context.Background(), fakeEnricher{}, vulnerabilities, outcomes, queueDir)
+	entries, err := os.ReadDir(queueDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "CVE-2024-0001.proposals.json" {
+		t.Errorf("enrichUnmappable() queued %v", entries)
+	}
+}
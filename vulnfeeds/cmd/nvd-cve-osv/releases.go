@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// releasesDatabaseSpecificKey is the database_specific key of a GIT range's
+// events as releases.
+const releasesDatabaseSpecificKey = "releases"
+
+// commitReleases loads the commit release tables stored by commit-releases,
+// once per repo.
+type commitReleases struct {
+	store git.TagStore
+
+	mu sync.Mutex
+	// tables is nil for the repos without a table.
+	tables map[string]*git.CommitReleases
+}
+
+func newCommitReleases(store git.TagStore) *commitReleases {
+	return &commitReleases{store: store, tables: make(map[string]*git.CommitReleases)}
+}
+
+// table returns the commit release table of repo, or nil if it has none.
+func (c *commitReleases) table(ctx context.Context, repo string) *git.CommitReleases {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if table, ok := c.tables[repo]; ok {
+		return table
+	}
+	table, err := git.LoadCommitReleases(ctx, c.store, repo)
+	if err != nil {
+		if !errors.Is(err, git.ErrCacheMiss) {
+			Logger.Warnf("Failed to load the commit releases of %s: %v", repo, err)
+		}
+		table = nil
+	}
+	c.tables[repo] = table
+	return table
+}
+
+// annotate records, in the database_specific of each GIT range of affected,
+// the earliest release containing the commit of each of its introduced and
+// fixed events. last_affected and limit events have no equivalent release:
+// the earliest release containing the last affected commit needn't be the
+// last one affected. Ranges of repos without a table are left as they are,
+// as is everything when c is nil.
+func (c *commitReleases) annotate(ctx context.Context, affected *vulns.Affected) {
+	if c == nil {
+		return
+	}
+	for i := range affected.Ranges {
+		r := &affected.Ranges[i]
+		if r.Type != "GIT" {
+			continue
+		}
+		table := c.table(ctx, r.Repo)
+		if table == nil {
+			continue
+		}
+		var releases []vulns.Event
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced == "0":
+				releases = append(releases, vulns.Event{Introduced: "0"})
+			case e.Introduced != "":
+				if release, err := table.Release(e.Introduced); err == nil {
+					releases = append(releases, vulns.Event{Introduced: release})
+				}
+			case e.Fixed != "":
+				if release, err := table.Release(e.Fixed); err == nil {
+					releases = append(releases, vulns.Event{Fixed: release})
+				}
+			}
+		}
+		if !slices.ContainsFunc(releases, func(e vulns.Event) bool { return e.Introduced != "0" }) {
+			continue
+		}
+		if r.DatabaseSpecific == nil {
+			r.DatabaseSpecific = make(map[string]any)
+		}
+		r.DatabaseSpecific[releasesDatabaseSpecificKey] = releases
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestCommitReleasesAnnotate(t *testing.T) {
+	ctx := context.Background()
+	store := git.DirTagStore(t.TempDir())
+	table := &git.CommitReleases{
+		Repo:    "https://github.com/example/libfoo",
+		Tags:    []string{"v1.0.0", "v1.1.0"},
+		Commits: map[string]int{"aaaa": 0, "bbbb": 1},
+	}
+	if err := git.StoreCommitReleases(ctx, store, table); err != nil {
+		t.Fatal(err)
+	}
+
+	affected := vulns.Affected{Ranges: []vulns.AffectedRange{
+		{Type: "GIT", Repo: "https://github.com/example/libfoo", Events: []vulns.Event{{Introduced: "aaaa"}, {Fixed: "bbbb"}}},
+		{Type: "GIT", Repo: "https://github.com/example/libfoo", Events: []vulns.Event{{Introduced: "0"}, {LastAffected: "bbbb"}}},
+		{Type: "GIT", Repo: "https://github.com/example/libbar", Events: []vulns.Event{{Introduced: "aaaa"}, {Fixed: "bbbb"}}},
+	}}
+	newCommitReleases(store).annotate(ctx, &affected)
+
+	want := vulns.Affected{Ranges: []vulns.AffectedRange{
+		{
+			Type:             "GIT",
+			Repo:             "https://github.com/example/libfoo",
+			Events:           []vulns.Event{{Introduced: "aaaa"}, {Fixed: "bbbb"}},
+			DatabaseSpecific: map[string]any{releasesDatabaseSpecificKey: []vulns.Event{{Introduced: "v1.0.0"}, {Fixed: "v1.1.0"}}},
+		},
+		{Type: "GIT", Repo: "https://github.com/example/libfoo", Events: []vulns.Event{{Introduced: "0"}, {LastAffected: "bbbb"}}},
+		{Type: "GIT", Repo: "https://github.com/example/libbar", Events: []vulns.Event{{Introduced: "aaaa"}, {Fixed: "bbbb"}}},
+	}}
+	if diff := gocmp.Diff(want, affected); diff != "" {
+		t.Errorf("annotate() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// maxAffectedVersions is the number of affected versions beyond which a
+// CVE's extracted versions are suspicious, e.g. every release being listed
+// instead of a range.
+const maxAffectedVersions = 50
+
+const nvdDetailURLBase = "https://nvd.nist.gov/vuln/detail/"
+
+// curationEntry is a CVE whose version extraction failed or produced
+// suspicious results, for a human to review.
+type curationEntry struct {
+	CVE              cves.CVEID
+	Outcome          ConversionOutcome
+	AffectedVersions int
+	Reasons          []string
+}
+
+func (e curationEntry) NVDURL() string {
+	return nvdDetailURLBase + string(e.CVE)
+}
+
+// compareVersions compares normalized versions component by component,
+// numerically where both components are numbers.
+func compareVersions(a, b string) (int, error) {
+	na, err := cves.NormalizeVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	nb, err := cves.NormalizeVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	ca, cb := strings.Split(na, "-"), strings.Split(nb, "-")
+	for i := 0; i < len(ca) && i < len(cb); i++ {
+		x, xErr := strconv.Atoi(ca[i])
+		y, yErr := strconv.Atoi(cb[i])
+		if xErr == nil && yErr == nil {
+			if x != y {
+				return x - y, nil
+			}
+			continue
+		}
+		if c := strings.Compare(ca[i], cb[i]); c != 0 {
+			return c, nil
+		}
+	}
+	return len(ca) - len(cb), nil
+}
+
+// suspiciousVersions returns why the versions extracted for a CVE are
+// suspicious, if they are.
+func suspiciousVersions(versions cves.VersionInfo) []string {
+	var reasons []string
+	if len(versions.AffectedVersions) == 0 && len(versions.AffectedCommits) == 0 {
+		reasons = append(reasons, "no versions or commits extracted")
+	}
+	for _, av := range versions.AffectedVersions {
+		if av.Introduced == "" || av.Fixed == "" {
+			continue
+		}
+		if c, err := compareVersions(av.Fixed, av.Introduced); err == nil && c < 0 {
+			reasons = append(reasons, fmt.Sprintf("fixed %s < introduced %s", av.Fixed, av.Introduced))
+		}
+	}
+	if len(versions.AffectedVersions) > maxAffectedVersions {
+		reasons = append(reasons, fmt.Sprintf("%d affected versions", len(versions.AffectedVersions)))
+	}
+	return reasons
+}
+
+// curationEntries returns the CVEs with repos whose conversion failed or
+// whose extracted versions are suspicious.
+func curationEntries(vulnerabilities []cves.Vulnerability, outcomes map[cves.CVEID]ConversionOutcome) []curationEntry {
+	var entries []curationEntry
+	for _, cve := range vulnerabilities {
+		outcome, ok := outcomes[cve.CVE.ID]
+		if !ok {
+			continue
+		}
+		var reasons []string
+		switch outcome {
+		case Rejected, NoSoftware, NoRepos:
+			// Version extraction wasn't attempted.
+			continue
+		case NoRanges:
+			reasons = append(reasons, "no affected ranges could be derived")
+		case FixUnresolvable:
+			reasons = append(reasons, "fixed versions couldn't be resolved to commits")
+		case ConversionUnknown:
+			reasons = append(reasons, "conversion failed")
+		case NeedsReview:
+			reasons = append(reasons, "converted with too little confidence to publish")
+		}
+		versions, _ := cves.ExtractVersionInfo(cve.CVE, nil)
+		reasons = append(reasons, suspiciousVersions(versions)...)
+		if len(reasons) == 0 {
+			continue
+		}
+		entries = append(entries, curationEntry{
+			CVE:              cve.CVE.ID,
+			Outcome:          outcome,
+			AffectedVersions: len(versions.AffectedVersions),
+			Reasons:          reasons,
+		})
+	}
+	return entries
+}
+
+func writeCurationMarkdown(w io.Writer, entries []curationEntry) error {
+	if _, err := fmt.Fprintf(w, "# CVEs needing curation\n\n%d CVEs had failed or suspicious version extraction.\n\n", len(entries)); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if _, err := io.WriteString(w, "| CVE | Outcome | Affected versions | Reasons |\n| --- | --- | --- | --- |\n"); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "| [%s](%s) | %s | %d | %s |\n", e.CVE, e.NVDURL(), e.Outcome, e.AffectedVersions, strings.Join(e.Reasons, "; ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var curationHTMLTemplate = template.Must(template.New("curation").Parse(`<!DOCTYPE html>
+<html>
+<head><title>CVEs needing curation</title></head>
+<body>
+<h1>CVEs needing curation</h1>
+<p>{{len .}} CVEs had failed or suspicious version extraction.</p>
+{{if .}}<table>
+<tr><th>CVE</th><th>Outcome</th><th>Affected versions</th><th>Reasons</th></tr>
+{{range .}}<tr><td><a href="{{.NVDURL}}">{{.CVE}}</a></td><td>{{.Outcome}}</td><td>{{.AffectedVersions}}</td><td>{{range $i, $r := .Reasons}}{{if $i}}; {{end}}{{$r}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// writeCurationReport writes the report of CVEs needing curation to path, as
+// HTML if it ends in .html and Markdown otherwise.
+func writeCurationReport(path string, entries []curationEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".html" {
+		err = curationHTMLTemplate.Execute(f, entries)
+	} else {
+		err = writeCurationMarkdown(f, entries)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
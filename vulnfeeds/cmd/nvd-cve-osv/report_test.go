@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestSuspiciousVersions(t *testing.T) {
+	var many []cves.AffectedVersion
+	for i := 0; i <= maxAffectedVersions; i++ {
+		many = append(many, cves.AffectedVersion{LastAffected: fmt.Sprintf("1.%d", i)})
+	}
+	tests := []struct {
+		name     string
+		versions cves.VersionInfo
+		want     []string
+	}{
+		{
+			name:     "empty",
+			versions: cves.VersionInfo{},
+			want:     []string{"no versions or commits extracted"},
+		},
+		{
+			name:     "fixed before introduced",
+			versions: cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Introduced: "2.10.0", Fixed: "2.9.1"}}},
+			want:     []string{"fixed 2.9.1 < introduced 2.10.0"},
+		},
+		{
+			name:     "valid range",
+			versions: cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Introduced: "2.9.1", Fixed: "2.10.0"}}},
+		},
+		{
+			name:     "too many affected versions",
+			versions: cves.VersionInfo{AffectedVersions: many},
+			want:     []string{"51 affected versions"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := gocmp.Diff(tc.want, suspiciousVersions(tc.versions)); diff != "" {
+				t.Errorf("suspiciousVersions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteCurationReport(t *testing.T) {
+	vulnerabilities := []cves.Vulnerability{
+		{CVE: cves.CVE{ID: "CVE-2024-0001"}},
+		{CVE: cves.CVE{ID: "CVE-2024-0002"}},
+		{CVE: cves.CVE{ID: "CVE-2024-0003"}},
+	}
+	outcomes := map[cves.CVEID]ConversionOutcome{
+		"CVE-2024-0001": NoRanges,
+		"CVE-2024-0002": NoRepos,
+	}
+	entries := curationEntries(vulnerabilities, outcomes)
+	want := []curationEntry{{
+		CVE:     "CVE-2024-0001",
+		Outcome: NoRanges,
+		Reasons: []string{"no affected ranges could be derived", "no versions or commits extracted"},
+	}}
+	if diff := gocmp.Diff(want, entries); diff != "" {
+		t.Fatalf("curationEntries() mismatch (-want +got):\n%s", diff)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"curation_report.md", "curation_report.html"} {
+		path := filepath.Join(dir, name)
+		if err := writeCurationReport(path, entries); err != nil {
+			t.Fatalf("writeCurationReport(%q) failed: %v", name, err)
+		}
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(buf), "https://nvd.nist.gov/vuln/detail/CVE-2024-0001") {
+			t.Errorf("writeCurationReport(%q) has no NVD link:\n%s", name, buf)
+		}
+	}
+}
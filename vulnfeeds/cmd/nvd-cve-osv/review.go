@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// repoSourceConfidence is the confidence in the repos of a CVE by how they
+// were derived. Repos from the CPE dictionary index take the confidence of
+// the index match instead.
+var repoSourceConfidence = map[string]float64{
+	"cpe_repos":  1.0,
+	"references": 0.8,
+}
+
+// reviewPolicy decides which records are confident enough to publish. The
+// rest are written to a separate directory for a human to review.
+type reviewPolicy struct {
+	minConfidence float64
+	dir           string
+}
+
+// directory returns the directory to write a record of the given confidence
+// to, and whether it needs review.
+func (p reviewPolicy) directory(outDir string, confidence float64) (string, bool) {
+	if p.dir == "" || confidence >= p.minConfidence {
+		return outDir, false
+	}
+	return p.dir, true
+}
+
+// conversionConfidence returns the confidence of the ranges converted from a
+// CVE's versions, given the confidence of its repos.
+func conversionConfidence(versions cves.VersionInfo, repoConfidence float64) float64 {
+	return versions.VersionConfidence() * repoConfidence
+}
+
+// setRangeConfidence records the confidence of each of the affected ranges.
+func setRangeConfidence(affected *vulns.Affected, confidence float64) {
+	for i := range affected.Ranges {
+		if affected.Ranges[i].DatabaseSpecific == nil {
+			affected.Ranges[i].DatabaseSpecific = make(map[string]any)
+		}
+		affected.Ranges[i].DatabaseSpecific["confidence"] = confidence
+	}
+}
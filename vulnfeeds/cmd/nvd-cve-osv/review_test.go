@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestReviewPolicy(t *testing.T) {
+	review := reviewPolicy{minConfidence: 0.7, dir: "/review"}
+	tests := []struct {
+		name            string
+		versions        cves.VersionInfo
+		repoConfidence  float64
+		wantDir         string
+		wantNeedsReview bool
+	}{
+		{
+			name:           "CPE versions and curated repos",
+			versions:       cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Fixed: "1.2.3"}}},
+			repoConfidence: repoSourceConfidence["cpe_repos"],
+			wantDir:        "/out",
+		},
+		{
+			name:           "description versions and curated repos",
+			versions:       cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Fixed: "1.2.3", Confidence: 0.8}}},
+			repoConfidence: repoSourceConfidence["cpe_repos"],
+			wantDir:        "/out",
+		},
+		{
+			name:            "description versions and repos from references",
+			versions:        cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Fixed: "1.2.3", Confidence: 0.8}}},
+			repoConfidence:  repoSourceConfidence["references"],
+			wantDir:         "/review",
+			wantNeedsReview: true,
+		},
+		{
+			name:            "fuzzy CPE dictionary index match",
+			versions:        cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Fixed: "1.2.3"}}},
+			repoConfidence:  0.5,
+			wantDir:         "/review",
+			wantNeedsReview: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, needsReview := review.directory("/out", conversionConfidence(tc.versions, tc.repoConfidence))
+			if dir != tc.wantDir || needsReview != tc.wantNeedsReview {
+				t.Errorf("directory() = %q, %v, want %q, %v", dir, needsReview, tc.wantDir, tc.wantNeedsReview)
+			}
+		})
+	}
+
+	disabled := reviewPolicy{dir: "/review"}
+	if dir, needsReview := disabled.directory("/out", 0.1); dir != "/out" || needsReview {
+		t.Errorf("directory() with review disabled = %q, %v, want /out, false", dir, needsReview)
+	}
+}
+
+func TestSetRangeConfidence(t *testing.T) {
+	affected := vulns.Affected{Ranges: []vulns.AffectedRange{
+		{Type: "GIT", Repo: "https://github.com/foo/bar"},
+		{Type: "GIT", Repo: "https://github.com/foo/baz", DatabaseSpecific: map[string]any{"source": "x"}},
+	}}
+	setRangeConfidence(&affected, 0.64)
+	want := []map[string]any{{"confidence": 0.64}, {"source": "x", "confidence": 0.64}}
+	var got []map[string]any
+	for _, r := range affected.Ranges {
+		got = append(got, r.DatabaseSpecific)
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("setRangeConfidence() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -52,6 +52,7 @@ for (( YEAR = $(date +%Y) ; YEAR >= ${FIRST_INSCOPE_YEAR} ; YEAR-- )); do
     --cpe_repos "${WORK_DIR}/cpe_product_to_repo.json" \
     --nvd_json "${WORK_DIR}/nvd/nvdcve-2.0-${YEAR}.json" \
     --out_dir "${WORK_DIR}/nvd2osv/${YEAR}" \
+    --review_dir "${WORK_DIR}/nvd2osv/needs_review/${YEAR}" \
     --out_format PackageInfo
 
   # Copy results to staging area.
@@ -83,4 +84,11 @@ fi
 echo "Copying NVD CVE records successfully converted to GCS bucket"
 gsutil -q -m rsync -c -d "${WORK_DIR}/nvd2osv/gcs_stage" "${OSV_OUTPUT_GCS_PATH}"
 
+# Low-confidence records are kept out of the published output; copy them
+# for review if there's somewhere to.
+if [[ -n "${REVIEW_GCS_PATH:-}" && -d "${WORK_DIR}/nvd2osv/needs_review" ]]; then
+  echo "Copying NVD CVE records needing review to GCS bucket"
+  gsutil -q -m rsync -r -c -d "${WORK_DIR}/nvd2osv/needs_review" "${REVIEW_GCS_PATH}"
+fi
+
 echo "Conversion run complete"
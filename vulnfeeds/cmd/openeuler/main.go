@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	openEulerCVRFURLBase       = "https://repo.openeuler.org/security/data/cvrf/"
+	openEulerCVRFIndexURL      = openEulerCVRFURLBase + "index.txt"
+	openEulerOutputPathDefault = "parts/openeuler"
+	openEulerEcosystem         = "openEuler"
+)
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("openeuler-osv")
+	defer logCleanup()
+
+	openEulerOutputPath := flag.String(
+		"openEulerOutput",
+		openEulerOutputPathDefault,
+		"path to output general openEuler affected package information")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	err := os.MkdirAll(*openEulerOutputPath, 0755)
+	if err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+
+	advisoryPaths, err := downloadCVRFIndex()
+	if err != nil {
+		Logger.Fatalf("Failed to get openEuler CVRF index: %s", err)
+	}
+
+	advisories := make([]CVRF, 0, len(advisoryPaths))
+	for _, advisoryPath := range advisoryPaths {
+		advisory, err := downloadCVRF(advisoryPath)
+		if err != nil {
+			Logger.Warnf("Failed to get openEuler advisory %s: %s", advisoryPath, err)
+			continue
+		}
+		advisories = append(advisories, advisory)
+	}
+
+	cvePkgInfos := generateOpenEulerOSV(advisories)
+	if err = writeToOutput(cvePkgInfos, *openEulerOutputPath); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+	if err := outputFlags.Finish("openeuler-osv"); err != nil {
+		Logger.Fatalf("openEuler CVE conversion failed: %s", err)
+	}
+
+	Logger.Infof("openEuler CVE conversion succeeded.")
+}
+
+// releaseFromCPE returns the openEuler release of a product from its CPE,
+// e.g. "20.03-LTS-SP1" for "cpe:/a:openEuler:openEuler:20.03-LTS-SP1".
+func releaseFromCPE(cpe string) string {
+	parts := strings.Split(cpe, ":")
+	if len(parts) < 5 || !strings.EqualFold(parts[2], openEulerEcosystem) {
+		return ""
+	}
+	return parts[4]
+}
+
+// splitSourceRPM splits a source RPM file name such as
+// "openssl-1.1.1f-4.oe1.src.rpm" into its package name and
+// version-release ("openssl", "1.1.1f-4.oe1").
+func splitSourceRPM(fileName string) (name string, version string, ok bool) {
+	nvr, found := strings.CutSuffix(fileName, ".src.rpm")
+	if !found {
+		return "", "", false
+	}
+	releaseIndex := strings.LastIndex(nvr, "-")
+	if releaseIndex <= 0 {
+		return "", "", false
+	}
+	versionIndex := strings.LastIndex(nvr[:releaseIndex], "-")
+	if versionIndex <= 0 {
+		return "", "", false
+	}
+	return nvr[:versionIndex], nvr[versionIndex+1:], true
+}
+
+// fixedReleases returns the releases an advisory vulnerability is fixed in,
+// keyed by product ID.
+func fixedReleases(vuln CVRFVulnerability, releaseByProductID map[string]string) map[string]bool {
+	fixed := make(map[string]bool)
+	for _, status := range vuln.ProductStatuses {
+		if status.Type != "Fixed" {
+			continue
+		}
+		for _, productID := range status.ProductIDs {
+			if release, ok := releaseByProductID[productID]; ok {
+				fixed[release] = true
+			}
+		}
+	}
+	return fixed
+}
+
+// generateOpenEulerOSV converts openEuler CVRF advisories to OSV PackageInfo
+// format, with one fixed version per source package and openEuler release.
+// When multiple advisories fix the same package for a CVE, the earliest
+// advisory wins.
+func generateOpenEulerOSV(advisories []CVRF) map[string][]vulns.PackageInfo {
+	Logger.Infof("Converting %d openEuler advisories to OSV package infos.", len(advisories))
+
+	// Sorts advisories to ensure results remain consistent between runs.
+	sort.SliceStable(advisories, func(i, j int) bool {
+		return advisories[i].DocumentTracking.ID < advisories[j].DocumentTracking.ID
+	})
+
+	type pkgKey struct {
+		ecosystem string
+		name      string
+	}
+	seen := make(map[string]map[pkgKey]bool)
+	osvPkgInfos := make(map[string][]vulns.PackageInfo)
+
+	for _, advisory := range advisories {
+		releaseByProductID := make(map[string]string)
+		var sourcePackages []CVRFFullProductName
+		for _, branch := range advisory.ProductTree.Branches {
+			switch {
+			case branch.Type == "Product Name":
+				for _, product := range branch.Products {
+					if release := releaseFromCPE(product.CPE); release != "" {
+						releaseByProductID[product.ProductID] = release
+					}
+				}
+			case branch.Type == "Package Arch" && branch.Name == "src":
+				sourcePackages = append(sourcePackages, branch.Products...)
+			}
+		}
+
+		for _, vuln := range advisory.Vulnerabilities {
+			cveID := strings.TrimSpace(vuln.CVE)
+			if cveID == "" {
+				continue
+			}
+			fixed := fixedReleases(vuln, releaseByProductID)
+			severity := ""
+			for _, threat := range vuln.Threats {
+				if threat.Type == "Impact" {
+					severity = strings.TrimSpace(threat.Description)
+				}
+			}
+
+			for _, product := range sourcePackages {
+				release := releaseFromCPE(product.CPE)
+				if release == "" || !fixed[release] {
+					continue
+				}
+				name, version, ok := splitSourceRPM(strings.TrimSpace(product.Name))
+				if !ok {
+					Logger.Warnf("Invalid source RPM name %q in %s", product.Name, advisory.DocumentTracking.ID)
+					continue
+				}
+
+				key := pkgKey{ecosystem: openEulerEcosystem + ":" + release, name: name}
+				if seen[cveID] == nil {
+					seen[cveID] = make(map[pkgKey]bool)
+				}
+				if seen[cveID][key] {
+					continue
+				}
+				seen[cveID][key] = true
+
+				pkgInfo := vulns.PackageInfo{
+					PkgName:   name,
+					Ecosystem: key.ecosystem,
+					PURL:      purl.RPM("openeuler", name, "openEuler-"+release),
+					VersionInfo: cves.VersionInfo{
+						AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: version}},
+					},
+					EcosystemSpecific: map[string]string{
+						"advisory": advisory.DocumentTracking.ID,
+					},
+				}
+				if severity != "" {
+					pkgInfo.EcosystemSpecific["severity"] = severity
+				}
+				osvPkgInfos[cveID] = append(osvPkgInfos[cveID], pkgInfo)
+			}
+		}
+	}
+
+	return osvPkgInfos
+}
+
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, outputPath string) error {
+	Logger.Infof("Writing package infos to the output.")
+	for cveId, pkgInfos := range cvePkgInfos {
+		filePath := path.Join(outputPath, cveId+".openeuler.json")
+		if err := parts.Write(filePath, pkgInfos); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(pkgInfos))
+	}
+
+	return nil
+}
+
+// parseCVRFIndex returns the advisory paths listed in the CVRF index file.
+func parseCVRFIndex(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasSuffix(line, ".xml") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+// downloadCVRFIndex downloads the list of all openEuler CVRF advisories.
+func downloadCVRFIndex() ([]string, error) {
+	res, err := faulttolerant.Get(openEulerCVRFIndexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+
+	return parseCVRFIndex(res.Body)
+}
+
+// parseCVRF decodes an openEuler CVRF advisory.
+func parseCVRF(r io.Reader) (CVRF, error) {
+	var advisory CVRF
+	err := xml.NewDecoder(r).Decode(&advisory)
+	return advisory, err
+}
+
+// downloadCVRF downloads and parses a single openEuler CVRF advisory.
+func downloadCVRF(advisoryPath string) (CVRF, error) {
+	res, err := faulttolerant.Get(openEulerCVRFURLBase + advisoryPath)
+	if err != nil {
+		return CVRF{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return CVRF{}, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+
+	return parseCVRF(res.Body)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func Test_splitSourceRPM(t *testing.T) {
+	tests := []struct {
+		fileName    string
+		wantName    string
+		wantVersion string
+		wantOK      bool
+	}{
+		{"openssl-1.1.1f-4.oe1.src.rpm", "openssl", "1.1.1f-4.oe1", true},
+		{"python-pip-20.2.2-1.oe1.src.rpm", "python-pip", "20.2.2-1.oe1", true},
+		{"kernel-5.10.0-60.18.0.50.oe2203.src.rpm", "kernel", "5.10.0-60.18.0.50.oe2203", true},
+		{"openssl-1.1.1f-4.oe1.aarch64.rpm", "", "", false},
+		{"openssl-1.1.1f.src.rpm", "", "", false},
+	}
+	for _, tt := range tests {
+		name, version, ok := splitSourceRPM(tt.fileName)
+		if name != tt.wantName || version != tt.wantVersion || ok != tt.wantOK {
+			t.Errorf("splitSourceRPM(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.fileName, name, version, ok, tt.wantName, tt.wantVersion, tt.wantOK)
+		}
+	}
+}
+
+func Test_parseCVRFIndex(t *testing.T) {
+	file, err := os.Open("../../test_data/openeuler/index.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	got, err := parseCVRFIndex(file)
+	if err != nil {
+		t.Fatalf("parseCVRFIndex() error: %v", err)
+	}
+	want := []string{
+		"2021/cvrf-openEuler-SA-2021-1001.xml",
+		"2021/cvrf-openEuler-SA-2021-1002.xml",
+		"2022/cvrf-openEuler-SA-2022-1500.xml",
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("parseCVRFIndex() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_generateOpenEulerOSV(t *testing.T) {
+	file, err := os.Open("../../test_data/openeuler/cvrf-openEuler-SA-2021-1001.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	advisory, err := parseCVRF(file)
+	if err != nil {
+		t.Fatalf("parseCVRF() error: %v", err)
+	}
+
+	// A later advisory for the same packages must not override the earlier fix.
+	later := advisory
+	later.DocumentTracking.ID = "openEuler-SA-2021-1999"
+
+	got := generateOpenEulerOSV([]CVRF{later, advisory})
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2020-1971": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "openEuler:20.03-LTS",
+				PURL:      "pkg:rpm/openeuler/openssl?distro=openEuler-20.03-LTS",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1.1.1f-4.oe1"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "openEuler-SA-2021-1001", "severity": "High"},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "openEuler:20.09",
+				PURL:      "pkg:rpm/openeuler/openssl?distro=openEuler-20.09",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1.1.1f-7.oe1"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "openEuler-SA-2021-1001", "severity": "High"},
+			},
+		},
+		"CVE-2020-1967": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "openEuler:20.09",
+				PURL:      "pkg:rpm/openeuler/openssl?distro=openEuler-20.09",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1.1.1f-7.oe1"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "openEuler-SA-2021-1001", "severity": "Medium"},
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("generateOpenEulerOSV() mismatch (-want +got):\n%s", diff)
+	}
+}
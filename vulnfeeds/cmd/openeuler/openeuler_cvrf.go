@@ -0,0 +1,42 @@
+package main
+
+// CVRF is the subset of an openEuler CVRF security advisory needed to
+// determine the fixed source package versions of each CVE.
+type CVRF struct {
+	DocumentTitle    string `xml:"DocumentTitle"`
+	DocumentTracking struct {
+		ID                 string `xml:"Identification>ID"`
+		InitialReleaseDate string `xml:"InitialReleaseDate"`
+		CurrentReleaseDate string `xml:"CurrentReleaseDate"`
+	} `xml:"DocumentTracking"`
+	ProductTree struct {
+		Branches []CVRFBranch `xml:"Branch"`
+	} `xml:"ProductTree"`
+	Vulnerabilities []CVRFVulnerability `xml:"Vulnerability"`
+}
+
+// CVRFBranch groups products by type, e.g. "Product Name" for the
+// distribution releases and "Package Arch" for the packages of an architecture.
+type CVRFBranch struct {
+	Type     string                `xml:"Type,attr"`
+	Name     string                `xml:"Name,attr"`
+	Products []CVRFFullProductName `xml:"FullProductName"`
+}
+
+type CVRFFullProductName struct {
+	ProductID string `xml:"ProductID,attr"`
+	CPE       string `xml:"CPE,attr"`
+	Name      string `xml:",chardata"`
+}
+
+type CVRFVulnerability struct {
+	CVE             string `xml:"CVE"`
+	ProductStatuses []struct {
+		Type       string   `xml:"Type,attr"`
+		ProductIDs []string `xml:"ProductID"`
+	} `xml:"ProductStatuses>Status"`
+	Threats []struct {
+		Type        string `xml:"Type,attr"`
+		Description string `xml:"Description"`
+	} `xml:"Threats>Threat"`
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// record is a generic OSV record, so fields unknown to vulns.Vulnerability
+// are compared too.
+type record map[string]any
+
+// loadDir loads the OSV records (.json) in a directory tree, keyed by ID.
+func loadDir(dir string) (map[string]record, error) {
+	records := make(map[string]record)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rel, _ := filepath.Rel(dir, path)
+		return addRecord(records, filepath.ToSlash(rel), f)
+	})
+	return records, err
+}
+
+// loadGCS loads the OSV records (.json) under a GCS prefix, keyed by ID.
+func loadGCS(ctx context.Context, client *storage.Client, bucket, prefix string) (map[string]record, error) {
+	records := make(map[string]record)
+	it := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(attrs.Name, ".json") {
+			continue
+		}
+		r, err := client.Bucket(bucket).Object(attrs.Name).NewReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = addRecord(records, strings.TrimPrefix(attrs.Name, prefix), r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// addRecord decodes a record, keying it by its ID, or name if it has none.
+func addRecord(records map[string]record, name string, r io.Reader) error {
+	var rec record
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	id, ok := rec["id"].(string)
+	if !ok || id == "" {
+		id = name
+	}
+	if _, ok := records[id]; ok {
+		return fmt.Errorf("duplicate record %s in %s", id, name)
+	}
+	records[id] = rec
+	return nil
+}
+
+// load loads the records of a local directory, or a gs://bucket/prefix.
+func load(ctx context.Context, client func() (*storage.Client, error), location string) (map[string]record, error) {
+	path, ok := strings.CutPrefix(location, "gs://")
+	if !ok {
+		return loadDir(location)
+	}
+	cl, err := client()
+	if err != nil {
+		return nil, err
+	}
+	bucket, prefix, _ := strings.Cut(path, "/")
+	return loadGCS(ctx, cl, bucket, prefix)
+}
+
+// FieldDiff is a changed field of a record.
+type FieldDiff struct {
+	// Path is the field's path, e.g. affected[0].ranges[0].events[1].fixed.
+	Path string
+	Old  any
+	New  any
+}
+
+// RecordDiff is a changed record.
+type RecordDiff struct {
+	ID     string
+	Fields []FieldDiff
+}
+
+// Diff is the difference between two sets of records.
+type Diff struct {
+	Added   []string
+	Removed []string
+	Changed []RecordDiff
+}
+
+// diffRecords compares two sets of records, ignoring the fields at the given
+// paths (e.g. modified) wherever they appear in a record.
+func diffRecords(old, new map[string]record, ignore []string) Diff {
+	ignored := make(map[string]bool)
+	for _, p := range ignore {
+		ignored[p] = true
+	}
+	var d Diff
+	for id, n := range new {
+		o, ok := old[id]
+		if !ok {
+			d.Added = append(d.Added, id)
+			continue
+		}
+		var fields []FieldDiff
+		diffValues("", map[string]any(o), map[string]any(n), ignored, &fields)
+		if len(fields) > 0 {
+			d.Changed = append(d.Changed, RecordDiff{ID: id, Fields: fields})
+		}
+	}
+	for id := range old {
+		if _, ok := new[id]; !ok {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].ID < d.Changed[j].ID })
+	return d
+}
+
+// ignoredPath returns path with its list indexes removed, as matched
+// against ignored fields.
+func ignoredPath(path string) string {
+	var b strings.Builder
+	inIndex := false
+	for _, r := range path {
+		switch {
+		case r == '[':
+			inIndex = true
+		case r == ']':
+			inIndex = false
+		case !inIndex:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// diffValues appends the differences between two JSON values to fields.
+func diffValues(path string, o, n any, ignored map[string]bool, fields *[]FieldDiff) {
+	if ignored[ignoredPath(path)] {
+		return
+	}
+	switch ov := o.(type) {
+	case map[string]any:
+		nv, ok := n.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range ov {
+			keys[k] = true
+		}
+		for k := range nv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffValues(child, ov[k], nv[k], ignored, fields)
+		}
+		return
+	case []any:
+		nv, ok := n.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(ov) || i < len(nv); i++ {
+			var oi, ni any
+			if i < len(ov) {
+				oi = ov[i]
+			}
+			if i < len(nv) {
+				ni = nv[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), oi, ni, ignored, fields)
+		}
+		return
+	}
+	if !reflect.DeepEqual(o, n) {
+		*fields = append(*fields, FieldDiff{Path: path, Old: o, New: n})
+	}
+}
+
+// formatValue formats a JSON value for the report.
+func formatValue(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
+
+// writeReport writes a diff for humans to review.
+func writeReport(w io.Writer, d Diff) error {
+	for _, id := range d.Added {
+		if _, err := fmt.Fprintf(w, "+ %s\n", id); err != nil {
+			return err
+		}
+	}
+	for _, id := range d.Removed {
+		if _, err := fmt.Fprintf(w, "- %s\n", id); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Changed {
+		if _, err := fmt.Fprintf(w, "~ %s\n", c.ID); err != nil {
+			return err
+		}
+		for _, f := range c.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", f.Path, formatValue(f.Old), formatValue(f.New)); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func writeRecords(t *testing.T, records map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range records {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiff(t *testing.T) {
+	oldDir := writeRecords(t, map[string]string{
+		"curl/CVE-2024-0001.json": `{"id": "CVE-2024-0001", "modified": "2024-01-01T00:00:00Z", "affected": [{"ranges": [{"events": [{"introduced": "0"}, {"fixed": "8.6.0"}]}], "database_specific": {"generated": "a"}}]}`,
+		"curl/CVE-2024-0002.json": `{"id": "CVE-2024-0002", "modified": "2024-01-01T00:00:00Z"}`,
+		"curl/CVE-2024-0003.json": `{"id": "CVE-2024-0003", "modified": "2024-01-01T00:00:00Z", "aliases": ["GHSA-xxxx"]}`,
+		"notes.txt":               "not a record",
+	})
+	newDir := writeRecords(t, map[string]string{
+		"CVE-2024-0001.json": `{"id": "CVE-2024-0001", "modified": "2024-02-01T00:00:00Z", "affected": [{"ranges": [{"events": [{"introduced": "0"}, {"fixed": "8.7.1"}]}], "database_specific": {"generated": "b"}}]}`,
+		"CVE-2024-0003.json": `{"id": "CVE-2024-0003", "modified": "2024-02-01T00:00:00Z", "aliases": ["GHSA-xxxx"]}`,
+		"CVE-2024-0004.json": `{"id": "CVE-2024-0004", "modified": "2024-02-01T00:00:00Z"}`,
+	})
+
+	oldRecords, err := loadDir(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRecords, err := loadDir(newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := diffRecords(oldRecords, newRecords, []string{"modified", "affected.database_specific"})
+	want := Diff{
+		Added:   []string{"CVE-2024-0004"},
+		Removed: []string{"CVE-2024-0002"},
+		Changed: []RecordDiff{{
+			ID:     "CVE-2024-0001",
+			Fields: []FieldDiff{{Path: "affected[0].ranges[0].events[1].fixed", Old: "8.6.0", New: "8.7.1"}},
+		}},
+	}
+	if diff := gocmp.Diff(want, d); diff != "" {
+		t.Fatalf("diffRecords() mismatch (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	wantReport := "+ CVE-2024-0004\n" +
+		"- CVE-2024-0002\n" +
+		"~ CVE-2024-0001\n" +
+		"    affected[0].ranges[0].events[1].fixed: \"8.6.0\" -> \"8.7.1\"\n" +
+		"1 added, 1 removed, 1 changed\n"
+	if diff := gocmp.Diff(wantReport, buf.String()); diff != "" {
+		t.Errorf("writeReport() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffValuesLists(t *testing.T) {
+	var fields []FieldDiff
+	diffValues("aliases", []any{"GHSA-1"}, []any{"GHSA-1", "PYSEC-1"}, nil, &fields)
+	want := []FieldDiff{{Path: "aliases[1]", New: "PYSEC-1"}}
+	if diff := gocmp.Diff(want, fields); diff != "" {
+		t.Errorf("diffValues() mismatch (-want +got):\n%s", diff)
+	}
+}
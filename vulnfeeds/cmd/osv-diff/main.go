@@ -0,0 +1,70 @@
+// osv-diff compares two sets of generated OSV records, each a local directory
+// or a GCS prefix, reporting added, removed and changed records with
+// field-level differences, to review the impact of converter changes before
+// deployment.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("osv-diff")
+	defer logCleanup()
+
+	oldLocation := flag.String("old", "", "Directory or gs://bucket/prefix of the records before the change")
+	newLocation := flag.String("new", "", "Directory or gs://bucket/prefix of the records after the change")
+	ignore := flag.String("ignore", "modified", "Comma separated fields to ignore, as dotted paths without list indexes (e.g. affected.database_specific)")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if *oldLocation == "" || *newLocation == "" {
+		Logger.Fatalf("Both -old and -new are required")
+	}
+
+	ctx := context.Background()
+	var storageClient *storage.Client
+	var storageErr error
+	var storageOnce sync.Once
+	newStorageClient := func() (*storage.Client, error) {
+		storageOnce.Do(func() { storageClient, storageErr = storage.NewClient(ctx) })
+		return storageClient, storageErr
+	}
+
+	oldRecords, err := load(ctx, newStorageClient, *oldLocation)
+	if err != nil {
+		Logger.Fatalf("Failed to load %s: %v", *oldLocation, err)
+	}
+	newRecords, err := load(ctx, newStorageClient, *newLocation)
+	if err != nil {
+		Logger.Fatalf("Failed to load %s: %v", *newLocation, err)
+	}
+	if storageClient != nil {
+		storageClient.Close()
+	}
+
+	var ignored []string
+	for _, field := range strings.Split(*ignore, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			ignored = append(ignored, field)
+		}
+	}
+	if err := writeReport(os.Stdout, diffRecords(oldRecords, newRecords, ignored)); err != nil {
+		Logger.Fatalf("Failed to write report: %v", err)
+	}
+}
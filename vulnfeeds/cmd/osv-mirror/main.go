@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/git"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+const (
+	mirrorDirDefault    = "osv-mirror"
+	mirrorOutputDefault = "osv-mirror-output"
+)
+
+var Logger utility.LoggerWrapper
+var failureFlags = failures.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("osv-mirror")
+	defer logCleanup()
+
+	repo := flag.String("mirrorRepo", "", "URL of the git repo of the OSV database to mirror, e.g. https://github.com/bitnami/vulndb (if empty, -mirrorDir is mirrored as is)")
+	dir := flag.String("mirrorDir", mirrorDirDefault, "path to clone the repo into (or update an existing clone at)")
+	recordsPath := flag.String("mirrorRecords", "", "path of the records within the repo, e.g. data")
+	outputPath := flag.String("mirrorOutput", mirrorOutputDefault, "path to output the mirrored OSV records")
+	requirePrefix := flag.String("requirePrefix", "", "comma separated ID prefixes the records must have (after -rewritePrefix), e.g. BIT; records with others are dropped")
+	rewritePrefix := flag.String("rewritePrefix", "", "rewrite an ID prefix of the records, and of the records they reference, e.g. BIT=BITNAMI")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	policy, err := parseIDPolicy(*requirePrefix, *rewritePrefix)
+	if err != nil {
+		Logger.Fatalf("%s", err)
+	}
+	if *repo != "" {
+		Logger.Infof("Updating %s from %s", *dir, *repo)
+		if err := git.SyncCheckout(context.Background(), *repo, *dir); err != nil {
+			Logger.Fatalf("Failed to update the mirrored repo: %s", err)
+		}
+	}
+	if err := os.MkdirAll(*outputPath, 0755); err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+
+	var failed failures.Collector
+	stats, err := mirror(path.Join(*dir, *recordsPath), *outputPath, policy, time.Now(), &failed)
+	if err != nil {
+		Logger.Fatalf("Failed to mirror %s: %s", *dir, err)
+	}
+	failed.Report(Logger, "OSV records")
+	Logger.Infof("Mirrored %d records, dropped %d", stats.Mirrored, stats.Dropped)
+	if err := failed.Check(failureFlags.MaxRate()); err != nil {
+		Logger.Fatalf("Mirroring failed: %s", err)
+	}
+}
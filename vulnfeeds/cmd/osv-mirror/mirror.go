@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/validation"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// idPolicy is the policy of the IDs of the mirrored records.
+type idPolicy struct {
+	// allowed are the prefixes records may have, after any rewrite. Any
+	// prefix is allowed if empty.
+	allowed []ids.Prefix
+	// from is rewritten to to in the IDs of the records and of the records
+	// they reference, if set.
+	from, to ids.Prefix
+}
+
+// parseIDPolicy parses the comma separated prefixes records must have, and
+// the rewrite of a prefix, e.g. "BIT=BITNAMI".
+func parseIDPolicy(require string, rewrite string) (idPolicy, error) {
+	var policy idPolicy
+	for _, prefix := range strings.Split(require, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			policy.allowed = append(policy.allowed, ids.Prefix(prefix))
+		}
+	}
+	if rewrite != "" {
+		from, to, ok := strings.Cut(rewrite, "=")
+		if !ok || from == "" || !ids.Valid(to+"-1") {
+			return idPolicy{}, fmt.Errorf("invalid prefix rewrite %q, want FROM=TO", rewrite)
+		}
+		policy.from, policy.to = ids.Prefix(from), ids.Prefix(to)
+	}
+	return policy, nil
+}
+
+// rewrite returns id with its prefix rewritten by the policy.
+func (p idPolicy) rewrite(id string) string {
+	if p.from == "" || !ids.HasPrefix(id, p.from) {
+		return id
+	}
+	return string(p.to) + strings.TrimPrefix(id, string(p.from))
+}
+
+// apply applies the policy to a record, recording its original ID in the
+// "source_id" key of its database_specific if rewritten.
+func (p idPolicy) apply(v *vulns.Vulnerability) error {
+	if id := p.rewrite(v.ID); id != v.ID {
+		v.SetDatabaseSpecific("source_id", v.ID)
+		v.ID = id
+	}
+	for i := range v.Aliases {
+		v.Aliases[i] = p.rewrite(v.Aliases[i])
+	}
+	for i := range v.Related {
+		v.Related[i] = p.rewrite(v.Related[i])
+	}
+	prefix, _, err := ids.Split(v.ID)
+	if err != nil {
+		return err
+	}
+	if len(p.allowed) > 0 && !slices.Contains(p.allowed, prefix) {
+		return fmt.Errorf("ID %s doesn't have an allowed prefix", v.ID)
+	}
+	return nil
+}
+
+// normalize normalizes the ecosystems and package URLs of the affected
+// packages of a record. Package URLs that don't parse are left for
+// validation to reject.
+func normalize(v *vulns.Vulnerability) {
+	for i := range v.Affected {
+		pkg := v.Affected[i].Package
+		if pkg == nil {
+			continue
+		}
+		pkg.Ecosystem, _ = ecosystems.Canonical(pkg.Ecosystem)
+		if pkg.Purl == "" {
+			continue
+		}
+		if normalized, err := purl.Normalize(pkg.Purl); err == nil {
+			pkg.Purl = normalized
+		}
+	}
+}
+
+// mirrorStats are the statistics of a mirror run.
+type mirrorStats struct {
+	Mirrored int
+	// Dropped counts the records dropped by the ID policy or validation.
+	Dropped int
+}
+
+// mirror mirrors the OSV records (*.json) under recordsPath into outputPath,
+// applying the ID policy, normalizing them, and dropping those the importer
+// would reject. Records that can't be read are recorded in failed.
+func mirror(recordsPath string, outputPath string, policy idPolicy, now time.Time, failed *failures.Collector) (mirrorStats, error) {
+	var stats mirrorStats
+	seen := make(map[string]string)
+	err := filepath.WalkDir(recordsPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && filePath != recordsPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		record, _ := filepath.Rel(recordsPath, filePath)
+		v, err := loadRecord(filePath)
+		if err != nil {
+			failed.Failed(record, err)
+			return nil
+		}
+		failed.Succeeded()
+
+		if err := policy.apply(v); err != nil {
+			Logger.Warnf("Dropping %s: %v", record, err)
+			stats.Dropped++
+			return nil
+		}
+		normalize(v)
+		if problems := validation.Check(v, now); len(problems) > 0 {
+			for _, problem := range problems {
+				Logger.Warnf("Dropping %s: %s", record, problem)
+			}
+			stats.Dropped++
+			return nil
+		}
+		if other, ok := seen[v.ID]; ok {
+			Logger.Warnf("Dropping %s: %s is also the ID of %s", record, v.ID, other)
+			stats.Dropped++
+			return nil
+		}
+		seen[v.ID] = record
+
+		if err := writeRecord(path.Join(outputPath, v.ID+".json"), v); err != nil {
+			return fmt.Errorf("failed to write %s: %w", v.ID, err)
+		}
+		stats.Mirrored++
+		return nil
+	})
+	return stats, err
+}
+
+// loadRecord loads an OSV record.
+func loadRecord(filePath string) (*vulns.Vulnerability, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var v vulns.Vulnerability
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	if v.ID == "" {
+		return nil, errors.New("not an OSV record: no ID")
+	}
+	return &v, nil
+}
+
+// writeRecord writes an OSV record.
+func writeRecord(filePath string, v *vulns.Vulnerability) error {
+	return utility.WriteFileAtomic(filePath, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	})
+}
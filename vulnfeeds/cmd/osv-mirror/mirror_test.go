@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/failures"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const testRecordsPath = "../../test_data/osv-mirror/data"
+
+var now = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("osv-mirror-test")
+	code := m.Run()
+	logCleanup()
+	os.Exit(code)
+}
+
+func TestParseIDPolicy(t *testing.T) {
+	policy, err := parseIDPolicy("BITNAMI, GHSA", "BIT=BITNAMI")
+	if err != nil {
+		t.Fatalf("parseIDPolicy() failed: %v", err)
+	}
+	want := idPolicy{allowed: []ids.Prefix{"BITNAMI", "GHSA"}, from: "BIT", to: "BITNAMI"}
+	if diff := gocmp.Diff(want, policy, gocmp.AllowUnexported(idPolicy{})); diff != "" {
+		t.Errorf("parseIDPolicy() mismatch (-want +got):\n%s", diff)
+	}
+	for _, rewrite := range []string{"BIT", "=BITNAMI", "BIT=", "BIT=BIT NAMI"} {
+		if _, err := parseIDPolicy("", rewrite); err == nil {
+			t.Errorf("parseIDPolicy(%q) succeeded, want an error", rewrite)
+		}
+	}
+}
+
+func TestMirror(t *testing.T) {
+	outputPath := t.TempDir()
+	policy := idPolicy{allowed: []ids.Prefix{"BITNAMI"}, from: "BIT", to: "BITNAMI"}
+	var failed failures.Collector
+	stats, err := mirror(testRecordsPath, outputPath, policy, now, &failed)
+	if err != nil {
+		t.Fatalf("mirror() failed: %v", err)
+	}
+	// The GHSA record doesn't have an allowed prefix, and the other
+	// wordpress record has no introduced event.
+	if diff := gocmp.Diff(mirrorStats{Mirrored: 1, Dropped: 2}, stats); diff != "" {
+		t.Errorf("mirror() stats mismatch (-want +got):\n%s", diff)
+	}
+	if failures := failed.Failures(); len(failures) != 1 || failures[0].Record != "nginx/BIT-nginx-2024-1.json" {
+		t.Errorf("mirror() failures = %v, want the truncated nginx record", failures)
+	}
+
+	entries, err := os.ReadDir(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "BITNAMI-wordpress-2023-2745.json" {
+		t.Fatalf("mirror() wrote %v, want BITNAMI-wordpress-2023-2745.json", entries)
+	}
+	got, err := loadRecord(path.Join(outputPath, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "BITNAMI-wordpress-2023-2745" || got.DatabaseSpecific["source_id"] != "BIT-wordpress-2023-2745" {
+		t.Errorf("mirror() ID = %s, source ID = %v, want the rewritten ID and the original", got.ID, got.DatabaseSpecific["source_id"])
+	}
+	if diff := gocmp.Diff([]string{"BITNAMI-wordpress-2023-2746"}, got.Related); diff != "" {
+		t.Errorf("mirror() related mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff([]string{"CVE-2023-2745"}, got.Aliases); diff != "" {
+		t.Errorf("mirror() aliases mismatch (-want +got):\n%s", diff)
+	}
+	wantPackage := &vulns.AffectedPackage{Name: "wordpress", Ecosystem: "Bitnami", Purl: "pkg:bitnami/wordpress"}
+	if diff := gocmp.Diff(wantPackage, got.Affected[0].Package); diff != "" {
+		t.Errorf("mirror() package mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMirrorKeepsIDs(t *testing.T) {
+	outputPath := t.TempDir()
+	var failed failures.Collector
+	stats, err := mirror(testRecordsPath, outputPath, idPolicy{}, now, &failed)
+	if err != nil {
+		t.Fatalf("mirror() failed: %v", err)
+	}
+	if diff := gocmp.Diff(mirrorStats{Mirrored: 2, Dropped: 1}, stats); diff != "" {
+		t.Errorf("mirror() stats mismatch (-want +got):\n%s", diff)
+	}
+	for _, id := range []string{"BIT-wordpress-2023-2745", "GHSA-xxxx-yyyy-zzzz"} {
+		v, err := loadRecord(path.Join(outputPath, id+".json"))
+		if err != nil {
+			t.Errorf("mirror() didn't write %s: %v", id, err)
+			continue
+		}
+		if _, ok := v.DatabaseSpecific["source_id"]; ok {
+			t.Errorf("mirror() set the source ID of %s without rewriting it", id)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+#!/bin/bash
+
+## Mirrors a third party OSV database, normalizing its records and dropping
+## the invalid ones, then uploads the results to google cloud store.
+##
+## This script is intended to be the entrypoint of the docker image.
+## with the working directory being the root of the repository
+##
+## MIRROR_NAME names the mirror (e.g. bitnami), MIRROR_REPO is the git repo of
+## the database, and MIRROR_RECORDS the path of its records in the repo.
+## REQUIRE_PREFIX and REWRITE_PREFIX set the ID policy (see osv-mirror -help).
+
+set -e
+
+MIRROR_OUTPUT="osv-mirror-output/${MIRROR_NAME:?}"
+OUTPUT_BUCKET="${OUTPUT_GCS_BUCKET:=cve-osv-conversion}"
+
+echo "Setup initial directories ${MIRROR_OUTPUT}"
+rm -rf $MIRROR_OUTPUT && mkdir -p $MIRROR_OUTPUT
+
+./osv-mirror -mirrorRepo "${MIRROR_REPO:?}" -mirrorRecords "$MIRROR_RECORDS" \
+  -mirrorOutput "$MIRROR_OUTPUT" \
+  -requirePrefix "$REQUIRE_PREFIX" -rewritePrefix "$REWRITE_PREFIX"
+echo "Begin Syncing with cloud, GCS bucket: ${OUTPUT_BUCKET}"
+gsutil -q -m rsync -c -d $MIRROR_OUTPUT "gs://$OUTPUT_BUCKET/mirrors/$MIRROR_NAME"
+echo "Successfully synced with cloud"
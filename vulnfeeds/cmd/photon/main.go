@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/parts"
+	"github.com/google/osv/vulnfeeds/purl"
+	"github.com/google/osv/vulnfeeds/ratelimit"
+	"github.com/google/osv/vulnfeeds/rpm"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	photonCVEURLBase        = "https://packages.vmware.com/photon/photon_cve_metadata/cve_data_photon%s.json"
+	photonOutputPathDefault = "parts/photon"
+	photonReleasesDefault   = "1.0,2.0,3.0,4.0,5.0"
+	photonEcosystem         = "Photon OS"
+	// photonAllVersionsAffected is the affected versions of CVEs with no
+	// fix yet.
+	photonAllVersionsAffected = "all versions are vulnerable"
+)
+
+var Logger utility.LoggerWrapper
+var httpFlags = httpclient.RegisterFlags(flag.CommandLine)
+var rateLimits = ratelimit.RegisterFlags(flag.CommandLine)
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+// PhotonCVE is an entry of the CVE metadata of a Photon OS release: a CVE
+// affecting a package, and the version resolving it.
+type PhotonCVE struct {
+	CVEID string `json:"cve_id"`
+	Pkg   string `json:"pkg"`
+	// AffectedVersions describes the affected versions, e.g. "all versions
+	// before 1.1.1k-1.ph3 are vulnerable".
+	AffectedVersions string `json:"aff_ver"`
+	// ResolvedVersion is the version fixing the CVE, or "NA" if there's none.
+	ResolvedVersion string `json:"res_ver"`
+}
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("photon-osv")
+	defer logCleanup()
+
+	photonOutputPath := flag.String("photonOutput", photonOutputPathDefault, "path to output general Photon OS affected package information")
+	photonReleases := flag.String("photonReleases", photonReleasesDefault, "Comma separated Photon OS releases to convert")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if err := httpFlags.Install(); err != nil {
+		Logger.Fatalf("Failed to configure the HTTP client: %v", err)
+	}
+	defer httpclient.DefaultStats.Report(Logger)
+	if err := rateLimits.Install(); err != nil {
+		Logger.Fatalf("Failed to configure rate limits: %v", err)
+	}
+
+	err := os.MkdirAll(*photonOutputPath, 0755)
+	if err != nil {
+		Logger.Fatalf("Can't create output path: %s", err)
+	}
+
+	releaseCVEs := make(map[string][]PhotonCVE)
+	for _, release := range strings.Split(*photonReleases, ",") {
+		release = strings.TrimSpace(release)
+		if release == "" {
+			continue
+		}
+		photonCVEs, err := downloadPhotonCVEs(release)
+		if err != nil {
+			Logger.Warnf("Failed to get the CVE metadata of Photon OS %s: %s", release, err)
+			continue
+		}
+		releaseCVEs[release] = photonCVEs
+	}
+
+	cvePkgInfos := generatePhotonOSV(releaseCVEs)
+	if err = writeToOutput(cvePkgInfos, *photonOutputPath); err != nil {
+		Logger.Fatalf("Failed to write OSV output file: %s", err)
+	}
+	if err := outputFlags.Finish("photon-osv"); err != nil {
+		Logger.Fatalf("Photon OS CVE conversion failed: %s", err)
+	}
+
+	Logger.Infof("Photon OS CVE conversion succeeded.")
+}
+
+// generatePhotonOSV converts the CVE metadata of Photon OS releases to OSV
+// PackageInfo format, with one fixed version per package and release. CVEs
+// that affect all versions of a package and have no resolved version are
+// converted as unresolved.
+func generatePhotonOSV(releaseCVEs map[string][]PhotonCVE) map[string][]vulns.PackageInfo {
+	cveFixes := make(rpm.Fixes)
+	for release, photonCVEs := range releaseCVEs {
+		Logger.Infof("Converting %d Photon OS %s CVEs to OSV package infos.", len(photonCVEs), release)
+		for _, photonCVE := range photonCVEs {
+			key := rpm.PackageKey{Ecosystem: photonEcosystem + ":" + release, Name: photonCVE.Pkg}
+			if photonCVE.ResolvedVersion == "" || photonCVE.ResolvedVersion == "NA" {
+				if photonCVE.AffectedVersions == photonAllVersionsAffected {
+					cveFixes.Add(photonCVE.CVEID, key, rpm.Fix{})
+				}
+				continue
+			}
+			evr, ok := rpm.ParseEVR(photonCVE.ResolvedVersion)
+			if !ok {
+				Logger.Warnf("Invalid resolved version %q of %s in %s", photonCVE.ResolvedVersion, photonCVE.Pkg, photonCVE.CVEID)
+				continue
+			}
+			cveFixes.Add(photonCVE.CVEID, key, rpm.Fix{EVR: evr})
+		}
+	}
+
+	return cveFixes.PackageInfos(func(key rpm.PackageKey) string {
+		release := strings.TrimPrefix(key.Ecosystem, photonEcosystem+":")
+		return purl.RPM("photon", key.Name, "photon-"+release)
+	})
+}
+
+func writeToOutput(cvePkgInfos map[string][]vulns.PackageInfo, outputPath string) error {
+	Logger.Infof("Writing package infos to the output.")
+	for cveId, pkgInfos := range cvePkgInfos {
+		filePath := path.Join(outputPath, cveId+".photon.json")
+		if err := parts.Write(filePath, pkgInfos); err != nil {
+			return err
+		}
+		outputFlags.Add(filePath)
+		outputFlags.Count("packages", len(pkgInfos))
+	}
+
+	return nil
+}
+
+// parsePhotonCVEs decodes the CVE metadata of a Photon OS release.
+func parsePhotonCVEs(r io.Reader) ([]PhotonCVE, error) {
+	var photonCVEs []PhotonCVE
+	err := json.NewDecoder(r).Decode(&photonCVEs)
+	return photonCVEs, err
+}
+
+// downloadPhotonCVEs downloads the CVE metadata of a Photon OS release.
+func downloadPhotonCVEs(release string) ([]PhotonCVE, error) {
+	res, err := faulttolerant.Get(fmt.Sprintf(photonCVEURLBase, release))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+
+	return parsePhotonCVEs(res.Body)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func Test_generatePhotonOSV(t *testing.T) {
+	file, err := os.Open("../../test_data/photon/cve_data_photon3.0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	photonCVEs, err := parsePhotonCVEs(file)
+	if err != nil {
+		t.Fatalf("parsePhotonCVEs() error: %v", err)
+	}
+
+	got := generatePhotonOSV(map[string][]PhotonCVE{"3.0": photonCVEs})
+	// Invalid versions are skipped, unresolved CVEs affect all versions, and
+	// of several resolved versions, the earliest wins.
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2021-3449": {
+			{
+				PkgName:   "nxtgn-openssl",
+				Ecosystem: "Photon OS:3.0",
+				PURL:      "pkg:rpm/photon/nxtgn-openssl?distro=photon-3.0",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1.1.1k-1.ph3"}},
+				},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Photon OS:3.0",
+				PURL:      "pkg:rpm/photon/openssl?distro=photon-3.0",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1.0.2y-2.ph3"}},
+				},
+			},
+		},
+		"CVE-2021-4044": {
+			{
+				PkgName:   "linux",
+				Ecosystem: "Photon OS:3.0",
+				PURL:      "pkg:rpm/photon/linux?distro=photon-3.0",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}},
+				},
+				Unresolved: true,
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("generatePhotonOSV() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// partsDir is where the converters write their parts, relative to the work
+// directory. Some converters don't take their output path as a flag.
+const partsDir = "parts"
+
+// Config configures the stages of a pipeline run.
+type Config struct {
+	// WorkDir is the directory stages run in; relative paths are relative
+	// to it. It's relative to the config file if not absolute.
+	WorkDir string `yaml:"workDir"`
+	// BinDir is a directory of built commands to run. If empty, they're
+	// built from SourceDir into WorkDir/bin.
+	BinDir string `yaml:"binDir"`
+	// SourceDir is the vulnfeeds module the commands are built from.
+	SourceDir string `yaml:"sourceDir"`
+	Paths     Paths  `yaml:"paths"`
+
+	Download Stage      `yaml:"download"`
+	Parts    PartsStage `yaml:"parts"`
+	Combine  Stage      `yaml:"combine"`
+	Validate struct {
+		Skip bool `yaml:"skip"`
+		// Strict fails the run if any record would be rejected by the
+		// importer.
+		Strict bool `yaml:"strict"`
+	} `yaml:"validate"`
+	Upload struct {
+		Stage `yaml:",inline"`
+		// Destination is the gs://bucket/prefix to upload the records to.
+		// The stage is skipped without one.
+		Destination string `yaml:"destination"`
+	} `yaml:"upload"`
+}
+
+// Paths are the paths shared by the stages, relative to the work directory.
+type Paths struct {
+	CVEs   string `yaml:"cves"`
+	Output string `yaml:"output"`
+	// Cache is for data kept between runs, e.g. clones of upstream
+	// repositories, which converters' args can point into with ${cache}.
+	Cache string `yaml:"cache"`
+}
+
+// Stage is a stage that runs a command.
+type Stage struct {
+	Skip bool `yaml:"skip"`
+	// Args are passed to the command after those the pipeline passes.
+	// ${cves}, ${parts}, ${output}, ${cache} and ${workDir} are expanded to
+	// the shared paths, and other ${VAR}s to environment variables.
+	Args []string `yaml:"args"`
+}
+
+// PartsStage runs the converters that write parts.
+type PartsStage struct {
+	Skip       bool        `yaml:"skip"`
+	Converters []Converter `yaml:"converters"`
+}
+
+// Converter is a command under cmd/ that writes parts, e.g. "alpine".
+type Converter struct {
+	Name string   `yaml:"name"`
+	Args []string `yaml:"args"`
+}
+
+// loadConfig loads the config at path, filling in defaults.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p, def string) string {
+		if p == "" {
+			p = def
+		}
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	cfg.WorkDir = resolve(cfg.WorkDir, ".")
+	cfg.BinDir = resolve(cfg.BinDir, "")
+	cfg.SourceDir = resolve(cfg.SourceDir, ".")
+	if cfg.Paths.CVEs == "" {
+		cfg.Paths.CVEs = "cve_jsons"
+	}
+	if cfg.Paths.Output == "" {
+		cfg.Paths.Output = "osv_output"
+	}
+	if cfg.Paths.Cache == "" {
+		cfg.Paths.Cache = "cache"
+	}
+	for i, c := range cfg.Parts.Converters {
+		if c.Name == "" {
+			return nil, fmt.Errorf("converter %d has no name", i)
+		}
+	}
+	return &cfg, nil
+}
+
+// abs returns a path relative to the work directory as an absolute path.
+func (c *Config) abs(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.WorkDir, p)
+}
+
+// expand expands the shared paths and environment variables in args.
+func (c *Config) expand(args []string) []string {
+	vars := map[string]string{
+		"workDir": c.WorkDir,
+		"cves":    c.abs(c.Paths.CVEs),
+		"parts":   c.abs(partsDir),
+		"output":  c.abs(c.Paths.Output),
+		"cache":   c.abs(c.Paths.Cache),
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = os.Expand(arg, func(name string) string {
+			if v, ok := vars[name]; ok {
+				return v
+			}
+			return os.Getenv(name)
+		})
+	}
+	return expanded
+}
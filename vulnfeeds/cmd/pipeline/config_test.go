@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline.yaml")
+	data := `
+workDir: run
+binDir: /opt/vulnfeeds
+paths:
+  output: out
+parts:
+  converters:
+    - name: alpine
+      args: ["-alpineAports", "${cache}/aports"]
+    - name: debian
+validate:
+  strict: true
+upload:
+  destination: gs://bucket/osv-output/
+  args: ["-delete"]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.WorkDir, filepath.Join(dir, "run"); got != want {
+		t.Errorf("WorkDir = %q, want %q", got, want)
+	}
+	if got, want := cfg.BinDir, "/opt/vulnfeeds"; got != want {
+		t.Errorf("BinDir = %q, want %q", got, want)
+	}
+	if diff := gocmp.Diff(Paths{CVEs: "cve_jsons", Output: "out", Cache: "cache"}, cfg.Paths); diff != "" {
+		t.Errorf("Paths mismatch (-want +got):\n%s", diff)
+	}
+	if !cfg.Validate.Strict || cfg.Upload.Destination != "gs://bucket/osv-output/" {
+		t.Errorf("loadConfig() = %+v, want strict validation and the upload destination", cfg)
+	}
+	if diff := gocmp.Diff([]string{"-delete"}, cfg.Upload.Args); diff != "" {
+		t.Errorf("Upload.Args mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Setenv("PIPELINE_TEST_KEY", "secret")
+	got := cfg.expand([]string{"${cache}/aports", "-out=${output}", "${PIPELINE_TEST_KEY}"})
+	want := []string{filepath.Join(dir, "run", "cache", "aports"), "-out=" + filepath.Join(dir, "run", "out"), "secret"}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("expand() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":          "stages: [download]\n",
+		"converter without name": "parts:\n  converters:\n    - args: [-x]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pipeline.yaml")
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadConfig(path); err == nil {
+				t.Errorf("loadConfig() succeeded, want an error")
+			}
+		})
+	}
+}
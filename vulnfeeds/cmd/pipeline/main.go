@@ -0,0 +1,61 @@
+// pipeline runs the stages of the conversion pipeline (download → parts →
+// combine → validate → upload) in sequence from a YAML config, with the
+// paths they share wired together, so the production pipeline can be
+// reproduced locally with one command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("pipeline")
+	defer logCleanup()
+
+	configPath := flag.String("config", "", "Path to the YAML config of the pipeline")
+	skip := map[string]*bool{
+		"download": flag.Bool("skipDownload", false, "Skip downloading the CVEs, e.g. to reuse a previous run's"),
+		"parts":    flag.Bool("skipParts", false, "Skip running the converters, e.g. to reuse a previous run's parts"),
+		"combine":  flag.Bool("skipCombine", false, "Skip combining the CVEs and parts into records"),
+		"validate": flag.Bool("skipValidate", false, "Skip validating the records"),
+		"upload":   flag.Bool("skipUpload", false, "Skip uploading the records"),
+	}
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if *configPath == "" {
+		Logger.Fatalf("-config is required")
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		Logger.Fatalf("Failed to load config: %v", err)
+	}
+	if err := os.MkdirAll(cfg.WorkDir, 0755); err != nil {
+		Logger.Fatalf("Can't create work directory: %v", err)
+	}
+	if err := os.MkdirAll(cfg.abs(cfg.Paths.Cache), 0755); err != nil {
+		Logger.Fatalf("Can't create cache directory: %v", err)
+	}
+
+	skipped := make(map[string]bool)
+	for name, s := range skip {
+		skipped[name] = *s
+	}
+	p := &pipeline{cfg: cfg, built: make(map[string]string)}
+	results, err := runSteps(context.Background(), p.steps(skipped))
+	printSummary(os.Stdout, results)
+	if err != nil {
+		Logger.Fatalf("%v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/validation"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// The statuses of a stage in the summary.
+const (
+	statusOK      = "ok"
+	statusSkipped = "skipped"
+	statusFailed  = "failed"
+	statusNotRun  = "not run"
+)
+
+// step is a stage of the pipeline.
+type step struct {
+	name string
+	skip bool
+	// run runs the stage, returning a detail for the summary.
+	run func(ctx context.Context) (string, error)
+}
+
+// result is the outcome of a step, for the summary.
+type result struct {
+	name     string
+	status   string
+	detail   string
+	duration time.Duration
+}
+
+// runSteps runs the steps in order, stopping at the first that fails. Every
+// step has a result, those after a failure marked as not run.
+func runSteps(ctx context.Context, steps []step) ([]result, error) {
+	results := make([]result, 0, len(steps))
+	var failed error
+	for _, s := range steps {
+		switch {
+		case failed != nil:
+			results = append(results, result{name: s.name, status: statusNotRun})
+			continue
+		case s.skip:
+			results = append(results, result{name: s.name, status: statusSkipped})
+			continue
+		}
+		Logger.Infof("Running stage %s", s.name)
+		start := time.Now()
+		detail, err := s.run(ctx)
+		r := result{name: s.name, status: statusOK, detail: detail, duration: time.Since(start)}
+		if err != nil {
+			r.status = statusFailed
+			r.detail = err.Error()
+			failed = fmt.Errorf("stage %s failed: %w", s.name, err)
+		}
+		results = append(results, r)
+	}
+	return results, failed
+}
+
+// printSummary prints a table of the results.
+func printSummary(w io.Writer, results []result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tSTATUS\tDURATION\tDETAIL")
+	for _, r := range results {
+		duration := ""
+		if r.duration > 0 {
+			duration = r.duration.Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.name, r.status, duration, r.detail)
+	}
+	tw.Flush()
+}
+
+// pipeline runs the stages of a config.
+type pipeline struct {
+	cfg *Config
+	// built are the commands built this run, by name.
+	built map[string]string
+}
+
+// binary returns the path of a command, building it if there's no BinDir.
+func (p *pipeline) binary(ctx context.Context, name string) (string, error) {
+	if p.cfg.BinDir != "" {
+		return filepath.Join(p.cfg.BinDir, name), nil
+	}
+	if bin, ok := p.built[name]; ok {
+		return bin, nil
+	}
+	bin := filepath.Join(p.cfg.WorkDir, "bin", name)
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", bin, "./cmd/"+name)
+	cmd.Dir = p.cfg.SourceDir
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to build %s: %w", name, err)
+	}
+	p.built[name] = bin
+	return bin, nil
+}
+
+// run runs a command in the work directory.
+func (p *pipeline) run(ctx context.Context, name string, args ...string) error {
+	bin, err := p.binary(ctx, name)
+	if err != nil {
+		return err
+	}
+	Logger.Infof("Running %s %s", name, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = p.cfg.WorkDir
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// steps returns the stages of the pipeline, skipping those skipped by the
+// config or skip.
+func (p *pipeline) steps(skip map[string]bool) []step {
+	cfg := p.cfg
+	return []step{
+		{
+			name: "download",
+			skip: cfg.Download.Skip || skip["download"],
+			run: func(ctx context.Context) (string, error) {
+				args := append([]string{"-cvePath", cfg.abs(cfg.Paths.CVEs)}, cfg.expand(cfg.Download.Args)...)
+				return "", p.run(ctx, "download-cves", args...)
+			},
+		},
+		{
+			name: "parts",
+			skip: cfg.Parts.Skip || skip["parts"] || len(cfg.Parts.Converters) == 0,
+			run: func(ctx context.Context) (string, error) {
+				var names []string
+				for _, c := range cfg.Parts.Converters {
+					if err := p.run(ctx, c.Name, cfg.expand(c.Args)...); err != nil {
+						return "", err
+					}
+					names = append(names, c.Name)
+				}
+				return "ran " + strings.Join(names, ", "), nil
+			},
+		},
+		{
+			name: "combine",
+			skip: cfg.Combine.Skip || skip["combine"],
+			run: func(ctx context.Context) (string, error) {
+				args := append([]string{
+					"-cvePath", cfg.abs(cfg.Paths.CVEs),
+					"-partsPath", cfg.abs(partsDir),
+					"-osvOutputPath", cfg.abs(cfg.Paths.Output),
+				}, cfg.expand(cfg.Combine.Args)...)
+				return "", p.run(ctx, "combine-to-osv", args...)
+			},
+		},
+		{
+			name: "validate",
+			skip: cfg.Validate.Skip || skip["validate"],
+			run: func(context.Context) (string, error) {
+				records, problems, err := validateOutput(cfg.abs(cfg.Paths.Output), time.Now())
+				if err != nil {
+					return "", err
+				}
+				for _, problem := range problems {
+					Logger.Warnf("Validation problem: %s", problem)
+				}
+				detail := fmt.Sprintf("%d problems in %d records", len(problems), records)
+				if cfg.Validate.Strict && len(problems) > 0 {
+					return "", errors.New(detail)
+				}
+				return detail, nil
+			},
+		},
+		{
+			name: "upload",
+			skip: cfg.Upload.Skip || skip["upload"] || cfg.Upload.Destination == "",
+			run: func(ctx context.Context) (string, error) {
+				args := append([]string{
+					"-source", cfg.abs(cfg.Paths.Output),
+					"-destination", cfg.Upload.Destination,
+				}, cfg.expand(cfg.Upload.Args)...)
+				return "to " + cfg.Upload.Destination, p.run(ctx, "gcs-upload", args...)
+			},
+		},
+	}
+}
+
+// validateOutput checks the records in dir against the importer's
+// acceptance rules, returning the number of records and their problems.
+// Records written to several ecosystem shards are checked once.
+func validateOutput(dir string, now time.Time) (int, []validation.Problem, error) {
+	seen := make(map[string]bool)
+	var records []*vulns.Vulnerability
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		id := strings.TrimSuffix(d.Name(), ".json")
+		if d.IsDir() || id == d.Name() || !ids.Valid(id) || seen[id] {
+			return nil
+		}
+		seen[id] = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("%s is not a record: %w", path, err)
+		}
+		records = append(records, &v)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(records), validation.CheckAll(records, now), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestRunSteps(t *testing.T) {
+	var ran []string
+	stepFn := func(name string, err error) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			ran = append(ran, name)
+			return name + " done", err
+		}
+	}
+	results, err := runSteps(context.Background(), []step{
+		{name: "download", skip: true, run: stepFn("download", nil)},
+		{name: "parts", run: stepFn("parts", nil)},
+		{name: "combine", run: stepFn("combine", errors.New("boom"))},
+		{name: "validate", run: stepFn("validate", nil)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "combine") {
+		t.Errorf("runSteps() error = %v, want the combine stage's", err)
+	}
+	if diff := gocmp.Diff([]string{"parts", "combine"}, ran); diff != "" {
+		t.Errorf("ran stages mismatch (-want +got):\n%s", diff)
+	}
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.name+"="+r.status)
+	}
+	want := []string{"download=skipped", "parts=ok", "combine=failed", "validate=not run"}
+	if diff := gocmp.Diff(want, statuses); diff != "" {
+		t.Errorf("statuses mismatch (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	printSummary(&buf, results)
+	for _, s := range []string{"STAGE", "parts", "parts done", "boom", "not run"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("summary %q doesn't contain %q", buf.String(), s)
+		}
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	dir := t.TempDir()
+	valid := `{"id": "CVE-2024-0001", "modified": "2024-01-01T00:00:00Z", "summary": "x", "affected": [{"package": {"name": "curl", "ecosystem": "Debian:12"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.0"}]}]}]}`
+	invalid := `{"id": "CVE-2024-0002", "modified": "2999-01-01T00:00:00Z"}`
+	for name, data := range map[string]string{
+		"CVE-2024-0001.json":        valid,
+		"Debian/CVE-2024-0001.json": valid,
+		"CVE-2024-0002.json":        invalid,
+		"modified_id.csv":           "not a record",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, problems, err := validateOutput(dir, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records != 2 {
+		t.Errorf("validateOutput() checked %d records, want 2", records)
+	}
+	if len(problems) == 0 {
+		t.Fatal("validateOutput() found no problems, want those of CVE-2024-0002")
+	}
+	for _, p := range problems {
+		if p.ID != "CVE-2024-0002" {
+			t.Errorf("unexpected problem %s", p)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// preview serves a local web UI over a directory of generated OSV records,
+// listing them by ecosystem and rendering each with its affected ranges and
+// the problems the importer would have with it, so contributors can inspect
+// the effect of converter changes before opening a pull request.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("preview")
+	defer logCleanup()
+
+	dir := flag.String("dir", "osv_output", "Directory of OSV records (.json) to serve, e.g. combine-to-osv's osvOutputPath")
+	addr := flag.String("addr", "localhost:8080", "Address to serve the UI on")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	records, err := loadRecords(*dir, time.Now().UTC())
+	if err != nil {
+		Logger.Fatalf("Failed to load %s: %v", *dir, err)
+	}
+	Logger.Infof("Serving %d records of %s on http://%s/", len(records.byID), *dir, *addr)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newHandler(records),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := srv.ListenAndServe(); err != nil {
+		Logger.Fatalf("Server failed: %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/validation"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// noEcosystem lists the files that aren't OSV records.
+const noEcosystem = "(none)"
+
+// record is a loaded OSV record, with the problems the importer would have
+// with it.
+type record struct {
+	ID string
+	// Path is the record's file, relative to the directory served.
+	Path     string
+	Vuln     *vulns.Vulnerability
+	Problems []validation.Problem
+	// JSON is the record's file, indented.
+	JSON string
+}
+
+// records are the records of a directory, by ID and by ecosystem.
+type records struct {
+	byID map[string]*record
+	// byEcosystem lists the records affecting each (base) ecosystem, sorted
+	// by ID.
+	byEcosystem map[string][]*record
+}
+
+// loadRecords loads the OSV records (.json) in a directory tree, with future
+// timestamps judged relative to now. Records sharded into several
+// directories (by combine-to-osv -shardByEcosystem) are loaded once, and
+// files that don't parse are kept as records with an INVALID_JSON problem.
+func loadRecords(dir string, now time.Time) (*records, error) {
+	rs := &records{byID: make(map[string]*record), byEcosystem: make(map[string][]*record)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rs.add(parseRecord(filepath.ToSlash(rel), data, now))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ecosystemRecords := range rs.byEcosystem {
+		slices.SortFunc(ecosystemRecords, func(a, b *record) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+	return rs, nil
+}
+
+// parseRecord parses the record of the file at path, checking it.
+func parseRecord(path string, data []byte, now time.Time) *record {
+	r := &record{ID: path, Path: path, JSON: string(data)}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err == nil {
+		r.JSON = indented.String()
+	}
+	var v vulns.Vulnerability
+	if err := json.Unmarshal(data, &v); err != nil {
+		r.Problems = []validation.Problem{{ID: path, Finding: validation.InvalidJSON, Message: err.Error()}}
+		return r
+	}
+	if v.ID != "" {
+		r.ID = v.ID
+	}
+	r.Vuln = &v
+	r.Problems = validation.Check(&v, now)
+	return r
+}
+
+func (rs *records) add(r *record) {
+	if _, ok := rs.byID[r.ID]; ok {
+		return
+	}
+	rs.byID[r.ID] = r
+	for _, ecosystem := range recordEcosystems(r.Vuln) {
+		rs.byEcosystem[ecosystem] = append(rs.byEcosystem[ecosystem], r)
+	}
+}
+
+// recordEcosystems returns the base ecosystems (e.g. Debian for Debian:12)
+// v is exported under, including GIT and [EMPTY], or noEcosystem if it isn't
+// a record.
+func recordEcosystems(v *vulns.Vulnerability) []string {
+	if v == nil {
+		return []string{noEcosystem}
+	}
+	return slices.DeleteFunc(export.Ecosystems(v), func(ecosystem string) bool { return strings.Contains(ecosystem, ":") })
+}
+
+// rangeRow is an interval of an affected range: the events from an
+// introduced event up to the event ending it, if any.
+type rangeRow struct {
+	Introduced   string
+	Fixed        string
+	LastAffected string
+	Limit        string
+}
+
+func (r rangeRow) closed() bool {
+	return r.Fixed != "" || r.LastAffected != "" || r.Limit != ""
+}
+
+// rangeRows returns the intervals of the events of r, in order.
+func rangeRows(r vulns.AffectedRange) []rangeRow {
+	var rows []rangeRow
+	for _, e := range r.Events {
+		if e.Introduced != "" {
+			rows = append(rows, rangeRow{Introduced: e.Introduced})
+			continue
+		}
+		// An end without an introduced event is shown as an interval of its
+		// own, so it isn't hidden.
+		if len(rows) == 0 || rows[len(rows)-1].closed() {
+			rows = append(rows, rangeRow{})
+		}
+		row := &rows[len(rows)-1]
+		row.Fixed = e.Fixed
+		row.LastAffected = e.LastAffected
+		row.Limit = e.Limit
+	}
+	return rows
+}
+
+// rangeView is an affected range, as rendered.
+type rangeView struct {
+	vulns.AffectedRange
+	Rows []rangeRow
+}
+
+// affectedView is an affected entry, as rendered.
+type affectedView struct {
+	vulns.Affected
+	Ranges []rangeView
+}
+
+func affectedViews(v *vulns.Vulnerability) []affectedView {
+	var views []affectedView
+	for _, affected := range v.Affected {
+		view := affectedView{Affected: affected}
+		for _, r := range affected.Ranges {
+			view.Ranges = append(view.Ranges, rangeView{AffectedRange: r, Rows: rangeRows(r)})
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// ecosystemSummary is an ecosystem on the index page.
+type ecosystemSummary struct {
+	Name         string
+	Records      int
+	WithProblems int
+}
+
+func newHandler(rs *records) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		var summaries []ecosystemSummary
+		for name, ecosystemRecords := range rs.byEcosystem {
+			s := ecosystemSummary{Name: name, Records: len(ecosystemRecords)}
+			for _, rec := range ecosystemRecords {
+				if len(rec.Problems) > 0 {
+					s.WithProblems++
+				}
+			}
+			summaries = append(summaries, s)
+		}
+		slices.SortFunc(summaries, func(a, b ecosystemSummary) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		render(w, "index", map[string]any{"Total": len(rs.byID), "Ecosystems": summaries})
+	})
+	mux.HandleFunc("GET /ecosystem/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		ecosystemRecords, ok := rs.byEcosystem[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		render(w, "ecosystem", map[string]any{"Name": name, "Records": ecosystemRecords})
+	})
+	mux.HandleFunc("GET /record/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		rec, ok := rs.byID[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var affected []affectedView
+		if rec.Vuln != nil {
+			affected = affectedViews(rec.Vuln)
+		}
+		render(w, "record", map[string]any{"Record": rec, "Affected": affected})
+	})
+	mux.HandleFunc("GET /problems", func(w http.ResponseWriter, r *http.Request) {
+		var withProblems []*record
+		for _, rec := range rs.byID {
+			if len(rec.Problems) > 0 {
+				withProblems = append(withProblems, rec)
+			}
+		}
+		slices.SortFunc(withProblems, func(a, b *record) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+		render(w, "problems", withProblems)
+	})
+	return mux
+}
+
+// render executes the page template name with data, rendering it in full
+// before writing it so a failure is reported as such.
+func render(w http.ResponseWriter, name string, data any) {
+	var buf bytes.Buffer
+	if err := pageTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		Logger.Warnf("Failed to render %s: %v", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+var pageTemplates = template.Must(template.New("preview").Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+.problem { color: #b00; }
+pre { background: #f4f4f4; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<p><a href="/">Ecosystems</a> | <a href="/problems">Problems</a></p>
+<h1>{{.}}</h1>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "index"}}{{template "header" "OSV records"}}
+<p>{{.Total}} records.</p>
+<table>
+<tr><th>Ecosystem</th><th>Records</th><th>With problems</th></tr>
+{{range .Ecosystems}}<tr><td><a href="/ecosystem/{{.Name}}">{{.Name}}</a></td><td>{{.Records}}</td><td{{if .WithProblems}} class="problem"{{end}}>{{.WithProblems}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+
+{{define "ecosystem"}}{{template "header" .Name}}
+<p>{{len .Records}} records.</p>
+<table>
+<tr><th>ID</th><th>Summary</th><th>Modified</th><th>Problems</th></tr>
+{{range .Records}}<tr><td><a href="/record/{{.ID}}">{{.ID}}</a></td><td>{{with .Vuln}}{{.Summary}}{{end}}</td><td>{{with .Vuln}}{{.Modified}}{{end}}</td><td{{if .Problems}} class="problem"{{end}}>{{len .Problems}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+
+{{define "problem-list"}}<ul class="problem">
+{{range .}}<li>{{.Finding}}: {{.Message}}</li>
+{{end}}</ul>
+{{end}}
+
+{{define "record"}}{{with .Record}}{{template "header" .ID}}
+<p>From <code>{{.Path}}</code>.</p>
+{{if .Problems}}<h2>Problems</h2>
+{{template "problem-list" .Problems}}{{end}}
+{{with .Vuln}}
+{{with .Summary}}<p><strong>{{.}}</strong></p>{{end}}
+<p>Published {{.Published}}, modified {{.Modified}}{{with .Withdrawn}}, withdrawn {{.}}{{end}}.</p>
+{{with .Aliases}}<p>Aliases: {{range $i, $a := .}}{{if $i}}, {{end}}{{$a}}{{end}}</p>{{end}}
+{{with .Related}}<p>Related: {{range $i, $r := .}}{{if $i}}, {{end}}{{$r}}{{end}}</p>{{end}}
+<h2>Details</h2>
+<pre>{{.Details}}</pre>
+{{end}}{{end}}
+{{with .Affected}}<h2>Affected</h2>
+{{range .}}<h3>{{with .Package}}{{.Ecosystem}} {{.Name}}{{with .Purl}} (<code>{{.}}</code>){{end}}{{else}}No package{{end}}</h3>
+{{range .Ranges}}<p>{{.Type}} range{{with .Repo}} of <a href="{{.}}">{{.}}</a>{{end}}</p>
+<table>
+<tr><th>Introduced</th><th>Fixed</th><th>Last affected</th><th>Limit</th></tr>
+{{range .Rows}}<tr><td>{{.Introduced}}</td><td>{{.Fixed}}</td><td>{{.LastAffected}}</td><td>{{.Limit}}</td></tr>
+{{end}}</table>
+{{end}}{{with .Versions}}<p>{{len .}} affected versions: {{range $i, $v := .}}{{if $i}}, {{end}}{{$v}}{{end}}</p>
+{{end}}{{end}}{{end}}
+{{with .Record}}{{with .Vuln}}{{with .References}}<h2>References</h2>
+<ul>
+{{range .}}<li>{{.Type}}: <a href="{{.URL}}">{{.URL}}</a></li>
+{{end}}</ul>
+{{end}}{{end}}
+<h2>JSON</h2>
+<pre>{{.JSON}}</pre>
+{{end}}{{template "footer"}}{{end}}
+
+{{define "problems"}}{{template "header" "Problems"}}
+<p>{{len .}} records the importer would reject or flag.</p>
+<table>
+<tr><th>ID</th><th>Problems</th></tr>
+{{range .}}<tr><td><a href="/record/{{.ID}}">{{.ID}}</a></td><td>{{template "problem-list" .Problems}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+`))
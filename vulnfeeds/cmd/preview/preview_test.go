@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const alpineRecord = `{
+  "id": "CVE-2024-1234",
+  "summary": "Overflow in zlib",
+  "details": "A buffer overflow.",
+  "affected": [{
+    "package": {"ecosystem": "Alpine:v3.19", "name": "zlib", "purl": "pkg:apk/alpine/zlib?arch=source"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.3.1-r0"}]}]
+  }],
+  "references": [{"type": "ADVISORY", "url": "https://security.alpinelinux.org/vuln/CVE-2024-1234"}],
+  "modified": "2024-02-01T00:00:00Z",
+  "published": "2024-01-01T00:00:00Z"
+}`
+
+const gitRecord = `{
+  "id": "CVE-2024-5678",
+  "details": "A use after free.",
+  "affected": [{
+    "ranges": [{"type": "GIT", "repo": "https://github.com/madler/zlib", "events": [{"introduced": "0"}, {"fixed": "not-a-commit"}]}]
+  }],
+  "references": [],
+  "modified": "2024-02-01T00:00:00Z",
+  "published": "2024-01-01T00:00:00Z"
+}`
+
+func writeRecords(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadRecords(t *testing.T) {
+	dir := writeRecords(t, map[string]string{
+		"Alpine/CVE-2024-1234.json":       alpineRecord,
+		"Alpine:v3.19/CVE-2024-1234.json": alpineRecord,
+		"GIT/CVE-2024-5678.json":          gitRecord,
+		"broken.json":                     "{",
+		"Alpine/all.zip":                  "not a record",
+	})
+	rs, err := loadRecords(dir, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotEcosystems := make(map[string][]string)
+	for ecosystem, ecosystemRecords := range rs.byEcosystem {
+		for _, r := range ecosystemRecords {
+			gotEcosystems[ecosystem] = append(gotEcosystems[ecosystem], r.ID)
+		}
+	}
+	wantEcosystems := map[string][]string{
+		"Alpine":            {"CVE-2024-1234"},
+		export.GitEcosystem: {"CVE-2024-5678"},
+		noEcosystem:         {"broken.json"},
+	}
+	if diff := gocmp.Diff(wantEcosystems, gotEcosystems); diff != "" {
+		t.Errorf("records by ecosystem mismatch (-want +got):\n%s", diff)
+	}
+	if problems := rs.byID["CVE-2024-1234"].Problems; len(problems) != 0 {
+		t.Errorf("problems of CVE-2024-1234 = %v, want none", problems)
+	}
+	if problems := rs.byID["CVE-2024-5678"].Problems; len(problems) == 0 {
+		t.Error("CVE-2024-5678 has no problems, want its invalid commit")
+	}
+	if problems := rs.byID["broken.json"].Problems; len(problems) != 1 || problems[0].Finding != "INVALID_JSON" {
+		t.Errorf("problems of broken.json = %v, want INVALID_JSON", problems)
+	}
+}
+
+func TestRangeRows(t *testing.T) {
+	r := vulns.AffectedRange{Events: []vulns.Event{
+		{Introduced: "0"},
+		{Fixed: "1.0"},
+		{Introduced: "2.0"},
+		{LastAffected: "2.5"},
+		{Fixed: "3.1"},
+		{Introduced: "4.0"},
+	}}
+	want := []rangeRow{
+		{Introduced: "0", Fixed: "1.0"},
+		{Introduced: "2.0", LastAffected: "2.5"},
+		{Fixed: "3.1"},
+		{Introduced: "4.0"},
+	}
+	if diff := gocmp.Diff(want, rangeRows(r), gocmp.AllowUnexported(rangeRow{})); diff != "" {
+		t.Errorf("rangeRows() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	dir := writeRecords(t, map[string]string{
+		"CVE-2024-1234.json": alpineRecord,
+		"CVE-2024-5678.json": gitRecord,
+	})
+	rs, err := loadRecords(dir, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newHandler(rs))
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		want       []string
+	}{
+		{path: "/", wantStatus: http.StatusOK, want: []string{`href="/ecosystem/Alpine"`, `href="/ecosystem/GIT"`, "2 records."}},
+		{path: "/ecosystem/Alpine", wantStatus: http.StatusOK, want: []string{`href="/record/CVE-2024-1234"`, "Overflow in zlib"}},
+		{path: "/record/CVE-2024-1234", wantStatus: http.StatusOK, want: []string{"Alpine:v3.19 zlib", "<td>0</td><td>1.3.1-r0</td>", "https://security.alpinelinux.org/vuln/CVE-2024-1234"}},
+		{path: "/record/CVE-2024-5678", wantStatus: http.StatusOK, want: []string{"INVALID_COMMIT", "https://github.com/madler/zlib"}},
+		{path: "/problems", wantStatus: http.StatusOK, want: []string{`href="/record/CVE-2024-5678"`, "1 records"}},
+		{path: "/record/CVE-2024-0000", wantStatus: http.StatusNotFound},
+		{path: "/ecosystem/npm", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("body doesn't contain %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
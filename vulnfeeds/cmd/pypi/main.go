@@ -24,10 +24,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/manifest"
 	"github.com/google/osv/vulnfeeds/pypi"
 	"github.com/google/osv/vulnfeeds/triage"
+	"github.com/google/osv/vulnfeeds/utility"
 	"github.com/google/osv/vulnfeeds/vulns"
 )
 
@@ -35,6 +39,8 @@ const (
 	extension = ".yaml"
 )
 
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
 func loadExisting(vulnsDir string) (map[string]bool, error) {
 	ids := map[string]bool{}
 	err := filepath.Walk(vulnsDir, func(path string, info fs.FileInfo, err error) error {
@@ -103,8 +109,18 @@ func main() {
 	withoutNotes := flag.Bool("without_notes", false, "Output vulnerabilities without notes only.")
 	excludeUnbounded := flag.Bool("exclude_unbounded", false, "Exclude vulnerabilities with unbounded affected ranges.")
 	outDir := flag.String("out_dir", "", "Path to output results.")
+	runID := flag.String("run_id", "", "ID of this run, recorded in each record's generator (defaults to the start time)")
 
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
 	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+	if *runID == "" {
+		*runID = time.Now().UTC().Format(time.RFC3339)
+	}
+	generator := vulns.NewGenerator("pypi", *runID, utility.ReadBuildInfo())
 
 	data, err := ioutil.ReadFile(*jsonPath)
 	if err != nil {
@@ -121,7 +137,10 @@ func main() {
 		log.Fatalf("Failed to load false positives file %s: %v", *falsePositivesPath, err)
 	}
 
-	ecosystem := pypi.New(*pypiLinksPath, *pypiVersionsPath)
+	ecosystem, err := pypi.New(*pypiLinksPath, *pypiVersionsPath)
+	if err != nil {
+		log.Fatalf("Failed to load PyPI data: %v", err)
+	}
 	existingIDs, err := loadExisting(*outDir)
 	if err != nil {
 		log.Fatalf("Failed to load existing IDs: %v", err)
@@ -152,7 +171,7 @@ func main() {
 			}
 			log.Printf("Valid versions = %v\n", validVersions)
 
-			id := "PYSEC-0000-" + cve.CVE.ID // To be assigned later.
+			id := cves.CVEID(ids.Unassigned(ids.PYSEC, cve.CVE.ID)) // To be assigned later.
 			purl := ecosystem.PackageURL(pkg)
 			pkgInfo := vulns.PackageInfo{
 				PkgName:   pkg,
@@ -161,7 +180,11 @@ func main() {
 			}
 
 			v, notes := vulns.FromCVE(id, cve.CVE)
-			v.AddPkgInfo(pkgInfo)
+			v.SetGenerator(generator)
+			if err := v.AddPkgInfo(pkgInfo); err != nil {
+				log.Printf("Skipping %s match for %s: %v", cve.CVE.ID, pkg, err)
+				continue
+			}
 			versions, versionNotes := cves.ExtractVersionInfo(cve.CVE, validVersions)
 
 			notes = append(notes, versionNotes...)
@@ -210,6 +233,11 @@ func main() {
 					log.Fatalf("Failed to write %s: %v", notesPath, err)
 				}
 			}
+			outputFlags.Add(vulnPath)
+			outputFlags.Count("records", 1)
 		}
 	}
+	if err := outputFlags.Finish("pypi"); err != nil {
+		log.Fatalf("PyPI conversion failed: %v", err)
+	}
 }
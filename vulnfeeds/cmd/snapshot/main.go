@@ -0,0 +1,56 @@
+// snapshot bundles a directory of generated OSV records into a snapshot per
+// ecosystem: an all.zip of its records, an index of their IDs and modified
+// times, and a manifest of the records created, modified and removed since
+// the previous snapshot, so mirrors and scanners can fetch only what
+// changed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+var Logger utility.LoggerWrapper
+
+var outputFlags = manifest.RegisterFlags(flag.CommandLine)
+
+func main() {
+	var logCleanup func()
+	Logger, logCleanup = utility.CreateLoggerWrapper("snapshot")
+	defer logCleanup()
+
+	dir := flag.String("dir", "osv_output", "Directory of OSV records (.json), e.g. combine-to-osv's osvOutputPath")
+	outDir := flag.String("out", "snapshot", "Directory to write the snapshot of each ecosystem into, outside of -dir")
+	previousDir := flag.String("previous", "", "Directory of the previous snapshot to list changes since (by default -out, as left by the previous run)")
+	noPrevious := flag.Bool("no-previous", false, "List every record as created, rather than the changes since a previous snapshot")
+	showVersion := flag.Bool("version", false, "Print the converter build and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(utility.ReadBuildInfo())
+		return
+	}
+
+	previous := *previousDir
+	switch {
+	case *noPrevious:
+		previous = ""
+	case previous == "":
+		previous = *outDir
+	}
+
+	records, err := loadRecords(*dir)
+	if err != nil {
+		Logger.Fatalf("Failed to load %s: %v", *dir, err)
+	}
+	if err := writeSnapshot(records, *outDir, previous, time.Now().UTC(), outputFlags); err != nil {
+		Logger.Fatalf("Failed to write the snapshot: %v", err)
+	}
+	if err := outputFlags.Finish("snapshot"); err != nil {
+		Logger.Fatalf("Snapshot failed: %v", err)
+	}
+	Logger.Infof("Snapshotted %d records of %s into %s", len(records), *dir, *outDir)
+}
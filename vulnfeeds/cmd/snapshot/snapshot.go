@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/manifest"
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	// The (ID, modified) index of an ecosystem's records, one JSON object
+	// per line.
+	indexFileName = "index.jsonl"
+	// The changes to an ecosystem's records since the previous snapshot.
+	modifiedSinceFileName = "modified_since.json"
+)
+
+// record is an OSV record of the directory being snapshotted.
+type record struct {
+	ID       string
+	Modified string
+	// Path is the record's file.
+	Path       string
+	Ecosystems []string
+}
+
+// indexEntry is a line of an ecosystem's index.
+type indexEntry struct {
+	ID       string `json:"id"`
+	Modified string `json:"modified"`
+}
+
+// modifiedSince lists the IDs of an ecosystem's records created, modified
+// and removed since the previous snapshot, sorted.
+type modifiedSince struct {
+	// Previous is the time of the previous snapshot, if there was one. The
+	// lists are only of changes since then when it's set; otherwise every
+	// record is listed as created.
+	Previous string   `json:"previous,omitempty"`
+	Snapshot string   `json:"snapshot"`
+	Created  []string `json:"created"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// loadRecords loads the OSV records (.json) in a directory tree. Records
+// sharded into several directories (by combine-to-osv -shardByEcosystem)
+// are loaded once, from the first file found; files that aren't OSV records
+// are skipped with a warning.
+func loadRecords(dir string) ([]*record, error) {
+	byID := make(map[string]*record)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil || v.ID == "" {
+			Logger.Warnf("Skipping %s, which isn't an OSV record", path)
+			return nil
+		}
+		if _, ok := byID[v.ID]; ok {
+			return nil
+		}
+		byID[v.ID] = &record{ID: v.ID, Modified: v.Modified, Path: path, Ecosystems: export.Ecosystems(&v)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*record, 0, len(byID))
+	for _, r := range byID {
+		records = append(records, r)
+	}
+	slices.SortFunc(records, func(a, b *record) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return records, nil
+}
+
+// byEcosystem groups records by the ecosystems they're exported under,
+// keeping them sorted by ID.
+func byEcosystem(records []*record) map[string][]*record {
+	groups := make(map[string][]*record)
+	for _, r := range records {
+		for _, ecosystem := range r.Ecosystems {
+			groups[ecosystem] = append(groups[ecosystem], r)
+		}
+	}
+	return groups
+}
+
+// writeSnapshot writes the all.zip, index and modified-since manifest of
+// each ecosystem of records into a subdirectory of outDir, comparing them
+// with the indexes of the snapshot in previousDir, if it's set (which may be
+// outDir itself). Ecosystems of the previous snapshot without records
+// anymore are written empty, with all their records removed. The files
+// written are recorded in outputs.
+func writeSnapshot(records []*record, outDir, previousDir string, now time.Time, outputs *manifest.Flags) error {
+	groups := byEcosystem(records)
+	ecosystems := make([]string, 0, len(groups))
+	for ecosystem := range groups {
+		ecosystems = append(ecosystems, ecosystem)
+	}
+	if previousDir != "" {
+		previousEcosystems, err := snapshotEcosystems(previousDir)
+		if err != nil {
+			return err
+		}
+		ecosystems = append(ecosystems, previousEcosystems...)
+	}
+	slices.Sort(ecosystems)
+	ecosystems = slices.Compact(ecosystems)
+
+	for _, ecosystem := range ecosystems {
+		ecosystemRecords := groups[ecosystem]
+		changes := modifiedSince{Snapshot: now.Format(time.RFC3339)}
+		var previous map[string]string
+		if previousDir != "" {
+			// Read before anything is written, as the previous snapshot
+			// may be the one being replaced. An ecosystem new since the
+			// previous snapshot has no index there, and all its records
+			// are created.
+			var err error
+			previous, err = readIndex(filepath.Join(previousDir, ecosystem, indexFileName))
+			if err != nil {
+				return err
+			}
+			changes.Previous, err = readSnapshotTime(filepath.Join(previousDir, ecosystem, modifiedSinceFileName))
+			if err != nil {
+				return err
+			}
+		}
+		diffIndex(&changes, previous, ecosystemRecords)
+
+		ecosystemDir := filepath.Join(outDir, ecosystem)
+		if err := os.MkdirAll(ecosystemDir, 0755); err != nil {
+			return err
+		}
+		zipPath := filepath.Join(ecosystemDir, export.ZipFileName)
+		if err := writeZip(zipPath, ecosystemRecords); err != nil {
+			return fmt.Errorf("writing %s: %w", zipPath, err)
+		}
+		indexPath := filepath.Join(ecosystemDir, indexFileName)
+		if err := writeIndex(indexPath, ecosystemRecords); err != nil {
+			return fmt.Errorf("writing %s: %w", indexPath, err)
+		}
+		changesPath := filepath.Join(ecosystemDir, modifiedSinceFileName)
+		if err := writeJSON(changesPath, changes); err != nil {
+			return fmt.Errorf("writing %s: %w", changesPath, err)
+		}
+
+		for _, p := range []string{zipPath, indexPath, changesPath} {
+			outputs.Add(p)
+		}
+		outputs.Count("ecosystems", 1)
+		Logger.Infof("%s: %d records, %d created, %d modified and %d removed since the previous snapshot",
+			ecosystem, len(ecosystemRecords), len(changes.Created), len(changes.Modified), len(changes.Removed))
+	}
+	outputs.Count("records", len(records))
+
+	return nil
+}
+
+// snapshotEcosystems returns the ecosystems of the snapshot in dir: its
+// subdirectories with an index. There are none if dir doesn't exist yet.
+func snapshotEcosystems(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ecosystems []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), indexFileName)); err == nil {
+			ecosystems = append(ecosystems, entry.Name())
+		}
+	}
+	return ecosystems, nil
+}
+
+// diffIndex fills changes with the records created and modified since the
+// previous index, and the IDs of the index no longer among records.
+func diffIndex(changes *modifiedSince, previous map[string]string, records []*record) {
+	changes.Created, changes.Modified, changes.Removed = []string{}, []string{}, []string{}
+	current := make(map[string]bool, len(records))
+	for _, r := range records {
+		current[r.ID] = true
+		modified, ok := previous[r.ID]
+		switch {
+		case !ok:
+			changes.Created = append(changes.Created, r.ID)
+		case modified != r.Modified:
+			changes.Modified = append(changes.Modified, r.ID)
+		}
+	}
+	for id := range previous {
+		if !current[id] {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	slices.Sort(changes.Removed)
+}
+
+// writeZip bundles records into a zip, ordered by ID.
+func writeZip(zipPath string, records []*record) error {
+	entries := make([]export.ZipEntry, len(records))
+	for i, r := range records {
+		entries[i] = export.ZipEntry{Name: r.ID + ".json", Path: r.Path}
+	}
+	return export.WriteZip(zipPath, entries)
+}
+
+// writeIndex writes the (ID, modified) index of records, ordered by ID.
+func writeIndex(indexPath string, records []*record) error {
+	return utility.WriteFileAtomic(indexPath, 0644, func(w io.Writer) error {
+		writer := bufio.NewWriter(w)
+		encoder := json.NewEncoder(writer)
+		for _, r := range records {
+			if err := encoder.Encode(indexEntry{ID: r.ID, Modified: r.Modified}); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+// readIndex reads the modified time of each record of an index, or nothing
+// if there's no index.
+func readIndex(indexPath string) (map[string]string, error) {
+	file, err := os.Open(indexPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	index := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var entry indexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", indexPath, line, err)
+		}
+		index[entry.ID] = entry.Modified
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", indexPath, err)
+	}
+	return index, nil
+}
+
+// readSnapshotTime reads the time of the snapshot a modified-since manifest
+// was written by, or "" if there's no manifest.
+func readSnapshotTime(changesPath string) (string, error) {
+	data, err := os.ReadFile(changesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var changes modifiedSince
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", changesPath, err)
+	}
+	return changes.Snapshot, nil
+}
+
+func writeJSON(fileName string, v any) error {
+	return utility.WriteFileAtomic(fileName, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	})
+}
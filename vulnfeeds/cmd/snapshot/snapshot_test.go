@@ -0,0 +1,186 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/export"
+	"github.com/google/osv/vulnfeeds/manifest"
+)
+
+func alpineRecord(id, modified string) string {
+	return `{"id": "` + id + `", "modified": "` + modified + `", "affected": [{
+  "package": {"ecosystem": "Alpine:v3.19", "name": "zlib"},
+  "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.3.1-r0"}]}]
+}]}`
+}
+
+func gitRecord(id, modified string) string {
+	return `{"id": "` + id + `", "modified": "` + modified + `", "affected": [{
+  "ranges": [{"type": "GIT", "repo": "https://github.com/madler/zlib", "events": [{"introduced": "0"}]}]
+}]}`
+}
+
+func writeRecords(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func snapshot(t *testing.T, dir, outDir, previousDir string, now time.Time) *manifest.Flags {
+	t.Helper()
+	records, err := loadRecords(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := &manifest.Flags{}
+	if err := writeSnapshot(records, outDir, previousDir, now, outputs); err != nil {
+		t.Fatal(err)
+	}
+	return outputs
+}
+
+func readChanges(t *testing.T, outDir, ecosystem string) modifiedSince {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(outDir, ecosystem, modifiedSinceFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes modifiedSince
+	if err := json.Unmarshal(data, &changes); err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := writeRecords(t, map[string]string{
+		"CVE-2024-0001.json": alpineRecord("CVE-2024-0001", "2024-01-01T00:00:00Z"),
+		"CVE-2024-0002.json": alpineRecord("CVE-2024-0002", "2024-01-01T00:00:00Z"),
+		"CVE-2024-0003.json": gitRecord("CVE-2024-0003", "2024-01-01T00:00:00Z"),
+		// Sharded copies are only snapshotted once.
+		"Alpine/CVE-2024-0001.json": alpineRecord("CVE-2024-0001", "2024-01-01T00:00:00Z"),
+		"README.json":               "not a record",
+	})
+	outDir := filepath.Join(t.TempDir(), "snapshot")
+	first := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	outputs := snapshot(t, dir, outDir, outDir, first)
+
+	m, err := outputs.Build("snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := gocmp.Diff(map[string]int{"ecosystems": 3, "records": 3}, m.Counts); diff != "" {
+		t.Errorf("counts (-want +got):\n%s", diff)
+	}
+	if len(m.Files) != 9 {
+		t.Errorf("got %d files in the manifest, want 9", len(m.Files))
+	}
+
+	index, err := os.ReadFile(filepath.Join(outDir, "Alpine:v3.19", indexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIndex := `{"id":"CVE-2024-0001","modified":"2024-01-01T00:00:00Z"}
+{"id":"CVE-2024-0002","modified":"2024-01-01T00:00:00Z"}
+`
+	if diff := gocmp.Diff(wantIndex, string(index)); diff != "" {
+		t.Errorf("index (-want +got):\n%s", diff)
+	}
+
+	r, err := zip.OpenReader(filepath.Join(outDir, export.GitEcosystem, export.ZipFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	r.Close()
+	if diff := gocmp.Diff([]string{"CVE-2024-0003.json"}, names); diff != "" {
+		t.Errorf("GIT all.zip (-want +got):\n%s", diff)
+	}
+
+	wantFirst := modifiedSince{
+		Snapshot: "2024-02-01T00:00:00Z",
+		Created:  []string{"CVE-2024-0001", "CVE-2024-0002"},
+		Modified: []string{},
+		Removed:  []string{},
+	}
+	if diff := gocmp.Diff(wantFirst, readChanges(t, outDir, "Alpine")); diff != "" {
+		t.Errorf("first modified_since (-want +got):\n%s", diff)
+	}
+
+	// The next snapshot lists the changes since the first, including the
+	// removal of every record of an ecosystem.
+	dir = writeRecords(t, map[string]string{
+		"CVE-2024-0001.json": alpineRecord("CVE-2024-0001", "2024-02-15T00:00:00Z"),
+		"CVE-2024-0002.json": alpineRecord("CVE-2024-0002", "2024-01-01T00:00:00Z"),
+		"CVE-2024-0004.json": alpineRecord("CVE-2024-0004", "2024-02-15T00:00:00Z"),
+	})
+	snapshot(t, dir, outDir, outDir, first.Add(30*24*time.Hour))
+
+	wantSecond := modifiedSince{
+		Previous: "2024-02-01T00:00:00Z",
+		Snapshot: "2024-03-02T00:00:00Z",
+		Created:  []string{"CVE-2024-0004"},
+		Modified: []string{"CVE-2024-0001"},
+		Removed:  []string{},
+	}
+	if diff := gocmp.Diff(wantSecond, readChanges(t, outDir, "Alpine")); diff != "" {
+		t.Errorf("second modified_since (-want +got):\n%s", diff)
+	}
+	wantGit := modifiedSince{
+		Previous: "2024-02-01T00:00:00Z",
+		Snapshot: "2024-03-02T00:00:00Z",
+		Created:  []string{},
+		Modified: []string{},
+		Removed:  []string{"CVE-2024-0003"},
+	}
+	if diff := gocmp.Diff(wantGit, readChanges(t, outDir, export.GitEcosystem)); diff != "" {
+		t.Errorf("GIT modified_since (-want +got):\n%s", diff)
+	}
+	index, err = os.ReadFile(filepath.Join(outDir, export.GitEcosystem, indexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(index)) != "" {
+		t.Errorf("GIT index = %q, want it empty", index)
+	}
+}
+
+func TestSnapshotZipIsReproducible(t *testing.T) {
+	dir := writeRecords(t, map[string]string{
+		"CVE-2024-0001.json": alpineRecord("CVE-2024-0001", "2024-01-01T00:00:00Z"),
+		"CVE-2024-0002.json": alpineRecord("CVE-2024-0002", "2024-01-01T00:00:00Z"),
+	})
+	var zips []string
+	for range 2 {
+		outDir := t.TempDir()
+		snapshot(t, dir, outDir, "", time.Now())
+		data, err := os.ReadFile(filepath.Join(outDir, "Alpine", export.ZipFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		zips = append(zips, string(data))
+	}
+	if zips[0] != zips[1] {
+		t.Errorf("snapshots of the same records have different zips")
+	}
+}
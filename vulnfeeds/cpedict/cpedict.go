@@ -0,0 +1,317 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cpedict builds a normalized vendor/product index from the NVD CPE
+// dictionary and maps CPE URIs found in CVE configurations to package names
+// and repositories.
+package cpedict
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+const (
+	// Confidence of a match from the manual override file.
+	ConfidenceOverride = 1.0
+	// Confidence of a match on the normalized vendor and product.
+	ConfidenceExact = 0.9
+	// Maximum confidence of a fuzzy match of the product within the same vendor.
+	ConfidenceFuzzyMax = 0.8
+	// Maximum confidence of a match on the product alone, shared between all
+	// vendors with that product.
+	ConfidenceProductOnlyMax = 0.5
+
+	// Minimum similarity for a product to be considered a fuzzy match.
+	minFuzzySimilarity = 0.8
+)
+
+// Product is an application vendor/product from the CPE dictionary.
+type Product struct {
+	Vendor  string   `json:"vendor"`
+	Product string   `json:"product"`
+	Title   string   `json:"title,omitempty"`
+	Repos   []string `json:"repos,omitempty"`
+}
+
+// Override manually maps a vendor/product to a package, taking precedence over
+// any match from the dictionary. Ignore suppresses all matches.
+type Override struct {
+	PackageName string   `json:"package,omitempty"`
+	Repos       []string `json:"repos,omitempty"`
+	Ignore      bool     `json:"ignore,omitempty"`
+}
+
+// Match is a candidate package for a CPE URI.
+type Match struct {
+	Vendor      string
+	Product     string
+	PackageName string
+	Repos       []string
+	Confidence  float64
+	Reason      string
+}
+
+// Index is a normalized vendor/product index of the CPE dictionary.
+type Index struct {
+	products  map[string]*Product // Keyed by normalized "vendor:product".
+	byVendor  map[string][]*Product
+	byProduct map[string][]*Product
+	overrides map[string]Override // Keyed by normalized "vendor:product".
+}
+
+// Normalize canonicalizes a CPE vendor or product name for comparison:
+// quoting is removed, it is lowercased and runs of separators become "_".
+func Normalize(s string) string {
+	s = strings.ToLower(cves.RemoveQuoting(s))
+	var b strings.Builder
+	lastSeparator := false
+	for _, r := range s {
+		switch r {
+		case '_', '-', '.', ' ':
+			if !lastSeparator && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			lastSeparator = true
+		default:
+			b.WriteRune(r)
+			lastSeparator = false
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+func key(vendor, product string) string {
+	return Normalize(vendor) + ":" + Normalize(product)
+}
+
+type dictionary struct {
+	Items []struct {
+		Deprecated bool `xml:"deprecated,attr"`
+		Titles     []struct {
+			Lang  string `xml:"lang,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"title"`
+		CPE23 struct {
+			Name string `xml:"name,attr"`
+		} `xml:"cpe23-item"`
+	} `xml:"cpe-item"`
+}
+
+// ParseDictionary reads the application products of an NVD CPE dictionary
+// XML file, skipping deprecated entries.
+func ParseDictionary(r io.Reader) ([]Product, error) {
+	var d dictionary
+	if err := xml.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var products []Product
+	for _, item := range d.Items {
+		if item.Deprecated {
+			continue
+		}
+		cpe, err := cves.ParseCPE(item.CPE23.Name)
+		if err != nil || cpe.Part != "a" {
+			continue
+		}
+		k := key(cpe.Vendor, cpe.Product)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		title := ""
+		for _, t := range item.Titles {
+			if title == "" || strings.HasPrefix(t.Lang, "en") {
+				title = strings.TrimSpace(t.Value)
+			}
+		}
+		products = append(products, Product{Vendor: cpe.Vendor, Product: cpe.Product, Title: title})
+	}
+
+	return products, nil
+}
+
+// NewIndex builds an index of products. repos optionally maps "vendor:product"
+// (as output by cpe-repo-gen) to the product's repositories.
+func NewIndex(products []Product, repos map[string][]string) *Index {
+	idx := &Index{
+		products:  make(map[string]*Product, len(products)),
+		byVendor:  make(map[string][]*Product),
+		byProduct: make(map[string][]*Product),
+		overrides: make(map[string]Override),
+	}
+	normalizedRepos := make(map[string][]string, len(repos))
+	for vp, r := range repos {
+		vendor, product, _ := strings.Cut(vp, ":")
+		normalizedRepos[key(vendor, product)] = r
+	}
+	for _, p := range products {
+		k := key(p.Vendor, p.Product)
+		if _, ok := idx.products[k]; ok {
+			continue
+		}
+		if r, ok := normalizedRepos[k]; ok && len(p.Repos) == 0 {
+			p.Repos = r
+		}
+		idx.products[k] = &p
+		idx.byVendor[Normalize(p.Vendor)] = append(idx.byVendor[Normalize(p.Vendor)], &p)
+		idx.byProduct[Normalize(p.Product)] = append(idx.byProduct[Normalize(p.Product)], &p)
+	}
+	return idx
+}
+
+// Len returns the number of products in the index.
+func (idx *Index) Len() int {
+	return len(idx.products)
+}
+
+// Products returns the indexed products sorted by vendor and product.
+func (idx *Index) Products() []Product {
+	products := make([]Product, 0, len(idx.products))
+	for _, p := range idx.products {
+		products = append(products, *p)
+	}
+	slices.SortFunc(products, func(a, b Product) int {
+		return cmp.Or(cmp.Compare(a.Vendor, b.Vendor), cmp.Compare(a.Product, b.Product))
+	})
+	return products
+}
+
+// Write writes the index as JSON, to be read back by ReadIndex.
+func (idx *Index) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(struct {
+		Products []Product `json:"products"`
+	}{idx.Products()})
+}
+
+// ReadIndex reads an index written by Index.Write.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var stored struct {
+		Products []Product `json:"products"`
+	}
+	if err := json.NewDecoder(r).Decode(&stored); err != nil {
+		return nil, err
+	}
+	return NewIndex(stored.Products, nil), nil
+}
+
+// LoadOverrides reads a manual override file, a JSON object mapping
+// "vendor:product" to an Override, into the index.
+func (idx *Index) LoadOverrides(r io.Reader) error {
+	var overrides map[string]Override
+	if err := json.NewDecoder(r).Decode(&overrides); err != nil {
+		return err
+	}
+	for vp, o := range overrides {
+		vendor, product, ok := strings.Cut(vp, ":")
+		if !ok || vendor == "" || product == "" {
+			return fmt.Errorf("invalid override key %q, expected vendor:product", vp)
+		}
+		idx.overrides[key(vendor, product)] = o
+	}
+	return nil
+}
+
+// similarity returns the Levenshtein similarity of a and b, between 0 and 1.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func newMatch(p *Product, confidence float64, reason string) Match {
+	return Match{
+		Vendor:      p.Vendor,
+		Product:     p.Product,
+		PackageName: p.Product,
+		Repos:       p.Repos,
+		Confidence:  confidence,
+		Reason:      reason,
+	}
+}
+
+// Match returns the candidate packages for a CPE URI, most confident first.
+// A manual override is authoritative and returned alone.
+func (idx *Index) Match(cpeURI string) ([]Match, error) {
+	cpe, err := cves.ParseCPE(cpeURI)
+	if err != nil {
+		return nil, err
+	}
+	vendor, product := Normalize(cpe.Vendor), Normalize(cpe.Product)
+
+	if o, ok := idx.overrides[vendor+":"+product]; ok {
+		if o.Ignore {
+			return nil, nil
+		}
+		return []Match{{
+			Vendor:      cpe.Vendor,
+			Product:     cpe.Product,
+			PackageName: cmp.Or(o.PackageName, cpe.Product),
+			Repos:       o.Repos,
+			Confidence:  ConfidenceOverride,
+			Reason:      "override",
+		}}, nil
+	}
+
+	var matches []Match
+	if p, ok := idx.products[vendor+":"+product]; ok {
+		matches = append(matches, newMatch(p, ConfidenceExact, "exact"))
+	} else {
+		for _, p := range idx.byVendor[vendor] {
+			if s := similarity(product, Normalize(p.Product)); s >= minFuzzySimilarity {
+				matches = append(matches, newMatch(p, ConfidenceFuzzyMax*s, "fuzzy product"))
+			}
+		}
+	}
+	if len(matches) == 0 {
+		candidates := idx.byProduct[product]
+		for _, p := range candidates {
+			matches = append(matches, newMatch(p, ConfidenceProductOnlyMax/float64(len(candidates)), "product only"))
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Or(-cmp.Compare(a.Confidence, b.Confidence), cmp.Compare(a.Vendor, b.Vendor), cmp.Compare(a.Product, b.Product))
+	})
+	return matches, nil
+}
@@ -0,0 +1,138 @@
+package cpedict
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func loadTestIndex(t *testing.T) *Index {
+	t.Helper()
+	file, err := os.Open("../test_data/cpedict/official-cpe-dictionary-mock.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	products, err := ParseDictionary(file)
+	if err != nil {
+		t.Fatalf("ParseDictionary() error: %v", err)
+	}
+	idx := NewIndex(products, map[string][]string{
+		"openssl:openssl": {"https://github.com/openssl/openssl"},
+	})
+
+	overrides, err := os.Open("../test_data/cpedict/overrides.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer overrides.Close()
+	if err := idx.LoadOverrides(overrides); err != nil {
+		t.Fatalf("LoadOverrides() error: %v", err)
+	}
+	return idx
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"OpenSSL":          "openssl",
+		"python-pillow":    "python_pillow",
+		"node\\.js":        "node_js",
+		"foo__bar--baz":    "foo_bar_baz",
+		"linux_kernel":     "linux_kernel",
+		"-trailing-dash-":  "trailing_dash",
+		"Apache HTTP Srv.": "apache_http_srv",
+	}
+	for input, want := range tests {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseDictionary(t *testing.T) {
+	idx := loadTestIndex(t)
+	want := []Product{
+		{Vendor: "haxx", Product: "libcurl", Title: "Haxx libcurl 7.0"},
+		{Vendor: "openssl", Product: "openssl", Title: "OpenSSL Project OpenSSL 1.1.1", Repos: []string{"https://github.com/openssl/openssl"}},
+		{Vendor: "python", Product: "pillow", Title: "Python Pillow 8.0"},
+		{Vendor: "python-pillow", Product: "pillow", Title: "Pillow 9.0"},
+	}
+	if diff := gocmp.Diff(want, idx.Products()); diff != "" {
+		t.Errorf("Products() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	idx := loadTestIndex(t)
+	var buf bytes.Buffer
+	if err := idx.Write(&buf); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, err := ReadIndex(&buf)
+	if err != nil {
+		t.Fatalf("ReadIndex() error: %v", err)
+	}
+	if diff := gocmp.Diff(idx.Products(), got.Products()); diff != "" {
+		t.Errorf("ReadIndex() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	idx := loadTestIndex(t)
+	tests := []struct {
+		cpe  string
+		want []Match
+	}{
+		{
+			cpe: "cpe:2.3:a:openssl:openssl:3.0.1:*:*:*:*:*:*:*",
+			want: []Match{
+				{Vendor: "openssl", Product: "openssl", PackageName: "openssl", Repos: []string{"https://github.com/openssl/openssl"}, Confidence: ConfidenceExact, Reason: "exact"},
+			},
+		},
+		{
+			cpe: "cpe:2.3:a:OpenSSL:Open-SSL:3.0.1:*:*:*:*:*:*:*",
+			want: []Match{
+				{Vendor: "openssl", Product: "openssl", PackageName: "openssl", Repos: []string{"https://github.com/openssl/openssl"}, Confidence: ConfidenceFuzzyMax * (1 - 1.0/8), Reason: "fuzzy product"},
+			},
+		},
+		{
+			cpe: "cpe:2.3:a:pillow_project:pillow:9.0:*:*:*:*:*:*:*",
+			want: []Match{
+				{Vendor: "python", Product: "pillow", PackageName: "pillow", Confidence: ConfidenceProductOnlyMax / 2, Reason: "product only"},
+				{Vendor: "python-pillow", Product: "pillow", PackageName: "pillow", Confidence: ConfidenceProductOnlyMax / 2, Reason: "product only"},
+			},
+		},
+		{
+			cpe: "cpe:2.3:a:haxx:curl:8.0:*:*:*:*:*:*:*",
+			want: []Match{
+				{Vendor: "haxx", Product: "curl", PackageName: "curl", Repos: []string{"https://github.com/curl/curl"}, Confidence: ConfidenceOverride, Reason: "override"},
+			},
+		},
+		{
+			cpe:  "cpe:2.3:a:microsoft:windows:10:*:*:*:*:*:*:*",
+			want: nil,
+		},
+		{
+			cpe:  "cpe:2.3:a:unknown:unknown:1.0:*:*:*:*:*:*:*",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cpe, func(t *testing.T) {
+			got, err := idx.Match(tt.cpe)
+			if err != nil {
+				t.Fatalf("Match() error: %v", err)
+			}
+			if diff := gocmp.Diff(tt.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := idx.Match("not a cpe"); err == nil {
+		t.Errorf("Match() of an invalid CPE succeeded")
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csaf converts OSV records into CSAF 2.0 security advisories.
+//
+// See https://docs.oasis-open.org/csaf/csaf/v2.0/csaf-v2.0.html
+package csaf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	csafVersion = "2.0"
+	// The category of documents produced.
+	categorySecurityAdvisory = "csaf_security_advisory"
+)
+
+var nonFileNameChars = regexp.MustCompile(`[^+\-a-z0-9]+`)
+
+// Publisher identifies who publishes the advisories.
+type Publisher struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// DefaultPublisher is the publisher of advisories converted from OSV.dev.
+var DefaultPublisher = Publisher{Category: "coordinator", Name: "OSV", Namespace: "https://osv.dev"}
+
+// Document is a CSAF 2.0 document, restricted to what is converted from OSV.
+type Document struct {
+	Document        DocumentMeta    `json:"document"`
+	ProductTree     *ProductTree    `json:"product_tree,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type DocumentMeta struct {
+	Category    string      `json:"category"`
+	CSAFVersion string      `json:"csaf_version"`
+	Title       string      `json:"title"`
+	Publisher   Publisher   `json:"publisher"`
+	Tracking    Tracking    `json:"tracking"`
+	Notes       []Note      `json:"notes,omitempty"`
+	References  []Reference `json:"references,omitempty"`
+}
+
+type Tracking struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status"`
+	Version            string     `json:"version"`
+	InitialReleaseDate string     `json:"initial_release_date"`
+	CurrentReleaseDate string     `json:"current_release_date"`
+	RevisionHistory    []Revision `json:"revision_history"`
+}
+
+type Revision struct {
+	Number  string `json:"number"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+}
+
+type Note struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+	Title    string `json:"title,omitempty"`
+}
+
+type Reference struct {
+	Category string `json:"category,omitempty"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
+
+type ProductTree struct {
+	Branches []Branch `json:"branches"`
+}
+
+// Branch is a level of the product tree: vendor (the ecosystem), product_name
+// (the package) and product_version or product_version_range.
+type Branch struct {
+	Category string   `json:"category"`
+	Name     string   `json:"name"`
+	Branches []Branch `json:"branches,omitempty"`
+	Product  *Product `json:"product,omitempty"`
+}
+
+type Product struct {
+	ProductID                   string                       `json:"product_id"`
+	Name                        string                       `json:"name"`
+	ProductIdentificationHelper *ProductIdentificationHelper `json:"product_identification_helper,omitempty"`
+}
+
+type ProductIdentificationHelper struct {
+	PURL string `json:"purl,omitempty"`
+}
+
+type Vulnerability struct {
+	CVE           string         `json:"cve,omitempty"`
+	IDs           []ID           `json:"ids,omitempty"`
+	Notes         []Note         `json:"notes,omitempty"`
+	ProductStatus *ProductStatus `json:"product_status,omitempty"`
+	Remediations  []Remediation  `json:"remediations,omitempty"`
+	References    []Reference    `json:"references,omitempty"`
+}
+
+type ID struct {
+	SystemName string `json:"system_name"`
+	Text       string `json:"text"`
+}
+
+type ProductStatus struct {
+	Fixed         []string `json:"fixed,omitempty"`
+	KnownAffected []string `json:"known_affected,omitempty"`
+}
+
+type Remediation struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// FileName returns the file name CSAF requires for a document with the
+// tracking ID id.
+func FileName(id string) string {
+	return nonFileNameChars.ReplaceAllString(strings.ToLower(id), "_") + ".json"
+}
+
+// versRange returns the vers (https://github.com/package-url/purl-spec/blob/master/VERSION-RANGE-SPEC.rst)
+// expression of the versions a range's events introduce, up to the next fix
+// or limit.
+func versRange(scheme string, events []vulns.Event) []string {
+	var ranges []string
+	introduced := ""
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			introduced = e.Introduced
+		case e.Fixed != "", e.Limit != "":
+			upper := e.Fixed
+			if upper == "" {
+				upper = e.Limit
+			}
+			if introduced == "0" || introduced == "" {
+				ranges = append(ranges, fmt.Sprintf("vers:%s/<%s", scheme, upper))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("vers:%s/>=%s|<%s", scheme, introduced, upper))
+			}
+			introduced = ""
+		case e.LastAffected != "":
+			if introduced == "0" || introduced == "" {
+				ranges = append(ranges, fmt.Sprintf("vers:%s/<=%s", scheme, e.LastAffected))
+			} else {
+				ranges = append(ranges, fmt.Sprintf("vers:%s/>=%s|<=%s", scheme, introduced, e.LastAffected))
+			}
+			introduced = ""
+		}
+	}
+	if introduced == "0" {
+		ranges = append(ranges, fmt.Sprintf("vers:%s/*", scheme))
+	} else if introduced != "" {
+		ranges = append(ranges, fmt.Sprintf("vers:%s/>=%s", scheme, introduced))
+	}
+	return ranges
+}
+
+// versScheme returns the vers scheme of an OSV ecosystem.
+func versScheme(ecosystem string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	switch base {
+	case "PyPI":
+		return "pypi"
+	case "npm":
+		return "npm"
+	case "Go":
+		return "golang"
+	case "Maven":
+		return "maven"
+	case "crates.io":
+		return "cargo"
+	case "RubyGems":
+		return "gem"
+	case "NuGet":
+		return "nuget"
+	case "Debian", "Ubuntu":
+		return "deb"
+	case "Alpine":
+		return "alpine"
+	case "Red Hat", "AlmaLinux", "Rocky Linux", "openEuler", "SUSE", "openSUSE":
+		return "rpm"
+	}
+	return "generic"
+}
+
+// converter accumulates the product tree while converting a record.
+type converter struct {
+	nextID  int
+	vendors []Branch
+}
+
+func (c *converter) productID() string {
+	c.nextID++
+	return fmt.Sprintf("CSAFPID-%04d", c.nextID)
+}
+
+// packageBranch returns the product_name branch of a package, creating it.
+func (c *converter) packageBranch(pkg *vulns.AffectedPackage) *Branch {
+	var vendor *Branch
+	for i := range c.vendors {
+		if c.vendors[i].Name == pkg.Ecosystem {
+			vendor = &c.vendors[i]
+		}
+	}
+	if vendor == nil {
+		c.vendors = append(c.vendors, Branch{Category: "vendor", Name: pkg.Ecosystem})
+		vendor = &c.vendors[len(c.vendors)-1]
+	}
+	for i := range vendor.Branches {
+		if vendor.Branches[i].Name == pkg.Name {
+			return &vendor.Branches[i]
+		}
+	}
+	vendor.Branches = append(vendor.Branches, Branch{Category: "product_name", Name: pkg.Name})
+	return &vendor.Branches[len(vendor.Branches)-1]
+}
+
+// addProduct adds a product_version or product_version_range of pkg to the
+// product tree, returning its ID.
+func (c *converter) addProduct(pkg *vulns.AffectedPackage, category string, version string) string {
+	branch := c.packageBranch(pkg)
+	for _, b := range branch.Branches {
+		if b.Category == category && b.Name == version {
+			return b.Product.ProductID
+		}
+	}
+	product := &Product{ProductID: c.productID(), Name: fmt.Sprintf("%s %s", pkg.Name, version)}
+	if pkg.Purl != "" {
+		purl := pkg.Purl
+		if category == "product_version" && !strings.Contains(purl, "@") {
+			purl += "@" + version
+		}
+		product.ProductIdentificationHelper = &ProductIdentificationHelper{PURL: purl}
+	}
+	branch.Branches = append(branch.Branches, Branch{Category: category, Name: version, Product: product})
+	return product.ProductID
+}
+
+// FromOSV converts an OSV record into a CSAF security advisory. Affected
+// package ranges become product_version_range products that are known
+// affected, and their fixed versions product_version products that are
+// fixed, with a vendor_fix remediation. Git ranges have no CSAF equivalent
+// and are omitted.
+func FromOSV(v *vulns.Vulnerability, publisher Publisher) (*Document, error) {
+	if v.ID == "" {
+		return nil, fmt.Errorf("record has no ID")
+	}
+	if v.Published == "" || v.Modified == "" {
+		return nil, fmt.Errorf("%s: record has no published or modified time", v.ID)
+	}
+
+	title := v.Summary
+	if title == "" {
+		title = v.ID
+	}
+	doc := &Document{
+		Document: DocumentMeta{
+			Category:    categorySecurityAdvisory,
+			CSAFVersion: csafVersion,
+			Title:       title,
+			Publisher:   publisher,
+			Tracking: Tracking{
+				ID:                 v.ID,
+				Status:             "final",
+				Version:            "1",
+				InitialReleaseDate: v.Published,
+				CurrentReleaseDate: v.Modified,
+				RevisionHistory:    []Revision{{Number: "1", Date: v.Modified, Summary: "Converted from OSV."}},
+			},
+		},
+	}
+	if v.Withdrawn != "" {
+		doc.Document.Notes = append(doc.Document.Notes, Note{Category: "general", Title: "Withdrawn", Text: fmt.Sprintf("This advisory was withdrawn on %s.", v.Withdrawn)})
+	}
+
+	vuln := Vulnerability{IDs: []ID{{SystemName: "OSV", Text: v.ID}}}
+	for _, id := range append([]string{v.ID}, v.Aliases...) {
+		if ids.IsCVE(id) {
+			if vuln.CVE == "" {
+				vuln.CVE = id
+			}
+		} else if id != v.ID {
+			systemName, _, _ := strings.Cut(id, "-")
+			if prefix, _, err := ids.Split(id); err == nil {
+				systemName = string(prefix)
+			}
+			vuln.IDs = append(vuln.IDs, ID{SystemName: systemName, Text: id})
+		}
+	}
+	if v.Details != "" {
+		vuln.Notes = append(vuln.Notes, Note{Category: "description", Title: "Details", Text: v.Details})
+	}
+	if v.Summary != "" {
+		vuln.Notes = append(vuln.Notes, Note{Category: "summary", Title: "Summary", Text: v.Summary})
+	}
+	for _, r := range v.References {
+		vuln.References = append(vuln.References, Reference{Category: "external", Summary: r.Type, URL: r.URL})
+	}
+
+	c := &converter{}
+	status := &ProductStatus{}
+	for _, affected := range v.Affected {
+		if affected.Package == nil || affected.Package.Name == "" {
+			continue
+		}
+		scheme := versScheme(affected.Package.Ecosystem)
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			var affectedIDs []string
+			for _, vers := range versRange(scheme, r.Events) {
+				affectedIDs = append(affectedIDs, c.addProduct(affected.Package, "product_version_range", vers))
+			}
+			status.KnownAffected = append(status.KnownAffected, affectedIDs...)
+			for _, e := range r.Events {
+				if e.Fixed == "" {
+					continue
+				}
+				status.Fixed = append(status.Fixed, c.addProduct(affected.Package, "product_version", e.Fixed))
+				vuln.Remediations = append(vuln.Remediations, Remediation{
+					Category:   "vendor_fix",
+					Details:    fmt.Sprintf("Upgrade %s to version %s or later.", affected.Package.Name, e.Fixed),
+					ProductIDs: affectedIDs,
+				})
+			}
+		}
+		for _, version := range affected.Versions {
+			status.KnownAffected = append(status.KnownAffected, c.addProduct(affected.Package, "product_version", version))
+		}
+	}
+	status.KnownAffected = unique(status.KnownAffected)
+	status.Fixed = unique(status.Fixed)
+	if len(status.KnownAffected) > 0 || len(status.Fixed) > 0 {
+		vuln.ProductStatus = status
+	}
+	if len(c.vendors) > 0 {
+		doc.ProductTree = &ProductTree{Branches: c.vendors}
+	}
+	doc.Vulnerabilities = []Vulnerability{vuln}
+	return doc, nil
+}
+
+// unique removes repeated elements, keeping the first occurrence.
+func unique(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	var result []string
+	for _, e := range s {
+		if !seen[e] {
+			seen[e] = true
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package csaf
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestVersRange(t *testing.T) {
+	tests := []struct {
+		events []vulns.Event
+		want   []string
+	}{
+		{
+			events: []vulns.Event{{Introduced: "0"}, {Fixed: "1.2.3"}},
+			want:   []string{"vers:pypi/<1.2.3"},
+		},
+		{
+			events: []vulns.Event{{Introduced: "1.0"}, {Fixed: "1.2.3"}, {Introduced: "2.0"}, {LastAffected: "2.1"}},
+			want:   []string{"vers:pypi/>=1.0|<1.2.3", "vers:pypi/>=2.0|<=2.1"},
+		},
+		{
+			events: []vulns.Event{{Introduced: "0"}},
+			want:   []string{"vers:pypi/*"},
+		},
+		{
+			events: []vulns.Event{{Introduced: "3.0"}},
+			want:   []string{"vers:pypi/>=3.0"},
+		},
+	}
+	for _, tt := range tests {
+		if diff := gocmp.Diff(tt.want, versRange("pypi", tt.events)); diff != "" {
+			t.Errorf("versRange(%+v) mismatch (-want +got):\n%s", tt.events, diff)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	if got := FileName("DEBIAN-CVE-2024.1234"); got != "debian-cve-2024_1234.json" {
+		t.Errorf("FileName() = %q", got)
+	}
+}
+
+func TestFromOSV(t *testing.T) {
+	v := &vulns.Vulnerability{
+		ID:        "PYSEC-2024-1",
+		Summary:   "Pillow buffer overflow",
+		Details:   "A buffer overflow in Pillow.",
+		Aliases:   []string{"CVE-2024-1234", "GHSA-aaaa-bbbb-cccc"},
+		Published: "2024-01-01T00:00:00Z",
+		Modified:  "2024-02-01T00:00:00Z",
+		Affected: []vulns.Affected{
+			{
+				Package: &vulns.AffectedPackage{Name: "pillow", Ecosystem: "PyPI", Purl: "pkg:pypi/pillow"},
+				Ranges: []vulns.AffectedRange{
+					{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "10.0.1"}}},
+					{Type: "GIT", Repo: "https://github.com/python-pillow/Pillow", Events: []vulns.Event{{Introduced: "0"}}},
+				},
+			},
+		},
+		References: []vulns.Reference{{Type: "ADVISORY", URL: "https://example.com/advisory"}},
+	}
+
+	got, err := FromOSV(v, DefaultPublisher)
+	if err != nil {
+		t.Fatalf("FromOSV() error: %v", err)
+	}
+	want := &Document{
+		Document: DocumentMeta{
+			Category:    "csaf_security_advisory",
+			CSAFVersion: "2.0",
+			Title:       "Pillow buffer overflow",
+			Publisher:   DefaultPublisher,
+			Tracking: Tracking{
+				ID:                 "PYSEC-2024-1",
+				Status:             "final",
+				Version:            "1",
+				InitialReleaseDate: "2024-01-01T00:00:00Z",
+				CurrentReleaseDate: "2024-02-01T00:00:00Z",
+				RevisionHistory:    []Revision{{Number: "1", Date: "2024-02-01T00:00:00Z", Summary: "Converted from OSV."}},
+			},
+		},
+		ProductTree: &ProductTree{Branches: []Branch{{
+			Category: "vendor",
+			Name:     "PyPI",
+			Branches: []Branch{{
+				Category: "product_name",
+				Name:     "pillow",
+				Branches: []Branch{
+					{
+						Category: "product_version_range",
+						Name:     "vers:pypi/<10.0.1",
+						Product: &Product{
+							ProductID:                   "CSAFPID-0001",
+							Name:                        "pillow vers:pypi/<10.0.1",
+							ProductIdentificationHelper: &ProductIdentificationHelper{PURL: "pkg:pypi/pillow"},
+						},
+					},
+					{
+						Category: "product_version",
+						Name:     "10.0.1",
+						Product: &Product{
+							ProductID:                   "CSAFPID-0002",
+							Name:                        "pillow 10.0.1",
+							ProductIdentificationHelper: &ProductIdentificationHelper{PURL: "pkg:pypi/pillow@10.0.1"},
+						},
+					},
+				},
+			}},
+		}}},
+		Vulnerabilities: []Vulnerability{{
+			CVE: "CVE-2024-1234",
+			IDs: []ID{{SystemName: "OSV", Text: "PYSEC-2024-1"}, {SystemName: "GHSA", Text: "GHSA-aaaa-bbbb-cccc"}},
+			Notes: []Note{
+				{Category: "description", Title: "Details", Text: "A buffer overflow in Pillow."},
+				{Category: "summary", Title: "Summary", Text: "Pillow buffer overflow"},
+			},
+			ProductStatus: &ProductStatus{Fixed: []string{"CSAFPID-0002"}, KnownAffected: []string{"CSAFPID-0001"}},
+			Remediations: []Remediation{{
+				Category:   "vendor_fix",
+				Details:    "Upgrade pillow to version 10.0.1 or later.",
+				ProductIDs: []string{"CSAFPID-0001"},
+			}},
+			References: []Reference{{Category: "external", Summary: "ADVISORY", URL: "https://example.com/advisory"}},
+		}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("FromOSV() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := FromOSV(&vulns.Vulnerability{ID: "PYSEC-2024-2"}, DefaultPublisher); err == nil {
+		t.Errorf("FromOSV() of a record without timestamps succeeded")
+	}
+}
@@ -17,6 +17,8 @@ package cves
 import (
 	"strings"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -46,15 +48,8 @@ type CVE5 struct {
 				Lang  string `json:"lang"`
 				Value string `json:"value"`
 			}
-			Tags     []string `json:"tags"`
-			Affected []struct {
-				Vendor   string `json:"vendor"`
-				Product  string `json:"product"`
-				Versions []struct {
-					Version string `json:"version"`
-					Status  string `json:"status"`
-				}
-			}
+			Tags       []string       `json:"tags"`
+			Affected   []CVE5Affected `json:"affected"`
 			References []struct {
 				URL string `json:"url"`
 			}
@@ -65,17 +60,90 @@ type CVE5 struct {
 					Description string `json:"description"`
 				}
 			}
+			Credits []CVE5Credit `json:"credits"`
 		}
 	}
 }
 
+// CVE5Affected is a CNA-provided entry in containers.cna.affected.
+// See https://github.com/CVEProject/cve-schema/blob/main/schema/CVE_Record_Format.json
+type CVE5Affected struct {
+	Vendor        string        `json:"vendor"`
+	Product       string        `json:"product"`
+	CollectionURL string        `json:"collectionURL,omitempty"`
+	PackageName   string        `json:"packageName,omitempty"`
+	Repo          string        `json:"repo,omitempty"`
+	DefaultStatus string        `json:"defaultStatus,omitempty"`
+	Versions      []CVE5Version `json:"versions"`
+}
+
+// CVE5Credit acknowledges a contribution to a CVE, in containers.cna.credits.
+type CVE5Credit struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+	// Type is one of finder, reporter, analyst, coordinator, remediation
+	// developer, remediation reviewer, remediation verifier, tool, sponsor
+	// or other.
+	Type string `json:"type,omitempty"`
+}
+
+// CVE5Version is a single version or version range of a CVE5Affected entry.
+type CVE5Version struct {
+	Version         string `json:"version"`
+	Status          string `json:"status"`
+	VersionType     string `json:"versionType,omitempty"`
+	LessThan        string `json:"lessThan,omitempty"`
+	LessThanOrEqual string `json:"lessThanOrEqual,omitempty"`
+}
+
+// EnglishDescription returns the CVE's first "en" description or, failing
+// that, its first description in a regional English locale (e.g. "en-US"),
+// regardless of the order of the other locales.
 func EnglishDescription(cve CVE) string {
+	regional := ""
 	for _, desc := range cve.Descriptions {
-		if desc.Lang == "en" {
+		lang := strings.ToLower(strings.TrimSpace(desc.Lang))
+		if lang == "en" {
 			return desc.Value
 		}
+		if regional == "" && strings.HasPrefix(lang, "en-") {
+			regional = desc.Value
+		}
+	}
+	return regional
+}
+
+const (
+	// Older rejected and disputed CVEs are only marked as such by the
+	// start of their description.
+	rejectedMarker       = "** REJECT **"
+	rejectedReasonMarker = "Rejected reason:"
+	disputedMarker       = "** DISPUTED **"
+	// NVD's vulnStatus of rejected CVEs.
+	rejectedStatus = "Rejected"
+	// The cveTags tag of disputed CVEs.
+	disputedTag = "disputed"
+)
+
+// IsRejected reports whether a CVE has been rejected, by its NVD status or
+// its description.
+func IsRejected(cve CVE) bool {
+	if cve.VulnStatus != nil && *cve.VulnStatus == rejectedStatus {
+		return true
+	}
+	description := strings.TrimSpace(EnglishDescription(cve))
+	return strings.HasPrefix(description, rejectedMarker) || strings.HasPrefix(description, rejectedReasonMarker)
+}
+
+// IsDisputed reports whether a CVE's validity is disputed, by its tags or
+// its description.
+func IsDisputed(cve CVE) bool {
+	for _, tag := range cve.CVETags {
+		if slices.Contains(tag.Tags, disputedTag) {
+			return true
+		}
 	}
-	return ""
+	return strings.HasPrefix(strings.TrimSpace(EnglishDescription(cve)), disputedMarker)
 }
 
 func ParseCVE5Timestamp(timestamp string) (time.Time, error) {
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MinDescriptionConfidence is the confidence below which version ranges
+// extracted from a description aren't used.
+const MinDescriptionConfidence = 0.5
+
+// DescriptionRange is a version range extracted from the text of a CVE
+// description.
+type DescriptionRange struct {
+	AffectedVersion
+	// Rule is the name of the rule that matched.
+	Rule string
+	// Confidence is how likely the range is to be right, from 0 to 1.
+	Confidence float64
+	// Text is the text the rule matched.
+	Text string
+}
+
+// descriptionRule is a pattern of a description that gives a version range.
+// Each rule's pattern has named groups for the versions it extracts:
+// introduced, fixed (the first version not affected) and last (the last
+// version affected).
+type descriptionRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	confidence float64
+}
+
+// descriptionVersion matches a version in a description, e.g. 2.4.1, v1.0.0-rc1,
+// 5.2.1+dfsg or 1175.v4b_d517d6db_f0. Each dot must be followed by more of the version, so a
+// sentence's trailing period isn't part of it.
+const descriptionVersion = `v?(\d+(?:\.[0-9A-Za-z]+)*(?:[-+~_][0-9A-Za-z]+(?:\.[0-9A-Za-z]+)*)*)`
+
+// newDescriptionRule returns a rule whose pattern has the placeholders
+// {introduced}, {fixed} and {last} replaced by versions, optionally preceded
+// by "version" or "versions".
+func newDescriptionRule(name, pattern string, confidence float64) descriptionRule {
+	for _, group := range []string{"introduced", "fixed", "last"} {
+		version := `(?:versions?\s+)?` + strings.Replace(descriptionVersion, "(", "(?P<"+group+">", 1)
+		pattern = strings.ReplaceAll(pattern, "{"+group+"}", version)
+	}
+	// Patterns starting with a word, or a version, mustn't start mid-word.
+	if !strings.HasPrefix(pattern, "<") && !strings.HasPrefix(pattern, ">") {
+		pattern = `\b` + pattern
+	}
+	return descriptionRule{
+		name:       name,
+		pattern:    regexp.MustCompile(`(?i)` + pattern + `\b`),
+		confidence: confidence,
+	}
+}
+
+// descriptionRules are the rules tried on a description, most specific first.
+// Text a rule matched isn't matched by later rules, so e.g. "1.0 before 1.3"
+// doesn't also give a range of "before 1.3".
+var descriptionRules = []descriptionRule{
+	newDescriptionRule("comparator_range", `>=\s*{introduced}\s*(?:,|and)?\s*<\s*{fixed}`, 0.9),
+	newDescriptionRule("comparator_range_inclusive", `>=\s*{introduced}\s*(?:,|and)?\s*<=\s*{last}`, 0.9),
+	newDescriptionRule("range_before", `(?:from\s+)?{introduced}\s*,?\s+(?:(?:and|or)\s+(?:later|above|newer)\s+)?(?:before|prior\s+to|until|up\s+until)\s+{fixed}`, 0.9),
+	newDescriptionRule("range_through", `(?:from\s+)?{introduced}\s+(?:through|thru|up\s+to\s+and\s+including)\s+{last}`, 0.85),
+	newDescriptionRule("range_between", `between\s+{introduced}\s+and\s+{last}`, 0.8),
+	newDescriptionRule("range_to", `(?:from\s+)?{introduced}\s+(?:to|-)\s+{last}`, 0.6),
+	newDescriptionRule("before", `(?:before|prior\s+to|earlier\s+than|older\s+than|lower\s+than)\s+{fixed}`, 0.8),
+	newDescriptionRule("through", `(?:through|thru|up\s+to\s+and\s+including)\s+{last}`, 0.75),
+	newDescriptionRule("and_earlier", `{last}\s*,?\s+(?:and|or)\s+(?:earlier|prior|before|below|older|lower)`, 0.75),
+	newDescriptionRule("up_to", `up\s+to\s+{last}`, 0.6),
+	newDescriptionRule("comparator_before", `<\s*{fixed}`, 0.7),
+	newDescriptionRule("comparator_through", `<=\s*{last}`, 0.7),
+	newDescriptionRule("fixed_in", `(?:fixed|patched|resolved|addressed)\s+in\s+{fixed}`, 0.6),
+	// A version affected from, without an end, says nothing of when it was
+	// fixed, so it's too weak to be used on its own.
+	newDescriptionRule("and_later", `{introduced}\s+(?:and|or)\s+(?:later|above|newer|higher)`, 0.4),
+	newDescriptionRule("since", `(?:starting\s+(?:with|in|from)|since)\s+{introduced}`, 0.4),
+}
+
+// upgradeContext matches text advising an upgrade, e.g. "upgrade from 1.0 to
+// 1.3", where a range is of the versions to upgrade between rather than the
+// affected ones.
+var upgradeContext = regexp.MustCompile(`(?i)\b(?:upgrad|updat)\w*\W+(?:\w+\W+){0,3}$`)
+
+// descriptionMatch is a match of a rule in a description.
+type descriptionMatch struct {
+	rule       descriptionRule
+	start, end int
+	versions   map[string]string
+}
+
+// matchDescriptionRules returns the matches of descriptionRules in text, in
+// the order they appear.
+func matchDescriptionRules(text string) []descriptionMatch {
+	var matches []descriptionMatch
+	claimed := make([]bool, len(text))
+	for _, rule := range descriptionRules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(text, -1) {
+			if claimedAny(claimed, loc[0], loc[1]) {
+				continue
+			}
+			versions := make(map[string]string)
+			for i, name := range rule.pattern.SubexpNames() {
+				if name != "" && loc[2*i] >= 0 {
+					versions[name] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			for i := loc[0]; i < loc[1]; i++ {
+				claimed[i] = true
+			}
+			matches = append(matches, descriptionMatch{rule: rule, start: loc[0], end: loc[1], versions: versions})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func claimedAny(claimed []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if claimed[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractVersionsFromText extracts the version ranges a description states in
+// prose, e.g. "before 2.4.1" or "1.0 through 1.3", scoring the confidence of
+// each. If validVersions is given, inclusive ranges are converted to the
+// next valid version being fixed, and versions that aren't valid lower the
+// confidence. It returns the ranges in the order they appear, with notes on
+// what lowered their confidence.
+func ExtractVersionsFromText(validVersions []string, text string) ([]DescriptionRange, []string) {
+	var notes []string
+	var ranges []DescriptionRange
+	for _, match := range matchDescriptionRules(text) {
+		introduced := match.versions["introduced"]
+		fixed := match.versions["fixed"]
+		lastAffected := match.versions["last"]
+		confidence := match.rule.confidence
+		matchText := strings.TrimSpace(text[match.start:match.end])
+
+		// A series, e.g. 4.17.x, is introduced by its first version.
+		if series, ok := strings.CutSuffix(strings.ToLower(introduced), ".x"); ok {
+			introduced = series + ".0"
+		}
+		if strings.HasSuffix(strings.ToLower(fixed), ".x") || strings.HasSuffix(strings.ToLower(lastAffected), ".x") {
+			notes = append(notes, fmt.Sprintf("Extracted range %q ends in a series", matchText))
+			confidence -= 0.3
+		}
+
+		if lastAffected != "" {
+			// An inclusive range is fixed by the version that comes after,
+			// if it's known.
+			if next, err := nextVersion(validVersions, lastAffected); err == nil {
+				fixed, lastAffected = next, ""
+			}
+		}
+
+		for _, version := range []struct{ kind, version string }{
+			{"introduced", introduced},
+			{"fixed", fixed},
+			{"last_affected", lastAffected},
+		} {
+			if version.version == "" {
+				continue
+			}
+			if !hasVersion(validVersions, version.version) {
+				notes = append(notes, fmt.Sprintf("Extracted %s version %s is not a valid version", version.kind, version.version))
+				confidence -= 0.3
+			}
+			if !strings.Contains(version.version, ".") {
+				// A bare number is as likely to be a count or a year.
+				confidence -= 0.35
+			}
+		}
+		if upgradeContext.MatchString(text[:match.start]) {
+			confidence -= 0.4
+		}
+		end := fixed
+		if end == "" {
+			end = lastAffected
+		}
+		if introduced != "" && end != "" && len(validVersions) > 0 && versionIndex(validVersions, introduced) > versionIndex(validVersions, end) {
+			notes = append(notes, fmt.Sprintf("Extracted range %s to %s is reversed", introduced, end))
+			confidence -= 0.5
+		}
+
+		ranges = addDescriptionRange(ranges, DescriptionRange{
+			AffectedVersion: AffectedVersion{
+				Introduced:   introduced,
+				Fixed:        fixed,
+				LastAffected: lastAffected,
+			},
+			Rule:       match.rule.name,
+			Confidence: min(max(confidence, 0), 1),
+			Text:       matchText,
+		})
+	}
+	return ranges, notes
+}
+
+// addDescriptionRange adds r to ranges, unless it's a duplicate, in which case
+// the more confident of the two is kept.
+func addDescriptionRange(ranges []DescriptionRange, r DescriptionRange) []DescriptionRange {
+	for i, existing := range ranges {
+		if existing.AffectedVersion == r.AffectedVersion {
+			if r.Confidence > existing.Confidence {
+				ranges[i] = r
+			}
+			return ranges
+		}
+	}
+	return append(ranges, r)
+}
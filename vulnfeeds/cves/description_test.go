@@ -0,0 +1,96 @@
+package cves
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// descriptionTestCase is a case of the description corpus in
+// test_data/cve-descriptions.
+type descriptionTestCase struct {
+	Description   string   `json:"description"`
+	ValidVersions []string `json:"valid_versions"`
+	Want          []struct {
+		AffectedVersion
+		Rule string `json:"rule"`
+		// Used is whether the range is confident enough to be used.
+		Used bool `json:"used"`
+	} `json:"want"`
+}
+
+func TestExtractVersionsFromText(t *testing.T) {
+	file, err := os.Open("../test_data/cve-descriptions/descriptions.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	defer file.Close()
+	var tests []descriptionTestCase
+	if err := json.NewDecoder(file).Decode(&tests); err != nil {
+		t.Fatalf("Failed to decode test data: %v", err)
+	}
+
+	for _, tc := range tests {
+		got, _ := ExtractVersionsFromText(tc.ValidVersions, tc.Description)
+		var gotVersions, wantVersions []AffectedVersion
+		var gotRules, wantRules []string
+		var gotUsed, wantUsed []bool
+		for _, r := range got {
+			gotVersions = append(gotVersions, r.AffectedVersion)
+			gotRules = append(gotRules, r.Rule)
+			gotUsed = append(gotUsed, r.Confidence >= MinDescriptionConfidence)
+			if r.Confidence < 0 || r.Confidence > 1 {
+				t.Errorf("%q: confidence of %q = %v, want between 0 and 1", tc.Description, r.Text, r.Confidence)
+			}
+		}
+		for _, w := range tc.Want {
+			wantVersions = append(wantVersions, w.AffectedVersion)
+			wantRules = append(wantRules, w.Rule)
+			wantUsed = append(wantUsed, w.Used)
+		}
+		if diff := cmp.Diff(wantVersions, gotVersions); diff != "" {
+			t.Errorf("%q: versions mismatch (-want +got):\n%s", tc.Description, diff)
+		}
+		if diff := cmp.Diff(wantRules, gotRules); diff != "" {
+			t.Errorf("%q: rules mismatch (-want +got):\n%s", tc.Description, diff)
+		}
+		if diff := cmp.Diff(wantUsed, gotUsed); diff != "" {
+			t.Errorf("%q: used mismatch (-want +got):\n%s", tc.Description, diff)
+		}
+	}
+}
+
+func TestExtractVersionInfoDescriptionFallback(t *testing.T) {
+	exact := "cpe:2.3:a:haxx:curl:8.3.0:*:*:*:*:*:*:*"
+	fixed := "8.4.0"
+	cve := CVE{
+		ID:           "CVE-2023-38545",
+		Descriptions: []LangString{{Lang: "en", Value: "curl 7.69.0 before 8.4.0 contains a heap-based buffer overflow in the SOCKS5 proxy handshake."}},
+		Configurations: []Config{{Nodes: []Node{{
+			Operator: "OR",
+			CPEMatch: []CPEMatch{{Vulnerable: true, Criteria: exact}},
+		}}}},
+	}
+
+	got, _ := ExtractVersionInfo(cve, nil)
+	want := []AffectedVersion{{Introduced: "7.69.0", Fixed: "8.4.0", Confidence: 0.9}}
+	if diff := cmp.Diff(want, got.AffectedVersions); diff != "" {
+		t.Errorf("ExtractVersionInfo() of an exact version CPE mismatch (-want +got):\n%s", diff)
+	}
+	if c := got.VersionConfidence(); c != 0.9 {
+		t.Errorf("VersionConfidence() of description versions = %v, want 0.9", c)
+	}
+
+	// CPE bounds are preferred to the description.
+	cve.Configurations[0].Nodes[0].CPEMatch[0] = CPEMatch{Vulnerable: true, Criteria: "cpe:2.3:a:haxx:curl:*:*:*:*:*:*:*:*", VersionEndExcluding: &fixed}
+	got, _ = ExtractVersionInfo(cve, nil)
+	want = []AffectedVersion{{Fixed: "8.4.0"}}
+	if diff := cmp.Diff(want, got.AffectedVersions); diff != "" {
+		t.Errorf("ExtractVersionInfo() of a bounded CPE mismatch (-want +got):\n%s", diff)
+	}
+	if c := got.VersionConfidence(); c != 1 {
+		t.Errorf("VersionConfidence() of CPE versions = %v, want 1", c)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cves
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// CVEHistoryAPIJSON20Schema is a page of the NVD CVE Change History API 2.0.
+// See https://nvd.nist.gov/developers/vulnerabilities#divGetCveHistory
+type CVEHistoryAPIJSON20Schema struct {
+	ResultsPerPage int             `json:"resultsPerPage"`
+	StartIndex     int             `json:"startIndex"`
+	TotalResults   int             `json:"totalResults"`
+	Format         string          `json:"format"`
+	Version        string          `json:"version"`
+	Timestamp      NVDTime         `json:"timestamp"`
+	CVEChanges     []CVEChangeItem `json:"cveChanges"`
+}
+
+type CVEChangeItem struct {
+	Change CVEChange `json:"change"`
+}
+
+// CVEChange is an event in the history of a CVE, e.g. its analysis or an
+// update by its CNA.
+type CVEChange struct {
+	CVEID            CVEID             `json:"cveId"`
+	EventName        string            `json:"eventName"`
+	CVEChangeID      string            `json:"cveChangeId"`
+	SourceIdentifier string            `json:"sourceIdentifier"`
+	Created          NVDTime           `json:"created"`
+	Details          []CVEChangeDetail `json:"details,omitempty"`
+}
+
+// CVEChangeDetail is a change of a field of a CVE.
+type CVEChangeDetail struct {
+	Action   string `json:"action,omitempty"`
+	Type     string `json:"type"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+}
+
+// Meaningful reports whether the change is to the fields OSV records are
+// converted from: the description, references, CPE configurations and CVSS
+// v3 metrics, or the CVE's rejection. Other changes (e.g. CWE remaps and
+// vendor comments) are churn as far as the records are concerned.
+func (c CVEChange) Meaningful() bool {
+	if c.EventName == "CVE Rejected" || c.EventName == "CVE Unrejected" {
+		return true
+	}
+	for _, d := range c.Details {
+		if d.Type == "Description" || d.Type == "Reference" || d.Type == "CPE Configuration" || strings.HasPrefix(d.Type, "CVSS V3") {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeHistory is the time of the last meaningful change of each CVE, as of
+// the NVD change history from Start up to Updated.
+type ChangeHistory struct {
+	Start   time.Time           `json:"start"`
+	Updated time.Time           `json:"updated"`
+	Changed map[CVEID]time.Time `json:"changed"`
+}
+
+// NewChangeHistory returns an empty ChangeHistory starting at start.
+func NewChangeHistory(start time.Time) *ChangeHistory {
+	return &ChangeHistory{Start: start, Updated: start, Changed: make(map[CVEID]time.Time)}
+}
+
+// LoadChangeHistory loads a ChangeHistory written by ToJSON, returning
+// fs.ErrNotExist if there's none at filePath.
+func LoadChangeHistory(filePath string) (*ChangeHistory, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var h ChangeHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	if h.Changed == nil {
+		h.Changed = make(map[CVEID]time.Time)
+	}
+	return &h, nil
+}
+
+func (h *ChangeHistory) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(h)
+}
+
+// Record records the meaningful changes among changes.
+func (h *ChangeHistory) Record(changes []CVEChangeItem) {
+	for _, item := range changes {
+		c := item.Change
+		if !c.Meaningful() {
+			continue
+		}
+		created := c.Created.UTC()
+		if created.After(h.Changed[c.CVEID]) {
+			h.Changed[c.CVEID] = created
+		}
+	}
+}
+
+// LastChanged returns the time of the last meaningful change of a CVE, and
+// whether it's known: it isn't for CVEs modified since the history was
+// updated, nor for CVEs published before the history starts and not
+// meaningfully changed since, whose last meaningful change predates it. A
+// CVE published since the history starts without meaningful changes since
+// was last changed when it was published.
+func (h *ChangeHistory) LastChanged(cve CVE) (time.Time, bool) {
+	if cve.LastModified.After(h.Updated) {
+		return time.Time{}, false
+	}
+	if t, ok := h.Changed[cve.ID]; ok {
+		return t, true
+	}
+	if !cve.Published.Before(h.Start) {
+		return cve.Published.Time, true
+	}
+	return time.Time{}, false
+}
+
+// Apply sets the LastModified time of cve to its last meaningful change, if
+// that's known, so the records converted from it are only modified when it
+// meaningfully changes.
+func (h *ChangeHistory) Apply(cve *CVE) {
+	if t, ok := h.LastChanged(*cve); ok && t.Before(cve.LastModified.Time) {
+		cve.LastModified.Time = t
+	}
+}
@@ -0,0 +1,67 @@
+package cves
+
+import (
+	"testing"
+	"time"
+)
+
+func nvdTime(s string) NVDTime {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return NVDTime{t}
+}
+
+func TestChangeHistoryApply(t *testing.T) {
+	h := NewChangeHistory(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h.Updated = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	h.Record([]CVEChangeItem{
+		{Change: CVEChange{CVEID: "CVE-2023-0001", EventName: "Reanalysis", Created: nvdTime("2024-02-01T00:00:00Z"),
+			Details: []CVEChangeDetail{{Action: "Changed", Type: "CPE Configuration"}}}},
+		{Change: CVEChange{CVEID: "CVE-2023-0001", EventName: "CWE Remap", Created: nvdTime("2024-03-01T00:00:00Z"),
+			Details: []CVEChangeDetail{{Action: "Changed", Type: "CWE"}}}},
+		{Change: CVEChange{CVEID: "CVE-2023-0002", EventName: "CVE Rejected", Created: nvdTime("2024-02-15T00:00:00Z")}},
+	})
+
+	tests := []struct {
+		name string
+		cve  CVE
+		want string
+	}{
+		{
+			name: "meaningfully changed",
+			cve:  CVE{ID: "CVE-2023-0001", Published: nvdTime("2023-01-01T00:00:00Z"), LastModified: nvdTime("2024-03-01T00:00:00Z")},
+			want: "2024-02-01T00:00:00Z",
+		},
+		{
+			name: "rejected",
+			cve:  CVE{ID: "CVE-2023-0002", Published: nvdTime("2023-01-01T00:00:00Z"), LastModified: nvdTime("2024-02-15T00:00:00Z")},
+			want: "2024-02-15T00:00:00Z",
+		},
+		{
+			name: "published since the history starts",
+			cve:  CVE{ID: "CVE-2024-0001", Published: nvdTime("2024-01-10T00:00:00Z"), LastModified: nvdTime("2024-04-01T00:00:00Z")},
+			want: "2024-01-10T00:00:00Z",
+		},
+		{
+			name: "unchanged since before the history starts",
+			cve:  CVE{ID: "CVE-2022-0001", Published: nvdTime("2022-01-01T00:00:00Z"), LastModified: nvdTime("2024-04-01T00:00:00Z")},
+			want: "2024-04-01T00:00:00Z",
+		},
+		{
+			name: "modified since the history was updated",
+			cve:  CVE{ID: "CVE-2023-0001", Published: nvdTime("2023-01-01T00:00:00Z"), LastModified: nvdTime("2024-07-01T00:00:00Z")},
+			want: "2024-07-01T00:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cve := tt.cve
+			h.Apply(&cve)
+			if got := cve.LastModified.Format(time.RFC3339); got != tt.want {
+				t.Errorf("Apply() LastModified = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -109,6 +109,12 @@ type CVEAPIJSON20Schema struct {
 
 type CVEID string
 
+// CVETag is a tag of a CVE by one of its sources, e.g. "disputed".
+type CVETag struct {
+	SourceIdentifier string   `json:"sourceIdentifier,omitempty" yaml:"sourceIdentifier,omitempty" mapstructure:"sourceIdentifier,omitempty"`
+	Tags             []string `json:"tags,omitempty" yaml:"tags,omitempty" mapstructure:"tags,omitempty"`
+}
+
 type CVE struct {
 	// CISAActionDue corresponds to the JSON schema field "cisaActionDue".
 	CISAActionDue *types.SerializableDate `json:"cisaActionDue,omitempty" yaml:"cisaActionDue,omitempty" mapstructure:"cisaActionDue,omitempty"`
@@ -123,6 +129,9 @@ type CVE struct {
 	// "cisaVulnerabilityName".
 	CISAVulnerabilityName *string `json:"cisaVulnerabilityName,omitempty" yaml:"cisaVulnerabilityName,omitempty" mapstructure:"cisaVulnerabilityName,omitempty"`
 
+	// CVETags corresponds to the JSON schema field "cveTags".
+	CVETags []CVETag `json:"cveTags,omitempty" yaml:"cveTags,omitempty" mapstructure:"cveTags,omitempty"`
+
 	// Configurations corresponds to the JSON schema field "configurations".
 	Configurations []Config `json:"configurations,omitempty" yaml:"configurations,omitempty" mapstructure:"configurations,omitempty"`
 
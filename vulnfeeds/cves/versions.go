@@ -30,6 +30,9 @@ import (
 	"github.com/knqyf263/go-cpe/naming"
 	"github.com/sethvargo/go-retry"
 	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/httpclient"
+	"github.com/google/osv/vulnfeeds/repourl"
 )
 
 type AffectedCommit struct {
@@ -41,14 +44,10 @@ type AffectedCommit struct {
 }
 
 func (ac *AffectedCommit) SetRepo(repo string) {
-	// GitHub.com repos are demonstrably case-insensitive, and frequently
-	// expressed in URLs with varying cases, so normalize them to lowercase.
-	// vulns.AddPkgInfo() treats repos case sensitively, and this can result in
-	// incorrect behaviour.
-	if strings.Contains(strings.ToLower(repo), "github.com") {
-		repo = strings.ToLower(repo)
-	}
-	ac.Repo = repo
+	// Repos are frequently expressed with varying URLs (e.g. GitHub.com repos
+	// are case-insensitive, and mirrored), and vulns.AddPkgInfo() treats repos
+	// case sensitively, so normalize them to their canonical URL.
+	ac.Repo = repourl.Canonical(repo)
 }
 
 func (ac *AffectedCommit) SetIntroduced(commit string) {
@@ -98,6 +97,10 @@ type AffectedVersion struct {
 	Introduced   string `json:"introduced,omitempty" yaml:"introduced,omitempty"`
 	Fixed        string `json:"fixed,omitempty" yaml:"fixed,omitempty"`
 	LastAffected string `json:"last_affected,omitempty" yaml:"last_affected,omitempty"`
+	// Confidence is how likely a range extracted heuristically (e.g. from a
+	// description) is to be right, from 0 to 1. It's unset for ranges taken
+	// from structured data.
+	Confidence float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`
 }
 
 type VersionInfo struct {
@@ -105,6 +108,18 @@ type VersionInfo struct {
 	AffectedVersions []AffectedVersion `json:"affected_versions,omitempty" yaml:"affected_versions,omitempty"`
 }
 
+// VersionConfidence returns the confidence of the least confident affected
+// version extracted heuristically, or 1 if none were.
+func (vi *VersionInfo) VersionConfidence() float64 {
+	confidence := 1.0
+	for _, av := range vi.AffectedVersions {
+		if av.Confidence > 0 {
+			confidence = min(confidence, av.Confidence)
+		}
+	}
+	return confidence
+}
+
 func (vi *VersionInfo) HasFixedVersions() bool {
 	for _, av := range vi.AffectedVersions {
 		if av.Fixed != "" {
@@ -624,8 +639,13 @@ func ValidateAndCanonicalizeLink(link string) (canonicalLink string, err error)
 		// Handle what's presumably a git:// URL.
 		return link, err
 	}
+	attempt := 0
 	backoff := retry.NewExponential(1 * time.Second)
 	if err := retry.Do(context.Background(), retry.WithMaxRetries(3, backoff), func(ctx context.Context) error {
+		if attempt > 0 {
+			httpclient.Retried(link)
+		}
+		attempt++
 		req, err := http.NewRequest("HEAD", link, nil)
 		if err != nil {
 			return err
@@ -731,71 +751,26 @@ func nextVersion(validVersions []string, version string) (string, error) {
 	return validVersions[idx], nil
 }
 
-func processExtractedVersion(version string) string {
-	version = strings.Trim(version, ".")
-	// Version should contain at least a "." or a number.
-	if !strings.ContainsAny(version, ".") && !strings.ContainsAny(version, "0123456789") {
-		return ""
-	}
-
-	return version
-}
-
+// extractVersionsFromDescription returns the version ranges extracted from a
+// description with at least MinDescriptionConfidence.
 func extractVersionsFromDescription(validVersions []string, description string) ([]AffectedVersion, []string) {
-	// Match:
-	//  - x.x.x before x.x.x
-	//  - x.x.x through x.x.x
-	//  - through x.x.x
-	//  - before x.x.x
-	pattern := regexp.MustCompile(`(?i)([\w.+\-]+)?\s+(through|before)\s+(?:version\s+)?([\w.+\-]+)`)
-	matches := pattern.FindAllStringSubmatch(description, -1)
-	if matches == nil {
+	ranges, notes := ExtractVersionsFromText(validVersions, description)
+	if len(ranges) == 0 {
 		return nil, []string{"Failed to parse versions from description"}
 	}
 
-	var notes []string
 	var versions []AffectedVersion
-	for _, match := range matches {
-		// Trim periods that are part of sentences.
-		introduced := processExtractedVersion(match[1])
-		fixed := processExtractedVersion(match[3])
-		lastaffected := ""
-		if match[2] == "through" {
-			// "Through" implies inclusive range, so the fixed version is the one that comes after.
-			var err error
-			fixed, err = nextVersion(validVersions, fixed)
-			if err != nil {
-				notes = append(notes, err.Error())
-				// if that inference failed, we know this version was definitely still vulnerable.
-				lastaffected = cleanVersion(match[3])
-				notes = append(notes, fmt.Sprintf("Using %s as last_affected version instead", cleanVersion(match[3])))
-			}
-		}
-
-		if introduced == "" && fixed == "" && lastaffected == "" {
-			notes = append(notes, "Failed to match version range from description")
+	for _, r := range ranges {
+		if r.Confidence < MinDescriptionConfidence {
+			notes = append(notes, fmt.Sprintf("Ignoring %q from description with confidence %.2f (%s)", r.Text, r.Confidence, r.Rule))
 			continue
 		}
-
-		if introduced != "" && !hasVersion(validVersions, introduced) {
-			notes = append(notes, fmt.Sprintf("Extracted introduced version %s is not a valid version", introduced))
-		}
-		if fixed != "" && !hasVersion(validVersions, fixed) {
-			notes = append(notes, fmt.Sprintf("Extracted fixed version %s is not a valid version", fixed))
-		}
-		if lastaffected != "" && !hasVersion(validVersions, lastaffected) {
-			notes = append(notes, fmt.Sprintf("Extracted last_affected version %s is not a valid version", lastaffected))
-		}
-		// Favour fixed over last_affected for schema compliance.
-		if fixed != "" && lastaffected != "" {
-			lastaffected = ""
-		}
-
-		versions = append(versions, AffectedVersion{
-			Introduced:   introduced,
-			Fixed:        fixed,
-			LastAffected: lastaffected,
-		})
+		av := r.AffectedVersion
+		av.Confidence = r.Confidence
+		versions = append(versions, av)
+	}
+	if len(versions) == 0 {
+		notes = append(notes, "Failed to match version range from description")
 	}
 
 	return versions, notes
@@ -815,6 +790,7 @@ func ExtractVersionInfo(cve CVE, validVersions []string) (v VersionInfo, notes [
 	}
 
 	gotVersions := false
+	gotBounds := false
 	for _, config := range cve.Configurations {
 		for _, node := range config.Nodes {
 			if node.Operator != "OR" {
@@ -826,6 +802,10 @@ func ExtractVersionInfo(cve CVE, validVersions []string) (v VersionInfo, notes [
 					continue
 				}
 
+				if match.VersionStartIncluding != nil || match.VersionStartExcluding != nil || match.VersionEndExcluding != nil || match.VersionEndIncluding != nil {
+					gotBounds = true
+				}
+
 				introduced := ""
 				fixed := ""
 				lastaffected := ""
@@ -900,11 +880,16 @@ func ExtractVersionInfo(cve CVE, validVersions []string) (v VersionInfo, notes [
 			}
 		}
 	}
-	if !gotVersions {
-		var extractNotes []string
-		v.AffectedVersions, extractNotes = extractVersionsFromDescription(validVersions, EnglishDescription(cve))
+	// CPEs of only specific versions don't say which later versions are
+	// affected, so prefer any range the description gives.
+	if !gotBounds {
+		descVersions, extractNotes := extractVersionsFromDescription(validVersions, EnglishDescription(cve))
 		notes = append(notes, extractNotes...)
-		if len(v.AffectedVersions) > 0 {
+		if len(descVersions) > 0 {
+			if gotVersions {
+				notes = append(notes, "Using versions extracted from the description instead of the CPEs' unbounded versions")
+			}
+			v.AffectedVersions = descVersions
 			log.Printf("[%s] Extracted versions from description = %+v", cve.ID, v.AffectedVersions)
 		}
 	}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cvss scores CVSS vectors, so the records carry the numeric base
+// score and rating of their severity and consumers don't have to compute it.
+package cvss
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	gocvss30 "github.com/pandatix/go-cvss/30"
+	gocvss31 "github.com/pandatix/go-cvss/31"
+	gocvss40 "github.com/pandatix/go-cvss/40"
+)
+
+// ErrUnsupportedVersion is returned for vectors of a CVSS version that isn't
+// scored.
+var ErrUnsupportedVersion = errors.New("unsupported CVSS version")
+
+// Score is the score of a CVSS vector.
+type Score struct {
+	// Version is the CVSS version of the vector, e.g. "3.1".
+	Version string `json:"version"`
+	// BaseScore is the numeric score, from 0.0 to 10.0. For CVSS v4 vectors
+	// with threat or environmental metrics, it accounts for them, as the
+	// specification defines a single score.
+	BaseScore float64 `json:"base_score"`
+	// Rating is the qualitative rating of the score: NONE, LOW, MEDIUM, HIGH
+	// or CRITICAL.
+	Rating string `json:"rating"`
+}
+
+// Calculate scores a CVSS v3.0, v3.1 or v4.0 vector.
+func Calculate(vector string) (Score, error) {
+	var score Score
+	var err error
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.0/"):
+		score.Version = "3.0"
+		var v *gocvss30.CVSS30
+		if v, err = gocvss30.ParseVector(vector); err == nil {
+			score.BaseScore = v.BaseScore()
+			score.Rating, err = gocvss30.Rating(score.BaseScore)
+		}
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		score.Version = "3.1"
+		var v *gocvss31.CVSS31
+		if v, err = gocvss31.ParseVector(vector); err == nil {
+			score.BaseScore = v.BaseScore()
+			score.Rating, err = gocvss31.Rating(score.BaseScore)
+		}
+	case strings.HasPrefix(vector, "CVSS:4.0/"):
+		score.Version = "4.0"
+		var v *gocvss40.CVSS40
+		if v, err = gocvss40.ParseVector(vector); err == nil {
+			score.BaseScore = v.Score()
+			score.Rating, err = gocvss40.Rating(score.BaseScore)
+		}
+	default:
+		return Score{}, fmt.Errorf("%w: %q", ErrUnsupportedVersion, vector)
+	}
+	if err != nil {
+		return Score{}, fmt.Errorf("invalid CVSS vector %q: %w", vector, err)
+	}
+	return score, nil
+}
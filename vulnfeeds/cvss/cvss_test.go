@@ -0,0 +1,105 @@
+package cvss
+
+import (
+	"errors"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// The examples of the CVSS v3.1 specification document.
+// https://www.first.org/cvss/v3.1/examples
+func TestCalculateV31(t *testing.T) {
+	tests := []struct {
+		name   string
+		vector string
+		want   Score
+	}{
+		{"CVE-2013-1937", "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", Score{"3.1", 6.1, "MEDIUM"}},
+		{"CVE-2013-0375", "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", Score{"3.1", 6.4, "MEDIUM"}},
+		{"CVE-2014-3566", "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N", Score{"3.1", 3.1, "LOW"}},
+		{"CVE-2012-1516", "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H", Score{"3.1", 9.9, "CRITICAL"}},
+		{"CVE-2009-0783", "CVSS:3.1/AV:L/AC:L/PR:H/UI:N/S:U/C:L/I:L/A:L", Score{"3.1", 4.2, "MEDIUM"}},
+		{"CVE-2012-0384", "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", Score{"3.1", 8.8, "HIGH"}},
+		{"CVE-2015-1098", "CVSS:3.1/AV:L/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H", Score{"3.1", 7.8, "HIGH"}},
+		{"CVE-2014-0160", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N", Score{"3.1", 7.5, "HIGH"}},
+		{"CVE-2014-6271", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Score{"3.1", 9.8, "CRITICAL"}},
+		{"CVE-2008-1447", "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:C/C:N/I:H/A:N", Score{"3.1", 6.8, "MEDIUM"}},
+		{"CVE-2014-2005", "CVSS:3.1/AV:P/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Score{"3.1", 6.8, "MEDIUM"}},
+		{"CVE-2010-0467", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:L/I:N/A:N", Score{"3.1", 5.8, "MEDIUM"}},
+		{"CVE-2012-1342", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:N/I:L/A:N", Score{"3.1", 5.8, "MEDIUM"}},
+		{"CVE-2013-6014", "CVSS:3.1/AV:A/AC:L/PR:N/UI:N/S:C/C:N/I:H/A:H", Score{"3.1", 9.3, "CRITICAL"}},
+		{"CVE-2016-1645", "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H", Score{"3.1", 8.8, "HIGH"}},
+		{"CVE-2016-0128", "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:U/C:H/I:H/A:N", Score{"3.1", 6.8, "MEDIUM"}},
+		{"no impact", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", Score{"3.1", 0.0, "NONE"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Calculate(tc.vector)
+			if err != nil {
+				t.Fatalf("Calculate(%q) failed: %v", tc.vector, err)
+			}
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Calculate(%q) mismatch (-want +got):\n%s", tc.vector, diff)
+			}
+		})
+	}
+}
+
+func TestCalculateV30(t *testing.T) {
+	got, err := Calculate("CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	if err != nil {
+		t.Fatalf("Calculate() failed: %v", err)
+	}
+	if diff := gocmp.Diff(Score{"3.0", 9.8, "CRITICAL"}, got); diff != "" {
+		t.Errorf("Calculate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// Examples of the CVSS v4.0 specification and the FIRST calculator.
+// https://www.first.org/cvss/v4.0/examples
+func TestCalculateV40(t *testing.T) {
+	tests := []struct {
+		name   string
+		vector string
+		want   Score
+	}{
+		{"all high", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", Score{"4.0", 10.0, "CRITICAL"}},
+		{"vulnerable system only", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Score{"4.0", 9.3, "CRITICAL"}},
+		{"local with user interaction", "CVSS:4.0/AV:L/AC:L/AT:N/PR:N/UI:P/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Score{"4.0", 8.5, "HIGH"}},
+		{"no impact", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", Score{"4.0", 0.0, "NONE"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Calculate(tc.vector)
+			if err != nil {
+				t.Fatalf("Calculate(%q) failed: %v", tc.vector, err)
+			}
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Calculate(%q) mismatch (-want +got):\n%s", tc.vector, diff)
+			}
+		})
+	}
+}
+
+func TestCalculateInvalid(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   error
+	}{
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", ErrUnsupportedVersion},
+		{"CVSS:3.1/AV:N/AC:L", nil},
+		{"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", nil},
+		{"CVSS:4.0/AV:N", nil},
+	}
+	for _, tc := range tests {
+		_, err := Calculate(tc.vector)
+		if err == nil {
+			t.Errorf("Calculate(%q) succeeded, want an error", tc.vector)
+			continue
+		}
+		if tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("Calculate(%q) = %v, want %v", tc.vector, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package details composes the details text of records converted from
+// distributions' advisories from a template, so they say which packages of
+// which releases are affected and fixed, rather than only repeating the
+// upstream CVE's description.
+package details
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// DefaultTemplate introduces the upstream description with a line per
+// affected package.
+const DefaultTemplate = `{{range .Packages}}{{.Name}} in {{.Distro}}{{with .Release}} {{.}}{{end}} is affected by {{$.ID}}{{with .Fixed}}, and fixed in {{join . ", "}}{{end}}.
+{{end}}{{with .Upstream}}
+Upstream description:
+
+{{.}}{{end}}`
+
+// Package is an affected package of a record, as given to templates.
+type Package struct {
+	Name string
+	// Distro is the package's ecosystem, without its release (e.g. "Debian").
+	Distro string
+	// Release is the release of the ecosystem (e.g. "12"), if it has one.
+	Release string
+	// Fixed are the versions the package is fixed in.
+	Fixed []string
+}
+
+// Data is what templates are executed with.
+type Data struct {
+	ID       string
+	Packages []Package
+	// Upstream is the upstream CVE's description.
+	Upstream string
+}
+
+// Generator composes details from a template.
+type Generator struct {
+	tmpl *template.Template
+}
+
+// New returns a Generator of the text/template text, which has a join
+// function of strings.Join.
+func New(text string) (*Generator, error) {
+	tmpl, err := template.New("details").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{tmpl: tmpl}, nil
+}
+
+// Load returns a Generator of the template in the file at path, or of
+// DefaultTemplate if path is empty.
+func Load(path string) (*Generator, error) {
+	if path == "" {
+		return New(DefaultTemplate)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(string(text))
+}
+
+// DataOf returns the Data of v, taking its current details as the upstream
+// description. Packages without ECOSYSTEM or SEMVER ranges, e.g. of GIT
+// ranges only, are left out.
+func DataOf(v *vulns.Vulnerability) Data {
+	data := Data{ID: v.ID, Upstream: strings.TrimSpace(v.Details)}
+	for _, affected := range v.Affected {
+		if affected.Package == nil {
+			continue
+		}
+		distro, release, _ := strings.Cut(affected.Package.Ecosystem, ":")
+		pkg := Package{Name: affected.Package.Name, Distro: distro, Release: release}
+		hasVersions := len(affected.Versions) > 0
+		for _, r := range affected.Ranges {
+			if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+				continue
+			}
+			hasVersions = true
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					pkg.Fixed = append(pkg.Fixed, e.Fixed)
+				}
+			}
+		}
+		if hasVersions {
+			data.Packages = append(data.Packages, pkg)
+		}
+	}
+	return data
+}
+
+// Generate returns the details of v composed by the template. Records
+// without affected packages keep their details.
+func (g *Generator) Generate(v *vulns.Vulnerability) (string, error) {
+	data := DataOf(v)
+	if len(data.Packages) == 0 {
+		return v.Details, nil
+	}
+	var buf bytes.Buffer
+	if err := g.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,74 @@
+package details
+
+import (
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func record() *vulns.Vulnerability {
+	return &vulns.Vulnerability{
+		ID:      "CVE-2024-1234",
+		Details: "A heap overflow in curl before 8.6.0.\n",
+		Affected: []vulns.Affected{
+			{
+				Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Debian:12"},
+				Ranges:  []vulns.AffectedRange{{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "7.88.1-10+deb12u5"}}}},
+			},
+			{
+				Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Alpine:v3.19"},
+				Ranges:  []vulns.AffectedRange{{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}}}},
+			},
+			{
+				Ranges: []vulns.AffectedRange{{Type: "GIT", Repo: "https://github.com/curl/curl", Events: []vulns.Event{{Introduced: "0"}}}},
+			},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	g, err := New(DefaultTemplate)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	got, err := g.Generate(record())
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	want := `curl in Debian 12 is affected by CVE-2024-1234, and fixed in 7.88.1-10+deb12u5.
+curl in Alpine v3.19 is affected by CVE-2024-1234.
+
+Upstream description:
+
+A heap overflow in curl before 8.6.0.`
+	if got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateCustom(t *testing.T) {
+	g, err := New(`{{range .Packages}}[{{.Distro}}] {{.Name}}{{end}}`)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	got, err := g.Generate(record())
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if want := "[Debian] curl[Alpine] curl"; got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+
+	// Records without affected packages keep their details.
+	v := record()
+	v.Affected = v.Affected[2:]
+	if got, _ := g.Generate(v); got != v.Details {
+		t.Errorf("Generate() of a record without packages = %q, want %q", got, v.Details)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New("{{.Packages"); err == nil {
+		t.Error("New() of an invalid template: want error")
+	}
+}
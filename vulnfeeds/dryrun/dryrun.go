@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dryrun lets converters run in full without writing their output,
+// reporting what they would have created, modified and removed instead, so
+// changes to their code or configuration can be checked against the output
+// of a previous run.
+package dryrun
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// Flags holds the dry-run flags, shared by the converters.
+type Flags struct {
+	enabled *bool
+	diff    *bool
+}
+
+// RegisterFlags registers the dry-run flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		enabled: fs.Bool("dryRun", false, "Convert without writing anything, printing a summary of the output files that would be created, modified and removed instead"),
+		diff:    fs.Bool("dryRunDiff", false, "Also print the diff of each output file that would be modified (with -dryRun)"),
+	}
+}
+
+// Enabled reports whether the run is a dry run.
+func (f *Flags) Enabled() bool {
+	return *f.enabled
+}
+
+// Report summarizes plan against its output directory and prints the
+// summary to w.
+func (f *Flags) Report(plan *Plan, w io.Writer) error {
+	summary, err := plan.Summarize()
+	if err != nil {
+		return err
+	}
+	summary.Print(w, *f.diff)
+	return nil
+}
+
+// Plan collects the files a converter would write to, and remove from, an
+// output directory. It's safe for concurrent use.
+type Plan struct {
+	dir   string
+	match func(name string) bool
+
+	mu      sync.Mutex
+	files   map[string][]byte
+	removed map[string]bool
+}
+
+// NewPlan returns the plan of the output directory dir. Existing files whose
+// path (relative to dir, with forward slashes) satisfies match and that the
+// plan doesn't write count as removed, since they'd be stale; with a nil
+// match, only the files removed explicitly do.
+func NewPlan(dir string, match func(name string) bool) *Plan {
+	return &Plan{dir: dir, match: match, files: make(map[string][]byte), removed: make(map[string]bool)}
+}
+
+// Write records that the file name, relative to the output directory, would
+// be written with data.
+func (p *Plan) Write(name string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[filepath.ToSlash(name)] = bytes.Clone(data)
+	delete(p.removed, filepath.ToSlash(name))
+}
+
+// Remove records that the file name, relative to the output directory, would
+// be removed.
+func (p *Plan) Remove(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.files[filepath.ToSlash(name)]; !ok {
+		p.removed[filepath.ToSlash(name)] = true
+	}
+}
+
+// Summary is what a plan would change in its output directory.
+type Summary struct {
+	Created   []string
+	Modified  []string
+	Removed   []string
+	Unchanged int
+	// Diffs holds the diff of each modified file, by name.
+	Diffs map[string]string
+}
+
+// Summarize compares the plan to the files in its output directory.
+func (p *Plan) Summarize() (*Summary, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := &Summary{Diffs: make(map[string]string)}
+	for name, data := range p.files {
+		existing, err := os.ReadFile(filepath.Join(p.dir, filepath.FromSlash(name)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			s.Created = append(s.Created, name)
+		case err != nil:
+			return nil, err
+		case bytes.Equal(existing, data):
+			s.Unchanged++
+		default:
+			s.Modified = append(s.Modified, name)
+			s.Diffs[name] = gocmp.Diff(string(existing), string(data))
+		}
+	}
+	removed := make(map[string]bool)
+	for name := range p.removed {
+		if _, err := os.Stat(filepath.Join(p.dir, filepath.FromSlash(name))); err == nil {
+			removed[name] = true
+		}
+	}
+	if p.match != nil {
+		err := filepath.WalkDir(p.dir, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path == p.dir {
+				return filepath.SkipDir
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(p.dir, path)
+			if err != nil {
+				return err
+			}
+			name := filepath.ToSlash(rel)
+			if _, ok := p.files[name]; !ok && p.match(name) {
+				removed[name] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name := range removed {
+		s.Removed = append(s.Removed, name)
+	}
+	slices.Sort(s.Created)
+	slices.Sort(s.Modified)
+	slices.Sort(s.Removed)
+	return s, nil
+}
+
+// Print prints the summary to w, with the diffs of the modified files if
+// diffs is set.
+func (s *Summary) Print(w io.Writer, diffs bool) {
+	fmt.Fprintf(w, "Dry run: %d created, %d modified, %d removed, %d unchanged\n", len(s.Created), len(s.Modified), len(s.Removed), s.Unchanged)
+	for _, name := range s.Created {
+		fmt.Fprintf(w, "  created:  %s\n", name)
+	}
+	for _, name := range s.Modified {
+		fmt.Fprintf(w, "  modified: %s\n", name)
+		if diffs {
+			fmt.Fprintln(w, s.Diffs[name])
+		}
+	}
+	for _, name := range s.Removed {
+		fmt.Fprintf(w, "  removed:  %s\n", name)
+	}
+}
@@ -0,0 +1,140 @@
+package dryrun
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"CVE-2024-0001.json":       "same\n",
+		"CVE-2024-0002.json":       "old\n",
+		"CVE-2024-0003.json":       "stale\n",
+		"PyPI/CVE-2024-0004.json":  "stale\n",
+		"modified-since-1d.txt":    "CVE-2024-0001\n",
+		"PyPI/CVE-2024-0005.json":  "explicitly removed\n",
+		"other/CVE-2024-0006.json": "kept\n",
+	})
+
+	tests := []struct {
+		name  string
+		match func(string) bool
+		want  *Summary
+	}{
+		{
+			name:  "matched",
+			match: func(name string) bool { return strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, "other/") },
+			want: &Summary{
+				Created:   []string{"CVE-2024-0007.json"},
+				Modified:  []string{"CVE-2024-0002.json"},
+				Removed:   []string{"CVE-2024-0003.json", "PyPI/CVE-2024-0004.json", "PyPI/CVE-2024-0005.json"},
+				Unchanged: 1,
+			},
+		},
+		{
+			name: "explicit removals only",
+			want: &Summary{
+				Created:   []string{"CVE-2024-0007.json"},
+				Modified:  []string{"CVE-2024-0002.json"},
+				Removed:   []string{"PyPI/CVE-2024-0005.json"},
+				Unchanged: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := NewPlan(dir, tt.match)
+			plan.Write("CVE-2024-0001.json", []byte("same\n"))
+			plan.Write("CVE-2024-0002.json", []byte("new\n"))
+			plan.Write("CVE-2024-0007.json", []byte("created\n"))
+			plan.Remove("PyPI/CVE-2024-0005.json")
+			// Removing a file that doesn't exist, or that's written, is a no-op.
+			plan.Remove("CVE-2024-0008.json")
+			plan.Remove("CVE-2024-0001.json")
+
+			got, err := plan.Summarize()
+			if err != nil {
+				t.Fatalf("Summarize() error: %v", err)
+			}
+			if diff := gocmp.Diff(tt.want, got, cmpopts.IgnoreFields(Summary{}, "Diffs")); diff != "" {
+				t.Errorf("Summarize() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := got.Diffs["CVE-2024-0002.json"]; !strings.Contains(diff, "-") || !strings.Contains(diff, "new") {
+				t.Errorf("Summarize() diff of CVE-2024-0002.json = %q, want old and new content", diff)
+			}
+		})
+	}
+
+	// Nothing was written.
+	content, err := os.ReadFile(filepath.Join(dir, "CVE-2024-0002.json"))
+	if err != nil || string(content) != "old\n" {
+		t.Errorf("CVE-2024-0002.json = %q, %v, want it untouched", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CVE-2024-0007.json")); !os.IsNotExist(err) {
+		t.Errorf("CVE-2024-0007.json was written")
+	}
+}
+
+func TestSummarizeMissingDir(t *testing.T) {
+	plan := NewPlan(filepath.Join(t.TempDir(), "missing"), func(string) bool { return true })
+	plan.Write("CVE-2024-0001.json", []byte("{}\n"))
+	got, err := plan.Summarize()
+	if err != nil {
+		t.Fatalf("Summarize() error: %v", err)
+	}
+	if diff := gocmp.Diff([]string{"CVE-2024-0001.json"}, got.Created); diff != "" {
+		t.Errorf("Summarize() created mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.json": "old\n"})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"-dryRun", "-dryRunDiff"}); err != nil {
+		t.Fatal(err)
+	}
+	if !flags.Enabled() {
+		t.Fatalf("Enabled() = false with -dryRun")
+	}
+	plan := NewPlan(dir, nil)
+	plan.Write("a.json", []byte("new\n"))
+	plan.Write("b.json", []byte("new\n"))
+
+	var out bytes.Buffer
+	if err := flags.Report(plan, &out); err != nil {
+		t.Fatalf("Report() error: %v", err)
+	}
+	for _, want := range []string{
+		"Dry run: 1 created, 1 modified, 0 removed, 0 unchanged",
+		"created:  b.json",
+		"modified: a.json",
+		"new",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report() output missing %q:\n%s", want, out.String())
+		}
+	}
+}
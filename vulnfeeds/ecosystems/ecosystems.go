@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ecosystems is the registry of the OSV ecosystems converters emit
+// packages in, and builds and parses the ecosystem strings of packages, e.g.
+// "Alpine:v3.18", so that converters can't emit an ecosystem OSV doesn't
+// recognize.
+package ecosystems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ossf/osv-schema/bindings/go/osvschema"
+	"golang.org/x/exp/slices"
+)
+
+// Ecosystem is an OSV ecosystem, without a release (e.g. "Alpine").
+type Ecosystem string
+
+// The ecosystems of the converters in this repository.
+const (
+	Alpine                = Ecosystem(osvschema.EcosystemAlpine)
+	AmazonLinux Ecosystem = "Amazon Linux"
+	CentOS      Ecosystem = "CentOS"
+	Debian                = Ecosystem(osvschema.EcosystemDebian)
+	Fedora      Ecosystem = "Fedora"
+	Linux                 = Ecosystem(osvschema.EcosystemLinux)
+	OpenEuler   Ecosystem = "openEuler"
+	PhotonOS              = Ecosystem(osvschema.EcosystemPhotonOS)
+)
+
+// known are the ecosystems OSV recognizes.
+var known = []Ecosystem{
+	Ecosystem(osvschema.EcosystemAlmaLinux),
+	Ecosystem(osvschema.EcosystemAlpine),
+	Ecosystem(osvschema.EcosystemAndroid),
+	Ecosystem(osvschema.EcosystemBioconductor),
+	Ecosystem(osvschema.EcosystemBitnami),
+	Ecosystem(osvschema.EcosystemChainguard),
+	Ecosystem(osvschema.EcosystemConanCenter),
+	Ecosystem(osvschema.EcosystemCRAN),
+	Ecosystem(osvschema.EcosystemCratesIO),
+	Ecosystem(osvschema.EcosystemDebian),
+	Ecosystem(osvschema.EcosystemGHC),
+	Ecosystem(osvschema.EcosystemGitHubActions),
+	Ecosystem(osvschema.EcosystemGo),
+	Ecosystem(osvschema.EcosystemHackage),
+	Ecosystem(osvschema.EcosystemHex),
+	Ecosystem(osvschema.EcosystemKubernetes),
+	Ecosystem(osvschema.EcosystemLinux),
+	Ecosystem(osvschema.EcosystemMageia),
+	Ecosystem(osvschema.EcosystemMaven),
+	Ecosystem(osvschema.EcosystemNPM),
+	Ecosystem(osvschema.EcosystemNuGet),
+	Ecosystem(osvschema.EcosystemOpenSUSE),
+	Ecosystem(osvschema.EcosystemOSSFuzz),
+	Ecosystem(osvschema.EcosystemPackagist),
+	Ecosystem(osvschema.EcosystemPhotonOS),
+	Ecosystem(osvschema.EcosystemPub),
+	Ecosystem(osvschema.EcosystemPyPI),
+	Ecosystem(osvschema.EcosystemRedHat),
+	Ecosystem(osvschema.EcosystemRockyLinux),
+	Ecosystem(osvschema.EcosystemRubyGems),
+	Ecosystem(osvschema.EcosystemSUSE),
+	Ecosystem(osvschema.EcosystemSwiftURL),
+	Ecosystem(osvschema.EcosystemUbuntu),
+	Ecosystem(osvschema.EcosystemWolfi),
+	// Accepted by the importer, but not yet in the schema bindings.
+	OpenEuler,
+}
+
+// pending are the ecosystems converted by vulnfeeds pending their addition
+// to the schema. Their records don't validate until then.
+var pending = []Ecosystem{
+	AmazonLinux,
+	CentOS,
+	Fedora,
+}
+
+// Known reports whether e is an ecosystem OSV recognizes.
+func (e Ecosystem) Known() bool {
+	return slices.Contains(known, e)
+}
+
+// Pending reports whether e is converted by vulnfeeds, but not yet an
+// ecosystem OSV recognizes.
+func (e Ecosystem) Pending() bool {
+	return slices.Contains(pending, e)
+}
+
+// Contains reports whether ecosystem is e, or a release of e, for a known or
+// pending e.
+func (e Ecosystem) Contains(ecosystem string) bool {
+	if !e.Known() && !e.Pending() {
+		return false
+	}
+	base, release, hasRelease := strings.Cut(ecosystem, ":")
+	return Ecosystem(base) == e && (!hasRelease || release != "")
+}
+
+// Name is the ecosystem of a package: an Ecosystem, and the release of it
+// the package is in, if any.
+type Name struct {
+	Ecosystem Ecosystem
+	Release   string
+}
+
+// String returns the ecosystem string of n, e.g. "Alpine:v3.18".
+func (n Name) String() string {
+	if n.Release == "" {
+		return string(n.Ecosystem)
+	}
+	return string(n.Ecosystem) + ":" + n.Release
+}
+
+// New returns the Name of release of e, or of e if release is empty, or an
+// error if e isn't recognized.
+func New(e Ecosystem, release string) (Name, error) {
+	if !e.Known() {
+		return Name{}, fmt.Errorf("unknown ecosystem %q", e)
+	}
+	if strings.TrimSpace(release) != release {
+		return Name{}, fmt.Errorf("invalid release %q of %s", release, e)
+	}
+	return Name{Ecosystem: e, Release: release}, nil
+}
+
+// Parse parses an ecosystem string, e.g. "Alpine:v3.18", of a recognized
+// ecosystem.
+func Parse(ecosystem string) (Name, error) {
+	base, release, hasRelease := strings.Cut(ecosystem, ":")
+	if hasRelease && release == "" {
+		return Name{}, fmt.Errorf("empty release in ecosystem %q", ecosystem)
+	}
+	return New(Ecosystem(base), release)
+}
+
+// Base returns the ecosystem of an ecosystem string, without its release.
+func Base(ecosystem string) Ecosystem {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return Ecosystem(base)
+}
+
+// Canonical returns ecosystem with the casing of the recognized ecosystem it
+// names case-insensitively (e.g. "PyPI" for "pypi"), keeping any release,
+// and whether it names one.
+func Canonical(ecosystem string) (string, bool) {
+	base, release, hasRelease := strings.Cut(ecosystem, ":")
+	i := slices.IndexFunc(known, func(e Ecosystem) bool { return strings.EqualFold(string(e), base) })
+	if i < 0 {
+		return ecosystem, false
+	}
+	if hasRelease {
+		return string(known[i]) + ":" + release, true
+	}
+	return string(known[i]), true
+}
@@ -0,0 +1,96 @@
+package ecosystems
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		ecosystem Ecosystem
+		release   string
+		want      string
+		wantErr   bool
+	}{
+		{Alpine, "v3.18", "Alpine:v3.18", false},
+		{Debian, "", "Debian", false},
+		{PhotonOS, "5.0", "Photon OS:5.0", false},
+		{"Alpnie", "v3.18", "", true},
+		{Alpine, " v3.18", "", true},
+	}
+	for _, tt := range tests {
+		got, err := New(tt.ecosystem, tt.release)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q, %q) error = %v, want error %v", tt.ecosystem, tt.release, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("New(%q, %q) = %q, want %q", tt.ecosystem, tt.release, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		want      Name
+		wantErr   bool
+	}{
+		{"Alpine:v3.18", Name{Alpine, "v3.18"}, false},
+		{"Debian", Name{Debian, ""}, false},
+		{"Ubuntu:Pro:18.04:LTS", Name{"Ubuntu", "Pro:18.04:LTS"}, false},
+		{"Alpine:", Name{}, true},
+		{"Fedora:39", Name{}, true},
+		{"alpine:v3.18", Name{}, true},
+		{"", Name{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.ecosystem)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.ecosystem, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.ecosystem, got, tt.want)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		ecosystem Ecosystem
+		s         string
+		want      bool
+	}{
+		{Alpine, "Alpine", true},
+		{Alpine, "Alpine:v3.18", true},
+		{Alpine, "AlpineLinux", false},
+		{Alpine, "Alpine:", false},
+		{Debian, "Alpine:v3.18", false},
+		{Fedora, "Fedora:39", true},
+		{"Alpnie", "Alpnie", false},
+	}
+	for _, tt := range tests {
+		if got := tt.ecosystem.Contains(tt.s); got != tt.want {
+			t.Errorf("%q.Contains(%q) = %v, want %v", tt.ecosystem, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		want      string
+		wantOK    bool
+	}{
+		{"PyPI", "PyPI", true},
+		{"pypi", "PyPI", true},
+		{"BITNAMI", "Bitnami", true},
+		{"debian:12", "Debian:12", true},
+		{"crates.IO", "crates.io", true},
+		{"Unknown", "Unknown", false},
+	}
+	for _, tt := range tests {
+		got, ok := Canonical(tt.ecosystem)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Canonical(%q) = %q, %v, want %q, %v", tt.ecosystem, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package enrichment sends the descriptions of CVEs the converters can't map
+// to a package to an external service (e.g. a language model) proposing
+// affected packages and versions. Proposals are never written into records:
+// they go to a curation queue for a human to review.
+package enrichment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// Request describes a CVE the converters couldn't map to a package.
+type Request struct {
+	CVE         cves.CVEID `json:"cve"`
+	Description string     `json:"description"`
+	CPEs        []string   `json:"cpes,omitempty"`
+	References  []string   `json:"references,omitempty"`
+}
+
+// Proposal is an affected package proposed for a CVE.
+type Proposal struct {
+	CVE              cves.CVEID             `json:"cve"`
+	PackageName      string                 `json:"package_name"`
+	Ecosystem        string                 `json:"ecosystem,omitempty"`
+	Repos            []string               `json:"repos,omitempty"`
+	AffectedVersions []cves.AffectedVersion `json:"affected_versions,omitempty"`
+	Confidence       float64                `json:"confidence"`
+	Rationale        string                 `json:"rationale,omitempty"`
+}
+
+// Enricher proposes affected packages for a batch of CVEs.
+type Enricher interface {
+	Propose(ctx context.Context, requests []Request) ([]Proposal, error)
+}
+
+// HTTPEnricher posts batches of requests as JSON to an external service,
+// which responds with a JSON array of proposals.
+type HTTPEnricher struct {
+	URL    string
+	Client *http.Client
+}
+
+func (h *HTTPEnricher) Propose(ctx context.Context, requests []Request) ([]Proposal, error) {
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response: %v", resp.StatusCode)
+	}
+	var proposals []Proposal
+	if err := json.NewDecoder(resp.Body).Decode(&proposals); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// CachingEnricher caches the proposals of another Enricher in a directory,
+// keyed by CVE and description, so unchanged CVEs aren't sent again.
+type CachingEnricher struct {
+	Enricher Enricher
+	Dir      string
+}
+
+func cacheKey(r Request) string {
+	hash := sha256.Sum256([]byte(r.Description))
+	return fmt.Sprintf("%s-%s.json", r.CVE, hex.EncodeToString(hash[:8]))
+}
+
+func (c *CachingEnricher) Propose(ctx context.Context, requests []Request) ([]Proposal, error) {
+	var proposals []Proposal
+	var uncached []Request
+	for _, r := range requests {
+		data, err := os.ReadFile(filepath.Join(c.Dir, cacheKey(r)))
+		if errors.Is(err, fs.ErrNotExist) {
+			uncached = append(uncached, r)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var cached []Proposal
+		if err := json.Unmarshal(data, &cached); err != nil {
+			uncached = append(uncached, r)
+			continue
+		}
+		proposals = append(proposals, cached...)
+	}
+	if len(uncached) == 0 {
+		return proposals, nil
+	}
+
+	fresh, err := c.Enricher.Propose(ctx, uncached)
+	if err != nil {
+		return proposals, err
+	}
+	byCVE := make(map[cves.CVEID][]Proposal)
+	for _, p := range fresh {
+		byCVE[p.CVE] = append(byCVE[p.CVE], p)
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+	// Cache empty responses too, so CVEs without proposals aren't retried.
+	for _, r := range uncached {
+		data, err := json.Marshal(byCVE[r.CVE])
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(c.Dir, cacheKey(r)), data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return append(proposals, fresh...), nil
+}
+
+// ProposeInBatches sends requests to e in batches of batchSize, each with a
+// strict timeout. Batches that fail are skipped, so a slow or unavailable
+// service never holds up the conversion; their errors are returned joined.
+func ProposeInBatches(ctx context.Context, e Enricher, requests []Request, batchSize int, timeout time.Duration) ([]Proposal, error) {
+	if batchSize <= 0 {
+		batchSize = len(requests)
+	}
+	var proposals []Proposal
+	var errs []error
+	for start := 0; start < len(requests); start += batchSize {
+		end := min(start+batchSize, len(requests))
+		batchCtx, cancel := context.WithTimeout(ctx, timeout)
+		batch, err := e.Propose(batchCtx, requests[start:end])
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("batch %s..%s: %w", requests[start].CVE, requests[end-1].CVE, err))
+		}
+		proposals = append(proposals, batch...)
+	}
+	return proposals, errors.Join(errs...)
+}
+
+// WriteQueue writes proposals to the curation queue in dir, one file of
+// proposals per CVE named <CVE>.proposals.json. Proposals below minConfidence
+// are dropped.
+func WriteQueue(dir string, proposals []Proposal, minConfidence float64) (int, error) {
+	byCVE := make(map[cves.CVEID][]Proposal)
+	for _, p := range proposals {
+		if p.Confidence < minConfidence {
+			continue
+		}
+		byCVE[p.CVE] = append(byCVE[p.CVE], p)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	for CVE, cveProposals := range byCVE {
+		data, err := json.MarshalIndent(cveProposals, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, string(CVE)+".proposals.json"), data, 0644); err != nil {
+			return 0, err
+		}
+	}
+	return len(byCVE), nil
+}
@@ -0,0 +1,154 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// fakeEnricher proposes a package named after each CVE, recording the batches it was sent.
+type fakeEnricher struct {
+	batches [][]cves.CVEID
+	delay   time.Duration
+}
+
+func (f *fakeEnricher) Propose(ctx context.Context, requests []Request) ([]Proposal, error) {
+	var batch []cves.CVEID
+	var proposals []Proposal
+	for _, r := range requests {
+		batch = append(batch, r.CVE)
+		proposals = append(proposals, Proposal{CVE: r.CVE, PackageName: "pkg-" + string(r.CVE), Confidence: 0.7})
+	}
+	f.batches = append(f.batches, batch)
+	select {
+	case <-time.After(f.delay):
+		return proposals, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func requests(ids ...cves.CVEID) []Request {
+	var r []Request
+	for _, id := range ids {
+		r = append(r, Request{CVE: id, Description: "description of " + string(id)})
+	}
+	return r
+}
+
+func TestHTTPEnricher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got []Request
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		proposals := []Proposal{}
+		for _, req := range got {
+			proposals = append(proposals, Proposal{CVE: req.CVE, PackageName: "libfoo", Ecosystem: "PyPI", Confidence: 0.9})
+		}
+		_ = json.NewEncoder(w).Encode(proposals)
+	}))
+	defer server.Close()
+
+	e := &HTTPEnricher{URL: server.URL}
+	got, err := e.Propose(context.Background(), requests("CVE-2024-0001"))
+	if err != nil {
+		t.Fatalf("Propose() error: %v", err)
+	}
+	want := []Proposal{{CVE: "CVE-2024-0001", PackageName: "libfoo", Ecosystem: "PyPI", Confidence: 0.9}}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Propose() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachingEnricher(t *testing.T) {
+	fake := &fakeEnricher{}
+	c := &CachingEnricher{Enricher: fake, Dir: t.TempDir()}
+	ctx := context.Background()
+
+	if _, err := c.Propose(ctx, requests("CVE-2024-0001", "CVE-2024-0002")); err != nil {
+		t.Fatalf("Propose() error: %v", err)
+	}
+	got, err := c.Propose(ctx, requests("CVE-2024-0001", "CVE-2024-0003"))
+	if err != nil {
+		t.Fatalf("Propose() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Propose() = %+v, want 2 proposals", got)
+	}
+	wantBatches := [][]cves.CVEID{{"CVE-2024-0001", "CVE-2024-0002"}, {"CVE-2024-0003"}}
+	if diff := gocmp.Diff(wantBatches, fake.batches); diff != "" {
+		t.Errorf("Propose() sent batches mismatch (-want +got):\n%s", diff)
+	}
+
+	// A changed description isn't served from the cache.
+	changed := requests("CVE-2024-0001")
+	changed[0].Description = "updated"
+	if _, err := c.Propose(ctx, changed); err != nil {
+		t.Fatalf("Propose() error: %v", err)
+	}
+	if len(fake.batches) != 3 {
+		t.Errorf("Propose() of a changed description was served from the cache")
+	}
+}
+
+func TestProposeInBatches(t *testing.T) {
+	fake := &fakeEnricher{}
+	got, err := ProposeInBatches(context.Background(), fake, requests("CVE-2024-0001", "CVE-2024-0002", "CVE-2024-0003"), 2, time.Second)
+	if err != nil {
+		t.Fatalf("ProposeInBatches() error: %v", err)
+	}
+	if len(got) != 3 || len(fake.batches) != 2 {
+		t.Errorf("ProposeInBatches() = %+v in batches %v", got, fake.batches)
+	}
+
+	slow := &fakeEnricher{delay: time.Minute}
+	got, err = ProposeInBatches(context.Background(), slow, requests("CVE-2024-0001"), 2, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ProposeInBatches() error = %v, want a timeout", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ProposeInBatches() of a timed out batch = %+v", got)
+	}
+}
+
+func TestWriteQueue(t *testing.T) {
+	dir := t.TempDir()
+	proposals := []Proposal{
+		{CVE: "CVE-2024-0001", PackageName: "libfoo", Confidence: 0.9},
+		{CVE: "CVE-2024-0001", PackageName: "libbar", Confidence: 0.6},
+		{CVE: "CVE-2024-0002", PackageName: "libbaz", Confidence: 0.1},
+	}
+	queued, err := WriteQueue(dir, proposals, 0.5)
+	if err != nil {
+		t.Fatalf("WriteQueue() error: %v", err)
+	}
+	if queued != 1 {
+		t.Errorf("WriteQueue() = %d, want 1", queued)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "CVE-2024-0001.proposals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Proposal
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := gocmp.Diff(proposals[:2], got); diff != "" {
+		t.Errorf("WriteQueue() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CVE-2024-0002.proposals.json")); err == nil {
+		t.Errorf("WriteQueue() queued a proposal below the minimum confidence")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export lays out records the way the OSV exporter publishes them:
+// under each ecosystem they're exported under, bundled into an all.zip.
+package export
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/utility"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+const (
+	// GitEcosystem is the ecosystem of records with no package, only GIT
+	// ranges.
+	GitEcosystem = "GIT"
+	// EmptyEcosystem is the ecosystem of records with neither a package nor
+	// a GIT range.
+	EmptyEcosystem = "[EMPTY]"
+	// ZipFileName is the bundle of all of an ecosystem's records.
+	ZipFileName = "all.zip"
+	// ProtobufExt is the extension of the protobuf encoding of records.
+	ProtobufExt = ".pb"
+)
+
+// Ecosystems returns the ecosystems a record is exported under, as osv.Bug
+// does: the ecosystems of its affected packages (and, for those with a
+// release, their base ecosystem too), else GIT if it has a GIT range, else
+// [EMPTY]. They're sorted.
+func Ecosystems(v *vulns.Vulnerability) []string {
+	var ecosystems []string
+	git := false
+	for _, affected := range v.Affected {
+		if affected.Package != nil && affected.Package.Ecosystem != "" {
+			base, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+			ecosystems = append(ecosystems, affected.Package.Ecosystem, base)
+			continue
+		}
+		git = git || slices.ContainsFunc(affected.Ranges, func(r vulns.AffectedRange) bool { return r.Type == "GIT" })
+	}
+	switch {
+	case len(ecosystems) > 0:
+		slices.Sort(ecosystems)
+		return slices.Compact(ecosystems)
+	case git:
+		return []string{GitEcosystem}
+	}
+	return []string{EmptyEcosystem}
+}
+
+// ZipEntry is a record file to bundle into a zip.
+type ZipEntry struct {
+	// Name is the entry's name in the zip, e.g. "CVE-2024-1234.json".
+	Name string
+	// Path is the record's file.
+	Path string
+}
+
+// WriteZip bundles the files of entries into a zip at zipPath, in order.
+// Entries are stamped with a fixed time so unchanged records produce an
+// identical zip.
+func WriteZip(zipPath string, entries []ZipEntry) error {
+	return utility.WriteFileAtomic(zipPath, 0644, func(w io.Writer) error {
+		zipWriter := zip.NewWriter(w)
+		for _, e := range entries {
+			header := &zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: time.Unix(0, 0).UTC()}
+			entry, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			file, err := os.Open(e.Path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(entry, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return zipWriter.Close()
+	})
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestEcosystems(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected []vulns.Affected
+		want     []string
+	}{
+		{
+			name: "packages",
+			affected: []vulns.Affected{
+				{Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Alpine:v3.18"}},
+				{Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Debian:12"}},
+				{Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Alpine:v3.19"}},
+			},
+			want: []string{"Alpine", "Alpine:v3.18", "Alpine:v3.19", "Debian", "Debian:12"},
+		},
+		{
+			name: "packages and git",
+			affected: []vulns.Affected{
+				{Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Debian"}},
+				{Ranges: []vulns.AffectedRange{{Type: "GIT", Repo: "https://github.com/curl/curl"}}},
+			},
+			want: []string{"Debian"},
+		},
+		{
+			name: "git",
+			affected: []vulns.Affected{
+				{Ranges: []vulns.AffectedRange{{Type: "GIT", Repo: "https://github.com/curl/curl"}}},
+			},
+			want: []string{GitEcosystem},
+		},
+		{
+			name: "empty",
+			want: []string{EmptyEcosystem},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Ecosystems(&vulns.Vulnerability{ID: "CVE-2024-1234", Affected: tc.affected})
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Ecosystems() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	dir := t.TempDir()
+	var entries []ZipEntry
+	for _, id := range []string{"GHSA-2", "CVE-2024-1"} {
+		path := filepath.Join(dir, id+".json")
+		if err := os.WriteFile(path, []byte(id), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, ZipEntry{Name: id + ".json", Path: path})
+	}
+	zipPath := filepath.Join(dir, ZipFileName)
+	if err := WriteZip(zipPath, entries); err != nil {
+		t.Fatalf("WriteZip() error: %v", err)
+	}
+	first, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var got []string
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		got = append(got, f.Name+"="+string(data))
+	}
+	if diff := gocmp.Diff([]string{"GHSA-2.json=GHSA-2", "CVE-2024-1.json=CVE-2024-1"}, got); diff != "" {
+		t.Errorf("WriteZip() entries mismatch (-want +got):\n%s", diff)
+	}
+
+	// Unchanged records produce an identical zip.
+	if err := WriteZip(zipPath, entries); err != nil {
+		t.Fatalf("WriteZip() rewrite error: %v", err)
+	}
+	second, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("WriteZip() of the same records produced different zips")
+	}
+}
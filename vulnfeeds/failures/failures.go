@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package failures collects the records a converter run failed to handle, so
+// one bad record doesn't abort the whole run: failures are reported together
+// at the end, and the run only fails if too many records did.
+package failures
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultMaxRate is the default maximum fraction of records that may fail
+// before a run is considered failed.
+const DefaultMaxRate = 0.01
+
+// Failure is a record that failed.
+type Failure struct {
+	// Record identifies the record, e.g. by its ID or file name.
+	Record string
+	Err    error
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("%s: %v", f.Record, f.Err)
+}
+
+// Collector collects the outcomes of the records of a run. It's safe for
+// concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	succeeded int
+	failures  []Failure
+}
+
+// Succeeded counts a record that succeeded.
+func (c *Collector) Succeeded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.succeeded++
+}
+
+// Failed records a record that failed.
+func (c *Collector) Failed(record string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = append(c.failures, Failure{Record: record, Err: err})
+}
+
+// Total returns the number of records counted.
+func (c *Collector) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.succeeded + len(c.failures)
+}
+
+// Failures returns the records that failed, in order.
+func (c *Collector) Failures() []Failure {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failures := make([]Failure, len(c.failures))
+	copy(failures, c.failures)
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].Record < failures[j].Record })
+	return failures
+}
+
+// Rate returns the fraction of the records counted that failed.
+func (c *Collector) Rate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := c.succeeded + len(c.failures)
+	if total == 0 {
+		return 0
+	}
+	return float64(len(c.failures)) / float64(total)
+}
+
+// Check returns an error if more than maxRate of the records counted failed.
+func (c *Collector) Check(maxRate float64) error {
+	if rate := c.Rate(); rate > maxRate {
+		return fmt.Errorf("%d of %d records failed (%.2f%%), more than the maximum of %.2f%%", len(c.Failures()), c.Total(), rate*100, maxRate*100)
+	}
+	return nil
+}
+
+// Logger is where Report logs, e.g. a utility.LoggerWrapper.
+type Logger interface {
+	Infof(format string, a ...any)
+	Warnf(format string, a ...any)
+}
+
+// Report logs each failure and a summary of the run, under the name of what
+// was handled (e.g. "CVE files").
+func (c *Collector) Report(logger Logger, name string) {
+	failures := c.Failures()
+	for _, f := range failures {
+		logger.Warnf("Failed to handle %s", f)
+	}
+	logger.Infof("Handled %d %s, of which %d failed", c.Total(), name, len(failures))
+}
+
+// Flags are the command line flags configuring how many failures a run
+// tolerates, shared by the converters.
+type Flags struct {
+	maxRate *float64
+}
+
+// RegisterFlags registers the failure flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		maxRate: fs.Float64("max_failure_rate", DefaultMaxRate, "Maximum fraction (0 to 1) of records that may fail without failing the run"),
+	}
+}
+
+// MaxRate returns the maximum failure rate configured.
+func (f *Flags) MaxRate() float64 {
+	return *f.maxRate
+}
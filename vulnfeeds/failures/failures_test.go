@@ -0,0 +1,61 @@
+package failures
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+type logger struct {
+	lines []string
+}
+
+func (l *logger) Infof(format string, a ...any) {
+	l.lines = append(l.lines, "I "+fmt.Sprintf(format, a...))
+}
+
+func (l *logger) Warnf(format string, a ...any) {
+	l.lines = append(l.lines, "W "+fmt.Sprintf(format, a...))
+}
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	for range 98 {
+		c.Succeeded()
+	}
+	c.Failed("CVE-2023-0002", errors.New("bad JSON"))
+	c.Failed("CVE-2023-0001", errors.New("not found"))
+
+	if got, want := c.Total(), 100; got != want {
+		t.Errorf("Total() = %d, want %d", got, want)
+	}
+	if got, want := c.Rate(), 0.02; got != want {
+		t.Errorf("Rate() = %v, want %v", got, want)
+	}
+	if err := c.Check(0.05); err != nil {
+		t.Errorf("Check(0.05) = %v, want nil", err)
+	}
+	if err := c.Check(DefaultMaxRate); err == nil {
+		t.Errorf("Check(%v) = nil, want an error", DefaultMaxRate)
+	}
+
+	var l logger
+	c.Report(&l, "CVE files")
+	want := []string{
+		"W Failed to handle CVE-2023-0001: not found",
+		"W Failed to handle CVE-2023-0002: bad JSON",
+		"I Handled 100 CVE files, of which 2 failed",
+	}
+	if diff := gocmp.Diff(want, l.lines); diff != "" {
+		t.Errorf("Report() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollectorEmpty(t *testing.T) {
+	var c Collector
+	if err := c.Check(0); err != nil {
+		t.Errorf("Check(0) = %v, want nil for no records", err)
+	}
+}
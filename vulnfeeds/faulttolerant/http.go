@@ -3,30 +3,53 @@ package faulttolerant
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/sethvargo/go-retry"
+
+	"github.com/google/osv/vulnfeeds/httpclient"
 )
 
 // Make a HTTP GET request for url and retry 3 times, with an exponential backoff.
 func Get(url string) (resp *http.Response, err error) {
+	return GetWithHeader(url, nil)
+}
+
+// Make a HTTP GET request for url with the given headers and retry 3 times,
+// with an exponential backoff.
+func GetWithHeader(url string, header http.Header) (resp *http.Response, err error) {
+	attempt := 0
 	backoff := retry.NewExponential(1 * time.Second)
 	if err := retry.Do(context.Background(), retry.WithMaxRetries(3, backoff), func(ctx context.Context) error {
+		if attempt > 0 {
+			httpclient.Retried(url)
+		}
+		attempt++
 		req, err := http.NewRequest("GET", url, nil)
 		if err != nil {
 			return err
 		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
 
 		r, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return err
 		}
 
+		if r.StatusCode == http.StatusTooManyRequests {
+			discard(r)
+			return retry.RetryableError(fmt.Errorf("throttled: %v", r.StatusCode))
+		}
 		switch r.StatusCode / 100 {
 		case 4:
+			discard(r)
 			return fmt.Errorf("bad response: %v", r.StatusCode)
 		case 5:
+			discard(r)
 			return retry.RetryableError(fmt.Errorf("bad response: %v", r.StatusCode))
 		default:
 			resp = r
@@ -40,8 +63,13 @@ func Get(url string) (resp *http.Response, err error) {
 
 // Make a HTTP HEAD request for url and retry 3 times, with an exponential backoff.
 func Head(url string) (resp *http.Response, err error) {
+	attempt := 0
 	backoff := retry.NewExponential(1 * time.Second)
 	if err := retry.Do(context.Background(), retry.WithMaxRetries(3, backoff), func(ctx context.Context) error {
+		if attempt > 0 {
+			httpclient.Retried(url)
+		}
+		attempt++
 		req, err := http.NewRequest("HEAD", url, nil)
 		if err != nil {
 			return err
@@ -67,3 +95,10 @@ func Head(url string) (resp *http.Response, err error) {
 	}
 	return resp, err
 }
+
+// discard drains and closes the body of a response that isn't returned, so
+// its connection can be reused.
+func discard(r *http.Response) {
+	io.Copy(io.Discard, r.Body)
+	r.Body.Close()
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixcommits detects the commits fixing a vulnerability from the
+// references of its CVE: references to commits in supported Git hosts (GitHub,
+// GitLab, cgit and GitWeb) are normalized to (repo, commit) pairs, verified to
+// exist, and turned into GIT ranges with fixed events.
+package fixcommits
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/repourl"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// GitLabAPIDefault is the base URL of the gitlab.com REST API.
+const GitLabAPIDefault = "https://gitlab.com/api/v4"
+
+// ErrCommitNotFound is returned by Verify for commits that don't exist in
+// their repo.
+var ErrCommitNotFound = errors.New("commit not found")
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Candidate is a possible fix commit referenced by a CVE.
+type Candidate struct {
+	Repo   string
+	Commit string
+	// Reference is the URL the commit was found in.
+	Reference string
+}
+
+// FromReference returns the commit a reference URL points at, if it's a
+// commit URL of a supported Git host.
+func FromReference(link string) (Candidate, error) {
+	repo, err := cves.Repo(link)
+	if err != nil {
+		return Candidate{}, err
+	}
+	commit, err := cves.Commit(link)
+	if err != nil {
+		return Candidate{}, err
+	}
+	commit = strings.ToLower(commit)
+	commit = strings.TrimSuffix(strings.TrimSuffix(commit, ".patch"), ".diff")
+	if !commitRegexp.MatchString(commit) {
+		return Candidate{}, fmt.Errorf("%q isn't a commit hash in %s", commit, link)
+	}
+	return Candidate{Repo: repourl.Canonical(repo), Commit: commit, Reference: link}, nil
+}
+
+// Verifier verifies that a candidate's commit exists, and returns its full
+// hash.
+type Verifier interface {
+	Verify(ctx context.Context, c Candidate) (string, error)
+}
+
+// HTTPVerifier verifies GitHub and GitLab commits with their APIs, which also
+// expand abbreviated hashes. Commits of other hosts are verified by
+// requesting their reference.
+type HTTPVerifier struct {
+	GitHub    *github.Feature
+	Client    *http.Client
+	GitLabAPI string
+}
+
+// NewHTTPVerifier returns an HTTPVerifier making its GitHub requests with gh.
+func NewHTTPVerifier(gh *github.Client) *HTTPVerifier {
+	return &HTTPVerifier{
+		GitHub:    gh.Feature("fixcommits", 0),
+		Client:    http.DefaultClient,
+		GitLabAPI: GitLabAPIDefault,
+	}
+}
+
+// Verify implements Verifier.
+func (v *HTTPVerifier) Verify(ctx context.Context, c Candidate) (string, error) {
+	u, err := url.Parse(c.Repo)
+	if err != nil {
+		return "", err
+	}
+	switch u.Hostname() {
+	case "github.com":
+		hash, err := v.GitHub.ResolveRef(ctx, c.Repo, c.Commit)
+		if errors.Is(err, github.ErrNotFound) {
+			return "", fmt.Errorf("%w: %v", ErrCommitNotFound, err)
+		}
+		if err != nil {
+			return "", err
+		}
+		return verified(c, hash)
+	case "gitlab.com":
+		hash, err := v.gitLabCommit(ctx, strings.Trim(u.Path, "/"), c.Commit)
+		if err != nil {
+			return "", err
+		}
+		return verified(c, hash)
+	default:
+		if _, err := cves.ValidateAndCanonicalizeLink(c.Reference); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrCommitNotFound, err)
+		}
+		return c.Commit, nil
+	}
+}
+
+// verified checks the full hash an API returned for a candidate.
+func verified(c Candidate, hash string) (string, error) {
+	hash = strings.ToLower(hash)
+	if len(hash) != 40 || !strings.HasPrefix(hash, c.Commit) {
+		return "", fmt.Errorf("%w: got %q for %s in %s", ErrCommitNotFound, hash, c.Commit, c.Repo)
+	}
+	return hash, nil
+}
+
+// gitLabCommit returns the full hash of a commit of a GitLab project.
+func (v *HTTPVerifier) gitLabCommit(ctx context.Context, project string, commit string) (string, error) {
+	u := fmt.Sprintf("%s/projects/%s/repository/commits/%s", v.GitLabAPI, url.PathEscape(project), commit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrCommitNotFound, u)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("bad response for %s: %s", u, resp.Status)
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// Detect returns the verified fix commits referenced by a CVE, once per
+// repo and commit, in the order referenced. Candidates that fail
+// verification are left out, and described in the returned notes.
+func Detect(ctx context.Context, v Verifier, references []cves.Reference) (commits []cves.AffectedCommit, notes []string) {
+	seen := map[Candidate]bool{}
+	for _, ref := range references {
+		c, err := FromReference(ref.Url)
+		if err != nil {
+			continue
+		}
+		key := Candidate{Repo: c.Repo, Commit: c.Commit}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		hash, err := v.Verify(ctx, c)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Unverified fix commit %s in %s: %v", c.Commit, c.Repo, err))
+			continue
+		}
+		ac := cves.AffectedCommit{Repo: c.Repo}
+		ac.SetFixed(hash)
+		// Abbreviated and full references to the same commit are only
+		// recognizable once verified.
+		if slices.Contains(commits, ac) {
+			continue
+		}
+		commits = append(commits, ac)
+	}
+	return commits, notes
+}
+
+// Ranges returns a GIT range per repo of the fix commits, introduced at 0.
+func Ranges(commits []cves.AffectedCommit) []vulns.AffectedRange {
+	var ranges []vulns.AffectedRange
+	for _, ac := range commits {
+		if ac.Fixed == "" {
+			continue
+		}
+		i := slices.IndexFunc(ranges, func(r vulns.AffectedRange) bool { return r.Repo == ac.Repo })
+		if i < 0 {
+			ranges = append(ranges, vulns.AffectedRange{
+				Type:   "GIT",
+				Repo:   ac.Repo,
+				Events: []vulns.Event{{Introduced: "0"}},
+			})
+			i = len(ranges) - 1
+		}
+		ranges[i].Events = append(ranges[i].Events, vulns.Event{Fixed: ac.Fixed})
+	}
+	return ranges
+}
@@ -0,0 +1,169 @@
+package fixcommits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/github"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestFromReference(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    Candidate
+		wantErr bool
+	}{
+		{
+			link: "https://github.com/MariaDB/server/commit/b1351c15946349f9daa7e5297fb2ac6f3139e4a8",
+			want: Candidate{Repo: "https://github.com/mariadb/server", Commit: "b1351c15946349f9daa7e5297fb2ac6f3139e4a8"},
+		},
+		{
+			link: "https://github.com/curl/curl/commit/4367a20cc4.patch",
+			want: Candidate{Repo: "https://github.com/curl/curl", Commit: "4367a20cc4"},
+		},
+		{
+			link: "https://gitlab.com/qemu-project/qemu/-/commit/4367a20cc4",
+			want: Candidate{Repo: "https://gitlab.com/qemu-project/qemu", Commit: "4367a20cc4"},
+		},
+		{
+			link: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/commit/?id=ee1fee900537b5d9560e9f937402de5ddc8412f3",
+			want: Candidate{Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git", Commit: "ee1fee900537b5d9560e9f937402de5ddc8412f3"},
+		},
+		{
+			link: "https://git.gnupg.org/cgi-bin/gitweb.cgi?p=libksba.git;a=commit;h=f61a5ea4e0f6a80fd4b28ef0174bee77793cf070",
+			want: Candidate{Repo: "git://git.gnupg.org/libksba.git", Commit: "f61a5ea4e0f6a80fd4b28ef0174bee77793cf070"},
+		},
+		{
+			link:    "https://github.com/curl/curl/issues/1234",
+			wantErr: true,
+		},
+		{
+			link:    "https://nvd.nist.gov/vuln/detail/CVE-2023-0001",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		got, err := FromReference(tc.link)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("FromReference(%q) error = %v, want error: %v", tc.link, err, tc.wantErr)
+			continue
+		}
+		if tc.wantErr {
+			continue
+		}
+		tc.want.Reference = tc.link
+		if diff := gocmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("FromReference(%q) mismatch (-want +got):\n%s", tc.link, diff)
+		}
+	}
+}
+
+func TestHTTPVerifier(t *testing.T) {
+	const full = "4367a20cc4bd5e6a1b4a0ac8f3b6a4ab6a7dca11"
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /github/repos/curl/curl/commits/{commit}", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("GitHub request Authorization = %q, want the token", got)
+		}
+		if r.PathValue("commit") != "4367a20cc4" {
+			http.Error(w, `{"message": "No commit found for SHA"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		fmt.Fprintf(w, `{"sha": %q}`, full)
+	})
+	mux.HandleFunc("GET /gitlab/projects/{project}/repository/commits/{commit}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("project") != "qemu-project/qemu" || r.PathValue("commit") != "4367a20cc4" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %q}`, full)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	v := NewHTTPVerifier(github.NewClient("token").WithBaseURL(srv.URL + "/github"))
+	v.GitLabAPI = srv.URL + "/gitlab"
+
+	tests := []struct {
+		c       Candidate
+		want    string
+		wantErr error
+	}{
+		{c: Candidate{Repo: "https://github.com/curl/curl", Commit: "4367a20cc4"}, want: full},
+		{c: Candidate{Repo: "https://github.com/curl/curl", Commit: "deadbeef"}, wantErr: ErrCommitNotFound},
+		{c: Candidate{Repo: "https://gitlab.com/qemu-project/qemu", Commit: "4367a20cc4"}, want: full},
+		{c: Candidate{Repo: "https://gitlab.com/qemu-project/qemu", Commit: "deadbeef"}, wantErr: ErrCommitNotFound},
+		// A commit that isn't the one requested.
+		{c: Candidate{Repo: "https://gitlab.com/qemu-project/qemu", Commit: "0123456"}, wantErr: ErrCommitNotFound},
+	}
+	for _, tc := range tests {
+		got, err := v.Verify(context.Background(), tc.c)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("Verify(%+v) error = %v, want %v", tc.c, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("Verify(%+v) = %q, want %q", tc.c, got, tc.want)
+		}
+	}
+}
+
+type fakeVerifier map[string]string
+
+func (f fakeVerifier) Verify(_ context.Context, c Candidate) (string, error) {
+	hash, ok := f[c.Repo+"@"+c.Commit]
+	if !ok {
+		return "", ErrCommitNotFound
+	}
+	return hash, nil
+}
+
+func TestDetect(t *testing.T) {
+	const full = "4367a20cc4bd5e6a1b4a0ac8f3b6a4ab6a7dca11"
+	v := fakeVerifier{
+		"https://github.com/curl/curl@4367a20cc4":                                     full,
+		"https://github.com/curl/curl@" + full:                                        full,
+		"https://gitlab.com/libtiff/libtiff@b05bb61f454eeb8a85164c8a31510aeb9d79129c": "b05bb61f454eeb8a85164c8a31510aeb9d79129c",
+	}
+	refs := []cves.Reference{
+		{Url: "https://github.com/curl/curl/commit/4367a20cc4"},
+		{Url: "https://curl.se/docs/CVE-2023-0001.html"},
+		{Url: "https://github.com/Curl/curl/commit/" + full},
+		{Url: "https://gitlab.com/libtiff/libtiff/-/commit/b05bb61f454eeb8a85164c8a31510aeb9d79129c"},
+		{Url: "https://github.com/curl/curl/commit/deadbeef"},
+	}
+	got, notes := Detect(context.Background(), v, refs)
+	want := []cves.AffectedCommit{
+		{Repo: "https://github.com/curl/curl", Fixed: full},
+		{Repo: "https://gitlab.com/libtiff/libtiff", Fixed: "b05bb61f454eeb8a85164c8a31510aeb9d79129c"},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Detect() mismatch (-want +got):\n%s", diff)
+	}
+	if len(notes) != 1 {
+		t.Errorf("Detect() notes = %q, want one for the unverified commit", notes)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	got := Ranges([]cves.AffectedCommit{
+		{Repo: "https://github.com/curl/curl", Fixed: "aaaa"},
+		{Repo: "https://gitlab.com/libtiff/libtiff", Fixed: "bbbb"},
+		{Repo: "https://github.com/curl/curl", Fixed: "cccc"},
+		{Repo: "https://github.com/curl/curl", Introduced: "dddd"},
+	})
+	want := []vulns.AffectedRange{
+		{Type: "GIT", Repo: "https://github.com/curl/curl", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "aaaa"}, {Fixed: "cccc"}}},
+		{Type: "GIT", Repo: "https://gitlab.com/libtiff/libtiff", Events: []vulns.Event{{Introduced: "0"}, {Fixed: "bbbb"}}},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Ranges() mismatch (-want +got):\n%s", diff)
+	}
+}
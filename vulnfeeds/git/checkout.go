@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// remoteHead is the remote-tracking reference of the default branch of a
+// checkout's origin.
+const remoteHead = plumbing.ReferenceName("refs/remotes/origin/HEAD")
+
+// SyncCheckout makes a shallow clone of a repo's default branch in dir if it
+// doesn't exist yet, or updates it to the branch's latest commit otherwise,
+// discarding any local changes. It's for repos of records, where only the
+// latest records are needed, not the history.
+func SyncCheckout(ctx context.Context, repoURL string, dir string) error {
+	repo, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		_, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{URL: repoURL, Depth: 1, SingleBranch: true})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec("+HEAD:" + remoteHead)},
+		Depth:    1,
+		Force:    true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	remote, err := repo.Reference(remoteHead, true)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Reset(&git.ResetOptions{Commit: remote.Hash(), Mode: git.HardReset})
+}
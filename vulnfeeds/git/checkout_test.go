@@ -0,0 +1,57 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile commits a file to the worktree of repo.
+func commitFile(t *testing.T, repo *git.Repository, dir string, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := worktree.Commit("update "+name, &git.CommitOptions{Author: signature}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncCheckout(t *testing.T) {
+	upstreamDir := t.TempDir()
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, upstreamDir, "record.json", "1")
+
+	dir := filepath.Join(t.TempDir(), "checkout")
+	for _, want := range []string{"1", "2"} {
+		if want == "2" {
+			commitFile(t, upstream, upstreamDir, "record.json", "2")
+		}
+		if err := SyncCheckout(context.Background(), "file://"+upstreamDir, dir); err != nil {
+			t.Fatalf("SyncCheckout() of %s failed: %v", want, err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "record.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("SyncCheckout() checked out %q, want %q", got, want)
+		}
+	}
+}
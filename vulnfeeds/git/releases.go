@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrNoRelease is returned when a commit isn't in any release.
+var ErrNoRelease = errors.New("commit isn't in a release")
+
+// CommitReleases maps the commits of a repository to the earliest release
+// tag containing them, so GIT ranges can be annotated with the equivalent
+// versions.
+type CommitReleases struct {
+	Repo string `json:"repo"`
+	// Tags are the release tags, in the order of their commit times.
+	Tags []string `json:"tags"`
+	// Commits maps each commit to the index in Tags of its earliest release.
+	Commits map[string]int `json:"commits"`
+}
+
+// releaseTag is a release tag and the commit it points to.
+type releaseTag struct {
+	name   string
+	commit *object.Commit
+}
+
+// releaseTags returns the tags of repo that normalize to a version (as
+// NormalizeRepoTags does), ordered by their commit times.
+func releaseTags(repo *gogit.Repository, repoURL string) ([]releaseTag, error) {
+	repoName, err := RepoName(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []releaseTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if _, err := normalizeRepoTag(name, repoName); err != nil {
+			return nil
+		}
+		// Annotated tags are peeled to their commit.
+		hash, err := repo.ResolveRevision(plumbing.Revision(ref.Name().String()))
+		if err != nil {
+			return nil
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return nil
+		}
+		tags = append(tags, releaseTag{name: name, commit: commit})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		ti, tj := tags[i].commit.Committer.When, tags[j].commit.Committer.When
+		if ti.Equal(tj) {
+			return tags[i].name < tags[j].name
+		}
+		return ti.Before(tj)
+	})
+	return tags, nil
+}
+
+// BuildCommitReleases computes the earliest release of every commit of a
+// cloned repository reachable from a release tag. Each commit is visited
+// once: a commit already assigned to an earlier release has all its
+// ancestors assigned too.
+func BuildCommitReleases(repo *gogit.Repository, repoURL string) (*CommitReleases, error) {
+	tags, err := releaseTags(repo, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	cr := &CommitReleases{Repo: repoURL, Commits: make(map[string]int)}
+	for _, tag := range tags {
+		index := len(cr.Tags)
+		cr.Tags = append(cr.Tags, tag.name)
+		pending := []*object.Commit{tag.commit}
+		for len(pending) > 0 {
+			c := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if _, ok := cr.Commits[c.Hash.String()]; ok {
+				continue
+			}
+			cr.Commits[c.Hash.String()] = index
+			err := c.Parents().ForEach(func(parent *object.Commit) error {
+				if _, ok := cr.Commits[parent.Hash.String()]; !ok {
+					pending = append(pending, parent)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to walk the history of %s: %w", tag.name, err)
+			}
+		}
+	}
+	return cr, nil
+}
+
+// Release returns the earliest release tag containing commit.
+func (cr *CommitReleases) Release(commit string) (string, error) {
+	index, ok := cr.Commits[commit]
+	if !ok || index >= len(cr.Tags) {
+		return "", ErrNoRelease
+	}
+	return cr.Tags[index], nil
+}
+
+// commitReleasesKey returns the key the commit releases of repoURL are
+// stored under.
+func commitReleasesKey(repoURL string) string {
+	hash := sha256.Sum256([]byte(repoURL))
+	return fmt.Sprintf("commit-releases/%s.json.gz", hex.EncodeToString(hash[:]))
+}
+
+// StoreCommitReleases stores the gzipped commit releases of a repository.
+func StoreCommitReleases(ctx context.Context, store TagStore, cr *CommitReleases) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(cr); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return store.Store(ctx, commitReleasesKey(cr.Repo), buf.Bytes())
+}
+
+// LoadCommitReleases loads the commit releases of a repository, or returns
+// ErrCacheMiss.
+func LoadCommitReleases(ctx context.Context, store TagStore, repoURL string) (*CommitReleases, error) {
+	data, err := store.Load(ctx, commitReleasesKey(repoURL))
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	buf, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	var cr CommitReleases
+	if err := json.Unmarshal(buf, &cr); err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
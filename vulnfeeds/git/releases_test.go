@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestCommitReleases(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(msg string) plumbing.Hash {
+		t.Helper()
+		when = when.Add(time.Hour)
+		if err := os.WriteFile(filepath.Join(dir, "file"), []byte(msg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("file"); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+		hash, err := wt.Commit(msg, &gogit.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	first := commit("first")
+	if _, err := repo.CreateTag("v1.0.0", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	second := commit("second")
+	// Not a release.
+	if _, err := repo.CreateTag("nightly", second, nil); err != nil {
+		t.Fatal(err)
+	}
+	third := commit("third")
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	if _, err := repo.CreateTag("libfoo-1.1.0", third, &gogit.CreateTagOptions{Tagger: sig, Message: "1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	unreleased := commit("unreleased")
+
+	cr, err := BuildCommitReleases(repo, "https://github.com/example/libfoo")
+	if err != nil {
+		t.Fatalf("BuildCommitReleases() error = %v", err)
+	}
+	want := &CommitReleases{
+		Repo: "https://github.com/example/libfoo",
+		Tags: []string{"v1.0.0", "libfoo-1.1.0"},
+		Commits: map[string]int{
+			first.String():  0,
+			second.String(): 1,
+			third.String():  1,
+		},
+	}
+	if diff := gocmp.Diff(want, cr); diff != "" {
+		t.Errorf("BuildCommitReleases() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := cr.Release(unreleased.String()); !errors.Is(err, ErrNoRelease) {
+		t.Errorf("Release() of an unreleased commit = %v, want ErrNoRelease", err)
+	}
+
+	ctx := context.Background()
+	store := DirTagStore(t.TempDir())
+	if err := StoreCommitReleases(ctx, store, cr); err != nil {
+		t.Fatalf("StoreCommitReleases() error = %v", err)
+	}
+	loaded, err := LoadCommitReleases(ctx, store, cr.Repo)
+	if err != nil {
+		t.Fatalf("LoadCommitReleases() error = %v", err)
+	}
+	if tag, err := loaded.Release(second.String()); err != nil || tag != "libfoo-1.1.0" {
+		t.Errorf("Release() = %q, %v, want libfoo-1.1.0", tag, err)
+	}
+	if _, err := LoadCommitReleases(ctx, store, "https://github.com/example/other"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("LoadCommitReleases() of another repo = %v, want ErrCacheMiss", err)
+	}
+}
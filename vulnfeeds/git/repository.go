@@ -190,29 +190,38 @@ func NormalizeRepoTags(repoURL string, repoTagsCache RepoTagsCache) (NormalizedT
 		}
 	}
 	// Cache miss.
-	assumedReponame, err := RepoName(repoURL)
+	tags, err := RepoTags(repoURL, repoTagsCache)
 	if err != nil {
 		return nil, err
 	}
-	tags, err := RepoTags(repoURL, repoTagsCache)
+	NormalizedTags, err = normalizeTags(repoURL, tags)
 	if err != nil {
 		return nil, err
 	}
-	NormalizedTags = make(map[string]NormalizedTag)
+	if repoTagsCache != nil {
+		// The RepoTags() call above will have cached the Tag map already
+		tagsMap := repoTagsCache[repoURL].Tag
+		repoTagsCache[repoURL] = RepoTagsMap{Tag: tagsMap, NormalizedTag: NormalizedTags}
+	}
+	return NormalizedTags, nil
+}
+
+// normalizeTags returns a map of the normalized tags of repoURL mapping back to original tags and also commit hashes.
+func normalizeTags(repoURL string, tags Tags) (map[string]NormalizedTag, error) {
+	assumedReponame, err := RepoName(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	normalizedTags := make(map[string]NormalizedTag)
 	for _, t := range tags {
 		normalizedTag, err := normalizeRepoTag(strings.ToLower(t.Tag), assumedReponame)
 		if err != nil {
 			// It's conceivable that not all tags are normalizable or potentially versions.
 			continue
 		}
-		NormalizedTags[normalizedTag] = NormalizedTag{OriginalTag: t.Tag, Commit: t.Commit}
-	}
-	if repoTagsCache != nil {
-		// The RepoTags() call above will have cached the Tag map already
-		tagsMap := repoTagsCache[repoURL].Tag
-		repoTagsCache[repoURL] = RepoTagsMap{Tag: tagsMap, NormalizedTag: NormalizedTags}
+		normalizedTags[normalizedTag] = NormalizedTag{OriginalTag: t.Tag, Commit: t.Commit}
 	}
-	return NormalizedTags, nil
+	return normalizedTags, nil
 }
 
 // Return a list of just the references that are tags.
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// ErrCacheMiss is returned by a TagStore that doesn't have an entry.
+var ErrCacheMiss = errors.New("tag cache miss")
+
+// TagStore persists the tags of repositories between runs.
+type TagStore interface {
+	// Load returns the data stored under key, or ErrCacheMiss.
+	Load(ctx context.Context, key string) ([]byte, error)
+	Store(ctx context.Context, key string, data []byte) error
+}
+
+// DirTagStore stores tags in a local directory.
+type DirTagStore string
+
+func (d DirTagStore) Load(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	return data, err
+}
+
+func (d DirTagStore) Store(_ context.Context, key string, data []byte) error {
+	fileName := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+// GCSTagStore stores tags in a GCS bucket, under an optional prefix.
+type GCSTagStore struct {
+	Bucket *storage.BucketHandle
+	Prefix string
+}
+
+func (g GCSTagStore) Load(ctx context.Context, key string) ([]byte, error) {
+	r, err := g.Bucket.Object(g.Prefix + key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (g GCSTagStore) Store(ctx context.Context, key string, data []byte) error {
+	w := g.Bucket.Object(g.Prefix + key).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// TagCache memoizes the tags of remote repositories per repository per day,
+// in memory and in a chain of TagStores (e.g. a local directory in front of
+// GCS), so converters resolving versions to commits don't repeatedly list the
+// same repositories.
+type TagCache struct {
+	stores []TagStore
+	now    func() time.Time
+	list   func(repoURL string) (Tags, error)
+
+	mu     sync.Mutex
+	memory map[string]Tags
+}
+
+// NewTagCache creates a TagCache backed by stores, consulted in order.
+func NewTagCache(stores ...TagStore) *TagCache {
+	return &TagCache{
+		stores: stores,
+		now:    time.Now,
+		list: func(repoURL string) (Tags, error) {
+			return RepoTags(repoURL, nil)
+		},
+		memory: make(map[string]Tags),
+	}
+}
+
+// cacheKey returns the key the tags of repoURL are stored under today.
+func (c *TagCache) cacheKey(repoURL string) string {
+	hash := sha256.Sum256([]byte(repoURL))
+	return fmt.Sprintf("%s/%s.json", c.now().UTC().Format(time.DateOnly), hex.EncodeToString(hash[:]))
+}
+
+// Tags returns the tags of repoURL, listing the remote (as git ls-remote
+// would) at most once per day.
+func (c *TagCache) Tags(ctx context.Context, repoURL string) (Tags, error) {
+	key := c.cacheKey(repoURL)
+
+	c.mu.Lock()
+	tags, ok := c.memory[key]
+	c.mu.Unlock()
+	if ok {
+		return tags, nil
+	}
+
+	missed := 0
+	for _, store := range c.stores {
+		data, err := store.Load(ctx, key)
+		if err == nil && json.Unmarshal(data, &tags) == nil {
+			break
+		}
+		tags = nil
+		missed++
+	}
+
+	if tags == nil {
+		var err error
+		tags, err = c.list(repoURL)
+		if err != nil {
+			return nil, err
+		}
+		if tags == nil {
+			tags = Tags{}
+		}
+	}
+
+	// Backfill the stores that missed, e.g. the local directory from GCS.
+	if missed > 0 {
+		data, err := json.Marshal(tags)
+		if err != nil {
+			return nil, err
+		}
+		for _, store := range c.stores[:missed] {
+			if err := store.Store(ctx, key, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.memory[key] = tags
+	c.mu.Unlock()
+	return tags, nil
+}
+
+// NormalizedTags returns the tags of repoURL by their normalized names, for
+// VersionToCommit, tolerating tag naming conventions such as a "v" prefix,
+// the repo name as a prefix or underscores as separators.
+func (c *TagCache) NormalizedTags(ctx context.Context, repoURL string) (map[string]NormalizedTag, error) {
+	tags, err := c.Tags(ctx, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeTags(repoURL, tags)
+}
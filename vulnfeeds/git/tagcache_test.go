@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func newTestTagCache(t *testing.T, now time.Time, tags Tags, stores ...TagStore) (*TagCache, *int) {
+	t.Helper()
+	listed := 0
+	c := NewTagCache(stores...)
+	c.now = func() time.Time { return now }
+	c.list = func(string) (Tags, error) {
+		listed++
+		return tags, nil
+	}
+	return c, &listed
+}
+
+func TestTagCache(t *testing.T) {
+	ctx := context.Background()
+	repo := "https://github.com/example/libfoo"
+	tags := Tags{
+		{Tag: "v1.2.3", Commit: "1111111111111111111111111111111111111111"},
+		{Tag: "libfoo-1_3_0", Commit: "2222222222222222222222222222222222222222"},
+	}
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	local := DirTagStore(t.TempDir())
+	remote := DirTagStore(t.TempDir())
+
+	c, listed := newTestTagCache(t, day, tags, local, remote)
+	for range 2 {
+		got, err := c.Tags(ctx, repo)
+		if err != nil {
+			t.Fatalf("Tags() error: %v", err)
+		}
+		if diff := gocmp.Diff(tags, got); diff != "" {
+			t.Errorf("Tags() mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if *listed != 1 {
+		t.Errorf("listed the remote %d times, want 1", *listed)
+	}
+
+	// A new process on a fresh machine is served from the shared store, and
+	// backfills the local one.
+	fresh := DirTagStore(t.TempDir())
+	c, listed = newTestTagCache(t, day, nil, fresh, remote)
+	if _, err := c.Tags(ctx, repo); err != nil {
+		t.Fatalf("Tags() error: %v", err)
+	}
+	if *listed != 0 {
+		t.Errorf("listed the remote %d times, want 0", *listed)
+	}
+	if _, err := fresh.Load(ctx, c.cacheKey(repo)); err != nil {
+		t.Errorf("local store wasn't backfilled: %v", err)
+	}
+
+	// The next day, the remote is listed again.
+	c, listed = newTestTagCache(t, day.Add(24*time.Hour), tags, local, remote)
+	if _, err := c.Tags(ctx, repo); err != nil {
+		t.Fatalf("Tags() error: %v", err)
+	}
+	if *listed != 1 {
+		t.Errorf("listed the remote %d times, want 1", *listed)
+	}
+}
+
+func TestNormalizedTags(t *testing.T) {
+	ctx := context.Background()
+	repo := "https://github.com/example/libfoo"
+	tags := Tags{
+		{Tag: "v1.2.3", Commit: "1111111111111111111111111111111111111111"},
+		{Tag: "libfoo-1_3_0", Commit: "2222222222222222222222222222222222222222"},
+	}
+	c, _ := newTestTagCache(t, time.Now(), tags)
+	normalizedTags, err := c.NormalizedTags(ctx, repo)
+	if err != nil {
+		t.Fatalf("NormalizedTags() error: %v", err)
+	}
+
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "1.2.3", want: "1111111111111111111111111111111111111111"},
+		{version: "1.3.0", want: "2222222222222222222222222222222222222222"},
+		{version: "2.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		ac, err := VersionToCommit(tt.version, repo, cves.Fixed, normalizedTags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("VersionToCommit(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if ac.Fixed != tt.want {
+			t.Errorf("VersionToCommit(%q) = %q, want %q", tt.version, ac.Fixed, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// advisoriesPerPage is the most advisories GitHub returns per page.
+const advisoriesPerPage = 100
+
+// Advisory is the subset of a global security advisory of the GitHub
+// Advisory Database the converters use.
+type Advisory struct {
+	GHSAID             string                  `json:"ghsa_id"`
+	CVEID              string                  `json:"cve_id"`
+	HTMLURL            string                  `json:"html_url"`
+	Type               string                  `json:"type"`
+	Summary            string                  `json:"summary"`
+	Description        string                  `json:"description"`
+	SourceCodeLocation string                  `json:"source_code_location"`
+	References         []string                `json:"references"`
+	PublishedAt        string                  `json:"published_at"`
+	UpdatedAt          string                  `json:"updated_at"`
+	WithdrawnAt        string                  `json:"withdrawn_at"`
+	Vulnerabilities    []AdvisoryVulnerability `json:"vulnerabilities"`
+}
+
+// AdvisoryVulnerability is a package an advisory affects.
+type AdvisoryVulnerability struct {
+	// Package is nil for advisories that don't name one.
+	Package                *AdvisoryPackage `json:"package"`
+	VulnerableVersionRange string           `json:"vulnerable_version_range"`
+	FirstPatchedVersion    string           `json:"first_patched_version"`
+}
+
+// AdvisoryPackage is a package of an ecosystem, e.g. "npm".
+type AdvisoryPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// Advisories lists the global security advisories matching query (e.g.
+// type=unreviewed, or modified=>2025-01-01), following every page of the
+// results.
+func (f *Feature) Advisories(ctx context.Context, query url.Values) ([]Advisory, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("per_page", strconv.Itoa(advisoriesPerPage))
+	var all []Advisory
+	path := "/advisories?" + q.Encode()
+	for path != "" {
+		var page []Advisory
+		next, err := f.getPage(ctx, path, &page)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		path = next
+	}
+	return all, nil
+}
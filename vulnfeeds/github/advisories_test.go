@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAdvisories(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/advisories" || r.URL.Query().Get("type") != "unreviewed" || r.URL.Query().Get("per_page") != "100" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Query().Get("after") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/advisories?type=unreviewed&per_page=100&after=page2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"ghsa_id": "GHSA-aaaa-aaaa-aaaa", "vulnerabilities": []}]`)
+		case "page2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/advisories?type=unreviewed&per_page=100>; rel="prev"`, server.URL))
+			fmt.Fprint(w, `[{"ghsa_id": "GHSA-bbbb-bbbb-bbbb", "vulnerabilities": [{"package": {"ecosystem": "npm", "name": "left-pad"}}]}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := NewClient("").WithBaseURL(server.URL)
+
+	advisories, err := c.Feature("test", 0).Advisories(context.Background(), url.Values{"type": {"unreviewed"}})
+	if err != nil {
+		t.Fatalf("Advisories() error: %v", err)
+	}
+	if len(advisories) != 2 || advisories[0].GHSAID != "GHSA-aaaa-aaaa-aaaa" || advisories[1].GHSAID != "GHSA-bbbb-bbbb-bbbb" {
+		t.Fatalf("Advisories() = %+v", advisories)
+	}
+	if pkg := advisories[1].Vulnerabilities[0].Package; pkg == nil || pkg.Ecosystem != "npm" {
+		t.Errorf("package of %s = %+v, want npm", advisories[1].GHSAID, pkg)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	c := NewClient("")
+	tests := []struct {
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{link: "", want: ""},
+		{link: `<https://api.github.com/advisories?after=abc>; rel="next", <https://api.github.com/advisories?before=xyz>; rel="prev"`, want: "/advisories?after=abc"},
+		{link: `<https://api.github.com/advisories?before=xyz>; rel="prev"`, want: ""},
+		{link: `<https://example.com/advisories?after=abc>; rel="next"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := c.nextPage(tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("nextPage(%q) error = %v, wantErr %v", tt.link, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("nextPage(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package github is a GitHub REST API client shared by the features of the
+// converters that need it. It authenticates with a token, budgets the rate
+// limit between features, caches responses and degrades gracefully once the
+// budget is exhausted.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// APIURL is the base URL of the GitHub REST API.
+	APIURL = "https://api.github.com"
+	// TokenEnv is the environment variable NewClientFromEnv reads the token from.
+	TokenEnv = "GITHUB_TOKEN"
+)
+
+var (
+	// ErrBudgetExhausted is returned instead of making a request once the
+	// feature's budget or the rate limit is exhausted. Callers should carry on
+	// without the information rather than fail.
+	ErrBudgetExhausted = errors.New("GitHub API budget exhausted")
+	// ErrNotFound is returned when the requested resource doesn't exist.
+	ErrNotFound = errors.New("not found on GitHub")
+)
+
+type cachedResponse struct {
+	etag string
+	body []byte
+	// next is the path of the next page of a paginated response.
+	next string
+}
+
+// Client is a GitHub REST API client. It is safe for concurrent use.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	now        func() time.Time
+
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+	cache     map[string]cachedResponse
+}
+
+// NewClient creates a client authenticating with token. An empty token makes
+// anonymous requests, which have a much lower rate limit.
+func NewClient(token string) *Client {
+	return &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    APIURL,
+		token:      token,
+		now:        time.Now,
+		remaining:  -1,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+// WithBaseURL points the client at another API server, e.g. a GitHub
+// Enterprise server or a test server.
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+	return c
+}
+
+// NewClientFromEnv creates a client authenticating with the token in $GITHUB_TOKEN.
+func NewClientFromEnv() *Client {
+	return NewClient(os.Getenv(TokenEnv))
+}
+
+// Remaining returns the number of requests left in the current rate limit
+// window, or -1 if it isn't known yet.
+func (c *Client) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.reset.IsZero() && c.now().After(c.reset) {
+		return -1
+	}
+	return c.remaining
+}
+
+// Feature is a share of the client's rate limit reserved by one feature, so
+// that one feature can't starve the others.
+type Feature struct {
+	client *Client
+	name   string
+
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// Feature returns a handle making at most limit uncached requests on behalf
+// of the named feature. A limit of 0 means no limit beyond the rate limit.
+func (c *Client) Feature(name string, limit int) *Feature {
+	return &Feature{client: c, name: name, limit: limit}
+}
+
+// Used returns the number of requests made by the feature.
+func (f *Feature) Used() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.used
+}
+
+// take consumes one request of the feature's budget.
+func (f *Feature) take() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.limit > 0 && f.used >= f.limit {
+		return fmt.Errorf("%s: %w", f.name, ErrBudgetExhausted)
+	}
+	if f.client.Remaining() == 0 {
+		return fmt.Errorf("%s: rate limit: %w", f.name, ErrBudgetExhausted)
+	}
+	f.used++
+	return nil
+}
+
+// updateRateLimit records the rate limit reported by a response.
+func (c *Client) updateRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remaining = remaining
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		c.reset = time.Unix(reset, 0)
+	}
+}
+
+// Get fetches the API path (e.g. "/repos/google/osv.dev") and decodes the
+// JSON response into v. Responses are cached and revalidated with their
+// ETag, and revalidations that GitHub reports as unchanged don't count
+// against the rate limit.
+func (f *Feature) Get(ctx context.Context, path string, v any) error {
+	_, err := f.getPage(ctx, path, v)
+	return err
+}
+
+// getPage is Get, also returning the path of the next page of a paginated
+// response, from its Link header, or "" if it's the last.
+func (f *Feature) getPage(ctx context.Context, path string, v any) (string, error) {
+	c := f.client
+	u := c.baseURL + path
+
+	c.mu.Lock()
+	cached, isCached := c.cache[u]
+	c.mu.Unlock()
+
+	if err := f.take(); err != nil {
+		// Stale information beats none.
+		if isCached {
+			return cached.next, json.Unmarshal(cached.body, v)
+		}
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if isCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	c.updateRateLimit(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		return cached.next, json.Unmarshal(cached.body, v)
+	// GitHub responds with 422 to commits it can't resolve.
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnprocessableEntity:
+		return "", fmt.Errorf("%s: %w", path, ErrNotFound)
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		c.mu.Lock()
+		c.remaining = 0
+		c.mu.Unlock()
+		if isCached {
+			return cached.next, json.Unmarshal(cached.body, v)
+		}
+		return "", fmt.Errorf("%s: rate limited: %w", f.name, ErrBudgetExhausted)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%s: bad response: %v", path, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	next, err := c.nextPage(resp.Header.Get("Link"))
+	if err != nil {
+		return "", err
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		c.cache[u] = cachedResponse{etag: etag, body: body, next: next}
+		c.mu.Unlock()
+	}
+	return next, json.Unmarshal(body, v)
+}
+
+// nextPage returns the path of the next page in a Link header, e.g.
+// `<https://api.github.com/advisories?after=abc>; rel="next"`, or "" if
+// there's none.
+func (c *Client) nextPage(link string) (string, error) {
+	for _, l := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(l), ";")
+		if !ok || !slices.Contains(strings.Fields(strings.ReplaceAll(params, ";", " ")), `rel="next"`) {
+			continue
+		}
+		next := strings.Trim(strings.TrimSpace(target), "<>")
+		path, ok := strings.CutPrefix(next, c.baseURL)
+		if !ok {
+			return "", fmt.Errorf("next page %q isn't on %s", next, c.baseURL)
+		}
+		return path, nil
+	}
+	return "", nil
+}
+
+// Repository is the subset of a GitHub repository the converters use.
+type Repository struct {
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+	Fork          bool   `json:"fork"`
+}
+
+// OwnerRepo returns the owner and name of the repository of a GitHub URL.
+func OwnerRepo(repoURL string) (owner string, repo string, err error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Hostname() != "github.com" {
+		return "", "", fmt.Errorf("%q is not a GitHub URL", repoURL)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%q is not a GitHub repository URL", repoURL)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
+
+// Repo looks up the repository of a GitHub URL. Renamed and transferred
+// repositories resolve to their current name.
+func (f *Feature) Repo(ctx context.Context, repoURL string) (*Repository, error) {
+	owner, name, err := OwnerRepo(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repository
+	if err := f.Get(ctx, fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name)), &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// ResolveRef returns the commit hash a branch, tag or abbreviated commit of
+// the repository of a GitHub URL refers to.
+func (f *Feature) ResolveRef(ctx context.Context, repoURL string, ref string) (string, error) {
+	owner, name, err := OwnerRepo(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := f.Get(ctx, fmt.Sprintf("/repos/%s/%s/commits/%s", url.PathEscape(owner), url.PathEscape(name), url.PathEscape(ref)), &commit); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client talking to a fake API that serves one
+// repository, and a counter of the requests the fake API handled.
+func newTestClient(t *testing.T, remaining int) (*Client, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if remaining == 0 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/google/osv.dev":
+			if r.Header.Get("If-None-Match") == `"etag"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			remaining--
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+			w.Header().Set("ETag", `"etag"`)
+			fmt.Fprint(w, `{"full_name": "google/osv.dev", "default_branch": "master"}`)
+		case "/repos/google/osv.dev/commits/v1.0.0":
+			remaining--
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+			fmt.Fprint(w, `{"sha": "1111111111111111111111111111111111111111"}`)
+		default:
+			remaining--
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient("token")
+	c.baseURL = server.URL
+	return c, &requests
+}
+
+func TestOwnerRepo(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{url: "https://github.com/google/osv.dev", wantOwner: "google", wantRepo: "osv.dev"},
+		{url: "https://github.com/google/osv.dev.git", wantOwner: "google", wantRepo: "osv.dev"},
+		{url: "https://github.com/google/osv.dev/commit/abcdef", wantOwner: "google", wantRepo: "osv.dev"},
+		{url: "https://github.com/google", wantErr: true},
+		{url: "https://gitlab.com/gitlab-org/gitlab", wantErr: true},
+	}
+	for _, tt := range tests {
+		owner, repo, err := OwnerRepo(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("OwnerRepo(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if owner != tt.wantOwner || repo != tt.wantRepo {
+			t.Errorf("OwnerRepo(%q) = %q, %q, want %q, %q", tt.url, owner, repo, tt.wantOwner, tt.wantRepo)
+		}
+	}
+}
+
+func TestRepoAndResolveRef(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestClient(t, 10)
+	f := c.Feature("test", 0)
+
+	repo, err := f.Repo(ctx, "https://github.com/google/osv.dev")
+	if err != nil {
+		t.Fatalf("Repo() error: %v", err)
+	}
+	if repo.FullName != "google/osv.dev" || repo.DefaultBranch != "master" {
+		t.Errorf("Repo() = %+v", repo)
+	}
+
+	sha, err := f.ResolveRef(ctx, "https://github.com/google/osv.dev", "v1.0.0")
+	if err != nil {
+		t.Fatalf("ResolveRef() error: %v", err)
+	}
+	if sha != "1111111111111111111111111111111111111111" {
+		t.Errorf("ResolveRef() = %q", sha)
+	}
+
+	if _, err := f.Repo(ctx, "https://github.com/google/bogus"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Repo() of a missing repository error = %v, want ErrNotFound", err)
+	}
+	if got := c.Remaining(); got != 7 {
+		t.Errorf("Remaining() = %d, want 7", got)
+	}
+}
+
+func TestFeatureBudget(t *testing.T) {
+	ctx := context.Background()
+	c, requests := newTestClient(t, 10)
+	f := c.Feature("test", 2)
+
+	if _, err := f.ResolveRef(ctx, "https://github.com/google/osv.dev", "v1.0.0"); err != nil {
+		t.Fatalf("ResolveRef() error: %v", err)
+	}
+	if _, err := f.Repo(ctx, "https://github.com/google/osv.dev"); err != nil {
+		t.Fatalf("Repo() error: %v", err)
+	}
+	// The budget is exhausted: uncached lookups fail without a request and
+	// cached ones are served from the cache.
+	if _, err := f.ResolveRef(ctx, "https://github.com/google/osv.dev", "v1.0.0"); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("ResolveRef() error = %v, want ErrBudgetExhausted", err)
+	}
+	if _, err := f.Repo(ctx, "https://github.com/google/osv.dev"); err != nil {
+		t.Errorf("Repo() of a cached repository error: %v", err)
+	}
+	if *requests != 2 {
+		t.Errorf("made %d requests, want 2", *requests)
+	}
+
+	// Other features have their own budget.
+	if _, err := c.Feature("other", 1).Repo(ctx, "https://github.com/google/osv.dev"); err != nil {
+		t.Errorf("Repo() from another feature error: %v", err)
+	}
+}
+
+func TestRateLimitExhausted(t *testing.T) {
+	ctx := context.Background()
+	c, requests := newTestClient(t, 0)
+	f := c.Feature("test", 0)
+
+	for range 2 {
+		if _, err := f.Repo(ctx, "https://github.com/google/osv.dev"); !errors.Is(err, ErrBudgetExhausted) {
+			t.Errorf("Repo() error = %v, want ErrBudgetExhausted", err)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("made %d requests, want 1", *requests)
+	}
+}
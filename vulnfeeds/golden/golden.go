@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden compares the output of vulnfeeds tests to golden files,
+// and rewrites the files when the output changes on purpose.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// UpdateEnv is the environment variable that, when set, makes the helpers
+// write the golden files from the output instead of comparing them.
+const UpdateEnv = "VULNFEEDS_UPDATE_GOLDEN"
+
+// Bytes compares got to the golden file at path, failing the test on a
+// difference.
+func Bytes(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if err := check(path, got, os.Getenv(UpdateEnv) != ""); err != nil {
+		t.Error(err)
+	}
+}
+
+// check compares got to the golden file at path, or writes it to the file
+// if update is set.
+func check(path string, got []byte, update bool) error {
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create golden file directory: %w", err)
+		}
+		return os.WriteFile(path, got, 0644)
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file (set %s=1 to create it): %w", UpdateEnv, err)
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("output differs from golden file %s (set %s=1 to update it) (-want +got):\n%s", path, UpdateEnv, gocmp.Diff(string(want), string(got)))
+	}
+	return nil
+}
+
+// JSON compares the indented JSON encoding of got to the golden file at
+// path, failing the test on a difference.
+func JSON(t testing.TB, path string, got any) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	Bytes(t, path, append(data, '\n'))
+}
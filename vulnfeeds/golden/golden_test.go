@@ -0,0 +1,39 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "out.json")
+	if err := check(path, []byte("a\n"), false); err == nil {
+		t.Error("check() of a missing golden file succeeded")
+	}
+	if err := check(path, []byte("a\n"), true); err != nil {
+		t.Fatalf("check() failed to update the golden file: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "a\n" {
+		t.Errorf("golden file = %q, %v, want %q", data, err, "a\n")
+	}
+	if err := check(path, []byte("a\n"), false); err != nil {
+		t.Errorf("check() of the same output failed: %v", err)
+	}
+	if err := check(path, []byte("b\n"), false); err == nil {
+		t.Error("check() of different output succeeded")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	t.Setenv(UpdateEnv, "1")
+	JSON(t, path, map[string]int{"a": 1})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "{\n  \"a\": 1\n}\n"; got != want {
+		t.Errorf("golden file = %q, want %q", got, want)
+	}
+}
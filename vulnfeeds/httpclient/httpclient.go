@@ -0,0 +1,298 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpclient configures the HTTP client the downloaders share,
+// http.DefaultClient: its timeouts, proxy, per-host connection limits and
+// the user agent identifying the osv.dev pipeline to the feeds. It also
+// counts the requests sent to each host, their retries, the bytes downloaded
+// and how many connections were opened or reused, for the run to report.
+//
+// Install it before ratelimit, which limits and caches the requests on top of
+// it, so cached responses aren't counted as requests.
+package httpclient
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+// Defaults of the Options.
+const (
+	DefaultTimeout               = 30 * time.Minute
+	DefaultDialTimeout           = 30 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 2 * time.Minute
+	DefaultMaxConnsPerHost       = 16
+)
+
+// Options configure the shared client.
+type Options struct {
+	// Timeout limits the whole of a request, including reading its body.
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// Proxy is the URL of the proxy to send requests through, or empty to
+	// use $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY.
+	Proxy string
+	// UserAgent is sent with requests that don't set one, by default
+	// UserAgent().
+	UserAgent string
+	// MaxConnsPerHost limits the connections to each host, in use or idle.
+	// Idle connections are kept for up to as many.
+	MaxConnsPerHost int
+	// Base, if set, sends the requests instead of a transport configured by
+	// the options, e.g. a vcr.Recorder replaying a fixture in tests. The
+	// requests are counted either way.
+	Base http.RoundTripper
+}
+
+// UserAgent returns the default user agent, naming the pipeline and the
+// converter build.
+func UserAgent() string {
+	version := utility.ReadBuildInfo().Version
+	if version == "" {
+		version = "unknown"
+	}
+	return "osv.dev-vulnfeeds/" + version + " (+https://github.com/google/osv.dev/tree/master/vulnfeeds)"
+}
+
+// NewTransport returns a pooled transport configured by opts.
+func NewTransport(opts Options) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	dialer := &net.Dialer{Timeout: cmp.Or(opts.DialTimeout, DefaultDialTimeout), KeepAlive: 30 * time.Second}
+	t.DialContext = dialer.DialContext
+	t.TLSHandshakeTimeout = cmp.Or(opts.TLSHandshakeTimeout, DefaultTLSHandshakeTimeout)
+	t.ResponseHeaderTimeout = cmp.Or(opts.ResponseHeaderTimeout, DefaultResponseHeaderTimeout)
+	t.MaxConnsPerHost = cmp.Or(opts.MaxConnsPerHost, DefaultMaxConnsPerHost)
+	t.MaxIdleConnsPerHost = t.MaxConnsPerHost
+	return t, nil
+}
+
+// Transport is an http.RoundTripper that sets the user agent of requests
+// without one, and counts them in Stats.
+type Transport struct {
+	Base      http.RoundTripper
+	UserAgent string
+	Stats     *Stats
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.Stats.gotConn(host, info.Reused)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	if t.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	t.Stats.add(host, func(s *HostStats) { s.Requests++ })
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		t.Stats.add(host, func(s *HostStats) { s.Errors++ })
+		return nil, err
+	}
+	res.Body = &countingBody{ReadCloser: res.Body, count: func(n int) {
+		t.Stats.add(host, func(s *HostStats) { s.Bytes += int64(n) })
+	}}
+	return res, nil
+}
+
+// countingBody counts the bytes read from a body.
+type countingBody struct {
+	io.ReadCloser
+	count func(n int)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.count(n)
+	}
+	return n, err
+}
+
+// Install configures http.DefaultClient, which the downloaders use, with
+// opts, counting its requests in DefaultStats. It must be called before any
+// request is sent, as the client isn't otherwise safe to reconfigure.
+func Install(opts Options) error {
+	base := opts.Base
+	if base == nil {
+		t, err := NewTransport(opts)
+		if err != nil {
+			return err
+		}
+		base = t
+	}
+	http.DefaultClient.Transport = &Transport{
+		Base:      base,
+		UserAgent: cmp.Or(opts.UserAgent, UserAgent()),
+		Stats:     DefaultStats,
+	}
+	http.DefaultClient.Timeout = cmp.Or(opts.Timeout, DefaultTimeout)
+	return nil
+}
+
+// Flags are the command line flags configuring the shared client, shared by
+// the downloaders.
+type Flags struct {
+	timeout         *time.Duration
+	proxy           *string
+	userAgent       *string
+	maxConnsPerHost *int
+}
+
+// RegisterFlags registers the client flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		timeout:         fs.Duration("http_timeout", DefaultTimeout, "How long a request, including reading its response, may take"),
+		proxy:           fs.String("http_proxy", "", "URL of the proxy to send requests through (by default, taken from $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY)"),
+		userAgent:       fs.String("user_agent", "", "User agent to identify requests with (by default, the pipeline and converter build)"),
+		maxConnsPerHost: fs.Int("http_max_conns_per_host", DefaultMaxConnsPerHost, "Maximum number of connections to each host"),
+	}
+}
+
+// Install installs the client configured by the flags.
+func (f *Flags) Install() error {
+	if *f.timeout <= 0 {
+		return errors.New("-http_timeout must be positive")
+	}
+	if *f.maxConnsPerHost < 1 {
+		return errors.New("-http_max_conns_per_host must be at least 1")
+	}
+	return Install(Options{Timeout: *f.timeout, Proxy: *f.proxy, UserAgent: *f.userAgent, MaxConnsPerHost: *f.maxConnsPerHost})
+}
+
+// HostStats are the counters of a host.
+type HostStats struct {
+	Requests int64 `json:"requests"`
+	// Retries are the requests sent again after a failure, counted by the
+	// retry loops with Retried.
+	Retries int64 `json:"retries"`
+	// Errors are the requests that failed without a response.
+	Errors int64 `json:"errors"`
+	Bytes  int64 `json:"bytes"`
+	// NewConns and ReusedConns count the connections requests were sent on,
+	// opened for them or reused from the pool.
+	NewConns    int64 `json:"new_conns"`
+	ReusedConns int64 `json:"reused_conns"`
+}
+
+// Stats counts the requests to each host. It's safe for concurrent use.
+type Stats struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+}
+
+// DefaultStats counts the requests of http.DefaultClient, once installed.
+var DefaultStats = &Stats{}
+
+func (s *Stats) add(host string, update func(*HostStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hosts == nil {
+		s.hosts = make(map[string]*HostStats)
+	}
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &HostStats{}
+		s.hosts[host] = h
+	}
+	update(h)
+}
+
+func (s *Stats) gotConn(host string, reused bool) {
+	s.add(host, func(h *HostStats) {
+		if reused {
+			h.ReusedConns++
+		} else {
+			h.NewConns++
+		}
+	})
+}
+
+// Retried counts a retry of a request to the host of rawURL.
+func (s *Stats) Retried(rawURL string) {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	s.add(strings.ToLower(host), func(h *HostStats) { h.Retries++ })
+}
+
+// Retried counts a retry of a request to the host of rawURL in DefaultStats.
+func Retried(rawURL string) {
+	DefaultStats.Retried(rawURL)
+}
+
+// Hosts returns a copy of the counters of each host.
+func (s *Stats) Hosts() map[string]HostStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts := make(map[string]HostStats, len(s.hosts))
+	for host, h := range s.hosts {
+		hosts[host] = *h
+	}
+	return hosts
+}
+
+// Logger is the logging the stats are reported to.
+type Logger interface {
+	Infof(format string, a ...any)
+}
+
+// Report logs the counters of each host, ordered by host.
+func (s *Stats) Report(logger Logger) {
+	hosts := s.Hosts()
+	names := make([]string, 0, len(hosts))
+	for host := range hosts {
+		names = append(names, host)
+	}
+	slices.Sort(names)
+	for _, host := range names {
+		h := hosts[host]
+		logger.Infof("HTTP %s: %d requests (%d retries, %d errors), %d bytes downloaded, %d connections opened and %d reused",
+			host, h.Requests, h.Retries, h.Errors, h.Bytes, h.NewConns, h.ReusedConns)
+	}
+}
@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/vcr"
+)
+
+func TestTransport(t *testing.T) {
+	var mu sync.Mutex
+	var userAgents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		userAgents = append(userAgents, r.UserAgent())
+		mu.Unlock()
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	base, err := NewTransport(Options{MaxConnsPerHost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &Stats{}
+	client := &http.Client{Transport: &Transport{Base: base, UserAgent: "test-agent", Stats: stats}}
+
+	// Requests are counted safely from concurrent downloads.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "custom")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	stats.Retried(srv.URL + "/again")
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := stats.Hosts()[u.Hostname()]
+	if got.Requests != 11 || got.Retries != 1 || got.Errors != 0 || got.Bytes != 55 {
+		t.Errorf("stats = %+v, want 11 requests, 1 retry, no errors and 55 bytes", got)
+	}
+	if got.NewConns+got.ReusedConns != 11 || got.NewConns > 2 {
+		t.Errorf("stats = %+v, want 11 connections used, at most 2 of them opened", got)
+	}
+	if req.Header.Get("User-Agent") != "custom" {
+		t.Errorf("the request's header was modified")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if n := strings.Count(strings.Join(userAgents, ","), "test-agent"); n != 10 || userAgents[len(userAgents)-1] != "custom" {
+		t.Errorf("user agents = %v, want test-agent unless set", userAgents)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	stats := &Stats{}
+	client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Stats: stats}}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Fatal("Get of a closed server succeeded")
+	}
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.Hosts()[u.Hostname()]; got.Requests != 1 || got.Errors != 1 {
+		t.Errorf("stats = %+v, want 1 failed request", got)
+	}
+}
+
+func TestInstallReplay(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	content := `{"interactions": [{"request": {"method": "GET", "url": "https://feeds.example.com/feed.json"}, "response": {"status": 200, "body": "[]"}}]}`
+	if err := os.WriteFile(fixture, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder, err := vcr.New(fixture, vcr.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, previousTimeout := http.DefaultClient.Transport, http.DefaultClient.Timeout
+	t.Cleanup(func() {
+		http.DefaultClient.Transport, http.DefaultClient.Timeout = previous, previousTimeout
+	})
+
+	// The replay harness stands in for the network, and its responses are
+	// counted as the network's would be.
+	if err := Install(Options{Base: recorder}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Get("https://feeds.example.com/feed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if got := DefaultStats.Hosts()["feeds.example.com"]; got.Requests != 1 || got.Bytes != 2 {
+		t.Errorf("stats = %+v, want 1 request of 2 bytes", got)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(Options{Proxy: "http://proxy.example.com:3128", MaxConnsPerHost: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxConnsPerHost != 4 || transport.MaxIdleConnsPerHost != 4 {
+		t.Errorf("MaxConnsPerHost = %d, MaxIdleConnsPerHost = %d, want 4", transport.MaxConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+	if transport.ResponseHeaderTimeout != DefaultResponseHeaderTimeout {
+		t.Errorf("ResponseHeaderTimeout = %v, want %v", transport.ResponseHeaderTimeout, DefaultResponseHeaderTimeout)
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://nvd.nist.gov/", nil)
+	proxy, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("http://proxy.example.com:3128", proxy.String()); diff != "" {
+		t.Errorf("proxy (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewTransport(Options{Proxy: "not a url"}); err == nil {
+		t.Errorf("NewTransport with an invalid proxy succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ids builds, parses and validates vulnerability IDs, such as CVE IDs,
+// the IDs distributions publish their own records of a CVE under (e.g.
+// ALPINE-CVE-2022-1234), and database IDs allocated by year (e.g.
+// PYSEC-2022-196).
+package ids
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// Prefix is the database prefix an ID starts with.
+type Prefix string
+
+const (
+	CVE    Prefix = "CVE"
+	Alpine Prefix = "ALPINE"
+	Debian Prefix = "DEBIAN"
+	GHSA   Prefix = "GHSA"
+	PYSEC  Prefix = "PYSEC"
+	Snyk   Prefix = "SNYK"
+)
+
+// distroPrefixes maps the ecosystems of distributions to the prefix of the
+// ID they publish their own record of a CVE under.
+var distroPrefixes = map[string]Prefix{
+	"Alpine": Alpine,
+	"Debian": Debian,
+}
+
+var (
+	// The IDs accepted by the OSV schema.
+	idRegexp  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(-[A-Za-z0-9._:]+)+$`)
+	cveRegexp = regexp.MustCompile(`^CVE-(\d{4})-(\d{4,})$`)
+	// IDs allocated per year, e.g. PYSEC-2022-196.
+	yearlyRegexp = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)-(\d{4})-(\d+)$`)
+)
+
+var ErrInvalidID = errors.New("invalid ID")
+
+// Valid reports whether id is a valid OSV ID.
+func Valid(id string) bool {
+	return idRegexp.MatchString(id)
+}
+
+// IsCVE reports whether id is a valid CVE ID.
+func IsCVE(id string) bool {
+	return cveRegexp.MatchString(id)
+}
+
+// ParseCVE validates a CVE ID, ignoring surrounding whitespace.
+func ParseCVE(id string) (cves.CVEID, error) {
+	id = strings.TrimSpace(id)
+	if !IsCVE(id) {
+		return "", fmt.Errorf("%w: %q is not a CVE ID", ErrInvalidID, id)
+	}
+	return cves.CVEID(id), nil
+}
+
+// CVEYearAndNumber returns the year and sequence number of a CVE ID, e.g.
+// "2023" and "23127" for CVE-2023-23127.
+func CVEYearAndNumber(id cves.CVEID) (year string, number string, err error) {
+	m := cveRegexp.FindStringSubmatch(string(id))
+	if m == nil {
+		return "", "", fmt.Errorf("%w: %q is not a CVE ID", ErrInvalidID, id)
+	}
+	return m[1], m[2], nil
+}
+
+// Split splits an ID into its prefix and the rest, e.g. ALPINE and
+// CVE-2022-1234 for ALPINE-CVE-2022-1234.
+func Split(id string) (Prefix, string, error) {
+	if !Valid(id) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	prefix, rest, _ := strings.Cut(id, "-")
+	return Prefix(prefix), rest, nil
+}
+
+// HasPrefix reports whether id is a valid ID with the given prefix.
+func HasPrefix(id string, prefix Prefix) bool {
+	p, _, err := Split(id)
+	return err == nil && p == prefix
+}
+
+// DistroPrefix returns the prefix of the IDs the distribution of ecosystem
+// (ignoring any ":<release>" suffix) publishes its records of CVEs under.
+func DistroPrefix(ecosystem string) (Prefix, bool) {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	prefix, ok := distroPrefixes[base]
+	return prefix, ok
+}
+
+// DistroCVE returns the ID of a distribution's record of a CVE, e.g.
+// ALPINE-CVE-2022-1234.
+func DistroCVE(prefix Prefix, id cves.CVEID) string {
+	return string(prefix) + "-" + string(id)
+}
+
+// Yearly returns an ID allocated per year, e.g. PYSEC-2022-196.
+func Yearly(prefix Prefix, year int, number int) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, year, number)
+}
+
+// ParseYearly parses an ID allocated per year with the given prefix,
+// returning its year and number.
+func ParseYearly(id string, prefix Prefix) (year int, number int, err error) {
+	m := yearlyRegexp.FindStringSubmatch(id)
+	if m == nil || Prefix(m[1]) != prefix {
+		return 0, 0, fmt.Errorf("%w: %q is not a %s ID", ErrInvalidID, id, prefix)
+	}
+	year, _ = strconv.Atoi(m[2])
+	number, err = strconv.Atoi(m[3])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %q: %w", ErrInvalidID, id, err)
+	}
+	return year, number, nil
+}
+
+// Unassigned returns the placeholder ID of a record derived from a CVE that
+// is yet to be allocated an ID, e.g. PYSEC-0000-CVE-2022-1234.
+func Unassigned(prefix Prefix, id cves.CVEID) string {
+	return string(prefix) + "-0000-" + string(id)
+}
+
+// IsUnassigned reports whether id (or a file named after it) is a
+// placeholder ID yet to be allocated.
+func IsUnassigned(id string, prefix Prefix) bool {
+	return strings.HasPrefix(id, string(prefix)+"-0000-")
+}
@@ -0,0 +1,114 @@
+package ids
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestParseCVE(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    cves.CVEID
+		wantErr bool
+	}{
+		{id: "CVE-2022-1234", want: "CVE-2022-1234"},
+		{id: " CVE-2022-123456 ", want: "CVE-2022-123456"},
+		{id: "CVE-2022-123", wantErr: true},
+		{id: "cve-2022-1234", wantErr: true},
+		{id: "TEMP-0000000-0D1A2B", wantErr: true},
+		{id: "ALPINE-CVE-2022-1234", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseCVE(tc.id)
+		if tc.wantErr {
+			if !errors.Is(err, ErrInvalidID) {
+				t.Errorf("ParseCVE(%q) error = %v, want ErrInvalidID", tc.id, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ParseCVE(%q) = %q, %v, want %q", tc.id, got, err, tc.want)
+		}
+	}
+}
+
+func TestCVEYearAndNumber(t *testing.T) {
+	year, number, err := CVEYearAndNumber("CVE-2023-23127")
+	if err != nil || year != "2023" || number != "23127" {
+		t.Errorf("CVEYearAndNumber() = %q, %q, %v, want 2023, 23127", year, number, err)
+	}
+	if _, _, err := CVEYearAndNumber("GHSA-xxxx-yyyy-zzzz"); err == nil {
+		t.Errorf("CVEYearAndNumber() of a GHSA ID succeeded")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		id         string
+		wantPrefix Prefix
+		wantRest   string
+		wantErr    bool
+	}{
+		{id: "ALPINE-CVE-2022-1234", wantPrefix: Alpine, wantRest: "CVE-2022-1234"},
+		{id: "GHSA-fr26-qjc8-mvjx", wantPrefix: GHSA, wantRest: "fr26-qjc8-mvjx"},
+		{id: "PYSEC-2022-196", wantPrefix: PYSEC, wantRest: "2022-196"},
+		{id: "CVE", wantErr: true},
+		{id: "-CVE-2022-1234", wantErr: true},
+		{id: "CVE-2022-1234 ", wantErr: true},
+	}
+	for _, tc := range tests {
+		prefix, rest, err := Split(tc.id)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Split(%q) error = %v, want error %v", tc.id, err, tc.wantErr)
+			continue
+		}
+		if prefix != tc.wantPrefix || rest != tc.wantRest {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", tc.id, prefix, rest, tc.wantPrefix, tc.wantRest)
+		}
+	}
+	if !HasPrefix("SNYK-PYTHON-TRYTOND-1730329", Snyk) || HasPrefix("SNYKS-1", Snyk) {
+		t.Errorf("HasPrefix() doesn't match on the whole prefix")
+	}
+}
+
+func TestDistroCVE(t *testing.T) {
+	for ecosystem, want := range map[string]string{
+		"Alpine:v3.18": "ALPINE-CVE-2022-1234",
+		"Debian":       "DEBIAN-CVE-2022-1234",
+	} {
+		prefix, ok := DistroPrefix(ecosystem)
+		if !ok {
+			t.Errorf("DistroPrefix(%q) not found", ecosystem)
+			continue
+		}
+		if got := DistroCVE(prefix, "CVE-2022-1234"); got != want {
+			t.Errorf("DistroCVE(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+	if _, ok := DistroPrefix("PyPI"); ok {
+		t.Errorf("DistroPrefix(PyPI) found")
+	}
+}
+
+func TestYearly(t *testing.T) {
+	id := Yearly(PYSEC, 2022, 196)
+	if id != "PYSEC-2022-196" {
+		t.Errorf("Yearly() = %q, want PYSEC-2022-196", id)
+	}
+	year, number, err := ParseYearly(id, PYSEC)
+	if err != nil || year != 2022 || number != 196 {
+		t.Errorf("ParseYearly(%q) = %d, %d, %v, want 2022, 196", id, year, number, err)
+	}
+	for _, id := range []string{"GHSA-2022-196", "PYSEC-0000-CVE-2022-1234", "PYSEC-22-1"} {
+		if _, _, err := ParseYearly(id, PYSEC); err == nil {
+			t.Errorf("ParseYearly(%q) succeeded", id)
+		}
+	}
+
+	unassigned := Unassigned(PYSEC, "CVE-2022-1234")
+	if unassigned != "PYSEC-0000-CVE-2022-1234" || !IsUnassigned(unassigned, PYSEC) || IsUnassigned(id, PYSEC) {
+		t.Errorf("Unassigned() = %q, not recognized as unassigned", unassigned)
+	}
+}
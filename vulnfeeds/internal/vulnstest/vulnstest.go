@@ -0,0 +1,218 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vulnstest builds OSV records for tests, so the records they expect
+// read like the records themselves.
+package vulnstest
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// VulnerabilityBuilder builds a Vulnerability:
+//
+//	want := vulnstest.NewVulnerability("CVE-2024-1234").
+//		Published("2024-01-01T00:00:00Z").
+//		Alias("GHSA-xxxx-xxxx-xxxx").
+//		Reference("FIX", "https://github.com/foo/bar/commit/abc").
+//		Affected(vulnstest.NewAffected().
+//			Package("Debian:12", "bar").
+//			Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.2.3-4")))).
+//		Build()
+type VulnerabilityBuilder struct {
+	v vulns.Vulnerability
+}
+
+// NewVulnerability returns a builder of the record with the given ID.
+func NewVulnerability(id string) *VulnerabilityBuilder {
+	return &VulnerabilityBuilder{v: vulns.Vulnerability{ID: id}}
+}
+
+// Summary sets the record's summary.
+func (b *VulnerabilityBuilder) Summary(summary string) *VulnerabilityBuilder {
+	b.v.Summary = summary
+	return b
+}
+
+// Details sets the record's details.
+func (b *VulnerabilityBuilder) Details(details string) *VulnerabilityBuilder {
+	b.v.Details = details
+	return b
+}
+
+// Published sets the record's published time.
+func (b *VulnerabilityBuilder) Published(published string) *VulnerabilityBuilder {
+	b.v.Published = published
+	return b
+}
+
+// Modified sets the record's modified time.
+func (b *VulnerabilityBuilder) Modified(modified string) *VulnerabilityBuilder {
+	b.v.Modified = modified
+	return b
+}
+
+// Withdrawn sets the record's withdrawn time.
+func (b *VulnerabilityBuilder) Withdrawn(withdrawn string) *VulnerabilityBuilder {
+	b.v.Withdrawn = withdrawn
+	return b
+}
+
+// Alias adds aliases to the record.
+func (b *VulnerabilityBuilder) Alias(ids ...string) *VulnerabilityBuilder {
+	b.v.Aliases = append(b.v.Aliases, ids...)
+	return b
+}
+
+// Related adds related records to the record.
+func (b *VulnerabilityBuilder) Related(ids ...string) *VulnerabilityBuilder {
+	b.v.Related = append(b.v.Related, ids...)
+	return b
+}
+
+// Reference adds a reference of the given type to the record.
+func (b *VulnerabilityBuilder) Reference(refType, url string) *VulnerabilityBuilder {
+	b.v.References = append(b.v.References, vulns.Reference{Type: refType, URL: url})
+	return b
+}
+
+// Severity adds a severity score of the given type to the record.
+func (b *VulnerabilityBuilder) Severity(scoreType, score string) *VulnerabilityBuilder {
+	b.v.Severity = append(b.v.Severity, vulns.Severity{Type: scoreType, Score: score})
+	return b
+}
+
+// DatabaseSpecific sets a field of the record's database_specific.
+func (b *VulnerabilityBuilder) DatabaseSpecific(key string, value any) *VulnerabilityBuilder {
+	b.v.SetDatabaseSpecific(key, value)
+	return b
+}
+
+// Affected adds affected entries to the record.
+func (b *VulnerabilityBuilder) Affected(affected ...*AffectedBuilder) *VulnerabilityBuilder {
+	for _, a := range affected {
+		b.v.Affected = append(b.v.Affected, a.Build())
+	}
+	return b
+}
+
+// Build returns the record built. Later calls to the builder don't change
+// it.
+func (b *VulnerabilityBuilder) Build() *vulns.Vulnerability {
+	v := b.v
+	v.Affected = slices.Clone(b.v.Affected)
+	v.References = slices.Clone(b.v.References)
+	v.DatabaseSpecific = maps.Clone(b.v.DatabaseSpecific)
+	return &v
+}
+
+// AffectedBuilder builds an affected entry.
+type AffectedBuilder struct {
+	a vulns.Affected
+}
+
+// NewAffected returns a builder of an affected entry.
+func NewAffected() *AffectedBuilder {
+	return &AffectedBuilder{}
+}
+
+// Package sets the entry's package.
+func (b *AffectedBuilder) Package(ecosystem, name string) *AffectedBuilder {
+	if b.a.Package == nil {
+		b.a.Package = &vulns.AffectedPackage{}
+	}
+	b.a.Package.Ecosystem = ecosystem
+	b.a.Package.Name = name
+	return b
+}
+
+// PURL sets the PURL of the entry's package.
+func (b *AffectedBuilder) PURL(purl string) *AffectedBuilder {
+	if b.a.Package == nil {
+		b.a.Package = &vulns.AffectedPackage{}
+	}
+	b.a.Package.Purl = purl
+	return b
+}
+
+// Range adds ranges to the entry.
+func (b *AffectedBuilder) Range(ranges ...vulns.AffectedRange) *AffectedBuilder {
+	b.a.Ranges = append(b.a.Ranges, ranges...)
+	return b
+}
+
+// Versions adds affected versions to the entry.
+func (b *AffectedBuilder) Versions(versions ...string) *AffectedBuilder {
+	b.a.Versions = append(b.a.Versions, versions...)
+	return b
+}
+
+// EcosystemSpecific sets a field of the entry's ecosystem_specific.
+func (b *AffectedBuilder) EcosystemSpecific(key string, value any) *AffectedBuilder {
+	if b.a.EcosystemSpecific == nil {
+		b.a.EcosystemSpecific = make(map[string]any)
+	}
+	b.a.EcosystemSpecific[key] = value
+	return b
+}
+
+// DatabaseSpecific sets a field of the entry's database_specific.
+func (b *AffectedBuilder) DatabaseSpecific(key string, value any) *AffectedBuilder {
+	if b.a.DatabaseSpecific == nil {
+		b.a.DatabaseSpecific = make(map[string]any)
+	}
+	b.a.DatabaseSpecific[key] = value
+	return b
+}
+
+// Build returns the entry built.
+func (b *AffectedBuilder) Build() vulns.Affected {
+	a := b.a
+	if a.Package != nil {
+		pkg := *a.Package
+		a.Package = &pkg
+	}
+	a.Ranges = slices.Clone(b.a.Ranges)
+	return a
+}
+
+// EcosystemRange returns an ECOSYSTEM range of the events.
+func EcosystemRange(events ...vulns.Event) vulns.AffectedRange {
+	return vulns.AffectedRange{Type: "ECOSYSTEM", Events: events}
+}
+
+// SemverRange returns a SEMVER range of the events.
+func SemverRange(events ...vulns.Event) vulns.AffectedRange {
+	return vulns.AffectedRange{Type: "SEMVER", Events: events}
+}
+
+// GitRange returns a GIT range of the events, in the commits of repo.
+func GitRange(repo string, events ...vulns.Event) vulns.AffectedRange {
+	return vulns.AffectedRange{Type: "GIT", Repo: repo, Events: events}
+}
+
+// Introduced returns an introduced event.
+func Introduced(version string) vulns.Event { return vulns.Event{Introduced: version} }
+
+// Fixed returns a fixed event.
+func Fixed(version string) vulns.Event { return vulns.Event{Fixed: version} }
+
+// LastAffected returns a last_affected event.
+func LastAffected(version string) vulns.Event { return vulns.Event{LastAffected: version} }
+
+// Limit returns a limit event.
+func Limit(version string) vulns.Event { return vulns.Event{Limit: version} }
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifest records the files a command writes, so the steps of a
+// batch pipeline (Cloud Batch, Argo and the like) can check, and hand on,
+// exactly what a previous step produced, and so a step that ran to
+// completion but produced nothing fails instead of passing its empty output
+// on.
+package manifest
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+// ErrEmpty is returned by Finish when a run that expected output wrote none.
+var ErrEmpty = errors.New("no output files were written")
+
+// Entry is a file in a manifest.
+type Entry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest lists the output files of a run, with their content hashes, and
+// the counts the command kept of what it wrote.
+type Manifest struct {
+	Tool   string         `json:"tool"`
+	Files  []Entry        `json:"files"`
+	Counts map[string]int `json:"counts,omitempty"`
+}
+
+// Flags holds the manifest flags, shared by the commands, and the outputs
+// recorded during the run. It's safe for concurrent use.
+type Flags struct {
+	path           *string
+	expectNonempty *bool
+
+	mu     sync.Mutex
+	files  map[string]bool
+	counts map[string]int
+}
+
+// RegisterFlags registers the manifest flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		path:           fs.String("outputManifest", "", "Write a JSON manifest of the output files (with their SHA-256 hashes and sizes) and counts to this path"),
+		expectNonempty: fs.Bool("expectNonempty", false, "Fail if the run wrote no output files"),
+	}
+}
+
+// Add records that the file at path was written. It's hashed when the run
+// finishes, so files written more than once are listed with their final
+// content.
+func (f *Flags) Add(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string]bool)
+	}
+	f.files[path] = true
+}
+
+// Count adds n to the count called name, e.g. the number of records written.
+func (f *Flags) Count(name string, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[name] += n
+}
+
+// Build returns the manifest of the files recorded so far.
+func (f *Flags) Build(tool string) (Manifest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := Manifest{Tool: tool, Files: []Entry{}}
+	for path := range f.files {
+		e, err := hashFile(path)
+		if err != nil {
+			return Manifest{}, err
+		}
+		m.Files = append(m.Files, e)
+	}
+	slices.SortFunc(m.Files, func(a, b Entry) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	if len(f.counts) > 0 {
+		m.Counts = maps.Clone(f.counts)
+	}
+
+	return m, nil
+}
+
+// Finish writes the manifest of the run to the -outputManifest path, if
+// one was given, and returns ErrEmpty if -expectNonempty was given and no
+// output files were recorded. The manifest is written either way, so an
+// empty run can be inspected.
+func (f *Flags) Finish(tool string) error {
+	m, err := f.Build(tool)
+	if err != nil {
+		return err
+	}
+	if *f.path != "" {
+		err := utility.WriteFileAtomic(*f.path, 0644, func(w io.Writer) error {
+			e := json.NewEncoder(w)
+			e.SetIndent("", "  ")
+			return e.Encode(m)
+		})
+		if err != nil {
+			return fmt.Errorf("writing manifest: %w", err)
+		}
+	}
+	if *f.expectNonempty && len(m.Files) == 0 {
+		return ErrEmpty
+	}
+
+	return nil
+}
+
+func hashFile(path string) (Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return Entry{}, fmt.Errorf("hashing %s: %w", path, err)
+	}
+
+	return Entry{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Size: n}, nil
+}
@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func newFlags(t *testing.T, args ...string) *Flags {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestFinish(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.json")
+	f := newFlags(t, "-outputManifest", manifestPath, "-expectNonempty")
+
+	b := filepath.Join(dir, "b.json")
+	a := filepath.Join(dir, "a.json")
+	for path, content := range map[string]string{b: "stale", a: "abc"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		f.Add(path)
+	}
+	// Files are hashed with their final content.
+	if err := os.WriteFile(b, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f.Add(b)
+	f.Count("records", 2)
+	f.Count("records", 1)
+
+	if err := f.Finish("test"); err != nil {
+		t.Fatalf("Finish() = %v", err)
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Manifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Manifest{
+		Tool: "test",
+		Files: []Entry{
+			{Path: a, SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", Size: 3},
+			{Path: b, SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Size: 0},
+		},
+		Counts: map[string]int{"records": 3},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("manifest mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFinishEmpty(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.json")
+
+	if err := newFlags(t, "-outputManifest", manifestPath).Finish("test"); err != nil {
+		t.Errorf("Finish() without -expectNonempty = %v, want nil", err)
+	}
+	err := newFlags(t, "-outputManifest", manifestPath, "-expectNonempty").Finish("test")
+	if !errors.Is(err, ErrEmpty) {
+		t.Errorf("Finish() = %v, want %v", err, ErrEmpty)
+	}
+	// The manifest of an empty run is still written.
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Manifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := gocmp.Diff(Manifest{Tool: "test", Files: []Entry{}}, got); diff != "" {
+		t.Errorf("manifest mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFinishMissingFile(t *testing.T) {
+	f := newFlags(t)
+	f.Add(filepath.Join(t.TempDir(), "missing.json"))
+	if err := f.Finish("test"); err == nil {
+		t.Error("Finish() = nil, want an error for a recorded file that doesn't exist")
+	}
+}
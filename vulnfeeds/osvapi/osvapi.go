@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package osvapi reads the records already published on osv.dev, with its
+// public API, so the converters can link the records they generate to those
+// of other sources.
+package osvapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// APIURL is the base URL of the osv.dev API.
+const APIURL = "https://api.osv.dev"
+
+// ErrNotFound is returned for IDs osv.dev has no record of.
+var ErrNotFound = errors.New("not found on osv.dev")
+
+// Client is an osv.dev API client. It is safe for concurrent use.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a client of the API at baseURL (APIURL if empty),
+// sending requests with http.DefaultClient, so they're limited and cached
+// as the downloaders' are.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = APIURL
+	}
+	return &Client{httpClient: http.DefaultClient, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Get returns the published record with the given ID (or alias), or
+// ErrNotFound.
+func (c *Client) Get(ctx context.Context, id string) (*vulns.Vulnerability, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/vulns/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+	default:
+		return nil, fmt.Errorf("getting %s: bad response: %v", id, resp.Status)
+	}
+	var v vulns.Vulnerability
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", id, err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,42 @@
+package osvapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/vulns/GHSA-xxxx-yyyy-zzzz":
+			io.WriteString(w, `{"id": "GHSA-xxxx-yyyy-zzzz", "aliases": ["CVE-2024-1234"], "affected": [{"ranges": [{"type": "GIT", "repo": "https://github.com/madler/zlib", "events": [{"introduced": "0"}, {"fixed": "abc123"}]}]}]}`)
+		case "/v1/vulns/BROKEN-1":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"code": 5, "message": "Bug not found."}`)
+		}
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL + "/")
+	ctx := context.Background()
+
+	v, err := client.Get(ctx, "GHSA-xxxx-yyyy-zzzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != "GHSA-xxxx-yyyy-zzzz" || len(v.Affected) != 1 || v.Affected[0].Ranges[0].Repo != "https://github.com/madler/zlib" {
+		t.Errorf("Get() = %+v, want the GHSA record", v)
+	}
+
+	if _, err := client.Get(ctx, "GHSA-none"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of an unknown ID error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.Get(ctx, "BROKEN-1"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a failing ID error = %v, want a bad response", err)
+	}
+}
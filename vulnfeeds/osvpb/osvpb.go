@@ -0,0 +1,273 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package osvpb encodes OSV records as protobuf: the osv.Vulnerability
+// message of osv/vulnerability.proto, as served by the OSV API
+// (osv_service_v1), generated into vulnerability.pb.go, for importers and
+// tests that need the protobuf form of records.
+//
+// The message has no database_specific field of ranges, so those of
+// vulns.AffectedRange are dropped, and timestamps are decoded in UTC.
+package osvpb
+
+//go:generate protoc -I ../.. --go_out=. --go_opt=module=github.com/google/osv/vulnfeeds/osvpb --go_opt=Mosv/vulnerability.proto=github.com/google/osv/vulnfeeds/osvpb;osvpb ../../osv/vulnerability.proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// Marshal encodes v as a binary osv.Vulnerability message. The encoding is
+// deterministic, so unchanged records encode to the same bytes.
+func Marshal(v *vulns.Vulnerability) ([]byte, error) {
+	pb, err := FromVulnerability(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.MarshalOptions{Deterministic: true}.Marshal(pb)
+}
+
+// Unmarshal decodes a binary osv.Vulnerability message.
+func Unmarshal(data []byte) (*vulns.Vulnerability, error) {
+	var pb Vulnerability
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return nil, err
+	}
+
+	return ToVulnerability(&pb), nil
+}
+
+// FromVulnerability converts v to its message. It fails on enum values the
+// message doesn't have, e.g. an unknown range type.
+func FromVulnerability(v *vulns.Vulnerability) (*Vulnerability, error) {
+	pb := &Vulnerability{
+		SchemaVersion: v.SchemaVersion,
+		Id:            v.ID,
+		Aliases:       v.Aliases,
+		Related:       v.Related,
+		Upstream:      v.Upstream,
+		Summary:       v.Summary,
+		Details:       v.Details,
+	}
+	var err error
+	if pb.Published, err = toTimestamp("published", v.Published); err != nil {
+		return nil, err
+	}
+	if pb.Modified, err = toTimestamp("modified", v.Modified); err != nil {
+		return nil, err
+	}
+	if pb.Withdrawn, err = toTimestamp("withdrawn", v.Withdrawn); err != nil {
+		return nil, err
+	}
+	if pb.Severity, err = toSeverities(v.Severity); err != nil {
+		return nil, err
+	}
+	if pb.DatabaseSpecific, err = toStruct(v.DatabaseSpecific); err != nil {
+		return nil, fmt.Errorf("database_specific: %w", err)
+	}
+	for i, affected := range v.Affected {
+		a, err := toAffected(affected)
+		if err != nil {
+			return nil, fmt.Errorf("affected[%d]: %w", i, err)
+		}
+		pb.Affected = append(pb.Affected, a)
+	}
+	for _, ref := range v.References {
+		refType, ok := Reference_Type_value[ref.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown reference type %q", ref.Type)
+		}
+		pb.References = append(pb.References, &Reference{Type: Reference_Type(refType), Url: ref.URL})
+	}
+	for _, credit := range v.Credits {
+		c := &Credit{Name: credit.Name, Contact: credit.Contact}
+		if credit.Type != "" {
+			creditType, ok := Credit_Type_value[credit.Type]
+			if !ok {
+				return nil, fmt.Errorf("unknown credit type %q", credit.Type)
+			}
+			c.Type = Credit_Type(creditType)
+		}
+		pb.Credits = append(pb.Credits, c)
+	}
+
+	return pb, nil
+}
+
+func toAffected(affected vulns.Affected) (*Affected, error) {
+	a := &Affected{Versions: affected.Versions}
+	if p := affected.Package; p != nil {
+		a.Package = &Package{Name: p.Name, Ecosystem: p.Ecosystem, Purl: p.Purl}
+	}
+	var err error
+	if a.Severity, err = toSeverities(affected.Severity); err != nil {
+		return nil, err
+	}
+	if a.EcosystemSpecific, err = toStruct(affected.EcosystemSpecific); err != nil {
+		return nil, fmt.Errorf("ecosystem_specific: %w", err)
+	}
+	if a.DatabaseSpecific, err = toStruct(affected.DatabaseSpecific); err != nil {
+		return nil, fmt.Errorf("database_specific: %w", err)
+	}
+	for _, r := range affected.Ranges {
+		rangeType, ok := Range_Type_value[r.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown range type %q", r.Type)
+		}
+		pbRange := &Range{Type: Range_Type(rangeType), Repo: r.Repo}
+		for _, e := range r.Events {
+			pbRange.Events = append(pbRange.Events, &Event{
+				Introduced:   e.Introduced,
+				Fixed:        e.Fixed,
+				Limit:        e.Limit,
+				LastAffected: e.LastAffected,
+			})
+		}
+		a.Ranges = append(a.Ranges, pbRange)
+	}
+
+	return a, nil
+}
+
+func toSeverities(severities []vulns.Severity) ([]*Severity, error) {
+	var pbSeverities []*Severity
+	for _, s := range severities {
+		severityType, ok := Severity_Type_value[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown severity type %q", s.Type)
+		}
+		pbSeverities = append(pbSeverities, &Severity{Type: Severity_Type(severityType), Score: s.Score})
+	}
+
+	return pbSeverities, nil
+}
+
+func toTimestamp(field string, s string) (*timestamppb.Timestamp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+
+	return timestamppb.New(t), nil
+}
+
+// toStruct converts a JSON object to a Struct, via its JSON encoding, as
+// it may hold any values that encode as JSON (e.g. []string), which
+// structpb.NewStruct doesn't take.
+func toStruct(m map[string]any) (*structpb.Struct, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ToVulnerability converts a message back to a record.
+func ToVulnerability(pb *Vulnerability) *vulns.Vulnerability {
+	v := &vulns.Vulnerability{
+		SchemaVersion:    pb.GetSchemaVersion(),
+		ID:               pb.GetId(),
+		Aliases:          pb.GetAliases(),
+		Related:          pb.GetRelated(),
+		Upstream:         pb.GetUpstream(),
+		Summary:          pb.GetSummary(),
+		Details:          pb.GetDetails(),
+		Published:        fromTimestamp(pb.GetPublished()),
+		Modified:         fromTimestamp(pb.GetModified()),
+		Withdrawn:        fromTimestamp(pb.GetWithdrawn()),
+		Severity:         fromSeverities(pb.GetSeverity()),
+		DatabaseSpecific: fromStruct(pb.GetDatabaseSpecific()),
+	}
+	for _, a := range pb.GetAffected() {
+		affected := vulns.Affected{
+			Severity:          fromSeverities(a.GetSeverity()),
+			Versions:          a.GetVersions(),
+			EcosystemSpecific: fromStruct(a.GetEcosystemSpecific()),
+			DatabaseSpecific:  fromStruct(a.GetDatabaseSpecific()),
+		}
+		if p := a.GetPackage(); p != nil {
+			affected.Package = &vulns.AffectedPackage{Name: p.GetName(), Ecosystem: p.GetEcosystem(), Purl: p.GetPurl()}
+		}
+		for _, r := range a.GetRanges() {
+			affectedRange := vulns.AffectedRange{Type: r.GetType().String(), Repo: r.GetRepo()}
+			for _, e := range r.GetEvents() {
+				affectedRange.Events = append(affectedRange.Events, vulns.Event{
+					Introduced:   e.GetIntroduced(),
+					Fixed:        e.GetFixed(),
+					Limit:        e.GetLimit(),
+					LastAffected: e.GetLastAffected(),
+				})
+			}
+			affected.Ranges = append(affected.Ranges, affectedRange)
+		}
+		v.Affected = append(v.Affected, affected)
+	}
+	for _, ref := range pb.GetReferences() {
+		v.References = append(v.References, vulns.Reference{Type: ref.GetType().String(), URL: ref.GetUrl()})
+	}
+	for _, c := range pb.GetCredits() {
+		credit := vulns.Credit{Name: c.GetName(), Contact: c.GetContact()}
+		if c.GetType() != Credit_UNSPECIFIED {
+			credit.Type = c.GetType().String()
+		}
+		v.Credits = append(v.Credits, credit)
+	}
+
+	return v
+}
+
+func fromSeverities(pbSeverities []*Severity) []vulns.Severity {
+	var severities []vulns.Severity
+	for _, s := range pbSeverities {
+		severities = append(severities, vulns.Severity{Type: s.GetType().String(), Score: s.GetScore()})
+	}
+
+	return severities
+}
+
+func fromTimestamp(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.AsTime().UTC().Format(time.RFC3339Nano)
+}
+
+func fromStruct(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+
+	return s.AsMap()
+}
@@ -0,0 +1,153 @@
+package osvpb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// loadRecords loads the OSV records used as test cases, by file name.
+func loadRecords(t *testing.T) map[string]*vulns.Vulnerability {
+	t.Helper()
+	var paths []string
+	for _, pattern := range []string{"../test_data/golden/vulns/*.json", "../test_data/osv-schema/*.json"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no test records found")
+	}
+	records := make(map[string]*vulns.Vulnerability)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		records[filepath.Base(path)] = &v
+	}
+	return records
+}
+
+// jsonObject returns the JSON encoding of v as a generic object, so records
+// are compared as JSON, whatever types their database_specific values were
+// decoded as.
+func jsonObject(t *testing.T, v *vulns.Vulnerability) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatal(err)
+	}
+	return object
+}
+
+// representable returns v without the fields the message doesn't have.
+func representable(v *vulns.Vulnerability) *vulns.Vulnerability {
+	w := *v
+	w.Affected = nil
+	for _, affected := range v.Affected {
+		var ranges []vulns.AffectedRange
+		for _, r := range affected.Ranges {
+			r.DatabaseSpecific = nil
+			ranges = append(ranges, r)
+		}
+		affected.Ranges = ranges
+		w.Affected = append(w.Affected, affected)
+	}
+	return &w
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, v := range loadRecords(t) {
+		t.Run(name, func(t *testing.T) {
+			data, err := Marshal(v)
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			got, err := Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if diff := gocmp.Diff(jsonObject(t, representable(v)), jsonObject(t, got)); diff != "" {
+				t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestProtoJSON checks the proto JSON encoding of the messages decodes to
+// the same records as the OSV JSON encoding, i.e. that the message's fields
+// and enum names match the OSV schema.
+func TestProtoJSON(t *testing.T) {
+	for name, v := range loadRecords(t) {
+		t.Run(name, func(t *testing.T) {
+			pb, err := FromVulnerability(v)
+			if err != nil {
+				t.Fatalf("FromVulnerability() error: %v", err)
+			}
+			data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(pb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got vulns.Vulnerability
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("failed to decode %s: %v", data, err)
+			}
+			if diff := gocmp.Diff(jsonObject(t, representable(v)), jsonObject(t, &got)); diff != "" {
+				t.Errorf("proto JSON mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	v := &vulns.Vulnerability{
+		ID: "CVE-2024-1234",
+		DatabaseSpecific: map[string]any{
+			"a": "1", "b": []string{"2", "3"}, "c": map[string]any{"d": 4, "e": true},
+		},
+	}
+	first, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		again, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(first) {
+			t.Fatal("Marshal() isn't deterministic")
+		}
+	}
+}
+
+func TestFromVulnerabilityUnknownEnums(t *testing.T) {
+	for name, v := range map[string]*vulns.Vulnerability{
+		"range":     {Affected: []vulns.Affected{{Ranges: []vulns.AffectedRange{{Type: "BOGUS"}}}}},
+		"severity":  {Severity: []vulns.Severity{{Type: "BOGUS"}}},
+		"reference": {References: []vulns.Reference{{Type: "BOGUS"}}},
+		"credit":    {Credits: []vulns.Credit{{Name: "someone", Type: "BOGUS"}}},
+		"timestamp": {Modified: "yesterday"},
+	} {
+		if _, err := FromVulnerability(v); err == nil {
+			t.Errorf("FromVulnerability() of an invalid %s = nil error, want an error", name)
+		}
+	}
+}
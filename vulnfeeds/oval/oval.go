@@ -0,0 +1,298 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oval parses the OVAL definitions distributions (e.g. Debian, Ubuntu,
+// SUSE and Oracle Linux) publish their vulnerabilities in, and extracts the
+// packages each definition affects and their fixed versions.
+package oval
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+var cveIDRegexp = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// Definitions is the subset of an OVAL definitions file needed to recover
+// the fixed version of each package for each definition.
+type Definitions struct {
+	Definitions []Definition `xml:"definitions>definition"`
+	Tests       struct {
+		DPKGInfo []Test `xml:"dpkginfo_test"`
+		RPMInfo  []Test `xml:"rpminfo_test"`
+	} `xml:"tests"`
+	Objects struct {
+		DPKGInfo []Object `xml:"dpkginfo_object"`
+		RPMInfo  []Object `xml:"rpminfo_object"`
+	} `xml:"objects"`
+	States struct {
+		DPKGInfo []State `xml:"dpkginfo_state"`
+		RPMInfo  []State `xml:"rpminfo_state"`
+	} `xml:"states"`
+	Variables []struct {
+		ID     string   `xml:"id,attr"`
+		Values []string `xml:"value"`
+	} `xml:"variables>constant_variable"`
+}
+
+// Definition is an OVAL definition, e.g. of a vulnerability or the patch
+// (advisory) fixing it.
+type Definition struct {
+	ID       string `xml:"id,attr"`
+	Class    string `xml:"class,attr"`
+	Metadata struct {
+		Title      string `xml:"title"`
+		References []struct {
+			Source string `xml:"source,attr"`
+			RefID  string `xml:"ref_id,attr"`
+		} `xml:"reference"`
+		// Advisory CVEs, as published by Red Hat, Oracle Linux and SUSE.
+		AdvisoryCVEs []string `xml:"advisory>cve"`
+		Severity     string   `xml:"advisory>severity"`
+	} `xml:"metadata"`
+	Criteria Criteria `xml:"criteria"`
+}
+
+// Criteria is a (possibly nested) criteria tree of an OVAL definition.
+type Criteria struct {
+	Operator  string     `xml:"operator,attr"`
+	Negate    bool       `xml:"negate,attr"`
+	Criteria  []Criteria `xml:"criteria"`
+	Criterion []struct {
+		TestRef string `xml:"test_ref,attr"`
+		Comment string `xml:"comment,attr"`
+		Negate  bool   `xml:"negate,attr"`
+	} `xml:"criterion"`
+	ExtendDefinitions []struct {
+		DefinitionRef string `xml:"definition_ref,attr"`
+		Comment       string `xml:"comment,attr"`
+		Negate        bool   `xml:"negate,attr"`
+	} `xml:"extend_definition"`
+}
+
+// Test checks the state of the packages matched by an object.
+type Test struct {
+	ID      string `xml:"id,attr"`
+	Comment string `xml:"comment,attr"`
+	Object  struct {
+		Ref string `xml:"object_ref,attr"`
+	} `xml:"object"`
+	State struct {
+		Ref string `xml:"state_ref,attr"`
+	} `xml:"state"`
+}
+
+// Object matches packages by name, either directly or through a variable.
+type Object struct {
+	ID   string `xml:"id,attr"`
+	Name struct {
+		VarRef string `xml:"var_ref,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"name"`
+}
+
+// State is the version a package is checked against.
+type State struct {
+	ID      string       `xml:"id,attr"`
+	EVR     StateVersion `xml:"evr"`
+	Version StateVersion `xml:"version"`
+}
+
+// StateVersion is a version and how it's compared.
+type StateVersion struct {
+	Operation string `xml:"operation,attr"`
+	Value     string `xml:",chardata"`
+}
+
+// fixed returns the fixed version a state checks for, if it checks that a
+// package is earlier than it.
+func (s State) fixed() (string, bool) {
+	for _, v := range []StateVersion{s.EVR, s.Version} {
+		if v.Operation == "less than" && strings.TrimSpace(v.Value) != "" {
+			return strings.TrimSpace(v.Value), true
+		}
+	}
+	return "", false
+}
+
+// Parse decodes OVAL definitions.
+func Parse(r io.Reader) (*Definitions, error) {
+	var defs Definitions
+	if err := xml.NewDecoder(r).Decode(&defs); err != nil {
+		return nil, err
+	}
+	return &defs, nil
+}
+
+// CVEs returns the CVEs a definition is about.
+func (d Definition) CVEs() []string {
+	var cveIDs []string
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if cveIDRegexp.MatchString(id) && !slices.Contains(cveIDs, id) {
+			cveIDs = append(cveIDs, id)
+		}
+	}
+	for _, ref := range d.Metadata.References {
+		if ref.Source == "CVE" {
+			add(ref.RefID)
+		}
+	}
+	for _, id := range d.Metadata.AdvisoryCVEs {
+		add(id)
+	}
+	if len(cveIDs) == 0 {
+		// Some definitions only carry the CVE ID in the title.
+		if fields := strings.Fields(d.Metadata.Title); len(fields) > 0 {
+			add(fields[0])
+		}
+	}
+	return cveIDs
+}
+
+// Affected is a package a definition affects.
+type Affected struct {
+	Definition string
+	Class      string
+	CVEs       []string
+	// Kind is the kind of package, "dpkg" or "rpm".
+	Kind    string
+	Package string
+	// Fixed is the first version not affected, or empty if every version
+	// of the package is.
+	Fixed string
+	// Platforms are the comments of the other criteria the package's test
+	// is combined with, e.g. "Debian 12 is installed", identifying the
+	// product it's affected in.
+	Platforms []string
+}
+
+// packageTest is a test of the version of a package.
+type packageTest struct {
+	kind     string
+	packages []string
+	fixed    string
+	// checksVersion is whether the test checks that packages are earlier
+	// than a fixed version, rather than only installed.
+	checksVersion bool
+}
+
+// packageTests resolves the tests of package versions.
+func (d *Definitions) packageTests() map[string]packageTest {
+	variables := make(map[string][]string, len(d.Variables))
+	for _, v := range d.Variables {
+		variables[v.ID] = v.Values
+	}
+	tests := make(map[string]packageTest)
+	resolve := func(kind string, ts []Test, os []Object, ss []State) {
+		objects := make(map[string][]string, len(os))
+		for _, o := range os {
+			if o.Name.VarRef != "" {
+				objects[o.ID] = variables[o.Name.VarRef]
+			} else if name := strings.TrimSpace(o.Name.Value); name != "" {
+				objects[o.ID] = []string{name}
+			}
+		}
+		states := make(map[string]State, len(ss))
+		for _, s := range ss {
+			states[s.ID] = s
+		}
+		for _, t := range ts {
+			packages, ok := objects[t.Object.Ref]
+			if !ok {
+				continue
+			}
+			test := packageTest{kind: kind, packages: packages}
+			if t.State.Ref != "" {
+				state, ok := states[t.State.Ref]
+				if !ok {
+					continue
+				}
+				if test.fixed, test.checksVersion = state.fixed(); !test.checksVersion {
+					// Other comparisons, such as the version of a
+					// release package, identify platforms.
+					continue
+				}
+			}
+			tests[t.ID] = test
+		}
+	}
+	resolve("dpkg", d.Tests.DPKGInfo, d.Objects.DPKGInfo, d.States.DPKGInfo)
+	resolve("rpm", d.Tests.RPMInfo, d.Objects.RPMInfo, d.States.RPMInfo)
+	return tests
+}
+
+// Affected evaluates the criteria of the vulnerability and patch definitions,
+// returning the packages they affect. Every test of a package being earlier
+// than a version gives a fixed version, and every test of a package being
+// installed without a version check gives a package affected in every
+// version. The comments of the other criteria they're ANDed with are
+// returned as their platforms. Negated criteria are skipped.
+func (d *Definitions) Affected() []Affected {
+	tests := d.packageTests()
+	var affected []Affected
+	for _, def := range d.Definitions {
+		if def.Class != "vulnerability" && def.Class != "patch" {
+			continue
+		}
+		cveIDs := def.CVEs()
+		var walk func(c Criteria, platforms []string)
+		walk = func(c Criteria, platforms []string) {
+			if c.Negate {
+				return
+			}
+			if !strings.EqualFold(c.Operator, "OR") {
+				// Every other criterion must hold too, so those that
+				// aren't package tests identify the platform.
+				platforms = slices.Clone(platforms)
+				for _, criterion := range c.Criterion {
+					if _, ok := tests[criterion.TestRef]; !ok && !criterion.Negate && criterion.Comment != "" {
+						platforms = append(platforms, criterion.Comment)
+					}
+				}
+				for _, ext := range c.ExtendDefinitions {
+					if !ext.Negate && ext.Comment != "" {
+						platforms = append(platforms, ext.Comment)
+					}
+				}
+			}
+			for _, criterion := range c.Criterion {
+				test, ok := tests[criterion.TestRef]
+				if !ok || criterion.Negate {
+					continue
+				}
+				for _, pkg := range test.packages {
+					affected = append(affected, Affected{
+						Definition: def.ID,
+						Class:      def.Class,
+						CVEs:       cveIDs,
+						Kind:       test.kind,
+						Package:    pkg,
+						Fixed:      test.fixed,
+						Platforms:  platforms,
+					})
+				}
+			}
+			for _, sub := range c.Criteria {
+				walk(sub, platforms)
+			}
+		}
+		walk(def.Criteria, nil)
+	}
+	return affected
+}
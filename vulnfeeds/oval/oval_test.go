@@ -0,0 +1,121 @@
+package oval
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func loadDefinitions(t *testing.T, path string) *Definitions {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	defs, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse(%s) failed: %v", path, err)
+	}
+	return defs
+}
+
+func TestAffected(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []Affected
+	}{
+		{
+			name: "rpminfo",
+			path: "../test_data/oval/suse-oval-mock.xml",
+			want: []Affected{
+				{
+					Definition: "oval:org.opensuse.security:def:202321490",
+					Class:      "vulnerability",
+					CVEs:       []string{"CVE-2023-21490"},
+					Kind:       "rpm",
+					Package:    "libfoo1",
+					Fixed:      "0:1.2.3-150500.3.6.1",
+					Platforms:  []string{"SUSE Linux Enterprise Server 15 SP5 is installed"},
+				},
+				{
+					Definition: "oval:org.opensuse.security:def:202321490",
+					Class:      "vulnerability",
+					CVEs:       []string{"CVE-2023-21490"},
+					Kind:       "rpm",
+					Package:    "foo-tools",
+					Fixed:      "0:1.2.3-150500.3.6.1",
+					Platforms:  []string{"SUSE Linux Enterprise Server 15 SP5 is installed"},
+				},
+				{
+					Definition: "oval:org.opensuse.security:def:202321490",
+					Class:      "vulnerability",
+					CVEs:       []string{"CVE-2023-21490"},
+					Kind:       "rpm",
+					Package:    "bar",
+					Platforms:  []string{"SUSE Linux Enterprise Server 15 SP6 is installed"},
+				},
+			},
+		},
+		{
+			name: "dpkginfo with variables",
+			path: "../test_data/oval/ubuntu-oval-mock.xml",
+			want: []Affected{
+				{
+					Definition: "oval:com.ubuntu.noble:def:68001000000",
+					Class:      "patch",
+					CVEs:       []string{"CVE-2024-1111", "CVE-2024-2222"},
+					Kind:       "dpkg",
+					Package:    "libexample1",
+					Fixed:      "0:2.4.1-1ubuntu0.1",
+					Platforms:  []string{"Ubuntu 24.04 LTS (noble) is installed."},
+				},
+				{
+					Definition: "oval:com.ubuntu.noble:def:68001000000",
+					Class:      "patch",
+					CVEs:       []string{"CVE-2024-1111", "CVE-2024-2222"},
+					Kind:       "dpkg",
+					Package:    "example-utils",
+					Fixed:      "0:2.4.1-1ubuntu0.1",
+					Platforms:  []string{"Ubuntu 24.04 LTS (noble) is installed."},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := loadDefinitions(t, tc.path).Affected()
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Affected() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefinitionCVEs(t *testing.T) {
+	defs, err := Parse(strings.NewReader(`<oval_definitions><definitions>
+<definition id="1" class="vulnerability"><metadata><title>CVE-2018-1000500 busybox</title></metadata></definition>
+<definition id="2" class="vulnerability"><metadata><title>DSA-1 foo</title><reference source="CVE" ref_id="CVE-2020-1234"/><reference source="DSA" ref_id="DSA-1"/></metadata></definition>
+<definition id="3" class="vulnerability"><metadata><title>DSA-2 foo</title></metadata></definition>
+</definitions></oval_definitions>`))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	var got [][]string
+	for _, def := range defs.Definitions {
+		got = append(got, def.CVEs())
+	}
+	want := [][]string{{"CVE-2018-1000500"}, {"CVE-2020-1234"}, nil}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("CVEs() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<oval_definitions>")); err == nil {
+		t.Errorf("Parse() of truncated XML succeeded, want error")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkgindex verifies the fixed versions converters emit against the
+// package indexes of their distributions (e.g. Alpine's APKINDEX, or Debian's
+// Sources files), as security feeds occasionally list fixes that were never
+// published.
+package pkgindex
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// Index maps each source package of a release to the latest version it
+// ships.
+type Index map[string]string
+
+// Add records that the release ships version of pkg, keeping the latest
+// version by compare.
+func (ix Index) Add(pkg, version string, compare vulns.Comparator) {
+	existing, ok := ix[pkg]
+	if !ok {
+		ix[pkg] = version
+		return
+	}
+	if c, err := compare(version, existing); err == nil && c > 0 {
+		ix[pkg] = version
+	}
+}
+
+// Finding is a fixed version that couldn't be verified.
+type Finding struct {
+	CVE       string
+	Package   string
+	Ecosystem string
+	Fixed     string
+	// Shipped is the latest version in the index, if the package is in it.
+	Shipped string
+	// Downgraded is set if the fix was replaced by the shipped version
+	// being the last affected.
+	Downgraded bool
+}
+
+func (f Finding) String() string {
+	if f.Shipped == "" {
+		return fmt.Sprintf("%s %s (%s): fixed in %s, but the package isn't in the index", f.CVE, f.Package, f.Ecosystem, f.Fixed)
+	}
+	s := fmt.Sprintf("%s %s (%s): fixed in %s, but the index only has %s", f.CVE, f.Package, f.Ecosystem, f.Fixed, f.Shipped)
+	if f.Downgraded {
+		s += ", now the last affected version"
+	}
+	return s
+}
+
+// Summary summarizes the fixed versions a Verifier checked.
+type Summary struct {
+	// Verified counts the fixed versions the index has (or a later one).
+	Verified int
+	// Unverifiable counts the fixed versions of ecosystems without an index
+	// or a version comparator.
+	Unverifiable int
+	// Missing are the fixes of packages not in the index.
+	Missing []Finding
+	// Unpublished are the fixes later than the version in the index.
+	Unpublished []Finding
+}
+
+// Logger logs a Summary.
+type Logger interface {
+	Infof(format string, a ...any)
+	Warnf(format string, a ...any)
+}
+
+// Report logs each finding, then the counts of the summary.
+func (s Summary) Report(logger Logger) {
+	for _, f := range s.Missing {
+		logger.Warnf("Unverified fix: %s", f)
+	}
+	downgraded := 0
+	for _, f := range s.Unpublished {
+		logger.Warnf("Unpublished fix: %s", f)
+		if f.Downgraded {
+			downgraded++
+		}
+	}
+	logger.Infof("Verified fixed versions against package indexes: %d published, %d of packages not in the index, %d unpublished (%d downgraded), %d unverifiable",
+		s.Verified, len(s.Missing), len(s.Unpublished), downgraded, s.Unverifiable)
+}
+
+// Verifier verifies fixed versions against the indexes of their ecosystems,
+// loading each index once. It's safe for concurrent use.
+type Verifier struct {
+	// Load loads the index of an ecosystem, e.g. "Alpine:v3.18".
+	Load func(ecosystem string) (Index, error)
+	// Downgrade replaces unpublished fixes with the version in the index
+	// being the last affected, as everything shipped so far is affected.
+	Downgrade bool
+	Logger    Logger
+
+	mu      sync.Mutex
+	indexes map[string]Index
+	summary Summary
+}
+
+// index returns the index of ecosystem, or nil if it couldn't be loaded.
+func (v *Verifier) index(ecosystem string) Index {
+	if v.indexes == nil {
+		v.indexes = make(map[string]Index)
+	}
+	ix, ok := v.indexes[ecosystem]
+	if !ok {
+		var err error
+		ix, err = v.Load(ecosystem)
+		if err != nil {
+			if v.Logger != nil {
+				v.Logger.Warnf("Not verifying the fixed versions of %s: %s", ecosystem, err)
+			}
+			ix = nil
+		}
+		v.indexes[ecosystem] = ix
+	}
+	return ix
+}
+
+// Verify verifies the fixed versions of pkgInfo, a package affected by cve,
+// downgrading unpublished fixes if v.Downgrade is set.
+func (v *Verifier) Verify(cve string, pkgInfo *vulns.PackageInfo) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, av := range pkgInfo.VersionInfo.AffectedVersions {
+		if av.Fixed == "" {
+			continue
+		}
+		compare, ok := vulns.ComparatorFor(pkgInfo.Ecosystem)
+		ix := v.index(pkgInfo.Ecosystem)
+		if !ok || ix == nil {
+			v.summary.Unverifiable++
+			continue
+		}
+		finding := Finding{CVE: cve, Package: pkgInfo.PkgName, Ecosystem: pkgInfo.Ecosystem, Fixed: av.Fixed}
+		shipped, ok := ix[pkgInfo.PkgName]
+		if !ok {
+			v.summary.Missing = append(v.summary.Missing, finding)
+			continue
+		}
+		c, err := compare(shipped, av.Fixed)
+		if err != nil {
+			v.summary.Unverifiable++
+			continue
+		}
+		if c >= 0 {
+			v.summary.Verified++
+			continue
+		}
+		finding.Shipped = shipped
+		if v.Downgrade {
+			pkgInfo.VersionInfo.AffectedVersions[i].Fixed = ""
+			pkgInfo.VersionInfo.AffectedVersions[i].LastAffected = shipped
+			finding.Downgraded = true
+		}
+		v.summary.Unpublished = append(v.summary.Unpublished, finding)
+	}
+}
+
+// Summary returns the summary of the fixed versions verified so far, with
+// the findings ordered by CVE, package and ecosystem.
+func (v *Verifier) Summary() Summary {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s := v.summary
+	s.Missing = sortFindings(s.Missing)
+	s.Unpublished = sortFindings(s.Unpublished)
+	return s
+}
+
+func sortFindings(findings []Finding) []Finding {
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.CVE != b.CVE {
+			return a.CVE < b.CVE
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Ecosystem < b.Ecosystem
+	})
+	return sorted
+}
+
+// Flags are the command line flags configuring the verification of fixed
+// versions, shared by the converters.
+type Flags struct {
+	verify    *bool
+	downgrade *bool
+}
+
+// RegisterFlags registers the verification flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		verify:    fs.Bool("verify_fixed", false, "Verify the fixed versions against the distribution's package index, reporting those never published"),
+		downgrade: fs.Bool("verify_fixed_downgrade", false, "With -verify_fixed, replace unpublished fixed versions with the published version as the last affected"),
+	}
+}
+
+// NewVerifier returns a Verifier configured by the flags loading indexes
+// with load, or nil if verification isn't enabled.
+func (f *Flags) NewVerifier(load func(ecosystem string) (Index, error), logger Logger) *Verifier {
+	if !*f.verify {
+		return nil
+	}
+	return &Verifier{Load: load, Downgrade: *f.downgrade, Logger: logger}
+}
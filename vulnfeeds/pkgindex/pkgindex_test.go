@@ -0,0 +1,75 @@
+package pkgindex
+
+import (
+	"errors"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vercmp"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestIndexAdd(t *testing.T) {
+	ix := make(Index)
+	for _, version := range []string{"1.2-r1", "1.10-r0", "1.9-r3"} {
+		ix.Add("pkg", version, vercmp.APK)
+	}
+	if got := ix["pkg"]; got != "1.10-r0" {
+		t.Errorf("Index has %s, want the latest version 1.10-r0", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	loads := 0
+	load := func(ecosystem string) (Index, error) {
+		loads++
+		if ecosystem != "Alpine:v3.18" {
+			return nil, errors.New("no index")
+		}
+		return Index{"published": "1.2-r1", "unpublished": "1.2-r0"}, nil
+	}
+	pkgInfo := func(name, ecosystem, fixed string) vulns.PackageInfo {
+		return vulns.PackageInfo{
+			PkgName:     name,
+			Ecosystem:   ecosystem,
+			VersionInfo: cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Introduced: "0", Fixed: fixed}}},
+		}
+	}
+
+	for _, downgrade := range []bool{false, true} {
+		v := &Verifier{Load: load, Downgrade: downgrade}
+		pkgInfos := []vulns.PackageInfo{
+			pkgInfo("published", "Alpine:v3.18", "1.2-r1"),
+			pkgInfo("unpublished", "Alpine:v3.18", "1.2-r1"),
+			pkgInfo("removed", "Alpine:v3.18", "1.0-r0"),
+			pkgInfo("published", "Alpine:v3.17", "1.2-r1"),
+		}
+		loads = 0
+		for i := range pkgInfos {
+			v.Verify("CVE-2024-1234", &pkgInfos[i])
+		}
+		if loads != 2 {
+			t.Errorf("loaded %d indexes, want each of the 2 once", loads)
+		}
+
+		want := Summary{
+			Verified:     1,
+			Unverifiable: 1,
+			Missing:      []Finding{{CVE: "CVE-2024-1234", Package: "removed", Ecosystem: "Alpine:v3.18", Fixed: "1.0-r0"}},
+			Unpublished:  []Finding{{CVE: "CVE-2024-1234", Package: "unpublished", Ecosystem: "Alpine:v3.18", Fixed: "1.2-r1", Shipped: "1.2-r0", Downgraded: downgrade}},
+		}
+		if diff := gocmp.Diff(want, v.Summary()); diff != "" {
+			t.Errorf("Summary() mismatch (-want +got):\n%s", diff)
+		}
+
+		wantAffected := []cves.AffectedVersion{{Introduced: "0", Fixed: "1.2-r1"}}
+		if downgrade {
+			wantAffected = []cves.AffectedVersion{{Introduced: "0", LastAffected: "1.2-r0"}}
+		}
+		if diff := gocmp.Diff(wantAffected, pkgInfos[1].VersionInfo.AffectedVersions); diff != "" {
+			t.Errorf("downgrade %v: unpublished affected versions mismatch (-want +got):\n%s", downgrade, diff)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight checks the pipeline's environment: that its feeds are
+// reachable and still parse, and that its credentials, buckets, Datastore
+// kinds and output paths are usable. check-config runs the checks at deploy
+// time and doctor before each run.
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+
+	"github.com/google/osv/vulnfeeds/utility"
+)
+
+// probeObject is written to, and deleted from, each bucket and output path
+// checked.
+const probeObject = ".preflight-probe"
+
+// Check is a single check of the pipeline's environment.
+type Check struct {
+	// Kind groups related checks in the report, e.g. "feed".
+	Kind   string
+	Target string
+	Run    func(ctx context.Context) error
+}
+
+// Result is the outcome of a check, as reported.
+type Result struct {
+	Kind       string `json:"kind"`
+	Target     string `json:"target"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of a run of checks.
+type Report struct {
+	Build   string   `json:"build"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+// Run runs each check with the given timeout, in order.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{Build: utility.ReadBuildInfo().String(), Results: make([]Result, 0, len(checks))}
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := c.Run(checkCtx)
+		cancel()
+		r := Result{Kind: c.Kind, Target: c.Target, OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			r.Error = err.Error()
+			report.Failed++
+		} else {
+			report.Passed++
+		}
+		report.Results = append(report.Results, r)
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteText writes a line per check of the report and a summary.
+func WriteText(w io.Writer, report Report) error {
+	for _, r := range report.Results {
+		var err error
+		if r.OK {
+			_, err = fmt.Fprintf(w, "ok   %-14s %s (%dms)\n", r.Kind, r.Target, r.DurationMS)
+		} else {
+			_, err = fmt.Fprintf(w, "FAIL %-14s %s: %s\n", r.Kind, r.Target, r.Error)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d checks failed\n", report.Failed, len(report.Results))
+	return err
+}
+
+// get requests url, failing unless the response is OK.
+func get(ctx context.Context, client *http.Client, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return res, nil
+}
+
+// FeedCheck checks a feed URL is reachable.
+func FeedCheck(client *http.Client, url string) Check {
+	return Check{Kind: "feed", Target: url, Run: func(ctx context.Context) error {
+		res, err := get(ctx, client, url, nil)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}}
+}
+
+// NVDAPIKeyCheck checks the NVD API accepts an API key.
+func NVDAPIKeyCheck(client *http.Client, endpoint, apiKey string) Check {
+	return Check{Kind: "credentials", Target: "NVD API key", Run: func(ctx context.Context) error {
+		header := http.Header{}
+		header.Set("apiKey", apiKey)
+		res, err := get(ctx, client, endpoint+"?resultsPerPage=1", header)
+		if err != nil {
+			return fmt.Errorf("NVD API rejected the key: %w", err)
+		}
+		return res.Body.Close()
+	}}
+}
+
+// SecretCheck checks a Secret Manager secret version is accessible.
+func SecretCheck(secretVersion string) Check {
+	return Check{Kind: "credentials", Target: secretVersion, Run: func(ctx context.Context) error {
+		secret, err := utility.GetSecret(secretVersion)
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			return errors.New("secret is empty")
+		}
+		return nil
+	}}
+}
+
+// BucketCheck checks a bucket is writable, by writing and deleting a probe
+// object.
+func BucketCheck(client func() (*storage.Client, error), bucket string) Check {
+	return Check{Kind: "bucket", Target: "gs://" + bucket, Run: func(ctx context.Context) error {
+		cl, err := client()
+		if err != nil {
+			return fmt.Errorf("failed to create storage client: %w", err)
+		}
+		obj := cl.Bucket(bucket).Object(probeObject)
+		w := obj.NewWriter(ctx)
+		if _, err := io.WriteString(w, utility.ReadBuildInfo().String()); err != nil {
+			w.Close()
+			return fmt.Errorf("failed to write: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to write: %w", err)
+		}
+		if err := obj.Delete(ctx); err != nil {
+			return fmt.Errorf("failed to delete probe object: %w", err)
+		}
+		return nil
+	}}
+}
+
+// DatastoreCheck checks entities of a Datastore kind can be read.
+func DatastoreCheck(client func() (*datastore.Client, error), kind string) Check {
+	return Check{Kind: "datastore", Target: kind, Run: func(ctx context.Context) error {
+		cl, err := client()
+		if err != nil {
+			return fmt.Errorf("failed to create datastore client: %w", err)
+		}
+		q := datastore.NewQuery(kind).KeysOnly().Limit(1)
+		if _, err := cl.GetAll(ctx, q, nil); err != nil {
+			return fmt.Errorf("failed to query: %w", err)
+		}
+		return nil
+	}}
+}
+
+// OutputPathCheck checks an output path can be created and written to.
+func OutputPathCheck(dir string) Check {
+	return Check{Kind: "output path", Target: dir, Run: func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(dir, probeObject)
+		if err != nil {
+			return err
+		}
+		name := f.Name()
+		_, err = f.WriteString("probe")
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if removeErr := os.Remove(name); err == nil {
+			err = removeErr
+		}
+		return err
+	}}
+}
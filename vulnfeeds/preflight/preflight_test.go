@@ -0,0 +1,187 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func serve(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFeedCheck(t *testing.T) {
+	srv := serve(t, "")
+	if err := FeedCheck(srv.Client(), srv.URL+"/feed").Run(context.Background()); err != nil {
+		t.Errorf("FeedCheck() of a reachable feed = %v", err)
+	}
+	if err := FeedCheck(srv.Client(), srv.URL+"/missing").Run(context.Background()); err == nil {
+		t.Errorf("FeedCheck() of a missing feed succeeded")
+	}
+}
+
+func TestNVDAPIKeyCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apiKey") != "good" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	if err := NVDAPIKeyCheck(srv.Client(), srv.URL, "good").Run(context.Background()); err != nil {
+		t.Errorf("NVDAPIKeyCheck() of a good key = %v", err)
+	}
+	if err := NVDAPIKeyCheck(srv.Client(), srv.URL, "bad").Run(context.Background()); err == nil {
+		t.Errorf("NVDAPIKeyCheck() of a bad key succeeded")
+	}
+}
+
+func TestOutputPathCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "parts", "alpine")
+	if err := OutputPathCheck(dir).Run(context.Background()); err != nil {
+		t.Fatalf("OutputPathCheck() = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("OutputPathCheck() left %d files behind", len(entries))
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := OutputPathCheck(file).Run(context.Background()); err == nil {
+		t.Errorf("OutputPathCheck() of a file succeeded")
+	}
+}
+
+// nvdPage returns an NVD API response of the given vulnerabilities.
+func nvdPage(vulns string) string {
+	return `{"format": "NVD_CVE", "version": "2.0", "resultsPerPage": 1, "startIndex": 0, "totalResults": 1, "timestamp": "2024-01-01T00:00:00.000", "vulnerabilities": [` + vulns + `]}`
+}
+
+func TestSampleChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   func(client *http.Client, url string) Check
+		body    string
+		wantErr bool
+	}{
+		{
+			name:  "nvd",
+			check: func(client *http.Client, url string) Check { return NVDSampleCheck(client, url, "") },
+			body:  nvdPage(`{"cve": {"id": "CVE-2024-0001", "published": "2024-01-01T00:00:00.000", "lastModified": "2024-01-01T00:00:00.000", "descriptions": [{"lang": "en", "value": "A flaw."}], "references": []}}`),
+		},
+		{
+			name:    "nvd without vulnerabilities",
+			check:   func(client *http.Client, url string) Check { return NVDSampleCheck(client, url, "") },
+			body:    nvdPage(""),
+			wantErr: true,
+		},
+		{
+			name:  "alpine",
+			check: AlpineSampleCheck,
+			body:  `{"distroversion": "edge", "packages": [{"pkg": {"name": "openssl", "secfixes": {"3.1.4-r1": ["CVE-2023-5363"]}}}]}`,
+		},
+		{
+			name:    "alpine format change",
+			check:   AlpineSampleCheck,
+			body:    `{"distroversion": "edge", "packages": [{"package": {"name": "openssl"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:  "debian",
+			check: DebianSampleCheck,
+			body:  `{"openssl": {"CVE-2023-5363": {"releases": {"bookworm": {"status": "resolved", "fixed_version": "3.0.11-1~deb12u2"}}}}, "truncated`,
+		},
+		{
+			name:    "debian not an object",
+			check:   DebianSampleCheck,
+			body:    `[]`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := serve(t, tc.body)
+			err := tc.check(srv.Client(), srv.URL).Run(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Run() = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err := tc.check(srv.Client(), srv.URL+"/missing").Run(context.Background()); err == nil {
+				t.Errorf("Run() of a missing feed succeeded")
+			}
+		})
+	}
+}
+
+func testReport(t *testing.T) Report {
+	t.Helper()
+	checks := []Check{
+		{Kind: "feed", Target: "https://example.com", Run: func(ctx context.Context) error { return nil }},
+		{Kind: "bucket", Target: "gs://bucket", Run: func(ctx context.Context) error { return errors.New("permission denied") }},
+	}
+	report := Run(context.Background(), checks, time.Second)
+	for i := range report.Results {
+		report.Results[i].DurationMS = 0
+	}
+	return report
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := testReport(t)
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+	want := Report{
+		Build:  report.Build,
+		Passed: 1,
+		Failed: 1,
+		Results: []Result{
+			{Kind: "feed", Target: "https://example.com", OK: true},
+			{Kind: "bucket", Target: "gs://bucket", Error: "permission denied"},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, testReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	want := "ok   feed           https://example.com (0ms)\n" +
+		"FAIL bucket         gs://bucket: permission denied\n" +
+		"1 of 2 checks failed\n"
+	if diff := gocmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WriteText() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,115 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/ids"
+)
+
+// NVDSampleCheck checks a record can be fetched from the NVD API and parsed
+// as the converter does.
+func NVDSampleCheck(client *http.Client, endpoint, apiKey string) Check {
+	return Check{Kind: "sample", Target: endpoint, Run: func(ctx context.Context) error {
+		header := http.Header{}
+		if apiKey != "" {
+			header.Set("apiKey", apiKey)
+		}
+		res, err := get(ctx, client, endpoint+"?resultsPerPage=1", header)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		var page cves.CVEAPIJSON20Schema
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+			return fmt.Errorf("failed to parse NVD response: %w", err)
+		}
+		if len(page.Vulnerabilities) == 0 {
+			return errors.New("NVD response has no vulnerabilities")
+		}
+		if id := page.Vulnerabilities[0].CVE.ID; !ids.IsCVE(string(id)) {
+			return fmt.Errorf("NVD record has an invalid ID %q", id)
+		}
+		return nil
+	}}
+}
+
+// alpineSecDB is the part of an Alpine secdb file the converter relies on.
+type alpineSecDB struct {
+	DistroVersion string `json:"distroversion"`
+	Packages      []struct {
+		Pkg struct {
+			Name     string              `json:"name"`
+			SecFixes map[string][]string `json:"secfixes"`
+		} `json:"pkg"`
+	} `json:"packages"`
+}
+
+// AlpineSampleCheck checks an Alpine secdb file can be fetched and has a
+// package with secfixes.
+func AlpineSampleCheck(client *http.Client, url string) Check {
+	return Check{Kind: "sample", Target: url, Run: func(ctx context.Context) error {
+		res, err := get(ctx, client, url, nil)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		var secdb alpineSecDB
+		if err := json.NewDecoder(res.Body).Decode(&secdb); err != nil {
+			return fmt.Errorf("failed to parse secdb: %w", err)
+		}
+		for _, pkg := range secdb.Packages {
+			if pkg.Pkg.Name != "" && len(pkg.Pkg.SecFixes) > 0 {
+				return nil
+			}
+		}
+		return errors.New("secdb has no packages with secfixes")
+	}}
+}
+
+// debianCVE is the part of a Debian security tracker CVE the converter
+// relies on.
+type debianCVE struct {
+	Releases map[string]struct {
+		Status       string `json:"status"`
+		FixedVersion string `json:"fixed_version"`
+	} `json:"releases"`
+}
+
+// DebianSampleCheck checks the first package of the Debian security tracker
+// data parses, without downloading the rest of it.
+func DebianSampleCheck(client *http.Client, url string) Check {
+	return Check{Kind: "sample", Target: url, Run: func(ctx context.Context) error {
+		res, err := get(ctx, client, url, nil)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		dec := json.NewDecoder(res.Body)
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return fmt.Errorf("tracker data isn't a JSON object: %v", err)
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse tracker data: %w", err)
+		}
+		pkg, ok := tok.(string)
+		if !ok {
+			return errors.New("tracker data has no packages")
+		}
+		var vulns map[string]debianCVE
+		if err := dec.Decode(&vulns); err != nil {
+			return fmt.Errorf("failed to parse tracker data of %s: %w", pkg, err)
+		}
+		for _, v := range vulns {
+			if len(v.Releases) > 0 {
+				return nil
+			}
+		}
+		return fmt.Errorf("tracker data of %s has no releases", pkg)
+	}}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package purl builds the package URLs
+// (https://github.com/package-url/purl-spec) of the packages converters
+// emit, so every converter formats them the same way: escaped per the spec,
+// with qualifiers sorted by key.
+package purl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// ArchSource is the arch qualifier of source packages, which distro
+// advisories are about.
+const ArchSource = "source"
+
+// New returns the package URL of a package, without a version. Empty
+// qualifiers are omitted.
+func New(purlType, namespace, name string, qualifiers map[string]string) string {
+	q := make(map[string]string, len(qualifiers))
+	for k, v := range qualifiers {
+		if v != "" {
+			q[k] = v
+		}
+	}
+	return packageurl.NewPackageURL(purlType, namespace, name, "", packageurl.QualifiersFromMap(q), "").ToString()
+}
+
+// Alpine returns the package URL of an Alpine source package in a release,
+// e.g. "v3.18" or "edge".
+func Alpine(name, release string) string {
+	distro := ""
+	if release != "" {
+		distro = "alpine-" + strings.TrimPrefix(release, "v")
+	}
+	return New(packageurl.TypeApk, "alpine", name, map[string]string{"arch": ArchSource, "distro": distro})
+}
+
+// Debian returns the package URL of a Debian source package in a release,
+// by its version number, e.g. "12".
+func Debian(name, release string) string {
+	distro := ""
+	if release != "" {
+		distro = "debian-" + release
+	}
+	return New(packageurl.TypeDebian, "debian", name, map[string]string{"arch": ArchSource, "distro": distro})
+}
+
+// RPM returns the package URL of an RPM package of a vendor, e.g. "fedora",
+// in a distro, e.g. "fedora-37".
+func RPM(vendor, name, distro string) string {
+	return New(packageurl.TypeRPM, vendor, name, map[string]string{"distro": distro})
+}
+
+// Normalize returns a package URL in the canonical form of the spec, without
+// a version, as the purl of an affected package must be.
+func Normalize(purl string) (string, error) {
+	p, err := packageurl.FromString(purl)
+	if err != nil {
+		return "", fmt.Errorf("invalid package URL %q: %w", purl, err)
+	}
+	p.Version = ""
+	return p.ToString(), nil
+}
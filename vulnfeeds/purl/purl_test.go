@@ -0,0 +1,58 @@
+package purl
+
+import (
+	"testing"
+
+	"github.com/package-url/packageurl-go"
+)
+
+func TestPURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"alpine", Alpine("openssl", "v3.18"), "pkg:apk/alpine/openssl?arch=source&distro=alpine-3.18"},
+		{"alpine edge", Alpine("curl", "edge"), "pkg:apk/alpine/curl?arch=source&distro=alpine-edge"},
+		{"alpine without release", Alpine("curl", ""), "pkg:apk/alpine/curl?arch=source"},
+		{"debian", Debian("nginx", "12"), "pkg:deb/debian/nginx?arch=source&distro=debian-12"},
+		{"debian escaped", Debian("libsigc++-2.0", "11"), "pkg:deb/debian/libsigc%2B%2B-2.0?arch=source&distro=debian-11"},
+		{"rpm", RPM("openeuler", "openssl", "openEuler-20.03-LTS"), "pkg:rpm/openeuler/openssl?distro=openEuler-20.03-LTS"},
+		{"no qualifiers", New(packageurl.TypePyPi, "", "django-allauth", nil), "pkg:pypi/django-allauth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+			if _, err := packageurl.FromString(tt.got); err != nil {
+				t.Errorf("%q doesn't parse: %v", tt.got, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		purl string
+		want string
+	}{
+		{"pkg:bitnami/wordpress", "pkg:bitnami/wordpress"},
+		{"pkg:bitnami/wordpress@6.2.0", "pkg:bitnami/wordpress"},
+		{"PKG:NPM/%40angular/core", "pkg:npm/%40angular/core"},
+		{"pkg:deb/debian/curl?distro=debian-12&arch=source", "pkg:deb/debian/curl?arch=source&distro=debian-12"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.purl)
+		if err != nil {
+			t.Errorf("Normalize(%q) failed: %v", tt.purl, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.purl, got, tt.want)
+		}
+	}
+	if _, err := Normalize("wordpress"); err == nil {
+		t.Error("Normalize(\"wordpress\") succeeded, want an error")
+	}
+}
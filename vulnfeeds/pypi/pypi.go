@@ -16,17 +16,20 @@ package pypi
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
 
 	version "github.com/aquasecurity/go-pep440-version"
+	"github.com/package-url/packageurl-go"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/purl"
 	"github.com/google/osv/vulnfeeds/triage"
 )
 
@@ -80,35 +83,30 @@ var linkBlocklist = map[string]bool{
 	"unknown":                    true,
 }
 
-func readOrPanic(path string) []byte {
-	data, err := ioutil.ReadFile(path)
+func loadLinks(path string) ([]pypiLinks, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Failed to read %s: %v", path, err)
+		return nil, err
 	}
 
-	return data
-}
-
-func loadLinks(path string) []pypiLinks {
-	data := readOrPanic(path)
-
 	var links []pypiLinks
-	err := json.Unmarshal(data, &links)
-	if err != nil {
-		log.Fatalf("Failed to parse %s: %v", data, err)
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
-	return links
+	return links, nil
 }
 
-func loadVersions(path string) []pypiVersions {
-	data := readOrPanic(path)
+func loadVersions(path string) ([]pypiVersions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
 	var versions []pypiVersions
-	err := json.Unmarshal(data, &versions)
-	if err != nil {
-		log.Fatalf("Failed to parse %s: %v", data, err)
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
-	return versions
+	return versions, nil
 }
 
 // NormalizePackageName normalizes a PyPI package name.
@@ -222,9 +220,15 @@ func processVersions(versionsSource []pypiVersions) map[string][]string {
 	return versions
 }
 
-func New(pypiLinksPath string, pypiVersionsPath string) *PyPI {
-	linksSource := loadLinks(pypiLinksPath)
-	versionsSource := loadVersions(pypiVersionsPath)
+func New(pypiLinksPath string, pypiVersionsPath string) (*PyPI, error) {
+	linksSource, err := loadLinks(pypiLinksPath)
+	if err != nil {
+		return nil, err
+	}
+	versionsSource, err := loadVersions(pypiVersionsPath)
+	if err != nil {
+		return nil, err
+	}
 
 	links, vendorProductToPkg := processLinks(linksSource)
 	return &PyPI{
@@ -232,7 +236,7 @@ func New(pypiLinksPath string, pypiVersionsPath string) *PyPI {
 		versions:           processVersions(versionsSource),
 		checkedPackages:    map[string]bool{},
 		vendorProductToPkg: vendorProductToPkg,
-	}
+	}, nil
 }
 
 func (p *PyPI) Matches(cve cves.CVE, falsePositives *triage.FalsePositives) []string {
@@ -283,8 +287,7 @@ func (p *PyPI) Matches(cve cves.CVE, falsePositives *triage.FalsePositives) []st
 func (p *PyPI) PackageURL(pkg string) string {
 	// https://github.com/package-url/purl-spec/blob/master/PURL-TYPES.rst#pypi
 	// Example: pkg:pypi/django-allauth
-	normalizedName := NormalizePackageName(pkg)
-	return "pkg:pypi/" + normalizedName
+	return purl.New(packageurl.TypePyPi, "", NormalizePackageName(pkg), nil)
 }
 
 func filterVersions(versions []string) []string {
@@ -0,0 +1,35 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix
+
+package ratelimit
+
+import "sync"
+
+// entryLocks are the locks of the cache entries in use by this process.
+var entryLocks sync.Map
+
+// lockEntry takes an exclusive lock on the cache entry at path and returns
+// the function releasing it. Without flock, the lock is only shared within
+// this process, so converters running concurrently may each download an
+// entry.
+func lockEntry(path string) (func(), error) {
+	mu, _ := entryLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	var once sync.Once
+	return func() {
+		once.Do(mu.(*sync.Mutex).Unlock)
+	}, nil
+}
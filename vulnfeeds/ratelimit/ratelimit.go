@@ -0,0 +1,368 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit keeps the downloaders polite to the upstream feeds, by
+// limiting the rate and parallelism of requests per host, and can serve
+// responses from a local cache instead of the network. The cache can be shared
+// by converters running concurrently: its entries are locked while they're
+// downloaded, so each is only downloaded once (on Unix; elsewhere, entries are
+// only locked within a process).
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrOffline is returned for requests not in the cache when offline.
+var ErrOffline = errors.New("not in the local cache while offline")
+
+// CacheEnv is the environment variable naming the default cache directory,
+// so every converter of a job shares one cache.
+const CacheEnv = "VULNFEEDS_HTTP_CACHE"
+
+// DefaultTTL is how long cached responses are served without revalidating
+// them by default.
+const DefaultTTL = time.Hour
+
+// Limit is the politeness configuration of a host.
+type Limit struct {
+	RequestsPerSecond float64
+	Burst             int
+	// MaxParallel is the maximum number of requests in flight, until their
+	// response headers arrive.
+	MaxParallel int
+}
+
+// DefaultLimit applies to hosts without a limit of their own.
+var DefaultLimit = Limit{RequestsPerSecond: 10, Burst: 10, MaxParallel: 8}
+
+// DefaultLimits are the limits of the feeds known to throttle the pipeline.
+var DefaultLimits = map[string]Limit{
+	// NVD allows 50 requests per rolling 30 seconds with an API key.
+	"services.nvd.nist.gov": {RequestsPerSecond: 50.0 / 30, Burst: 5, MaxParallel: 1},
+	"nvd.nist.gov":          {RequestsPerSecond: 1, Burst: 2, MaxParallel: 2},
+	"secdb.alpinelinux.org": {RequestsPerSecond: 2, Burst: 4, MaxParallel: 2},
+	// The security tracker renders its pages on demand.
+	"security.alpinelinux.org":    {RequestsPerSecond: 1, Burst: 2, MaxParallel: 1},
+	"security-tracker.debian.org": {RequestsPerSecond: 1, Burst: 2, MaxParallel: 1},
+	"web.archive.org":             {RequestsPerSecond: 1, Burst: 1, MaxParallel: 1},
+	// Bodhi's update queries are expensive.
+	"bodhi.fedoraproject.org": {RequestsPerSecond: 1, Burst: 2, MaxParallel: 1},
+}
+
+// ParseLimits parses comma separated host=requests_per_second:burst:max_parallel
+// limits, e.g. "services.nvd.nist.gov=0.5:1:1".
+func ParseLimits(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		host, values, ok := strings.Cut(item, "=")
+		parts := strings.Split(values, ":")
+		if !ok || host == "" || len(parts) != 3 {
+			return nil, fmt.Errorf("invalid limit %q, want host=requests_per_second:burst:max_parallel", item)
+		}
+		rps, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || rps <= 0 {
+			return nil, fmt.Errorf("invalid requests per second in %q", item)
+		}
+		burst, err := strconv.Atoi(parts[1])
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid burst in %q", item)
+		}
+		parallel, err := strconv.Atoi(parts[2])
+		if err != nil || parallel < 1 {
+			return nil, fmt.Errorf("invalid max parallelism in %q", item)
+		}
+		limits[strings.ToLower(host)] = Limit{RequestsPerSecond: rps, Burst: burst, MaxParallel: parallel}
+	}
+	return limits, nil
+}
+
+// hostLimiter enforces the Limit of a host.
+type hostLimiter struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// Transport is an http.RoundTripper that limits the requests to each host.
+// With a CacheDir, it caches the bodies of successful GET responses, and when
+// Offline, it only serves them from the cache. Cached responses younger than
+// the TTL are served without a request; older ones (or all of them, with
+// Refresh) are revalidated with a conditional request.
+type Transport struct {
+	Base     http.RoundTripper
+	Limits   map[string]Limit
+	CacheDir string
+	Offline  bool
+	TTL      time.Duration
+	Refresh  bool
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+func (t *Transport) hostLimiter(host string) *hostLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hosts == nil {
+		t.hosts = make(map[string]*hostLimiter)
+	}
+	h, ok := t.hosts[host]
+	if !ok {
+		limit, ok := t.Limits[host]
+		if !ok {
+			limit = DefaultLimit
+		}
+		h = &hostLimiter{
+			limiter: rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst),
+			slots:   make(chan struct{}, limit.MaxParallel),
+		}
+		t.hosts[host] = h
+	}
+	return h
+}
+
+// cachePath returns the path the body of a GET of url is cached at.
+func (t *Transport) cachePath(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(t.CacheDir, hex.EncodeToString(hash[:]))
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cacheable := t.CacheDir != "" && req.Method == http.MethodGet
+	if t.Offline {
+		if !cacheable {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrOffline)
+		}
+		res, err := cachedResponse(req, t.cachePath(req.URL.String()))
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrOffline)
+		}
+		return res, nil
+	}
+	if !cacheable {
+		return t.roundTrip(req)
+	}
+
+	// The entry stays locked until its response body is closed (and written
+	// to the cache), so other converters sharing the cache wait for it rather
+	// than download it too. It is also unlocked once the request's context is
+	// done, so a body that is never closed doesn't hold it forever.
+	path := t.cachePath(req.URL.String())
+	lockUnlock, err := lockEntry(path)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(req.Context(), lockUnlock)
+	unlock := func() {
+		stop()
+		lockUnlock()
+	}
+	info, err := os.Stat(path)
+	cached := err == nil
+	if cached && t.fresh(info) {
+		defer unlock()
+		return cachedResponse(req, path)
+	}
+	if cached && req.Header.Get("If-Modified-Since") == "" && req.Header.Get("If-None-Match") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+	res, err := t.roundTrip(req)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	switch {
+	case cached && res.StatusCode == http.StatusNotModified:
+		defer unlock()
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return cachedResponse(req, path)
+	case res.StatusCode == http.StatusOK:
+		if f, err := os.CreateTemp(t.CacheDir, ".partial-"); err == nil {
+			res.Body = &cachingBody{ReadCloser: res.Body, file: f, path: path, unlock: unlock}
+			return res, nil
+		}
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: unlock}
+	return res, nil
+}
+
+// roundTrip sends req within the limits of its host. The host's slot is
+// released once the response headers arrive, so a body that is never closed
+// doesn't hold it.
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	h := t.hostLimiter(strings.ToLower(req.URL.Hostname()))
+	if err := h.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	select {
+	case h.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	<-h.slots
+	return res, err
+}
+
+// releasingBody releases the lock of a cache entry when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// cachingBody writes a body to the cache as it is read, keeping it only if
+// it was read completely, then unlocks its entry.
+type cachingBody struct {
+	io.ReadCloser
+	file     *os.File
+	path     string
+	unlock   func()
+	complete bool
+	failed   bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.failed {
+		if _, werr := b.file.Write(p[:n]); werr != nil {
+			b.failed = true
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		b.complete = true
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	// Only unlocked once the entry is in place, or a converter waiting on it
+	// would find none and download it again.
+	defer b.unlock()
+	err := b.ReadCloser.Close()
+	closeErr := b.file.Close()
+	if b.complete && !b.failed && closeErr == nil && os.Rename(b.file.Name(), b.path) == nil {
+		return err
+	}
+	os.Remove(b.file.Name())
+	return err
+}
+
+// Options configure Install.
+type Options struct {
+	// Limits override DefaultLimits per host.
+	Limits   map[string]Limit
+	CacheDir string
+	Offline  bool
+	// TTL is how long cached responses are served without revalidating them.
+	TTL time.Duration
+	// Refresh revalidates every cached response, whatever its age.
+	Refresh bool
+}
+
+// Install makes http.DefaultClient, which the downloaders use, polite. Its
+// requests are sent on with the transport it had (see httpclient.Install).
+func Install(opts Options) error {
+	if opts.Offline && opts.CacheDir == "" {
+		return errors.New("offline mode requires a cache directory")
+	}
+	if opts.CacheDir != "" {
+		if err := os.MkdirAll(opts.CacheDir, 0755); err != nil {
+			return err
+		}
+	}
+	limits := make(map[string]Limit, len(DefaultLimits)+len(opts.Limits))
+	for host, limit := range DefaultLimits {
+		limits[host] = limit
+	}
+	for host, limit := range opts.Limits {
+		limits[host] = limit
+	}
+	base := http.DefaultClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	http.DefaultClient.Transport = &Transport{
+		Base:     base,
+		Limits:   limits,
+		CacheDir: opts.CacheDir,
+		Offline:  opts.Offline,
+		TTL:      opts.TTL,
+		Refresh:  opts.Refresh,
+	}
+	return nil
+}
+
+// Flags are the command line flags configuring politeness, shared by the
+// downloaders.
+type Flags struct {
+	limits   *string
+	cacheDir *string
+	ttl      *time.Duration
+	refresh  *bool
+	offline  *bool
+}
+
+// RegisterFlags registers the politeness flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		limits:   fs.String("rate_limits", "", "Comma separated host=requests_per_second:burst:max_parallel limits, overriding the defaults"),
+		cacheDir: fs.String("http_cache", os.Getenv(CacheEnv), "Directory to cache downloaded feeds in, shared by concurrent runs (defaults to $"+CacheEnv+")"),
+		ttl:      fs.Duration("http_cache_ttl", DefaultTTL, "How long cached feeds are used without revalidating them"),
+		refresh:  fs.Bool("refresh", false, "Revalidate every cached feed, whatever its age"),
+		offline:  fs.Bool("offline", false, "Only read feeds from the cache in -http_cache, never the network"),
+	}
+}
+
+// Install installs the politeness configured by the flags.
+func (f *Flags) Install() error {
+	limits, err := ParseLimits(*f.limits)
+	if err != nil {
+		return err
+	}
+	return Install(Options{Limits: limits, CacheDir: *f.cacheDir, Offline: *f.offline, TTL: *f.ttl, Refresh: *f.refresh})
+}
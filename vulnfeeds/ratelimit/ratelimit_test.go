@@ -0,0 +1,288 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestParseLimits(t *testing.T) {
+	got, err := ParseLimits("services.nvd.nist.gov=0.5:1:1, Secdb.AlpineLinux.org=2:4:2")
+	if err != nil {
+		t.Fatalf("ParseLimits() error = %v", err)
+	}
+	want := map[string]Limit{
+		"services.nvd.nist.gov": {RequestsPerSecond: 0.5, Burst: 1, MaxParallel: 1},
+		"secdb.alpinelinux.org": {RequestsPerSecond: 2, Burst: 4, MaxParallel: 2},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseLimits() mismatch (-want +got):\n%s", diff)
+	}
+	for _, spec := range []string{"example.com", "example.com=1:1", "example.com=0:1:1", "example.com=1:1:0"} {
+		if _, err := ParseLimits(spec); err == nil {
+			t.Errorf("ParseLimits(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestTransportMaxParallel(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	client := &http.Client{Transport: &Transport{
+		Base:   srv.Client().Transport,
+		Limits: map[string]Limit{u.Hostname(): {RequestsPerSecond: 1000, Burst: 10, MaxParallel: 2}},
+	}}
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+		}()
+	}
+	for range 5 {
+		release <- struct{}{}
+	}
+	wg.Wait()
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("%d requests were in flight, want at most 2", got)
+	}
+}
+
+func TestTransportUnclosedBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	client := &http.Client{Transport: &Transport{
+		Base:   srv.Client().Transport,
+		Limits: map[string]Limit{u.Hostname(): {RequestsPerSecond: 1000, Burst: 10, MaxParallel: 1}},
+	}}
+	// A caller not closing the body mustn't keep the host's only slot.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 2 {
+			if _, err := client.Get(srv.URL); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second request waited on the unclosed body of the first")
+	}
+}
+
+func TestTransportCacheEntryUnlockedWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("feed"))
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	client := &http.Client{Transport: &Transport{Base: srv.Client().Transport, CacheDir: cacheDir}}
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	// The body is never closed, but its entry is unlocked with the context.
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cache entry stayed locked after the context was done")
+	}
+}
+
+func TestTransportOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("feed " + r.URL.Path))
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	online := &http.Client{Transport: &Transport{Base: srv.Client().Transport, CacheDir: cacheDir}}
+	for _, path := range []string{"/feed", "/missing", "/unread"} {
+		res, err := online.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != "/unread" {
+			io.ReadAll(res.Body)
+		}
+		res.Body.Close()
+	}
+
+	offline := &http.Client{Transport: &Transport{CacheDir: cacheDir, Offline: true}}
+	res, err := offline.Get(srv.URL + "/feed")
+	if err != nil {
+		t.Fatalf("offline Get() of a cached feed error = %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "feed /feed" {
+		t.Errorf("offline Get() = %q, want the cached feed", body)
+	}
+	// Neither errors nor partially read bodies are cached.
+	for _, path := range []string{"/missing", "/unread", "/other"} {
+		if _, err := offline.Get(srv.URL + path); !errors.Is(err, ErrOffline) {
+			t.Errorf("offline Get(%s) error = %v, want ErrOffline", path, err)
+		}
+	}
+}
+
+func TestTransportCacheRevalidation(t *testing.T) {
+	var requests, conditional atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-Modified-Since") != "" {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("feed"))
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	get := func(transport *Transport) {
+		t.Helper()
+		res, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || string(body) != "feed" {
+			t.Errorf("Get() = %d %q, want the feed", res.StatusCode, body)
+		}
+	}
+	get(&Transport{Base: srv.Client().Transport, CacheDir: cacheDir, TTL: time.Hour})
+	get(&Transport{Base: srv.Client().Transport, CacheDir: cacheDir, TTL: time.Hour})
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests made within the TTL, want 1", got)
+	}
+	get(&Transport{Base: srv.Client().Transport, CacheDir: cacheDir, TTL: time.Hour, Refresh: true})
+	get(&Transport{Base: srv.Client().Transport, CacheDir: cacheDir})
+	if got := conditional.Load(); got != 2 {
+		t.Errorf("%d conditional requests made, want 2", got)
+	}
+}
+
+func TestTransportSharedCache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("feed"))
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	// Each client stands in for a converter sharing the cache.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := &http.Client{Transport: &Transport{Base: srv.Client().Transport, CacheDir: cacheDir, TTL: time.Hour}}
+			res, err := client.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if body, _ := io.ReadAll(res.Body); string(body) != "feed" {
+				t.Errorf("Get() = %q, want the feed", body)
+			}
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if got := requests.Load(); got != 1 {
+		t.Errorf("the feed was downloaded %d times, want once", got)
+	}
+}
+
+func TestTransportSharedCacheEntryWritten(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("feed"))
+	}))
+	defer srv.Close()
+
+	// The second transport waits on the entry while the first downloads it,
+	// and must find it cached once the first's body is closed, however
+	// quickly it gets the lock.
+	for round := range 50 {
+		cacheDir := t.TempDir()
+		requests.Store(0)
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client := &http.Client{Transport: &Transport{Base: srv.Client().Transport, CacheDir: cacheDir, TTL: time.Hour}}
+				res, err := client.Get(srv.URL)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}()
+		}
+		wg.Wait()
+		if got := requests.Load(); got != 1 {
+			t.Fatalf("round %d: the feed was downloaded %d times, want once", round, got)
+		}
+	}
+}
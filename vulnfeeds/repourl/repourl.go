@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package repourl canonicalizes Git repository URLs. References point at the
+// same repo with many URL shapes (http or https, git:// or SSH, with or
+// without .git, on mirrors), so repos are canonicalized before being compared
+// or written to GIT ranges, for each to be known by a single URL.
+package repourl
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// majorHosts are the hosts serving repos over HTTPS with or without the .git
+// suffix, whose paths are case-insensitive for GitHub.
+var majorHosts = []string{
+	"bitbucket.org",
+	"github.com",
+	"gitlab.com",
+}
+
+// gitSuffixHosts are the hosts whose repos are canonically known by their
+// URL with the .git suffix.
+var gitSuffixHosts = []string{
+	"git.kernel.org",
+}
+
+// aliases maps the canonicalized URLs of well-known mirrors to the repo they
+// mirror.
+var aliases = map[string]string{
+	"https://github.com/torvalds/linux":      "https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git",
+	"https://github.com/gregkh/linux":        "https://git.kernel.org/pub/scm/linux/kernel/git/stable/linux.git",
+	"https://github.com/git/git":             "https://git.kernel.org/pub/scm/git/git.git",
+	"https://github.com/bluez/bluez":         "https://git.kernel.org/pub/scm/bluetooth/bluez.git",
+	"https://github.com/qemu/qemu":           "https://gitlab.com/qemu-project/qemu",
+	"https://git.qemu.org/qemu.git":          "https://gitlab.com/qemu-project/qemu",
+	"https://github.com/gnutls/gnutls":       "https://gitlab.com/gnutls/gnutls",
+	"https://github.com/bminor/glibc":        "https://sourceware.org/git/glibc.git",
+	"https://github.com/bminor/binutils-gdb": "https://sourceware.org/git/binutils-gdb.git",
+	"https://github.com/mirror/busybox":      "https://git.busybox.net/busybox",
+	"https://github.com/emacs-mirror/emacs":  "https://git.savannah.gnu.org/git/emacs.git",
+	"https://git.openssl.org/openssl.git":    "https://github.com/openssl/openssl",
+	"https://git.php.net/php-src.git":        "https://github.com/php/php-src",
+}
+
+// scpLike matches the scp-like syntax of SSH URLs, e.g.
+// git@github.com:google/osv.dev.git
+var scpLike = regexp.MustCompile(`^[\w.-]+@([\w.-]+):(.+)$`)
+
+// Canonical returns the canonical URL of a repo:
+//   - the scheme is https, except for git:// URLs of hosts other than the
+//     major ones, which may only serve the git protocol
+//   - the host is lowercased, without "www." or credentials
+//   - repos of major hosts drop the .git suffix, query and fragment, and
+//     GitHub's are lowercased, as their paths are case-insensitive
+//   - trailing slashes are removed, and hosts known by the .git suffix get it
+//   - well-known mirrors are replaced by the repo they mirror
+//
+// URLs that can't be parsed are returned as is.
+func Canonical(repo string) string {
+	repo = strings.TrimSpace(repo)
+	if m := scpLike.FindStringSubmatch(repo); m != nil && !strings.Contains(repo, "://") {
+		repo = "ssh://" + m[1] + "/" + strings.TrimPrefix(m[2], "/")
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.Host == "" {
+		return repo
+	}
+	u.User = nil
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	major := slices.Contains(majorHosts, u.Hostname())
+	switch {
+	case u.Scheme == "git" && !major:
+	case u.Scheme == "ssh":
+		// Without the SSH port.
+		u.Host = u.Hostname()
+		u.Scheme = "https"
+	default:
+		u.Scheme = "https"
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	if major {
+		u.Path = strings.TrimSuffix(u.Path, ".git")
+		u.RawQuery = ""
+		u.Fragment = ""
+		if u.Hostname() == "github.com" {
+			u.Path = strings.ToLower(u.Path)
+		}
+	}
+	if slices.Contains(gitSuffixHosts, u.Hostname()) && u.Path != "" && !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	canonical := u.String()
+	if alias, ok := aliases[canonical]; ok {
+		return alias
+	}
+	return canonical
+}
+
+// Dedup returns the canonical URLs of repos, each once, in the order they
+// first appear.
+func Dedup(repos []string) []string {
+	var deduped []string
+	for _, repo := range repos {
+		canonical := Canonical(repo)
+		if !slices.Contains(deduped, canonical) {
+			deduped = append(deduped, canonical)
+		}
+	}
+	return deduped
+}
+
+// Same reports whether two URLs are of the same repo.
+func Same(a, b string) bool {
+	return Canonical(a) == Canonical(b)
+}
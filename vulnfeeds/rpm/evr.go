@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpm handles the versions and advisory metadata of RPM based
+// distributions.
+package rpm
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+// EVR is an RPM epoch:version-release.
+type EVR struct {
+	Epoch   int
+	Version string
+	Release string
+}
+
+// ParseEVR parses a version-release, optionally prefixed by an epoch, such
+// as "1:3.0.7-25.el9".
+func ParseEVR(s string) (EVR, bool) {
+	var evr EVR
+	if epoch, rest, found := strings.Cut(s, ":"); found {
+		e, err := strconv.Atoi(epoch)
+		if err != nil || e < 0 {
+			return EVR{}, false
+		}
+		evr.Epoch, s = e, rest
+	}
+	releaseIndex := strings.LastIndex(s, "-")
+	if releaseIndex <= 0 || releaseIndex == len(s)-1 {
+		return EVR{}, false
+	}
+	evr.Version, evr.Release = s[:releaseIndex], s[releaseIndex+1:]
+	return evr, true
+}
+
+// String formats an EVR the way dnf does, omitting a zero epoch.
+func (e EVR) String() string {
+	if e.Epoch == 0 {
+		return e.Version + "-" + e.Release
+	}
+	return strconv.Itoa(e.Epoch) + ":" + e.Version + "-" + e.Release
+}
+
+// Compare compares EVRs the way RPM does, returning -1, 0 or 1.
+func (e EVR) Compare(other EVR) int {
+	switch {
+	case e.Epoch < other.Epoch:
+		return -1
+	case e.Epoch > other.Epoch:
+		return 1
+	}
+	if c := vercmp.RPMVersion(e.Version, other.Version); c != 0 {
+		return c
+	}
+	return vercmp.RPMVersion(e.Release, other.Release)
+}
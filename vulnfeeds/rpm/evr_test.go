@@ -0,0 +1,41 @@
+package rpm
+
+import "testing"
+
+func TestEVR(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.0.9-2.fc38", "3.0.9-2.fc38", 0},
+		{"1:3.0.8-1.fc38", "3.0.9-2.fc38", 1},
+		{"0:3.0.9-2.fc38", "3.0.9-2.fc38", 0},
+		{"3.0.9-2.fc38", "3.0.9-10.fc38", -1},
+		{"3.0.9-2.fc38", "3.0.10-1.fc38", -1},
+	}
+	for _, tt := range tests {
+		a, ok := ParseEVR(tt.a)
+		if !ok {
+			t.Fatalf("ParseEVR(%q) failed", tt.a)
+		}
+		b, ok := ParseEVR(tt.b)
+		if !ok {
+			t.Fatalf("ParseEVR(%q) failed", tt.b)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%q.Compare(%q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if evr, _ := ParseEVR("1:3.0.9-2.fc38"); evr.String() != "1:3.0.9-2.fc38" {
+		t.Errorf("String() = %q, want 1:3.0.9-2.fc38", evr.String())
+	}
+	if evr, _ := ParseEVR("0:3.0.9-2.fc38"); evr.String() != "3.0.9-2.fc38" {
+		t.Errorf("String() = %q, want the zero epoch omitted", evr.String())
+	}
+	for _, invalid := range []string{"3.0.9", "x:3.0.9-1", "3.0.9-", "-1"} {
+		if _, ok := ParseEVR(invalid); ok {
+			t.Errorf("ParseEVR(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"sort"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// PackageKey is a source package of a distribution release.
+type PackageKey struct {
+	Ecosystem string
+	Name      string
+}
+
+// Fix is the fixed version of a package, and the advisory that fixed it. A
+// Fix without a version records a package that is affected but not fixed
+// yet.
+type Fix struct {
+	EVR      EVR
+	Advisory string
+	Severity string
+}
+
+// Unfixed returns whether the fix has no fixed version.
+func (f Fix) Unfixed() bool {
+	return f.EVR.Version == ""
+}
+
+// Fixes collects the fixed versions of the packages of each CVE. When several
+// advisories fix a package for a CVE, the lowest EVR wins, since any later
+// update includes the fix too. Ties go to the lowest advisory ID, so results
+// don't depend on the order advisories are added in. Any fix wins over an
+// unfixed entry.
+type Fixes map[string]map[PackageKey]Fix
+
+// Add adds the fix of a package for a CVE.
+func (f Fixes) Add(cveID string, key PackageKey, newFix Fix) {
+	if f[cveID] == nil {
+		f[cveID] = make(map[PackageKey]Fix)
+	}
+	if existing, ok := f[cveID][key]; ok {
+		switch {
+		case newFix.Unfixed():
+			return
+		case existing.Unfixed():
+			// Replaced by the fix.
+		default:
+			c := existing.EVR.Compare(newFix.EVR)
+			if c < 0 || c == 0 && existing.Advisory <= newFix.Advisory {
+				return
+			}
+		}
+	}
+	f[cveID][key] = newFix
+}
+
+// PackageInfos returns the package infos of each CVE, sorted by ecosystem
+// and package name so results remain consistent between runs.
+func (f Fixes) PackageInfos(purl func(key PackageKey) string) map[string][]vulns.PackageInfo {
+	osvPkgInfos := make(map[string][]vulns.PackageInfo)
+	for cveID, pkgFixes := range f {
+		keys := make([]PackageKey, 0, len(pkgFixes))
+		for key := range pkgFixes {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].Ecosystem != keys[j].Ecosystem {
+				return keys[i].Ecosystem < keys[j].Ecosystem
+			}
+			return keys[i].Name < keys[j].Name
+		})
+		for _, key := range keys {
+			pkgFix := pkgFixes[key]
+			pkgInfo := vulns.PackageInfo{
+				PkgName:   key.Name,
+				Ecosystem: key.Ecosystem,
+				PURL:      purl(key),
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}},
+				},
+			}
+			if pkgFix.Unfixed() {
+				pkgInfo.Unresolved = true
+			} else {
+				pkgInfo.VersionInfo.AffectedVersions = append(pkgInfo.VersionInfo.AffectedVersions, cves.AffectedVersion{Fixed: pkgFix.EVR.String()})
+			}
+			if pkgFix.Advisory != "" {
+				pkgInfo.EcosystemSpecific = map[string]string{"advisory": pkgFix.Advisory}
+			}
+			if pkgFix.Severity != "" {
+				if pkgInfo.EcosystemSpecific == nil {
+					pkgInfo.EcosystemSpecific = make(map[string]string)
+				}
+				pkgInfo.EcosystemSpecific["severity"] = pkgFix.Severity
+			}
+			osvPkgInfos[cveID] = append(osvPkgInfos[cveID], pkgInfo)
+		}
+	}
+	return osvPkgInfos
+}
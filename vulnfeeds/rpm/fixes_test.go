@@ -0,0 +1,93 @@
+package rpm
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestFixes(t *testing.T) {
+	evr := func(s string) EVR {
+		e, ok := ParseEVR(s)
+		if !ok {
+			t.Fatalf("ParseEVR(%q) failed", s)
+		}
+		return e
+	}
+	openssl := PackageKey{Ecosystem: "Fedora:38", Name: "openssl"}
+	curl := PackageKey{Ecosystem: "Fedora:38", Name: "curl"}
+
+	f := make(Fixes)
+	f.Add("CVE-2023-2650", openssl, Fix{EVR: evr("1:3.0.9-2.fc38"), Advisory: "FEDORA-2023-b", Severity: "High"})
+	// The lowest EVR wins, regardless of the order fixes are added in.
+	f.Add("CVE-2023-2650", openssl, Fix{EVR: evr("1:3.0.8-1.fc38"), Advisory: "FEDORA-2023-c"})
+	f.Add("CVE-2023-2650", openssl, Fix{EVR: evr("1:3.0.10-1.fc38"), Advisory: "FEDORA-2023-a"})
+	// Ties go to the lowest advisory ID.
+	f.Add("CVE-2023-2650", curl, Fix{EVR: evr("8.0.1-1.fc38"), Advisory: "FEDORA-2023-e"})
+	f.Add("CVE-2023-2650", curl, Fix{EVR: evr("8.0.1-1.fc38"), Advisory: "FEDORA-2023-d"})
+	f.Add("CVE-2023-28320", curl, Fix{EVR: evr("8.0.1-1.fc38")})
+	// Any fix wins over an unfixed entry.
+	f.Add("CVE-2023-28320", openssl, Fix{})
+	f.Add("CVE-2023-28320", curl, Fix{})
+	f.Add("CVE-2024-0727", openssl, Fix{})
+
+	got := f.PackageInfos(func(key PackageKey) string { return "pkg:rpm/fedora/" + key.Name })
+	want := map[string][]vulns.PackageInfo{
+		"CVE-2023-2650": {
+			{
+				PkgName:   "curl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/curl",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "8.0.1-1.fc38"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-d"},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/openssl",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "1:3.0.8-1.fc38"}},
+				},
+				EcosystemSpecific: map[string]string{"advisory": "FEDORA-2023-c"},
+			},
+		},
+		"CVE-2023-28320": {
+			{
+				PkgName:   "curl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/curl",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}, {Fixed: "8.0.1-1.fc38"}},
+				},
+			},
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/openssl",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}},
+				},
+				Unresolved: true,
+			},
+		},
+		"CVE-2024-0727": {
+			{
+				PkgName:   "openssl",
+				Ecosystem: "Fedora:38",
+				PURL:      "pkg:rpm/fedora/openssl",
+				VersionInfo: cves.VersionInfo{
+					AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}},
+				},
+				Unresolved: true,
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("PackageInfos() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpm
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/faulttolerant"
+)
+
+// cveIDPattern matches a CVE ID.
+var cveIDPattern = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// RepoMD is the index of a repository's metadata (repodata/repomd.xml).
+type RepoMD struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// UpdateInfo is the subset of a repository's updateinfo advisories needed to
+// determine the fixed source package versions of each CVE.
+type UpdateInfo struct {
+	Updates []Update `xml:"update"`
+}
+
+// Update is an updateinfo advisory.
+type Update struct {
+	Type       string `xml:"type,attr"`
+	Status     string `xml:"status,attr"`
+	ID         string `xml:"id"`
+	Title      string `xml:"title"`
+	Severity   string `xml:"severity"`
+	References []struct {
+		ID   string `xml:"id,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"references>reference"`
+	Packages []Package `xml:"pkglist>collection>package"`
+}
+
+// Package is a binary package an advisory updates.
+type Package struct {
+	Name    string `xml:"name,attr"`
+	Epoch   string `xml:"epoch,attr"`
+	Version string `xml:"version,attr"`
+	Release string `xml:"release,attr"`
+	Src     string `xml:"src,attr"`
+}
+
+// EVR returns the version of the package.
+func (p Package) EVR() (EVR, error) {
+	evr := EVR{Version: p.Version, Release: p.Release}
+	if p.Epoch != "" {
+		epoch, err := strconv.Atoi(p.Epoch)
+		if err != nil || epoch < 0 {
+			return EVR{}, fmt.Errorf("invalid epoch %q of %s", p.Epoch, p.Name)
+		}
+		evr.Epoch = epoch
+	}
+	return evr, nil
+}
+
+// CVEs returns the CVEs an advisory references.
+func (u Update) CVEs() []string {
+	var ids []string
+	for _, ref := range u.References {
+		if ref.Type == "cve" && cveIDPattern.MatchString(ref.ID) {
+			ids = append(ids, ref.ID)
+		}
+	}
+	return ids
+}
+
+// SplitSourceRPM splits a source RPM name-version-release such as
+// "openssl-3.1.1-4.fc39", optionally suffixed by ".src.rpm", into its
+// package name and version-release ("openssl", "3.1.1-4.fc39").
+func SplitSourceRPM(nvr string) (name string, version string, ok bool) {
+	nvr = strings.TrimSuffix(nvr, ".src.rpm")
+	releaseIndex := strings.LastIndex(nvr, "-")
+	if releaseIndex <= 0 || releaseIndex == len(nvr)-1 {
+		return "", "", false
+	}
+	versionIndex := strings.LastIndex(nvr[:releaseIndex], "-")
+	if versionIndex <= 0 {
+		return "", "", false
+	}
+	return nvr[:versionIndex], nvr[versionIndex+1:], true
+}
+
+// updateInfoHref returns the location of the updateinfo in a repository's
+// metadata index.
+func updateInfoHref(repoMD RepoMD) (string, error) {
+	for _, data := range repoMD.Data {
+		if data.Type == "updateinfo" {
+			return data.Location.Href, nil
+		}
+	}
+	return "", errors.New("the repository has no updateinfo")
+}
+
+// decompress decompresses repository metadata by its file extension.
+func decompress(r io.Reader, href string) (io.Reader, error) {
+	switch {
+	case strings.HasSuffix(href, ".xml"):
+		return r, nil
+	case strings.HasSuffix(href, ".gz"):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(href, ".bz2"):
+		return bzip2.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression of %s", href)
+	}
+}
+
+// ParseUpdateInfo decodes updateinfo advisories.
+func ParseUpdateInfo(r io.Reader) (UpdateInfo, error) {
+	var updateInfo UpdateInfo
+	err := xml.NewDecoder(r).Decode(&updateInfo)
+	return updateInfo, err
+}
+
+// DownloadUpdateInfo downloads the updateinfo advisories of the repository
+// at repoURL, which must end in a slash.
+func DownloadUpdateInfo(repoURL string) (UpdateInfo, error) {
+	res, err := faulttolerant.Get(repoURL + "repodata/repomd.xml")
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UpdateInfo{}, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+	var repoMD RepoMD
+	if err := xml.NewDecoder(res.Body).Decode(&repoMD); err != nil {
+		return UpdateInfo{}, fmt.Errorf("failed to parse repomd.xml: %w", err)
+	}
+	href, err := updateInfoHref(repoMD)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+
+	res, err = faulttolerant.Get(repoURL + href)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UpdateInfo{}, fmt.Errorf("HTTP request failed: %s", res.Status)
+	}
+	r, err := decompress(res.Body, href)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	return ParseUpdateInfo(r)
+}
@@ -0,0 +1,59 @@
+package rpm
+
+import (
+	"os"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestSplitSourceRPM(t *testing.T) {
+	tests := []struct {
+		nvr         string
+		wantName    string
+		wantVersion string
+		wantOK      bool
+	}{
+		{"openssl-3.0.9-2.fc38", "openssl", "3.0.9-2.fc38", true},
+		{"python-pip-23.1.2-1.fc39", "python-pip", "23.1.2-1.fc39", true},
+		{"openssl-3.0.7-16.el9.src.rpm", "openssl", "3.0.7-16.el9", true},
+		{"openssl-3.0.9", "", "", false},
+		{"openssl-3.0.9-", "", "", false},
+	}
+	for _, tt := range tests {
+		name, version, ok := SplitSourceRPM(tt.nvr)
+		if name != tt.wantName || version != tt.wantVersion || ok != tt.wantOK {
+			t.Errorf("SplitSourceRPM(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.nvr, name, version, ok, tt.wantName, tt.wantVersion, tt.wantOK)
+		}
+	}
+}
+
+func TestParseUpdateInfo(t *testing.T) {
+	file, err := os.Open("../test_data/fedora/updateinfo.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	updateInfo, err := ParseUpdateInfo(file)
+	if err != nil {
+		t.Fatalf("ParseUpdateInfo() error: %v", err)
+	}
+	if len(updateInfo.Updates) != 3 {
+		t.Fatalf("ParseUpdateInfo() returned %d updates, want 3", len(updateInfo.Updates))
+	}
+
+	update := updateInfo.Updates[0]
+	if diff := gocmp.Diff([]string{"CVE-2023-2650"}, update.CVEs()); diff != "" {
+		t.Errorf("CVEs() mismatch (-want +got):\n%s", diff)
+	}
+	evr, err := update.Packages[0].EVR()
+	if err != nil {
+		t.Fatalf("EVR() error: %v", err)
+	}
+	if got := evr.String(); got != "1:3.0.7-16.el9" {
+		t.Errorf("EVR() = %s, want 1:3.0.7-16.el9", got)
+	}
+	if _, err := (Package{Name: "openssl", Epoch: "x", Version: "1", Release: "1"}).EVR(); err == nil {
+		t.Error("EVR() of an invalid epoch succeeded")
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server runs a converter as a long-running service, so it can be
+// deployed to Cloud Run and triggered by Cloud Scheduler instead of running
+// as a batch job. Each request to /run converts once, by running the
+// converter's own executable with the flags it was started with and the
+// overrides in the request, and streams the run's output and a summary back.
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ServeFlag is the flag enabling server mode. Runs are started without it.
+const ServeFlag = "serve"
+
+// Flags are the command line flags configuring server mode.
+type Flags struct {
+	addr    *string
+	timeout *time.Duration
+}
+
+// RegisterFlags registers the server mode flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		addr:    fs.String(ServeFlag, "", "Address (e.g. :8080) to serve /run and /healthz on, converting once per request to /run, instead of converting once and exiting"),
+		timeout: fs.Duration("run_timeout", time.Hour, "Maximum duration of a run started by a request in server mode"),
+	}
+}
+
+// Enabled returns whether server mode was requested.
+func (f *Flags) Enabled() bool {
+	return *f.addr != ""
+}
+
+// Serve serves the command named name, whose flags are fs, until it's
+// interrupted or terminated.
+func (f *Flags) Serve(name string, fs *flag.FlagSet) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	s := &Server{
+		Name:     name,
+		Command:  exe,
+		Flags:    fs,
+		Reserved: []string{ServeFlag, "run_timeout", "version"},
+		Timeout:  *f.timeout,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return ListenAndServe(ctx, *f.addr, s)
+}
+
+// RunRequest is the body of a request to /run, all of it optional.
+type RunRequest struct {
+	// Flags overrides the values of flags for this run, by flag name.
+	Flags map[string]string `json:"flags,omitempty"`
+}
+
+// Summary summarizes a run.
+type Summary struct {
+	Command         string    `json:"command"`
+	Args            []string  `json:"args"`
+	Started         time.Time `json:"started"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Status is "succeeded" or "failed".
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Event is a line of the JSON lines streamed in response to /run: a line of
+// the run's output, or its summary, which is the last line.
+type Event struct {
+	Output  string   `json:"output,omitempty"`
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+// Server is the HTTP handler of a converter in server mode.
+type Server struct {
+	// Name names the converter in summaries.
+	Name string
+	// Command is the executable run for each request.
+	Command string
+	// Flags are the converter's flags. The flags set on the command line
+	// are passed on to each run, and requests may override any of them but
+	// the Reserved ones.
+	Flags    *flag.FlagSet
+	Reserved []string
+	// Timeout is the maximum duration of a run, or 0 for no limit.
+	Timeout time.Duration
+
+	// running is held by the run in progress: runs write to the same
+	// output, so only one runs at a time.
+	running sync.Mutex
+}
+
+// ServeHTTP serves /run and /healthz.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "ok\n")
+	case "/run":
+		s.serveRun(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// args returns the arguments of a run, the flags set on the command line
+// with the overrides applied.
+func (s *Server) args(overrides map[string]string) ([]string, error) {
+	values := make(map[string]string)
+	s.Flags.Visit(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	for _, name := range s.Reserved {
+		delete(values, name)
+	}
+	for name, value := range overrides {
+		if s.Flags.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown flag %q", name)
+		}
+		for _, reserved := range s.Reserved {
+			if name == reserved {
+				return nil, fmt.Errorf("flag %q can't be set per run", name)
+			}
+		}
+		values[name] = value
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("-%s=%s", name, values[name]))
+	}
+	return args, nil
+}
+
+func (s *Server) serveRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	args, err := s.args(req.Flags)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !s.running.TryLock() {
+		http.Error(w, "a run is already in progress", http.StatusConflict)
+		return
+	}
+	defer s.running.Unlock()
+
+	ctx := r.Context()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	stream := &eventWriter{w: w, enc: json.NewEncoder(w)}
+	summary := s.run(ctx, args, stream)
+	stream.write(Event{Summary: &summary})
+}
+
+// run runs the command, streaming its output.
+func (s *Server) run(ctx context.Context, args []string, stream *eventWriter) Summary {
+	summary := Summary{Command: s.Name, Args: args, Started: time.Now().UTC()}
+	pr, pw := io.Pipe()
+	cmd := exec.CommandContext(ctx, s.Command, args...)
+	cmd.Stdout, cmd.Stderr = pw, pw
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			stream.write(Event{Output: scanner.Text()})
+		}
+		// Keep draining after a line too long to scan, so the run isn't
+		// blocked writing its output.
+		io.Copy(io.Discard, pr)
+	}()
+	err := cmd.Run()
+	pw.Close()
+	<-done
+
+	summary.DurationSeconds = time.Since(summary.Started).Seconds()
+	summary.ExitCode = cmd.ProcessState.ExitCode()
+	summary.Status = "succeeded"
+	if err != nil {
+		summary.Status = "failed"
+		summary.Error = err.Error()
+		if ctx.Err() != nil {
+			summary.Error = fmt.Sprintf("%v (%v)", err, ctx.Err())
+		}
+	}
+	return summary
+}
+
+// eventWriter writes the events of a run, flushing each.
+type eventWriter struct {
+	mu  sync.Mutex
+	w   http.ResponseWriter
+	enc *json.Encoder
+}
+
+func (e *eventWriter) write(event Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// A client that went away doesn't stop the run's output from being
+	// drained, so errors are ignored.
+	_ = e.enc.Encode(event)
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// ListenAndServe serves s on addr until ctx is done, then waits for the run
+// in progress, if any, to finish.
+func ListenAndServe(ctx context.Context, addr string, s *Server) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	if err := srv.Shutdown(context.Background()); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// TestMain stands in for a converter when run with SERVER_TEST_RUN set: it
+// prints its arguments, and fails if asked to.
+func TestMain(m *testing.M) {
+	if os.Getenv("SERVER_TEST_RUN") == "" {
+		os.Exit(m.Run())
+	}
+	for _, arg := range os.Args[1:] {
+		fmt.Println(arg)
+		if arg == "-fail=true" {
+			os.Exit(3)
+		}
+	}
+	os.Exit(0)
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("SERVER_TEST_RUN", "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(ServeFlag, "", "")
+	fs.String("output", "parts", "")
+	fs.Bool("fail", false, "")
+	fs.Bool("edge", true, "")
+	if err := fs.Parse([]string{"-serve=:8080", "-output=out"}); err != nil {
+		t.Fatal(err)
+	}
+	return &Server{Name: "test", Command: exe, Flags: fs, Reserved: []string{ServeFlag}}
+}
+
+func post(t *testing.T, s *Server, body string) (*http.Response, []Event) {
+	t.Helper()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	resp, err := http.Post(ts.URL+"/run", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var events []Event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return resp, events
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		description string
+		body        string
+		wantOutput  []string
+		wantStatus  string
+		wantCode    int
+	}{
+		{
+			description: "flags from the command line",
+			body:        "",
+			wantOutput:  []string{"-output=out"},
+			wantStatus:  "succeeded",
+		},
+		{
+			description: "overrides",
+			body:        `{"flags": {"edge": "false", "output": "other"}}`,
+			wantOutput:  []string{"-edge=false", "-output=other"},
+			wantStatus:  "succeeded",
+		},
+		{
+			description: "failed run",
+			body:        `{"flags": {"fail": "true"}}`,
+			wantOutput:  []string{"-fail=true"},
+			wantStatus:  "failed",
+			wantCode:    3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			resp, events := post(t, newTestServer(t), tt.body)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("POST /run status = %d", resp.StatusCode)
+			}
+			if len(events) == 0 || events[len(events)-1].Summary == nil {
+				t.Fatalf("POST /run events = %+v, want a summary last", events)
+			}
+			var output []string
+			for _, e := range events[:len(events)-1] {
+				output = append(output, e.Output)
+			}
+			if diff := gocmp.Diff(tt.wantOutput, output); diff != "" {
+				t.Errorf("POST /run output mismatch (-want +got):\n%s", diff)
+			}
+			summary := events[len(events)-1].Summary
+			if summary.Status != tt.wantStatus || summary.ExitCode != tt.wantCode {
+				t.Errorf("POST /run summary = %+v, want status %q and exit code %d", summary, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunRejected(t *testing.T) {
+	s := newTestServer(t)
+	for _, body := range []string{`{"flags": {"unknown": "1"}}`, `{"flags": {"serve": ":9090"}}`, `not json`} {
+		if resp, _ := post(t, s, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /run with %s status = %d, want %d", body, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	s.running.Lock()
+	defer s.running.Unlock()
+	if resp, _ := post(t, s, ""); resp.StatusCode != http.StatusConflict {
+		t.Errorf("POST /run during a run status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
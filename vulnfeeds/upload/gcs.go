@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upload
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// resumableThreshold is the size above which objects are uploaded in a
+// resumable session, in chunks retried on their own, rather than in a single
+// request.
+const resumableThreshold = 8 << 20
+
+// ErrChecksumMismatch is returned by GCS.Write when the CRC32C checksum GCS
+// computed of an object differs from that of the data written, which means
+// it was corrupted in transit. It's retryable.
+var ErrChecksumMismatch = errors.New("CRC32C checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// contentTypes are the content types of the files published, by extension,
+// for those mime doesn't know or may not know.
+var contentTypes = map[string]string{
+	".json":  "application/json",
+	".jsonl": "application/x-ndjson",
+	".zip":   "application/zip",
+	".pb":    "application/x-protobuf",
+}
+
+// contentType returns the content type of an object, by its extension.
+func contentType(name string) string {
+	ext := path.Ext(name)
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// GCS is a Destination under a prefix of a GCS bucket.
+type GCS struct {
+	Bucket *storage.BucketHandle
+	Prefix string
+	// CacheControl is the Cache-Control header objects are served with,
+	// e.g. "no-cache" for records clients should revalidate. GCS serves
+	// public objects without one as cacheable for an hour.
+	CacheControl string
+}
+
+// ParseGCSURL returns the bucket and prefix of a gs://bucket/prefix URL.
+func ParseGCSURL(url string) (string, string, error) {
+	rest, ok := strings.CutPrefix(url, "gs://")
+	if !ok {
+		return "", "", fmt.Errorf("%q is not a gs:// URL", url)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%q has no bucket", url)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+func (g *GCS) List(ctx context.Context) (map[string]string, error) {
+	objects := make(map[string]string)
+	query := &storage.Query{Prefix: g.Prefix}
+	if err := query.SetAttrSelection([]string{"Name", "MD5"}); err != nil {
+		return nil, err
+	}
+	it := g.Bucket.Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects[strings.TrimPrefix(attrs.Name, g.Prefix)] = hex.EncodeToString(attrs.MD5)
+	}
+}
+
+// Write writes an object with its content type and the cache control, and
+// verifies GCS received it intact: GCS rejects an upload whose data doesn't
+// match the CRC32C checksum sent with it, and the checksum of the object it
+// stored is checked too.
+func (g *GCS) Write(ctx context.Context, name string, data []byte) error {
+	obj := g.Bucket.Object(g.Prefix + name)
+	sum := crc32.Checksum(data, crc32cTable)
+	var w *storage.Writer
+	if len(data) > resumableThreshold {
+		// Rewriting an object with the same data is idempotent, so the
+		// chunks of a resumable session are retried whatever the failure,
+		// resuming the upload rather than restarting it.
+		w = obj.Retryer(storage.WithPolicy(storage.RetryAlways)).NewWriter(ctx)
+		w.ChunkSize = googleapi.DefaultUploadChunkSize
+	} else {
+		// Records are small, so each is uploaded in a single request rather
+		// than a resumable session.
+		w = obj.NewWriter(ctx)
+		w.ChunkSize = 0
+	}
+	w.ContentType = contentType(name)
+	w.CacheControl = g.CacheControl
+	w.CRC32C = sum
+	w.SendCRC32C = true
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if got := w.Attrs().CRC32C; got != sum {
+		return fmt.Errorf("%s: %w: got %08x, want %08x", name, ErrChecksumMismatch, got, sum)
+	}
+	return nil
+}
+
+func (g *GCS) Delete(ctx context.Context, name string) error {
+	err := g.Bucket.Object(g.Prefix + name).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Retryable returns whether err is transient: a server error, throttling, a
+// timeout or a corrupted upload, or an error without a status, like a
+// dropped connection.
+func (g *GCS) Retryable(err error) bool {
+	if errors.Is(err, ErrChecksumMismatch) {
+		return true
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled)
+	}
+	// GCS rejects data that doesn't match the checksum sent with it.
+	if apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "CRC32C") {
+		return true
+	}
+	return apiErr.Code >= 500 || apiErr.Code == http.StatusTooManyRequests || apiErr.Code == http.StatusRequestTimeout
+}
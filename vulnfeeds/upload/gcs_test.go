@@ -0,0 +1,173 @@
+package upload
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// fakeGCS is a GCS JSON API server accepting multipart uploads. It records
+// the metadata of each object, and reports corrupt checksums for the first
+// corrupt uploads.
+type fakeGCS struct {
+	mu       sync.Mutex
+	objects  map[string]map[string]any
+	uploads  int
+	corrupt  int
+	contents map[string][]byte
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/bucket/o") {
+		http.NotFound(w, r)
+		return
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	var metadata map[string]any
+	var data []byte
+	for i := 0; ; i++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		buf, _ := io.ReadAll(part)
+		if i == 0 {
+			if err := json.Unmarshal(buf, &metadata); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			data = buf
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads++
+	sum := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+	if f.corrupt > 0 {
+		f.corrupt--
+		sum++
+	}
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], sum)
+	metadata["crc32c"] = base64.StdEncoding.EncodeToString(b[:])
+	metadata["bucket"] = "bucket"
+	name := metadata["name"].(string)
+	f.objects[name] = metadata
+	f.contents[name] = data
+	json.NewEncoder(w).Encode(metadata)
+}
+
+func newFakeGCS(t *testing.T) (*fakeGCS, *storage.Client) {
+	t.Helper()
+	f := &fakeGCS{objects: make(map[string]map[string]any), contents: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := storage.NewClient(context.Background(), option.WithEndpoint(srv.URL+"/storage/v1/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return f, client
+}
+
+func TestGCSWrite(t *testing.T) {
+	f, client := newFakeGCS(t)
+	dest := &GCS{Bucket: client.Bucket("bucket"), Prefix: "osv-output/", CacheControl: "no-cache"}
+	ctx := context.Background()
+
+	for name, wantType := range map[string]string{
+		"CVE-2024-1234.json": "application/json",
+		"all.zip":            "application/zip",
+		"CVE-2024-1234.pb":   "application/x-protobuf",
+	} {
+		if err := dest.Write(ctx, name, []byte("data of "+name)); err != nil {
+			t.Fatalf("Write(%s) error: %v", name, err)
+		}
+		metadata := f.objects["osv-output/"+name]
+		if metadata["contentType"] != wantType || metadata["cacheControl"] != "no-cache" {
+			t.Errorf("metadata of %s = %v, want content type %s and no-cache", name, metadata, wantType)
+		}
+		if string(f.contents["osv-output/"+name]) != "data of "+name {
+			t.Errorf("content of %s = %q", name, f.contents["osv-output/"+name])
+		}
+	}
+
+	f.corrupt = 1
+	err := dest.Write(ctx, "CVE-2024-5678.json", []byte("data"))
+	if !errors.Is(err, ErrChecksumMismatch) || !dest.Retryable(err) {
+		t.Errorf("Write() of a corrupted upload error = %v, want a retryable ErrChecksumMismatch", err)
+	}
+}
+
+func TestUploadToGCSRetriesCorruption(t *testing.T) {
+	f, client := newFakeGCS(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"CVE-2024-1234.json": "{}"})
+	f.corrupt = 2
+	u := &Uploader{Dest: &fakeListGCS{GCS: &GCS{Bucket: client.Bucket("bucket")}}, MaxRetries: 3}
+	stats, err := u.Upload(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	if stats.Uploaded != 1 || f.uploads != 3 {
+		t.Errorf("Upload() uploaded %d in %d requests, want 1 in 3", stats.Uploaded, f.uploads)
+	}
+}
+
+// fakeListGCS is a GCS whose bucket is empty when listed, as the fake
+// server doesn't list objects.
+type fakeListGCS struct {
+	*GCS
+}
+
+func (fakeListGCS) List(context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func TestGCSRetryable(t *testing.T) {
+	g := &GCS{}
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: &googleapi.Error{Code: http.StatusServiceUnavailable}, want: true},
+		{err: &googleapi.Error{Code: http.StatusTooManyRequests}, want: true},
+		{err: &googleapi.Error{Code: http.StatusBadRequest, Message: `Provided CRC32C "AAAAAA==" doesn't match calculated CRC32C "BBBBBB==".`}, want: true},
+		{err: &googleapi.Error{Code: http.StatusBadRequest}, want: false},
+		{err: &googleapi.Error{Code: http.StatusForbidden}, want: false},
+		{err: fmt.Errorf("x: %w", ErrChecksumMismatch), want: true},
+		{err: context.Canceled, want: false},
+		{err: io.ErrUnexpectedEOF, want: true},
+	} {
+		if got := g.Retryable(tc.err); got != tc.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/pubsub"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// Withdrawn is the Kind of a Notification of a record that was created or
+// updated as withdrawn.
+const Withdrawn ChangeKind = "withdrawn"
+
+// Notification is the message published for a change to a record.
+type Notification struct {
+	ID string `json:"id"`
+	// Object is the name of the record's object, relative to the
+	// destination.
+	Object string     `json:"object"`
+	Kind   ChangeKind `json:"change"`
+	// Ecosystems are the ecosystems of the record's affected packages.
+	Ecosystems []string `json:"ecosystems,omitempty"`
+	// SHA256 is the hex SHA-256 of the record's new content.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// NewNotification returns the Notification of a change to an object, or
+// false if the object isn't a record.
+func NewNotification(change Change) (Notification, bool) {
+	if path.Ext(change.Name) != ".json" {
+		return Notification{}, false
+	}
+	n := Notification{
+		ID:     strings.TrimSuffix(path.Base(change.Name), ".json"),
+		Object: change.Name,
+		Kind:   change.Kind,
+	}
+	if change.Kind == Deleted {
+		return n, true
+	}
+	sum := sha256.Sum256(change.Data)
+	n.SHA256 = hex.EncodeToString(sum[:])
+	var record vulns.Vulnerability
+	if err := json.Unmarshal(change.Data, &record); err != nil {
+		// Still worth notifying, by the record's name.
+		return n, true
+	}
+	if record.ID != "" {
+		n.ID = record.ID
+	}
+	if record.Withdrawn != "" {
+		n.Kind = Withdrawn
+	}
+	for _, affected := range record.Affected {
+		if affected.Package != nil && affected.Package.Ecosystem != "" && !slices.Contains(n.Ecosystems, affected.Package.Ecosystem) {
+			n.Ecosystems = append(n.Ecosystems, affected.Package.Ecosystem)
+		}
+	}
+	slices.Sort(n.Ecosystems)
+	return n, true
+}
+
+// PubSub publishes a Notification for each change to a record to a Pub/Sub
+// topic, with the record's ID and the kind of change as the "id" and
+// "change" attributes, so subscriptions can filter on them.
+type PubSub struct {
+	Topic *pubsub.Topic
+}
+
+// OnChange publishes the Notification of a change, to be used as an
+// Uploader's OnChange.
+func (p *PubSub) OnChange(ctx context.Context, change Change) error {
+	n, ok := NewNotification(change)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = p.Topic.Publish(ctx, &pubsub.Message{
+		Data:       data,
+		Attributes: map[string]string{"id": n.ID, "change": string(n.Kind)},
+	}).Get(ctx)
+	return err
+}
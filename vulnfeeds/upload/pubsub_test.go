@@ -0,0 +1,66 @@
+package upload
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestNewNotification(t *testing.T) {
+	tests := []struct {
+		description string
+		change      Change
+		want        Notification
+		wantOK      bool
+	}{
+		{
+			description: "updated record",
+			change: Change{Name: "Debian/CVE-2024-0001.json", Kind: Updated, Data: []byte(`{
+				"id": "CVE-2024-0001",
+				"affected": [
+					{"package": {"name": "curl", "ecosystem": "Debian:12"}},
+					{"package": {"name": "curl", "ecosystem": "Alpine:v3.19"}},
+					{"package": {"name": "libcurl", "ecosystem": "Debian:12"}}
+				]
+			}`)},
+			want: Notification{
+				ID:         "CVE-2024-0001",
+				Object:     "Debian/CVE-2024-0001.json",
+				Kind:       Updated,
+				Ecosystems: []string{"Alpine:v3.19", "Debian:12"},
+			},
+			wantOK: true,
+		},
+		{
+			description: "withdrawn record",
+			change:      Change{Name: "CVE-2024-0002.json", Kind: Created, Data: []byte(`{"id": "CVE-2024-0002", "withdrawn": "2024-06-01T00:00:00Z"}`)},
+			want:        Notification{ID: "CVE-2024-0002", Object: "CVE-2024-0002.json", Kind: Withdrawn},
+			wantOK:      true,
+		},
+		{
+			description: "deleted record",
+			change:      Change{Name: "CVE-2024-0003.json", Kind: Deleted},
+			want:        Notification{ID: "CVE-2024-0003", Object: "CVE-2024-0003.json", Kind: Deleted},
+			wantOK:      true,
+		},
+		{
+			description: "not a record",
+			change:      Change{Name: "modified-since-1h.txt", Kind: Updated, Data: []byte("CVE-2024-0001\n")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got, ok := NewNotification(tt.change)
+			if ok != tt.wantOK {
+				t.Fatalf("NewNotification() ok = %t, want %t", ok, tt.wantOK)
+			}
+			if ok && tt.change.Kind != Deleted && got.SHA256 == "" {
+				t.Errorf("NewNotification() has no SHA-256")
+			}
+			got.SHA256 = ""
+			if diff := gocmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("NewNotification() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
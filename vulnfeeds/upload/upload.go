@@ -0,0 +1,433 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upload publishes a directory of records to a bucket, uploading
+// only the records that changed, with bounded concurrency and rate, retrying
+// failed uploads, and recording completed uploads so an interrupted run
+// resumes where it left off.
+package upload
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// ErrEmptySource is returned by Upload when asked to delete the objects
+// without a file in a directory that has no files.
+var ErrEmptySource = errors.New("no files to upload")
+
+// ErrTooManyDeletions is returned by Upload when it would delete more than
+// MaxDeleteFraction of the objects.
+var ErrTooManyDeletions = errors.New("too many objects to delete")
+
+// Destination is where records are uploaded to, e.g. a GCS prefix. Object
+// names are relative to it.
+type Destination interface {
+	// List returns the MD5 checksum of each object, by name.
+	List(ctx context.Context) (map[string]string, error)
+	// Write writes an object.
+	Write(ctx context.Context, name string, data []byte) error
+	// Delete deletes an object.
+	Delete(ctx context.Context, name string) error
+	// Retryable returns whether an error of Write or Delete is transient.
+	Retryable(err error) bool
+}
+
+// Uploader uploads the files of a directory to a Destination.
+type Uploader struct {
+	Dest Destination
+	// Concurrency is the maximum number of uploads in flight.
+	Concurrency int
+	// RequestsPerSecond is the maximum rate of uploads and deletions, or 0
+	// for no limit.
+	RequestsPerSecond float64
+	// MaxRetries is the number of times a failed upload is retried, with an
+	// exponential backoff starting at Backoff.
+	MaxRetries uint64
+	Backoff    time.Duration
+	// ManifestPath is the file completed uploads are recorded in, so a run
+	// that's interrupted and run again skips them. It's removed once every
+	// upload succeeds, as the objects at the destination are then up to
+	// date. If it's empty, every changed file is uploaded again.
+	ManifestPath string
+	// Delete deletes the objects without a file in the directory. It's
+	// refused if the directory has no files, as an empty directory (e.g.
+	// of a failed conversion) would otherwise delete every record.
+	Delete bool
+	// MaxDeleteFraction, if set, refuses to delete more than this fraction
+	// of the objects, e.g. when the directory was only partly written.
+	MaxDeleteFraction float64
+	// OnChange, if set, is called after each object is created, updated or
+	// deleted, e.g. to notify downstream systems. It's retried like uploads,
+	// and an object whose OnChange fails is counted as failed. The failed
+	// change is recorded in the manifest as pending, and notified by the next
+	// run, although the object is then up to date.
+	OnChange func(ctx context.Context, change Change) error
+}
+
+// ChangeKind is the way an object changed.
+type ChangeKind string
+
+const (
+	Created ChangeKind = "created"
+	Updated ChangeKind = "updated"
+	Deleted ChangeKind = "deleted"
+)
+
+// Change is a change Upload made to an object.
+type Change struct {
+	Name string
+	Kind ChangeKind
+	// Data is the uploaded content, unset for deleted objects.
+	Data []byte
+}
+
+// Stats counts the objects an upload handled.
+type Stats struct {
+	Uploaded  int
+	Unchanged int
+	Resumed   int
+	Deleted   int
+	// Notified counts the changes of an earlier run notified again, as
+	// their OnChange failed.
+	Notified int
+	Failed   int
+}
+
+// manifestEntry is a line of the manifest, recording a completed upload or
+// deletion, and the change still to be notified if its OnChange failed.
+type manifestEntry struct {
+	Name    string     `json:"name"`
+	MD5     string     `json:"md5"`
+	Pending ChangeKind `json:"pending,omitempty"`
+}
+
+// loadManifest returns the latest entry of each object recorded in the
+// manifest at path, which may not exist yet.
+func loadManifest(path string) (map[string]manifestEntry, error) {
+	done := make(map[string]manifestEntry)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry manifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A line cut short by an interrupted run.
+			continue
+		}
+		done[entry.Name] = entry
+	}
+	return done, scanner.Err()
+}
+
+// manifestWriter appends completed uploads to the manifest.
+type manifestWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (w *manifestWriter) record(entry manifestEntry) error {
+	if w == nil {
+		return nil
+	}
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.f.Write(append(buf, '\n'))
+	return err
+}
+
+// localFile is a file to upload.
+type localFile struct {
+	name string
+	path string
+	md5  string
+}
+
+// listFiles returns the files in dir, named by their path relative to it.
+func listFiles(dir string) ([]localFile, error) {
+	var files []localFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := md5.Sum(data)
+		files = append(files, localFile{name: filepath.ToSlash(rel), path: path, md5: hex.EncodeToString(sum[:])})
+		return nil
+	})
+	return files, err
+}
+
+// Upload uploads the files in dir that differ from the objects at the
+// destination and from the uploads recorded in the manifest. Uploads that
+// fail after retrying are counted, and reported together once the rest are
+// done.
+func (u *Uploader) Upload(ctx context.Context, dir string) (Stats, error) {
+	var stats Stats
+	files, err := listFiles(dir)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	remote, err := u.Dest.List(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list the destination: %w", err)
+	}
+	if u.Delete {
+		if err := u.checkDeletions(files, remote); err != nil {
+			return stats, err
+		}
+	}
+
+	var manifest *manifestWriter
+	done := make(map[string]manifestEntry)
+	if u.ManifestPath != "" {
+		if done, err = loadManifest(u.ManifestPath); err != nil {
+			return stats, fmt.Errorf("failed to load manifest: %w", err)
+		}
+		f, err := os.OpenFile(u.ManifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return stats, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		defer f.Close()
+		manifest = &manifestWriter{f: f}
+	}
+
+	var limiter *rate.Limiter
+	if u.RequestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(u.RequestsPerSecond), 1)
+	}
+	var mu sync.Mutex
+	var failures []string
+	fail := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Failed++
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	// do runs op with the rate limit, retrying transient errors.
+	do := func(ctx context.Context, op func(context.Context) error) error {
+		backoff := retry.WithMaxRetries(u.MaxRetries, retry.NewExponential(max(u.Backoff, time.Millisecond)))
+		return retry.Do(ctx, backoff, func(ctx context.Context) error {
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return err
+				}
+			}
+			err := op(ctx)
+			if err != nil && u.Dest.Retryable(err) {
+				return retry.RetryableError(err)
+			}
+			return err
+		})
+	}
+	// notify calls OnChange, recording the change as pending in the manifest
+	// if it fails, and as done otherwise.
+	notify := func(ctx context.Context, change Change, md5 string) error {
+		err := do(ctx, func(ctx context.Context) error { return u.OnChange(ctx, change) })
+		entry := manifestEntry{Name: change.Name, MD5: md5}
+		if err != nil {
+			entry.Pending = change.Kind
+		}
+		if rerr := manifest.record(entry); rerr != nil {
+			return fmt.Errorf("failed to record upload: %w", rerr)
+		}
+		return err
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(max(u.Concurrency, 1))
+	local := make(map[string]bool)
+	for _, file := range files {
+		local[file.name] = true
+		entry := done[file.name]
+		pending := entry.MD5 == file.md5 && entry.Pending != "" && u.OnChange != nil
+		switch {
+		case pending:
+			g.Go(func() error {
+				data, err := os.ReadFile(file.path)
+				if err == nil {
+					err = notify(gCtx, Change{Name: file.name, Kind: entry.Pending, Data: data}, file.md5)
+				}
+				if err != nil {
+					if gCtx.Err() != nil {
+						return gCtx.Err()
+					}
+					fail(file.name, err)
+					return nil
+				}
+				mu.Lock()
+				stats.Notified++
+				mu.Unlock()
+				return nil
+			})
+			continue
+		case remote[file.name] == file.md5:
+			stats.Unchanged++
+			continue
+		case entry.MD5 == file.md5:
+			stats.Resumed++
+			continue
+		}
+		g.Go(func() error {
+			data, err := os.ReadFile(file.path)
+			if err == nil {
+				err = do(gCtx, func(ctx context.Context) error { return u.Dest.Write(ctx, file.name, data) })
+			}
+			if err == nil {
+				if u.OnChange != nil {
+					change := Change{Name: file.name, Kind: Created, Data: data}
+					if _, exists := remote[file.name]; exists {
+						change.Kind = Updated
+					}
+					err = notify(gCtx, change, file.md5)
+				} else if rerr := manifest.record(manifestEntry{Name: file.name, MD5: file.md5}); rerr != nil {
+					return fmt.Errorf("failed to record upload: %w", rerr)
+				}
+			}
+			if err != nil {
+				if gCtx.Err() != nil {
+					return gCtx.Err()
+				}
+				fail(file.name, err)
+				return nil
+			}
+			mu.Lock()
+			stats.Uploaded++
+			mu.Unlock()
+			return nil
+		})
+	}
+	// Deletions whose OnChange failed are notified again, as the objects are
+	// gone from the destination.
+	if u.OnChange != nil {
+		for name, entry := range done {
+			if entry.Pending != Deleted || local[name] {
+				continue
+			}
+			if _, exists := remote[name]; exists {
+				continue
+			}
+			g.Go(func() error {
+				if err := notify(gCtx, Change{Name: name, Kind: Deleted}, ""); err != nil {
+					if gCtx.Err() != nil {
+						return gCtx.Err()
+					}
+					fail(name, err)
+					return nil
+				}
+				mu.Lock()
+				stats.Notified++
+				mu.Unlock()
+				return nil
+			})
+		}
+	}
+	if u.Delete {
+		for name := range remote {
+			if local[name] {
+				continue
+			}
+			g.Go(func() error {
+				err := do(gCtx, func(ctx context.Context) error { return u.Dest.Delete(ctx, name) })
+				if err == nil && u.OnChange != nil {
+					err = notify(gCtx, Change{Name: name, Kind: Deleted}, "")
+				}
+				if err != nil {
+					if gCtx.Err() != nil {
+						return gCtx.Err()
+					}
+					fail(name, err)
+					return nil
+				}
+				mu.Lock()
+				stats.Deleted++
+				mu.Unlock()
+				return nil
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return stats, err
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return stats, fmt.Errorf("%d objects failed, e.g. %s", len(failures), failures[0])
+	}
+	if u.ManifestPath != "" {
+		if err := os.Remove(u.ManifestPath); err != nil {
+			return stats, fmt.Errorf("failed to remove manifest: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// checkDeletions refuses to delete the objects without a file in files if
+// there are no files, or if they're more than MaxDeleteFraction of remote.
+func (u *Uploader) checkDeletions(files []localFile, remote map[string]string) error {
+	if len(files) == 0 {
+		if len(remote) == 0 {
+			return nil
+		}
+		return ErrEmptySource
+	}
+	if u.MaxDeleteFraction <= 0 {
+		return nil
+	}
+	local := make(map[string]bool, len(files))
+	for _, file := range files {
+		local[file.name] = true
+	}
+	extraneous := 0
+	for name := range remote {
+		if !local[name] {
+			extraneous++
+		}
+	}
+	if float64(extraneous) > u.MaxDeleteFraction*float64(len(remote)) {
+		return fmt.Errorf("%w: %d of %d, more than %g", ErrTooManyDeletions, extraneous, len(remote), u.MaxDeleteFraction)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package upload
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+var errPermanent = errors.New("permanent")
+
+// fakeDestination is a Destination in memory, whose writes of an object fail
+// the given number of times first.
+type fakeDestination struct {
+	mu       sync.Mutex
+	objects  map[string]string
+	failures map[string]int
+	writes   []string
+}
+
+func (f *fakeDestination) List(context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	objects := make(map[string]string)
+	for name, sum := range f.objects {
+		objects[name] = sum
+	}
+	return objects, nil
+}
+
+func (f *fakeDestination) Write(_ context.Context, name string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = append(f.writes, name)
+	if f.failures[name] < 0 {
+		return errPermanent
+	}
+	if f.failures[name] > 0 {
+		f.failures[name]--
+		return errors.New("transient")
+	}
+	sum := md5.Sum(data)
+	f.objects[name] = hex.EncodeToString(sum[:])
+	return nil
+}
+
+func (f *fakeDestination) Delete(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, name)
+	return nil
+}
+
+func (f *fakeDestination) Retryable(err error) bool {
+	return !errors.Is(err, errPermanent)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"CVE-2024-0001.json":      "unchanged",
+		"CVE-2024-0002.json":      "changed",
+		"PyPI/CVE-2024-0003.json": "new",
+		"CVE-2024-0004.json":      "flaky",
+	})
+	dest := &fakeDestination{
+		objects: map[string]string{
+			"CVE-2024-0001.json": md5Hex("unchanged"),
+			"CVE-2024-0002.json": md5Hex("before"),
+			"CVE-2024-0005.json": md5Hex("removed"),
+		},
+		failures: map[string]int{"CVE-2024-0004.json": 2},
+	}
+	u := &Uploader{Dest: dest, Concurrency: 2, MaxRetries: 3, Delete: true}
+
+	stats, err := u.Upload(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	if diff := gocmp.Diff(Stats{Uploaded: 3, Unchanged: 1, Deleted: 1}, stats); diff != "" {
+		t.Errorf("Upload() stats mismatch (-want +got):\n%s", diff)
+	}
+	want := map[string]string{
+		"CVE-2024-0001.json":      md5Hex("unchanged"),
+		"CVE-2024-0002.json":      md5Hex("changed"),
+		"PyPI/CVE-2024-0003.json": md5Hex("new"),
+		"CVE-2024-0004.json":      md5Hex("flaky"),
+	}
+	if diff := gocmp.Diff(want, dest.objects); diff != "" {
+		t.Errorf("Upload() objects mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUploadResumes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"CVE-2024-0001.json": "first",
+		"CVE-2024-0002.json": "second",
+	})
+	manifestPath := filepath.Join(t.TempDir(), "manifest.jsonl")
+	dest := &fakeDestination{
+		objects:  map[string]string{},
+		failures: map[string]int{"CVE-2024-0002.json": -1},
+	}
+	u := &Uploader{Dest: dest, Concurrency: 1, MaxRetries: 3, ManifestPath: manifestPath}
+
+	stats, err := u.Upload(context.Background(), dir)
+	if err == nil {
+		t.Errorf("Upload() with a permanent failure succeeded")
+	}
+	if diff := gocmp.Diff(Stats{Uploaded: 1, Failed: 1}, stats); diff != "" {
+		t.Errorf("Upload() stats mismatch (-want +got):\n%s", diff)
+	}
+	if len(dest.writes) != 2 {
+		t.Errorf("Upload() wrote %q, want a permanent failure not to be retried", dest.writes)
+	}
+
+	// Resuming against a destination that doesn't list what was uploaded
+	// (e.g. an eventually consistent listing) skips what the manifest
+	// records.
+	dest.objects = map[string]string{}
+	dest.failures = nil
+	dest.writes = nil
+	stats, err = u.Upload(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Upload() resumed error: %v", err)
+	}
+	if diff := gocmp.Diff(Stats{Uploaded: 1, Resumed: 1}, stats); diff != "" {
+		t.Errorf("Upload() resumed stats mismatch (-want +got):\n%s", diff)
+	}
+	if diff := gocmp.Diff([]string{"CVE-2024-0002.json"}, dest.writes); diff != "" {
+		t.Errorf("Upload() resumed writes mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Errorf("Upload() kept the manifest of a completed upload")
+	}
+}
+
+func TestUploadOnChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"CVE-2024-0001.json": "unchanged",
+		"CVE-2024-0002.json": "changed",
+		"CVE-2024-0003.json": "new",
+	})
+	dest := &fakeDestination{
+		objects: map[string]string{
+			"CVE-2024-0001.json": md5Hex("unchanged"),
+			"CVE-2024-0002.json": md5Hex("before"),
+			"CVE-2024-0004.json": md5Hex("removed"),
+		},
+	}
+	var mu sync.Mutex
+	changes := make(map[string]ChangeKind)
+	u := &Uploader{Dest: dest, Concurrency: 2, Delete: true, OnChange: func(_ context.Context, change Change) error {
+		mu.Lock()
+		defer mu.Unlock()
+		changes[change.Name] = change.Kind
+		return nil
+	}}
+	if _, err := u.Upload(context.Background(), dir); err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	want := map[string]ChangeKind{
+		"CVE-2024-0002.json": Updated,
+		"CVE-2024-0003.json": Created,
+		"CVE-2024-0004.json": Deleted,
+	}
+	if diff := gocmp.Diff(want, changes); diff != "" {
+		t.Errorf("Upload() changes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUploadRetriesNotifications(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"CVE-2024-0001.json": "new",
+		"CVE-2024-0002.json": "notified",
+	})
+	manifestPath := filepath.Join(t.TempDir(), "manifest.jsonl")
+	dest := &fakeDestination{
+		objects: map[string]string{
+			"CVE-2024-0003.json": md5Hex("removed"),
+		},
+	}
+	var mu sync.Mutex
+	var changes []Change
+	failing := map[string]bool{"CVE-2024-0001.json": true, "CVE-2024-0003.json": true}
+	u := &Uploader{Dest: dest, Concurrency: 1, Delete: true, ManifestPath: manifestPath, OnChange: func(_ context.Context, change Change) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing[change.Name] {
+			return errPermanent
+		}
+		changes = append(changes, change)
+		return nil
+	}}
+	stats, err := u.Upload(context.Background(), dir)
+	if err == nil {
+		t.Errorf("Upload() with failed notifications succeeded")
+	}
+	if diff := gocmp.Diff(Stats{Uploaded: 1, Failed: 2}, stats); diff != "" {
+		t.Errorf("Upload() stats mismatch (-want +got):\n%s", diff)
+	}
+
+	// The objects are now up to date, but the failed changes are still
+	// notified, and only them.
+	failing = nil
+	changes = nil
+	dest.writes = nil
+	stats, err = u.Upload(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Upload() rerun error: %v", err)
+	}
+	if diff := gocmp.Diff(Stats{Unchanged: 1, Notified: 2}, stats); diff != "" {
+		t.Errorf("Upload() rerun stats mismatch (-want +got):\n%s", diff)
+	}
+	if len(dest.writes) != 0 {
+		t.Errorf("Upload() rerun wrote %q, want only notifications", dest.writes)
+	}
+	want := map[string]ChangeKind{
+		"CVE-2024-0001.json": Created,
+		"CVE-2024-0003.json": Deleted,
+	}
+	got := make(map[string]ChangeKind)
+	for _, change := range changes {
+		got[change.Name] = change.Kind
+		if change.Kind == Created && string(change.Data) != "new" {
+			t.Errorf("Upload() rerun notified %s with %q, want its content", change.Name, change.Data)
+		}
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Upload() rerun changes mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Errorf("Upload() kept the manifest once every change was notified")
+	}
+}
+
+func TestParseGCSURL(t *testing.T) {
+	for _, tc := range []struct {
+		url, bucket, prefix string
+		wantErr             bool
+	}{
+		{url: "gs://bucket/osv-output", bucket: "bucket", prefix: "osv-output/"},
+		{url: "gs://bucket/osv-output/", bucket: "bucket", prefix: "osv-output/"},
+		{url: "gs://bucket", bucket: "bucket"},
+		{url: "bucket/osv-output", wantErr: true},
+		{url: "gs:///osv-output", wantErr: true},
+	} {
+		bucket, prefix, err := ParseGCSURL(tc.url)
+		if (err != nil) != tc.wantErr || bucket != tc.bucket || prefix != tc.prefix {
+			t.Errorf("ParseGCSURL(%q) = %q, %q, %v, want %q, %q, error %v", tc.url, bucket, prefix, err, tc.bucket, tc.prefix, tc.wantErr)
+		}
+	}
+}
+
+func TestUploadRefusesDeletions(t *testing.T) {
+	remote := map[string]string{
+		"CVE-2024-0001.json": md5Hex("kept"),
+		"CVE-2024-0002.json": md5Hex("removed"),
+		"CVE-2024-0003.json": md5Hex("removed"),
+		"CVE-2024-0004.json": md5Hex("removed"),
+	}
+	tests := []struct {
+		name              string
+		files             map[string]string
+		maxDeleteFraction float64
+		wantErr           error
+	}{
+		{
+			name:    "empty source",
+			wantErr: ErrEmptySource,
+		},
+		{
+			name:              "too many deletions",
+			files:             map[string]string{"CVE-2024-0001.json": "kept"},
+			maxDeleteFraction: 0.5,
+			wantErr:           ErrTooManyDeletions,
+		},
+		{
+			name:              "within the limit",
+			files:             map[string]string{"CVE-2024-0001.json": "kept"},
+			maxDeleteFraction: 0.75,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			objects := make(map[string]string)
+			for name, sum := range remote {
+				objects[name] = sum
+			}
+			dest := &fakeDestination{objects: objects}
+			u := &Uploader{Dest: dest, Delete: true, MaxDeleteFraction: tc.maxDeleteFraction}
+
+			_, err := u.Upload(context.Background(), dir)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Upload() error = %v, want %v", err, tc.wantErr)
+			}
+			wantObjects := remote
+			if tc.wantErr == nil {
+				wantObjects = map[string]string{"CVE-2024-0001.json": md5Hex("kept")}
+			}
+			if diff := gocmp.Diff(wantObjects, dest.objects); diff != "" {
+				t.Errorf("Upload() objects mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
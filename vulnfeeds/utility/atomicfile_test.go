@@ -0,0 +1,45 @@
+package utility
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "record.json")
+	if err := os.WriteFile(fileName, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed write leaves the existing file, and no temporary file, behind.
+	err := WriteFileAtomic(fileName, 0644, func(w io.Writer) error {
+		io.WriteString(w, `{"id": "CVE-`)
+		return errors.New("injected failure")
+	})
+	if err == nil {
+		t.Errorf("WriteFileAtomic() with a failing write succeeded")
+	}
+	if data, _ := os.ReadFile(fileName); string(data) != "old" {
+		t.Errorf("File after a failed write = %q, want %q", data, "old")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Failed write left %d files, want 1", len(entries))
+	}
+
+	if err := WriteFileAtomic(fileName, 0644, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}); err != nil {
+		t.Fatalf("WriteFileAtomic() error: %v", err)
+	}
+	if data, _ := os.ReadFile(fileName); string(data) != "new" {
+		t.Errorf("File after a write = %q, want %q", data, "new")
+	}
+	if info, err := os.Stat(fileName); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("File mode = %v (%v), want 0644", info.Mode().Perm(), err)
+	}
+}
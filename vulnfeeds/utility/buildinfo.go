@@ -0,0 +1,77 @@
+package utility
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// The version and revision of release builds, set with
+// -ldflags "-X github.com/google/osv/vulnfeeds/utility.version=... -X github.com/google/osv/vulnfeeds/utility.revision=..."
+// where the VCS information isn't available to the Go toolchain (e.g. Docker
+// builds of the vulnfeeds directory alone).
+var (
+	version  string
+	revision string
+)
+
+// BuildInfo identifies the converter build a binary was built from.
+type BuildInfo struct {
+	// The release version, or the Go module version ("(devel)" for builds
+	// from a checkout).
+	Version string `json:"version,omitempty"`
+	// The VCS revision.
+	Revision string `json:"revision,omitempty"`
+	// The time of the VCS revision, in RFC 3339 format.
+	Time string `json:"time,omitempty"`
+	// Whether the checkout had uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// ReadBuildInfo returns the build of the running binary, preferring the
+// version and revision set at link time to those recorded by the toolchain.
+func ReadBuildInfo() BuildInfo {
+	var b BuildInfo
+	if info, ok := debug.ReadBuildInfo(); ok {
+		b.Version = info.Main.Version
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				b.Revision = setting.Value
+			case "vcs.time":
+				b.Time = setting.Value
+			case "vcs.modified":
+				b.Modified = setting.Value == "true"
+			}
+		}
+	}
+	if version != "" {
+		b.Version = version
+	}
+	if revision != "" {
+		b.Revision = revision
+	}
+	return b
+}
+
+// String describes the build, e.g. "v1.2.3 (revision abc123, 2025-01-01T00:00:00Z, modified)".
+func (b BuildInfo) String() string {
+	v := b.Version
+	if v == "" {
+		v = "unknown"
+	}
+	var details []string
+	if b.Revision != "" {
+		details = append(details, "revision "+b.Revision)
+	}
+	if b.Time != "" {
+		details = append(details, b.Time)
+	}
+	if b.Modified {
+		details = append(details, "modified")
+	}
+	if len(details) == 0 {
+		return v
+	}
+	return fmt.Sprintf("%s (%s)", v, strings.Join(details, ", "))
+}
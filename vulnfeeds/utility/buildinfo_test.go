@@ -0,0 +1,29 @@
+package utility
+
+import "testing"
+
+func TestBuildInfoString(t *testing.T) {
+	tests := []struct {
+		build BuildInfo
+		want  string
+	}{
+		{BuildInfo{}, "unknown"},
+		{BuildInfo{Version: "(devel)"}, "(devel)"},
+		{BuildInfo{Version: "v1.2.3", Revision: "abc123"}, "v1.2.3 (revision abc123)"},
+		{BuildInfo{Version: "v1.2.3", Revision: "abc123", Time: "2025-01-01T00:00:00Z", Modified: true}, "v1.2.3 (revision abc123, 2025-01-01T00:00:00Z, modified)"},
+	}
+	for _, tc := range tests {
+		if got := tc.build.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q, want %q", tc.build, got, tc.want)
+		}
+	}
+}
+
+func TestReadBuildInfoLinkTime(t *testing.T) {
+	defer func(v, r string) { version, revision = v, r }(version, revision)
+	version, revision = "v1.2.3", "abc123"
+	got := ReadBuildInfo()
+	if got.Version != "v1.2.3" || got.Revision != "abc123" {
+		t.Errorf("ReadBuildInfo() = %+v, want the link time version and revision", got)
+	}
+}
@@ -0,0 +1,213 @@
+package utility
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+const (
+	// The most log entries held in memory waiting to be delivered. Entries
+	// logged while the queue is full are spilled straight to disk.
+	logQueueSize = 10000
+	// The most entries delivered to Cloud Logging in one flush.
+	logBatchSize = 500
+	// How many times a batch that failed to deliver is retried, backing off
+	// exponentially, before it's spilled to disk.
+	logRetries      = 4
+	logBackoff      = 500 * time.Millisecond
+	logCloseTimeout = 30 * time.Second
+	// Overrides where undeliverable log entries are spilled to.
+	LogSpillPathEnv = "LOG_SPILL_PATH"
+)
+
+// entryWriter synchronously writes a batch of log entries.
+type entryWriter interface {
+	WriteEntries(entries []logging.Entry) error
+}
+
+// gcloudEntryWriter writes entries to Cloud Logging, reporting whether any of
+// them failed to be written.
+type gcloudEntryWriter struct {
+	logger *logging.Logger
+}
+
+func (w gcloudEntryWriter) WriteEntries(entries []logging.Entry) error {
+	for _, e := range entries {
+		w.logger.Log(e)
+	}
+	return w.logger.Flush()
+}
+
+// spilledEntry is a log entry written to the spill file, one JSON object per
+// line.
+type spilledEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Severity  string    `json:"severity"`
+	Payload   any       `json:"payload"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// logDelivery delivers log entries in the background, so a slow or failing
+// Cloud Logging never blocks or fails the caller. Entries are buffered in a
+// bounded queue and delivered in batches, retrying with backoff. Entries that
+// can't be delivered (or queued) are appended to a local spill file instead,
+// so the record of what happened is never lost.
+type logDelivery struct {
+	writer    entryWriter
+	queue     chan logging.Entry
+	retries   int
+	backoff   time.Duration
+	spillPath string
+
+	mu        sync.RWMutex // Guards closing queue against concurrent enqueues.
+	closed    bool
+	abort     chan struct{}
+	abortOnce sync.Once
+	done      chan struct{}
+
+	spillMu   sync.Mutex
+	spillFile *os.File
+	spilled   int
+}
+
+func newLogDelivery(writer entryWriter, spillPath string) *logDelivery {
+	d := &logDelivery{
+		writer:    writer,
+		queue:     make(chan logging.Entry, logQueueSize),
+		retries:   logRetries,
+		backoff:   logBackoff,
+		spillPath: spillPath,
+		abort:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// enqueue queues e for delivery without blocking, spilling it if the queue
+// is full or closed.
+func (d *logDelivery) enqueue(e logging.Entry) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.spill([]logging.Entry{e}, errors.New("logger closed"))
+		return
+	}
+	select {
+	case d.queue <- e:
+	default:
+		d.spill([]logging.Entry{e}, errors.New("log queue full"))
+	}
+}
+
+func (d *logDelivery) run() {
+	defer close(d.done)
+	for e := range d.queue {
+		batch := []logging.Entry{e}
+	fill:
+		for len(batch) < logBatchSize {
+			select {
+			case e, ok := <-d.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, e)
+			default:
+				break fill
+			}
+		}
+		d.deliver(batch)
+	}
+}
+
+// deliver writes batch, retrying with exponential backoff, and spills it if
+// it still fails or delivery is aborted.
+func (d *logDelivery) deliver(batch []logging.Entry) {
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-d.abort:
+			d.spill(batch, errors.New("delivery aborted on close"))
+			return
+		default:
+		}
+		err := d.writer.WriteEntries(batch)
+		if err == nil {
+			return
+		}
+		if attempt == d.retries {
+			d.spill(batch, err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-d.abort:
+		}
+		backoff *= 2
+	}
+}
+
+// spill appends entries to the spill file, falling back to stderr if even
+// that fails.
+func (d *logDelivery) spill(entries []logging.Entry, reason error) {
+	d.spillMu.Lock()
+	defer d.spillMu.Unlock()
+	if d.spillFile == nil {
+		f, err := os.OpenFile(d.spillPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("Failed to open log spill file %q: %v", d.spillPath, err)
+		} else {
+			log.Printf("Spilling undelivered log entries to %s: %v", d.spillPath, reason)
+			d.spillFile = f
+		}
+	}
+	for _, e := range entries {
+		d.spilled++
+		if d.spillFile == nil {
+			log.Printf("%v: %v", e.Severity, e.Payload)
+			continue
+		}
+		data, err := json.Marshal(spilledEntry{Timestamp: e.Timestamp, Severity: e.Severity.String(), Payload: e.Payload, Error: reason.Error()})
+		if err == nil {
+			_, err = d.spillFile.Write(append(data, '\n'))
+		}
+		if err != nil {
+			log.Printf("%v: %v", e.Severity, e.Payload)
+		}
+	}
+}
+
+// Close delivers the queued entries, waiting up to timeout before spilling
+// whatever is left, and returns how many entries were spilled in total.
+func (d *logDelivery) Close(timeout time.Duration) int {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-time.After(timeout):
+		d.abortOnce.Do(func() { close(d.abort) })
+		<-d.done
+	}
+
+	d.spillMu.Lock()
+	defer d.spillMu.Unlock()
+	if d.spillFile != nil {
+		d.spillFile.Close()
+		d.spillFile = nil
+	}
+	return d.spilled
+}
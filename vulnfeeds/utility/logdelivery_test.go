@@ -0,0 +1,142 @@
+package utility
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// fakeEntryWriter fails its first failures writes, then records the
+// entries written. A blocking writer waits until release is closed.
+type fakeEntryWriter struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	written  []string
+	release  chan struct{}
+}
+
+func (w *fakeEntryWriter) WriteEntries(entries []logging.Entry) error {
+	if w.release != nil {
+		<-w.release
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempts++
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("unavailable")
+	}
+	for _, e := range entries {
+		w.written = append(w.written, e.Payload.(string))
+	}
+	return nil
+}
+
+func readSpill(t *testing.T, spillPath string) []string {
+	t.Helper()
+	f, err := os.Open(spillPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var payloads []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e spilledEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Failed to decode spilled entry %q: %v", scanner.Text(), err)
+		}
+		payloads = append(payloads, e.Payload.(string))
+	}
+	return payloads
+}
+
+func TestLogDelivery(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		wantWritten []string
+		wantSpilled []string
+	}{
+		{
+			name:        "delivered",
+			wantWritten: []string{"a", "b"},
+		},
+		{
+			name:        "retried",
+			failures:    2,
+			wantWritten: []string{"a", "b"},
+		},
+		{
+			name:        "spilled",
+			failures:    100,
+			wantSpilled: []string{"a", "b"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+			writer := &fakeEntryWriter{failures: tc.failures, release: make(chan struct{})}
+			d := newLogDelivery(writer, spillPath)
+			d.backoff = time.Millisecond
+			d.enqueue(logging.Entry{Payload: "a"})
+			d.enqueue(logging.Entry{Payload: "b"})
+			close(writer.release)
+
+			if spilled := d.Close(time.Minute); spilled != len(tc.wantSpilled) {
+				t.Errorf("Close() = %d, want %d", spilled, len(tc.wantSpilled))
+			}
+			if diff := gocmp.Diff(tc.wantWritten, writer.written); diff != "" {
+				t.Errorf("written mismatch (-want +got):\n%s", diff)
+			}
+			if diff := gocmp.Diff(tc.wantSpilled, readSpill(t, spillPath)); diff != "" {
+				t.Errorf("spilled mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLogDeliveryNeverBlocks(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	// A writer that hangs until the delivery is closed.
+	writer := &fakeEntryWriter{release: make(chan struct{})}
+	d := newLogDelivery(writer, spillPath)
+
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < logQueueSize+logBatchSize+10; i++ {
+			d.enqueue(logging.Entry{Payload: "entry"})
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("enqueue() blocked on a hung writer")
+	}
+
+	// Closing times out, spilling whatever wasn't delivered.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(writer.release)
+	}()
+	spilled := d.Close(10 * time.Millisecond)
+	if got := len(readSpill(t, spillPath)); got != spilled || spilled == 0 {
+		t.Errorf("Close() = %d, with %d entries in the spill file", spilled, got)
+	}
+	if delivered := len(writer.written); delivered+spilled != logQueueSize+logBatchSize+10 {
+		t.Errorf("%d delivered and %d spilled, want %d in total", delivered, spilled, logQueueSize+logBatchSize+10)
+	}
+}
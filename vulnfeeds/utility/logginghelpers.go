@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 
 	"cloud.google.com/go/logging"
@@ -23,16 +24,52 @@ func CreateLoggerWrapper(logID string) (LoggerWrapper, func()) {
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
+	spillPath, spillPathSet := os.LookupEnv(LogSpillPathEnv)
+	if !spillPathSet {
+		spillPath = filepath.Join(os.TempDir(), logID+"-log-spill.jsonl")
+	}
+	build := ReadBuildInfo()
+	log.Printf("%s build: %s", logID, build)
+	logger := client.Logger(logID, logging.CommonLabels(map[string]string{
+		"converter_version":  build.Version,
+		"converter_revision": build.Revision,
+	}))
 	wrapper := LoggerWrapper{
-		GCloudLogger: client.Logger(logID),
+		GCloudLogger: logger,
+		delivery:     newLogDelivery(gcloudEntryWriter{logger}, spillPath),
+	}
+	return wrapper, func() {
+		wrapper.close()
+		client.Close()
 	}
-	return wrapper, func() { client.Close() }
 }
 
 // LoggerWrapper wraps the Logger provided by google cloud
 // Will default to the go stdout and stderr logging if GCP logger is not set
 type LoggerWrapper struct {
 	GCloudLogger *logging.Logger
+	// Delivers entries to GCloudLogger in the background, if set.
+	delivery *logDelivery
+}
+
+// log sends an entry to Cloud Logging, in the background if possible.
+func (wrapper LoggerWrapper) log(e logging.Entry) {
+	if wrapper.delivery != nil {
+		wrapper.delivery.enqueue(e)
+		return
+	}
+	wrapper.GCloudLogger.Log(e)
+}
+
+// close delivers the outstanding entries, reporting any that had to be
+// spilled to disk.
+func (wrapper LoggerWrapper) close() {
+	if wrapper.delivery == nil {
+		return
+	}
+	if spilled := wrapper.delivery.Close(logCloseTimeout); spilled > 0 {
+		log.Printf("%d log entries couldn't be delivered and were spilled to %s", spilled, wrapper.delivery.spillPath)
+	}
 }
 
 // Infof prints Info level log
@@ -42,7 +79,7 @@ func (wrapper LoggerWrapper) Infof(format string, a ...any) {
 		return
 	}
 
-	wrapper.GCloudLogger.Log(logging.Entry{
+	wrapper.log(logging.Entry{
 		Severity: logging.Info,
 		Payload:  fmt.Sprintf(format, a...) + "\n",
 	})
@@ -55,7 +92,7 @@ func (wrapper LoggerWrapper) Warnf(format string, a ...any) {
 		return
 	}
 
-	wrapper.GCloudLogger.Log(logging.Entry{
+	wrapper.log(logging.Entry{
 		Severity: logging.Warning,
 		Payload:  fmt.Sprintf(format, a...) + "\n",
 	})
@@ -68,10 +105,14 @@ func (wrapper LoggerWrapper) Fatalf(format string, a ...any) {
 		return
 	}
 
-	wrapper.GCloudLogger.Log(logging.Entry{
+	wrapper.log(logging.Entry{
 		Severity: logging.Error,
 		Payload:  fmt.Sprintf(format, a...) + "\n" + string(debug.Stack()),
 	})
+	if wrapper.delivery != nil {
+		wrapper.close()
+		os.Exit(1)
+	}
 	err := wrapper.GCloudLogger.Flush()
 	if err != nil {
 		log.Fatalln("Failed to flush logger")
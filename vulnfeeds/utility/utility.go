@@ -1,6 +1,9 @@
 package utility
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 // SliceEqual returns true if two slices have identical items in the same order
 func SliceEqual[K comparable](a []K, b []K) bool {
@@ -41,3 +44,14 @@ func IsRepoURL(url string) bool {
 
 	return re.MatchString(url)
 }
+
+// SplitList splits a comma separated flag value, dropping empty entries.
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,18 @@
+package utility
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+func TestSplitList(t *testing.T) {
+	got := SplitList(" v3.18, v3.19,,edge ")
+	want := []string{"v3.18", "v3.19", "edge"}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("SplitList() mismatch (-want +got):\n%s", diff)
+	}
+	if got := SplitList(""); got != nil {
+		t.Errorf("SplitList(\"\") = %q, want nil", got)
+	}
+}
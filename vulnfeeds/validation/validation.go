@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation checks generated records against the acceptance rules of
+// the production importer (gcp/workers/importer and osv/sources.py), so records
+// it would reject are caught when they're generated rather than failing
+// silently downstream.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ossf/osv-schema/bindings/go/osvschema"
+	"github.com/package-url/packageurl-go"
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/ecosystems"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/vercmp"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// Finding classifies a problem. Where the importer records a quality finding
+// for it, the name matches osv.ImportFindings.
+type Finding string
+
+const (
+	InvalidJSON    Finding = "INVALID_JSON"
+	InvalidPackage Finding = "INVALID_PACKAGE"
+	InvalidPURL    Finding = "INVALID_PURL"
+	InvalidCommit  Finding = "INVALID_COMMIT"
+	InvalidRange   Finding = "INVALID_RANGE"
+	BadAliasedCVE  Finding = "BAD_ALIASED_CVE"
+	// The following are rejected by the schema or Datastore, but not recorded
+	// as a finding by the importer.
+	InvalidID       Finding = "INVALID_ID"
+	InvalidTime     Finding = "INVALID_TIMESTAMP"
+	FutureTimestamp Finding = "FUTURE_TIMESTAMP"
+	EntityTooLarge  Finding = "ENTITY_TOO_LARGE"
+)
+
+// MaxEntitySize is the maximum size of a Datastore entity. Records are
+// approximated by the size of their JSON.
+const MaxEntitySize = 1048572
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Problem is a reason the importer would reject, or flag, a record.
+type Problem struct {
+	ID      string
+	Finding Finding
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.ID, p.Finding, p.Message)
+}
+
+// Check returns the problems the importer would have with v, with future
+// timestamps judged relative to now.
+func Check(v *vulns.Vulnerability, now time.Time) []Problem {
+	var problems []Problem
+	add := func(finding Finding, format string, a ...any) {
+		problems = append(problems, Problem{ID: v.ID, Finding: finding, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if !ids.Valid(v.ID) {
+		add(InvalidID, "invalid ID %q", v.ID)
+	}
+
+	for _, ts := range []struct {
+		name  string
+		value string
+	}{{"modified", v.Modified}, {"published", v.Published}, {"withdrawn", v.Withdrawn}} {
+		if ts.value == "" {
+			if ts.name == "modified" {
+				add(InvalidTime, "missing modified timestamp")
+			}
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, ts.value)
+		if err != nil {
+			add(InvalidTime, "invalid %s timestamp %q", ts.name, ts.value)
+			continue
+		}
+		if t.After(now) {
+			add(FutureTimestamp, "%s timestamp %s is in the future", ts.name, ts.value)
+		}
+	}
+
+	for _, alias := range v.Aliases {
+		if strings.HasPrefix(alias, "CVE-") && !ids.IsCVE(alias) {
+			add(BadAliasedCVE, "invalid CVE alias %q", alias)
+		}
+	}
+
+	for _, severity := range v.Severity {
+		if !slices.Contains([]osvschema.SeverityType{osvschema.SeverityCVSSV2, osvschema.SeverityCVSSV3, osvschema.SeverityCVSSV4}, osvschema.SeverityType(severity.Type)) {
+			add(InvalidJSON, "invalid severity type %q", severity.Type)
+		}
+	}
+	for _, reference := range v.References {
+		if !validReferenceType(reference.Type) {
+			add(InvalidJSON, "invalid reference type %q for %s", reference.Type, reference.URL)
+		}
+	}
+
+	for i, affected := range v.Affected {
+		problems = append(problems, checkAffected(v.ID, i, affected)...)
+	}
+
+	if data, err := json.Marshal(v); err != nil {
+		add(InvalidJSON, "failed to marshal: %v", err)
+	} else if len(data) > MaxEntitySize {
+		add(EntityTooLarge, "record is %d bytes, more than the %d allowed", len(data), MaxEntitySize)
+	}
+
+	return problems
+}
+
+func validReferenceType(t string) bool {
+	switch osvschema.ReferenceType(t) {
+	case osvschema.ReferenceAdvisory, osvschema.ReferenceArticle, osvschema.ReferenceDetection,
+		osvschema.ReferenceDiscussion, osvschema.ReferenceReport, osvschema.ReferenceFix,
+		osvschema.ReferenceIntroduced, osvschema.ReferenceGit, osvschema.ReferencePackage,
+		osvschema.ReferenceEvidence, osvschema.ReferenceWeb:
+		return true
+	}
+	return false
+}
+
+func checkAffected(id string, i int, affected vulns.Affected) []Problem {
+	var problems []Problem
+	add := func(finding Finding, format string, a ...any) {
+		problems = append(problems, Problem{ID: id, Finding: finding, Message: fmt.Sprintf("affected[%d]: ", i) + fmt.Sprintf(format, a...)})
+	}
+
+	if pkg := affected.Package; pkg != nil {
+		if pkg.Name == "" {
+			add(InvalidPackage, "missing package name")
+		}
+		if base := ecosystems.Base(pkg.Ecosystem); base.Pending() {
+			add(InvalidPackage, "ecosystem %q is not in the OSV schema yet", pkg.Ecosystem)
+		} else if !base.Known() {
+			add(InvalidPackage, "unknown ecosystem %q", pkg.Ecosystem)
+		}
+		if pkg.Purl != "" {
+			if _, err := packageurl.FromString(pkg.Purl); err != nil {
+				add(InvalidPURL, "invalid PURL %q: %v", pkg.Purl, err)
+			}
+		}
+	} else if !slices.ContainsFunc(affected.Ranges, func(r vulns.AffectedRange) bool { return r.Type == string(osvschema.RangeGit) }) {
+		add(InvalidPackage, "no package and no GIT range")
+	}
+
+	for j, r := range affected.Ranges {
+		switch osvschema.RangeType(r.Type) {
+		case osvschema.RangeSemVer, osvschema.RangeEcosystem:
+		case osvschema.RangeGit:
+			if r.Repo == "" {
+				add(InvalidRange, "ranges[%d]: GIT range without a repo", j)
+			}
+		default:
+			add(InvalidRange, "ranges[%d]: invalid type %q", j, r.Type)
+		}
+		hasIntroduced := false
+		for _, e := range r.Events {
+			set := 0
+			for _, value := range []string{e.Introduced, e.Fixed, e.LastAffected, e.Limit} {
+				if value == "" {
+					continue
+				}
+				set++
+				if r.Type == string(osvschema.RangeGit) && value != "0" && value != "*" && !commitRegexp.MatchString(value) {
+					add(InvalidCommit, "ranges[%d]: %q is not a full commit hash", j, value)
+				}
+			}
+			if set != 1 {
+				add(InvalidRange, "ranges[%d]: event %+v must set exactly one field", j, e)
+			}
+			if e.Introduced != "" {
+				hasIntroduced = true
+			}
+		}
+		if !hasIntroduced {
+			add(InvalidRange, "ranges[%d]: no introduced event", j)
+		}
+		if c, ok := rangeComparator(affected, r); ok {
+			for _, err := range checkOrder(c, r.Events) {
+				add(InvalidRange, "ranges[%d]: %v", j, err)
+			}
+		}
+	}
+
+	return problems
+}
+
+// rangeComparator returns the Comparator the versions of a range are ordered
+// by, if it's known.
+func rangeComparator(affected vulns.Affected, r vulns.AffectedRange) (vulns.Comparator, bool) {
+	switch osvschema.RangeType(r.Type) {
+	case osvschema.RangeSemVer:
+		return vercmp.SemVer, true
+	case osvschema.RangeEcosystem:
+		if affected.Package != nil {
+			return vulns.ComparatorFor(affected.Package.Ecosystem)
+		}
+	}
+	return nil, false
+}
+
+// checkOrder checks each introduced event against the fixed or last affected
+// event that ends it.
+func checkOrder(c vulns.Comparator, events []vulns.Event) []error {
+	var errs []error
+	for k, e := range events {
+		if e.Introduced == "" {
+			continue
+		}
+		for _, end := range events[k+1:] {
+			if end.Introduced != "" {
+				break
+			}
+			if end.Fixed == "" && end.LastAffected == "" {
+				continue
+			}
+			if err := c.CheckRange(e.Introduced, end.Fixed, end.LastAffected); err != nil {
+				errs = append(errs, err)
+			}
+			break
+		}
+	}
+	return errs
+}
+
+// CheckAll returns the problems of all of records, in order.
+func CheckAll(records []*vulns.Vulnerability, now time.Time) []Problem {
+	var problems []Problem
+	for _, v := range records {
+		problems = append(problems, Check(v, now)...)
+	}
+	return problems
+}
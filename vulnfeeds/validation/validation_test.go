@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+var now = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func validRecord() *vulns.Vulnerability {
+	return &vulns.Vulnerability{
+		ID:        "CVE-2024-1234",
+		Modified:  "2025-02-01T00:00:00Z",
+		Published: "2024-01-01T00:00:00Z",
+		Aliases:   []string{"GHSA-xxxx-yyyy-zzzz"},
+		Affected: []vulns.Affected{
+			{
+				Package: &vulns.AffectedPackage{Name: "curl", Ecosystem: "Debian:12", Purl: "pkg:deb/debian/curl"},
+				Ranges: []vulns.AffectedRange{{
+					Type:   "ECOSYSTEM",
+					Events: []vulns.Event{{Introduced: "0"}, {Fixed: "7.88.1-10+deb12u5"}},
+				}},
+			},
+			{
+				Ranges: []vulns.AffectedRange{{
+					Type:   "GIT",
+					Repo:   "https://github.com/curl/curl",
+					Events: []vulns.Event{{Introduced: "0"}, {Fixed: "1111111111111111111111111111111111111111"}},
+				}},
+			},
+		},
+		References: []vulns.Reference{{Type: "WEB", URL: "https://curl.se/docs/CVE-2024-1234.html"}},
+	}
+}
+
+func TestCheckValid(t *testing.T) {
+	if problems := Check(validRecord(), now); len(problems) != 0 {
+		t.Errorf("Check() of a valid record = %v", problems)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		description string
+		modify      func(v *vulns.Vulnerability)
+		want        []Finding
+	}{
+		{
+			description: "bad ID",
+			modify:      func(v *vulns.Vulnerability) { v.ID = "cve 2024 1234" },
+			want:        []Finding{InvalidID},
+		},
+		{
+			description: "future modified",
+			modify:      func(v *vulns.Vulnerability) { v.Modified = "2025-03-02T00:00:00Z" },
+			want:        []Finding{FutureTimestamp},
+		},
+		{
+			description: "unparseable published",
+			modify:      func(v *vulns.Vulnerability) { v.Published = "yesterday" },
+			want:        []Finding{InvalidTime},
+		},
+		{
+			description: "bad CVE alias",
+			modify:      func(v *vulns.Vulnerability) { v.Aliases = append(v.Aliases, "CVE-24-1") },
+			want:        []Finding{BadAliasedCVE},
+		},
+		{
+			description: "unknown ecosystem and bad PURL",
+			modify: func(v *vulns.Vulnerability) {
+				v.Affected[0].Package.Ecosystem = "Debain:12"
+				v.Affected[0].Package.Purl = "curl"
+			},
+			want: []Finding{InvalidPackage, InvalidPURL},
+		},
+		{
+			description: "ecosystem pending its addition to the schema",
+			modify:      func(v *vulns.Vulnerability) { v.Affected[0].Package.Ecosystem = "Fedora:39" },
+			want:        []Finding{InvalidPackage},
+		},
+		{
+			description: "abbreviated commit",
+			modify:      func(v *vulns.Vulnerability) { v.Affected[1].Ranges[0].Events[1].Fixed = "1111111" },
+			want:        []Finding{InvalidCommit},
+		},
+		{
+			description: "range without introduced",
+			modify:      func(v *vulns.Vulnerability) { v.Affected[0].Ranges[0].Events = v.Affected[0].Ranges[0].Events[1:] },
+			want:        []Finding{InvalidRange},
+		},
+		{
+			description: "inverted ecosystem range",
+			modify: func(v *vulns.Vulnerability) {
+				v.Affected[0].Ranges[0].Events = []vulns.Event{{Introduced: "7.88.1-10+deb12u5"}, {Fixed: "7.88.1-10"}}
+			},
+			want: []Finding{InvalidRange},
+		},
+		{
+			description: "empty semver range",
+			modify: func(v *vulns.Vulnerability) {
+				v.Affected[0].Ranges = append(v.Affected[0].Ranges, vulns.AffectedRange{
+					Type:   "SEMVER",
+					Events: []vulns.Event{{Introduced: "0"}, {Fixed: "1.0.0"}, {Introduced: "2.0.0"}, {Fixed: "2.0.0"}},
+				})
+			},
+			want: []Finding{InvalidRange},
+		},
+		{
+			description: "oversized",
+			modify:      func(v *vulns.Vulnerability) { v.Details = strings.Repeat("x", MaxEntitySize) },
+			want:        []Finding{EntityTooLarge},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			v := validRecord()
+			tt.modify(v)
+			var got []Finding
+			for _, p := range Check(v, now) {
+				got = append(got, p.Finding)
+			}
+			if diff := gocmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Check() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vcr records the HTTP responses of the feeds downloaders fetch into
+// fixtures, and replays them in tests, so downloaders can be tested without
+// hitting live endpoints.
+//
+// A fixture is a JSON file of the interactions recorded, in order. Replaying
+// answers each request with the first unused interaction of the same method,
+// URL and body. Request headers (e.g. API keys) aren't recorded.
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// RecordEnv is the environment variable that, when set, makes Use record
+// fixtures from the live endpoints instead of replaying them.
+const RecordEnv = "VULNFEEDS_VCR_RECORD"
+
+// Mode is whether a Recorder records or replays.
+type Mode int
+
+const (
+	// Replay answers requests from the fixture, failing those it has no
+	// response for.
+	Replay Mode = iota
+	// Record sends requests on, recording their responses.
+	Record
+)
+
+// Request is a recorded request.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response. Its body is in Body if it's text, or in
+// BodyBase64 otherwise.
+type Response struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Fixture is the content of a fixture file.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// droppedHeaders aren't recorded: they no longer describe the recorded
+// body, or are specific to the recording session.
+var droppedHeaders = []string{"Content-Length", "Content-Encoding", "Set-Cookie", "Date"}
+
+// Recorder is an http.RoundTripper that records or replays the interactions
+// of a fixture.
+type Recorder struct {
+	path string
+	mode Mode
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	fixture  Fixture
+	replayed []bool
+}
+
+// New returns a Recorder of the fixture at path. Replaying loads the
+// fixture; recording sends requests on to base (http.DefaultTransport if
+// nil), starting the fixture afresh.
+func New(path string, mode Mode, base http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, base: base}
+	if mode == Record {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	r.replayed = make([]bool, len(r.fixture.Interactions))
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := Request{Method: req.Method, URL: req.URL.String()}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		recorded.Body = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if r.mode == Record {
+		return r.record(req, recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.fixture.Interactions {
+		if r.replayed[i] || interaction.Request != recorded {
+			continue
+		}
+		r.replayed[i] = true
+		return interaction.Response.toHTTP(req)
+	}
+	return nil, fmt.Errorf("vcr: no recorded response for %s %s in %s", req.Method, recorded.URL, r.path)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	base := r.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+
+	response := Response{Status: res.StatusCode, Header: res.Header.Clone()}
+	for _, h := range droppedHeaders {
+		response.Header.Del(h)
+	}
+	if len(response.Header) == 0 {
+		response.Header = nil
+	}
+	if utf8.Valid(body) {
+		response.Body = string(body)
+	} else {
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, Interaction{Request: recorded, Response: response})
+	return res, nil
+}
+
+func (resp Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(resp.BodyBase64); err != nil {
+			return nil, fmt.Errorf("vcr: invalid recorded body of %s %s: %w", req.Method, req.URL, err)
+		}
+	}
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(resp.Status) + " " + http.StatusText(resp.Status),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the fixture. It does nothing when
+// replaying.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// Use makes http.DefaultClient, which the downloaders use, replay the
+// fixture at path for the rest of a test. With RecordEnv set, it records the
+// fixture from the live endpoints instead, saving it once the test ends.
+func Use(t testing.TB, path string) *Recorder {
+	t.Helper()
+	mode := Replay
+	if os.Getenv(RecordEnv) != "" {
+		mode = Record
+	}
+	previous := http.DefaultClient.Transport
+	r, err := New(path, mode, previous)
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	http.DefaultClient.Transport = r
+	t.Cleanup(func() {
+		http.DefaultClient.Transport = previous
+		if err := r.Save(); err != nil {
+			t.Errorf("Failed to save fixture %s: %v", path, err)
+		}
+	})
+	return r
+}
@@ -0,0 +1,91 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get(%q) error: %v", url, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "call "+strings.Repeat("!", calls))
+		case "/binary":
+			w.Write([]byte{0x1f, 0x8b, 0xff, 0x00})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	recorder, err := New(path, Record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: recorder}
+	var want []string
+	for _, p := range []string{"/text", "/text", "/binary", "/missing"} {
+		_, body := get(t, client, srv.URL+p)
+		want = append(want, body)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	srv.Close()
+	replayer, err := New(path, Replay, nil)
+	if err != nil {
+		t.Fatalf("New() of the recorded fixture error: %v", err)
+	}
+	client = &http.Client{Transport: replayer}
+	for i, p := range []string{"/text", "/text", "/binary", "/missing"} {
+		status, body := get(t, client, srv.URL+p)
+		if body != want[i] {
+			t.Errorf("replayed GET %s = %q, want %q", p, body, want[i])
+		}
+		if p == "/missing" && status != http.StatusNotFound {
+			t.Errorf("replayed GET %s status = %d, want %d", p, status, http.StatusNotFound)
+		}
+	}
+	// Every interaction was replayed once.
+	if _, err := client.Get(srv.URL + "/text"); err == nil {
+		t.Errorf("replayed GET of an exhausted request succeeded")
+	}
+}
+
+func TestUse(t *testing.T) {
+	t.Setenv(RecordEnv, "")
+	previous := http.DefaultClient.Transport
+	t.Run("replay", func(t *testing.T) {
+		Use(t, "../test_data/vcr/example.json")
+		status, body := get(t, http.DefaultClient, "https://example.com/feed.json")
+		if status != http.StatusOK || body != `{"feed": []}` {
+			t.Errorf("replayed GET = %d %q", status, body)
+		}
+	})
+	if http.DefaultClient.Transport != previous {
+		t.Errorf("Use() didn't restore http.DefaultClient")
+	}
+}
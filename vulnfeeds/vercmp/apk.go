@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vercmp compares package versions by the ordering of the ecosystem
+// they belong to.
+package vercmp
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var apkVersionParser = regexp.MustCompile(`^(\d+(?:\.\d+)*)([a-z]?)((?:_?(?:alpha|beta|pre|rc|cvs|svn|git|hg|p)\d*)*)(?:[-.]r(\d+))?$`)
+var apkSuffixParser = regexp.MustCompile(`_?(alpha|beta|pre|rc|cvs|svn|git|hg|p)(\d*)`)
+
+// apkSuffixOrder orders version suffixes: pre-release suffixes sort before
+// the bare version (0), post-release ones after.
+var apkSuffixOrder = map[string]int{
+	"alpha": -4, "beta": -3, "pre": -2, "rc": -1,
+	"cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5,
+}
+
+type apkVersion struct {
+	numbers  []int
+	letter   string
+	suffixes [][2]int // Suffix order and number.
+	revision int
+}
+
+// ValidAPK returns whether v is a valid Alpine package version.
+func ValidAPK(v string) bool {
+	_, err := parseAPK(v)
+	return err == nil
+}
+
+func parseAPK(v string) (apkVersion, error) {
+	m := apkVersionParser.FindStringSubmatch(v)
+	if m == nil {
+		return apkVersion{}, fmt.Errorf("invalid Alpine version %q", v)
+	}
+	var parsed apkVersion
+	for _, n := range strings.Split(m[1], ".") {
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			return apkVersion{}, fmt.Errorf("invalid Alpine version %q: %w", v, err)
+		}
+		parsed.numbers = append(parsed.numbers, i)
+	}
+	parsed.letter = m[2]
+	for _, s := range apkSuffixParser.FindAllStringSubmatch(m[3], -1) {
+		n, _ := strconv.Atoi(s[2])
+		parsed.suffixes = append(parsed.suffixes, [2]int{apkSuffixOrder[s[1]], n})
+	}
+	if m[4] != "" {
+		parsed.revision, _ = strconv.Atoi(m[4])
+	}
+	return parsed, nil
+}
+
+// APK compares two Alpine package versions (as apk does, for the versions
+// found in the secdb), returning -1, 0 or 1.
+func APK(a, b string) (int, error) {
+	va, err := parseAPK(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseAPK(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(va.numbers) && i < len(vb.numbers); i++ {
+		if c := cmp.Compare(va.numbers[i], vb.numbers[i]); c != 0 {
+			return c, nil
+		}
+	}
+	if c := cmp.Compare(len(va.numbers), len(vb.numbers)); c != 0 {
+		return c, nil
+	}
+	if c := strings.Compare(va.letter, vb.letter); c != 0 {
+		return c, nil
+	}
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		// A missing suffix sorts between pre- and post-release suffixes.
+		var sa, sb [2]int
+		if i < len(va.suffixes) {
+			sa = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			sb = vb.suffixes[i]
+		}
+		if c := cmp.Compare(sa[0], sb[0]); c != 0 {
+			return c, nil
+		}
+		if c := cmp.Compare(sa[1], sb[1]); c != 0 {
+			return c, nil
+		}
+	}
+	return cmp.Compare(va.revision, vb.revision), nil
+}
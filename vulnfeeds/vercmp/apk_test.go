@@ -0,0 +1,33 @@
+package vercmp
+
+import "testing"
+
+func TestAPK(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3-r0", "1.2.3-r0", 0},
+		{"1.2.3-r1", "1.2.3-r0", 1},
+		{"1.2.10-r0", "1.2.9-r0", 1},
+		{"1.2-r0", "1.2.1-r0", -1},
+		{"1.2.3a-r0", "1.2.3-r0", 1},
+		{"1.2.3_rc1-r0", "1.2.3-r0", -1},
+		{"1.2.3_p1-r0", "1.2.3-r0", 1},
+		{"1.2.3_alpha2-r0", "1.2.3_beta1-r0", -1},
+		{"2.0", "1.9.9-r10", 1},
+	}
+	for _, tt := range tests {
+		got, err := APK(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("APK(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("APK(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := APK("not a version", "1.0"); err == nil {
+		t.Errorf("APK() of an invalid version succeeded")
+	}
+}
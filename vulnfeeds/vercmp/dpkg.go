@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type dpkgVersion struct {
+	epoch    int
+	upstream string
+	revision string
+}
+
+func parseDpkg(v string) (dpkgVersion, error) {
+	var parsed dpkgVersion
+	v = strings.TrimSpace(v)
+	if e, rest, found := strings.Cut(v, ":"); found {
+		epoch, err := strconv.Atoi(e)
+		if err != nil || epoch < 0 {
+			return dpkgVersion{}, fmt.Errorf("invalid epoch in Debian version %q", v)
+		}
+		parsed.epoch, v = epoch, rest
+	}
+	parsed.upstream = v
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		parsed.upstream, parsed.revision = v[:i], v[i+1:]
+	}
+	if parsed.upstream == "" || !isDigit(parsed.upstream[0]) {
+		return dpkgVersion{}, fmt.Errorf("invalid Debian version %q", v)
+	}
+	return parsed, nil
+}
+
+// Dpkg compares two Debian package versions ("[epoch:]upstream[-revision]")
+// as dpkg does, returning -1, 0 or 1.
+func Dpkg(a, b string) (int, error) {
+	va, err := parseDpkg(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseDpkg(b)
+	if err != nil {
+		return 0, err
+	}
+	if c := cmp.Compare(va.epoch, vb.epoch); c != 0 {
+		return c, nil
+	}
+	if c := dpkgVerrevcmp(va.upstream, vb.upstream); c != 0 {
+		return c, nil
+	}
+	return dpkgVerrevcmp(va.revision, vb.revision), nil
+}
+
+// dpkgOrder is the weight of a character outside of a numeric run: "~" sorts
+// before anything (even the end of the version), then letters, then other
+// characters.
+func dpkgOrder(c byte) int {
+	switch {
+	case isDigit(c):
+		return 0
+	case ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+		return int(c)
+	case c == '~':
+		return -1
+	}
+	return int(c) + 256
+}
+
+// dpkgVerrevcmp is a port of dpkg's verrevcmp, which compares alternating
+// non-numeric and numeric runs of an upstream version or revision.
+func dpkgVerrevcmp(a, b string) int {
+	for len(a) > 0 || len(b) > 0 {
+		for (len(a) > 0 && !isDigit(a[0])) || (len(b) > 0 && !isDigit(b[0])) {
+			var ac, bc int
+			if len(a) > 0 {
+				ac = dpkgOrder(a[0])
+			}
+			if len(b) > 0 {
+				bc = dpkgOrder(b[0])
+			}
+			if ac != bc {
+				return cmp.Compare(ac, bc)
+			}
+			a, b = a[1:], b[1:]
+		}
+		for len(a) > 0 && a[0] == '0' {
+			a = a[1:]
+		}
+		for len(b) > 0 && b[0] == '0' {
+			b = b[1:]
+		}
+		var first int
+		for len(a) > 0 && isDigit(a[0]) && len(b) > 0 && isDigit(b[0]) {
+			if first == 0 {
+				first = cmp.Compare(a[0], b[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+		if len(a) > 0 && isDigit(a[0]) {
+			return 1
+		}
+		if len(b) > 0 && isDigit(b[0]) {
+			return -1
+		}
+		if first != 0 {
+			return first
+		}
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package vercmp
+
+import "testing"
+
+func TestDpkg(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3-1", "1.2.3-1", 0},
+		{"1.2.3-2", "1.2.3-1", 1},
+		{"1.2.10-1", "1.2.9-1", 1},
+		{"1:1.0-1", "2.0-1", 1},
+		{"1.0~rc1-1", "1.0-1", -1},
+		{"1.0~rc1", "1.0~~", 1},
+		{"1.0a", "1.0+", -1},
+		{"1.0", "1.0-0", 0},
+		{"1.0+dfsg-1", "1.0-1", 1},
+		{"2.36-9+deb12u4", "2.36-9+deb12u10", -1},
+		{"1.001", "1.1", 0},
+		{"1.0-1ubuntu0.1", "1.0-1", 1},
+	}
+	for _, tt := range tests {
+		got, err := Dpkg(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("Dpkg(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Dpkg(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	for _, v := range []string{"", "x:1.0", "abc"} {
+		if _, err := Dpkg(v, "1.0"); err == nil {
+			t.Errorf("Dpkg(%q, \"1.0\"): want error", v)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var kernelParser = regexp.MustCompile(`^(\d+(?:\.\d+)*)(?:-rc(\d+))?$`)
+
+// Kernel compares two Linux kernel versions (e.g. 6.1, 6.1.80 or 6.8-rc1),
+// returning -1, 0 or 1. Missing components are zero, so 6.1 is 6.1.0, and
+// release candidates precede their release.
+func Kernel(a, b string) (int, error) {
+	ma := kernelParser.FindStringSubmatch(a)
+	if ma == nil {
+		return 0, fmt.Errorf("invalid kernel version %q", a)
+	}
+	mb := kernelParser.FindStringSubmatch(b)
+	if mb == nil {
+		return 0, fmt.Errorf("invalid kernel version %q", b)
+	}
+	ca, cb := strings.Split(ma[1], "."), strings.Split(mb[1], ".")
+	for i := 0; i < len(ca) || i < len(cb); i++ {
+		// Leading zeros are trimmed, so zero and missing components are "".
+		var na, nb string
+		if i < len(ca) {
+			na = strings.TrimLeft(ca[i], "0")
+		}
+		if i < len(cb) {
+			nb = strings.TrimLeft(cb[i], "0")
+		}
+		if c := compareNumeric(na, nb); c != 0 {
+			return c, nil
+		}
+	}
+	switch {
+	case ma[2] == mb[2]:
+		return 0, nil
+	case ma[2] == "":
+		return 1, nil
+	case mb[2] == "":
+		return -1, nil
+	}
+	return compareNumeric(strings.TrimLeft(ma[2], "0"), strings.TrimLeft(mb[2], "0")), nil
+}
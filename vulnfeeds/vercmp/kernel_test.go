@@ -0,0 +1,37 @@
+package vercmp
+
+import "testing"
+
+func TestKernel(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"6.1", "6.1", 0},
+		{"6.1", "6.1.0", 0},
+		{"6.1.80", "6.1.9", 1},
+		{"6.1.80", "6.2", -1},
+		{"6.10", "6.9", 1},
+		{"2.6.39", "3.0", -1},
+		{"2.6.32.71", "2.6.32.8", 1},
+		{"6.8-rc1", "6.8", -1},
+		{"6.8-rc2", "6.8-rc10", -1},
+		{"6.8-rc7", "6.7.12", 1},
+		{"6.20", "7.0", -1},
+	}
+	for _, tt := range tests {
+		got, err := Kernel(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("Kernel(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Kernel(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	for _, v := range []string{"", "6.1.*", "v6.1", "6.1-rc", "6.1.y"} {
+		if _, err := Kernel(v, "6.1"); err == nil {
+			t.Errorf("Kernel(%q, \"6.1\"): want error", v)
+		}
+	}
+}
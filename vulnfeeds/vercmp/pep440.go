@@ -0,0 +1,35 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"fmt"
+
+	pep440 "github.com/aquasecurity/go-pep440-version"
+)
+
+// PEP440 compares two Python package versions by PEP 440, returning -1, 0
+// or 1.
+func PEP440(a, b string) (int, error) {
+	va, err := pep440.Parse(a)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PEP 440 version %q: %w", a, err)
+	}
+	vb, err := pep440.Parse(b)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PEP 440 version %q: %w", b, err)
+	}
+	return va.Compare(vb), nil
+}
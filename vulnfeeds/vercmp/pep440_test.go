@@ -0,0 +1,30 @@
+package vercmp
+
+import "testing"
+
+func TestPEP440(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.0", "2.0", 0},
+		{"2.0.0rc1", "2.0.0", -1},
+		{"2.0.post1", "2.0", 1},
+		{"1.0.dev1", "1.0a1", -1},
+		{"1!1.0", "2.0", 1},
+		{"2.10", "2.9", 1},
+	}
+	for _, tt := range tests {
+		got, err := PEP440(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("PEP440(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("PEP440(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := PEP440("not a version", "1.0"); err == nil {
+		t.Errorf("PEP440() of an invalid version succeeded")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// RPMVersion compares the versions or releases of RPMs, returning -1, 0 or
+// 1. It's a port of rpm's rpmvercmp: versions are compared segment by
+// segment, where segments are runs of digits (compared numerically) or
+// letters (compared lexically), numeric segments are newer than alphabetic
+// ones, "~" sorts before anything (even the end of the version), and "^"
+// sorts after the end of the version but before anything else.
+func RPMVersion(a, b string) int {
+	if a == b {
+		return 0
+	}
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && !isAlnum(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case len(a) == 0:
+				return -1
+			case len(b) == 0:
+				return 1
+			case a[0] != '^':
+				return 1
+			case b[0] != '^':
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if len(a) == 0 || len(b) == 0 {
+			break
+		}
+
+		numeric := isDigit(a[0])
+		segment := func(s string) (string, string) {
+			i := 0
+			for i < len(s) && (numeric && isDigit(s[i]) || !numeric && isAlnum(s[i]) && !isDigit(s[i])) {
+				i++
+			}
+			return s[:i], s[i:]
+		}
+		var segA, segB string
+		segA, a = segment(a)
+		segB, b = segment(b)
+		if segB == "" {
+			// The segments are of different types.
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		if numeric {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				if len(segA) < len(segB) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// RPM compares two RPM versions, each an optional epoch, a version and an
+// optional release ("[epoch:]version[-release]"), returning -1, 0 or 1. A
+// release is only compared when both versions have one, as rpm does.
+func RPM(a, b string) (int, error) {
+	ea, va, ra, err := splitEVR(a)
+	if err != nil {
+		return 0, err
+	}
+	eb, vb, rb, err := splitEVR(b)
+	if err != nil {
+		return 0, err
+	}
+	if c := cmp.Compare(ea, eb); c != 0 {
+		return c, nil
+	}
+	if c := RPMVersion(va, vb); c != 0 {
+		return c, nil
+	}
+	if ra == "" || rb == "" {
+		return 0, nil
+	}
+	return RPMVersion(ra, rb), nil
+}
+
+func splitEVR(v string) (epoch int, version, release string, err error) {
+	if e, rest, found := strings.Cut(v, ":"); found {
+		epoch, err = strconv.Atoi(e)
+		if err != nil || epoch < 0 {
+			return 0, "", "", fmt.Errorf("invalid epoch in RPM version %q", v)
+		}
+		v = rest
+	}
+	version = v
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		version, release = v[:i], v[i+1:]
+	}
+	if version == "" {
+		return 0, "", "", fmt.Errorf("invalid RPM version %q", v)
+	}
+	return epoch, version, release, nil
+}
@@ -0,0 +1,71 @@
+package vercmp
+
+import "testing"
+
+func TestRPMVersion(t *testing.T) {
+	// Cases from rpm's own rpmvercmp tests.
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"2.0.1", "2.0", 1},
+		{"2.0", "2.0.1", -1},
+		{"2.0.1a", "2.0.1", 1},
+		{"5.5p1", "5.5p2", -1},
+		{"5.5p10", "5.5p2", 1},
+		{"10xyz", "10.1xyz", -1},
+		{"xyz10", "xyz10.1", -1},
+		{"1.0aa", "1.0a", 1},
+		{"1b.fc17", "1.fc17", -1},
+		{"1.0", "1.0a", -1},
+		{"2_0", "2_0", 0},
+		{"2.0", "2_0", 0},
+		{"a", "1", -1},
+		{"001", "1", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~rc1~git123", "1.0~rc1", -1},
+		{"1.0^", "1.0", 1},
+		{"1.0^git1", "1.0^git2", -1},
+		{"1.0^git1", "1.01", -1},
+		{"1.0^20160101", "1.0.1", -1},
+		{"1.0~rc1^git1", "1.0~rc1", 1},
+	}
+	for _, tt := range tests {
+		if got := RPMVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("RPMVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := RPMVersion(tt.b, tt.a); got != -tt.want {
+			t.Errorf("RPMVersion(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestRPM(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.0.7-25.el9", "3.0.7-25.el9", 0},
+		{"1:3.0.7-25.el9", "3.0.7-27.el9", 1},
+		{"3.0.7-25.el9", "3.0.7-27.el9", -1},
+		{"3.0.7", "3.0.7-27.el9", 0},
+		{"3.0.10", "3.0.7-27.el9", 1},
+		{"1.2-rc1-3", "1.2-rc1-10", -1},
+	}
+	for _, tt := range tests {
+		got, err := RPM(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("RPM(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RPM(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := RPM("x:1.0", "1.0"); err == nil {
+		t.Errorf("RPM with an invalid epoch: want error")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var semverParser = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// SemVer compares two semantic versions by SemVer 2.0.0 precedence,
+// returning -1, 0 or 1. A leading "v" is allowed, and build metadata is
+// ignored.
+func SemVer(a, b string) (int, error) {
+	ma := semverParser.FindStringSubmatch(a)
+	if ma == nil {
+		return 0, fmt.Errorf("invalid semantic version %q", a)
+	}
+	mb := semverParser.FindStringSubmatch(b)
+	if mb == nil {
+		return 0, fmt.Errorf("invalid semantic version %q", b)
+	}
+	for i := 1; i <= 3; i++ {
+		if c := compareNumeric(ma[i], mb[i]); c != 0 {
+			return c, nil
+		}
+	}
+	return comparePrerelease(ma[4], mb[4]), nil
+}
+
+// compareNumeric compares runs of digits without leading zeros, which may be
+// too long for an int.
+func compareNumeric(a, b string) int {
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// comparePrerelease compares pre-release versions, where a version without
+// one has precedence over any version with one.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	ia, ib := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ia) && i < len(ib); i++ {
+		na, nb := isNumeric(ia[i]), isNumeric(ib[i])
+		var c int
+		switch {
+		case na && nb:
+			c = compareNumeric(strings.TrimLeft(ia[i], "0"), strings.TrimLeft(ib[i], "0"))
+		case na:
+			c = -1
+		case nb:
+			c = 1
+		default:
+			c = strings.Compare(ia[i], ib[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(ia), len(ib))
+}
+
+func isNumeric(s string) bool {
+	for i := range len(s) {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
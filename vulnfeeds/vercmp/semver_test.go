@@ -0,0 +1,41 @@
+package vercmp
+
+import "testing"
+
+func TestSemVer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3+build.1", "1.2.3", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		// The ordering example of SemVer 2.0.0.
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"18446744073709551616.0.0", "18446744073709551615.0.0", 1},
+	}
+	for _, tt := range tests {
+		got, err := SemVer(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("SemVer(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SemVer(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	for _, v := range []string{"1.2", "01.2.3", "1.2.3-", "latest"} {
+		if _, err := SemVer(v, "1.0.0"); err == nil {
+			t.Errorf("SemVer(%q, \"1.0.0\"): want error", v)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// ecosystemPURLTypes maps OSV ecosystems to the purl type of their packages,
+// as osv/purl_helpers.py does.
+var ecosystemPURLTypes = map[string]string{
+	"AlmaLinux":   "rpm",
+	"Alpine":      "apk",
+	"Bitnami":     "bitnami",
+	"Chainguard":  "apk",
+	"ConanCenter": "conan",
+	"CRAN":        "cran",
+	"crates.io":   "cargo",
+	"Debian":      "deb",
+	"Go":          "golang",
+	"Hackage":     "hackage",
+	"Hex":         "hex",
+	"Mageia":      "rpm",
+	"Maven":       "maven",
+	"npm":         "npm",
+	"NuGet":       "nuget",
+	"openSUSE":    "rpm",
+	"Packagist":   "composer",
+	"Pub":         "pub",
+	"PyPI":        "pypi",
+	"Red Hat":     "rpm",
+	"Rocky Linux": "rpm",
+	"RubyGems":    "gem",
+	"SUSE":        "rpm",
+	"SwiftURL":    "swift",
+	"Ubuntu":      "deb",
+	"Wolfi":       "apk",
+}
+
+// knownPURLType reports whether purls of type t name packages of an OSV
+// ecosystem, so can be matched against records by purl.
+func knownPURLType(t string) bool {
+	if _, ok := legacyPURLTypes[t]; ok {
+		return true
+	}
+	for _, known := range ecosystemPURLTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+// packageIndex counts the records affecting each package name, by ecosystem.
+// Names are lowercased, and ecosystems have no release suffix.
+type packageIndex map[string]map[string]int
+
+func newPackageIndex(records []*vulns.Vulnerability) packageIndex {
+	index := make(packageIndex)
+	for _, v := range records {
+		if v.Withdrawn != "" {
+			continue
+		}
+		for _, affected := range v.Affected {
+			if affected.Package == nil || affected.Package.Name == "" {
+				continue
+			}
+			name := strings.ToLower(affected.Package.Name)
+			ecosystem, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+			if index[name] == nil {
+				index[name] = make(map[string]int)
+			}
+			index[name][ecosystem]++
+		}
+	}
+	return index
+}
+
+// infer returns the package name and ecosystem a purl of a generic or unknown
+// type most likely names: the ecosystem with the most records affecting a
+// package of its name (with its namespace, if there's a match, e.g.
+// pkg:generic/github.com/gin-gonic/gin), breaking ties by ecosystem name.
+func (index packageIndex) infer(purl packageurl.PackageURL) (name string, ecosystem string, ok bool) {
+	candidates := []string{purl.Name}
+	if purl.Namespace != "" {
+		candidates = []string{purl.Namespace + "/" + purl.Name, purl.Name}
+	}
+	for _, candidate := range candidates {
+		counts := index[strings.ToLower(candidate)]
+		if len(counts) == 0 {
+			continue
+		}
+		ecosystems := make([]string, 0, len(counts))
+		for e := range counts {
+			ecosystems = append(ecosystems, e)
+		}
+		sort.Slice(ecosystems, func(i, j int) bool {
+			if counts[ecosystems[i]] != counts[ecosystems[j]] {
+				return counts[ecosystems[i]] > counts[ecosystems[j]]
+			}
+			return ecosystems[i] < ecosystems[j]
+		})
+		return candidate, ecosystems[0], true
+	}
+	return "", "", false
+}
+
+// inferredMatch reports whether affected is for the package name of
+// ecosystem, as inferred for a generic purl.
+func inferredMatch(affected vulns.Affected, name string, ecosystem string) bool {
+	if affected.Package == nil {
+		return false
+	}
+	base, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+	return base == ecosystem && strings.EqualFold(affected.Package.Name, name)
+}
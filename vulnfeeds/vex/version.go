@@ -0,0 +1,42 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/osv/vulnfeeds/vercmp"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// comparator returns the comparator of versions in a range of the given type
+// of an affected package of the given ecosystem: semantic versioning for
+// SEMVER ranges, and the ecosystem's own ordering for ECOSYSTEM ranges, by
+// vulns.ComparatorFor, with PEP 440 for PyPI. Ecosystems without one can't
+// be matched, as comparing distribution versions (with epochs, tildes and
+// releases) generically would get their statuses wrong.
+func comparator(ecosystem, rangeType string) (vulns.Comparator, error) {
+	if rangeType == "SEMVER" {
+		return vercmp.SemVer, nil
+	}
+	if c, ok := vulns.ComparatorFor(ecosystem); ok {
+		return c, nil
+	}
+	if base, _, _ := strings.Cut(ecosystem, ":"); base == "PyPI" {
+		return vercmp.PEP440, nil
+	}
+	return nil, fmt.Errorf("no version ordering for ecosystem %q", ecosystem)
+}
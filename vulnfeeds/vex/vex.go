@@ -0,0 +1,451 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vex matches the components of an SBOM (CycloneDX or SPDX JSON)
+// against OSV records by purl and version, and emits VEX statements saying
+// whether each component is affected by, not affected by, or fixed for each
+// vulnerability.
+package vex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+// Status is the VEX status of a component for a vulnerability, as named by
+// OpenVEX.
+type Status string
+
+const (
+	Affected    Status = "affected"
+	NotAffected Status = "not_affected"
+	Fixed       Status = "fixed"
+)
+
+// Component is a package in an SBOM.
+type Component struct {
+	// Ref is the component's identifier in the SBOM (a CycloneDX bom-ref or
+	// an SPDX SPDXID).
+	Ref     string
+	Purl    string
+	Version string
+}
+
+// Statement is the status of a component for a vulnerability.
+type Statement struct {
+	Vulnerability string
+	Aliases       []string
+	Component     Component
+	Status        Status
+	// InferredEcosystem is the ecosystem the component was assumed to be of,
+	// if its purl is of a generic or unknown type (e.g. pkg:generic/zlib).
+	InferredEcosystem string
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Version    string               `json:"version"`
+	Purl       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type spdxPackage struct {
+	SPDXID       string `json:"SPDXID"`
+	VersionInfo  string `json:"versionInfo"`
+	ExternalRefs []struct {
+		ReferenceType    string `json:"referenceType"`
+		ReferenceLocator string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+// ParseSBOM returns the components with a purl of a CycloneDX or SPDX JSON
+// SBOM.
+func ParseSBOM(data []byte) ([]Component, error) {
+	var sbom struct {
+		BOMFormat   string               `json:"bomFormat"`
+		Components  []cycloneDXComponent `json:"components"`
+		SPDXVersion string               `json:"spdxVersion"`
+		Packages    []spdxPackage        `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &sbom); err != nil {
+		return nil, err
+	}
+	var components []Component
+	switch {
+	case sbom.BOMFormat == "CycloneDX":
+		var walk func([]cycloneDXComponent)
+		walk = func(cs []cycloneDXComponent) {
+			for _, c := range cs {
+				if c.Purl != "" {
+					components = append(components, Component{Ref: c.BOMRef, Purl: c.Purl, Version: c.Version})
+				}
+				walk(c.Components)
+			}
+		}
+		walk(sbom.Components)
+	case sbom.SPDXVersion != "":
+		for _, p := range sbom.Packages {
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					components = append(components, Component{Ref: p.SPDXID, Purl: ref.ReferenceLocator, Version: p.VersionInfo})
+				}
+			}
+		}
+	default:
+		return nil, errors.New("neither a CycloneDX nor an SPDX SBOM")
+	}
+	return components, nil
+}
+
+// legacyPURLTypes maps the types of legacy purl forms still produced by some
+// tools to the type and namespace the purl spec gives them, e.g.
+// pkg:alpine/curl to pkg:apk/alpine/curl.
+var legacyPURLTypes = map[string][2]string{
+	"alpine": {"apk", "alpine"},
+}
+
+// canonicalPURL returns p in the form the purl spec gives it, accepting
+// legacy forms.
+func canonicalPURL(p packageurl.PackageURL) packageurl.PackageURL {
+	if canonical, ok := legacyPURLTypes[p.Type]; ok && p.Namespace == "" {
+		p.Type, p.Namespace = canonical[0], canonical[1]
+	}
+	return p
+}
+
+// samePackage reports whether two purls name the same package, ignoring
+// versions, qualifiers and subpaths.
+func samePackage(a, b packageurl.PackageURL) bool {
+	a, b = canonicalPURL(a), canonicalPURL(b)
+	return a.Type == b.Type && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// componentVersion returns the version of c, preferring its purl's.
+func componentVersion(c Component, purl packageurl.PackageURL) string {
+	if purl.Version != "" {
+		return purl.Version
+	}
+	return c.Version
+}
+
+// status returns the status of version for an affected entry: affected if
+// it's listed or in a range, fixed if it's at or after a fix, and not
+// affected otherwise.
+func status(affected vulns.Affected, version string) (Status, error) {
+	for _, v := range affected.Versions {
+		if v == version {
+			return Affected, nil
+		}
+	}
+	result := NotAffected
+	for _, r := range affected.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue
+		}
+		var ecosystem string
+		if affected.Package != nil {
+			ecosystem = affected.Package.Ecosystem
+		}
+		compare, err := comparator(ecosystem, r.Type)
+		if err != nil {
+			return "", err
+		}
+		inRange, pastFix, err := evaluateRange(r.Events, compare, version)
+		if err != nil {
+			return "", err
+		}
+		if inRange {
+			return Affected, nil
+		}
+		if pastFix {
+			result = Fixed
+		}
+	}
+	return result, nil
+}
+
+// evaluateRange reports whether version is within the range described by
+// events, and whether it's at or after one of the range's fixes. As the OSV
+// schema specifies, the events are sorted by version and applied in turn.
+func evaluateRange(events []vulns.Event, compare vulns.Comparator, version string) (inRange bool, pastFix bool, err error) {
+	less := func(a, b string) (bool, error) {
+		switch {
+		case a == b:
+			return false, nil
+		case a == "0":
+			return true, nil
+		case b == "0":
+			return false, nil
+		}
+		c, err := compare(a, b)
+		return c < 0, err
+	}
+	sorted := slices.Clone(events)
+	var sortErr error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		l, err := less(eventVersion(sorted[i]), eventVersion(sorted[j]))
+		if err != nil {
+			sortErr = err
+		}
+		return l
+	})
+	if sortErr != nil {
+		return false, false, sortErr
+	}
+	for _, e := range sorted {
+		after, err := less(eventVersion(e), version)
+		if err != nil {
+			return false, false, err
+		}
+		atOrAfter := after || eventVersion(e) == version
+		switch {
+		case e.Introduced != "" && atOrAfter:
+			inRange = true
+		case e.Fixed != "" && atOrAfter:
+			inRange = false
+			pastFix = true
+		case e.LastAffected != "" && after:
+			inRange = false
+		case e.Limit != "" && atOrAfter:
+			inRange = false
+		}
+	}
+	return inRange, pastFix, nil
+}
+
+// eventVersion returns the version of whichever field of e is set.
+func eventVersion(e vulns.Event) string {
+	return e.Introduced + e.Fixed + e.LastAffected + e.Limit
+}
+
+// Match returns the statements for every component of the SBOM that one of
+// the records has an affected package for (by purl). Components whose purls
+// are of a generic or unknown type are matched by name instead, in the
+// ecosystem inferred from the records' packages of that name, and their
+// statements are marked as inferred.
+func Match(records []*vulns.Vulnerability, components []Component) ([]Statement, error) {
+	var statements []Statement
+	var errs []error
+	var index packageIndex
+	for _, c := range components {
+		purl, err := packageurl.FromString(c.Purl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("component %s: %w", c.Ref, err))
+			continue
+		}
+		version := componentVersion(c, purl)
+		var inferredName, inferredEcosystem string
+		if !knownPURLType(purl.Type) {
+			if index == nil {
+				index = newPackageIndex(records)
+			}
+			var ok bool
+			if inferredName, inferredEcosystem, ok = index.infer(purl); !ok {
+				continue
+			}
+		}
+		for _, v := range records {
+			if v.Withdrawn != "" {
+				continue
+			}
+			var matched bool
+			result := NotAffected
+			for _, affected := range v.Affected {
+				if inferredEcosystem != "" {
+					if !inferredMatch(affected, inferredName, inferredEcosystem) {
+						continue
+					}
+				} else {
+					if affected.Package == nil || affected.Package.Purl == "" {
+						continue
+					}
+					affectedPurl, err := packageurl.FromString(affected.Package.Purl)
+					if err != nil || !samePackage(purl, affectedPurl) {
+						continue
+					}
+				}
+				matched = true
+				if version == "" {
+					// Without a version, assume the worst.
+					result = Affected
+					break
+				}
+				s, err := status(affected, version)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s for component %s: %w", v.ID, c.Ref, err))
+					matched = false
+					break
+				}
+				if s == Affected || (s == Fixed && result == NotAffected) {
+					result = s
+				}
+				if result == Affected {
+					break
+				}
+			}
+			if matched {
+				statements = append(statements, Statement{Vulnerability: v.ID, Aliases: v.Aliases, Component: c, Status: result, InferredEcosystem: inferredEcosystem})
+			}
+		}
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Vulnerability < statements[j].Vulnerability
+	})
+	return statements, errors.Join(errs...)
+}
+
+// inferredNote explains a statement whose component's ecosystem was inferred,
+// or is empty.
+func inferredNote(s Statement) string {
+	if s.InferredEcosystem == "" {
+		return ""
+	}
+	return fmt.Sprintf("The ecosystem of %s was inferred to be %s from the names of the packages affected by OSV records", s.Component.Purl, s.InferredEcosystem)
+}
+
+// OpenVEXDocument is an OpenVEX (https://openvex.dev) document.
+type OpenVEXDocument struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  string             `json:"timestamp"`
+	Version    int                `json:"version"`
+	Statements []OpenVEXStatement `json:"statements"`
+}
+
+type OpenVEXStatement struct {
+	Vulnerability   OpenVEXVulnerability `json:"vulnerability"`
+	Products        []OpenVEXProduct     `json:"products"`
+	Status          Status               `json:"status"`
+	Justification   string               `json:"justification,omitempty"`
+	ActionStatement string               `json:"action_statement,omitempty"`
+	StatusNotes     string               `json:"status_notes,omitempty"`
+}
+
+type OpenVEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+type OpenVEXProduct struct {
+	ID string `json:"@id"`
+}
+
+// ToOpenVEX returns statements as an OpenVEX document.
+func ToOpenVEX(statements []Statement, id string, author string, timestamp time.Time) OpenVEXDocument {
+	doc := OpenVEXDocument{
+		Context:    "https://openvex.dev/ns/v0.2.0",
+		ID:         id,
+		Author:     author,
+		Timestamp:  timestamp.UTC().Format(time.RFC3339),
+		Version:    1,
+		Statements: []OpenVEXStatement{},
+	}
+	for _, s := range statements {
+		statement := OpenVEXStatement{
+			Vulnerability: OpenVEXVulnerability{Name: s.Vulnerability, Aliases: s.Aliases},
+			Products:      []OpenVEXProduct{{ID: s.Component.Purl}},
+			Status:        s.Status,
+		}
+		switch s.Status {
+		case NotAffected:
+			statement.Justification = "vulnerable_code_not_present"
+		case Affected:
+			statement.ActionStatement = "Upgrade to a fixed version"
+		}
+		statement.StatusNotes = inferredNote(s)
+		doc.Statements = append(doc.Statements, statement)
+	}
+	return doc
+}
+
+// CycloneDXDocument is a CycloneDX 1.5 BOM holding only VEX.
+type CycloneDXDocument struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	Version         int                      `json:"version"`
+	Metadata        CycloneDXMetadata        `json:"metadata"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+}
+
+type CycloneDXVulnerability struct {
+	ID         string             `json:"id"`
+	References []CycloneDXRef     `json:"references,omitempty"`
+	Analysis   CycloneDXAnalysis  `json:"analysis"`
+	Affects    []CycloneDXAffects `json:"affects"`
+}
+
+type CycloneDXRef struct {
+	ID string `json:"id"`
+}
+
+type CycloneDXAnalysis struct {
+	State         string `json:"state"`
+	Justification string `json:"justification,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+}
+
+type CycloneDXAffects struct {
+	Ref string `json:"ref"`
+}
+
+// cycloneDXStates maps statuses to CycloneDX analysis states.
+var cycloneDXStates = map[Status]string{
+	Affected:    "exploitable",
+	NotAffected: "not_affected",
+	Fixed:       "resolved",
+}
+
+// ToCycloneDX returns statements as a CycloneDX VEX BOM. Components are
+// referred to by their ref in the SBOM, or their purl if they have none.
+func ToCycloneDX(statements []Statement, timestamp time.Time) CycloneDXDocument {
+	doc := CycloneDXDocument{
+		BOMFormat:       "CycloneDX",
+		SpecVersion:     "1.5",
+		Version:         1,
+		Metadata:        CycloneDXMetadata{Timestamp: timestamp.UTC().Format(time.RFC3339)},
+		Vulnerabilities: []CycloneDXVulnerability{},
+	}
+	for _, s := range statements {
+		ref := s.Component.Ref
+		if ref == "" {
+			ref = s.Component.Purl
+		}
+		vuln := CycloneDXVulnerability{
+			ID:       s.Vulnerability,
+			Analysis: CycloneDXAnalysis{State: cycloneDXStates[s.Status], Detail: inferredNote(s)},
+			Affects:  []CycloneDXAffects{{Ref: ref}},
+		}
+		for _, alias := range s.Aliases {
+			vuln.References = append(vuln.References, CycloneDXRef{ID: alias})
+		}
+		if s.Status == NotAffected {
+			vuln.Analysis.Justification = "code_not_present"
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, vuln)
+	}
+	return doc
+}
@@ -0,0 +1,291 @@
+package vex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"github.com/package-url/packageurl-go"
+
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func loadTestRecords(t *testing.T) []*vulns.Vulnerability {
+	t.Helper()
+	files, err := filepath.Glob("../test_data/vex/osv/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*vulns.Vulnerability
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v vulns.Vulnerability
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, &v)
+	}
+	return records
+}
+
+func TestParseSBOM(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Component
+	}{
+		{
+			file: "cyclonedx.json",
+			want: []Component{
+				{Ref: "requests", Purl: "pkg:pypi/requests@2.28.1", Version: "2.28.1"},
+				{Ref: "gin", Purl: "pkg:golang/github.com/gin-gonic/gin@v1.10.0", Version: "1.10.0"},
+				{Ref: "lodash", Purl: "pkg:npm/lodash@4.17.21"},
+				{Ref: "left-pad", Purl: "pkg:npm/left-pad@1.3.0", Version: "1.3.0"},
+			},
+		},
+		{
+			file: "spdx.json",
+			want: []Component{
+				{Ref: "SPDXRef-Package-requests", Purl: "pkg:pypi/requests@2.31.0", Version: "2.31.0"},
+				{Ref: "SPDXRef-Package-gin", Purl: "pkg:golang/github.com/gin-gonic/gin", Version: "v1.9.1"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("../test_data/vex", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseSBOM(data)
+			if err != nil {
+				t.Fatalf("ParseSBOM() error: %v", err)
+			}
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseSBOM() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := ParseSBOM([]byte(`{"name": "not an SBOM"}`)); err == nil {
+		t.Errorf("ParseSBOM() of a non-SBOM succeeded")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	records := loadTestRecords(t)
+	tests := []struct {
+		name       string
+		components []Component
+		want       map[string]Status
+	}{
+		{
+			name: "in range",
+			components: []Component{
+				{Ref: "a", Purl: "pkg:pypi/requests@2.28.1"},
+				{Ref: "b", Purl: "pkg:npm/lodash@4.17.20"},
+				{Ref: "c", Purl: "pkg:golang/github.com/gin-gonic/gin", Version: "v1.9.1"},
+			},
+			want: map[string]Status{"PYSEC-2024-1/a": Affected, "CVE-2024-2222/b": Affected, "CVE-2024-2222/c": Affected},
+		},
+		{
+			name: "fixed",
+			components: []Component{
+				{Ref: "a", Purl: "pkg:pypi/requests@2.31.0"},
+				{Ref: "b", Purl: "pkg:npm/lodash@4.17.21"},
+			},
+			want: map[string]Status{"PYSEC-2024-1/a": Fixed, "CVE-2024-2222/b": Fixed},
+		},
+		{
+			name: "not affected",
+			components: []Component{
+				{Ref: "a", Purl: "pkg:pypi/requests@1.2.3"},
+				{Ref: "b", Purl: "pkg:golang/github.com/gin-gonic/gin@v1.10.0"},
+			},
+			want: map[string]Status{"PYSEC-2024-1/a": NotAffected, "CVE-2024-2222/b": NotAffected},
+		},
+		{
+			name: "no version",
+			components: []Component{
+				{Ref: "a", Purl: "pkg:npm/lodash"},
+			},
+			want: map[string]Status{"CVE-2024-2222/a": Affected},
+		},
+		{
+			name: "unrelated",
+			components: []Component{
+				{Ref: "a", Purl: "pkg:npm/left-pad@1.3.0"},
+				{Ref: "b", Purl: "pkg:pypi/requests-oauthlib@1.0.0"},
+			},
+			want: map[string]Status{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			statements, err := Match(records, tc.components)
+			if err != nil {
+				t.Fatalf("Match() error: %v", err)
+			}
+			got := make(map[string]Status)
+			for _, s := range statements {
+				got[s.Vulnerability+"/"+s.Component.Ref] = s.Status
+			}
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchInferred(t *testing.T) {
+	records := loadTestRecords(t)
+	components := []Component{
+		{Ref: "a", Purl: "pkg:generic/requests@2.28.1"},
+		{Ref: "b", Purl: "pkg:generic/LODASH", Version: "4.17.21"},
+		{Ref: "c", Purl: "pkg:golang-module/github.com/gin-gonic/gin@v1.9.1"},
+		{Ref: "d", Purl: "pkg:generic/zlib@1.3"},
+	}
+	statements, err := Match(records, components)
+	if err != nil {
+		t.Fatalf("Match() error: %v", err)
+	}
+	got := make(map[string]string)
+	for _, s := range statements {
+		got[s.Vulnerability+"/"+s.Component.Ref] = string(s.Status) + " " + s.InferredEcosystem
+	}
+	want := map[string]string{
+		"PYSEC-2024-1/a":  "affected PyPI",
+		"CVE-2024-2222/b": "fixed npm",
+		"CVE-2024-2222/c": "affected Go",
+	}
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	index := packageIndex{
+		"curl":                     {"Debian": 3, "Alpine": 3, "Ubuntu": 1},
+		"github.com/gin-gonic/gin": {"Go": 1},
+		"gin":                      {"npm": 1},
+	}
+	tests := []struct {
+		purl          string
+		wantName      string
+		wantEcosystem string
+		wantOK        bool
+	}{
+		{"pkg:generic/curl@8.5.0", "curl", "Alpine", true},
+		{"pkg:generic/github.com/gin-gonic/gin", "github.com/gin-gonic/gin", "Go", true},
+		{"pkg:generic/example.com/gin", "gin", "npm", true},
+		{"pkg:generic/zlib", "", "", false},
+	}
+	for _, tc := range tests {
+		purl, err := packageurl.FromString(tc.purl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, ecosystem, ok := index.infer(purl)
+		if name != tc.wantName || ecosystem != tc.wantEcosystem || ok != tc.wantOK {
+			t.Errorf("infer(%s) = %q, %q, %v, want %q, %q, %v", tc.purl, name, ecosystem, ok, tc.wantName, tc.wantEcosystem, tc.wantOK)
+		}
+	}
+}
+
+func TestSamePackage(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"pkg:apk/alpine/curl@8.5.0-r0", "pkg:apk/alpine/curl?arch=source", true},
+		{"pkg:alpine/curl@8.5.0-r0", "pkg:apk/alpine/curl?arch=source", true},
+		{"pkg:apk/wolfi/curl", "pkg:apk/alpine/curl", false},
+		{"pkg:alpine/curl", "pkg:apk/alpine/wget", false},
+	} {
+		a, err := packageurl.FromString(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := packageurl.FromString(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := samePackage(a, b); got != tc.want {
+			t.Errorf("samePackage(%s, %s) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestComparator(t *testing.T) {
+	tests := []struct {
+		ecosystem, rangeType string
+		a, b                 string
+		want                 int
+	}{
+		{"npm", "SEMVER", "1.2.10", "1.2.9", 1},
+		{"Go", "SEMVER", "v1.9.1", "1.10.0", -1},
+		{"npm", "SEMVER", "1.0.0-rc1", "1.0.0", -1},
+		{"PyPI", "ECOSYSTEM", "2.0.0rc1", "2.0.0", -1},
+		{"PyPI", "ECOSYSTEM", "2.0.post1", "2.0", 1},
+		{"Debian:12", "ECOSYSTEM", "1:2.0-1", "2.1-1", 1},
+		{"Debian:12", "ECOSYSTEM", "3.0.11-1~deb12u2", "3.0.11-1", -1},
+		{"Alpine:v3.19", "ECOSYSTEM", "8.5.0-r1", "8.5.0-r0", 1},
+		{"Red Hat", "ECOSYSTEM", "1:1.0-1.el9", "2.0-1.el9", 1},
+	}
+	for _, tc := range tests {
+		compare, err := comparator(tc.ecosystem, tc.rangeType)
+		if err != nil {
+			t.Errorf("comparator(%q, %q) error: %v", tc.ecosystem, tc.rangeType, err)
+			continue
+		}
+		got, err := compare(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("comparator(%q, %q)(%q, %q) error: %v", tc.ecosystem, tc.rangeType, tc.a, tc.b, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("comparator(%q, %q)(%q, %q) = %d, want %d", tc.ecosystem, tc.rangeType, tc.a, tc.b, got, tc.want)
+		}
+	}
+	if _, err := comparator("Maven", "ECOSYSTEM"); err == nil {
+		t.Errorf("comparator() of an ecosystem without a version ordering succeeded")
+	}
+}
+
+func TestToCycloneDX(t *testing.T) {
+	statements := []Statement{
+		{Vulnerability: "PYSEC-2024-1", Aliases: []string{"CVE-2024-1111"}, Component: Component{Ref: "requests", Purl: "pkg:pypi/requests@2.28.1"}, Status: Affected},
+		{Vulnerability: "CVE-2024-2222", Component: Component{Purl: "pkg:npm/lodash@4.17.21"}, Status: NotAffected},
+		{Vulnerability: "CVE-2024-2222", Component: Component{Ref: "lodash", Purl: "pkg:generic/lodash@4.17.20"}, Status: Affected, InferredEcosystem: "npm"},
+	}
+	got := ToCycloneDX(statements, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	want := []CycloneDXVulnerability{
+		{
+			ID:         "PYSEC-2024-1",
+			References: []CycloneDXRef{{ID: "CVE-2024-1111"}},
+			Analysis:   CycloneDXAnalysis{State: "exploitable"},
+			Affects:    []CycloneDXAffects{{Ref: "requests"}},
+		},
+		{
+			ID:       "CVE-2024-2222",
+			Analysis: CycloneDXAnalysis{State: "not_affected", Justification: "code_not_present"},
+			Affects:  []CycloneDXAffects{{Ref: "pkg:npm/lodash@4.17.21"}},
+		},
+		{
+			ID: "CVE-2024-2222",
+			Analysis: CycloneDXAnalysis{
+				State:  "exploitable",
+				Detail: "The ecosystem of pkg:generic/lodash@4.17.20 was inferred to be npm from the names of the packages affected by OSV records",
+			},
+			Affects: []CycloneDXAffects{{Ref: "lodash"}},
+		},
+	}
+	if diff := gocmp.Diff(want, got.Vulnerabilities); diff != "" {
+		t.Errorf("ToCycloneDX() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulns
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+// Comparator compares two versions of an ecosystem by its ordering,
+// returning -1, 0 or 1, or an error if either isn't a valid version.
+type Comparator func(a, b string) (int, error)
+
+var (
+	comparatorsMu sync.RWMutex
+	comparators   = map[string]Comparator{
+		"Alpine":     vercmp.APK,
+		"Chainguard": vercmp.APK,
+		"Wolfi":      vercmp.APK,
+
+		"Debian": vercmp.Dpkg,
+		"Ubuntu": vercmp.Dpkg,
+
+		"AlmaLinux":    vercmp.RPM,
+		"Amazon Linux": vercmp.RPM,
+		"CentOS":       vercmp.RPM,
+		"Fedora":       vercmp.RPM,
+		"Mageia":       vercmp.RPM,
+		"openEuler":    vercmp.RPM,
+		"openSUSE":     vercmp.RPM,
+		"Photon OS":    vercmp.RPM,
+		"Red Hat":      vercmp.RPM,
+		"Rocky Linux":  vercmp.RPM,
+		"SUSE":         vercmp.RPM,
+
+		"Bitnami":   vercmp.SemVer,
+		"crates.io": vercmp.SemVer,
+		"Go":        vercmp.SemVer,
+		"Hex":       vercmp.SemVer,
+		"npm":       vercmp.SemVer,
+		"Pub":       vercmp.SemVer,
+		"SwiftURL":  vercmp.SemVer,
+
+		"Linux": vercmp.Kernel,
+	}
+)
+
+// RegisterComparator sets the Comparator of an ecosystem, replacing any it
+// had.
+func RegisterComparator(ecosystem string, c Comparator) {
+	comparatorsMu.Lock()
+	defer comparatorsMu.Unlock()
+	comparators[ecosystem] = c
+}
+
+// ComparatorFor returns the Comparator of an ecosystem, which may have a
+// release suffix (e.g. "Alpine:v3.18"), and whether it has one.
+func ComparatorFor(ecosystem string) (Comparator, bool) {
+	ecosystem, _, _ = strings.Cut(ecosystem, ":")
+	comparatorsMu.RLock()
+	defer comparatorsMu.RUnlock()
+	c, ok := comparators[ecosystem]
+	return c, ok
+}
+
+// CheckRange checks that a range from introduced to fixed or last affected
+// is ordered by the Comparator: a range whose introduced version is its fixed
+// one matches nothing, and one whose introduced version is after its end
+// matches everything from the introduced version on. Ranges introduced at
+// "0" and versions the Comparator can't parse aren't checked.
+func (c Comparator) CheckRange(introduced, fixed, lastAffected string) error {
+	if introduced == "" || introduced == "0" {
+		return nil
+	}
+	if fixed != "" {
+		n, err := c(introduced, fixed)
+		switch {
+		case err != nil:
+		case n == 0:
+			return fmt.Errorf("range introduced and fixed at %q matches nothing", fixed)
+		case n > 0:
+			return fmt.Errorf("range introduced at %q is after its fix %q", introduced, fixed)
+		}
+	}
+	if lastAffected != "" {
+		n, err := c(introduced, lastAffected)
+		if err == nil && n > 0 {
+			return fmt.Errorf("range introduced at %q is after its last affected version %q", introduced, lastAffected)
+		}
+	}
+	return nil
+}
+
+// CheckAffectedVersion checks an AffectedVersion with the CheckRange of the
+// ecosystem's Comparator. AffectedVersions of ecosystems without one aren't
+// checked.
+func CheckAffectedVersion(ecosystem string, av cves.AffectedVersion) error {
+	c, ok := ComparatorFor(ecosystem)
+	if !ok {
+		return nil
+	}
+	return c.CheckRange(av.Introduced, av.Fixed, av.LastAffected)
+}
@@ -0,0 +1,54 @@
+package vulns
+
+import (
+	"testing"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestCheckAffectedVersion(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		av        cves.AffectedVersion
+		wantErr   bool
+	}{
+		{"Alpine:v3.19", cves.AffectedVersion{Introduced: "1.0-r0", Fixed: "1.1-r0"}, false},
+		{"Alpine:v3.19", cves.AffectedVersion{Introduced: "1.1-r0", Fixed: "1.1-r0"}, true},
+		{"Alpine:v3.19", cves.AffectedVersion{Introduced: "1.2-r0", Fixed: "1.1-r0"}, true},
+		{"Debian:12", cves.AffectedVersion{Introduced: "1.0~rc1-1", Fixed: "1.0-1"}, false},
+		{"Debian:12", cves.AffectedVersion{Introduced: "1.0-1", LastAffected: "1.0~rc1-1"}, true},
+		{"Red Hat", cves.AffectedVersion{Introduced: "1:1.0-1.el9", Fixed: "2.0-1.el9"}, true},
+		{"npm", cves.AffectedVersion{Introduced: "1.0.0-rc.1", Fixed: "1.0.0"}, false},
+		{"npm", cves.AffectedVersion{Introduced: "2.0.0", LastAffected: "1.9.9"}, true},
+		// Unchecked: introduced at 0, no Comparator, or an unparseable version.
+		{"npm", cves.AffectedVersion{Introduced: "0", Fixed: "0"}, false},
+		{"PyPI", cves.AffectedVersion{Introduced: "2.0", Fixed: "1.0"}, false},
+		{"npm", cves.AffectedVersion{Introduced: "latest", Fixed: "1.0.0"}, false},
+	}
+	for _, tt := range tests {
+		err := CheckAffectedVersion(tt.ecosystem, tt.av)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckAffectedVersion(%q, %+v) = %v, want error: %t", tt.ecosystem, tt.av, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRegisterComparator(t *testing.T) {
+	const ecosystem = "TestComparatorEco"
+	if _, ok := ComparatorFor(ecosystem); ok {
+		t.Fatalf("ComparatorFor(%q) before registering: want none", ecosystem)
+	}
+	RegisterComparator(ecosystem, func(a, b string) (int, error) { return 1, nil })
+	t.Cleanup(func() {
+		comparatorsMu.Lock()
+		defer comparatorsMu.Unlock()
+		delete(comparators, ecosystem)
+	})
+	c, ok := ComparatorFor(ecosystem + ":1")
+	if !ok {
+		t.Fatalf("ComparatorFor(%q) after registering: want one", ecosystem+":1")
+	}
+	if err := c.CheckRange("2", "1", ""); err == nil {
+		t.Errorf("CheckRange() of an inverted range: want error")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulns
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+// OSV credit types.
+const (
+	CreditFinder   = "FINDER"
+	CreditReporter = "REPORTER"
+	CreditOther    = "OTHER"
+)
+
+// cve5CreditTypes maps the credit types of CVE 5.x records to OSV's.
+var cve5CreditTypes = map[string]string{
+	"finder":                CreditFinder,
+	"reporter":              CreditReporter,
+	"analyst":               "ANALYST",
+	"coordinator":           "COORDINATOR",
+	"remediation developer": "REMEDIATION_DEVELOPER",
+	"remediation reviewer":  "REMEDIATION_REVIEWER",
+	"remediation verifier":  "REMEDIATION_VERIFIER",
+	"tool":                  "TOOL",
+	"sponsor":               "SPONSOR",
+	"other":                 CreditOther,
+}
+
+var (
+	// creditPhrase matches the acknowledgments descriptions commonly end
+	// with, e.g. "This issue was discovered by Jane Doe of Example Corp.".
+	creditPhrase = regexp.MustCompile(`(?i)\b(discovered|found|identified|reported|disclosed)\s+(?:to(?:\s+\S+){1,3}?\s+)?by\s+([^.;:()\n]+)`)
+	// creditNameEnd cuts an acknowledged name before the affiliation or
+	// circumstances that usually follow it.
+	creditNameEnd = regexp.MustCompile(`\s+(?:of|from|at|with|in|on|via|using|through|while|during|who|when|working)\s`)
+	// creditNameSep separates the names of a list.
+	creditNameSep = regexp.MustCompile(`\s*(?:,\s*and\s+|,\s*|\s+and\s+|\s*&\s*)`)
+)
+
+// maxCreditWords is the most words a name found in a description can
+// have, beyond which the phrase is more likely a sentence than a name.
+const maxCreditWords = 5
+
+// CreditsFromCVE5 returns the credits of a CVE 5.x record's CNA container,
+// preferring the English ones when it has them in several languages.
+func CreditsFromCVE5(cve cves.CVE5) []Credit {
+	all := cve.Containers.CNA.Credits
+	var english []cves.CVE5Credit
+	for _, c := range all {
+		if lang := strings.ToLower(c.Lang); lang == "en" || strings.HasPrefix(lang, "en-") {
+			english = append(english, c)
+		}
+	}
+	if len(english) > 0 {
+		all = english
+	}
+	var credits []Credit
+	for _, c := range all {
+		name := strings.Join(strings.Fields(c.Value), " ")
+		if name == "" {
+			continue
+		}
+		creditType, ok := cve5CreditTypes[strings.ToLower(strings.TrimSpace(c.Type))]
+		if !ok && c.Type != "" {
+			creditType = CreditOther
+		}
+		credits = appendCredit(credits, Credit{Name: name, Type: creditType})
+	}
+	return credits
+}
+
+// CreditsFromDescription returns the credits acknowledged by phrases such as
+// "discovered by" (FINDER) and "reported by" (REPORTER) in a description.
+// This is a heuristic: names are only taken when they look like names, so
+// acknowledgments are missed rather than made up.
+func CreditsFromDescription(description string) []Credit {
+	var credits []Credit
+	for _, m := range creditPhrase.FindAllStringSubmatch(description, -1) {
+		creditType := CreditFinder
+		if verb := strings.ToLower(m[1]); verb == "reported" || verb == "disclosed" {
+			creditType = CreditReporter
+		}
+		names := m[2]
+		if loc := creditNameEnd.FindStringIndex(names + " "); loc != nil {
+			names = names[:loc[0]]
+		}
+		for _, name := range creditNameSep.Split(names, -1) {
+			name = strings.Trim(strings.Join(strings.Fields(name), " "), `"'`)
+			if looksLikeName(name) {
+				credits = appendCredit(credits, Credit{Name: name, Type: creditType})
+			}
+		}
+	}
+	return credits
+}
+
+// looksLikeName reports whether text found after "discovered by" is
+// likely a name or handle rather than the rest of a sentence, e.g.
+// "a remote attacker".
+func looksLikeName(text string) bool {
+	words := strings.Fields(text)
+	if len(words) == 0 || len(words) > maxCreditWords {
+		return false
+	}
+	first := []rune(words[0])
+	return unicode.IsUpper(first[0]) || first[0] == '@'
+}
+
+// appendCredit appends a credit unless it has been given already.
+func appendCredit(credits []Credit, c Credit) []Credit {
+	for _, existing := range credits {
+		if strings.EqualFold(existing.Name, c.Name) && existing.Type == c.Type {
+			return credits
+		}
+	}
+	return append(credits, c)
+}
+
+// AddCredits adds credits to the record, skipping those it already gives.
+func (v *Vulnerability) AddCredits(credits ...Credit) {
+	for _, c := range credits {
+		v.Credits = appendCredit(v.Credits, c)
+	}
+}
@@ -0,0 +1,84 @@
+package vulns
+
+import (
+	"encoding/json"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestCreditsFromCVE5(t *testing.T) {
+	var record cves.CVE5
+	if err := json.Unmarshal([]byte(`{"containers": {"cna": {"credits": [
+		{"lang": "en", "value": "Jane  Doe", "type": "finder"},
+		{"lang": "en", "value": "Example CERT", "type": "coordinator"},
+		{"lang": "en", "value": "John Roe", "type": "remediation developer"},
+		{"lang": "en", "value": "fuzzer", "type": "something new"},
+		{"lang": "en", "value": "Anonymous"},
+		{"lang": "en", "value": "jane doe", "type": "finder"},
+		{"lang": "ja", "value": "ジェーン", "type": "finder"},
+		{"lang": "en", "value": " ", "type": "finder"}
+	]}}}`), &record); err != nil {
+		t.Fatal(err)
+	}
+	want := []Credit{
+		{Name: "Jane Doe", Type: CreditFinder},
+		{Name: "Example CERT", Type: "COORDINATOR"},
+		{Name: "John Roe", Type: "REMEDIATION_DEVELOPER"},
+		{Name: "fuzzer", Type: CreditOther},
+		{Name: "Anonymous"},
+	}
+	if diff := gocmp.Diff(want, CreditsFromCVE5(record)); diff != "" {
+		t.Errorf("CreditsFromCVE5() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCreditsFromDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []Credit
+	}{
+		{
+			description: "A heap overflow in foo. This issue was discovered by Jane Doe of Example Corp.",
+			want:        []Credit{{Name: "Jane Doe", Type: CreditFinder}},
+		},
+		{
+			description: "A flaw was found in bar. Reported by John Roe, Alice Smith and @bob (Example Security).",
+			want: []Credit{
+				{Name: "John Roe", Type: CreditReporter},
+				{Name: "Alice Smith", Type: CreditReporter},
+				{Name: "@bob", Type: CreditReporter},
+			},
+		},
+		{
+			description: "Found by OSS-Fuzz. Reported to the maintainers by Jane Doe.",
+			want: []Credit{
+				{Name: "OSS-Fuzz", Type: CreditFinder},
+				{Name: "Jane Doe", Type: CreditReporter},
+			},
+		},
+		{
+			description: "The vulnerability can be exploited by a remote attacker, as discovered by the researchers who looked at it.",
+		},
+		{
+			description: "Discovered by Someone With An Unusually Long Sentence Instead Of A Name.",
+		},
+	}
+	for _, tc := range tests {
+		if diff := gocmp.Diff(tc.want, CreditsFromDescription(tc.description)); diff != "" {
+			t.Errorf("CreditsFromDescription(%q) mismatch (-want +got):\n%s", tc.description, diff)
+		}
+	}
+}
+
+func TestAddCredits(t *testing.T) {
+	v := &Vulnerability{ID: "CVE-2024-1234"}
+	v.AddCredits(Credit{Name: "Jane Doe", Type: CreditFinder}, Credit{Name: "Jane Doe", Type: CreditReporter})
+	v.AddCredits(Credit{Name: "JANE DOE", Type: CreditFinder})
+	want := []Credit{{Name: "Jane Doe", Type: CreditFinder}, {Name: "Jane Doe", Type: CreditReporter}}
+	if diff := gocmp.Diff(want, v.Credits); diff != "" {
+		t.Errorf("AddCredits() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulns
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+const (
+	cve5StatusAffected   = "affected"
+	cve5StatusUnaffected = "unaffected"
+)
+
+var (
+	// https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string
+	semverRegexp  = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+	gitHashRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	// Free text frequently found in the version field instead of lessThan/lessThanOrEqual.
+	cve5LessThanText        = regexp.MustCompile(`(?i)^(?:<|before|prior to|earlier than|versions? before|versions? prior to)\s*(\S+)$`)
+	cve5LessThanOrEqualText = regexp.MustCompile(`(?i)^(?:<=|=<|up to and including|up to|through)\s*(\S+)$`)
+	cve5AndEarlierText      = regexp.MustCompile(`(?i)^(\S+)\s+(?:and|or)\s+(?:earlier|prior|before|below|lower|older)$`)
+	cve5ThroughText         = regexp.MustCompile(`(?i)^(\S+)\s+(?:through|to|-)\s+(\S+)$`)
+	cve5AtLeastText         = regexp.MustCompile(`(?i)^(?:>=|=>)\s*(\S+)$`)
+)
+
+// cve5Interval is a normalized form of a cves.CVE5Version.
+type cve5Interval struct {
+	start        string // Empty means from the beginning of time.
+	end          string // Empty means no upper bound.
+	endInclusive bool
+	single       bool // start is a single version rather than a range.
+}
+
+// isCVE5Placeholder reports whether a version value carries no information.
+func isCVE5Placeholder(version string) bool {
+	switch strings.ToLower(version) {
+	case "", "0", "*", "-", "n/a", "na", "all", "any", "unspecified", "unknown", "various", "all versions":
+		return true
+	}
+	return false
+}
+
+// isCVE5Wildcard reports whether an upper bound means "the rest of the branch", e.g. "5.10.*".
+func isCVE5Wildcard(version string) bool {
+	return isCVE5Placeholder(version) || strings.HasSuffix(version, ".*") || strings.HasSuffix(strings.ToLower(version), ".x")
+}
+
+// parseCVE5Version normalizes a CNA supplied version entry, including the
+// common free text forms that should have been expressed with lessThan or
+// lessThanOrEqual.
+func parseCVE5Version(v cves.CVE5Version) (cve5Interval, error) {
+	version := strings.TrimSpace(v.Version)
+	lessThan := strings.TrimSpace(v.LessThan)
+	lessThanOrEqual := strings.TrimSpace(v.LessThanOrEqual)
+
+	if lessThan == "" && lessThanOrEqual == "" {
+		if m := cve5LessThanText.FindStringSubmatch(version); m != nil {
+			version, lessThan = "", m[1]
+		} else if m := cve5LessThanOrEqualText.FindStringSubmatch(version); m != nil {
+			version, lessThanOrEqual = "", m[1]
+		} else if m := cve5AndEarlierText.FindStringSubmatch(version); m != nil {
+			version, lessThanOrEqual = "", m[1]
+		} else if m := cve5ThroughText.FindStringSubmatch(version); m != nil {
+			version, lessThanOrEqual = m[1], m[2]
+		} else if m := cve5AtLeastText.FindStringSubmatch(version); m != nil {
+			version, lessThan = m[1], "*"
+		}
+	}
+
+	if isCVE5Placeholder(version) {
+		version = ""
+	}
+
+	var interval cve5Interval
+	interval.start = version
+	switch {
+	case lessThan != "":
+		interval.end = lessThan
+	case lessThanOrEqual != "":
+		interval.end = lessThanOrEqual
+		interval.endInclusive = true
+	default:
+		if version == "" {
+			return interval, fmt.Errorf("no usable version in %+v", v)
+		}
+		interval.single = true
+		return interval, nil
+	}
+	if isCVE5Wildcard(interval.end) {
+		interval.end = ""
+		interval.endInclusive = false
+	}
+
+	return interval, nil
+}
+
+// cve5RangeType determines the OSV range type for a CNA versionType,
+// falling back to ECOSYSTEM when the values don't conform to the claimed type.
+// GIT ranges need full commit hashes, as abbreviated ones can be ambiguous.
+func cve5RangeType(versionType string, values ...string) string {
+	switch strings.ToLower(versionType) {
+	case "semver":
+		for _, v := range values {
+			if v != "" && !semverRegexp.MatchString(strings.TrimPrefix(v, "v")) {
+				return "ECOSYSTEM"
+			}
+		}
+		return "SEMVER"
+	case "git":
+		for _, v := range values {
+			if v != "" && !gitHashRegexp.MatchString(v) {
+				return "ECOSYSTEM"
+			}
+		}
+		return "GIT"
+	default:
+		return "ECOSYSTEM"
+	}
+}
+
+// AttachCVE5Versions converts the versions of a CVE 5.x CNA affected entry to
+// OSV SEMVER, GIT and ECOSYSTEM AffectedRanges. Individually listed affected
+// versions are added to Versions instead.
+// Returns notes about versions that couldn't be converted.
+func (affected *Affected) AttachCVE5Versions(cna cves.CVE5Affected) (notes []string) {
+	rangesByType := map[string]*AffectedRange{}
+	addEvent := func(rangeType string, e Event) {
+		r, ok := rangesByType[rangeType]
+		if !ok {
+			r = &AffectedRange{Type: rangeType, Events: []Event{}}
+			if rangeType == "GIT" {
+				r.Repo = cna.Repo
+			}
+			rangesByType[rangeType] = r
+		}
+		if !slices.Contains(r.Events, e) {
+			r.Events = append(r.Events, e)
+		}
+	}
+	defaultAffected := strings.EqualFold(cna.DefaultStatus, cve5StatusAffected)
+
+	for _, v := range cna.Versions {
+		interval, err := parseCVE5Version(v)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Skipping version: %v", err))
+			continue
+		}
+		rangeType := cve5RangeType(v.VersionType, interval.start, interval.end)
+		if strings.EqualFold(v.VersionType, "git") && rangeType != "GIT" {
+			notes = append(notes, fmt.Sprintf("Version %+v is not a commit, treating as ECOSYSTEM", v))
+		}
+		if strings.EqualFold(v.VersionType, "semver") && rangeType != "SEMVER" {
+			notes = append(notes, fmt.Sprintf("Version %+v is not valid SemVer, treating as ECOSYSTEM", v))
+		}
+		if rangeType == "GIT" && cna.Repo == "" {
+			notes = append(notes, fmt.Sprintf("Skipping git version %+v without a repo", v))
+			continue
+		}
+		if rangeType == "SEMVER" {
+			interval.start = strings.TrimPrefix(interval.start, "v")
+			interval.end = strings.TrimPrefix(interval.end, "v")
+		}
+		if interval.start != "" && interval.start == interval.end && !interval.endInclusive {
+			notes = append(notes, fmt.Sprintf("Skipping empty range %+v", v))
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(v.Status)) {
+		case cve5StatusAffected:
+			if interval.single {
+				if rangeType == "GIT" {
+					notes = append(notes, fmt.Sprintf("Skipping single affected commit %+v", v))
+					continue
+				}
+				if !slices.Contains(affected.Versions, interval.start) {
+					affected.Versions = append(affected.Versions, interval.start)
+				}
+				continue
+			}
+			addEvent(rangeType, Event{Introduced: cmp.Or(interval.start, "0")})
+			switch {
+			case interval.end == "":
+			case interval.endInclusive:
+				addEvent(rangeType, Event{LastAffected: interval.end})
+			default:
+				addEvent(rangeType, Event{Fixed: interval.end})
+			}
+		case cve5StatusUnaffected:
+			switch {
+			case interval.single && !defaultAffected:
+				// Nothing to learn from a single unaffected version when
+				// versions are unaffected by default.
+			case interval.start != "":
+				// The first unaffected version is where the fix landed.
+				addEvent(rangeType, Event{Fixed: interval.start})
+			case interval.end != "" && !interval.endInclusive && defaultAffected:
+				// Everything before the end is unaffected.
+				addEvent(rangeType, Event{Introduced: interval.end})
+			default:
+				notes = append(notes, fmt.Sprintf("Skipping unaffected version with no usable bound %+v", v))
+			}
+		default:
+			notes = append(notes, fmt.Sprintf("Skipping version with status %q: %+v", v.Status, v))
+		}
+	}
+
+	var ranges []AffectedRange
+	for _, r := range rangesByType {
+		hasIntroduced := slices.ContainsFunc(r.Events, func(e Event) bool { return e.Introduced != "" })
+		hasFixed := slices.ContainsFunc(r.Events, func(e Event) bool { return e.Fixed != "" })
+		if !hasIntroduced {
+			r.Events = append([]Event{{Introduced: "0"}}, r.Events...)
+		}
+		if hasFixed {
+			// Ranges with both fixed and last_affected events aren't schema compliant.
+			before := len(r.Events)
+			r.Events = slices.DeleteFunc(r.Events, func(e Event) bool { return e.LastAffected != "" })
+			if len(r.Events) != before {
+				notes = append(notes, fmt.Sprintf("Dropped last_affected events from %s range with fixed events", r.Type))
+			}
+		}
+		ranges = append(ranges, *r)
+	}
+	slices.SortFunc(ranges, func(a, b AffectedRange) int {
+		return cmp.Compare(a.Type, b.Type)
+	})
+	affected.Ranges = append(affected.Ranges, ranges...)
+
+	return notes
+}
@@ -0,0 +1,334 @@
+package vulns
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestAttachCVE5Versions(t *testing.T) {
+	tests := []struct {
+		description   string
+		cna           cves.CVE5Affected
+		wantRanges    []AffectedRange
+		wantVersions  []string
+		wantNoteCount int
+	}{
+		{
+			description: "semver lessThan range",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1.0.0", LessThan: "1.4.2", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "1.0.0"}, {Fixed: "1.4.2"}}},
+			},
+		},
+		{
+			description: "semver with v prefixes and placeholder start",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "0", LessThan: "v2.3.1", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "2.3.1"}}},
+			},
+		},
+		{
+			description: "semver lessThanOrEqual becomes last_affected",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "unspecified", LessThanOrEqual: "3.2.1", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {LastAffected: "3.2.1"}}},
+			},
+		},
+		{
+			description: "semver versionType with non-semver values falls back to ECOSYSTEM",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "2.4", LessThan: "2.4.58", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "2.4"}, {Fixed: "2.4.58"}}},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "custom versionType becomes ECOSYSTEM",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "7.0", LessThan: "7.0.14", Status: "affected", VersionType: "custom"},
+					{Version: "7.1", LessThan: "7.1.2", Status: "affected", VersionType: "custom"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "7.0"}, {Fixed: "7.0.14"}, {Introduced: "7.1"}, {Fixed: "7.1.2"}}},
+			},
+		},
+		{
+			description: "missing versionType becomes ECOSYSTEM",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "0", LessThan: "4.19.3", Status: "affected"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "4.19.3"}}},
+			},
+		},
+		{
+			description: "git commit range",
+			cna: cves.CVE5Affected{
+				Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/stable/linux.git",
+				Versions: []cves.CVE5Version{
+					{Version: "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2", LessThan: "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1", Status: "affected", VersionType: "git"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{
+					Type: "GIT",
+					Repo: "https://git.kernel.org/pub/scm/linux/kernel/git/stable/linux.git",
+					Events: []Event{
+						{Introduced: "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2"},
+						{Fixed: "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1"},
+					},
+				},
+			},
+		},
+		{
+			description: "git range without a repo is skipped",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1da177e4c3f41524e886b7f1b8a0c1fc7321cac2", LessThan: "0f8a5b6d0dafa4f533ac82e98f8b812073a7c9d1", Status: "affected", VersionType: "git"},
+				},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "git versionType with tag names falls back to ECOSYSTEM",
+			cna: cves.CVE5Affected{
+				Repo: "https://github.com/example/example",
+				Versions: []cves.CVE5Version{
+					{Version: "v1.2.0", LessThan: "v1.2.5", Status: "affected", VersionType: "git"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "v1.2.0"}, {Fixed: "v1.2.5"}}},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "git versionType with abbreviated commits falls back to ECOSYSTEM",
+			cna: cves.CVE5Affected{
+				Repo: "https://github.com/example/example",
+				Versions: []cves.CVE5Version{
+					{Version: "1da177e", LessThan: "0f8a5b6d0daf", Status: "affected", VersionType: "git"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "1da177e"}, {Fixed: "0f8a5b6d0daf"}}},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "Linux kernel CNA style with unaffected stable branches",
+			cna: cves.CVE5Affected{
+				DefaultStatus: "affected",
+				Versions: []cves.CVE5Version{
+					{Version: "4.14", Status: "affected"},
+					{Version: "0", LessThan: "4.14", Status: "unaffected", VersionType: "semver"},
+					{Version: "5.10.180", LessThanOrEqual: "5.10.*", Status: "unaffected", VersionType: "semver"},
+					{Version: "6.1.29", LessThanOrEqual: "6.1.*", Status: "unaffected", VersionType: "semver"},
+					{Version: "6.4", LessThanOrEqual: "*", Status: "unaffected", VersionType: "original_commit_for_fix"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "4.14"}, {Fixed: "6.4"}}},
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "5.10.180"}, {Fixed: "6.1.29"}}},
+			},
+			wantVersions:  []string{"4.14"},
+			wantNoteCount: 1,
+		},
+		{
+			description: "single affected versions go to the versions list",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "3.0.1", Status: "affected"},
+					{Version: "3.0.2", Status: "affected"},
+					{Version: "3.0.1", Status: "affected"},
+				},
+			},
+			wantVersions: []string{"3.0.1", "3.0.2"},
+		},
+		{
+			description: "n/a placeholders are skipped",
+			cna: cves.CVE5Affected{
+				Vendor:  "n/a",
+				Product: "n/a",
+				Versions: []cves.CVE5Version{
+					{Version: "n/a", Status: "affected"},
+				},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "free text less than",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "< 5.2.1", Status: "affected"},
+					{Version: "before 6.0.3", Status: "affected"},
+					{Version: "prior to 7.1", Status: "affected"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "5.2.1"}, {Fixed: "6.0.3"}, {Fixed: "7.1"}}},
+			},
+		},
+		{
+			description: "free text less than or equal",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "<= 2.2.0", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {LastAffected: "2.2.0"}}},
+			},
+		},
+		{
+			description: "free text and earlier",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "10.2.1 and earlier", Status: "affected"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {LastAffected: "10.2.1"}}},
+			},
+		},
+		{
+			description: "free text through",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1.0 through 1.3", Status: "affected"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "1.0"}, {LastAffected: "1.3"}}},
+			},
+		},
+		{
+			description: "free text at least is unbounded",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: ">= 4.0", Status: "affected"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "4.0"}}},
+			},
+		},
+		{
+			description: "wildcard upper bound is unbounded",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "2.0.0", LessThan: "*", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "2.0.0"}}},
+			},
+		},
+		{
+			description: "whitespace and status casing",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: " 1.1.0 ", LessThan: " 1.1.9", Status: "Affected ", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "1.1.0"}, {Fixed: "1.1.9"}}},
+			},
+		},
+		{
+			description: "unknown status is skipped",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1.0.0", LessThan: "1.0.5", Status: "unknown", VersionType: "semver"},
+				},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "zero length range is skipped",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1.0.0", LessThan: "1.0.0", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "last_affected dropped when fixed present",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "1.0.0", LessThan: "1.0.5", Status: "affected", VersionType: "semver"},
+					{Version: "2.0.0", LessThanOrEqual: "2.0.3", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "1.0.0"}, {Fixed: "1.0.5"}, {Introduced: "2.0.0"}}},
+			},
+			wantNoteCount: 1,
+		},
+		{
+			description: "duplicate entries are collapsed",
+			cna: cves.CVE5Affected{
+				Versions: []cves.CVE5Version{
+					{Version: "0", LessThan: "9.1.0", Status: "affected", VersionType: "semver"},
+					{Version: "0", LessThan: "9.1.0", Status: "affected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "9.1.0"}}},
+			},
+		},
+		{
+			description: "unaffected single version when unaffected by default",
+			cna: cves.CVE5Affected{
+				DefaultStatus: "unaffected",
+				Versions: []cves.CVE5Version{
+					{Version: "0", LessThan: "3.3.3", Status: "affected", VersionType: "semver"},
+					{Version: "3.3.3", Status: "unaffected", VersionType: "semver"},
+				},
+			},
+			wantRanges: []AffectedRange{
+				{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "3.3.3"}}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			affected := Affected{}
+			notes := affected.AttachCVE5Versions(tc.cna)
+			if diff := gocmp.Diff(tc.wantRanges, affected.Ranges); diff != "" {
+				t.Errorf("AttachCVE5Versions() ranges mismatch (-want +got):\n%s", diff)
+			}
+			if diff := gocmp.Diff(tc.wantVersions, affected.Versions); diff != "" {
+				t.Errorf("AttachCVE5Versions() versions mismatch (-want +got):\n%s", diff)
+			}
+			if len(notes) != tc.wantNoteCount {
+				t.Errorf("AttachCVE5Versions() returned %d notes, want %d: %v", len(notes), tc.wantNoteCount, notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulns
+
+import (
+	"regexp"
+	"strings"
+)
+
+// descriptionBoilerplate matches NVD analyst notes and vendor boilerplate
+// that don't describe the vulnerability, and are stripped from details.
+var descriptionBoilerplate = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\*\* unsupported when assigned \*\*`),
+	regexp.MustCompile(`(?i)NOTE: This vulnerability only affects products that are no longer supported by the maintainer\.`),
+	regexp.MustCompile(`(?i)The identifier \S+ was assigned to this vulnerability\.`),
+	regexp.MustCompile(`(?im)(?:NVD )?Analyst Note:.*$`),
+}
+
+// descriptionURL matches URLs inline in a description.
+var descriptionURL = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// descriptionSpaces matches runs of horizontal whitespace.
+var descriptionSpaces = regexp.MustCompile(`[ \t\f\v]+`)
+
+// descriptionBlankLines matches more than one blank line.
+var descriptionBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// SanitizeDescription prepares a CVE description for use as OSV details: it
+// strips analyst notes and boilerplate, normalizes whitespace, and turns
+// inline URLs into Markdown autolinks. The URLs are also returned, to be
+// added as references.
+func SanitizeDescription(description string) (details string, urls []string) {
+	details = strings.ReplaceAll(description, "\r\n", "\n")
+	for _, re := range descriptionBoilerplate {
+		details = re.ReplaceAllString(details, "")
+	}
+	details = descriptionURL.ReplaceAllStringFunc(details, func(u string) string {
+		// Trailing punctuation more likely ends the sentence than the URL.
+		trimmed := strings.TrimRight(u, ".,;:!?")
+		urls = append(urls, trimmed)
+		return "<" + trimmed + ">" + u[len(trimmed):]
+	})
+	lines := strings.Split(details, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(descriptionSpaces.ReplaceAllString(line, " "))
+	}
+	details = descriptionBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(details), unique(urls)
+}
+
+// addDescriptionReferences adds the URLs found in a description as
+// references, unless they're already referenced.
+func (v *Vulnerability) addDescriptionReferences(urls []string) {
+	referenced := make(map[string]bool)
+	for _, ref := range v.References {
+		referenced[ref.URL] = true
+	}
+	for _, u := range urls {
+		if referenced[u] {
+			continue
+		}
+		referenced[u] = true
+		v.References = append(v.References, Reference{Type: ClassifyReferenceLink(u, ""), URL: u})
+	}
+}
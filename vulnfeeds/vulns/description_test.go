@@ -0,0 +1,67 @@
+package vulns
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+)
+
+func TestSanitizeDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		wantDetails string
+		wantURLs    []string
+	}{
+		{
+			name:        "whitespace",
+			description: "  A flaw   in\tlibfoo.  \r\n\r\n\r\n\r\nDetails  follow. ",
+			wantDetails: "A flaw in libfoo.\n\nDetails follow.",
+		},
+		{
+			name:        "inline URLs",
+			description: "A flaw in libfoo. See https://example.com/advisory. Fixed by https://github.com/foo/libfoo/commit/abc123, also https://example.com/advisory",
+			wantDetails: "A flaw in libfoo. See <https://example.com/advisory>. Fixed by <https://github.com/foo/libfoo/commit/abc123>, also <https://example.com/advisory>",
+			wantURLs:    []string{"https://example.com/advisory", "https://github.com/foo/libfoo/commit/abc123"},
+		},
+		{
+			name:        "boilerplate",
+			description: "** UNSUPPORTED WHEN ASSIGNED ** A flaw was found in Foo CMS 1.0. It has been classified as critical. The identifier VDB-123456 was assigned to this vulnerability. NOTE: This vulnerability only affects products that are no longer supported by the maintainer.",
+			wantDetails: "A flaw was found in Foo CMS 1.0. It has been classified as critical.",
+		},
+		{
+			name:        "analyst note",
+			description: "A flaw in libfoo.\nNVD Analyst Note: this CVE is being reanalyzed.",
+			wantDetails: "A flaw in libfoo.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			details, urls := SanitizeDescription(tc.description)
+			if diff := gocmp.Diff(tc.wantDetails, details); diff != "" {
+				t.Errorf("SanitizeDescription() details mismatch (-want +got):\n%s", diff)
+			}
+			if diff := gocmp.Diff(tc.wantURLs, urls); diff != "" {
+				t.Errorf("SanitizeDescription() URLs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFromCVEDescriptionReferences(t *testing.T) {
+	cve := cves.CVE{
+		ID:           "CVE-2024-0001",
+		Descriptions: []cves.LangString{{Lang: "en", Value: "A flaw in libfoo, see https://example.com/advisory and https://github.com/foo/libfoo/commit/abc123."}},
+		References:   []cves.Reference{{Url: "https://github.com/foo/libfoo/commit/abc123", Tags: []string{"Patch"}}},
+	}
+	v, _ := FromCVE(cve.ID, cve)
+	want := []Reference{
+		{Type: "ADVISORY", URL: "https://example.com/advisory"},
+		{Type: "FIX", URL: "https://github.com/foo/libfoo/commit/abc123"},
+	}
+	if diff := gocmp.Diff(want, v.References); diff != "" {
+		t.Errorf("FromCVE() references mismatch (-want +got):\n%s", diff)
+	}
+}
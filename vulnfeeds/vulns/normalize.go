@@ -0,0 +1,296 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulns
+
+import (
+	"cmp"
+	"reflect"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/google/osv/vulnfeeds/vercmp"
+)
+
+// Normalize puts a record into a canonical form, so converting the same data
+// gives the same record whatever order it was gathered in, and diffs between
+// runs only show real changes. In each affected entry, it:
+//
+//   - rewrites the SEMVER ranges, and the ECOSYSTEM ranges of ecosystems with
+//     a Comparator, with their events sorted by version, merging the ranges
+//     whose intervals overlap or are adjacent and dropping the intervals
+//     introduced and fixed at the same version, which match nothing;
+//   - sorts and deduplicates the events of GIT ranges (which aren't merged,
+//     as each is evaluated on its own);
+//   - drops the ranges left without events and sorts the rest;
+//   - deduplicates the versions, sorting them if the ecosystem has a
+//     Comparator.
+//
+// It also sorts and deduplicates the aliases, related and upstream IDs. The
+// keys of maps are already sorted by encoding/json.
+func (v *Vulnerability) Normalize() {
+	for i := range v.Affected {
+		v.Affected[i].normalize()
+	}
+	v.Aliases = sortedUnique(v.Aliases)
+	v.Related = sortedUnique(v.Related)
+	v.Upstream = sortedUnique(v.Upstream)
+}
+
+func sortedUnique(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return slices.Compact(s)
+}
+
+func (a *Affected) normalize() {
+	var c Comparator
+	if a.Package != nil {
+		c, _ = ComparatorFor(a.Package.Ecosystem)
+	}
+
+	var ranges []AffectedRange
+	var groups []*intervalGroup
+	for _, r := range a.Ranges {
+		rc := c
+		switch r.Type {
+		case "SEMVER":
+			rc = vercmp.SemVer
+		case "GIT":
+			rc = nil
+		}
+		intervals, ok := rangeIntervals(r, rc)
+		if !ok {
+			r.Events = uniqueEvents(r.Events, r.Type == "GIT")
+			if len(r.Events) > 0 {
+				ranges = append(ranges, r)
+			}
+			continue
+		}
+		lastAffected := slices.ContainsFunc(intervals, func(i interval) bool { return i.lastAffected })
+		idx := slices.IndexFunc(groups, func(g *intervalGroup) bool {
+			return g.rangeType == r.Type && g.repo == r.Repo && g.lastAffected == lastAffected && reflect.DeepEqual(g.databaseSpecific, r.DatabaseSpecific)
+		})
+		if idx < 0 {
+			groups = append(groups, &intervalGroup{rangeType: r.Type, repo: r.Repo, lastAffected: lastAffected, databaseSpecific: r.DatabaseSpecific, compare: rc})
+			idx = len(groups) - 1
+		}
+		groups[idx].intervals = append(groups[idx].intervals, intervals...)
+	}
+	for _, g := range groups {
+		if r, ok := g.mergedRange(); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	slices.SortStableFunc(ranges, func(x, y AffectedRange) int {
+		return cmp.Or(cmp.Compare(x.Type, y.Type), cmp.Compare(x.Repo, y.Repo))
+	})
+	if ranges == nil && a.Ranges != nil {
+		ranges = []AffectedRange{}
+	}
+	a.Ranges = ranges
+
+	if a.Versions != nil {
+		a.Versions = uniqueVersions(a.Versions, c)
+	}
+}
+
+// interval is the versions from introduced (inclusive) to end, exclusive if
+// fixed and inclusive if lastAffected, or on with no end.
+type interval struct {
+	introduced   string
+	end          string
+	lastAffected bool
+}
+
+// intervalGroup is the intervals of the ranges of an affected entry that are
+// merged into one.
+type intervalGroup struct {
+	rangeType        string
+	repo             string
+	lastAffected     bool
+	databaseSpecific map[string]any
+	compare          Comparator
+	intervals        []interval
+}
+
+// compareVersions compares versions with c, ordering "0" before all others.
+func compareVersions(c Comparator, a, b string) (int, error) {
+	switch {
+	case a == b:
+		return 0, nil
+	case a == "0":
+		return -1, nil
+	case b == "0":
+		return 1, nil
+	}
+	return c(a, b)
+}
+
+// rangeIntervals returns the intervals of a range, pairing each introduced
+// event with the end that follows it. It returns false for ranges that can't
+// be rewritten safely: those without a Comparator, with limit events, ends
+// without an introduced version, versions c can't compare or intervals that
+// end before they're introduced.
+func rangeIntervals(r AffectedRange, c Comparator) ([]interval, bool) {
+	if c == nil || len(r.Events) == 0 {
+		return nil, false
+	}
+	var intervals []interval
+	var pending []string
+	for _, e := range r.Events {
+		switch {
+		case e.Introduced != "" && e.Fixed == "" && e.LastAffected == "" && e.Limit == "":
+			pending = append(pending, e.Introduced)
+		case (e.Fixed != "") != (e.LastAffected != "") && e.Introduced == "" && e.Limit == "" && len(pending) > 0:
+			for _, introduced := range pending {
+				i := interval{introduced: introduced, end: cmp.Or(e.Fixed, e.LastAffected), lastAffected: e.LastAffected != ""}
+				n, err := compareVersions(c, i.introduced, i.end)
+				if err != nil || n > 0 {
+					return nil, false
+				}
+				if n == 0 && !i.lastAffected {
+					// Introduced and fixed at once, which matches nothing.
+					continue
+				}
+				intervals = append(intervals, i)
+			}
+			pending = nil
+		default:
+			return nil, false
+		}
+	}
+	for _, introduced := range pending {
+		if introduced != "0" {
+			if _, err := c(introduced, introduced); err != nil {
+				return nil, false
+			}
+		}
+		intervals = append(intervals, interval{introduced: introduced})
+	}
+	return intervals, true
+}
+
+// mergedRange returns the range of the union of the group's intervals, with
+// its events sorted by version, and false if it has none.
+func (g *intervalGroup) mergedRange() (AffectedRange, bool) {
+	intervals := slices.Clone(g.intervals)
+	// All the versions compared fine when the intervals were made.
+	compare := func(a, b string) int {
+		n, _ := compareVersions(g.compare, a, b)
+		return n
+	}
+	slices.SortStableFunc(intervals, func(x, y interval) int { return compare(x.introduced, y.introduced) })
+
+	var merged []interval
+	for _, i := range intervals {
+		if len(merged) == 0 {
+			merged = append(merged, i)
+			continue
+		}
+		last := &merged[len(merged)-1]
+		if last.end != "" {
+			// Adjacent to a fixed end, or overlapping an end.
+			n := compare(i.introduced, last.end)
+			if n > 0 || (n == 0 && last.lastAffected) {
+				merged = append(merged, i)
+				continue
+			}
+		}
+		switch {
+		case last.end == "":
+		case i.end == "":
+			last.end, last.lastAffected = "", false
+		default:
+			if n := compare(i.end, last.end); n > 0 || (n == 0 && i.lastAffected) {
+				last.end, last.lastAffected = i.end, i.lastAffected
+			}
+		}
+	}
+	if len(merged) == 0 {
+		return AffectedRange{}, false
+	}
+
+	r := AffectedRange{Type: g.rangeType, Repo: g.repo, DatabaseSpecific: g.databaseSpecific}
+	for _, i := range merged {
+		r.Events = append(r.Events, Event{Introduced: i.introduced})
+		switch {
+		case i.end == "":
+		case i.lastAffected:
+			r.Events = append(r.Events, Event{LastAffected: i.end})
+		default:
+			r.Events = append(r.Events, Event{Fixed: i.end})
+		}
+	}
+	return r, true
+}
+
+// eventKind orders the kinds of events of GIT ranges.
+func eventKind(e Event) int {
+	switch {
+	case e.Introduced != "":
+		return 0
+	case e.Fixed != "":
+		return 1
+	case e.LastAffected != "":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// uniqueEvents removes the repeated events of a range, sorting them by kind
+// and commit if sorted is set, and keeping their order otherwise.
+func uniqueEvents(events []Event, sorted bool) []Event {
+	var unique []Event
+	for _, e := range events {
+		if e != (Event{}) && !slices.Contains(unique, e) {
+			unique = append(unique, e)
+		}
+	}
+	if sorted {
+		slices.SortFunc(unique, func(x, y Event) int {
+			return cmp.Or(cmp.Compare(eventKind(x), eventKind(y)),
+				cmp.Compare(x.Introduced+x.Fixed+x.LastAffected+x.Limit, y.Introduced+y.Fixed+y.LastAffected+y.Limit))
+		})
+	}
+	return unique
+}
+
+// uniqueVersions removes the repeated versions, sorting them with c if it's
+// non-nil and can compare them all, and keeping their order otherwise.
+func uniqueVersions(versions []string, c Comparator) []string {
+	var unique []string
+	for _, version := range versions {
+		if !slices.Contains(unique, version) {
+			unique = append(unique, version)
+		}
+	}
+	if c == nil {
+		return unique
+	}
+	for _, version := range unique {
+		if _, err := c(version, version); err != nil {
+			return unique
+		}
+	}
+	slices.SortStableFunc(unique, func(a, b string) int {
+		n, _ := c(a, b)
+		return n
+	})
+	return unique
+}
@@ -0,0 +1,125 @@
+package vulns_test
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/internal/vulnstest"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *vulnstest.AffectedBuilder
+		want *vulnstest.AffectedBuilder
+	}{
+		{
+			name: "sorts and merges overlapping and adjacent ranges",
+			in: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("2.0.0"), vulnstest.Fixed("2.1.0")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.0.0"), vulnstest.Introduced("1.5.0"), vulnstest.Fixed("1.8.0")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0.0"), vulnstest.Fixed("1.2.0")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.7.0"), vulnstest.Fixed("1.9.0")),
+			),
+			want: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.2.0"), vulnstest.Introduced("1.5.0"), vulnstest.Fixed("1.9.0"), vulnstest.Introduced("2.0.0"), vulnstest.Fixed("2.1.0")),
+			),
+		},
+		{
+			name: "open interval absorbs later ones",
+			in: vulnstest.NewAffected().Package("Debian:12", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0-1"), vulnstest.Fixed("1.2-1")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.1-1")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("2.0-1"), vulnstest.Fixed("2.1-1")),
+			),
+			want: vulnstest.NewAffected().Package("Debian:12", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0-1")),
+			),
+		},
+		{
+			name: "drops zero-length intervals and empty ranges",
+			in: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0.0"), vulnstest.Fixed("1.0.0")),
+				vulnstest.SemverRange(vulnstest.Introduced("1.0.0"), vulnstest.Fixed("1.0.0"), vulnstest.Introduced("2.0.0"), vulnstest.LastAffected("2.0.0")),
+				vulns.AffectedRange{Type: "ECOSYSTEM"},
+			),
+			want: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.SemverRange(vulnstest.Introduced("2.0.0"), vulnstest.LastAffected("2.0.0")),
+			),
+		},
+		{
+			name: "keeps fixed and last affected ranges apart",
+			in: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0.0"), vulnstest.LastAffected("1.5.0")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.2.0"), vulnstest.Fixed("2.0.0")),
+			),
+			want: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0.0"), vulnstest.LastAffected("1.5.0")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.2.0"), vulnstest.Fixed("2.0.0")),
+			),
+		},
+		{
+			name: "sorts GIT events without merging ranges",
+			in: vulnstest.NewAffected().Range(
+				vulnstest.GitRange("https://github.com/b/b", vulnstest.Fixed("bbb"), vulnstest.Introduced("0"), vulnstest.Fixed("aaa"), vulnstest.Fixed("aaa")),
+				vulnstest.GitRange("https://github.com/a/a", vulnstest.Introduced("ccc"), vulnstest.Fixed("ddd")),
+				vulnstest.GitRange("https://github.com/a/a", vulnstest.Introduced("0"), vulnstest.Fixed("eee")),
+			),
+			want: vulnstest.NewAffected().Range(
+				vulnstest.GitRange("https://github.com/a/a", vulnstest.Introduced("ccc"), vulnstest.Fixed("ddd")),
+				vulnstest.GitRange("https://github.com/a/a", vulnstest.Introduced("0"), vulnstest.Fixed("eee")),
+				vulnstest.GitRange("https://github.com/b/b", vulnstest.Introduced("0"), vulnstest.Fixed("aaa"), vulnstest.Fixed("bbb")),
+			),
+		},
+		{
+			name: "leaves ranges it can't compare",
+			in: vulnstest.NewAffected().Package("PyPI", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("2.0"), vulnstest.Fixed("2.1"), vulnstest.Fixed("2.1")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0"), vulnstest.Fixed("1.1")),
+			).Versions("2.0", "1.0", "2.0"),
+			want: vulnstest.NewAffected().Package("PyPI", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("2.0"), vulnstest.Fixed("2.1")),
+				vulnstest.EcosystemRange(vulnstest.Introduced("1.0"), vulnstest.Fixed("1.1")),
+			).Versions("2.0", "1.0"),
+		},
+		{
+			name: "leaves unparseable and limited ranges",
+			in: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("latest"), vulnstest.Fixed("1.0.0")),
+				vulnstest.SemverRange(vulnstest.Introduced("1.0.0"), vulnstest.Limit("2.0.0")),
+				vulnstest.SemverRange(vulnstest.Introduced("3.0.0"), vulnstest.Fixed("2.0.0")),
+			),
+			want: vulnstest.NewAffected().Package("npm", "foo").Range(
+				vulnstest.EcosystemRange(vulnstest.Introduced("latest"), vulnstest.Fixed("1.0.0")),
+				vulnstest.SemverRange(vulnstest.Introduced("1.0.0"), vulnstest.Limit("2.0.0")),
+				vulnstest.SemverRange(vulnstest.Introduced("3.0.0"), vulnstest.Fixed("2.0.0")),
+			),
+		},
+		{
+			name: "sorts versions by the ecosystem",
+			in:   vulnstest.NewAffected().Package("npm", "foo").Versions("1.10.0", "1.9.0", "1.10.0", "1.0.0-rc.1"),
+			want: vulnstest.NewAffected().Package("npm", "foo").Versions("1.0.0-rc.1", "1.9.0", "1.10.0"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := vulnstest.NewVulnerability("CVE-2024-0001").Affected(tt.in).Build()
+			got.Normalize()
+			want := vulnstest.NewVulnerability("CVE-2024-0001").Affected(tt.want).Build()
+			if diff := gocmp.Diff(want, got); diff != "" {
+				t.Errorf("Normalize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeIDs(t *testing.T) {
+	got := vulnstest.NewVulnerability("CVE-2024-0001").Alias("GHSA-bbbb-bbbb-bbbb", "GHSA-aaaa-aaaa-aaaa", "GHSA-bbbb-bbbb-bbbb").Related("USN-2", "USN-1").Build()
+	got.Normalize()
+	want := vulnstest.NewVulnerability("CVE-2024-0001").Alias("GHSA-aaaa-aaaa-aaaa", "GHSA-bbbb-bbbb-bbbb").Related("USN-1", "USN-2").Build()
+	if diff := gocmp.Diff(want, got); diff != "" {
+		t.Errorf("Normalize() mismatch (-want +got):\n%s", diff)
+	}
+}
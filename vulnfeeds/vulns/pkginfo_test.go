@@ -0,0 +1,76 @@
+package vulns_test
+
+import (
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+
+	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/internal/vulnstest"
+	"github.com/google/osv/vulnfeeds/vulns"
+)
+
+func TestAddPkgInfoTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkgInfo vulns.PackageInfo
+		want    *vulns.Vulnerability
+	}{
+		{
+			name: "fixed version",
+			pkgInfo: vulns.PackageInfo{
+				PkgName:     "nginx",
+				Ecosystem:   "Debian",
+				PURL:        "pkg:deb/debian/nginx",
+				VersionInfo: cves.VersionInfo{AffectedVersions: []cves.AffectedVersion{{Fixed: "1.2.3-4"}}},
+			},
+			want: vulnstest.NewVulnerability("CVE-2024-1234").
+				Affected(vulnstest.NewAffected().
+					Package("Debian", "nginx").
+					PURL("pkg:deb/debian/nginx").
+					Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"), vulnstest.Fixed("1.2.3-4")))).
+				Build(),
+		},
+		{
+			name: "commits in two repos",
+			pkgInfo: vulns.PackageInfo{
+				VersionInfo: cves.VersionInfo{AffectedCommits: []cves.AffectedCommit{
+					{Repo: "https://github.com/foo/bar", Introduced: "aaaa", Fixed: "bbbb"},
+					{Repo: "https://github.com/baz/quux", Fixed: "cccc"},
+				}},
+			},
+			want: vulnstest.NewVulnerability("CVE-2024-1234").
+				Affected(vulnstest.NewAffected().
+					Range(
+						vulnstest.GitRange("https://github.com/baz/quux", vulnstest.Introduced("0"), vulnstest.Fixed("cccc")),
+						vulnstest.GitRange("https://github.com/foo/bar", vulnstest.Introduced("aaaa"), vulnstest.Fixed("bbbb")),
+					)).
+				Build(),
+		},
+		{
+			name: "unresolved",
+			pkgInfo: vulns.PackageInfo{
+				PkgName:    "curl",
+				Ecosystem:  "Alpine:v3.18",
+				Unresolved: true,
+			},
+			want: vulnstest.NewVulnerability("CVE-2024-1234").
+				Affected(vulnstest.NewAffected().
+					Package("Alpine:v3.18", "curl").
+					Range(vulnstest.EcosystemRange(vulnstest.Introduced("0"))).
+					DatabaseSpecific("unresolved", true)).
+				Build(),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := vulnstest.NewVulnerability("CVE-2024-1234").Build()
+			if err := got.AddPkgInfo(tc.pkgInfo); err != nil {
+				t.Fatalf("AddPkgInfo() error: %v", err)
+			}
+			if diff := gocmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("AddPkgInfo() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
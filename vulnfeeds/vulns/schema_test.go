@@ -0,0 +1,151 @@
+package vulns
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	"github.com/ossf/osv-schema/bindings/go/osvschema"
+)
+
+// jsonFields returns the struct fields of t (dereferencing pointers and
+// slices) keyed by their JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// elemStruct returns the struct type underlying t, if any.
+func elemStruct(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return nil, false
+	}
+	return t, true
+}
+
+// compareSchemaFields walks the canonical schema type and ours in parallel
+// and reports every JSON field present in only one of them.
+func compareSchemaFields(t *testing.T, path string, schema, ours reflect.Type) {
+	t.Helper()
+	schemaFields := jsonFields(schema)
+	ourFields := jsonFields(ours)
+	for name, sf := range schemaFields {
+		of, ok := ourFields[name]
+		if !ok {
+			t.Errorf("%s.%s is in the OSV schema but not in %s", path, name, ours.Name())
+			continue
+		}
+		schemaElem, schemaIsStruct := elemStruct(sf.Type)
+		ourElem, ourIsStruct := elemStruct(of.Type)
+		if schemaIsStruct != ourIsStruct {
+			t.Errorf("%s.%s: OSV schema type %s, ours %s", path, name, sf.Type, of.Type)
+			continue
+		}
+		if schemaIsStruct {
+			compareSchemaFields(t, path+"."+name, schemaElem, ourElem)
+		}
+	}
+	for name := range ourFields {
+		if _, ok := schemaFields[name]; !ok {
+			t.Errorf("%s.%s is in %s but not in the OSV schema", path, name, ours.Name())
+		}
+	}
+}
+
+func TestSchemaFieldCoverage(t *testing.T) {
+	compareSchemaFields(t, "vulnerability", reflect.TypeOf(osvschema.Vulnerability{}), reflect.TypeOf(Vulnerability{}))
+}
+
+// pruneEmpty removes null, empty string and empty array values, which
+// Vulnerability emits for fields that are required by our converters but
+// optional in the OSV schema.
+func pruneEmpty(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			val = pruneEmpty(val)
+			switch val := val.(type) {
+			case nil:
+				delete(v, k)
+				continue
+			case string:
+				if val == "" {
+					delete(v, k)
+					continue
+				}
+			case []any:
+				if len(val) == 0 {
+					delete(v, k)
+					continue
+				}
+			}
+			v[k] = val
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = pruneEmpty(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func TestSchemaExamplesRoundTrip(t *testing.T) {
+	examples, err := filepath.Glob("../test_data/osv-schema/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("no OSV schema examples found")
+	}
+
+	for _, example := range examples {
+		t.Run(filepath.Base(example), func(t *testing.T) {
+			data, err := os.ReadFile(example)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v Vulnerability
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&v); err != nil {
+				t.Fatalf("Failed to decode %s: %v", example, err)
+			}
+			out, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("Failed to marshal %s: %v", example, err)
+			}
+
+			var want, got any
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := gocmp.Diff(want, pruneEmpty(got)); diff != "" {
+				t.Errorf("Round trip of %s mismatch (-want +got):\n%s", example, diff)
+			}
+		})
+	}
+}
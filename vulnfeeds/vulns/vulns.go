@@ -18,19 +18,28 @@ import (
 	"cmp"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"net/url"
 	"os"
 	"path"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/exp/slices"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/cvss"
+	"github.com/google/osv/vulnfeeds/ids"
+	"github.com/google/osv/vulnfeeds/repourl"
+	"github.com/google/osv/vulnfeeds/utility"
 )
 
 const CVEListBasePath = "cves"
@@ -59,10 +68,23 @@ type Severity struct {
 }
 
 type Affected struct {
-	Package           *AffectedPackage  `json:"package,omitempty"`
-	Ranges            []AffectedRange   `json:"ranges" yaml:"ranges"`
-	Versions          []string          `json:"versions,omitempty" yaml:"versions,omitempty"`
-	EcosystemSpecific map[string]string `json:"ecosystem_specific,omitempty" yaml:"ecosystem_specific,omitempty"`
+	Package           *AffectedPackage `json:"package,omitempty"`
+	Severity          []Severity       `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Ranges            []AffectedRange  `json:"ranges" yaml:"ranges"`
+	Versions          []string         `json:"versions,omitempty" yaml:"versions,omitempty"`
+	EcosystemSpecific map[string]any   `json:"ecosystem_specific,omitempty" yaml:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  map[string]any   `json:"database_specific,omitempty" yaml:"database_specific,omitempty"`
+}
+
+// canonicalRepos returns the commits with their repos canonicalized, so the
+// commits of a repo share a single GIT range whatever URL they were found with.
+func canonicalRepos(commits []cves.AffectedCommit) []cves.AffectedCommit {
+	canonical := make([]cves.AffectedCommit, len(commits))
+	for i, ac := range commits {
+		ac.Repo = repourl.Canonical(ac.Repo)
+		canonical[i] = ac
+	}
+	return canonical
 }
 
 // AttachExtractedVersionInfo converts the cves.VersionInfo struct to OSV GIT and ECOSYSTEM AffectedRanges and AffectedPackage.
@@ -76,7 +98,7 @@ func (affected *Affected) AttachExtractedVersionInfo(version cves.VersionInfo) {
 	repoToCommits := map[string][]commit{}
 
 	unfixed := true
-	for _, ac := range version.AffectedCommits {
+	for _, ac := range canonicalRepos(version.AffectedCommits) {
 		if ac.Introduced != "" {
 			repoToCommits[ac.Repo] = append(repoToCommits[ac.Repo], commit{commitType: cves.Introduced, hash: ac.Introduced})
 		}
@@ -168,6 +190,12 @@ type PackageInfo struct {
 	PURL              string            `json:"purl,omitempty" yaml:"purl,omitempty"`
 	VersionInfo       cves.VersionInfo  `json:"fixed_version,omitempty" yaml:"fixed_version,omitempty"`
 	EcosystemSpecific map[string]string `json:"ecosystem_specific,omitempty" yaml:"ecosystem_specific,omitempty"`
+	// DatabaseSpecific is copied into the affected entry's database_specific,
+	// e.g. the distribution repository the package is in.
+	DatabaseSpecific map[string]any `json:"database_specific,omitempty" yaml:"database_specific,omitempty"`
+	// Unresolved marks a package that is known to be affected but has no fix
+	// yet, e.g. an open entry of a distribution's security tracker.
+	Unresolved bool `json:"unresolved,omitempty" yaml:"unresolved,omitempty"`
 }
 
 func (pi *PackageInfo) ToJSON(w io.Writer) error {
@@ -182,9 +210,10 @@ type AffectedPackage struct {
 }
 
 type AffectedRange struct {
-	Type   string  `json:"type" yaml:"type"`
-	Repo   string  `json:"repo,omitempty" yaml:"repo,omitempty"`
-	Events []Event `json:"events" yaml:"events"`
+	Type             string         `json:"type" yaml:"type"`
+	Repo             string         `json:"repo,omitempty" yaml:"repo,omitempty"`
+	Events           []Event        `json:"events" yaml:"events"`
+	DatabaseSpecific map[string]any `json:"database_specific,omitempty" yaml:"database_specific,omitempty"`
 }
 
 type Reference struct {
@@ -194,26 +223,97 @@ type Reference struct {
 
 type References []Reference
 
+type Credit struct {
+	Name    string   `json:"name" yaml:"name"`
+	Contact []string `json:"contact,omitempty" yaml:"contact,omitempty"`
+	Type    string   `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
 func (r References) Len() int           { return len(r) }
 func (r References) Less(i, j int) bool { return r[i].Type < r[j].Type }
 func (r References) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
 
 type Vulnerability struct {
-	ID         string      `json:"id" yaml:"id"`
-	Withdrawn  string      `json:"withdrawn,omitempty" yaml:"withdrawn,omitempty"`
-	Summary    string      `json:"summary,omitempty" yaml:"summary,omitempty"`
-	Severity   []Severity  `json:"severity,omitempty" yaml:"severity,omitempty"`
-	Details    string      `json:"details" yaml:"details"`
-	Affected   []Affected  `json:"affected" yaml:"affected"`
-	References []Reference `json:"references" yaml:"references"`
-	Aliases    []string    `json:"aliases,omitempty" yaml:"aliases,omitempty"`
-	Related    []string    `json:"related,omitempty" yaml:"related,omitempty"`
-	Modified   string      `json:"modified" yaml:"modified"`
-	Published  string      `json:"published" yaml:"published"`
+	SchemaVersion string      `json:"schema_version,omitempty" yaml:"schema_version,omitempty"`
+	ID            string      `json:"id" yaml:"id"`
+	Withdrawn     string      `json:"withdrawn,omitempty" yaml:"withdrawn,omitempty"`
+	Summary       string      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Severity      []Severity  `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Details       string      `json:"details" yaml:"details"`
+	Affected      []Affected  `json:"affected" yaml:"affected"`
+	References    []Reference `json:"references" yaml:"references"`
+	Aliases       []string    `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Related       []string    `json:"related,omitempty" yaml:"related,omitempty"`
+	Upstream      []string    `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	Credits       []Credit    `json:"credits,omitempty" yaml:"credits,omitempty"`
+	Modified      string      `json:"modified" yaml:"modified"`
+	Published     string      `json:"published" yaml:"published"`
+
+	DatabaseSpecific map[string]any `json:"database_specific,omitempty" yaml:"database_specific,omitempty"`
+}
+
+// ProvenanceSource identifies an upstream record a generated record was derived from.
+type ProvenanceSource struct {
+	Feed     string `json:"feed" yaml:"feed"`
+	RecordID string `json:"record_id" yaml:"record_id"`
+}
+
+// Provenance describes how and when a record was generated.
+// It is stored under database_specific.provenance.
+type Provenance struct {
+	Sources           []ProvenanceSource `json:"sources" yaml:"sources"`
+	ConverterVersion  string             `json:"converter_version,omitempty" yaml:"converter_version,omitempty"`
+	ConverterRevision string             `json:"converter_revision,omitempty" yaml:"converter_revision,omitempty"`
+	ConvertedAt       string             `json:"converted_at" yaml:"converted_at"`
+}
+
+// SchemaVersion is the version of the OSV schema generated records conform to.
+const SchemaVersion = "1.7.0"
+
+// Generator identifies the pipeline run that generated a record, so a bad
+// record can be traced back to the build that produced it.
+// It is stored under database_specific.generator.
+type Generator struct {
+	Tool     string `json:"tool" yaml:"tool"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	RunID    string `json:"run_id,omitempty" yaml:"run_id,omitempty"`
+}
+
+// NewGenerator returns the generator of the records tool generates in a run
+// with the given build.
+func NewGenerator(tool string, runID string, build utility.BuildInfo) Generator {
+	return Generator{
+		Tool:     tool,
+		Version:  build.Version,
+		Revision: build.Revision,
+		RunID:    runID,
+	}
+}
+
+// SetDatabaseSpecific sets a key in the record's database_specific field.
+func (v *Vulnerability) SetDatabaseSpecific(key string, value any) {
+	if v.DatabaseSpecific == nil {
+		v.DatabaseSpecific = make(map[string]any)
+	}
+	v.DatabaseSpecific[key] = value
+}
+
+// SetProvenance stamps the record with provenance metadata.
+func (v *Vulnerability) SetProvenance(p Provenance) {
+	v.SetDatabaseSpecific("provenance", p)
+}
+
+// SetGenerator stamps the record with the OSV schema version and the
+// generator that produced it.
+func (v *Vulnerability) SetGenerator(g Generator) {
+	v.SchemaVersion = SchemaVersion
+	v.SetDatabaseSpecific("generator", g)
 }
 
 // AddPkgInfo converts a PackageInfo struct to the corresponding AffectedRanges and adds them to the OSV vulnerability object.
-func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) {
+// AffectedVersions that fail CheckAffectedVersion for the package's ecosystem are left out, and reported in the returned error.
+func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) error {
 	affected := Affected{}
 
 	if pkgInfo.PkgName != "" && pkgInfo.Ecosystem != "" {
@@ -230,7 +330,8 @@ func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) {
 
 		hasAddedZeroIntroduced := make(map[string]bool)
 
-		for _, ac := range pkgInfo.VersionInfo.AffectedCommits {
+		commits := cves.VersionInfo{AffectedCommits: canonicalRepos(pkgInfo.VersionInfo.AffectedCommits)}
+		for _, ac := range commits.AffectedCommits {
 			entry, ok := gitCommitRangesByRepo[ac.Repo]
 			// Create the stub for the repo if necessary.
 			if !ok {
@@ -240,7 +341,7 @@ func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) {
 					Repo:   ac.Repo,
 				}
 
-				if !pkgInfo.VersionInfo.HasIntroducedCommits(ac.Repo) && !hasAddedZeroIntroduced[ac.Repo] {
+				if !commits.HasIntroducedCommits(ac.Repo) && !hasAddedZeroIntroduced[ac.Repo] {
 					// There was no explicitly defined introduced commit, so create one at 0.
 					entry.Events = append(entry.Events,
 						Event{
@@ -271,13 +372,26 @@ func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) {
 		}
 	}
 
-	if len(pkgInfo.VersionInfo.AffectedVersions) > 0 {
+	var affectedVersions []cves.AffectedVersion
+	var rangeErrs []error
+	for _, av := range pkgInfo.VersionInfo.AffectedVersions {
+		if err := CheckAffectedVersion(pkgInfo.Ecosystem, av); err != nil {
+			rangeErrs = append(rangeErrs, fmt.Errorf("%s: dropped %s range of %s: %w", v.ID, pkgInfo.Ecosystem, pkgInfo.PkgName, err))
+			continue
+		}
+		affectedVersions = append(affectedVersions, av)
+	}
+	if pkgInfo.Unresolved && len(affectedVersions) == 0 && len(pkgInfo.VersionInfo.AffectedCommits) == 0 {
+		// Every version is affected until a fix is released.
+		affectedVersions = []cves.AffectedVersion{{Introduced: "0"}}
+	}
+	if len(affectedVersions) > 0 {
 		versionRange := AffectedRange{
 			Type:   "ECOSYSTEM",
 			Events: []Event{},
 		}
 		hasIntroduced := false
-		for _, av := range pkgInfo.VersionInfo.AffectedVersions {
+		for _, av := range affectedVersions {
 			if av.Introduced != "" {
 				hasIntroduced = true
 				versionRange.Events = append(versionRange.Events, Event{
@@ -316,12 +430,59 @@ func (v *Vulnerability) AddPkgInfo(pkgInfo PackageInfo) {
 		return cmp.Compare(a.Repo, b.Repo)
 	})
 
-	affected.EcosystemSpecific = pkgInfo.EcosystemSpecific
+	if pkgInfo.EcosystemSpecific != nil {
+		affected.EcosystemSpecific = make(map[string]any, len(pkgInfo.EcosystemSpecific))
+		for k, val := range pkgInfo.EcosystemSpecific {
+			affected.EcosystemSpecific[k] = val
+		}
+	}
+	if pkgInfo.DatabaseSpecific != nil {
+		affected.DatabaseSpecific = maps.Clone(pkgInfo.DatabaseSpecific)
+	}
+	if pkgInfo.Unresolved {
+		if affected.DatabaseSpecific == nil {
+			affected.DatabaseSpecific = make(map[string]any)
+		}
+		affected.DatabaseSpecific["unresolved"] = true
+	}
 	v.Affected = append(v.Affected, affected)
+	return errors.Join(rangeErrs...)
+}
+
+// DeduplicateAffected removes affected entries that are identical to an
+// earlier entry (e.g. the same package and ranges contributed by multiple
+// parts), and collapses identical ranges within each remaining entry.
+// It returns the number of affected entries and ranges removed.
+func (v *Vulnerability) DeduplicateAffected() int {
+	removed := 0
+	var affected []Affected
+	for _, a := range v.Affected {
+		ranges := make([]AffectedRange, 0, len(a.Ranges))
+		for _, r := range a.Ranges {
+			if slices.ContainsFunc(ranges, func(seen AffectedRange) bool { return reflect.DeepEqual(seen, r) }) {
+				removed++
+				continue
+			}
+			ranges = append(ranges, r)
+		}
+		if len(ranges) != len(a.Ranges) {
+			a.Ranges = ranges
+		}
+
+		if slices.ContainsFunc(affected, func(seen Affected) bool { return reflect.DeepEqual(seen, a) }) {
+			removed++
+			continue
+		}
+		affected = append(affected, a)
+	}
+	v.Affected = affected
+	return removed
 }
 
 // AddSeverity adds CVSS3 severity information to the OSV vulnerability object.
 // It uses the highest available CVSS 3.x Primary score from the underlying CVE record.
+// The numeric base score and rating of the vector are set in the "cvss" key of
+// database_specific.
 func (v *Vulnerability) AddSeverity(CVEImpact *cves.CVEItemMetrics) {
 	if CVEImpact == nil {
 		return
@@ -365,6 +526,10 @@ func (v *Vulnerability) AddSeverity(CVEImpact *cves.CVEItemMetrics) {
 	}
 
 	v.Severity = append(v.Severity, severity)
+
+	if score, err := cvss.Calculate(bestVectorString); err == nil {
+		v.SetDatabaseSpecific("cvss", score)
+	}
 }
 
 func (v *Vulnerability) ToJSON(w io.Writer) error {
@@ -539,7 +704,7 @@ func extractReferencedVulns(id cves.CVEID, cve cves.CVE) ([]string, []string) {
 					if pathParts[len(pathParts)-2] == "advisories" {
 						a := pathParts[len(pathParts)-1]
 
-						if string(id) != a && strings.HasPrefix(a, "GHSA-") {
+						if string(id) != a && ids.HasPrefix(a, ids.GHSA) {
 							GHSAs = append(GHSAs, a)
 						}
 					}
@@ -549,7 +714,7 @@ func extractReferencedVulns(id cves.CVEID, cve cves.CVE) ([]string, []string) {
 					//Example: https://snyk.io/vuln/SNYK-PYTHON-TRYTOND-1730329
 					if pathParts[1] == "vuln" {
 						a := pathParts[len(pathParts)-1]
-						if string(id) != a && strings.HasPrefix(a, "SNYK-") {
+						if string(id) != a && ids.HasPrefix(a, ids.Snyk) {
 							SYNKs = append(SYNKs, a)
 						}
 					}
@@ -609,13 +774,55 @@ func ClassifyReferences(refs []cves.Reference) (references References) {
 	return references
 }
 
+// maxSummaryLength is the maximum length, in characters, of a summary
+// derived from a description.
+const maxSummaryLength = 120
+
+// Summary derives a summary from a description: its first sentence, with
+// whitespace collapsed, truncated at a word boundary if it's longer than
+// maxSummaryLength.
+func Summary(description string) string {
+	sentence := strings.Join(strings.Fields(firstSentence(description)), " ")
+	sentence = strings.TrimSuffix(sentence, ".")
+	if utf8.RuneCountInString(sentence) <= maxSummaryLength {
+		return sentence
+	}
+	runes := []rune(sentence)
+	truncated := string(runes[:maxSummaryLength])
+	if i := strings.LastIndex(truncated, " "); i > 0 {
+		truncated = truncated[:i]
+	}
+	return strings.TrimRight(truncated, " ,;:") + "..."
+}
+
+// firstSentence returns the description up to the end of its first
+// sentence: a full stop, question or exclamation mark followed by whitespace
+// (so version numbers don't end it), or a blank line.
+func firstSentence(description string) string {
+	description = strings.TrimSpace(description)
+	if i := strings.Index(description, "\n\n"); i >= 0 {
+		description = description[:i]
+	}
+	for i := 0; i < len(description)-1; i++ {
+		switch description[i] {
+		case '.', '!', '?':
+			if unicode.IsSpace(rune(description[i+1])) {
+				return description[:i+1]
+			}
+		}
+	}
+	return description
+}
+
 // FromCVE creates a minimal OSV object from a given CVEItem and id.
 // Leaves affected and version fields empty to be filled in later with AddPkgInfo
 func FromCVE(id cves.CVEID, cve cves.CVE) (*Vulnerability, []string) {
 	aliases, related := extractReferencedVulns(id, cve)
+	details, urls := SanitizeDescription(cves.EnglishDescription(cve))
 	v := Vulnerability{
 		ID:      string(id),
-		Details: cves.EnglishDescription(cve),
+		Summary: Summary(details),
+		Details: details,
 		Aliases: aliases,
 		Related: related,
 	}
@@ -623,7 +830,17 @@ func FromCVE(id cves.CVEID, cve cves.CVE) (*Vulnerability, []string) {
 	v.Published = cve.Published.Format(time.RFC3339)
 	v.Modified = cve.LastModified.Format(time.RFC3339)
 	v.References = ClassifyReferences(cve.References)
+	v.addDescriptionReferences(urls)
+	sort.Stable(References(v.References))
 	v.AddSeverity(cve.Metrics)
+	if cves.IsRejected(cve) {
+		v.Withdrawn = v.Modified
+		notes = append(notes, "CVE is rejected, marking as withdrawn")
+	}
+	if cves.IsDisputed(cve) {
+		v.SetDatabaseSpecific("disputed", true)
+		notes = append(notes, "CVE is disputed")
+	}
 	return &v, notes
 }
 
@@ -658,37 +875,42 @@ func CVEIsDisputed(v *Vulnerability, cveList string) (modified string, e error)
 	// 	Try to make an HTTP request for the CVE record in the CVE List
 	// 	iff .containers.cna.tags contains "disputed"
 	//		return .containers.cna.providerMetadata.dateUpdated, formatted for use in the Withdrawn field.
-	if !strings.HasPrefix(v.ID, "CVE-") {
-		return "", ErrVulnNotACVE
+	CVE, err := LoadCVE5(cves.CVEID(v.ID), cveList)
+	if err != nil {
+		return "", err
 	}
 
-	CVEParts := strings.Split(v.ID, "-")[1:3]
+	if slices.Contains(CVE.Containers.CNA.Tags, "disputed") {
+		modified, err = CVE5timestampToRFC3339(CVE.Containers.CNA.ProviderMetadata.DateUpdated)
+		return modified, err
+	}
+
+	return "", nil
+}
+
+// LoadCVE5 loads the CVE 5.x record of a CVE from a local clone of
+// https://github.com/CVEProject/cvelistV5 found in the location specified by
+// cveList.
+func LoadCVE5(id cves.CVEID, cveList string) (*cves.CVE5, error) {
+	CVEYear, CVENumber, err := ids.CVEYearAndNumber(id)
+	if err != nil {
+		return nil, ErrVulnNotACVE
+	}
 	// Replace the last three digits of the CVE ID with "xxx".
-	CVEYear, CVEIndexShard := CVEParts[0], CVEParts[1][:len(CVEParts[1])-3]+"xxx"
+	CVEIndexShard := CVENumber[:len(CVENumber)-3] + "xxx"
 
 	// cvelistV5/cves/2023/23xxx/CVE-2023-23127.json
-	CVEListFile := path.Join(cveList, CVEListBasePath, CVEYear, CVEIndexShard, v.ID+".json")
+	CVEListFile := path.Join(cveList, CVEListBasePath, CVEYear, CVEIndexShard, string(id)+".json")
 
 	f, err := os.Open(CVEListFile)
-
 	if err != nil {
-		return "", &VulnsCVEListError{"", err}
+		return nil, &VulnsCVEListError{"", err}
 	}
-
 	defer f.Close()
 
-	decoder := json.NewDecoder(f)
-
 	CVE := &cves.CVE5{}
-
-	if err := decoder.Decode(&CVE); err != nil {
-		return "", &VulnsCVEListError{"", err}
+	if err := json.NewDecoder(f).Decode(CVE); err != nil {
+		return nil, &VulnsCVEListError{"", err}
 	}
-
-	if slices.Contains(CVE.Containers.CNA.Tags, "disputed") {
-		modified, err = CVE5timestampToRFC3339(CVE.Containers.CNA.ProviderMetadata.DateUpdated)
-		return modified, err
-	}
-
-	return "", nil
+	return CVE, nil
 }
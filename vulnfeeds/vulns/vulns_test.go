@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"golang.org/x/exp/slices"
@@ -16,6 +17,8 @@ import (
 	"github.com/google/osv/vulnfeeds/utility"
 
 	"github.com/google/osv/vulnfeeds/cves"
+	"github.com/google/osv/vulnfeeds/cvss"
+	"github.com/google/osv/vulnfeeds/golden"
 )
 
 func TestClassifyReferenceLink(t *testing.T) {
@@ -139,6 +142,119 @@ func TestEnglishDescription(t *testing.T) {
 	}
 }
 
+func TestEnglishDescriptionLocales(t *testing.T) {
+	tests := []struct {
+		name         string
+		descriptions []cves.LangString
+		want         string
+	}{
+		{
+			name:         "English after another locale",
+			descriptions: []cves.LangString{{Lang: "es", Value: "Un problema."}, {Lang: "en", Value: "A flaw."}},
+			want:         "A flaw.",
+		},
+		{
+			name:         "English preferred over a regional locale",
+			descriptions: []cves.LangString{{Lang: "en-GB", Value: "A flaw, colour."}, {Lang: "en", Value: "A flaw."}},
+			want:         "A flaw.",
+		},
+		{
+			name:         "regional locale",
+			descriptions: []cves.LangString{{Lang: "ja", Value: "欠陥。"}, {Lang: "EN-us", Value: "A flaw."}},
+			want:         "A flaw.",
+		},
+		{
+			name:         "no English",
+			descriptions: []cves.LangString{{Lang: "es", Value: "Un problema."}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cves.EnglishDescription(cves.CVE{Descriptions: tc.descriptions}); got != tc.want {
+				t.Errorf("EnglishDescription() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name:        "first sentence",
+			description: "A flaw in libfoo 1.2.3 allows code execution. It was fixed in 1.2.4.",
+			want:        "A flaw in libfoo 1.2.3 allows code execution",
+		},
+		{
+			name:        "whitespace",
+			description: "  A flaw in\n  libfoo.\n\nDetails follow.",
+			want:        "A flaw in libfoo",
+		},
+		{
+			name:        "paragraph",
+			description: "A flaw in libfoo\n\nversions before 1.2.4",
+			want:        "A flaw in libfoo",
+		},
+		{
+			name:        "very long first sentence",
+			description: "kirby is a content management system (CMS) that adapts to many different projects and helps you build your own ideal interface. Cross-site scripting.",
+			want:        "kirby is a content management system (CMS) that adapts to many different projects and helps you build your own ideal...",
+		},
+		{
+			name:        "long word",
+			description: strings.Repeat("a", 130),
+			want:        strings.Repeat("a", 120) + "...",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Summary(tc.description)
+			if got != tc.want {
+				t.Errorf("Summary() = %q, want %q", got, tc.want)
+			}
+			if n := len([]rune(got)); n > maxSummaryLength+len("...") {
+				t.Errorf("Summary() is %d characters long", n)
+			}
+		})
+	}
+}
+
+func TestFromCVERejectedOrDisputed(t *testing.T) {
+	tests := []struct {
+		cve           string
+		wantWithdrawn string
+		wantDisputed  bool
+	}{
+		{cve: "CVE-2022-36707", wantWithdrawn: "2022-08-29T18:15:00Z"},
+		{cve: "CVE-2018-20225", wantDisputed: true},
+		{cve: "CVE-2022-36037"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.cve, func(t *testing.T) {
+			cveItem := loadTestData2(tc.cve)
+			if got := cves.IsRejected(cveItem.CVE); got != (tc.wantWithdrawn != "") {
+				t.Errorf("IsRejected() = %v", got)
+			}
+			if got := cves.IsDisputed(cveItem.CVE); got != tc.wantDisputed {
+				t.Errorf("IsDisputed() = %v, want %v", got, tc.wantDisputed)
+			}
+			v, _ := FromCVE(cveItem.CVE.ID, cveItem.CVE)
+			if v.Withdrawn != tc.wantWithdrawn {
+				t.Errorf("FromCVE() withdrawn = %q, want %q", v.Withdrawn, tc.wantWithdrawn)
+			}
+			if disputed, _ := v.DatabaseSpecific["disputed"].(bool); disputed != tc.wantDisputed {
+				t.Errorf("FromCVE() database_specific.disputed = %v, want %v", disputed, tc.wantDisputed)
+			}
+		})
+	}
+}
+
 func TestAddPkgInfo(t *testing.T) {
 	cveItem := loadTestData2("CVE-2022-36037")
 	vuln := Vulnerability{
@@ -334,11 +450,79 @@ func TestAddPkgInfo(t *testing.T) {
 	}
 }
 
+func TestAddPkgInfoUnresolved(t *testing.T) {
+	vuln := Vulnerability{ID: "CVE-2024-1234"}
+	vuln.AddPkgInfo(PackageInfo{
+		PkgName:    "openssl",
+		Ecosystem:  "Alpine:v3.19",
+		PURL:       "pkg:apk/alpine/openssl?arch=source",
+		Unresolved: true,
+	})
+	vuln.AddPkgInfo(PackageInfo{
+		PkgName:   "openssl",
+		Ecosystem: "Alpine:v3.18",
+		VersionInfo: cves.VersionInfo{
+			AffectedVersions: []cves.AffectedVersion{{Introduced: "0"}},
+		},
+		DatabaseSpecific: map[string]any{"repository": "community"},
+		Unresolved:       true,
+	})
+	want := []Affected{
+		{
+			Package: &AffectedPackage{Name: "openssl", Ecosystem: "Alpine:v3.19", Purl: "pkg:apk/alpine/openssl?arch=source"},
+			Ranges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}}},
+			},
+			DatabaseSpecific: map[string]any{"unresolved": true},
+		},
+		{
+			Package: &AffectedPackage{Name: "openssl", Ecosystem: "Alpine:v3.18"},
+			Ranges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}}},
+			},
+			DatabaseSpecific: map[string]any{"repository": "community", "unresolved": true},
+		},
+	}
+	if diff := gocmp.Diff(want, vuln.Affected); diff != "" {
+		t.Errorf("AddPkgInfo() of unresolved packages mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAddPkgInfoInvalidRanges(t *testing.T) {
+	vuln := Vulnerability{ID: "CVE-2024-1234"}
+	err := vuln.AddPkgInfo(PackageInfo{
+		PkgName:   "openssl",
+		Ecosystem: "Alpine:v3.19",
+		VersionInfo: cves.VersionInfo{
+			AffectedVersions: []cves.AffectedVersion{
+				{Introduced: "3.1.0-r0", Fixed: "3.1.4-r5"},
+				{Introduced: "3.1.4-r5", Fixed: "3.1.4-r5"},
+				{Introduced: "3.2.0-r0", Fixed: "3.1.9-r0"},
+			},
+		},
+	})
+	if err == nil {
+		t.Errorf("AddPkgInfo() of invalid ranges: want error")
+	}
+	want := []Affected{
+		{
+			Package: &AffectedPackage{Name: "openssl", Ecosystem: "Alpine:v3.19"},
+			Ranges: []AffectedRange{
+				{Type: "ECOSYSTEM", Events: []Event{{Introduced: "3.1.0-r0"}, {Fixed: "3.1.4-r5"}}},
+			},
+		},
+	}
+	if diff := gocmp.Diff(want, vuln.Affected); diff != "" {
+		t.Errorf("AddPkgInfo() of invalid ranges mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestAddSeverity(t *testing.T) {
 	tests := []struct {
 		description    string
 		inputCVE       cves.Vulnerability
 		expectedResult []Severity
+		expectedScore  any
 	}{
 		{
 			description: "Successful CVE severity extraction and attachment",
@@ -349,6 +533,7 @@ func TestAddSeverity(t *testing.T) {
 					Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
 				},
 			},
+			expectedScore: cvss.Score{Version: "3.1", BaseScore: 9.8, Rating: "CRITICAL"},
 		},
 		{
 			description:    "CVE with no impact information",
@@ -364,6 +549,9 @@ func TestAddSeverity(t *testing.T) {
 		if diff := gocmp.Diff(got, tc.expectedResult); diff != "" {
 			t.Errorf("test %q: Incorrect result: %s", tc.description, diff)
 		}
+		if diff := gocmp.Diff(tc.expectedScore, vuln.DatabaseSpecific["cvss"]); diff != "" {
+			t.Errorf("test %q: Incorrect score: %s", tc.description, diff)
+		}
 	}
 }
 
@@ -430,3 +618,92 @@ func TestNVD2(t *testing.T) {
 	cve := loadTestData2("CVE-2023-4863")
 	t.Logf("Loaded CVE: %#v", cve)
 }
+
+func TestDeduplicateAffected(t *testing.T) {
+	fixedRange := AffectedRange{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1.2.3-r0"}}}
+	otherRange := AffectedRange{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: "1.2.4-r0"}}}
+	pkg := &AffectedPackage{Name: "openssl", Ecosystem: "Alpine:v3.18", Purl: "pkg:apk/alpine/openssl?arch=source"}
+	otherPkg := &AffectedPackage{Name: "openssl", Ecosystem: "Alpine:v3.19", Purl: "pkg:apk/alpine/openssl?arch=source"}
+
+	tests := []struct {
+		description  string
+		inputVuln    Vulnerability
+		wantAffected []Affected
+		wantRemoved  int
+	}{
+		{
+			description: "Identical affected entries from multiple parts",
+			inputVuln: Vulnerability{
+				Affected: []Affected{
+					{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+					{Package: otherPkg, Ranges: []AffectedRange{fixedRange}},
+					{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+				},
+			},
+			wantAffected: []Affected{
+				{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+				{Package: otherPkg, Ranges: []AffectedRange{fixedRange}},
+			},
+			wantRemoved: 1,
+		},
+		{
+			description: "Identical ranges within an affected entry",
+			inputVuln: Vulnerability{
+				Affected: []Affected{
+					{Package: pkg, Ranges: []AffectedRange{fixedRange, otherRange, fixedRange}},
+				},
+			},
+			wantAffected: []Affected{
+				{Package: pkg, Ranges: []AffectedRange{fixedRange, otherRange}},
+			},
+			wantRemoved: 1,
+		},
+		{
+			description: "Entries that only become identical once ranges are collapsed",
+			inputVuln: Vulnerability{
+				Affected: []Affected{
+					{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+					{Package: pkg, Ranges: []AffectedRange{fixedRange, fixedRange}},
+				},
+			},
+			wantAffected: []Affected{
+				{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+			},
+			wantRemoved: 2,
+		},
+		{
+			description: "Nothing to deduplicate",
+			inputVuln: Vulnerability{
+				Affected: []Affected{
+					{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+					{Package: pkg, Ranges: []AffectedRange{otherRange}},
+				},
+			},
+			wantAffected: []Affected{
+				{Package: pkg, Ranges: []AffectedRange{fixedRange}},
+				{Package: pkg, Ranges: []AffectedRange{otherRange}},
+			},
+			wantRemoved: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		removed := tc.inputVuln.DeduplicateAffected()
+		if removed != tc.wantRemoved {
+			t.Errorf("test %q: DeduplicateAffected() removed %d, want %d", tc.description, removed, tc.wantRemoved)
+		}
+		if diff := gocmp.Diff(tc.wantAffected, tc.inputVuln.Affected); diff != "" {
+			t.Errorf("test %q: DeduplicateAffected() mismatch (-want +got):\n%s", tc.description, diff)
+		}
+	}
+}
+
+func TestFromCVEGolden(t *testing.T) {
+	for _, id := range []string{"CVE-2022-36037", "CVE-2023-4863", "CVE-2024-47177"} {
+		t.Run(id, func(t *testing.T) {
+			cveItem := loadTestData2(id)
+			v, _ := FromCVE(cveItem.CVE.ID, cveItem.CVE)
+			golden.JSON(t, fmt.Sprintf("../test_data/golden/vulns/%s.json", id), v)
+		})
+	}
+}